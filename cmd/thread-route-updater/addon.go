@@ -0,0 +1,43 @@
+package main
+
+import (
+	"maps"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/hassio"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/routes"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/state"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/unifi"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/version"
+)
+
+// haEntity returns the state published to Home Assistant: the number of
+// border routers, with the device count, mesh prefixes, version and, when the
+// UniFi sync is enabled, the sync outcome as attributes.
+func haEntity(st *state.State, syncer *unifi.Syncer) hassio.Entity {
+	snap := st.Snapshot()
+	prefixes := make([]string, 0, len(snap.MeshPrefixes))
+	for _, p := range slices.SortedFunc(maps.Keys(snap.MeshPrefixes), routes.ComparePrefixes) {
+		prefixes = append(prefixes, p.String())
+	}
+
+	attrs := map[string]interface{}{
+		"friendly_name":       "Thread border routers",
+		"icon":                "mdi:router-network",
+		"unit_of_measurement": "border routers",
+		"matter_devices":      snap.Devices,
+		"mesh_prefixes":       prefixes,
+		"version":             version.Get().Version,
+	}
+	if syncer != nil {
+		status := syncer.SyncStatus()
+		attrs["sync_phase"] = status.Phase
+		attrs["consecutive_failures"] = status.ConsecutiveFailures
+		if !status.LastSuccess.IsZero() {
+			attrs["last_sync"] = status.LastSuccess.Format(time.RFC3339)
+		}
+	}
+	return hassio.Entity{State: strconv.Itoa(len(snap.BorderRouters)), Attributes: attrs}
+}
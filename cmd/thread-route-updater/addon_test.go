@@ -0,0 +1,26 @@
+package main
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/state"
+)
+
+func TestHAEntity(t *testing.T) {
+	st := state.New(nil)
+	st.ObservePrefix(netip.MustParsePrefix("fd22::/64"))
+	st.ObservePrefix(netip.MustParsePrefix("fd11::/64"))
+
+	entity := haEntity(st, nil)
+	if entity.State != "0" {
+		t.Errorf("Expected no border routers, got %q", entity.State)
+	}
+	prefixes, _ := entity.Attributes["mesh_prefixes"].([]string)
+	if len(prefixes) != 2 || prefixes[0] != "fd11::/64" {
+		t.Errorf("Expected the mesh prefixes sorted, got %v", entity.Attributes["mesh_prefixes"])
+	}
+	if _, ok := entity.Attributes["sync_phase"]; ok {
+		t.Error("Expected no sync attributes without a syncer")
+	}
+}
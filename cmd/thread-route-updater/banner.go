@@ -0,0 +1,164 @@
+package main
+
+import (
+	"encoding/json"
+	"net/url"
+	"sort"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/config"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/logger"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/version"
+)
+
+// startupBanner summarizes the effective configuration in the one log line a
+// bug report needs. Secrets show as REDACTED when set and empty when not.
+type startupBanner struct {
+	Version    string            `json:"version"`
+	Controller *bannerController `json:"controller,omitempty"` // nil with the UniFi integration disabled
+	Discovery  bannerDiscovery   `json:"discovery"`
+	Routes     bannerRoutes      `json:"routes"`
+	StatusAddr string            `json:"status_addr"`
+	Features   []string          `json:"features"`
+}
+
+// bannerController is the controller as configured and as detected by the
+// startup probe; the detected fields are empty when it failed.
+type bannerController struct {
+	URL           string   `json:"url"`
+	Username      string   `json:"username"`
+	Password      string   `json:"password"`
+	Proxy         string   `json:"proxy,omitempty"`
+	FallbackHosts []string `json:"fallback_hosts,omitempty"`
+	InsecureSSL   bool     `json:"insecure_ssl"`
+	APIVersion    string   `json:"api_version"`
+	RouteAPI      string   `json:"route_api,omitempty"` // "legacy", "v2" or "traffic"
+	Version       string   `json:"version,omitempty"`
+}
+
+type bannerDiscovery struct {
+	Backend    string   `json:"backend"`
+	Mode       string   `json:"mode"`
+	Server     string   `json:"server,omitempty"`
+	Domains    []string `json:"domains"`
+	Interfaces []string `json:"interfaces"`
+}
+
+type bannerRoutes struct {
+	Mode             config.RouteMode `json:"mode"`
+	GracePeriod      string           `json:"grace_period"`
+	GraceRules       []string         `json:"grace_rules,omitempty"`
+	DeviceExpiration string           `json:"device_expiration"`
+	MaxRoutes        int              `json:"max_routes,omitempty"`
+	MaxAddsPerSync   int              `json:"max_adds_per_sync,omitempty"`
+	MaxDeletes       int              `json:"max_deletes_per_sync,omitempty"`
+}
+
+// newStartupBanner builds the banner for cfg. controller holds what the probe
+// detected, or is nil with the UniFi integration disabled; interfaces are the
+// multicast interfaces discovery browses on.
+func newStartupBanner(cfg config.Config, controller *bannerController, interfaces []string) startupBanner {
+	if interfaces == nil {
+		interfaces = []string{}
+	}
+	banner := startupBanner{
+		Version:    version.Get().String(),
+		Controller: controller,
+		Discovery: bannerDiscovery{
+			Backend:    cfg.Discovery.Backend,
+			Mode:       cfg.Discovery.Mode,
+			Server:     cfg.Discovery.Server,
+			Domains:    cfg.Discovery.Domains,
+			Interfaces: interfaces,
+		},
+		Routes: bannerRoutes{
+			Mode:             cfg.UniFi.RouteMode,
+			GracePeriod:      logger.FormatDuration(cfg.RouteGracePeriod),
+			DeviceExpiration: logger.FormatDuration(cfg.DeviceExpiration),
+			MaxRoutes:        cfg.UniFi.Limits.MaxRoutes,
+			MaxAddsPerSync:   cfg.UniFi.Limits.MaxAddsPerSync,
+			MaxDeletes:       cfg.UniFi.Limits.MaxDeletesPerSync,
+		},
+		StatusAddr: cfg.StatusAddr,
+		Features:   enabledFeatures(cfg),
+	}
+	for _, rule := range cfg.GraceRules {
+		banner.Routes.GraceRules = append(banner.Routes.GraceRules, rule.Match+"="+logger.FormatDuration(rule.Period))
+	}
+	return banner
+}
+
+// configuredController returns the controller settings of u with its secrets
+// redacted, before anything is detected.
+func configuredController(u config.UniFi) *bannerController {
+	return &bannerController{
+		URL:           u.APIBaseURL,
+		Username:      u.Username,
+		Password:      config.RedactSecret(u.Password),
+		Proxy:         redactURL(u.ProxyURL),
+		FallbackHosts: u.FallbackHosts,
+		InsecureSSL:   u.InsecureSSL,
+		APIVersion:    u.APIVersion,
+	}
+}
+
+// redactURL hides the password of a URL carrying credentials.
+func redactURL(raw string) string {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return config.RedactSecret(raw)
+	}
+	return u.Redacted()
+}
+
+// enabledFeatures lists the optional features cfg turns on, sorted.
+func enabledFeatures(cfg config.Config) []string {
+	features := []string{}
+	for name, on := range map[string]bool{
+		"unifi":              cfg.UniFi.Enabled,
+		"approval":           cfg.UniFi.Enabled && cfg.UniFi.Approval,
+		"quarantine":         cfg.UniFi.Enabled && cfg.UniFi.Quarantine > 0,
+		"offline_queue":      cfg.UniFi.Enabled && cfg.UniFi.QueueExpiry > 0,
+		"removal_windows":    cfg.UniFi.Enabled && len(cfg.UniFi.RemovalWindows) > 0,
+		"route_damping":      cfg.UniFi.Enabled && cfg.UniFi.Damping.Enabled,
+		"firewall":           cfg.UniFi.Enabled && cfg.UniFi.Firewall.Enabled,
+		"client_table":       cfg.UniFi.Enabled && cfg.UniFi.ClientRefresh > 0,
+		"plan_log":           cfg.UniFi.Enabled && cfg.UniFi.PlanDir != "",
+		"route_backup":       cfg.UniFi.Enabled && cfg.UniFi.BackupFile != "",
+		"removal_requery":    cfg.RemovalRequery > 0,
+		"route_export":       cfg.Export.Dir != "",
+		"hooks":              cfg.Hooks.OnRouteAdd != "" || cfg.Hooks.OnRouteRemove != "" || cfg.Hooks.OnSyncFail != "",
+		"home_assistant":     cfg.HomeAssistant.URL != "",
+		"nat64_detect":       cfg.Discovery.NAT64Detect,
+		"route_lifetimes":    cfg.Discovery.RouteLifetimes,
+		"commissionable":     cfg.Discovery.Commissionable,
+		"mdns_reflector":     len(cfg.Discovery.ReflectorInterfaces) >= 2,
+		"discovery_rounds":   cfg.Discovery.RoundWindow > 0,
+		"nd_proxy":           cfg.NDProxyInterface != "",
+		"dns_zone":           cfg.DNSZone.Addr != "",
+		"debug_endpoints":    cfg.DebugEndpoints,
+		"mdns_advertise":     cfg.MDNSAdvertise,
+		"update_check":       cfg.UpdateCheck,
+		"ipv6_preflight":     cfg.IPv6Preflight,
+		"mdns_selftest":      cfg.MDNSSelfTest,
+		"warn_ipv4_only":     cfg.WarnIPv4Only,
+		"ha_publish_state":   cfg.HomeAssistant.URL != "" && cfg.HomeAssistant.PublishState,
+		"change_settle":      cfg.ChangeSettle.Quiet > 0,
+		"sync_failure_limit": cfg.UniFi.Enabled && cfg.UniFi.SyncFailureLimit > 0,
+	} {
+		if on {
+			features = append(features, name)
+		}
+	}
+	sort.Strings(features)
+	return features
+}
+
+// logStartupBanner logs the banner as one JSON line.
+func logStartupBanner(banner startupBanner) {
+	line, err := json.Marshal(banner)
+	if err != nil {
+		logger.Warn("Startup: %v", err)
+		return
+	}
+	logger.Info("Startup: %s", line)
+}
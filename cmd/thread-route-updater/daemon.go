@@ -0,0 +1,384 @@
+package main
+
+import (
+	"maps"
+	"net"
+	"net/netip"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/config"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/discovery"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/dnszone"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/events"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/exporter"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/logger"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/ndproxy"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/poller"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/routes"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/state"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/status"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/unifi"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/version"
+)
+
+// monitorThreadBorderRouters continuously browses for Thread Border Routers.
+func monitorThreadBorderRouters(st *state.State, browser discovery.Browser, done <-chan struct{}) {
+	logger.Info("Starting Thread Border Router discovery...")
+	discovery.BrowseBorderRouters(st, browser, done)
+}
+
+// exitSyncFailures is the exit code after too many consecutive failed syncs,
+// distinct from the exit code 1 of startup errors so supervisors can tell them apart.
+const exitSyncFailures = 3
+
+// runRouteSync subscribes the UniFi syncer to topology events. Routes are only
+// applied once the events settle, so a burst of discoveries produces one sync,
+// and every interval, re-read after each wakeup so a config reload takes effect,
+// unless changes are settling to repair drift on the controller. The state itself
+// follows the events immediately. After failureLimit consecutive failed syncs
+// (never when 0) it closes giveUp and stops. Syncs queued in requests, which may
+// be nil, run right away. While route operations wait for an unreachable
+// controller, it checks every unifi.QueueRetry whether the controller answers and
+// syncs as soon as it does.
+func runRouteSync(st *state.State, syncer *unifi.Syncer, changes <-chan events.Event, requests *syncRequests,
+	interval func() time.Duration, settle config.Settle, failureLimit int, giveUp chan<- struct{}, done <-chan struct{}) {
+	every := interval()
+	resync := time.NewTicker(every)
+	defer resync.Stop()
+	retry := time.NewTicker(unifi.QueueRetry)
+	defer retry.Stop()
+	pending := &settler{cfg: settle}
+	defer pending.stop()
+	for {
+		select {
+		case e, ok := <-changes:
+			if !ok {
+				return
+			}
+			if e.Kind.Topology() {
+				pending.change(time.Now())
+			}
+		case <-pending.C():
+			logger.Debug("UniFi: %d topology changes settled, syncing routes", pending.settled())
+			syncRoutes(st, syncer)
+		case <-resync.C:
+			if !pending.pending() {
+				syncRoutes(st, syncer)
+			}
+		case <-retry.C:
+			if syncer.HasQueuedOps() && !pending.pending() && syncer.ControllerReachable() {
+				syncRoutes(st, syncer)
+			}
+		case <-requests.C():
+			requests.run(st, syncer)
+		case <-done:
+			return
+		}
+		if next := interval(); next != every {
+			logger.Info("UniFi: sync interval %s -> %s", every, next)
+			every = next
+			resync.Reset(every)
+		}
+		if failures := syncer.SyncStatus().ConsecutiveFailures; failureLimit > 0 && failures >= failureLimit {
+			logger.Error("UniFi: %d consecutive syncs failed, giving up", failures)
+			close(giveUp)
+			return
+		}
+	}
+}
+
+// runRouteExport rewrites the route files once topology changes settle and
+// every 30 seconds, which also picks up device changes in host route mode. The
+// exporter only touches files whose contents changed.
+func runRouteExport(st *state.State, exp *exporter.Exporter, changes <-chan events.Event, settle config.Settle, done <-chan struct{}) {
+	refresh := time.NewTicker(30 * time.Second)
+	defer refresh.Stop()
+	pending := &settler{cfg: settle}
+	defer pending.stop()
+	export := func() {
+		if err := exp.Export(detectedRoutes(st.Snapshot())); err != nil {
+			logger.Error("Route export failed: %v", err)
+		}
+	}
+	for {
+		select {
+		case e, ok := <-changes:
+			if !ok {
+				return
+			}
+			if e.Kind.Topology() {
+				pending.change(time.Now())
+			}
+		case <-pending.C():
+			pending.settled()
+			export()
+		case <-refresh.C:
+			if !pending.pending() {
+				export()
+			}
+		case <-done:
+			return
+		}
+	}
+}
+
+// startReflector starts relaying mDNS between the configured interfaces. A
+// misconfigured reflector is logged and skipped; discovery works without it.
+func startReflector(cfg config.Discovery, done <-chan struct{}) {
+	reflector, err := discovery.NewReflector(cfg.ReflectorInterfaces, cfg.ReflectorServices)
+	if err == nil {
+		err = reflector.Run(done)
+	}
+	if err != nil {
+		logger.Error("mDNS reflector disabled: %v", err)
+		return
+	}
+	logger.Info("mDNS reflector relaying %s between %s",
+		strings.Join(cfg.ReflectorServices, ", "), strings.Join(cfg.ReflectorInterfaces, ", "))
+}
+
+// startNDProxy starts answering neighbor solicitations on iface for the
+// Thread device addresses, logging instead when the proxy can't start.
+func startNDProxy(st *state.State, iface string, done <-chan struct{}) {
+	proxy, err := ndproxy.New(iface, func() map[netip.Addr]netip.Addr { return ndProxyTargets(st.Snapshot(), st.DeviceAddrs()) })
+	if err == nil {
+		err = proxy.Run(done)
+	}
+	if err != nil {
+		logger.Error("ND proxy disabled (needs CAP_NET_RAW): %v", err)
+		return
+	}
+	logger.Info("ND proxy answering for Thread device addresses on %s", iface)
+}
+
+// ndProxyTargets maps each device address inside a routed mesh prefix to the
+// lowest next hop serving it, so the answer is stable between refreshes.
+func ndProxyTargets(snap state.Snapshot, deviceAddrs []netip.Addr) map[netip.Addr]netip.Addr {
+	snap.RouteMode = config.RouteModePrefix
+	targets := make(map[netip.Addr]netip.Addr)
+	for _, r := range routes.HostRoutes(detectedRoutes(snap), deviceAddrs) {
+		target := r.CIDR.Addr()
+		if nexthop, ok := targets[target]; !ok || r.ThreadRouterIPv6.Less(nexthop) {
+			targets[target] = r.ThreadRouterIPv6
+		}
+	}
+	return targets
+}
+
+// startPresence registers the daemon's presence service, announcing the status
+// API port, and lists the other instances found at /status/peers.
+func startPresence(statusServer *status.Server, browser discovery.Browser, statusAddr string, done <-chan struct{}) {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = "thread-route-updater"
+	}
+	_, portStr, err := net.SplitHostPort(statusAddr)
+	port, _ := strconv.Atoi(portStr)
+	if err != nil || port == 0 {
+		logger.Warn("mDNS presence disabled: no port in STATUS_ADDR %q", statusAddr)
+		return
+	}
+	if err := discovery.Advertise(hostname, port, version.Version, done); err != nil {
+		logger.Warn("mDNS presence disabled: %v", err)
+		return
+	}
+	logger.Info("Announcing %s as %s on port %d", discovery.PresenceService, hostname, port)
+
+	peers := discovery.NewPeers(hostname)
+	statusServer.Register("peers", func() interface{} { return peers.List(15 * time.Minute) })
+	go poller.Supervise("peer discovery", done, func() { peers.Browse(browser, done) })
+}
+
+// startCommissioning lists the Matter devices awaiting setup at
+// /status/commissionable. With COMMISSIONING_POLL_INTERVAL set, it also browses
+// for Matter devices at that interval while a commissioning window is open, so
+// a newly commissioned device and its mesh prefix are picked up quickly.
+func startCommissioning(statusServer *status.Server, st *state.State, browser discovery.Browser, ouis discovery.OUIDatabase, cfg config.Discovery, done <-chan struct{}) {
+	nodes := discovery.NewCommissionable(15 * time.Minute)
+	statusServer.Register("commissionable", func() interface{} { return nodes.List() })
+	go poller.Supervise("commissionable discovery", done, func() { nodes.Browse(browser, done) })
+	if cfg.CommissioningInterval <= 0 {
+		return
+	}
+	window := discoveryWindow(cfg)
+	go poller.Supervise("commissioning discovery", done, func() {
+		nodes.WhileOpen(cfg.CommissioningInterval, done, func() {
+			burst := make(chan struct{})
+			timer := time.AfterFunc(window, func() { close(burst) })
+			defer timer.Stop()
+			logger.Debug("Commissioning window open, browsing for Matter devices")
+			discovery.BrowseMatterDevices(st, browser, ouis, burst)
+		})
+	})
+}
+
+// startDNSZone serves the DNS zone naming the Matter devices on cfg.Addr,
+// logging instead when the sockets can't be opened.
+func startDNSZone(statusServer *status.Server, st *state.State, cfg config.DNSZone, done <-chan struct{}) {
+	zone := dnszone.New(cfg.Name, st.Devices)
+	if err := zone.Serve(cfg.Addr, done); err != nil {
+		logger.Error("DNS zone disabled: %v", err)
+		return
+	}
+	statusServer.Register("dns_zone", func() interface{} { return zone.Records() })
+}
+
+// probeController checks the controller credentials with a read-only call and
+// logs what is wrong, so misconfigurations show up within seconds of startup.
+// The daemon keeps running either way; later syncs retry the login. It
+// reports whether the probe passed.
+func probeController(client *unifi.Client) bool {
+	result, err := client.Probe()
+	if result == unifi.ProbeOK {
+		logger.Info("UniFi: controller credentials OK")
+		return true
+	}
+	logger.Error("UniFi: controller probe failed (%s): %v; %s", result, err, result.Hint())
+	return false
+}
+
+// migrateLegacyRoutes deletes the legacy routes duplicating managed ones
+// before the first sync, which then adopts the others.
+func migrateLegacyRoutes(syncer *unifi.Syncer) {
+	result, err := syncer.MigrateLegacyRoutes()
+	if err != nil {
+		logger.Warn("UniFi: legacy route migration skipped: %v", err)
+		return
+	}
+	if result != (unifi.MigrationResult{}) {
+		logger.Info("UniFi: legacy route migration: %d duplicates deleted, %d duplicates kept, %d failed",
+			result.Removed, result.Kept, result.Failed)
+	}
+}
+
+// syncRoutes pushes the routes derived from the current state to UniFi.
+func syncRoutes(st *state.State, syncer *unifi.Syncer) {
+	syncer.Sync(detectedRoutes(st.Snapshot()))
+}
+
+// detectedRoutes returns the routes the snapshot's route policy gives its
+// Thread mesh prefixes and border routers.
+func detectedRoutes(snap state.Snapshot) []routes.Route {
+	return snap.Policy().Routes(snap.MeshPrefixes, snap.BorderRouters)
+}
+
+// logEvents is the display subscriber: it logs lifecycle events until the bus closes.
+func logEvents(ch <-chan events.Event) {
+	for e := range ch {
+		switch e.Kind {
+		case events.RouterAdded:
+			logger.Info("Thread Border Router discovered: %s", e.Name)
+		case events.RouterExpired:
+			logger.Info("Thread Border Router expired: %s", e.Name)
+		case events.PrefixExpired:
+			logger.Info("Thread mesh prefix expired: %s", e.Prefix)
+		case events.SyncFailed:
+			logger.Warn("UniFi: sync failed: %v", e.Err)
+		case events.SyncSucceeded:
+			logger.Debug("UniFi: sync succeeded (%s)", e.Detail)
+		case events.DeviceAdded, events.DeviceUpdated:
+			logger.Debug("Event %s: %s", e.Kind, e.Detail)
+		case events.DeviceExpired, events.RouterUpdated:
+			logger.Debug("Event %s: %s", e.Kind, e.Name)
+		}
+	}
+}
+
+// periodicRefresh cleans up expired devices, TREL peers, routers and Thread mesh
+// prefixes and compacts route tracking every 5 minutes, with the configuration
+// in effect at each run.
+func periodicRefresh(st *state.State, live *config.Live, done <-chan struct{}) {
+	poller.Run(done, 5*time.Minute, "expiration cleanup", func() error {
+		cfg := live.Get()
+		logger.Debug("Running expiration cleanup")
+		st.RemoveExpiredDevices(cfg.DeviceExpiration)
+		st.RemoveExpiredTRELPeers(cfg.DeviceExpiration)
+		expiredRouters := st.RemoveExpiredRouters(cfg.DeviceExpiration)
+		expiredPrefixes := st.RemoveExpiredPrefixes(cfg.Grace())
+		if expiredRouters > 0 || expiredPrefixes > 0 {
+			logger.Info("Expiration cleanup: removed %d border routers, %d prefixes",
+				expiredRouters, expiredPrefixes)
+		}
+		if compacted := st.CompactTracking(cfg.Grace()); compacted > 0 {
+			logger.Debug("Expiration cleanup: compacted %d tracking entries", compacted)
+		}
+		return nil
+	})
+}
+
+// pollClientTable re-reads the controller's client list and networks every
+// interval to learn where border routers attach to the LAN, warning about VLAN
+// layouts that break routing to Thread when they first show up.
+func pollClientTable(st *state.State, clients *unifi.ClientTable, interval time.Duration, done <-chan struct{}) {
+	warned := make(map[string]bool)
+	poller.Run(done, interval, "UniFi client list", func() error {
+		if err := clients.Refresh(); err != nil {
+			return err
+		}
+		snap := st.Snapshot()
+		st.SetRouterUplinks(clients.Uplinks(snap.BorderRouters))
+
+		current := make(map[string]bool)
+		for _, w := range unifi.VLANWarnings(clients.Topology(snap.BorderRouters, st.Devices())) {
+			current[w] = true
+			if !warned[w] {
+				logger.Warn("Topology: %s", w)
+			}
+		}
+		warned = current
+		return nil
+	})
+}
+
+// displayCurrentState logs the current state and the routes configured on UniFi.
+// syncer is nil when UniFi integration is disabled.
+func displayCurrentState(st *state.State, syncer *unifi.Syncer) {
+	snap := st.Snapshot()
+	detected := detectedRoutes(snap)
+
+	logger.Info("Status: %d Matter devices, %d border routers, %d prefixes, %d routes",
+		snap.Devices, len(snap.BorderRouters), len(snap.MeshPrefixes), len(detected))
+
+	for _, p := range slices.SortedFunc(maps.Keys(snap.MeshPrefixes), routes.ComparePrefixes) {
+		logger.Debug("Thread mesh prefix: %s last-seen=%s", p, time.Since(snap.MeshPrefixes[p]).Round(time.Second))
+	}
+	for _, r := range snap.BorderRouters {
+		for _, ip := range r.IPv6Addrs {
+			cidr := discovery.CIDR64(ip)
+			logger.Debug("TBR %s: ip=%s cidr=%s routable=%v", r.Name, ip, cidr, routes.IsRoutableRouterAddress(ip))
+		}
+	}
+
+	if len(detected) > 0 {
+		for _, route := range detected {
+			logger.Debug("Route detected: %s -> %s (%s)", route.CIDR, route.ThreadRouterIPv6, route.RouterName)
+		}
+	} else {
+		logger.Warn("No routes detected: no Thread networks found")
+	}
+
+	if syncer != nil {
+		syncer.LogConfiguredRoutes(detected)
+	}
+}
+
+// removalCheck returns a check that queries border routers once more and
+// reports whether the one at nexthop answers while still announcing network,
+// waiting up to timeout. Routes without a next hop address fall back to Matter
+// devices answering in network.
+func removalCheck(browser discovery.Browser, timeout time.Duration) func(network, nexthop string) bool {
+	return func(network, nexthop string) bool {
+		prefix, err := netip.ParsePrefix(network)
+		if err != nil {
+			return false
+		}
+		if addr, err := netip.ParseAddr(nexthop); err == nil {
+			return discovery.RequeryRouter(browser, addr, prefix.Masked(), timeout)
+		}
+		return discovery.Requery(browser, "_matter._tcp", prefix.Masked(), timeout)
+	}
+}
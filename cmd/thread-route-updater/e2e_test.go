@@ -0,0 +1,198 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/grandcat/zeroconf"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/config"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/discovery"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/events"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/state"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/unifi"
+)
+
+// fakeController is an in-memory UniFi controller serving the legacy static route API.
+type fakeController struct {
+	mu     sync.Mutex
+	routes []unifi.StaticRoute
+	nextID int
+}
+
+func (f *fakeController) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	const routing = "/proxy/network/api/s/default/rest/routing"
+	switch {
+	case r.URL.Path == "/api/auth/login":
+		w.Header().Set("X-CSRF-Token", "csrf")
+		http.SetCookie(w, &http.Cookie{Name: "TOKEN", Value: "session"})
+		_, _ = w.Write([]byte(`{"meta":{"rc":"ok"}}`))
+	case r.URL.Path == "/proxy/network/api/s/default/stat/sysinfo":
+		_, _ = w.Write([]byte(`{"meta":{"rc":"ok"},"data":[{"version":"8.6.9"}]}`))
+	case r.URL.Path == "/proxy/network/api/s/default/stat/device":
+		_, _ = w.Write([]byte(`{"meta":{"rc":"ok"},"data":[{"type":"udm","mac":"aa:bb:cc:dd:ee:ff"}]}`))
+	case r.URL.Path == routing && r.Method == http.MethodGet:
+		page := f.routes
+		if r.URL.Query().Get("_start") != "0" {
+			page = nil
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"meta": map[string]string{"rc": "ok"}, "data": page})
+	case r.URL.Path == routing && r.Method == http.MethodPost:
+		var route unifi.StaticRoute
+		_ = json.NewDecoder(r.Body).Decode(&route)
+		f.nextID++
+		route.ID = fmt.Sprintf("route%d", f.nextID)
+		f.routes = append(f.routes, route)
+		_, _ = w.Write([]byte(`{"meta":{"rc":"ok"}}`))
+	case strings.HasPrefix(r.URL.Path, routing+"/") && r.Method == http.MethodDelete:
+		id := strings.TrimPrefix(r.URL.Path, routing+"/")
+		for i, route := range f.routes {
+			if route.ID == id {
+				f.routes = append(f.routes[:i], f.routes[i+1:]...)
+				break
+			}
+		}
+		_, _ = w.Write([]byte(`{"meta":{"rc":"ok"}}`))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+// snapshot returns the routes currently configured on the controller.
+func (f *fakeController) snapshot() []unifi.StaticRoute {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]unifi.StaticRoute(nil), f.routes...)
+}
+
+// registerService announces a DNS-SD service over mDNS with the given addresses.
+func registerService(t *testing.T, instance, service, host string, addrs []string, txt []string) {
+	t.Helper()
+	server, err := zeroconf.RegisterProxy(instance, service, "local.", 5540, host, addrs, txt, multicastInterfaces())
+	if err != nil {
+		t.Fatalf("Failed to register %s: %v", service, err)
+	}
+	t.Cleanup(server.Shutdown)
+}
+
+// multicastInterfaces returns the up, multicast-capable interfaces.
+func multicastInterfaces() []net.Interface {
+	all, _ := net.Interfaces()
+	var out []net.Interface
+	for _, iface := range all {
+		if iface.Flags&net.FlagUp != 0 && iface.Flags&net.FlagMulticast != 0 {
+			out = append(out, iface)
+		}
+	}
+	return out
+}
+
+// TestEndToEnd announces a Thread border router and a Matter device over mDNS and
+// runs the discovery and route sync loops against a fake controller, checking
+// routes to both the border router's omr= prefix and the prefix derived from the
+// Matter device's address are programmed through the border router.
+func TestEndToEnd(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping end-to-end test in short mode")
+	}
+	if len(multicastInterfaces()) == 0 {
+		t.Skip("no multicast-capable interface")
+	}
+
+	const nexthop = "2001:4860:4860:1234::ff"
+	expected := map[string]bool{
+		"fd00:1111:2222:4444::/64": true, // from omr=
+		"fd00:1111:2222:3333::/64": true, // from the Matter device address
+	}
+	// omr= carries the prefix length followed by the prefix bytes.
+	omr := "omr=" + string([]byte{64, 0xfd, 0x00, 0x11, 0x11, 0x22, 0x22, 0x44, 0x44})
+	registerService(t, "E2E Border Router", "_meshcop._udp", "e2e-tbr", []string{nexthop}, []string{"rv=1", "nn=e2e", omr})
+	registerService(t, "E2E Light", "_matter._tcp", "e2e-light", []string{"fd00:1111:2222:3333::10"}, []string{"CRI=5000"})
+
+	controller := &fakeController{}
+	srv := httptest.NewTLSServer(controller)
+	defer srv.Close()
+
+	bus := events.NewBus()
+	defer bus.Close()
+	st := state.New(bus)
+	client := unifi.NewClient(config.UniFi{
+		APIBaseURL:  srv.URL,
+		Username:    "tester",
+		Password:    "secret",
+		InsecureSSL: true,
+		Enabled:     true,
+		APIVersion:  "auto",
+	})
+	syncer := unifi.NewSyncer(client, st, bus, config.GracePolicy{Default: time.Minute})
+	browser, err := discovery.NewBrowser(config.Discovery{Backend: "zeroconf"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go runRouteSync(st, syncer, bus.Subscribe(64), nil, func() time.Duration { return 30 * time.Second }, config.Settle{Quiet: time.Second, Max: 5 * time.Second}, 0, nil, done)
+	go monitorThreadBorderRouters(st, browser, done)
+	go discovery.BrowseMatterDevices(st, browser, nil, done)
+
+	deadline := time.Now().Add(30 * time.Second)
+	for time.Now().Before(deadline) {
+		found := 0
+		for _, route := range controller.snapshot() {
+			if _, ok := expected[route.StaticRouteNetwork]; !ok || route.StaticRouteNexthop != nexthop {
+				t.Fatalf("Unexpected route %+v", route)
+			}
+			if route.Name != unifi.RouteName("E2E Border Router", route.StaticRouteNetwork, nexthop) || route.GatewayDevice != "aa:bb:cc:dd:ee:ff" {
+				t.Errorf("Unexpected route %+v", route)
+			}
+			found++
+		}
+		if found == len(expected) {
+			return
+		}
+		time.Sleep(250 * time.Millisecond)
+	}
+	t.Fatalf("Expected routes to %v via %s, controller has %+v", expected, nexthop, controller.snapshot())
+}
+
+// TestRunRouteSyncGivesUp verifies the route sync loop stops and signals the
+// daemon to exit once the controller failed the configured number of syncs.
+func TestRunRouteSyncGivesUp(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "internal error", http.StatusInternalServerError)
+	}))
+	defer srv.Close()
+
+	bus := events.NewBus()
+	defer bus.Close()
+	st := state.New(bus)
+	client := unifi.NewClient(config.UniFi{APIBaseURL: srv.URL, InsecureSSL: true, Enabled: true, APIVersion: "v1"})
+	syncer := unifi.NewSyncer(client, st, bus, config.GracePolicy{Default: time.Minute})
+
+	done := make(chan struct{})
+	defer close(done)
+	giveUp := make(chan struct{})
+	go runRouteSync(st, syncer, bus.Subscribe(64), nil, func() time.Duration { return 30 * time.Second }, config.Settle{Quiet: time.Second, Max: 5 * time.Second}, 1, giveUp, done)
+	time.Sleep(50 * time.Millisecond) // let the loop subscribe before publishing
+	bus.Publish(events.Event{Kind: events.RouterAdded, Name: "Router1"})
+
+	select {
+	case <-giveUp:
+	case <-time.After(15 * time.Second):
+		t.Fatal("Expected the sync loop to give up after a failed sync")
+	}
+	if status := syncer.SyncStatus(); status.ConsecutiveFailures != 1 || status.LastError == nil ||
+		status.LastError.Phase != unifi.PhaseAuthenticate {
+		t.Errorf("Expected one failure in the authenticate phase, got %+v", status)
+	}
+}
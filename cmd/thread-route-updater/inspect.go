@@ -0,0 +1,178 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/netip"
+	"os"
+	"os/signal"
+	"slices"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/config"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/discovery"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/logger"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/state"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/unifi"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/pkg/threaddiscovery"
+)
+
+// inspectReport is what the inspect command prints: the discovered topology
+// and the routes the daemon would program for it.
+type inspectReport struct {
+	Time          time.Time                `json:"time"`
+	BorderRouters []discovery.BorderRouter `json:"border_routers"`
+	Devices       []discovery.MatterDevice `json:"devices"`
+	Networks      []state.ThreadNetwork    `json:"networks"`
+	MeshPrefixes  []netip.Prefix           `json:"mesh_prefixes"`
+	Routes        []inspectRoute           `json:"routes"`
+}
+
+// inspectRoute is a computed route with the name it would get on the controller.
+type inspectRoute struct {
+	Network string `json:"network"`
+	Nexthop string `json:"nexthop"`
+	Router  string `json:"router"`
+	Name    string `json:"name"`
+}
+
+// runInspect implements "thread-route-updater inspect": it browses for the
+// configured discovery window and prints what it found to stdout, without
+// contacting any controller. Without --once it keeps browsing and prints a
+// report after every window until interrupted. It returns the exit code.
+func runInspect(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("inspect", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	once := fs.Bool("once", false, "print a single report and exit")
+	output := fs.String("output", "json", "report format: json or text")
+	window := fs.Duration("duration", 10*time.Second, "how long to browse before each report")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *output != "json" && *output != "text" {
+		fmt.Fprintf(stderr, "inspect: unknown output format %q, expected json or text\n", *output)
+		return 2
+	}
+	if *window <= 0 {
+		fmt.Fprintln(stderr, "inspect: --duration must be positive")
+		return 2
+	}
+
+	if path := configFile(); path != "" {
+		if err := config.LoadFile(path); err != nil {
+			logger.Error("%v", err)
+			return 1
+		}
+	}
+	logger.InitLevel()
+	cfg := config.Load()
+	browser, err := discovery.NewBrowser(cfg.Discovery)
+	if err != nil {
+		logger.Error("Discovery: %v", err)
+		return 1
+	}
+	ouis, err := threaddiscovery.LoadOUIDatabase(cfg.Discovery.OUIFile)
+	if err != nil {
+		logger.Warn("Failed to load OUI database, using built-in vendors: %v", err)
+	}
+	st := state.New(nil)
+	st.ConfigureLimits(cfg.Tracking)
+	st.ConfigureNAT64(cfg.Discovery.NAT64Prefixes)
+	st.ConfigurePrefixConflicts(cfg.PrefixConflictPolicy)
+	st.ConfigureRouteMode(cfg.UniFi.RouteMode)
+	st.ConfigureOverrides(cfg.Overrides)
+	st.ConfigureNextHops(cfg.NextHops)
+
+	done := make(chan struct{})
+	defer close(done)
+	go discovery.BrowseBorderRouters(st, browser, done)
+	go discovery.BrowseMatterDevices(st, browser, ouis, done)
+	go discovery.BrowseTRELPeers(st, browser, done)
+	go discovery.PollHomeAssistant(st, cfg.HomeAssistant, done)
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(sigChan)
+
+	ticker := time.NewTicker(*window)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-sigChan:
+			return 0
+		}
+		if err := writeInspectReport(stdout, buildInspectReport(st), *output); err != nil {
+			logger.Error("Failed to write report: %v", err)
+			return 1
+		}
+		if *once {
+			return 0
+		}
+	}
+}
+
+// buildInspectReport collects the current topology of st and its routes.
+func buildInspectReport(st *state.State) inspectReport {
+	snap := st.Snapshot()
+	report := inspectReport{
+		Time:          time.Now().UTC(),
+		BorderRouters: snap.BorderRouters,
+		Devices:       st.Devices(),
+		Networks:      st.ThreadNetworks(),
+		MeshPrefixes:  sortedPrefixes(snap.MeshPrefixes),
+		Routes:        []inspectRoute{},
+	}
+	for _, r := range detectedRoutes(snap) {
+		network, nexthop := r.CIDR.String(), r.ThreadRouterIPv6.String()
+		report.Routes = append(report.Routes, inspectRoute{
+			Network: network,
+			Nexthop: nexthop,
+			Router:  r.RouterName,
+			Name:    unifi.RouteName(r.RouterName, network, nexthop),
+		})
+	}
+	return report
+}
+
+// sortedPrefixes returns the keys of prefixes in address order.
+func sortedPrefixes(prefixes map[netip.Prefix]time.Time) []netip.Prefix {
+	sorted := make([]netip.Prefix, 0, len(prefixes))
+	for p := range prefixes {
+		sorted = append(sorted, p)
+	}
+	slices.SortFunc(sorted, func(a, b netip.Prefix) int {
+		if c := a.Addr().Compare(b.Addr()); c != 0 {
+			return c
+		}
+		return a.Bits() - b.Bits()
+	})
+	return sorted
+}
+
+// writeInspectReport prints report to w as indented JSON or as text tables.
+func writeInspectReport(w io.Writer, report inspectReport, format string) error {
+	if format == "json" {
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(report)
+	}
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	fmt.Fprintf(tw, "Border routers: %d\n", len(report.BorderRouters))
+	for _, br := range report.BorderRouters {
+		fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\t%s\n", br.Name, br.Type, br.Link, br.OMRPrefix, br.ExtPANID)
+	}
+	fmt.Fprintf(tw, "Matter devices: %d\n", len(report.Devices))
+	for _, d := range report.Devices {
+		fmt.Fprintf(tw, "  %s\t%s\n", d.Name, d.Description())
+	}
+	fmt.Fprintf(tw, "Routes: %d\n", len(report.Routes))
+	for _, r := range report.Routes {
+		fmt.Fprintf(tw, "  %s\tvia %s\t%s\n", r.Network, r.Nexthop, r.Router)
+	}
+	return tw.Flush()
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/discovery"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/state"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/unifi"
+)
+
+// TestBuildInspectReport verifies the report lists the discovered topology and
+// the routes computed from it, named as they would be on the controller.
+func TestBuildInspectReport(t *testing.T) {
+	st := state.New(nil)
+	st.MergeBorderRouter(discovery.BorderRouter{
+		Name:      "Kitchen",
+		ExtPANID:  "dead00beef00cafe",
+		IPv6Addrs: []netip.Addr{netip.MustParseAddr("2a02:8109:aa22:4181::1")},
+		LastSeen:  time.Now(),
+	})
+	st.ObservePrefix(netip.MustParsePrefix("fd11:2222:3333:4444::/64"))
+	st.MergeDevice(discovery.MatterDevice{
+		Name:      "Lamp",
+		IPv6Addrs: []netip.Addr{netip.MustParseAddr("fd11:2222:3333:4444::5")},
+		LastSeen:  time.Now(),
+	})
+
+	report := buildInspectReport(st)
+	if len(report.BorderRouters) != 1 || len(report.Devices) != 1 || len(report.MeshPrefixes) != 1 {
+		t.Fatalf("Expected one border router, device and prefix, got %+v", report)
+	}
+	want := inspectRoute{
+		Network: "fd11:2222:3333:4444::/64",
+		Nexthop: "2a02:8109:aa22:4181::1",
+		Router:  "Kitchen",
+		Name:    unifi.RouteName("Kitchen", "fd11:2222:3333:4444::/64", "2a02:8109:aa22:4181::1"),
+	}
+	if len(report.Routes) != 1 || report.Routes[0] != want {
+		t.Errorf("Expected route %+v, got %+v", want, report.Routes)
+	}
+}
+
+// TestWriteInspectReport verifies the JSON and text renderings of a report.
+func TestWriteInspectReport(t *testing.T) {
+	report := buildInspectReport(state.New(nil))
+	report.Routes = append(report.Routes, inspectRoute{Network: "fd00::/64", Nexthop: "fe80::1", Router: "Kitchen"})
+
+	var out bytes.Buffer
+	if err := writeInspectReport(&out, report, "json"); err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string]json.RawMessage
+	if err := json.Unmarshal(out.Bytes(), &decoded); err != nil {
+		t.Fatalf("Expected valid JSON, got %v: %s", err, out.String())
+	}
+	for _, key := range []string{"time", "border_routers", "devices", "networks", "mesh_prefixes", "routes"} {
+		if _, ok := decoded[key]; !ok {
+			t.Errorf("Expected key %q in %s", key, out.String())
+		}
+	}
+
+	out.Reset()
+	if err := writeInspectReport(&out, report, "text"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(out.String(), "Routes: 1") || !strings.Contains(out.String(), "fd00::/64") {
+		t.Errorf("Expected the route in the text report, got %q", out.String())
+	}
+}
+
+// TestRunInspectFlags verifies invalid arguments are rejected before discovery starts.
+func TestRunInspectFlags(t *testing.T) {
+	tests := []struct {
+		name string
+		args []string
+	}{
+		{"unknown output", []string{"--once", "--output", "yaml"}},
+		{"zero duration", []string{"--once", "--duration", "0s"}},
+		{"unknown flag", []string{"--bogus"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+			if code := runInspect(tt.args, &stdout, &stderr); code != 2 {
+				t.Errorf("Expected exit code 2, got %d", code)
+			}
+			if stdout.Len() != 0 {
+				t.Errorf("Expected nothing on stdout, got %q", stdout.String())
+			}
+		})
+	}
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+)
+
+// launchdLabel is the launchd job label of the agent.
+const launchdLabel = "io.github.rafaelgaspar.thread-route-updater"
+
+// launchdPlist returns the property list of a launchd agent that starts exe
+// with args in dir at login and restarts it whenever it exits. launchd would
+// otherwise start it in /, where files with relative paths can't be written.
+func launchdPlist(exe string, args []string, dir string) []byte {
+	var b bytes.Buffer
+	b.WriteString(xml.Header)
+	b.WriteString(`<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">` + "\n")
+	b.WriteString("<plist version=\"1.0\">\n<dict>\n")
+	b.WriteString("\t<key>Label</key>\n\t<string>")
+	_ = xml.EscapeText(&b, []byte(launchdLabel))
+	b.WriteString("</string>\n\t<key>ProgramArguments</key>\n\t<array>\n")
+	for _, arg := range append([]string{exe}, args...) {
+		b.WriteString("\t\t<string>")
+		_ = xml.EscapeText(&b, []byte(arg))
+		b.WriteString("</string>\n")
+	}
+	b.WriteString("\t</array>\n\t<key>WorkingDirectory</key>\n\t<string>")
+	_ = xml.EscapeText(&b, []byte(dir))
+	b.WriteString("</string>\n\t<key>RunAtLoad</key>\n\t<true/>\n\t<key>KeepAlive</key>\n\t<true/>\n</dict>\n</plist>\n")
+	return b.Bytes()
+}
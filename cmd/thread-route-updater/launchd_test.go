@@ -0,0 +1,23 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLaunchdPlist(t *testing.T) {
+	plist := string(launchdPlist("/usr/local/bin/thread-route-updater", []string{"-service", "run", "-config", "/Users/me/R&D/config.env"},
+		"/Users/me/Library/Application Support/thread-route-updater"))
+
+	for _, want := range []string{
+		"<string>" + launchdLabel + "</string>",
+		"<string>/usr/local/bin/thread-route-updater</string>\n\t\t<string>-service</string>\n\t\t<string>run</string>",
+		"<string>/Users/me/R&amp;D/config.env</string>",
+		"<key>KeepAlive</key>\n\t<true/>",
+		"<key>WorkingDirectory</key>\n\t<string>/Users/me/Library/Application Support/thread-route-updater</string>",
+	} {
+		if !strings.Contains(plist, want) {
+			t.Errorf("Expected %q in the plist, got:\n%s", want, plist)
+		}
+	}
+}
@@ -0,0 +1,337 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/config"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/discovery"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/events"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/exporter"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/hassio"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/hooks"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/logger"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/metrics"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/poller"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/routes"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/state"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/status"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/unifi"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/version"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/pkg/dnssd"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/pkg/threaddiscovery"
+)
+
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == "inspect" {
+		os.Exit(runInspect(os.Args[2:], os.Stdout, os.Stderr))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "uninstall" {
+		os.Exit(runUninstall(os.Args[2:], os.Stdout, os.Stderr))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "restore-backup" {
+		os.Exit(runRestoreBackup(os.Args[2:], os.Stdout, os.Stderr))
+	}
+	exportState := flag.String("export-state", "", "write the discovery and route state to this JSON file on shutdown")
+	importState := flag.String("import-state", "", "load the discovery and route state from this JSON file at startup")
+	showVersion := flag.Bool("version", false, "print version information and exit")
+	configPath := flag.String("config", "", "env file to load, as CONFIG_FILE")
+	service := flag.String("service", "", "install, uninstall or run as a Windows service or launchd agent")
+	flag.Parse()
+
+	if *showVersion {
+		fmt.Println("thread-route-updater " + version.Get().String())
+		return
+	}
+	if *configPath != "" {
+		_ = os.Setenv("CONFIG_FILE", *configPath)
+	}
+	if *service != "" {
+		os.Exit(runService(*service, *exportState, *importState, os.Stdout, os.Stderr))
+	}
+	runDaemon(*exportState, *importState, nil)
+}
+
+// runDaemon runs the daemon until SIGINT or SIGTERM is received or stop is
+// closed. exportState and importState are the -export-state and -import-state
+// files.
+func runDaemon(exportState, importState string, stop <-chan struct{}) {
+	if config.InAddon() {
+		if err := config.LoadAddonOptions(config.AddonOptionsFile); err != nil {
+			logger.Error("%v", err)
+			os.Exit(1)
+		}
+	} else if path := configFile(); path != "" {
+		if err := config.LoadFile(path); err != nil {
+			logger.Error("%v", err)
+			os.Exit(1)
+		}
+	}
+	logger.InitLevel()
+
+	logger.Info("Thread Route Updater %s starting...", version.Get())
+
+	cfg := config.Load()
+	live := config.NewLive(cfg)
+	browser, err := discovery.NewBrowser(cfg.Discovery)
+	if err != nil {
+		logger.Error("Discovery: %v", err)
+		os.Exit(1)
+	}
+	ouis, err := threaddiscovery.LoadOUIDatabase(cfg.Discovery.OUIFile)
+	if err != nil {
+		logger.Warn("Failed to load OUI database, using built-in vendors: %v", err)
+	}
+	if cfg.MDNSSelfTest && cfg.Discovery.Backend == "zeroconf" {
+		if err := discovery.SelfTest(3 * time.Second); err != nil {
+			logger.Error("mDNS self-test failed, no Thread devices will be discovered: %v", err)
+		} else {
+			logger.Info("mDNS self-test passed")
+		}
+	}
+	if cfg.IPv6Preflight {
+		for _, warning := range discovery.Preflight() {
+			logger.Warn("IPv6 preflight: %s", warning)
+		}
+	}
+	bus := events.NewBus()
+	defer bus.Close()
+	st := state.New(bus)
+	st.ConfigureLimits(cfg.Tracking)
+	metrics.NewGaugeFunc("state_entries", "Entries in the daemon's state, by map.", "map", st.Sizes)
+	metrics.NewGaugeFunc("mesh_prefix_availability_percent", "Share of the stability window each mesh prefix was known.", "prefix",
+		st.PrefixStabilityMetrics(func(p state.PrefixStability) float64 { return p.Availability }))
+	metrics.NewGaugeFunc("mesh_prefix_flaps", "Times each mesh prefix expired and came back within the stability window.", "prefix",
+		st.PrefixStabilityMetrics(func(p state.PrefixStability) float64 { return float64(p.Flaps) }))
+	metrics.NewGaugeFunc("mesh_prefix_announcement_gap_seconds", "Average time between sightings of each mesh prefix.", "prefix",
+		st.PrefixStabilityMetrics(func(p state.PrefixStability) float64 { return p.AverageGap }))
+	if importState != "" {
+		if err := st.ImportFile(importState); err != nil {
+			logger.Error("Failed to import state: %v", err)
+			os.Exit(1)
+		}
+		logger.Info("Imported state from %s", importState)
+	}
+
+	statusServer := status.NewServer()
+	statusServer.RequireActionToken(cfg.StatusActions.Token)
+	if cfg.DebugEndpoints {
+		statusServer.EnableDebug()
+		logger.Info("Debug endpoints enabled: /debug/pprof/ and /debug/vars")
+	}
+	statusServer.Register("version", func() interface{} { return version.Get() })
+	statusServer.Register("state", func() interface{} { return st.Snapshot() })
+	statusServer.Register("devices", func() interface{} { return st.Devices() })
+	statusServer.Register("networks", func() interface{} { return st.ThreadNetworks() })
+	statusServer.Register("stability", func() interface{} { return st.PrefixStability() })
+	statusServer.Register("address_history", func() interface{} { return st.AddressHistory() })
+	statusServer.Register("prefix_lifetimes", func() interface{} { return st.PrefixLifetimes() })
+	statusServer.Register("export", func() interface{} { return st.Export() })
+	recent := events.NewRing(100)
+	statusServer.RegisterEndpoint("/api/v1/events", func() interface{} { return recent.Records() })
+
+	var syncer *unifi.Syncer
+	var syncs *syncRequests
+	var clients *unifi.ClientTable
+	var controller *bannerController
+	if cfg.UniFi.Enabled {
+		client := unifi.NewClient(cfg.UniFi)
+		controller = configuredController(cfg.UniFi)
+		if probeController(client) {
+			controller.RouteAPI = client.APIFamily()
+			controller.Version, _ = client.ControllerVersion()
+		}
+		syncer = unifi.NewSyncer(client, st, bus, cfg.Grace())
+		migrateLegacyRoutes(syncer)
+		if err := syncer.ResolveRouteInterface(); err != nil {
+			logger.Error("UniFi: interface routes disabled, using next hop routes: %v", err)
+		}
+		if cfg.RemovalRequery > 0 {
+			syncer.SetRemovalCheck(removalCheck(browser, cfg.RemovalRequery))
+		}
+		syncs = newSyncRequests(rediscover(st, browser, ouis, cfg.Discovery))
+		statusServer.RegisterAction("sync", func(r *http.Request) (interface{}, error) {
+			full := r.URL.Query().Get("full") == "true"
+			syncs.request(full)
+			return map[string]bool{"queued": true, "full": full}, nil
+		})
+		statusServer.Register("rejections", func() interface{} { return syncer.Rejections() })
+		statusServer.Register("damping", func() interface{} { return syncer.DampedRoutes() })
+		statusServer.Register("sync", func() interface{} { return syncer.SyncStatus() })
+		statusServer.Register("gateways", func() interface{} { return syncer.Gateways() })
+		statusServer.Register("queue", func() interface{} { return syncer.QueuedOps() })
+		statusServer.Register("logins", func() interface{} { return client.LoginBudget() })
+		if cfg.UniFi.ClientRefresh > 0 {
+			clients = unifi.NewClientTable(client)
+			statusServer.Register("topology", func() interface{} {
+				return clients.Topology(st.Snapshot().BorderRouters, st.Devices())
+			})
+			routed := func() ([]routes.Route, []discovery.BorderRouter) {
+				snap := st.Snapshot()
+				return detectedRoutes(snap), snap.BorderRouters
+			}
+			statusServer.Register("traffic", func() interface{} { return clients.RouteTraffic(routed()) })
+			for _, m := range []struct {
+				name, help string
+				value      func(unifi.Counters) float64
+			}{
+				{"border_router_rx_bytes_total", "The controller's rx_bytes counter of each border router client routes go through, by MAC address.", func(c unifi.Counters) float64 { return c.RxBytes }},
+				{"border_router_tx_bytes_total", "The controller's tx_bytes counter of each border router client routes go through, by MAC address.", func(c unifi.Counters) float64 { return c.TxBytes }},
+				{"border_router_rx_packets_total", "The controller's rx_packets counter of each border router client routes go through, by MAC address.", func(c unifi.Counters) float64 { return c.RxPackets }},
+				{"border_router_tx_packets_total", "The controller's tx_packets counter of each border router client routes go through, by MAC address.", func(c unifi.Counters) float64 { return c.TxPackets }},
+			} {
+				metrics.NewCounterFunc(m.name, m.help, "mac", clients.TrafficMetric(routed, m.value))
+			}
+		}
+		statusServer.Register("grace", func() interface{} { return syncer.GraceTimers() })
+		metrics.NewGaugeFunc("route_grace_remaining_seconds",
+			"Seconds until an undetected managed route is removed, by route.", "route", syncer.GraceRemaining)
+		statusServer.Register("plan", func() interface{} { return syncer.LastPlan() })
+		if cfg.UniFi.Approval {
+			statusServer.Register("pending", func() interface{} { return syncer.PendingPlan() })
+			statusServer.RegisterAction("approve", func(r *http.Request) (interface{}, error) {
+				id := r.URL.Query().Get("plan")
+				if err := syncer.Approve(id); err != nil {
+					return nil, err
+				}
+				return map[string]string{"approved": id}, nil
+			})
+		}
+		if cfg.UniFi.Quarantine > 0 {
+			statusServer.Register("quarantine", func() interface{} { return syncer.QuarantinedRoutes() })
+			statusServer.RegisterAction("restore-quarantine", func(r *http.Request) (interface{}, error) {
+				return syncer.RestoreQuarantined()
+			})
+		}
+	}
+	logStartupBanner(newStartupBanner(cfg, controller, dnssd.MulticastInterfaces()))
+
+	sigChan := make(chan os.Signal, 1)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
+	reload := make(chan os.Signal, 1)
+	signal.Notify(reload, syscall.SIGHUP)
+
+	done := make(chan struct{})
+	giveUp := make(chan struct{})
+
+	if missing := statusServer.Undocumented(); len(missing) > 0 {
+		logger.Warn("Status API: %s missing from /api/openapi.json", strings.Join(missing, ", "))
+	}
+	// Long-running goroutines are restarted after a panic. Event subscriptions
+	// are taken outside, so a restarted goroutine keeps its queue.
+	supervise := func(name string, fn func()) {
+		go poller.Supervise(name, done, fn)
+	}
+	supervise("status API", func() { statusServer.Serve(cfg.StatusAddr, done) })
+	eventLog, eventRing, hookEvents := bus.Subscribe(64), bus.Subscribe(64), bus.Subscribe(64)
+	supervise("event log", func() { logEvents(eventLog) })
+	supervise("event history", func() { recent.Run(eventRing) })
+	supervise("hooks", func() { hooks.Run(cfg.Hooks, hookEvents) })
+	supervise("config reload", func() { runConfigReload(reload, live, st, syncer, syncs, done) })
+	if syncer != nil {
+		syncEvents := bus.Subscribe(64)
+		supervise("route sync", func() {
+			interval := func() time.Duration { return live.Get().UniFi.SyncInterval }
+			runRouteSync(st, syncer, syncEvents, syncs, interval, cfg.ChangeSettle, cfg.UniFi.SyncFailureLimit, giveUp, done)
+		})
+		if clients != nil {
+			supervise("client table", func() { pollClientTable(st, clients, cfg.UniFi.ClientRefresh, done) })
+		}
+	}
+	if exp := exporter.New(cfg.Export); exp != nil {
+		logger.Info("Exporting routes as %s to %s", strings.Join(cfg.Export.Formats, ", "), cfg.Export.Dir)
+		exportEvents := bus.Subscribe(64)
+		supervise("route export", func() { runRouteExport(st, exp, exportEvents, cfg.ChangeSettle, done) })
+	}
+	if cfg.Discovery.RoundWindow > 0 {
+		logger.Info("Starting Thread Border Router and Matter device discovery...")
+		supervise("network discovery", func() { discovery.BrowseNetwork(st, browser, ouis, cfg.Discovery.RoundWindow, done) })
+	} else {
+		supervise("border router discovery", func() { monitorThreadBorderRouters(st, browser, done) })
+		supervise("Matter device discovery", func() { discovery.BrowseMatterDevices(st, browser, ouis, done) })
+	}
+	supervise("TREL peer discovery", func() { discovery.BrowseTRELPeers(st, browser, done) })
+	if cfg.Discovery.Commissionable {
+		startCommissioning(statusServer, st, browser, ouis, cfg.Discovery, done)
+	}
+	if cfg.MDNSAdvertise {
+		startPresence(statusServer, browser, cfg.StatusAddr, done)
+	}
+	st.ConfigureNAT64(cfg.Discovery.NAT64Prefixes)
+	st.ConfigurePrefixConflicts(cfg.PrefixConflictPolicy)
+	st.ConfigureRouteMode(cfg.UniFi.RouteMode)
+	st.ConfigureOverrides(cfg.Overrides)
+	st.ConfigureNextHops(cfg.NextHops)
+	st.ConfigureIPv4OnlyWarning(cfg.WarnIPv4Only)
+	if cfg.Discovery.NAT64Detect {
+		if err := discovery.ListenPREF64(st, done); err != nil {
+			logger.Warn("NAT64 prefix detection unavailable (needs CAP_NET_RAW): %v", err)
+		}
+	}
+	if cfg.Discovery.RouteLifetimes {
+		if err := discovery.ListenRouteLifetimes(st, done); err != nil {
+			logger.Warn("Route lifetime detection unavailable (needs CAP_NET_RAW): %v", err)
+		}
+	}
+	if cfg.NDProxyInterface != "" {
+		startNDProxy(st, cfg.NDProxyInterface, done)
+	}
+	if cfg.DNSZone.Addr != "" {
+		startDNSZone(statusServer, st, cfg.DNSZone, done)
+	}
+	if len(cfg.Discovery.ReflectorInterfaces) > 0 {
+		startReflector(cfg.Discovery, done)
+	}
+	if len(cfg.Discovery.SRPServices) > 0 {
+		supervise("SRP service discovery", func() { discovery.BrowseSRPServices(st, browser, cfg.Discovery.SRPServices, done) })
+	}
+	supervise("Home Assistant datasets", func() { discovery.PollHomeAssistant(st, cfg.HomeAssistant, done) })
+	supervise("Home Assistant state", func() {
+		hassio.Publish(cfg.HomeAssistant, time.Minute, func() hassio.Entity { return haEntity(st, syncer) }, done)
+	})
+	supervise("state refresh", func() { periodicRefresh(st, live, done) })
+	if cfg.UpdateCheck {
+		supervise("update check", func() { version.CheckForUpdates(done, 24*time.Hour) })
+	}
+
+	ticker := time.NewTicker(30 * time.Second)
+	defer ticker.Stop()
+
+	shutdown := func() {
+		defer logger.FlushRepeats()
+		close(done)
+		if exportState != "" {
+			if err := st.ExportFile(exportState); err != nil {
+				logger.Error("Failed to export state: %v", err)
+			} else {
+				logger.Info("Exported state to %s", exportState)
+			}
+		}
+	}
+
+	for {
+		select {
+		case <-ticker.C:
+			displayCurrentState(st, syncer)
+		case sig := <-sigChan:
+			logger.Info("Received signal %v, shutting down", sig)
+			shutdown()
+			return
+		case <-stop:
+			logger.Info("Service stop requested, shutting down")
+			shutdown()
+			return
+		case <-giveUp:
+			logger.Error("Exiting with code %d after %d consecutive failed syncs (SYNC_FAILURE_LIMIT)",
+				exitSyncFailures, cfg.UniFi.SyncFailureLimit)
+			shutdown()
+			os.Exit(exitSyncFailures)
+		}
+	}
+}
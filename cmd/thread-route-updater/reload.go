@@ -0,0 +1,83 @@
+package main
+
+import (
+	"os"
+	"strings"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/config"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/logger"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/state"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/unifi"
+)
+
+// configFile returns the env file named by CONFIG_FILE, empty when unset.
+func configFile() string {
+	return os.Getenv("CONFIG_FILE")
+}
+
+// runConfigReload reloads the configuration on every signal from reload,
+// then queues a full resync, until done is closed. Reloads run here rather
+// than on the main loop, as applying them waits for a running sync to finish.
+// Signals arriving during a reload are merged into one more reload.
+func runConfigReload(reload <-chan os.Signal, live *config.Live, st *state.State, syncer *unifi.Syncer,
+	syncs *syncRequests, done <-chan struct{}) {
+	for {
+		select {
+		case <-reload:
+			logger.Info("Received SIGHUP, reloading configuration")
+			reloadConfig(live, st, syncer)
+			syncs.request(true)
+		case <-done:
+			return
+		}
+	}
+}
+
+// reloadConfig re-reads the config file on SIGHUP and applies the settings
+// that can change while running. Changed settings that need a restart are
+// logged and keep their running values. syncer is nil when UniFi integration
+// is disabled.
+func reloadConfig(live *config.Live, st *state.State, syncer *unifi.Syncer) {
+	path := configFile()
+	if path == "" {
+		logger.Warn("Config reload: CONFIG_FILE is not set, nothing to re-read")
+		return
+	}
+	if err := config.LoadFile(path); err != nil {
+		logger.Error("Config reload failed, keeping the running configuration: %v", err)
+		return
+	}
+
+	level := logger.CurrentLevel()
+	logger.InitLevel()
+	if next := logger.CurrentLevel(); next != level {
+		logger.Info("Config reload: log level %s -> %s", level, next)
+	}
+
+	current := live.Get()
+	loaded := config.Load()
+	next := current.WithLive(loaded)
+	applied := config.Diff(current, next)
+	restart := config.Diff(next, loaded)
+
+	st.ConfigureLimits(next.Tracking)
+	st.ConfigurePrefixConflicts(next.PrefixConflictPolicy)
+	st.ConfigureNAT64(next.Discovery.NAT64Prefixes)
+	st.ConfigureOverrides(next.Overrides)
+	st.ConfigureNextHops(next.NextHops)
+	st.ConfigureIPv4OnlyWarning(next.WarnIPv4Only)
+	if syncer != nil {
+		syncer.Reconfigure(next.Grace(), next.UniFi.Limits, next.UniFi.SyncLog)
+	}
+	live.Set(next)
+
+	switch {
+	case len(applied) == 0 && len(restart) == 0:
+		logger.Info("Config reload: no changes in %s", path)
+	case len(applied) > 0:
+		logger.Info("Config reload: applied %s", strings.Join(applied, ", "))
+	}
+	if len(restart) > 0 {
+		logger.Warn("Config reload: %s changed but need a restart to take effect", strings.Join(restart, ", "))
+	}
+}
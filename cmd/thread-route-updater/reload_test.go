@@ -0,0 +1,89 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/config"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/state"
+)
+
+// TestReloadConfig verifies a reload applies live settings from the config
+// file and keeps restart-only settings at their running values.
+func TestReloadConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.env")
+	t.Setenv("CONFIG_FILE", path)
+	t.Cleanup(func() {
+		// Restore the variables the file set.
+		_ = os.WriteFile(path, nil, 0o600)
+		_ = config.LoadFile(path)
+	})
+
+	live := config.NewLive(config.Load())
+	content := "ROUTE_GRACE_PERIOD=1h\nSYNC_INTERVAL=2m\nSTATUS_ADDR=:9090\n"
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	reloadConfig(live, state.New(nil), nil)
+
+	cfg := live.Get()
+	if cfg.RouteGracePeriod != time.Hour {
+		t.Errorf("Expected the grace period to be reloaded, got %s", cfg.RouteGracePeriod)
+	}
+	if cfg.UniFi.SyncInterval != 2*time.Minute {
+		t.Errorf("Expected the sync interval to be reloaded, got %s", cfg.UniFi.SyncInterval)
+	}
+	if cfg.StatusAddr != ":8080" {
+		t.Errorf("Expected STATUS_ADDR to wait for a restart, got %s", cfg.StatusAddr)
+	}
+}
+
+// TestReloadConfigBadFile verifies a broken config file leaves the running
+// configuration in place.
+func TestReloadConfigBadFile(t *testing.T) {
+	t.Setenv("CONFIG_FILE", filepath.Join(t.TempDir(), "missing.env"))
+	live := config.NewLive(config.Config{RouteGracePeriod: 10 * time.Minute})
+	reloadConfig(live, state.New(nil), nil)
+	if got := live.Get().RouteGracePeriod; got != 10*time.Minute {
+		t.Errorf("Expected the running grace period to be kept, got %s", got)
+	}
+}
+
+// TestRunConfigReload verifies SIGHUP is handled off the main loop and queues
+// a full resync.
+func TestRunConfigReload(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.env")
+	t.Setenv("CONFIG_FILE", path)
+	t.Cleanup(func() {
+		_ = os.WriteFile(path, nil, 0o600)
+		_ = config.LoadFile(path)
+	})
+	if err := os.WriteFile(path, []byte("ROUTE_GRACE_PERIOD=1h\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	live := config.NewLive(config.Load())
+	syncs := newSyncRequests(nil)
+	reload := make(chan os.Signal, 1)
+	done := make(chan struct{})
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		runConfigReload(reload, live, state.New(nil), nil, syncs, done)
+	}()
+	reload <- syscall.SIGHUP
+
+	select {
+	case <-syncs.C():
+	case <-time.After(5 * time.Second):
+		t.Fatal("Expected a resync to be queued after the reload")
+	}
+	if got := live.Get().RouteGracePeriod; got != time.Hour {
+		t.Errorf("Expected the grace period to be reloaded, got %s", got)
+	}
+	close(done)
+	<-stopped
+}
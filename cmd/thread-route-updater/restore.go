@@ -0,0 +1,78 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/config"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/logger"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/unifi"
+)
+
+// runRestoreBackup implements "thread-route-updater restore-backup": it
+// recreates the static routes of the route backup that are missing from the
+// controller, printing each one to stdout. With --dry-run it only prints them.
+// Existing routes are never changed or deleted. It returns the exit code: 1
+// when the backup cannot be read or a route could not be recreated.
+func runRestoreBackup(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("restore-backup", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	dryRun := fs.Bool("dry-run", false, "print the routes that would be recreated without changing anything")
+	file := fs.String("file", "", "backup to restore; default ROUTE_BACKUP_FILE")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	if path := configFile(); path != "" {
+		if err := config.LoadFile(path); err != nil {
+			logger.Error("%v", err)
+			return 1
+		}
+	}
+	logger.InitLevel()
+	cfg := config.Load()
+
+	path := *file
+	if path == "" {
+		path = cfg.UniFi.BackupFile
+	}
+	if path == "" {
+		fmt.Fprintln(stderr, "restore-backup: ROUTE_BACKUP_FILE is off, pass --file")
+		return 1
+	}
+	backup, err := unifi.LoadBackup(path)
+	if err != nil {
+		fmt.Fprintf(stderr, "restore-backup: %v\n", err)
+		return 1
+	}
+	fmt.Fprintf(stdout, "Backup of %s taken %s with %d static routes\n",
+		backup.Controller, backup.Created.Format("2006-01-02 15:04:05 MST"), len(backup.Routes))
+
+	result, err := unifi.NewClient(cfg.UniFi).RestoreBackup(backup, *dryRun)
+	printRestore(stdout, *dryRun, result)
+	for _, err := range result.Failures {
+		fmt.Fprintf(stderr, "restore-backup: failed to recreate %v\n", err)
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "restore-backup: controller: %v\n", err)
+	}
+	if err != nil || len(result.Failures) > 0 {
+		return 1
+	}
+	return 0
+}
+
+// printRestore prints the routes recreated on the controller.
+func printRestore(w io.Writer, dryRun bool, result unifi.RestoreResult) {
+	verb := "Restored"
+	if dryRun {
+		verb = "Would restore"
+	}
+	for _, r := range result.Routes {
+		fmt.Fprintf(w, "%s route %s via %s (%s)\n", verb, r.StaticRouteNetwork, r.StaticRouteNexthop, r.Name)
+	}
+	if len(result.Routes) == 0 {
+		fmt.Fprintln(w, "Every backed-up route is on the controller")
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/config"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/discovery"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/logger"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/state"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/unifi"
+)
+
+// syncRequests queues syncs asked for outside the sync loop, by SIGHUP or the
+// status API. Requests arriving while one is queued are merged into it, and a
+// merged request is full when any of them was.
+type syncRequests struct {
+	mu   sync.Mutex
+	full bool
+	c    chan struct{}
+	// rediscover runs an active discovery burst before a full resync; nil skips it.
+	rediscover func()
+}
+
+func newSyncRequests(rediscover func()) *syncRequests {
+	return &syncRequests{c: make(chan struct{}, 1), rediscover: rediscover}
+}
+
+// request queues a sync. A full one first rediscovers the network and drops
+// the syncer's caches, so the plan is recomputed from scratch.
+func (r *syncRequests) request(full bool) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	r.full = r.full || full
+	r.mu.Unlock()
+	select {
+	case r.c <- struct{}{}:
+	default:
+	}
+}
+
+// C returns the channel that fires when a sync was requested, or nil for a
+// nil r so a select never picks it.
+func (r *syncRequests) C() <-chan struct{} {
+	if r == nil {
+		return nil
+	}
+	return r.c
+}
+
+// take dequeues the requested sync after C fired, reporting whether it is full.
+func (r *syncRequests) take() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	full := r.full
+	r.full = false
+	return full
+}
+
+// run performs the requested sync after C fired.
+func (r *syncRequests) run(st *state.State, syncer *unifi.Syncer) {
+	if r.take() {
+		logger.Info("UniFi: full resync requested, rediscovering and reconciling every route")
+		if r.rediscover != nil {
+			r.rediscover()
+		}
+		syncer.DropCaches()
+	} else {
+		logger.Debug("UniFi: sync requested")
+	}
+	syncRoutes(st, syncer)
+}
+
+// rediscover returns a function querying for border routers and Matter
+// devices for one discovery window, recording the answers as one round once
+// it closed, so a full resync starts from answers rather than cached
+// announcements.
+func rediscover(st *state.State, browser discovery.Browser, ouis discovery.OUIDatabase, cfg config.Discovery) func() {
+	return func() {
+		window := discoveryWindow(cfg)
+		burst := make(chan struct{})
+		timer := time.AfterFunc(window, func() { close(burst) })
+		defer timer.Stop()
+		discovery.BrowseNetwork(st, browser, ouis, window, burst)
+	}
+}
+
+// discoveryWindow is how long a discovery burst browses: the discovery
+// timeout, or 3 seconds when none is configured.
+func discoveryWindow(cfg config.Discovery) time.Duration {
+	if cfg.Timeout > 0 {
+		return cfg.Timeout
+	}
+	return 3 * time.Second
+}
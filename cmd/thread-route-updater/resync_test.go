@@ -0,0 +1,38 @@
+package main
+
+import "testing"
+
+// TestSyncRequestsMerge verifies requests queued before the sync loop picks
+// them up run as one sync, full when any of them asked for it.
+func TestSyncRequestsMerge(t *testing.T) {
+	r := newSyncRequests(nil)
+	r.request(false)
+	r.request(true)
+	r.request(false)
+
+	select {
+	case <-r.C():
+	default:
+		t.Fatal("Expected a queued sync")
+	}
+	if !r.take() {
+		t.Error("Expected the merged sync to be full")
+	}
+	select {
+	case <-r.C():
+		t.Error("Expected the requests merged into one sync")
+	default:
+	}
+
+	r.request(false)
+	<-r.C()
+	if r.take() {
+		t.Error("Expected a plain sync after the full one ran")
+	}
+
+	var none *syncRequests
+	none.request(true)
+	if none.C() != nil {
+		t.Error("Expected no channel without a syncer")
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+const (
+	serviceName        = "thread-route-updater"
+	serviceDisplayName = "Thread Route Updater"
+	serviceDescription = "Keeps UniFi static routes to Thread networks up to date"
+)
+
+// runService implements -service: "install" registers the daemon with the
+// platform's service manager (the Windows service control manager or launchd),
+// "uninstall" removes it again and "run" is what the service manager starts.
+// The installed service runs with the -config, -export-state and
+// -import-state files given at install time, made absolute, since service
+// managers start it in another directory and without the shell environment.
+// It returns the exit code.
+func runService(action, exportState, importState string, stdout, stderr io.Writer) int {
+	var err error
+	switch action {
+	case "install":
+		var exe string
+		var args []string
+		if exe, err = os.Executable(); err == nil {
+			args, err = serviceArgs(configFile(), exportState, importState)
+		}
+		if err == nil {
+			err = installService(exe, args)
+		}
+		if err == nil {
+			fmt.Fprintf(stdout, "Installed service %s: %s %v\n", serviceName, exe, args)
+		}
+	case "uninstall":
+		if err = uninstallService(); err == nil {
+			fmt.Fprintf(stdout, "Removed service %s\n", serviceName)
+		}
+	case "run":
+		err = runAsService(func(stop <-chan struct{}) {
+			runDaemon(exportState, importState, stop)
+		})
+	default:
+		fmt.Fprintf(stderr, "service: unknown action %q, expected install, uninstall or run\n", action)
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintf(stderr, "service %s: %v\n", action, err)
+		return 1
+	}
+	return 0
+}
+
+// serviceArgs returns the arguments the service manager starts the daemon
+// with, carrying over the config and state files as absolute paths.
+func serviceArgs(configPath, exportState, importState string) ([]string, error) {
+	args := []string{"-service", "run"}
+	for _, f := range []struct{ flag, path string }{
+		{"-config", configPath},
+		{"-export-state", exportState},
+		{"-import-state", importState},
+	} {
+		if f.path == "" {
+			continue
+		}
+		abs, err := filepath.Abs(f.path)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, f.flag, abs)
+	}
+	return args, nil
+}
@@ -0,0 +1,96 @@
+//go:build darwin
+
+package main
+
+import (
+	"fmt"
+	"log/syslog"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/config"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/logger"
+)
+
+// launchdPlistPath returns the path of the agent's property list.
+func launchdPlistPath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, "Library", "LaunchAgents", launchdLabel+".plist"), nil
+}
+
+// launchdDomain is the launchd domain of the current user's agents.
+func launchdDomain() string {
+	return fmt.Sprintf("gui/%d", os.Getuid())
+}
+
+// installService writes the launchd agent for exe, running in the state
+// directory, and loads it.
+func installService(exe string, args []string) error {
+	path, err := launchdPlistPath()
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("%s already exists", path)
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	dir := config.StateDir()
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, launchdPlist(exe, args, dir), 0o644); err != nil {
+		return err
+	}
+	if out, err := exec.Command("launchctl", "bootstrap", launchdDomain(), path).CombinedOutput(); err != nil {
+		return fmt.Errorf("launchctl bootstrap: %v: %s", err, out)
+	}
+	return nil
+}
+
+// uninstallService unloads the launchd agent and removes its property list.
+func uninstallService() error {
+	path, err := launchdPlistPath()
+	if err != nil {
+		return err
+	}
+	if _, err := os.Stat(path); err != nil {
+		return fmt.Errorf("service %s is not installed: %w", serviceName, err)
+	}
+	if out, err := exec.Command("launchctl", "bootout", launchdDomain(), path).CombinedOutput(); err != nil {
+		logger.Warn("launchctl bootout: %v: %s", err, out)
+	}
+	return os.Remove(path)
+}
+
+// runAsService runs the daemon under launchd, logging to syslog, which macOS
+// records in the unified log. launchd stops the agent with SIGTERM, which the
+// daemon handles itself.
+func runAsService(run func(stop <-chan struct{})) error {
+	w, err := syslog.New(syslog.LOG_DAEMON|syslog.LOG_INFO, serviceName)
+	if err != nil {
+		logger.Warn("syslog unavailable, logging to standard error: %v", err)
+	} else {
+		defer func() { _ = w.Close() }()
+		logger.SetSink(func(level logger.Level, msg string) {
+			switch level {
+			case logger.ERROR:
+				_ = w.Err(msg)
+			case logger.WARN:
+				_ = w.Warning(msg)
+			case logger.DEBUG:
+				_ = w.Debug(msg)
+			default:
+				_ = w.Info(msg)
+			}
+		})
+		defer logger.SetSink(nil)
+	}
+	run(nil)
+	return nil
+}
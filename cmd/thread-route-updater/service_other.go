@@ -0,0 +1,17 @@
+//go:build !windows && !darwin
+
+package main
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// errServiceUnsupported is returned on platforms without a -service backend.
+var errServiceUnsupported = fmt.Errorf("not supported on %s; run the daemon under systemd, Docker or Kubernetes", runtime.GOOS)
+
+func installService(exe string, args []string) error { return errServiceUnsupported }
+
+func uninstallService() error { return errServiceUnsupported }
+
+func runAsService(run func(stop <-chan struct{})) error { return errServiceUnsupported }
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestServiceArgs(t *testing.T) {
+	wd, err := filepath.Abs(".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	args, err := serviceArgs("config.env", "", "/var/lib/state.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"-service", "run", "-config", filepath.Join(wd, "config.env"), "-import-state", "/var/lib/state.json"}
+	if !slices.Equal(args, want) {
+		t.Errorf("Expected %v, got %v", want, args)
+	}
+}
+
+func TestRunServiceUnknownAction(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := runService("restart", "", "", &stdout, &stderr); code != 2 {
+		t.Errorf("Expected exit code 2, got %d", code)
+	}
+	if !strings.Contains(stderr.String(), `unknown action "restart"`) {
+		t.Errorf("Expected the action reported, got %q", stderr.String())
+	}
+}
@@ -0,0 +1,122 @@
+//go:build windows
+
+package main
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/windows/svc"
+	"golang.org/x/sys/windows/svc/eventlog"
+	"golang.org/x/sys/windows/svc/mgr"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/logger"
+)
+
+// installService registers exe as an automatically started Windows service
+// and the service name as an Event Log source.
+func installService(exe string, args []string) error {
+	m, err := mgr.Connect()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = m.Disconnect() }()
+
+	if s, err := m.OpenService(serviceName); err == nil {
+		_ = s.Close()
+		return fmt.Errorf("service %s already exists", serviceName)
+	}
+	s, err := m.CreateService(serviceName, exe, mgr.Config{
+		DisplayName: serviceDisplayName,
+		Description: serviceDescription,
+		StartType:   mgr.StartAutomatic,
+	}, args...)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = s.Close() }()
+
+	if err := eventlog.InstallAsEventCreate(serviceName, eventlog.Error|eventlog.Warning|eventlog.Info); err != nil {
+		_ = s.Delete()
+		return fmt.Errorf("event log source: %w", err)
+	}
+	return nil
+}
+
+// uninstallService stops and deletes the service and its Event Log source.
+func uninstallService() error {
+	m, err := mgr.Connect()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = m.Disconnect() }()
+
+	s, err := m.OpenService(serviceName)
+	if err != nil {
+		return fmt.Errorf("service %s is not installed: %w", serviceName, err)
+	}
+	defer func() { _ = s.Close() }()
+	_, _ = s.Control(svc.Stop)
+	if err := s.Delete(); err != nil {
+		return err
+	}
+	_ = eventlog.Remove(serviceName)
+	return nil
+}
+
+// runAsService runs the daemon under the service control manager, logging to
+// the Event Log until the service is stopped.
+func runAsService(run func(stop <-chan struct{})) error {
+	elog, err := eventlog.Open(serviceName)
+	if err != nil {
+		return fmt.Errorf("event log: %w", err)
+	}
+	defer func() { _ = elog.Close() }()
+	logger.SetSink(func(level logger.Level, msg string) {
+		switch level {
+		case logger.ERROR:
+			_ = elog.Error(1, msg)
+		case logger.WARN:
+			_ = elog.Warning(1, msg)
+		default:
+			_ = elog.Info(1, msg)
+		}
+	})
+	defer logger.SetSink(nil)
+
+	return svc.Run(serviceName, windowsService{run: run})
+}
+
+// windowsService adapts the daemon to the service control manager.
+type windowsService struct {
+	run func(stop <-chan struct{})
+}
+
+// Execute runs the daemon and closes its stop channel on a stop or shutdown
+// request, reporting the service stopped once the daemon has shut down.
+func (w windowsService) Execute(args []string, requests <-chan svc.ChangeRequest, status chan<- svc.Status) (bool, uint32) {
+	status <- svc.Status{State: svc.StartPending}
+	stop := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		w.run(stop)
+		close(finished)
+	}()
+	status <- svc.Status{State: svc.Running, Accepts: svc.AcceptStop | svc.AcceptShutdown}
+
+	for {
+		select {
+		case <-finished:
+			return false, 0
+		case req := <-requests:
+			switch req.Cmd {
+			case svc.Interrogate:
+				status <- req.CurrentStatus
+			case svc.Stop, svc.Shutdown:
+				status <- svc.Status{State: svc.StopPending}
+				close(stop)
+				<-finished
+				return false, 0
+			}
+		}
+	}
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/config"
+)
+
+// settler coalesces a burst of topology changes, such as a border router
+// rebooting and re-announcing everything, into one application of routes. It
+// fires once no change arrived for cfg.Quiet, or cfg.Max after the first change
+// of the burst so a constant trickle of changes cannot hold routes back forever.
+type settler struct {
+	cfg     config.Settle
+	timer   *time.Timer
+	first   time.Time
+	changes int
+}
+
+// change records a topology change at now and rearms the timer.
+func (s *settler) change(now time.Time) {
+	if s.timer == nil {
+		s.first = now
+	}
+	s.changes++
+	wait := s.cfg.Quiet
+	if s.cfg.Max > 0 {
+		wait = min(wait, s.first.Add(s.cfg.Max).Sub(now))
+	}
+	wait = max(wait, 0)
+	if s.timer == nil {
+		s.timer = time.NewTimer(wait)
+		return
+	}
+	s.timer.Reset(wait)
+}
+
+// C returns the channel that fires once pending changes settled, or nil while
+// none are pending so a select never picks it.
+func (s *settler) C() <-chan time.Time {
+	if s.timer == nil {
+		return nil
+	}
+	return s.timer.C
+}
+
+// pending reports whether changes are waiting to settle.
+func (s *settler) pending() bool {
+	return s.timer != nil
+}
+
+// settled resets the settler after C fired and returns how many changes the
+// burst coalesced.
+func (s *settler) settled() int {
+	n := s.changes
+	s.timer, s.changes = nil, 0
+	return n
+}
+
+// stop releases the timer of pending changes.
+func (s *settler) stop() {
+	if s.timer != nil {
+		s.timer.Stop()
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/config"
+)
+
+// TestSettlerWaitsForQuiet verifies a burst of changes fires once, after the
+// quiet period following its last change.
+func TestSettlerWaitsForQuiet(t *testing.T) {
+	s := &settler{cfg: config.Settle{Quiet: 100 * time.Millisecond, Max: time.Minute}}
+	defer s.stop()
+	if s.C() != nil || s.pending() {
+		t.Fatal("Expected an idle settler to have no channel")
+	}
+
+	start := time.Now()
+	for range 4 {
+		s.change(time.Now())
+		time.Sleep(40 * time.Millisecond)
+	}
+	<-s.C()
+	if elapsed := time.Since(start); elapsed < 200*time.Millisecond {
+		t.Errorf("Expected the settler to wait for the burst to end, fired after %s", elapsed)
+	}
+	if n := s.settled(); n != 4 {
+		t.Errorf("Expected 4 coalesced changes, got %d", n)
+	}
+	if s.C() != nil || s.pending() {
+		t.Error("Expected the settler to be idle after settling")
+	}
+}
+
+// TestSettlerMaxWait verifies a steady stream of changes cannot delay the
+// settler past its maximum wait.
+func TestSettlerMaxWait(t *testing.T) {
+	s := &settler{cfg: config.Settle{Quiet: 100 * time.Millisecond, Max: 200 * time.Millisecond}}
+	defer s.stop()
+
+	start := time.Now()
+	s.change(start)
+	ticker := time.NewTicker(20 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			s.change(time.Now())
+			continue
+		case <-s.C():
+		case <-time.After(2 * time.Second):
+			t.Fatal("Expected the settler to fire at its maximum wait")
+		}
+		break
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Expected the settler to fire after about 200ms, fired after %s", elapsed)
+	}
+}
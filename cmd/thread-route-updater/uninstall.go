@@ -0,0 +1,117 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/config"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/exporter"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/logger"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/unifi"
+)
+
+// runUninstall implements "thread-route-updater uninstall": it removes every
+// route and firewall rule the daemon created from the controller, then the
+// local files it wrote, printing each item removed to stdout. With --dry-run
+// it only prints what would be removed. It returns the exit code: 1 when
+// anything could not be removed. The daemon should be stopped first, or it
+// recreates the routes on its next sync.
+func runUninstall(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("uninstall", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	dryRun := fs.Bool("dry-run", false, "print what would be removed without removing anything")
+	stateFile := fs.String("state", "", "also remove this state file, as written by --export-state")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	if path := configFile(); path != "" {
+		if err := config.LoadFile(path); err != nil {
+			logger.Error("%v", err)
+			return 1
+		}
+	}
+	logger.InitLevel()
+	cfg := config.Load()
+
+	verb := "Removed"
+	if *dryRun {
+		verb = "Would remove"
+	}
+	failed := false
+	if cfg.UniFi.Enabled {
+		result, err := unifi.NewClient(cfg.UniFi).Teardown(*dryRun)
+		printTeardown(stdout, verb, result)
+		for _, err := range result.Failures {
+			fmt.Fprintf(stderr, "uninstall: failed to remove %v\n", err)
+		}
+		if err != nil {
+			fmt.Fprintf(stderr, "uninstall: controller: %v\n", err)
+		}
+		failed = err != nil || len(result.Failures) > 0
+	} else {
+		fmt.Fprintln(stdout, "UniFi integration is disabled, leaving the controller alone")
+	}
+
+	for _, err := range removeLocalFiles(stdout, verb, localFiles(cfg, *stateFile), *dryRun) {
+		fmt.Fprintf(stderr, "uninstall: %v\n", err)
+		failed = true
+	}
+	if failed {
+		return 1
+	}
+	return 0
+}
+
+// printTeardown prints the routes and firewall rules removed from the controller.
+func printTeardown(w io.Writer, verb string, result unifi.TeardownResult) {
+	for _, r := range result.Routes {
+		fmt.Fprintf(w, "%s route %s via %s (%s)\n", verb, r.StaticRouteNetwork, r.StaticRouteNexthop, r.Name)
+	}
+	for _, r := range result.FirewallRules {
+		fmt.Fprintf(w, "%s firewall rule %q\n", verb, r.Name)
+	}
+}
+
+// localFiles returns the files the daemon wrote under cfg: route plans,
+// exported route files, the controller traffic recording and, when given, the
+// state file.
+func localFiles(cfg config.Config, stateFile string) []string {
+	var files []string
+	if cfg.UniFi.PlanDir != "" {
+		plans, err := unifi.PlanFiles(cfg.UniFi.PlanDir)
+		if err != nil {
+			logger.Warn("Failed to list route plans in %s: %v", cfg.UniFi.PlanDir, err)
+		}
+		files = append(files, plans...)
+	}
+	files = append(files, exporter.Files(cfg.Export)...)
+	if cfg.UniFi.RecordFile != "" {
+		files = append(files, cfg.UniFi.RecordFile)
+	}
+	if stateFile != "" {
+		files = append(files, stateFile)
+	}
+	return files
+}
+
+// removeLocalFiles removes the files that exist, printing each one, and
+// returns the errors of those that could not be removed.
+func removeLocalFiles(w io.Writer, verb string, files []string, dryRun bool) []error {
+	var errs []error
+	for _, path := range files {
+		if _, err := os.Stat(path); os.IsNotExist(err) {
+			continue
+		}
+		if !dryRun {
+			if err := os.Remove(path); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+		}
+		fmt.Fprintf(w, "%s file %s\n", verb, path)
+	}
+	return errs
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/config"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/unifi"
+)
+
+// TestUninstallLocalFiles verifies the plans, exported route files, traffic
+// recording and state file are removed, files that don't exist are skipped and
+// others are kept.
+func TestUninstallLocalFiles(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte("x"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	plan := write("plan-20250907T005838Z-abc.json")
+	radvd := write("thread-routes.radvd.conf")
+	state := write("state.json")
+	record := write("controller.jsonl")
+	other := write("notes.txt")
+
+	cfg := config.Config{
+		UniFi:  config.UniFi{PlanDir: dir, RecordFile: record},
+		Export: config.RouteExport{Dir: dir, Formats: []string{"radvd", "bird"}},
+	}
+	files := localFiles(cfg, state)
+
+	var dry bytes.Buffer
+	if errs := removeLocalFiles(&dry, "Would remove", files, true); len(errs) != 0 {
+		t.Fatalf("Expected no errors, got %v", errs)
+	}
+	if _, err := os.Stat(plan); err != nil {
+		t.Errorf("Expected a dry run to keep the files, got %v", err)
+	}
+
+	var out bytes.Buffer
+	if errs := removeLocalFiles(&out, "Removed", files, false); len(errs) != 0 {
+		t.Fatalf("Expected no errors, got %v", errs)
+	}
+	for _, path := range []string{plan, radvd, record, state} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("Expected %s removed, got %v", path, err)
+		}
+		if !strings.Contains(out.String(), "Removed file "+path) {
+			t.Errorf("Expected %s reported, got %q", path, out.String())
+		}
+	}
+	if strings.Contains(out.String(), "bird") {
+		t.Errorf("Expected the missing bird file skipped, got %q", out.String())
+	}
+	if dry.String() != strings.ReplaceAll(out.String(), "Removed", "Would remove") {
+		t.Errorf("Expected the dry run to list the same files, got %q", dry.String())
+	}
+	if _, err := os.Stat(other); err != nil {
+		t.Errorf("Expected unrelated files kept, got %v", err)
+	}
+}
+
+func TestPrintTeardown(t *testing.T) {
+	var out bytes.Buffer
+	printTeardown(&out, "Removed", unifi.TeardownResult{
+		Routes:        []unifi.StaticRoute{{Name: "Thread route via Kitchen", StaticRouteNetwork: "fd00:1::/64", StaticRouteNexthop: "2001:4860::1"}},
+		FirewallRules: []unifi.FirewallRule{{Name: "Thread firewall for fd00:1::/64"}},
+	})
+	want := "Removed route fd00:1::/64 via 2001:4860::1 (Thread route via Kitchen)\n" +
+		"Removed firewall rule \"Thread firewall for fd00:1::/64\"\n"
+	if out.String() != want {
+		t.Errorf("Expected %q, got %q", want, out.String())
+	}
+}
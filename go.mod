@@ -1,15 +1,18 @@
-module unifi-thread-route-updater
+module github.com/rafaelgaspar/unifi-thread-route-updater
 
 go 1.26.4
 
-require github.com/grandcat/zeroconf v1.0.0
+require (
+	github.com/godbus/dbus/v5 v5.2.2
+	github.com/grandcat/zeroconf v1.0.0
+	github.com/miekg/dns v1.1.72
+	golang.org/x/net v0.56.0
+	golang.org/x/sys v0.46.0
+)
 
 require (
 	github.com/cenkalti/backoff v2.2.1+incompatible // indirect
-	github.com/miekg/dns v1.1.72 // indirect
 	golang.org/x/mod v0.31.0 // indirect
-	golang.org/x/net v0.56.0 // indirect
 	golang.org/x/sync v0.19.0 // indirect
-	golang.org/x/sys v0.46.0 // indirect
 	golang.org/x/tools v0.40.0 // indirect
 )
@@ -0,0 +1,89 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// AddonOptionsFile is where the Home Assistant Supervisor writes the options
+// of an add-on.
+const AddonOptionsFile = "/data/options.json"
+
+// supervisorCoreURL is the Home Assistant Core API as proxied by the Supervisor.
+const supervisorCoreURL = "http://supervisor/core"
+
+// InAddon reports whether the daemon runs as a Home Assistant add-on: the
+// Supervisor passes every add-on a SUPERVISOR_TOKEN.
+func InAddon() bool {
+	return os.Getenv("SUPERVISOR_TOKEN") != ""
+}
+
+// LoadAddonOptions sets environment variables from the add-on options at path,
+// like LoadFile does for an env file. Each option is named after its variable
+// in lower case (ubiquity_username sets UBIQUITY_USERNAME); lists are joined
+// with commas, and null or empty options are left unset. HA_URL and HA_TOKEN
+// default to the Core API proxied by the Supervisor, and HA_PUBLISH_STATE to
+// true, so the add-on reads Thread datasets and publishes its state without
+// a long-lived access token. The route backup is kept in the add-on's /data.
+func LoadAddonOptions(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("add-on options: %w", err)
+	}
+	var options map[string]interface{}
+	if err := json.Unmarshal(data, &options); err != nil {
+		return fmt.Errorf("add-on options %s: %w", path, err)
+	}
+	values := make(map[string]string, len(options)+3)
+	for name, option := range options {
+		value, err := optionValue(option)
+		if err != nil {
+			return fmt.Errorf("add-on options %s: %s: %w", path, name, err)
+		}
+		if value != "" {
+			values[strings.ToUpper(name)] = value
+		}
+	}
+	defaults := map[string]string{
+		"HA_URL":           supervisorCoreURL,
+		"HA_TOKEN":         os.Getenv("SUPERVISOR_TOKEN"),
+		"HA_PUBLISH_STATE": "true",
+		"STATE_DIRECTORY":  "/data",
+	}
+	for key, value := range defaults {
+		if _, ok := values[key]; !ok && os.Getenv(key) == "" && value != "" {
+			values[key] = value
+		}
+	}
+	return setFileEnv(path, values)
+}
+
+// optionValue formats an add-on option as an environment variable value.
+func optionValue(option interface{}) (string, error) {
+	switch v := option.(type) {
+	case nil:
+		return "", nil
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case []interface{}:
+		items := make([]string, 0, len(v))
+		for _, item := range v {
+			s, err := optionValue(item)
+			if err != nil {
+				return "", err
+			}
+			if s != "" {
+				items = append(items, s)
+			}
+		}
+		return strings.Join(items, ","), nil
+	}
+	return "", fmt.Errorf("unsupported value %v", option)
+}
@@ -0,0 +1,55 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadAddonOptions(t *testing.T) {
+	t.Setenv("SUPERVISOR_TOKEN", "supervisor-token")
+	for _, key := range []string{"UBIQUITY_ENABLED", "UBIQUITY_USERNAME", "ROUTE_MAX_MANAGED", "DNSSD_DOMAINS", "ROUTE_PINS", "HA_URL", "HA_TOKEN", "HA_PUBLISH_STATE"} {
+		t.Setenv(key, "")
+		_ = os.Unsetenv(key)
+	}
+	t.Setenv("HA_PUBLISH_STATE", "false")
+	path := filepath.Join(t.TempDir(), "options.json")
+	options := `{"ubiquity_enabled": true, "ubiquity_username": "automation", "route_max_managed": 16,
+		"dnssd_domains": ["home.arpa", "lab.arpa"], "route_pins": null, "ha_token": ""}`
+	if err := os.WriteFile(path, []byte(options), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := LoadAddonOptions(path); err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range map[string]string{
+		"UBIQUITY_ENABLED":  "true",
+		"UBIQUITY_USERNAME": "automation",
+		"ROUTE_MAX_MANAGED": "16",
+		"DNSSD_DOMAINS":     "home.arpa,lab.arpa",
+		"HA_URL":            "http://supervisor/core",
+		"HA_TOKEN":          "supervisor-token",
+		"HA_PUBLISH_STATE":  "false", // set in the environment
+	} {
+		if got := os.Getenv(key); got != want {
+			t.Errorf("Expected %s=%q, got %q", key, want, got)
+		}
+	}
+	if _, set := os.LookupEnv("ROUTE_PINS"); set {
+		t.Error("Expected a null option left unset")
+	}
+}
+
+func TestLoadAddonOptionsInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "options.json")
+	if err := os.WriteFile(path, []byte(`{"route_pins": {"nested": true}}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadAddonOptions(path); err == nil {
+		t.Error("Expected an error for an object option")
+	}
+	if err := LoadAddonOptions(filepath.Join(t.TempDir(), "missing.json")); err == nil {
+		t.Error("Expected an error for a missing options file")
+	}
+}
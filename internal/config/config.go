@@ -0,0 +1,585 @@
+// Package config loads the daemon configuration from environment variables.
+package config
+
+import (
+	"fmt"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/logger"
+)
+
+// HomeAssistant holds configuration for the Home Assistant API
+type HomeAssistant struct {
+	URL         string
+	Token       string
+	InsecureSSL bool
+	// PublishState publishes the daemon's state as a sensor entity.
+	PublishState bool
+}
+
+// String returns the configuration with the token redacted, so it can be logged.
+func (h HomeAssistant) String() string {
+	type plain HomeAssistant
+	p := plain(h)
+	p.Token = RedactSecret(p.Token)
+	return fmt.Sprintf("%+v", p)
+}
+
+// StatusActions guards the status API's POST /actions endpoints.
+type StatusActions struct {
+	// Token is the bearer token actions require; empty admits loopback
+	// clients only.
+	Token string
+}
+
+// String returns the configuration with the token redacted, so it can be logged.
+func (a StatusActions) String() string {
+	return fmt.Sprintf("{Token:%s}", RedactSecret(a.Token))
+}
+
+// UniFi holds configuration for the UniFi controller API
+type UniFi struct {
+	RouterHostname string
+	Username       string
+	Password       string
+	APIBaseURL     string
+	InsecureSSL    bool
+	Enabled        bool
+	GatewayDevice  string
+	APIVersion     string // "auto", "v1" (legacy REST), "v2" or "traffic" (v2 traffic routes)
+	RouteMode      RouteMode
+	Limits         RouteLimits
+	Damping        RouteDamping
+	Firewall       Firewall
+	Logins         LoginBudget
+	// RouteInterface, when set, makes routes interface routes out of this
+	// network, by name or ID, instead of next hop routes.
+	RouteInterface string
+	// RouteFields set the distance and description of created routes.
+	RouteFields RouteFields
+	// GatewayRules pin the gateway device of routes to matching networks,
+	// overriding GatewayDevice and auto-detection.
+	GatewayRules []GatewayRule
+	// SyncLog is the sync log verbosity: "summary" logs one line per sync,
+	// "quiet" only for syncs that change something and "detail" adds a line
+	// per route change.
+	SyncLog string
+	// SyncInterval is how often routes are synced while no topology changes
+	// are settling, repairing drift on the controller.
+	SyncInterval time.Duration
+	// SyncFailureLimit is the number of consecutive failed syncs after which
+	// the daemon exits so its supervisor restarts it; 0 never exits.
+	SyncFailureLimit int
+	Approval         bool    // queue route changes until an operator approves them
+	RemovalWindows   Windows // when route removals may run; empty means always
+	// Quarantine is how long routes whose grace period passed stay disabled
+	// on the controller before they are deleted; 0 deletes them right away.
+	Quarantine time.Duration
+	// QueueExpiry is how long route operations computed while the controller
+	// is unreachable stay queued for when it returns; 0 disables the queue.
+	QueueExpiry time.Duration
+	PlanDir     string // directory receiving a JSON plan per sync with changes
+	PlanHistory int    // plan files kept in PlanDir
+	BackupFile  string // snapshot of the static routes taken before the first change; empty disables it
+	// BackupRequired refuses to change routes without a backup; set when
+	// ROUTE_BACKUP_FILE is, while the default path is best effort.
+	BackupRequired bool
+	RecordFile     string // debug bundle receiving the redacted controller traffic
+	ReplayFile     string // debug bundle answering controller requests instead of the controller
+	// FallbackHosts are further addresses of the controller, such as its LAN
+	// IP or VPN address, tried in order when RouterHostname is unreachable.
+	FallbackHosts []string
+	// ProxyURL is the proxy controller connections go through; when empty,
+	// HTTPS_PROXY and NO_PROXY from the environment apply.
+	ProxyURL string
+	// LegacyRouteNames are glob patterns of route names older releases or
+	// tools used for Thread routes; matching routes are adopted at startup.
+	LegacyRouteNames []string
+	// ClientRefresh is how often the client list is read to tell wired
+	// border routers from Wi-Fi ones; 0 never reads it.
+	ClientRefresh time.Duration
+}
+
+// String returns the configuration with the password redacted, so it can be logged.
+func (u UniFi) String() string {
+	type plain UniFi
+	p := plain(u)
+	p.Password = RedactSecret(p.Password)
+	return fmt.Sprintf("%+v", p)
+}
+
+// RedactSecret returns REDACTED for a set secret and "" for an unset one, so
+// logs still show whether it is configured.
+func RedactSecret(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return logger.Redacted
+}
+
+// RouteLimits caps how many routes the syncer manages and changes, so a discovery
+// glitch can't flood the controller. A zero limit disables that cap.
+type RouteLimits struct {
+	MaxRoutes         int // managed routes in total
+	MaxPerNetwork     int // managed routes to one Thread network
+	MaxAddsPerSync    int // routes added in one sync cycle
+	MaxDeletesPerSync int // routes deleted in one sync cycle
+}
+
+// Firewall configures the companion firewall rules accepting traffic to the
+// routed Thread networks, managed alongside the static routes.
+type Firewall struct {
+	Enabled   bool
+	Ruleset   string // e.g. "LANv6_IN"
+	RuleIndex int    // first rule index to use; managed rules take the lowest free ones
+}
+
+// LoginBudget caps the logins to the controller per hour, staying under the
+// thresholds at which UniFi OS locks the account out, UI included. A zero
+// limit disables that cap.
+type LoginBudget struct {
+	PerHour         int // login attempts in the last hour
+	FailuresPerHour int // failed login attempts in the last hour
+}
+
+// RouteDamping suppresses flapping routes like BGP route flap damping: every
+// addition or removal of a route adds a penalty of 1000, which halves every
+// HalfLife. A route whose penalty exceeds Suppress is not added again until it
+// has decayed below Reuse, or for at most MaxSuppress.
+type RouteDamping struct {
+	Enabled     bool
+	HalfLife    time.Duration
+	Suppress    int
+	Reuse       int
+	MaxSuppress time.Duration
+}
+
+// TrackingLimits bound the state kept by long-running instances; the least
+// recently seen entries are evicted first. A zero limit disables that bound.
+type TrackingLimits struct {
+	Routes      int // route keys tracked for grace periods and as programmed
+	DeviceAddrs int // addresses remembered per Matter device
+	// AddressHistory is the number of past IPv6 addresses kept per Matter
+	// device at /status/address_history.
+	AddressHistory int
+	// AddressHistoryAge is how long an address stays in the history after
+	// it was last announced, also once its device expired.
+	AddressHistoryAge time.Duration
+	// StabilityWindow is the rolling window of the mesh prefix availability
+	// statistics.
+	StabilityWindow time.Duration
+}
+
+// Discovery holds configuration for the DNS-SD discovery backend
+type Discovery struct {
+	Backend      string        // "zeroconf" (embedded mDNS), "unicast", "avahi" or "dnssd"
+	Mode         string        // "hybrid", "passive" (announcements only) or "active" (polling only)
+	Server       string        // unicast: DNS server or mDNS proxy as host:port
+	Domains      []string      // browse domains, e.g. "local." and a wide-area DNS-SD domain
+	PollInterval time.Duration // unicast and active mode: query interval
+	OUIFile      string        // IEEE OUI registry used to name device vendors
+	SRPServices  []string      // SRP-registered service types that keep known prefixes alive
+	// NAT64Prefixes are never routed, in addition to the well-known ones and
+	// those learned from router advertisements when NAT64Detect is set.
+	NAT64Prefixes []netip.Prefix
+	NAT64Detect   bool
+	// RouteLifetimes expires mesh prefixes with the route lifetimes border
+	// routers announce in router advertisements, instead of the grace period.
+	RouteLifetimes bool
+	// Timeout bounds each resolve or query; 0 uses the backend default.
+	Timeout time.Duration
+	// RenewInterval is how often passive browsing is renewed; 0 uses the backend default.
+	RenewInterval time.Duration
+	// RoundWindow is how long border router and Matter device sightings are
+	// collected before they are recorded together; 0 records each right away.
+	RoundWindow time.Duration
+	// ReflectorInterfaces are the interfaces or VLANs between which mDNS
+	// packets about ReflectorServices are relayed; fewer than two disables it.
+	ReflectorInterfaces []string
+	ReflectorServices   []string
+	// Commissionable browses _matterc._udp for Matter devices awaiting setup.
+	Commissionable bool
+	// CommissioningInterval is how often Matter devices are browsed for while
+	// a commissioning window is open; 0 disables the faster cadence.
+	CommissioningInterval time.Duration
+}
+
+// RouteExport writes the computed routes to files other routing daemons consume.
+type RouteExport struct {
+	Dir     string   // directory receiving the route files; empty disables the exporter
+	Formats []string // "radvd", "bird" and/or "ip"
+	Hook    string   // shell command run after the files change, e.g. to reload the consumer
+}
+
+// Hooks are shell commands run on route lifecycle events; empty ones are skipped.
+type Hooks struct {
+	OnRouteAdd    string
+	OnRouteRemove string
+	OnSyncFail    string
+}
+
+// Settle is how long topology changes are coalesced before routes are applied.
+type Settle struct {
+	Quiet time.Duration // wait this long after the last change of a burst
+	Max   time.Duration // but never longer than this after its first change
+}
+
+// Config is the complete daemon configuration.
+type Config struct {
+	UniFi            UniFi
+	HomeAssistant    HomeAssistant
+	Discovery        Discovery
+	Export           RouteExport
+	Hooks            Hooks
+	RouteGracePeriod time.Duration
+	GraceRules       []GraceRule
+	RemovalRequery   time.Duration // wait for the route's border router to answer once more before removing it; 0 disables it
+	DeviceExpiration time.Duration
+	ChangeSettle     Settle
+	Overrides        RouteOverrides
+	NextHops         NextHopPolicy
+	Tracking         TrackingLimits
+	// PrefixConflictPolicy picks the network routed for a mesh prefix that
+	// several Thread networks announce.
+	PrefixConflictPolicy ConflictPolicy
+	StatusAddr           string
+	StatusActions        StatusActions
+	DebugEndpoints       bool   // serve pprof and runtime variables on the status API
+	NDProxyInterface     string // LAN interface answering neighbor solicitations for Thread devices; empty disables it
+	DNSZone              DNSZone
+	MDNSSelfTest         bool
+	IPv6Preflight        bool // warn at startup when the LAN cannot route IPv6
+	MDNSAdvertise        bool // register the _thread-route-updater._tcp presence service
+	UpdateCheck          bool
+	WarnIPv4Only         bool // warn about Matter devices announcing only IPv4 addresses, which are not on Thread
+}
+
+// DNSZone configures the authoritative DNS zone naming Matter devices.
+type DNSZone struct {
+	Addr string // UDP and TCP address to answer on, e.g. ":5353"; empty disables the zone
+	Name string // zone name, e.g. "thread.home.arpa"
+}
+
+// Load returns the daemon configuration from environment variables.
+func Load() Config {
+	return Config{
+		UniFi:            loadUniFi(),
+		HomeAssistant:    loadHomeAssistant(),
+		Discovery:        loadDiscovery(),
+		Export:           loadRouteExport(),
+		RouteGracePeriod: parseDurationEnv("ROUTE_GRACE_PERIOD", 10*time.Minute),
+		GraceRules:       parseGraceRules(os.Getenv("ROUTE_GRACE_RULES")),
+		RemovalRequery:   parseDurationEnv("ROUTE_REMOVAL_REQUERY", 0),
+		DeviceExpiration: parseDurationEnv("DEVICE_EXPIRATION", 10*time.Minute),
+		Overrides: RouteOverrides{
+			Pins:   parseRoutePins(os.Getenv("ROUTE_PINS")),
+			Ignore: parsePrefixListEnv("ROUTE_IGNORE"),
+		},
+		NextHops: loadNextHopPolicy(),
+		ChangeSettle: Settle{
+			Quiet: parseDurationEnv("CHANGE_SETTLE", 5*time.Second),
+			Max:   parseDurationEnv("CHANGE_SETTLE_MAX", time.Minute),
+		},
+		Hooks: Hooks{
+			OnRouteAdd:    os.Getenv("ON_ROUTE_ADD"),
+			OnRouteRemove: os.Getenv("ON_ROUTE_REMOVE"),
+			OnSyncFail:    os.Getenv("ON_SYNC_FAIL"),
+		},
+		Tracking: TrackingLimits{
+			Routes:      parseIntEnv("ROUTE_TRACKING_MAX", 1024),
+			DeviceAddrs: parseIntEnv("DEVICE_ADDRESSES_MAX", 16),
+
+			AddressHistory:    parseIntEnv("DEVICE_ADDRESS_HISTORY", 64),
+			AddressHistoryAge: parseDurationEnv("DEVICE_ADDRESS_HISTORY_AGE", 7*24*time.Hour),
+			StabilityWindow:   parseDurationEnv("PREFIX_STATS_WINDOW", 24*time.Hour),
+		},
+		PrefixConflictPolicy: parseConflictPolicy(),
+		StatusAddr:           envOrDefault("STATUS_ADDR", ":8080"),
+		StatusActions:        loadStatusActions(),
+		DebugEndpoints:       os.Getenv("DEBUG_ENDPOINTS") == "true",
+		NDProxyInterface:     os.Getenv("ND_PROXY_INTERFACE"),
+		DNSZone: DNSZone{
+			Addr: os.Getenv("DNS_ZONE_ADDR"),
+			Name: envOrDefault("DNS_ZONE", "thread.home.arpa"),
+		},
+		MDNSSelfTest:  os.Getenv("MDNS_SELF_TEST") != "false",
+		IPv6Preflight: os.Getenv("IPV6_PREFLIGHT") != "false",
+		MDNSAdvertise: os.Getenv("MDNS_ADVERTISE") != "false",
+		UpdateCheck:   os.Getenv("UPDATE_CHECK") == "true",
+		WarnIPv4Only:  os.Getenv("WARN_IPV4_ONLY_DEVICES") == "true",
+	}
+}
+
+// Grace returns the route grace period policy: GraceRules with RouteGracePeriod
+// as fallback.
+func (c Config) Grace() GracePolicy {
+	return GracePolicy{Default: c.RouteGracePeriod, Rules: c.GraceRules}
+}
+
+// loadStatusActions returns the status API action guard from environment variables.
+func loadStatusActions() StatusActions {
+	token := os.Getenv("STATUS_ACTION_TOKEN")
+	logger.RegisterSecret(token)
+	return StatusActions{Token: token}
+}
+
+// loadHomeAssistant returns the Home Assistant configuration from environment variables.
+func loadHomeAssistant() HomeAssistant {
+	token := os.Getenv("HA_TOKEN")
+	logger.RegisterSecret(token)
+	return HomeAssistant{
+		URL:         os.Getenv("HA_URL"),
+		Token:       token,
+		InsecureSSL: os.Getenv("HA_INSECURE_SSL") == "true",
+
+		PublishState: os.Getenv("HA_PUBLISH_STATE") == "true",
+	}
+}
+
+// loadDiscovery returns the discovery backend configuration from environment variables.
+func loadDiscovery() Discovery {
+	return Discovery{
+		Backend:      strings.ToLower(envOrDefault("DISCOVERY_BACKEND", "zeroconf")),
+		Mode:         parseDiscoveryMode(),
+		Server:       os.Getenv("DNSSD_SERVER"),
+		Domains:      parseListEnv("DNSSD_DOMAINS", envOrDefault("DNSSD_DOMAIN", "local.")),
+		PollInterval: parseDurationEnv("DNSSD_POLL_INTERVAL", 30*time.Second),
+		OUIFile:      os.Getenv("OUI_FILE"),
+		SRPServices:  parseListEnv("SRP_SERVICES", ""),
+
+		NAT64Prefixes:  parsePrefixListEnv("NAT64_PREFIXES"),
+		NAT64Detect:    os.Getenv("NAT64_DETECT") == "true",
+		RouteLifetimes: os.Getenv("ROUTE_LIFETIMES") == "true",
+
+		Timeout:       parseDurationEnv("DISCOVERY_TIMEOUT", 0),
+		RenewInterval: parseDurationEnv("LISTEN_RENEW_INTERVAL", 0),
+		RoundWindow:   parseDurationEnv("DISCOVERY_ROUND_WINDOW", time.Second),
+
+		ReflectorInterfaces: parseListEnv("MDNS_REFLECTOR_INTERFACES", ""),
+		ReflectorServices:   parseListEnv("MDNS_REFLECTOR_SERVICES", "_matter._tcp,_matterc._udp,_hap._tcp,_hap._udp"),
+
+		Commissionable:        os.Getenv("COMMISSIONING_DISCOVERY") != "false",
+		CommissioningInterval: parseDurationEnv("COMMISSIONING_POLL_INTERVAL", 0),
+	}
+}
+
+// parseBackupFile returns the route backup path from ROUTE_BACKUP_FILE, or ""
+// when it is "off", and whether it was set. Unset, the backup goes to the
+// state directory.
+func parseBackupFile() (string, bool) {
+	path := os.Getenv("ROUTE_BACKUP_FILE")
+	if path == "" {
+		return filepath.Join(StateDir(), "route-backup.json"), false
+	}
+	if strings.EqualFold(path, "off") {
+		return "", false
+	}
+	return path, true
+}
+
+// defaultPassword is the factory password of UniFi devices, used when
+// UBIQUITY_PASSWORD is unset.
+const defaultPassword = "ubnt"
+
+// loadUniFi returns the UniFi controller configuration from environment variables.
+func loadUniFi() UniFi {
+	routerHostname := envOrDefault("UBIQUITY_ROUTER_HOSTNAME", "unifi.local")
+	backupFile, backupRequired := parseBackupFile()
+	username := envOrDefault("UBIQUITY_USERNAME", "ubnt")
+	password := envOrDefault("UBIQUITY_PASSWORD", defaultPassword)
+	proxyURL := os.Getenv("UNIFI_PROXY_URL")
+	registerProxySecret(proxyURL)
+	port := parseIntEnv("UNIFI_PORT", 443)
+	if port <= 0 || port > 65535 {
+		logger.Warn("Invalid UNIFI_PORT value %d, using default 443", port)
+		port = 443
+	}
+	if password != defaultPassword {
+		logger.RegisterSecret(password) // the default is no secret, and would scrub every "ubnt"
+	}
+	mode := parseRouteMode()
+	defaults := mode.defaultLimits()
+
+	return UniFi{
+		RouterHostname: routerHostname,
+		ProxyURL:       proxyURL,
+		FallbackHosts:  controllerHosts(parseListEnv("UBIQUITY_ROUTER_FALLBACK_HOSTS", ""), port),
+		Username:       username,
+		Password:       password,
+		APIBaseURL:     controllerURL(routerHostname, port),
+		InsecureSSL:    os.Getenv("UBIQUITY_INSECURE_SSL") == "true",
+		Enabled:        os.Getenv("UBIQUITY_ENABLED") == "true",
+		GatewayDevice:  os.Getenv("UBIQUITY_GATEWAY_DEVICE"),
+		APIVersion:     strings.ToLower(envOrDefault("UBIQUITY_API_VERSION", "auto")),
+		Approval:       os.Getenv("ROUTE_APPROVAL") == "true",
+		RemovalWindows: parseWindows("ROUTE_REMOVAL_WINDOWS", os.Getenv("ROUTE_REMOVAL_WINDOWS")),
+		Quarantine:     parseDurationEnv("ROUTE_QUARANTINE", 0),
+		QueueExpiry:    parseDurationEnv("ROUTE_QUEUE_EXPIRY", 15*time.Minute),
+		PlanDir:        os.Getenv("ROUTE_PLAN_DIR"),
+		PlanHistory:    parseIntEnv("ROUTE_PLAN_HISTORY", 100),
+		BackupFile:     backupFile,
+		BackupRequired: backupRequired,
+		RecordFile:     os.Getenv("UBIQUITY_RECORD_FILE"),
+		ReplayFile:     os.Getenv("UBIQUITY_REPLAY_FILE"),
+		ClientRefresh:  parseDurationEnv("UBIQUITY_CLIENT_REFRESH", 5*time.Minute),
+		RouteMode:      mode,
+		Limits: RouteLimits{
+			MaxRoutes:         parseIntEnv("ROUTE_MAX_MANAGED", defaults.MaxRoutes),
+			MaxPerNetwork:     parseIntEnv("ROUTE_MAX_PER_NETWORK", defaults.MaxPerNetwork),
+			MaxAddsPerSync:    parseIntEnv("ROUTE_MAX_ADDS_PER_SYNC", defaults.MaxAddsPerSync),
+			MaxDeletesPerSync: parseIntEnv("ROUTE_MAX_DELETES_PER_SYNC", defaults.MaxDeletesPerSync),
+		},
+		SyncLog:          parseSyncLog(),
+		SyncInterval:     parseSyncInterval(),
+		SyncFailureLimit: parseIntEnv("SYNC_FAILURE_LIMIT", 0),
+		GatewayRules:     parseGatewayRules(os.Getenv("UBIQUITY_GATEWAY_DEVICES")),
+		RouteFields:      loadRouteFields(),
+		RouteInterface:   parseRouteInterface(),
+		LegacyRouteNames: parseListEnv("ROUTE_LEGACY_NAMES", ""),
+		Firewall: Firewall{
+			Enabled:   os.Getenv("UBIQUITY_FIREWALL_RULES") == "true",
+			Ruleset:   envOrDefault("UBIQUITY_FIREWALL_RULESET", "LANv6_IN"),
+			RuleIndex: parseIntEnv("UBIQUITY_FIREWALL_RULE_INDEX", 2000),
+		},
+		Damping: RouteDamping{
+			Enabled:     os.Getenv("ROUTE_DAMPING") == "true",
+			HalfLife:    parseDurationEnv("ROUTE_DAMPING_HALF_LIFE", 15*time.Minute),
+			Suppress:    parseIntEnv("ROUTE_DAMPING_SUPPRESS", 2000),
+			Reuse:       parseIntEnv("ROUTE_DAMPING_REUSE", 750),
+			MaxSuppress: parseDurationEnv("ROUTE_DAMPING_MAX_SUPPRESS", time.Hour),
+		},
+		Logins: LoginBudget{
+			PerHour:         parseIntEnv("UBIQUITY_LOGIN_BUDGET", 30),
+			FailuresPerHour: parseIntEnv("UBIQUITY_LOGIN_FAILURE_BUDGET", 4),
+		},
+	}
+}
+
+// parseDiscoveryMode reads DISCOVERY_MODE, falling back to "hybrid" with a
+// warning when the value is unknown.
+func parseDiscoveryMode() string {
+	switch v := strings.ToLower(envOrDefault("DISCOVERY_MODE", "hybrid")); v {
+	case "hybrid", "passive", "active":
+		return v
+	default:
+		logger.Warn("Invalid DISCOVERY_MODE %q, using hybrid", v)
+		return "hybrid"
+	}
+}
+
+// parseSyncInterval reads SYNC_INTERVAL, falling back to 30s with a warning
+// when it is not positive.
+func parseSyncInterval() time.Duration {
+	const def = 30 * time.Second
+	d := parseDurationEnv("SYNC_INTERVAL", def)
+	if d <= 0 {
+		logger.Warn("Invalid SYNC_INTERVAL %s, using default %s", d, def)
+		return def
+	}
+	return d
+}
+
+// parseSyncLog reads SYNC_LOG, falling back to "summary" with a warning when the
+// value is unknown.
+func parseSyncLog() string {
+	switch v := strings.ToLower(envOrDefault("SYNC_LOG", "summary")); v {
+	case "quiet", "summary", "detail":
+		return v
+	default:
+		logger.Warn("Invalid SYNC_LOG %q, using summary", v)
+		return "summary"
+	}
+}
+
+// loadRouteExport returns the route file exporter configuration, dropping
+// unknown formats with a warning.
+func loadRouteExport() RouteExport {
+	export := RouteExport{Dir: os.Getenv("ROUTE_EXPORT_DIR"), Hook: os.Getenv("ROUTE_EXPORT_HOOK")}
+	for _, format := range parseListEnv("ROUTE_EXPORT_FORMATS", "radvd,bird,ip") {
+		switch format = strings.ToLower(format); format {
+		case "radvd", "bird", "ip":
+			export.Formats = append(export.Formats, format)
+		default:
+			logger.Warn("Invalid ROUTE_EXPORT_FORMATS entry %q: want radvd, bird or ip", format)
+		}
+	}
+	return export
+}
+
+// envOrDefault returns the environment variable value or a fallback if unset.
+func envOrDefault(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// parseDurationEnv parses a duration from an environment variable, falling back
+// to def on error or absence.
+func parseDurationEnv(key string, def time.Duration) time.Duration {
+	s := os.Getenv(key)
+	if s == "" {
+		return def
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		logger.Warn("Invalid %s format %q, using default %s", key, s, def)
+		return def
+	}
+	return d
+}
+
+// parseIntEnv parses a non-negative integer from an environment variable, falling
+// back to def on error or absence.
+func parseIntEnv(key string, def int) int {
+	s := os.Getenv(key)
+	if s == "" {
+		return def
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 {
+		logger.Warn("Invalid %s value %q, using default %d", key, s, def)
+		return def
+	}
+	return n
+}
+
+// parseListEnv splits a comma-separated environment variable into its trimmed,
+// non-empty items, falling back to def when there are none.
+func parseListEnv(key, def string) []string {
+	if items := splitList(envOrDefault(key, def)); len(items) > 0 {
+		return items
+	}
+	return splitList(def)
+}
+
+// splitList splits a comma-separated list into its trimmed, non-empty items.
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parsePrefixListEnv parses a comma-separated list of IPv6 prefixes, skipping
+// invalid ones with a warning.
+func parsePrefixListEnv(key string) []netip.Prefix {
+	var prefixes []netip.Prefix
+	for _, item := range parseListEnv(key, "") {
+		p, err := netip.ParsePrefix(item)
+		if err != nil || !p.Addr().Is6() {
+			logger.Warn("Invalid %s prefix %q", key, item)
+			continue
+		}
+		prefixes = append(prefixes, p.Masked())
+	}
+	return prefixes
+}
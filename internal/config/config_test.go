@@ -0,0 +1,329 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/logger"
+)
+
+// TestLoadUniFi tests the UniFi configuration loading function
+func TestLoadUniFi(t *testing.T) {
+	// Save original environment variables
+	originalVars := map[string]string{
+		"UBIQUITY_ROUTER_HOSTNAME": os.Getenv("UBIQUITY_ROUTER_HOSTNAME"),
+		"UBIQUITY_USERNAME":        os.Getenv("UBIQUITY_USERNAME"),
+		"UBIQUITY_PASSWORD":        os.Getenv("UBIQUITY_PASSWORD"),
+		"UBIQUITY_INSECURE_SSL":    os.Getenv("UBIQUITY_INSECURE_SSL"),
+		"UBIQUITY_ENABLED":         os.Getenv("UBIQUITY_ENABLED"),
+	}
+
+	// Clean up after test
+	defer func() {
+		for key, value := range originalVars {
+			if value == "" {
+				if err := os.Unsetenv(key); err != nil {
+					t.Errorf("Failed to unset %s: %v", key, err)
+				}
+			} else {
+				if err := os.Setenv(key, value); err != nil {
+					t.Errorf("Failed to set %s: %v", key, err)
+				}
+			}
+		}
+	}()
+
+	t.Run("Default configuration", func(t *testing.T) {
+		// Clear all environment variables
+		for key := range originalVars {
+			if err := os.Unsetenv(key); err != nil {
+				t.Errorf("Failed to unset %s: %v", key, err)
+			}
+		}
+
+		config := loadUniFi()
+
+		// Check defaults (the function has hardcoded defaults)
+		if config.RouterHostname != "unifi.local" {
+			t.Errorf("Expected RouterHostname 'unifi.local', got %s", config.RouterHostname)
+		}
+		if config.Username != "ubnt" {
+			t.Errorf("Expected Username 'ubnt', got %s", config.Username)
+		}
+		if config.Password != "ubnt" {
+			t.Errorf("Expected Password 'ubnt', got %s", config.Password)
+		}
+		if config.APIBaseURL != "https://unifi.local" {
+			t.Errorf("Expected APIBaseURL 'https://unifi.local', got %s", config.APIBaseURL)
+		}
+		if config.InsecureSSL {
+			t.Error("Expected InsecureSSL to be false")
+		}
+		if config.Enabled {
+			t.Error("Expected Enabled to be false")
+		}
+	})
+
+	t.Run("Configuration with environment variables", func(t *testing.T) {
+		// Set test environment variables
+		if err := os.Setenv("UBIQUITY_ROUTER_HOSTNAME", "test-router.local"); err != nil {
+			t.Fatalf("Failed to set UBIQUITY_ROUTER_HOSTNAME: %v", err)
+		}
+		if err := os.Setenv("UBIQUITY_USERNAME", "testuser"); err != nil {
+			t.Fatalf("Failed to set UBIQUITY_USERNAME: %v", err)
+		}
+		if err := os.Setenv("UBIQUITY_PASSWORD", "testpass"); err != nil {
+			t.Fatalf("Failed to set UBIQUITY_PASSWORD: %v", err)
+		}
+		if err := os.Setenv("UBIQUITY_INSECURE_SSL", "true"); err != nil {
+			t.Fatalf("Failed to set UBIQUITY_INSECURE_SSL: %v", err)
+		}
+		if err := os.Setenv("UBIQUITY_ENABLED", "true"); err != nil {
+			t.Fatalf("Failed to set UBIQUITY_ENABLED: %v", err)
+		}
+
+		config := loadUniFi()
+
+		// Check values
+		if config.RouterHostname != "test-router.local" {
+			t.Errorf("Expected RouterHostname 'test-router.local', got %s", config.RouterHostname)
+		}
+		if config.Username != "testuser" {
+			t.Errorf("Expected Username 'testuser', got %s", config.Username)
+		}
+		if config.Password != "testpass" {
+			t.Errorf("Expected Password 'testpass', got %s", config.Password)
+		}
+		if !config.InsecureSSL {
+			t.Error("Expected InsecureSSL to be true")
+		}
+		if !config.Enabled {
+			t.Error("Expected Enabled to be true")
+		}
+	})
+}
+
+// TestLoadEdgeCases tests edge cases for configuration parsing
+func TestLoadEdgeCases(t *testing.T) {
+	// Save original environment
+	originalEnv := map[string]string{
+		"UBIQUITY_ROUTER_HOSTNAME": os.Getenv("UBIQUITY_ROUTER_HOSTNAME"),
+		"UBIQUITY_USERNAME":        os.Getenv("UBIQUITY_USERNAME"),
+		"UBIQUITY_PASSWORD":        os.Getenv("UBIQUITY_PASSWORD"),
+		"UBIQUITY_INSECURE_SSL":    os.Getenv("UBIQUITY_INSECURE_SSL"),
+		"ROUTE_GRACE_PERIOD":       os.Getenv("ROUTE_GRACE_PERIOD"),
+	}
+
+	// Restore environment after test
+	defer func() {
+		for key, value := range originalEnv {
+			if value == "" {
+				_ = os.Unsetenv(key)
+			} else {
+				_ = os.Setenv(key, value)
+			}
+		}
+	}()
+
+	t.Run("Invalid grace period should use default", func(t *testing.T) {
+		_ = os.Setenv("ROUTE_GRACE_PERIOD", "invalid-duration")
+		config := Load()
+		expected := 10 * time.Minute // Default grace period
+		if config.RouteGracePeriod != expected {
+			t.Errorf("Expected grace period %v for invalid duration, got %v", expected, config.RouteGracePeriod)
+		}
+	})
+
+	t.Run("Empty grace period should use default", func(t *testing.T) {
+		_ = os.Setenv("ROUTE_GRACE_PERIOD", "")
+		config := Load()
+		expected := 10 * time.Minute // Default grace period
+		if config.RouteGracePeriod != expected {
+			t.Errorf("Expected grace period %v for empty duration, got %v", expected, config.RouteGracePeriod)
+		}
+	})
+
+	t.Run("Valid grace period should be parsed", func(t *testing.T) {
+		_ = os.Setenv("ROUTE_GRACE_PERIOD", "5m")
+		config := Load()
+		expected := 5 * time.Minute
+		if config.RouteGracePeriod != expected {
+			t.Errorf("Expected grace period %v, got %v", expected, config.RouteGracePeriod)
+		}
+	})
+
+	t.Run("Insecure SSL should be parsed correctly", func(t *testing.T) {
+		_ = os.Setenv("UBIQUITY_INSECURE_SSL", "true")
+		config := Load()
+		if !config.UniFi.InsecureSSL {
+			t.Errorf("Expected InsecureSSL to be true, got false")
+		}
+	})
+
+	t.Run("Secure SSL should be parsed correctly", func(t *testing.T) {
+		_ = os.Setenv("UBIQUITY_INSECURE_SSL", "false")
+		config := Load()
+		if config.UniFi.InsecureSSL {
+			t.Errorf("Expected InsecureSSL to be false, got true")
+		}
+	})
+}
+
+// TestParseIntEnv tests integer settings fall back to the default on bad input
+func TestParseIntEnv(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected int
+	}{
+		{"", 16},
+		{"0", 0},
+		{"32", 32},
+		{"-1", 16},
+		{"many", 16},
+	}
+
+	for _, tt := range tests {
+		t.Setenv("ROUTE_MAX_ADDS_PER_SYNC", tt.value)
+		if got := parseIntEnv("ROUTE_MAX_ADDS_PER_SYNC", 16); got != tt.expected {
+			t.Errorf("parseIntEnv(%q): expected %d, got %d", tt.value, tt.expected, got)
+		}
+	}
+}
+
+// TestParseListEnv tests lists fall back to the split default when the
+// variable has no items
+func TestParseListEnv(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected []string
+	}{
+		{"", []string{"radvd", "bird", "ip"}},
+		{",", []string{"radvd", "bird", "ip"}},
+		{" , ", []string{"radvd", "bird", "ip"}},
+		{" ip, bird ,", []string{"ip", "bird"}},
+	}
+
+	for _, tt := range tests {
+		t.Setenv("ROUTE_EXPORT_FORMATS", tt.value)
+		got := parseListEnv("ROUTE_EXPORT_FORMATS", "radvd, bird,ip")
+		if strings.Join(got, "|") != strings.Join(tt.expected, "|") {
+			t.Errorf("parseListEnv(%q): expected %q, got %q", tt.value, tt.expected, got)
+		}
+	}
+}
+
+func TestParseSyncLog(t *testing.T) {
+	tests := []struct {
+		value, expected string
+	}{
+		{"", "summary"},
+		{"Quiet", "quiet"},
+		{"detail", "detail"},
+		{"verbose", "summary"},
+	}
+	for _, tt := range tests {
+		t.Setenv("SYNC_LOG", tt.value)
+		if got := parseSyncLog(); got != tt.expected {
+			t.Errorf("SYNC_LOG=%q: expected %q, got %q", tt.value, tt.expected, got)
+		}
+	}
+}
+
+func TestParseDiscoveryMode(t *testing.T) {
+	tests := []struct {
+		value, expected string
+	}{
+		{"", "hybrid"},
+		{"Passive", "passive"},
+		{"active", "active"},
+		{"silent", "hybrid"},
+	}
+	for _, tt := range tests {
+		t.Setenv("DISCOVERY_MODE", tt.value)
+		if got := parseDiscoveryMode(); got != tt.expected {
+			t.Errorf("DISCOVERY_MODE=%q: expected %q, got %q", tt.value, tt.expected, got)
+		}
+	}
+}
+
+func TestLoadDiscovery(t *testing.T) {
+	t.Setenv("DISCOVERY_TIMEOUT", "")
+	t.Setenv("LISTEN_RENEW_INTERVAL", "")
+	cfg := loadDiscovery()
+	if cfg.Timeout != 0 || cfg.RenewInterval != 0 {
+		t.Errorf("Expected backend defaults, got timeout %v renew %v", cfg.Timeout, cfg.RenewInterval)
+	}
+
+	t.Setenv("DISCOVERY_TIMEOUT", "10s")
+	t.Setenv("LISTEN_RENEW_INTERVAL", "15m")
+	cfg = loadDiscovery()
+	if cfg.Timeout != 10*time.Second || cfg.RenewInterval != 15*time.Minute {
+		t.Errorf("Expected timeout 10s renew 15m, got %v %v", cfg.Timeout, cfg.RenewInterval)
+	}
+}
+
+func TestLoadDiscoveryDomains(t *testing.T) {
+	tests := []struct {
+		domains, domain string
+		expected        []string
+	}{
+		{"", "", []string{"local."}},
+		{"", "home.arpa.", []string{"home.arpa."}},
+		{"local., home.arpa.", "ignored.", []string{"local.", "home.arpa."}},
+		{" , ", "", []string{"local."}},
+	}
+	for _, tt := range tests {
+		t.Setenv("DNSSD_DOMAINS", tt.domains)
+		t.Setenv("DNSSD_DOMAIN", tt.domain)
+		got := loadDiscovery().Domains
+		if len(got) != len(tt.expected) {
+			t.Errorf("DNSSD_DOMAINS=%q DNSSD_DOMAIN=%q: expected %q, got %q", tt.domains, tt.domain, tt.expected, got)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.expected[i] {
+				t.Errorf("DNSSD_DOMAINS=%q DNSSD_DOMAIN=%q: expected %q, got %q", tt.domains, tt.domain, tt.expected, got)
+			}
+		}
+	}
+}
+
+// TestConfigStringRedactsSecrets verifies printing the configuration, as a
+// DEBUG dump with %+v would, never shows the password or token.
+func TestConfigStringRedactsSecrets(t *testing.T) {
+	cfg := Config{
+		UniFi:         UniFi{Username: "admin", Password: "hunter2"},
+		HomeAssistant: HomeAssistant{URL: "http://ha.local:8123", Token: "eyJhbGciOi"},
+	}
+	for _, out := range []string{fmt.Sprintf("%+v", cfg), fmt.Sprintf("%v", cfg.UniFi), fmt.Sprint(cfg.HomeAssistant)} {
+		if strings.Contains(out, "hunter2") || strings.Contains(out, "eyJhbGciOi") {
+			t.Errorf("Expected secrets to be redacted, got %s", out)
+		}
+	}
+	if out := cfg.UniFi.String(); !strings.Contains(out, "Password:REDACTED") || !strings.Contains(out, "Username:admin") {
+		t.Errorf("Expected a redacted password next to the username, got %s", out)
+	}
+	if out := (UniFi{}).String(); !strings.Contains(out, "Password: ") {
+		t.Errorf("Expected an unset password to stay empty, got %s", out)
+	}
+	if out := fmt.Sprintf("%+v", Config{StatusActions: StatusActions{Token: "s3cret"}}); strings.Contains(out, "s3cret") {
+		t.Errorf("Expected the action token to be redacted, got %s", out)
+	}
+}
+
+// TestDefaultPasswordNotScrubbed verifies the factory password isn't
+// registered as a log secret, which would scrub every "ubnt" in the logs.
+func TestDefaultPasswordNotScrubbed(t *testing.T) {
+	t.Setenv("UBIQUITY_PASSWORD", "")
+	t.Setenv("UBIQUITY_USERNAME", "")
+	cfg := loadUniFi()
+	if cfg.Password != "ubnt" {
+		t.Fatalf("Expected the default password, got %q", cfg.Password)
+	}
+	if got := logger.Scrub(`"username":"ubnt" host=ubnt-gateway`); got != `"username":"ubnt" host=ubnt-gateway` {
+		t.Errorf("Expected the default password left in log lines, got %q", got)
+	}
+}
@@ -0,0 +1,35 @@
+package config
+
+import (
+	"os"
+	"strings"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/logger"
+)
+
+// ConflictPolicy decides which Thread network is routed when border routers of
+// several networks announce the same mesh prefix.
+type ConflictPolicy string
+
+const (
+	// ConflictLowestExtPANID routes the network with the lowest extended PAN ID.
+	ConflictLowestExtPANID ConflictPolicy = "lowest-ext-panid"
+	// ConflictHighestExtPANID routes the network with the highest extended PAN ID.
+	ConflictHighestExtPANID ConflictPolicy = "highest-ext-panid"
+	// ConflictWithdraw routes none of them until the conflict is resolved.
+	ConflictWithdraw ConflictPolicy = "withdraw"
+)
+
+// parseConflictPolicy reads PREFIX_CONFLICT_POLICY, falling back to
+// ConflictLowestExtPANID with a warning when the value is unknown.
+func parseConflictPolicy() ConflictPolicy {
+	v := strings.ToLower(strings.TrimSpace(os.Getenv("PREFIX_CONFLICT_POLICY")))
+	switch p := ConflictPolicy(v); p {
+	case ConflictLowestExtPANID, ConflictHighestExtPANID, ConflictWithdraw:
+		return p
+	case "":
+		return ConflictLowestExtPANID
+	}
+	logger.Warn("Invalid PREFIX_CONFLICT_POLICY %q, using %s", v, ConflictLowestExtPANID)
+	return ConflictLowestExtPANID
+}
@@ -0,0 +1,21 @@
+package config
+
+import "testing"
+
+func TestParseConflictPolicy(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected ConflictPolicy
+	}{
+		{"", ConflictLowestExtPANID},
+		{"highest-ext-panid", ConflictHighestExtPANID},
+		{" Withdraw ", ConflictWithdraw},
+		{"bogus", ConflictLowestExtPANID},
+	}
+	for _, tt := range tests {
+		t.Setenv("PREFIX_CONFLICT_POLICY", tt.value)
+		if got := parseConflictPolicy(); got != tt.expected {
+			t.Errorf("PREFIX_CONFLICT_POLICY=%q: expected %s, got %s", tt.value, tt.expected, got)
+		}
+	}
+}
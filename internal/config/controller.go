@@ -0,0 +1,59 @@
+package config
+
+import (
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/logger"
+)
+
+// controllerHost returns host as the host[:port] part of a URL: IPv6 literals
+// are bracketed, with any zone kept, and port is appended when host names
+// none and port is a valid port other than 443. host may be a hostname, an IPv4 or IPv6
+// address, bracketed or not, with or without a port.
+func controllerHost(host string, port int) string {
+	host = strings.TrimSpace(host)
+	name, hostPort, err := net.SplitHostPort(host)
+	if err != nil {
+		name, hostPort = strings.TrimSuffix(strings.TrimPrefix(host, "["), "]"), ""
+	}
+	if hostPort == "" && port > 0 && port <= 65535 && port != 443 {
+		hostPort = strconv.Itoa(port)
+	}
+	if hostPort != "" {
+		return net.JoinHostPort(name, hostPort)
+	}
+	if strings.Contains(name, ":") {
+		return "[" + name + "]"
+	}
+	return name
+}
+
+// controllerURL returns the HTTPS base URL of the controller at host; see
+// controllerHost.
+func controllerURL(host string, port int) string {
+	u := url.URL{Scheme: "https", Host: controllerHost(host, port)}
+	return u.String()
+}
+
+// controllerHosts returns each of hosts as the host[:port] part of a URL.
+func controllerHosts(hosts []string, port int) []string {
+	out := make([]string, 0, len(hosts))
+	for _, h := range hosts {
+		out = append(out, controllerHost(h, port))
+	}
+	return out
+}
+
+// registerProxySecret keeps the password of a proxy URL out of the logs.
+func registerProxySecret(proxyURL string) {
+	u, err := url.Parse(proxyURL)
+	if err != nil || u.User == nil {
+		return
+	}
+	if password, ok := u.User.Password(); ok {
+		logger.RegisterSecret(password)
+	}
+}
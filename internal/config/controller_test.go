@@ -0,0 +1,64 @@
+package config
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestControllerURL(t *testing.T) {
+	tests := []struct {
+		host     string
+		port     int
+		expected string
+	}{
+		{"unifi.local", 443, "https://unifi.local"},
+		{"unifi.local", 8443, "https://unifi.local:8443"},
+		{"unifi.local:9443", 8443, "https://unifi.local:9443"}, // a port in the host wins
+		{"192.168.1.1", 443, "https://192.168.1.1"},
+		{"fd00::1", 443, "https://[fd00::1]"},
+		{"fd00::1", 8443, "https://[fd00::1]:8443"},
+		{"[fd00::1]", 443, "https://[fd00::1]"},
+		{"[fd00::1]:8443", 443, "https://[fd00::1]:8443"},
+		{"fe80::1%eth0", 443, "https://[fe80::1%25eth0]"},
+		{" unifi.local ", 0, "https://unifi.local"},
+		{"unifi.local", -1, "https://unifi.local"},
+		{"unifi.local", 70000, "https://unifi.local"},
+	}
+
+	for _, tt := range tests {
+		got := controllerURL(tt.host, tt.port)
+		if got != tt.expected {
+			t.Errorf("controllerURL(%q, %d): expected %s, got %s", tt.host, tt.port, tt.expected, got)
+		}
+		if _, err := url.Parse(got + "/api/auth/login"); err != nil {
+			t.Errorf("Expected %s to parse, got %v", got, err)
+		}
+	}
+}
+
+func TestLoadUniFiPort(t *testing.T) {
+	t.Setenv("UBIQUITY_ROUTER_HOSTNAME", "fd00::1")
+	t.Setenv("UBIQUITY_ROUTER_FALLBACK_HOSTS", "192.168.1.1,fd00::2,vpn.example.com:443")
+	t.Setenv("UNIFI_PORT", "8443")
+
+	cfg := loadUniFi()
+	if cfg.APIBaseURL != "https://[fd00::1]:8443" {
+		t.Errorf("Expected APIBaseURL https://[fd00::1]:8443, got %s", cfg.APIBaseURL)
+	}
+	expected := []string{"192.168.1.1:8443", "[fd00::2]:8443", "vpn.example.com:443"}
+	if len(cfg.FallbackHosts) != len(expected) {
+		t.Fatalf("Expected fallback hosts %v, got %v", expected, cfg.FallbackHosts)
+	}
+	for i, h := range expected {
+		if cfg.FallbackHosts[i] != h {
+			t.Errorf("Expected fallback host %s, got %s", h, cfg.FallbackHosts[i])
+		}
+	}
+
+	for _, port := range []string{"70000", "0", "-8443"} {
+		t.Setenv("UNIFI_PORT", port)
+		if cfg := loadUniFi(); cfg.APIBaseURL != "https://[fd00::1]" {
+			t.Errorf("Expected invalid port %s ignored, got %s", port, cfg.APIBaseURL)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package config
+
+import (
+	"net"
+	"net/netip"
+	"strings"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/logger"
+)
+
+// GatewayRule pins the gateway device programming routes to networks within
+// Network, for sites with several gateway-capable devices.
+type GatewayRule struct {
+	Network netip.Prefix
+	Device  string // MAC address of the gateway device
+}
+
+// GatewayFor returns the gateway device of the first rule whose network
+// contains network, a CIDR such as "fd00::/64".
+func GatewayFor(rules []GatewayRule, network string) (string, bool) {
+	prefix, err := netip.ParsePrefix(network)
+	if err != nil {
+		return "", false
+	}
+	for _, r := range rules {
+		if r.Network.Contains(prefix.Addr()) && prefix.Bits() >= r.Network.Bits() {
+			return r.Device, true
+		}
+	}
+	return "", false
+}
+
+// parseGatewayRules parses a comma-separated list of cidr=mac rules, e.g.
+// "fd12:3456::/48=aa:bb:cc:dd:ee:ff". Malformed entries are skipped with a warning.
+func parseGatewayRules(s string) []GatewayRule {
+	var rules []GatewayRule
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		network, device, ok := strings.Cut(entry, "=")
+		if !ok {
+			logger.Warn("Invalid UBIQUITY_GATEWAY_DEVICES entry %q, expected cidr=mac", entry)
+			continue
+		}
+		cidr, err := netip.ParsePrefix(strings.TrimSpace(network))
+		if err != nil {
+			logger.Warn("Invalid UBIQUITY_GATEWAY_DEVICES network in %q: %v", entry, err)
+			continue
+		}
+		mac, err := net.ParseMAC(strings.TrimSpace(device))
+		if err != nil {
+			logger.Warn("Invalid UBIQUITY_GATEWAY_DEVICES device in %q: %v", entry, err)
+			continue
+		}
+		rules = append(rules, GatewayRule{Network: cidr.Masked(), Device: mac.String()})
+	}
+	return rules
+}
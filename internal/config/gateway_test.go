@@ -0,0 +1,40 @@
+package config
+
+import "testing"
+
+func TestParseGatewayRules(t *testing.T) {
+	rules := parseGatewayRules("fd12:3456::/48=AA:BB:CC:DD:EE:01, fd99::/64=aa-bb-cc-dd-ee-02,bogus,fd00::/64=nomac,nocidr=aa:bb:cc:dd:ee:03")
+	if len(rules) != 2 {
+		t.Fatalf("Expected 2 valid rules, got %d: %+v", len(rules), rules)
+	}
+	if rules[0].Device != "aa:bb:cc:dd:ee:01" {
+		t.Errorf("Expected normalised MAC, got %q", rules[0].Device)
+	}
+	if rules[1].Network.String() != "fd99::/64" {
+		t.Errorf("Unexpected second rule %+v", rules[1])
+	}
+}
+
+func TestGatewayFor(t *testing.T) {
+	rules := parseGatewayRules("fd12:3456:789a::/64=aa:bb:cc:dd:ee:01,fd12:3456::/32=aa:bb:cc:dd:ee:02")
+
+	tests := []struct {
+		network  string
+		expected string
+		found    bool
+	}{
+		{"fd12:3456:789a::/64", "aa:bb:cc:dd:ee:01", true},
+		{"fd12:3456:789a::5/128", "aa:bb:cc:dd:ee:01", true},
+		{"fd12:3456:1::/64", "aa:bb:cc:dd:ee:02", true},
+		{"fd12::/16", "", false}, // wider than every rule
+		{"fd00::/64", "", false},
+		{"invalid", "", false},
+	}
+
+	for _, tt := range tests {
+		got, found := GatewayFor(rules, tt.network)
+		if got != tt.expected || found != tt.found {
+			t.Errorf("GatewayFor(%s) = %q, %v, want %q, %v", tt.network, got, found, tt.expected, tt.found)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package config
+
+import (
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/logger"
+)
+
+// GraceRule sets the grace period for route networks matching a prefix class
+// ("ula" for fc00::/7, "gua" for 2000::/3, "host" for /128 host routes) or an
+// explicit CIDR.
+type GraceRule struct {
+	Match  string
+	Period time.Duration
+	cidr   netip.Prefix
+}
+
+// GracePolicy resolves the grace period for a route network. The first matching
+// rule wins; networks matching no rule use Default.
+type GracePolicy struct {
+	Default time.Duration
+	Rules   []GraceRule
+}
+
+var (
+	ulaRange = netip.MustParsePrefix("fc00::/7")
+	guaRange = netip.MustParsePrefix("2000::/3")
+)
+
+// For returns the grace period for network, a CIDR such as "fd00::/64".
+func (p GracePolicy) For(network string) time.Duration {
+	prefix, err := netip.ParsePrefix(network)
+	if err != nil {
+		return p.Default
+	}
+	for _, r := range p.Rules {
+		if r.matches(prefix) {
+			return r.Period
+		}
+	}
+	return p.Default
+}
+
+// Override returns a copy of p in which networks within prefix use period,
+// ahead of its rules.
+func (p GracePolicy) Override(prefix netip.Prefix, period time.Duration) GracePolicy {
+	prefix = prefix.Masked()
+	rule := GraceRule{Match: prefix.String(), Period: period, cidr: prefix}
+	p.Rules = append([]GraceRule{rule}, p.Rules...)
+	return p
+}
+
+// matches reports whether the route network falls within the rule's class or CIDR.
+func (r GraceRule) matches(prefix netip.Prefix) bool {
+	ip := prefix.Addr()
+	switch r.Match {
+	case "host":
+		return prefix.Bits() == 128
+	case "ula":
+		return ulaRange.Contains(ip)
+	case "gua":
+		return guaRange.Contains(ip)
+	}
+	return r.cidr.IsValid() && r.cidr.Contains(ip)
+}
+
+// parseGraceRules parses a comma-separated list of match=duration rules, e.g.
+// "ula=1h,gua=5m,fd12:3456::/32=2h". Malformed entries are skipped with a warning.
+func parseGraceRules(s string) []GraceRule {
+	var rules []GraceRule
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		match, period, ok := strings.Cut(entry, "=")
+		if !ok {
+			logger.Warn("Invalid ROUTE_GRACE_RULES entry %q, expected match=duration", entry)
+			continue
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(period))
+		if err != nil {
+			logger.Warn("Invalid ROUTE_GRACE_RULES duration in %q: %v", entry, err)
+			continue
+		}
+		rule := GraceRule{Match: strings.ToLower(strings.TrimSpace(match)), Period: d}
+		if rule.Match != "ula" && rule.Match != "gua" && rule.Match != "host" {
+			cidr, err := netip.ParsePrefix(rule.Match)
+			if err != nil {
+				logger.Warn("Invalid ROUTE_GRACE_RULES match %q: want ula, gua, host or a CIDR", rule.Match)
+				continue
+			}
+			rule.cidr = cidr.Masked()
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
@@ -0,0 +1,72 @@
+package config
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestParseGraceRules(t *testing.T) {
+	rules := parseGraceRules("ula=1h, GUA=5m,fd12:3456::/32=2h,bogus,gua=notaduration,nowhere=1m")
+	if len(rules) != 3 {
+		t.Fatalf("Expected 3 valid rules, got %d: %+v", len(rules), rules)
+	}
+	if rules[1].Match != "gua" || rules[1].Period != 5*time.Minute {
+		t.Errorf("Unexpected second rule %+v", rules[1])
+	}
+	if !rules[2].cidr.IsValid() {
+		t.Error("Expected CIDR rule to be parsed")
+	}
+}
+
+func TestGracePolicyFor(t *testing.T) {
+	policy := GracePolicy{
+		Default: 10 * time.Minute,
+		Rules:   parseGraceRules("host=30m,fd12:3456::/32=2h,ula=1h,gua=5m"),
+	}
+
+	tests := []struct {
+		network  string
+		expected time.Duration
+	}{
+		{"fd12:3456:789a::/64", 2 * time.Hour},        // CIDR rule listed first wins over ula
+		{"fd00:1111:2222:3333::/64", time.Hour},       // ula
+		{"2001:4860:4860:1234::/64", 5 * time.Minute}, // gua
+		{"::ffff:0:0/96", 10 * time.Minute},           // no rule
+		{"fd12:3456:789a::5/128", 30 * time.Minute},   // host rule listed first wins over the CIDR
+		{"invalid", 10 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		if got := policy.For(tt.network); got != tt.expected {
+			t.Errorf("For(%s) = %v, want %v", tt.network, got, tt.expected)
+		}
+	}
+
+	if (GracePolicy{Default: time.Minute}).For("fd00::/64") != time.Minute {
+		t.Error("Expected Default without rules")
+	}
+}
+
+func TestGracePolicyOverride(t *testing.T) {
+	policy := GracePolicy{Default: 10 * time.Minute, Rules: parseGraceRules("host=30m,ula=1h")}
+	overridden := policy.Override(netip.MustParsePrefix("fd11:22::/64"), 0)
+
+	tests := []struct {
+		network  string
+		expected time.Duration
+	}{
+		{"fd11:22::/64", 0},
+		{"fd11:22::5/128", 0}, // host routes within the prefix too
+		{"fd11:23::/64", time.Hour},
+		{"2001:db8::/64", 10 * time.Minute},
+	}
+	for _, tt := range tests {
+		if got := overridden.For(tt.network); got != tt.expected {
+			t.Errorf("For(%s) = %v, want %v", tt.network, got, tt.expected)
+		}
+	}
+	if len(policy.Rules) != 2 || policy.For("fd11:22::/64") != time.Hour {
+		t.Errorf("Expected the original policy unchanged, got %+v", policy.Rules)
+	}
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"os"
+	"strings"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/logger"
+)
+
+// RouteMode decides the shape of the routes programmed on the controller.
+type RouteMode string
+
+const (
+	// RouteModePrefix routes each Thread mesh prefix (/64) through its border routers.
+	RouteModePrefix RouteMode = "prefix"
+	// RouteModeHost routes each Matter device address (/128) inside a mesh
+	// prefix, for edge routers refusing broad ULA prefixes.
+	RouteModeHost RouteMode = "host"
+)
+
+// parseRouteMode reads ROUTE_MODE, falling back to RouteModePrefix with a
+// warning when the value is unknown.
+func parseRouteMode() RouteMode {
+	v := strings.ToLower(strings.TrimSpace(os.Getenv("ROUTE_MODE")))
+	switch m := RouteMode(v); m {
+	case RouteModePrefix, RouteModeHost:
+		return m
+	case "":
+		return RouteModePrefix
+	}
+	logger.Warn("Invalid ROUTE_MODE %q, using %s", v, RouteModePrefix)
+	return RouteModePrefix
+}
+
+// parseRouteInterface reads ROUTE_TYPE and ROUTE_INTERFACE, returning the
+// network interface routes go out of, or "" for next hop routes.
+func parseRouteInterface() string {
+	iface := strings.TrimSpace(os.Getenv("ROUTE_INTERFACE"))
+	switch v := strings.ToLower(strings.TrimSpace(os.Getenv("ROUTE_TYPE"))); v {
+	case "", "nexthop", "nexthop-route":
+		return ""
+	case "interface", "interface-route":
+		if iface == "" {
+			logger.Warn("ROUTE_TYPE=%s needs ROUTE_INTERFACE, using next hop routes", v)
+		}
+		return iface
+	default:
+		logger.Warn("Invalid ROUTE_TYPE %q, using next hop routes", v)
+		return ""
+	}
+}
+
+// defaultLimits returns the route caps used when none are configured. Host
+// routes number one per device address and border router, so they get more
+// room than prefix routes.
+func (m RouteMode) defaultLimits() RouteLimits {
+	if m == RouteModeHost {
+		return RouteLimits{MaxRoutes: 512, MaxPerNetwork: 8, MaxAddsPerSync: 64, MaxDeletesPerSync: 64}
+	}
+	return RouteLimits{MaxRoutes: 64, MaxPerNetwork: 8, MaxAddsPerSync: 16, MaxDeletesPerSync: 16}
+}
@@ -0,0 +1,61 @@
+package config
+
+import "testing"
+
+func TestParseRouteMode(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected RouteMode
+	}{
+		{"", RouteModePrefix},
+		{"prefix", RouteModePrefix},
+		{" Host ", RouteModeHost},
+		{"bogus", RouteModePrefix},
+	}
+	for _, tt := range tests {
+		t.Setenv("ROUTE_MODE", tt.value)
+		if got := parseRouteMode(); got != tt.expected {
+			t.Errorf("ROUTE_MODE=%q: expected %s, got %s", tt.value, tt.expected, got)
+		}
+	}
+}
+
+func TestRouteModeLimits(t *testing.T) {
+	t.Setenv("ROUTE_MODE", "host")
+	t.Setenv("ROUTE_MAX_MANAGED", "")
+	t.Setenv("ROUTE_MAX_ADDS_PER_SYNC", "32")
+	limits := loadUniFi().Limits
+	if limits.MaxRoutes != 512 {
+		t.Errorf("Expected host mode default of 512 managed routes, got %d", limits.MaxRoutes)
+	}
+	if limits.MaxAddsPerSync != 32 {
+		t.Errorf("Expected configured 32 adds per sync, got %d", limits.MaxAddsPerSync)
+	}
+
+	t.Setenv("ROUTE_MODE", "")
+	if got := loadUniFi().Limits.MaxRoutes; got != 64 {
+		t.Errorf("Expected prefix mode default of 64 managed routes, got %d", got)
+	}
+}
+
+func TestParseRouteInterface(t *testing.T) {
+	tests := []struct {
+		routeType string
+		iface     string
+		expected  string
+	}{
+		{"", "LAN", ""},
+		{"nexthop", "LAN", ""},
+		{"interface", " LAN ", "LAN"},
+		{"Interface-Route", "net-lan", "net-lan"},
+		{"interface", "", ""},
+		{"bogus", "LAN", ""},
+	}
+	for _, tt := range tests {
+		t.Setenv("ROUTE_TYPE", tt.routeType)
+		t.Setenv("ROUTE_INTERFACE", tt.iface)
+		if got := parseRouteInterface(); got != tt.expected {
+			t.Errorf("ROUTE_TYPE=%q ROUTE_INTERFACE=%q: expected %q, got %q", tt.routeType, tt.iface, tt.expected, got)
+		}
+	}
+}
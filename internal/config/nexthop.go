@@ -0,0 +1,60 @@
+package config
+
+import (
+	"os"
+	"strings"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/logger"
+)
+
+// defaultNexthopPreference ranks border router types when a single next hop
+// per prefix is routed: mains-powered devices that are often wired first,
+// battery-backed and Wi-Fi-only speakers last.
+var defaultNexthopPreference = []string{"apple-tv", "otbr", "nest-wifi", "eero", "smartthings", "nest-hub", "homepod"}
+
+// NextHopPolicy decides which border routers a Thread mesh prefix is routed through.
+type NextHopPolicy struct {
+	// PreferWired drops routes through border routers on Wi-Fi when a wired
+	// one announces the same prefix, and ranks wired ones first for Single.
+	PreferWired bool
+	// SkipUnreachable drops routes through border routers on networks the
+	// gateway does not route IPv6 on, such as VLAN-only networks.
+	SkipUnreachable bool
+	// Single routes each prefix through one next hop, of the best ranked
+	// border router, instead of through every border router announcing it.
+	Single bool
+	// Preference ranks border router types, best first; types not listed
+	// rank after those listed.
+	Preference []string
+}
+
+// Rank returns the position of routerType in the preference list, lower is
+// better, or the list length for unlisted types.
+func (p NextHopPolicy) Rank(routerType string) int {
+	for i, t := range p.Preference {
+		if t == routerType {
+			return i
+		}
+	}
+	return len(p.Preference)
+}
+
+// loadNextHopPolicy reads ROUTE_NEXTHOPS, ROUTE_NEXTHOP_PREFERENCE,
+// ROUTE_NEXTHOP_PREFER_WIRED and ROUTE_SKIP_UNREACHABLE_VLANS.
+func loadNextHopPolicy() NextHopPolicy {
+	policy := NextHopPolicy{
+		PreferWired:     os.Getenv("ROUTE_NEXTHOP_PREFER_WIRED") != "false",
+		SkipUnreachable: os.Getenv("ROUTE_SKIP_UNREACHABLE_VLANS") == "true",
+	}
+	switch v := strings.ToLower(strings.TrimSpace(os.Getenv("ROUTE_NEXTHOPS"))); v {
+	case "", "all":
+	case "single":
+		policy.Single = true
+	default:
+		logger.Warn("Invalid ROUTE_NEXTHOPS %q, using all", v)
+	}
+	for _, t := range parseListEnv("ROUTE_NEXTHOP_PREFERENCE", strings.Join(defaultNexthopPreference, ",")) {
+		policy.Preference = append(policy.Preference, strings.ToLower(t))
+	}
+	return policy
+}
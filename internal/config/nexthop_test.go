@@ -0,0 +1,63 @@
+package config
+
+import "testing"
+
+// TestLoadNextHopPolicy tests ROUTE_NEXTHOPS and ROUTE_NEXTHOP_PREFERENCE parsing
+func TestLoadNextHopPolicy(t *testing.T) {
+	tests := []struct {
+		mode, preference string
+		single           bool
+		first            string
+	}{
+		{"", "", false, "apple-tv"},
+		{"single", "", true, "apple-tv"},
+		{"SINGLE", "OTBR, HomePod", true, "otbr"},
+		{"sometimes", "", false, "apple-tv"},
+	}
+	for _, tt := range tests {
+		t.Setenv("ROUTE_NEXTHOPS", tt.mode)
+		t.Setenv("ROUTE_NEXTHOP_PREFERENCE", tt.preference)
+		policy := loadNextHopPolicy()
+		if !policy.PreferWired {
+			t.Errorf("Expected wired next hops to be preferred by default")
+		}
+		if policy.Single != tt.single {
+			t.Errorf("ROUTE_NEXTHOPS=%q: expected single %v, got %v", tt.mode, tt.single, policy.Single)
+		}
+		if len(policy.Preference) == 0 || policy.Preference[0] != tt.first {
+			t.Errorf("ROUTE_NEXTHOP_PREFERENCE=%q: expected %q first, got %v", tt.preference, tt.first, policy.Preference)
+		}
+	}
+}
+
+// TestLoadNextHopPolicyPreferWired tests ROUTE_NEXTHOP_PREFER_WIRED=false turns
+// the preference off
+func TestLoadNextHopPolicyPreferWired(t *testing.T) {
+	t.Setenv("ROUTE_NEXTHOP_PREFER_WIRED", "false")
+	if loadNextHopPolicy().PreferWired {
+		t.Errorf("Expected wired next hops not to be preferred")
+	}
+}
+
+// TestLoadNextHopPolicySkipUnreachable tests ROUTE_SKIP_UNREACHABLE_VLANS is off
+// unless set
+func TestLoadNextHopPolicySkipUnreachable(t *testing.T) {
+	if loadNextHopPolicy().SkipUnreachable {
+		t.Errorf("Expected unreachable border routers to be kept by default")
+	}
+	t.Setenv("ROUTE_SKIP_UNREACHABLE_VLANS", "true")
+	if !loadNextHopPolicy().SkipUnreachable {
+		t.Errorf("Expected unreachable border routers to be skipped")
+	}
+}
+
+// TestNextHopPolicyRank tests listed types rank by position and unlisted types last
+func TestNextHopPolicyRank(t *testing.T) {
+	p := NextHopPolicy{Preference: []string{"otbr", "homepod"}}
+	if p.Rank("otbr") != 0 || p.Rank("homepod") != 1 {
+		t.Errorf("Expected listed types to rank by position, got %d and %d", p.Rank("otbr"), p.Rank("homepod"))
+	}
+	if got := p.Rank(""); got != 2 {
+		t.Errorf("Expected unknown type to rank 2, got %d", got)
+	}
+}
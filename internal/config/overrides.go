@@ -0,0 +1,93 @@
+package config
+
+import (
+	"net/netip"
+	"strings"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/logger"
+)
+
+// RoutePin makes Network always route via Nexthop, whatever discovery finds.
+type RoutePin struct {
+	Network netip.Prefix `json:"network"`
+	Nexthop netip.Addr   `json:"nexthop"`
+}
+
+// RouteOverrides are the static overrides merged with discovered routes.
+type RouteOverrides struct {
+	// Pins replace the discovered routes to their network; a pinned route is
+	// added when missing and never updated or deleted.
+	Pins []RoutePin `json:"pins,omitempty"`
+	// Ignore lists prefixes that are never managed: no routes into them are
+	// generated, and existing ones are left alone.
+	Ignore []netip.Prefix `json:"ignore,omitempty"`
+}
+
+// Pinned reports whether a pin routes network via nexthop.
+func (o RouteOverrides) Pinned(network, nexthop string) bool {
+	prefix, err1 := netip.ParsePrefix(network)
+	addr, err2 := netip.ParseAddr(nexthop)
+	if err1 != nil || err2 != nil {
+		return false
+	}
+	for _, p := range o.Pins {
+		if p.Network == prefix.Masked() && p.Nexthop == addr.WithZone("") {
+			return true
+		}
+	}
+	return false
+}
+
+// PinnedNetwork reports whether network lies within a pinned network.
+func (o RouteOverrides) PinnedNetwork(network netip.Prefix) bool {
+	for _, p := range o.Pins {
+		if p.Network.Contains(network.Addr()) && network.Bits() >= p.Network.Bits() {
+			return true
+		}
+	}
+	return false
+}
+
+// Ignored reports whether network, a CIDR such as "fd00::/64", overlaps an
+// ignored prefix.
+func (o RouteOverrides) Ignored(network string) bool {
+	prefix, err := netip.ParsePrefix(network)
+	if err != nil {
+		return false
+	}
+	for _, p := range o.Ignore {
+		if p.Overlaps(prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// parseRoutePins parses a comma-separated list of cidr=nexthop pins, e.g.
+// "fd12:3456:789a:1::/64=2001:db8::1". Malformed entries are skipped with a warning.
+func parseRoutePins(s string) []RoutePin {
+	var pins []RoutePin
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		network, nexthop, ok := strings.Cut(entry, "=")
+		if !ok {
+			logger.Warn("Invalid ROUTE_PINS entry %q, expected cidr=nexthop", entry)
+			continue
+		}
+		cidr, err := netip.ParsePrefix(strings.TrimSpace(network))
+		if err != nil || !cidr.Addr().Is6() {
+			logger.Warn("Invalid ROUTE_PINS network in %q", entry)
+			continue
+		}
+		addr, err := netip.ParseAddr(strings.TrimSpace(nexthop))
+		if err != nil || !addr.Is6() || addr.Is4In6() || addr.IsLinkLocalUnicast() {
+			logger.Warn("Invalid ROUTE_PINS next hop in %q, expected a global or ULA IPv6 address", entry)
+			continue
+		}
+		pins = append(pins, RoutePin{Network: cidr.Masked(), Nexthop: addr})
+	}
+	return pins
+}
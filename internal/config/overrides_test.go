@@ -0,0 +1,46 @@
+package config
+
+import (
+	"net/netip"
+	"testing"
+)
+
+// TestParseRoutePins tests parsing of ROUTE_PINS entries
+func TestParseRoutePins(t *testing.T) {
+	pins := parseRoutePins(" fd12:3456:789a:1::5/64=2001:db8::1 , bad, fd00::/64=fe80::1, 10.0.0.0/8=2001:db8::2, fd00:2::/64=not-an-ip,")
+	want := []RoutePin{{Network: netip.MustParsePrefix("fd12:3456:789a:1::/64"), Nexthop: netip.MustParseAddr("2001:db8::1")}}
+	if len(pins) != len(want) || pins[0] != want[0] {
+		t.Errorf("Expected %v, got %v", want, pins)
+	}
+}
+
+// TestRouteOverrides tests pin and ignore matching
+func TestRouteOverrides(t *testing.T) {
+	o := RouteOverrides{
+		Pins:   []RoutePin{{Network: netip.MustParsePrefix("fd00:1::/64"), Nexthop: netip.MustParseAddr("2001:db8::1")}},
+		Ignore: []netip.Prefix{netip.MustParsePrefix("fd00:9::/48")},
+	}
+	tests := []struct {
+		network, nexthop string
+		pinned, ignored  bool
+	}{
+		{"fd00:1::/64", "2001:db8::1", true, false},
+		{"FD00:1:0:0::/64", "2001:DB8::1", true, false},
+		{"fd00:1::/64", "2001:db8::2", false, false},
+		{"fd00:9:0:5::/64", "2001:db8::1", false, true},
+		{"fd00:9::/32", "2001:db8::1", false, true},
+		{"fd00:8::/64", "2001:db8::1", false, false},
+		{"garbage", "2001:db8::1", false, false},
+	}
+	for _, tt := range tests {
+		if got := o.Pinned(tt.network, tt.nexthop); got != tt.pinned {
+			t.Errorf("Pinned(%s, %s): expected %v, got %v", tt.network, tt.nexthop, tt.pinned, got)
+		}
+		if got := o.Ignored(tt.network); got != tt.ignored {
+			t.Errorf("Ignored(%s): expected %v, got %v", tt.network, tt.ignored, got)
+		}
+	}
+	if !o.PinnedNetwork(netip.MustParsePrefix("fd00:1::5/128")) || o.PinnedNetwork(netip.MustParsePrefix("fd00::/48")) {
+		t.Error("Expected only networks within the pinned network to count as pinned")
+	}
+}
@@ -0,0 +1,23 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// StateDir returns the directory the daemon keeps its files in by default:
+// $STATE_DIRECTORY when a service manager or the container image sets it,
+// else thread-route-updater in the user's configuration directory
+// (~/.config on Linux, ~/Library/Application Support on macOS, %AppData% on
+// Windows), else the working directory.
+func StateDir() string {
+	if dir := os.Getenv("STATE_DIRECTORY"); dir != "" {
+		dir, _, _ = strings.Cut(dir, ":") // systemd lists every StateDirectory=
+		return dir
+	}
+	if dir, err := os.UserConfigDir(); err == nil {
+		return filepath.Join(dir, "thread-route-updater")
+	}
+	return "."
+}
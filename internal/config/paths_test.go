@@ -0,0 +1,21 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestStateDir(t *testing.T) {
+	t.Setenv("STATE_DIRECTORY", "/var/lib/thread-route-updater:/var/lib/other")
+	if got := StateDir(); got != "/var/lib/thread-route-updater" {
+		t.Errorf("Expected the first STATE_DIRECTORY, got %q", got)
+	}
+
+	t.Setenv("STATE_DIRECTORY", "")
+	t.Setenv("XDG_CONFIG_HOME", "/home/me/.config")
+	t.Setenv("HOME", "/home/me")
+	t.Setenv("AppData", "/home/me/AppData")
+	if got := StateDir(); filepath.Base(got) != "thread-route-updater" || !filepath.IsAbs(got) {
+		t.Errorf("Expected an absolute directory in the user's configuration directory, got %q", got)
+	}
+}
@@ -0,0 +1,170 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+var (
+	fileMu sync.Mutex
+	// fileEnv holds, for each variable set from the config file, the value the
+	// environment had before, or nil when it was unset.
+	fileEnv = make(map[string]*string)
+)
+
+// LoadFile sets the environment variables listed in the env file at path,
+// one KEY=VALUE per line as used by docker --env-file, overriding the process
+// environment. Blank lines and # comments are skipped, values may be quoted.
+// Variables an earlier call set but the file no longer lists get their
+// original value back, so reloading an edited file behaves like a restart.
+func LoadFile(path string) error {
+	values, err := readEnvFile(path)
+	if err != nil {
+		return err
+	}
+	return setFileEnv(path, values)
+}
+
+// setFileEnv sets the environment to the values read from the config file at
+// path, restoring the variables an earlier call set but values no longer lists.
+func setFileEnv(path string, values map[string]string) error {
+	fileMu.Lock()
+	defer fileMu.Unlock()
+	for key, original := range fileEnv {
+		if _, ok := values[key]; ok {
+			continue
+		}
+		if original == nil {
+			_ = os.Unsetenv(key)
+		} else {
+			_ = os.Setenv(key, *original)
+		}
+		delete(fileEnv, key)
+	}
+	for key, value := range values {
+		if _, ok := fileEnv[key]; !ok {
+			if original, set := os.LookupEnv(key); set {
+				fileEnv[key] = &original
+			} else {
+				fileEnv[key] = nil
+			}
+		}
+		if err := os.Setenv(key, value); err != nil {
+			return fmt.Errorf("config file %s: %s: %w", path, key, err)
+		}
+	}
+	return nil
+}
+
+// readEnvFile parses the KEY=VALUE lines of an env file.
+func readEnvFile(path string) (map[string]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("config file: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	values := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		key, value, ok := strings.Cut(strings.TrimPrefix(line, "export "), "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" || strings.ContainsAny(key, " \t") {
+			return nil, fmt.Errorf("config file %s line %d: expected KEY=VALUE", path, n)
+		}
+		value = strings.TrimSpace(value)
+		if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+			value = value[1 : len(value)-1]
+		}
+		values[key] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("config file %s: %w", path, err)
+	}
+	return values, nil
+}
+
+// WithLive returns c with the settings that can change while the daemon runs
+// taken from next: grace periods, expiration, tracking and route limits, the
+// prefix conflict policy, configured NAT64 prefixes, route overrides, the next
+// hop policy, the IPv4-only device warning, the sync interval and the sync
+// log. Everything else keeps its value from c until a restart.
+func (c Config) WithLive(next Config) Config {
+	c.RouteGracePeriod = next.RouteGracePeriod
+	c.GraceRules = next.GraceRules
+	c.DeviceExpiration = next.DeviceExpiration
+	c.Tracking = next.Tracking
+	c.PrefixConflictPolicy = next.PrefixConflictPolicy
+	c.Discovery.NAT64Prefixes = next.Discovery.NAT64Prefixes
+	c.Overrides = next.Overrides
+	c.NextHops = next.NextHops
+	c.WarnIPv4Only = next.WarnIPv4Only
+	c.UniFi.Limits = next.UniFi.Limits
+	c.UniFi.SyncLog = next.UniFi.SyncLog
+	c.UniFi.SyncInterval = next.UniFi.SyncInterval
+	return c
+}
+
+// Diff returns the dotted names of the settings that differ between a and b,
+// such as "UniFi.Limits.MaxRoutes". Values are left out, as they may be secrets.
+func Diff(a, b Config) []string {
+	var changed []string
+	diffValue("", reflect.ValueOf(a), reflect.ValueOf(b), &changed)
+	return changed
+}
+
+// diffValue appends the names of the fields of a and b that differ, recursing
+// into the structs of this package.
+func diffValue(name string, a, b reflect.Value, changed *[]string) {
+	if a.Kind() == reflect.Struct && a.Type().PkgPath() == reflect.TypeOf(Config{}).PkgPath() {
+		for i := 0; i < a.NumField(); i++ {
+			field := a.Type().Field(i)
+			if !field.IsExported() {
+				continue
+			}
+			fieldName := field.Name
+			if name != "" {
+				fieldName = name + "." + field.Name
+			}
+			diffValue(fieldName, a.Field(i), b.Field(i), changed)
+		}
+		return
+	}
+	if !reflect.DeepEqual(a.Interface(), b.Interface()) {
+		*changed = append(*changed, name)
+	}
+}
+
+// Live holds the configuration in effect, shared between the goroutines that
+// read it and the reload that replaces it.
+type Live struct {
+	mu  sync.RWMutex
+	cfg Config
+}
+
+// NewLive returns a Live holding cfg.
+func NewLive(cfg Config) *Live {
+	return &Live{cfg: cfg}
+}
+
+// Get returns the configuration in effect.
+func (l *Live) Get() Config {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.cfg
+}
+
+// Set replaces the configuration in effect.
+func (l *Live) Set(cfg Config) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.cfg = cfg
+}
@@ -0,0 +1,113 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+// writeEnvFile writes content to an env file in a temporary directory.
+func writeEnvFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.env")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// TestLoadFile verifies env file values override the environment and that
+// variables dropped from the file get their original values back.
+func TestLoadFile(t *testing.T) {
+	t.Setenv("ROUTE_GRACE_PERIOD", "10m")
+	t.Setenv("LOG_LEVEL", "")
+	_ = os.Unsetenv("LOG_LEVEL")
+
+	path := writeEnvFile(t, "# grace\nROUTE_GRACE_PERIOD=30m\nexport LOG_LEVEL=\"DEBUG\"\n\nHA_URL='http://ha.local:8123'\n")
+	if err := LoadFile(path); err != nil {
+		t.Fatal(err)
+	}
+	for key, want := range map[string]string{"ROUTE_GRACE_PERIOD": "30m", "LOG_LEVEL": "DEBUG", "HA_URL": "http://ha.local:8123"} {
+		if got := os.Getenv(key); got != want {
+			t.Errorf("Expected %s=%q, got %q", key, want, got)
+		}
+	}
+
+	if err := os.WriteFile(path, []byte("HA_URL=http://other:8123\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if got := os.Getenv("ROUTE_GRACE_PERIOD"); got != "10m" {
+		t.Errorf("Expected ROUTE_GRACE_PERIOD restored to 10m, got %q", got)
+	}
+	if _, set := os.LookupEnv("LOG_LEVEL"); set {
+		t.Error("Expected LOG_LEVEL unset again")
+	}
+
+	if err := os.WriteFile(path, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := LoadFile(path); err != nil {
+		t.Fatal(err)
+	}
+	if _, set := os.LookupEnv("HA_URL"); set {
+		t.Error("Expected HA_URL unset once dropped from the file")
+	}
+}
+
+// TestLoadFileErrors verifies malformed or missing files are rejected.
+func TestLoadFileErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{"no equals", "ROUTE_GRACE_PERIOD\n"},
+		{"empty key", "=30m\n"},
+		{"space in key", "ROUTE GRACE=30m\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := LoadFile(writeEnvFile(t, tt.content)); err == nil {
+				t.Error("Expected an error")
+			}
+		})
+	}
+	if err := LoadFile(filepath.Join(t.TempDir(), "missing.env")); err == nil {
+		t.Error("Expected an error for a missing file")
+	}
+}
+
+// TestDiffAndWithLive verifies changed settings are named and that only the
+// live ones are taken over.
+func TestDiffAndWithLive(t *testing.T) {
+	current := Config{RouteGracePeriod: 10 * time.Minute, StatusAddr: ":8080"}
+	current.UniFi.Password = "old"
+	loaded := current
+	loaded.RouteGracePeriod = time.Hour
+	loaded.UniFi.Limits.MaxRoutes = 10
+	loaded.UniFi.Password = "new"
+	loaded.StatusAddr = ":9090"
+
+	if got := Diff(current, current); len(got) != 0 {
+		t.Errorf("Expected no differences, got %v", got)
+	}
+	want := []string{"UniFi.Password", "UniFi.Limits.MaxRoutes", "RouteGracePeriod", "StatusAddr"}
+	if got := Diff(current, loaded); !slices.Equal(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+
+	next := current.WithLive(loaded)
+	if next.RouteGracePeriod != time.Hour || next.UniFi.Limits.MaxRoutes != 10 {
+		t.Errorf("Expected the live settings to be applied, got %+v", next)
+	}
+	if next.StatusAddr != ":8080" || next.UniFi.Password != "old" {
+		t.Errorf("Expected restart-only settings to keep their values, got %+v", next)
+	}
+	if got := Diff(next, loaded); !slices.Equal(got, []string{"UniFi.Password", "StatusAddr"}) {
+		t.Errorf("Expected the restart-only changes, got %v", got)
+	}
+}
@@ -0,0 +1,103 @@
+package config
+
+import (
+	"net/netip"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/logger"
+)
+
+// RouteFields are the distance and description given to the routes the
+// daemon creates, globally and per Thread network. A higher distance lets
+// manual routes to the same network take precedence.
+type RouteFields struct {
+	Distance    int    // lowest distance of new routes; 0 starts from 1
+	Description string // empty leaves the field out
+	// Rules override Distance and Description for routes to networks within
+	// theirs; the first containing rule setting a field wins.
+	Rules []RouteFieldRule
+}
+
+// RouteFieldRule sets the distance or description of routes within Network.
+type RouteFieldRule struct {
+	Network     netip.Prefix
+	Distance    int
+	Description string
+}
+
+// For returns the distance and description of routes to network, a CIDR such
+// as "fd00::/64".
+func (f RouteFields) For(network string) (distance int, description string) {
+	distance, description = f.Distance, f.Description
+	prefix, err := netip.ParsePrefix(network)
+	if err != nil {
+		return distance, description
+	}
+	var haveDistance, haveDescription bool
+	for _, r := range f.Rules {
+		if !r.Network.Contains(prefix.Addr()) || prefix.Bits() < r.Network.Bits() {
+			continue
+		}
+		if r.Distance > 0 && !haveDistance {
+			distance, haveDistance = r.Distance, true
+		}
+		if r.Description != "" && !haveDescription {
+			description, haveDescription = r.Description, true
+		}
+	}
+	return distance, description
+}
+
+// loadRouteFields reads ROUTE_DISTANCE, ROUTE_DESCRIPTION and their per
+// network forms ROUTE_DISTANCES and ROUTE_DESCRIPTIONS.
+func loadRouteFields() RouteFields {
+	f := RouteFields{
+		Distance:    parseIntEnv("ROUTE_DISTANCE", 0),
+		Description: strings.TrimSpace(os.Getenv("ROUTE_DESCRIPTION")),
+	}
+	for _, rule := range parseFieldRules("ROUTE_DISTANCES", os.Getenv("ROUTE_DISTANCES")) {
+		n, err := strconv.Atoi(rule.value)
+		if err != nil || n < 1 {
+			logger.Warn("Invalid ROUTE_DISTANCES distance %q for %s, expected a positive number", rule.value, rule.network)
+			continue
+		}
+		f.Rules = append(f.Rules, RouteFieldRule{Network: rule.network, Distance: n})
+	}
+	for _, rule := range parseFieldRules("ROUTE_DESCRIPTIONS", os.Getenv("ROUTE_DESCRIPTIONS")) {
+		f.Rules = append(f.Rules, RouteFieldRule{Network: rule.network, Description: rule.value})
+	}
+	return f
+}
+
+// fieldRule is a cidr=value entry of a per network setting.
+type fieldRule struct {
+	network netip.Prefix
+	value   string
+}
+
+// parseFieldRules parses the comma-separated cidr=value entries of key.
+// Malformed entries are skipped with a warning.
+func parseFieldRules(key, s string) []fieldRule {
+	var rules []fieldRule
+	for _, entry := range strings.Split(s, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		network, value, ok := strings.Cut(entry, "=")
+		value = strings.TrimSpace(value)
+		if !ok || value == "" {
+			logger.Warn("Invalid %s entry %q, expected cidr=value", key, entry)
+			continue
+		}
+		cidr, err := netip.ParsePrefix(strings.TrimSpace(network))
+		if err != nil {
+			logger.Warn("Invalid %s network in %q: %v", key, entry, err)
+			continue
+		}
+		rules = append(rules, fieldRule{network: cidr.Masked(), value: value})
+	}
+	return rules
+}
@@ -0,0 +1,34 @@
+package config
+
+import (
+	"testing"
+)
+
+func TestRouteFieldsFor(t *testing.T) {
+	t.Setenv("ROUTE_DISTANCE", "100")
+	t.Setenv("ROUTE_DESCRIPTION", "Thread network")
+	t.Setenv("ROUTE_DISTANCES", "fd12:3456::/32=200, fd12:3456:1::/48=250, fd00::/8=x, bad=5")
+	t.Setenv("ROUTE_DESCRIPTIONS", "fd12:3456:1::/48=Upstairs mesh,fd99::/16=")
+
+	f := loadRouteFields()
+	if len(f.Rules) != 3 {
+		t.Fatalf("Expected 3 valid rules, got %+v", f.Rules)
+	}
+	tests := []struct {
+		network     string
+		distance    int
+		description string
+	}{
+		{"fd12:3456:1:2::/64", 200, "Upstairs mesh"}, // first distance rule wins
+		{"fd12:3456:2::/64", 200, "Thread network"},
+		{"fd99::/64", 100, "Thread network"},
+		{"fd12::/16", 100, "Thread network"}, // wider than every rule
+		{"not a network", 100, "Thread network"},
+	}
+	for _, tt := range tests {
+		distance, description := f.For(tt.network)
+		if distance != tt.distance || description != tt.description {
+			t.Errorf("For(%s): expected %d %q, got %d %q", tt.network, tt.distance, tt.description, distance, description)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/logger"
+)
+
+// Window is a recurring maintenance window: it opens at every time matching a
+// five-field cron schedule (minute hour day-of-month month day-of-week, local
+// time) and stays open for Duration.
+type Window struct {
+	Schedule string
+	Duration time.Duration
+	fields   [5]map[int]bool
+}
+
+// Windows is a set of maintenance windows. An empty set is always open.
+type Windows []Window
+
+// cronRanges are the value ranges of the five cron fields.
+var cronRanges = [5][2]int{{0, 59}, {0, 23}, {1, 31}, {1, 12}, {0, 6}}
+
+// Open reports whether t falls within any of the windows, or true when there are none.
+func (ws Windows) Open(t time.Time) bool {
+	if len(ws) == 0 {
+		return true
+	}
+	for _, w := range ws {
+		if w.Open(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// Open reports whether the window opened within Duration before t.
+func (w Window) Open(t time.Time) bool {
+	t = t.Truncate(time.Minute)
+	for start := t; t.Sub(start) < w.Duration; start = start.Add(-time.Minute) {
+		if w.matches(start) {
+			return true
+		}
+	}
+	return false
+}
+
+// matches reports whether t matches the cron schedule. As in cron, when both
+// day-of-month and day-of-week are restricted, either one matching is enough.
+func (w Window) matches(t time.Time) bool {
+	if !w.fields[0][t.Minute()] || !w.fields[1][t.Hour()] || !w.fields[3][int(t.Month())] {
+		return false
+	}
+	dom, dow := w.fields[2][t.Day()], w.fields[4][int(t.Weekday())]
+	if len(w.fields[2]) < 31 && len(w.fields[4]) < 7 {
+		return dom || dow
+	}
+	return dom && dow
+}
+
+// parseWindows parses a semicolon-separated list of "schedule@duration" windows,
+// e.g. "0 2 * * *@2h;0 10 * * 6,0@3h". Malformed entries are skipped with a warning.
+func parseWindows(key, s string) Windows {
+	var windows Windows
+	for _, entry := range strings.Split(s, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		schedule, duration, ok := strings.Cut(entry, "@")
+		if !ok {
+			logger.Warn("Invalid %s entry %q, expected schedule@duration", key, entry)
+			continue
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(duration))
+		if err != nil || d <= 0 {
+			logger.Warn("Invalid %s duration in %q", key, entry)
+			continue
+		}
+		w := Window{Schedule: strings.TrimSpace(schedule), Duration: d}
+		parts := strings.Fields(w.Schedule)
+		if len(parts) != 5 {
+			logger.Warn("Invalid %s schedule %q: want 5 cron fields", key, w.Schedule)
+			continue
+		}
+		valid := true
+		for i, part := range parts {
+			values, err := parseCronField(part, cronRanges[i][0], cronRanges[i][1])
+			if err != nil {
+				logger.Warn("Invalid %s schedule %q: %v", key, w.Schedule, err)
+				valid = false
+				break
+			}
+			w.fields[i] = values
+		}
+		if valid {
+			windows = append(windows, w)
+		}
+	}
+	return windows
+}
+
+// parseCronField expands a cron field such as "*", "1-5", "*/15" or "0,30" into
+// the set of values it matches within [lo, hi]. Day-of-week 7 means Sunday.
+func parseCronField(field string, lo, hi int) (map[int]bool, error) {
+	values := make(map[int]bool)
+	for _, item := range strings.Split(field, ",") {
+		rng, stepStr, hasStep := strings.Cut(item, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepStr)
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("bad step in %q", item)
+			}
+			step = n
+		}
+		from, to := lo, hi
+		if rng != "*" {
+			a, b, isRange := strings.Cut(rng, "-")
+			var err error
+			if from, err = strconv.Atoi(a); err != nil {
+				return nil, fmt.Errorf("bad value %q", item)
+			}
+			to = from
+			if isRange {
+				if to, err = strconv.Atoi(b); err != nil {
+					return nil, fmt.Errorf("bad range %q", item)
+				}
+			} else if hasStep {
+				to = hi
+			}
+		}
+		if hi == 6 && to == 7 {
+			// Allow 7 for Sunday in day-of-week fields.
+			if from == 7 {
+				from = 0
+				to = 0
+			} else {
+				to = 6
+				values[0] = true
+			}
+		}
+		if from < lo || to > hi || from > to {
+			return nil, fmt.Errorf("%q out of range %d-%d", item, lo, hi)
+		}
+		for v := from; v <= to; v += step {
+			values[v] = true
+		}
+	}
+	return values, nil
+}
@@ -0,0 +1,50 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseWindows(t *testing.T) {
+	windows := parseWindows("ROUTE_REMOVAL_WINDOWS", "0 2 * * *@2h; 30 9-17/2 * * 1-5@30m;bogus;0 2 * *@1h;0 25 * * *@1h;0 2 * * *@never")
+	if len(windows) != 2 {
+		t.Fatalf("Expected 2 valid windows, got %d: %+v", len(windows), windows)
+	}
+	if windows[1].Schedule != "30 9-17/2 * * 1-5" || windows[1].Duration != 30*time.Minute {
+		t.Errorf("Unexpected second window %+v", windows[1])
+	}
+}
+
+func TestWindowsOpen(t *testing.T) {
+	windows := parseWindows("ROUTE_REMOVAL_WINDOWS", "0 2 * * *@2h;0 10 * * 6,7@1h")
+	at := func(day, hour, minute int) time.Time {
+		// 2026-03-02 is a Monday.
+		return time.Date(2026, 3, day, hour, minute, 0, 0, time.Local)
+	}
+
+	tests := []struct {
+		name     string
+		time     time.Time
+		expected bool
+	}{
+		{"window start", at(2, 2, 0), true},
+		{"inside window", at(2, 3, 59), true},
+		{"window end", at(2, 4, 0), false},
+		{"before window", at(2, 1, 59), false},
+		{"weekday morning", at(2, 10, 30), false},
+		{"saturday morning", at(7, 10, 30), true},
+		{"sunday as 7", at(8, 10, 30), true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := windows.Open(tt.time); got != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+
+	if !(Windows{}).Open(at(2, 20, 0)) {
+		t.Error("Expected an empty window set to always be open")
+	}
+}
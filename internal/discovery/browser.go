@@ -0,0 +1,47 @@
+package discovery
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/config"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/logger"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/pkg/dnssd"
+)
+
+// Instance is a resolved DNS-SD service instance.
+type Instance = dnssd.Instance
+
+// Browser is a DNS-SD discovery backend.
+type Browser = dnssd.Browser
+
+// NewBrowser returns the discovery backend selected by cfg, browsing every
+// configured domain, logging through the daemon's logger and instrumented for
+// the discovery metrics.
+func NewBrowser(cfg config.Discovery) (Browser, error) {
+	backend := cfg.Backend
+	if backend == "" {
+		backend = "zeroconf"
+	}
+	if backend == "unicast" && cfg.Server == "" {
+		return nil, errors.New("unicast discovery requires DNSSD_SERVER")
+	}
+	if cfg.Mode == "passive" && backend != "zeroconf" {
+		return nil, fmt.Errorf("DISCOVERY_MODE=passive needs the zeroconf backend, not %q", backend)
+	}
+	browser, err := dnssd.NewBrowser(dnssd.Options{
+		Backend:       backend,
+		Mode:          cfg.Mode,
+		Server:        cfg.Server,
+		Domains:       cfg.Domains,
+		PollInterval:  cfg.PollInterval,
+		RenewInterval: cfg.RenewInterval,
+		Timeout:       cfg.Timeout,
+		Logger:        logger.Printf{},
+		Observer:      roundMetrics{},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return meteredBrowser{Browser: browser, backend: backend}, nil
+}
@@ -0,0 +1,130 @@
+package discovery
+
+import (
+	"net/netip"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/logger"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/poller"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/pkg/dnssd"
+)
+
+// CommissionableService is the DNS-SD service type Matter devices announce
+// while their commissioning window is open.
+const CommissionableService = "_matterc._udp"
+
+// CommissionableNode is a Matter device awaiting setup, as announced in the
+// TXT records of its _matterc._udp instance.
+type CommissionableNode struct {
+	Name          string `json:"name"`
+	Hostname      string `json:"hostname,omitempty"`
+	DeviceName    string `json:"device_name,omitempty"` // DN=
+	Discriminator int    `json:"discriminator"`         // D=, the 12-bit long discriminator
+	// ShortDiscriminator is the upper 4 bits of Discriminator, the part a
+	// manual pairing code carries.
+	ShortDiscriminator int          `json:"short_discriminator"`
+	VendorID           int          `json:"vendor_id,omitempty"`          // VP= vendor part
+	ProductID          int          `json:"product_id,omitempty"`         // VP= product part
+	CommissioningMode  int          `json:"commissioning_mode,omitempty"` // CM=: 1 basic, 2 enhanced window
+	PairingHint        int          `json:"pairing_hint,omitempty"`       // PH=
+	IPv6Addrs          []netip.Addr `json:"ipv6_addrs"`
+	LastSeen           time.Time    `json:"last_seen"`
+}
+
+// ParseCommissionableNode returns the node a _matterc._udp instance announces,
+// seen now. It returns false when the instance has no valid discriminator or
+// announces CM=0, meaning its commissioning window is closed.
+func ParseCommissionableNode(inst Instance) (CommissionableNode, bool) {
+	discriminator, err := strconv.Atoi(txtValue(inst.Text, "D"))
+	if err != nil || discriminator < 0 || discriminator > 0xfff {
+		return CommissionableNode{}, false
+	}
+	node := CommissionableNode{
+		Name:               dnssd.InstanceLabel(inst.Name),
+		Hostname:           strings.TrimSuffix(inst.Host, "."),
+		DeviceName:         txtValue(inst.Text, "DN"),
+		Discriminator:      discriminator,
+		ShortDiscriminator: discriminator >> 8,
+		IPv6Addrs:          inst.Addrs,
+		LastSeen:           time.Now(),
+	}
+	node.CommissioningMode, _ = strconv.Atoi(txtValue(inst.Text, "CM"))
+	if node.CommissioningMode == 0 {
+		return CommissionableNode{}, false
+	}
+	node.PairingHint, _ = strconv.Atoi(txtValue(inst.Text, "PH"))
+	vendor, product, _ := strings.Cut(txtValue(inst.Text, "VP"), "+")
+	node.VendorID, _ = strconv.Atoi(vendor)
+	node.ProductID, _ = strconv.Atoi(product)
+	return node, true
+}
+
+// Commissionable tracks the Matter devices with an open commissioning window.
+// It is safe for concurrent use.
+type Commissionable struct {
+	maxAge time.Duration
+
+	mu    sync.Mutex
+	nodes map[string]CommissionableNode
+}
+
+// NewCommissionable returns an empty list forgetting nodes not seen within maxAge.
+func NewCommissionable(maxAge time.Duration) *Commissionable {
+	return &Commissionable{maxAge: maxAge, nodes: make(map[string]CommissionableNode)}
+}
+
+// Browse records the instances of the commissionable service until done is closed.
+func (c *Commissionable) Browse(browser Browser, done <-chan struct{}) {
+	browser.Browse(CommissionableService, done, func(inst Instance) {
+		logger.Debug("DNS-SD %s: name=%s ips=%v txt=%v", CommissionableService, inst.Name, inst.Addrs, inst.Text)
+		node, ok := ParseCommissionableNode(inst)
+		if !ok {
+			return
+		}
+		c.mu.Lock()
+		_, known := c.nodes[node.Name]
+		c.nodes[node.Name] = node
+		c.mu.Unlock()
+		if !known {
+			logger.Info("Matter device awaiting commissioning: %s (discriminator %d, vendor %d, product %d)",
+				node.Name, node.Discriminator, node.VendorID, node.ProductID)
+		}
+	})
+}
+
+// List returns the nodes seen within maxAge, sorted by name, forgetting older ones.
+func (c *Commissionable) List() []CommissionableNode {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	nodes := []CommissionableNode{}
+	for name, node := range c.nodes {
+		if time.Since(node.LastSeen) > c.maxAge {
+			delete(c.nodes, name)
+			continue
+		}
+		nodes = append(nodes, node)
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Name < nodes[j].Name })
+	return nodes
+}
+
+// Open reports whether any commissioning window is open.
+func (c *Commissionable) Open() bool {
+	return len(c.List()) > 0
+}
+
+// WhileOpen calls fn every interval while a commissioning window is open, so
+// a device joining the network during setup is discovered sooner, until done
+// is closed.
+func (c *Commissionable) WhileOpen(interval time.Duration, done <-chan struct{}, fn func()) {
+	poller.Run(done, interval, "commissioning discovery", func() error {
+		if c.Open() {
+			fn()
+		}
+		return nil
+	})
+}
@@ -0,0 +1,73 @@
+package discovery
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestParseCommissionableNode(t *testing.T) {
+	tests := []struct {
+		name     string
+		txt      []string
+		ok       bool
+		expected CommissionableNode
+	}{
+		{
+			"enhanced window",
+			[]string{"D=3840", "VP=4937+258", "CM=2", "DN=Eve Energy", "PH=33"},
+			true,
+			CommissionableNode{Discriminator: 3840, ShortDiscriminator: 15, VendorID: 4937, ProductID: 258, CommissioningMode: 2, DeviceName: "Eve Energy", PairingHint: 33},
+		},
+		{"vendor only", []string{"D=100", "VP=65521", "CM=1"}, true, CommissionableNode{Discriminator: 100, VendorID: 65521, CommissioningMode: 1}},
+		{"window closed", []string{"D=3840", "CM=0"}, false, CommissionableNode{}},
+		{"no discriminator", []string{"CM=1"}, false, CommissionableNode{}},
+		{"discriminator out of range", []string{"D=4096", "CM=1"}, false, CommissionableNode{}},
+	}
+
+	for _, tt := range tests {
+		inst := Instance{Name: "8F2A1C3D4E5B6071._matterc._udp.local.", Host: "B8F44F123456.local.", Text: tt.txt}
+		got, ok := ParseCommissionableNode(inst)
+		if ok != tt.ok {
+			t.Errorf("%s: expected ok=%v, got %v", tt.name, tt.ok, ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		got.LastSeen = time.Time{}
+		tt.expected.Name, tt.expected.Hostname = "8F2A1C3D4E5B6071", "B8F44F123456.local"
+		if got.Name != tt.expected.Name || got.Hostname != tt.expected.Hostname || got.Discriminator != tt.expected.Discriminator ||
+			got.ShortDiscriminator != tt.expected.ShortDiscriminator || got.VendorID != tt.expected.VendorID ||
+			got.ProductID != tt.expected.ProductID || got.CommissioningMode != tt.expected.CommissioningMode ||
+			got.DeviceName != tt.expected.DeviceName || got.PairingHint != tt.expected.PairingHint {
+			t.Errorf("%s: expected %+v, got %+v", tt.name, tt.expected, got)
+		}
+	}
+}
+
+func TestCommissionable(t *testing.T) {
+	addr := netip.MustParseAddr("fe80::1")
+	nodes := NewCommissionable(10 * time.Minute)
+	if nodes.Open() {
+		t.Error("Expected no commissioning window open before browsing")
+	}
+	nodes.Browse(staticBrowser{
+		{Name: "B._matterc._udp.local.", Addrs: []netip.Addr{addr}, Text: []string{"D=1", "CM=1"}},
+		{Name: "A._matterc._udp.local.", Addrs: []netip.Addr{addr}, Text: []string{"D=2", "CM=1"}},
+		{Name: "C._matterc._udp.local.", Text: []string{"D=3", "CM=0"}},
+	}, nil)
+	nodes.mu.Lock()
+	stale := nodes.nodes["B"]
+	stale.LastSeen = time.Now().Add(-time.Hour)
+	nodes.nodes["B"] = stale
+	nodes.mu.Unlock()
+
+	got := nodes.List()
+	if len(got) != 1 || got[0].Name != "A" || got[0].Discriminator != 2 {
+		t.Errorf("Expected only node A listed, got %+v", got)
+	}
+	if !nodes.Open() {
+		t.Error("Expected a commissioning window open")
+	}
+}
@@ -0,0 +1,171 @@
+// Package discovery finds Thread Border Routers and Thread mesh prefixes via DNS-SD
+// and Home Assistant, reporting them to a Sink.
+package discovery
+
+import (
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/pkg/threaddiscovery"
+)
+
+// BorderRouter represents a discovered Thread Border Router
+type BorderRouter struct {
+	Name       string       `json:"name"`
+	ExtAddress string       `json:"ext_address,omitempty"` // Thread extended address (xa), hex
+	ExtPANID   string       `json:"ext_pan_id,omitempty"`  // Thread extended PAN ID (xp), hex
+	OMRPrefix  netip.Prefix `json:"omr_prefix,omitzero"`   // Thread mesh prefix announced in omr=
+	Type       string       `json:"type,omitempty"`        // model family, see RouterType
+	IPv6Addrs  []netip.Addr `json:"ipv6_addrs"`
+	LastSeen   time.Time    `json:"last_seen"`
+	Uplink                  // where the UniFi controller sees it, when it does
+}
+
+// Border router uplinks, as reported by the UniFi client list.
+const (
+	LinkWired    = "wired"
+	LinkWireless = "wireless"
+)
+
+// Uplink is where a border router attaches to the LAN, as far as the UniFi
+// controller knows it.
+type Uplink struct {
+	Link        string `json:"link,omitempty"`        // LinkWired or LinkWireless
+	Network     string `json:"network,omitempty"`     // controller network the router is on
+	VLAN        int    `json:"vlan,omitempty"`        // VLAN ID of that network, 0 for untagged
+	Unreachable bool   `json:"unreachable,omitempty"` // the gateway does not route IPv6 on that network
+}
+
+// MatterDevice represents a discovered Matter device
+type MatterDevice struct {
+	Name      string       `json:"name"`
+	Hostname  string       `json:"hostname,omitempty"`
+	MAC       string       `json:"mac,omitempty"`
+	Vendor    string       `json:"vendor,omitempty"`
+	IPv6Addrs []netip.Addr `json:"ipv6_addrs"`
+	IPv4Addrs []netip.Addr `json:"ipv4_addrs,omitempty"` // shown for completeness, never routed
+	LastSeen  time.Time    `json:"last_seen"`
+}
+
+// IPv4Only reports whether the device announced IPv4 addresses but no IPv6
+// ones, so it is not on Thread: Thread devices only ever have IPv6.
+func (d MatterDevice) IPv4Only() bool {
+	return len(d.IPv4Addrs) > 0 && len(d.IPv6Addrs) == 0
+}
+
+// Description returns the device name with its vendor and hardware address, as
+// far as they are known, e.g. "Kitchen Light (Signify (Philips Hue), 00:17:88:01:02:03)".
+func (d MatterDevice) Description() string {
+	var details []string
+	for _, s := range []string{d.Vendor, d.MAC} {
+		if s != "" {
+			details = append(details, s)
+		}
+	}
+	if len(details) == 0 {
+		return d.Name
+	}
+	return d.Name + " (" + strings.Join(details, ", ") + ")"
+}
+
+// OUIDatabase maps IEEE organizationally unique identifiers to vendor names.
+type OUIDatabase = threaddiscovery.OUIDatabase
+
+// Sink receives discovery results. Implementations must be safe for concurrent use.
+type Sink interface {
+	// MergeBorderRouter records a sighting of a border router, accumulating its
+	// addresses.
+	MergeBorderRouter(router BorderRouter)
+	// MergeDevice records a sighting of a Matter device, accumulating its addresses.
+	MergeDevice(device MatterDevice)
+	// ObservePrefix records a sighting of a Thread mesh prefix and reports
+	// whether it was new.
+	ObservePrefix(prefix netip.Prefix) bool
+	// RefreshPrefix records a sighting of a Thread mesh prefix only if it is
+	// already known, reporting whether it was.
+	RefreshPrefix(prefix netip.Prefix) bool
+	// MergeTRELPeer records a sighting of a TREL peer, accumulating its addresses.
+	MergeTRELPeer(peer TRELPeer)
+	// ObserveNAT64 records a NAT64 prefix valid for lifetime; a zero lifetime
+	// withdraws it.
+	ObserveNAT64(prefix netip.Prefix, lifetime time.Duration)
+}
+
+// BrowseMatterDevices browses for Matter devices, recording them with their host
+// name, hardware address and vendor (looked up in ouis), and extracting Thread
+// mesh prefixes from their ULA addresses — a fallback for TBRs that don't
+// advertise omr= in mDNS.
+func BrowseMatterDevices(sink Sink, browser Browser, ouis OUIDatabase, done <-chan struct{}) {
+	browser.Browse("_matter._tcp", done, func(inst Instance) {
+		if n, ok := deviceSighting(inst, ouis); ok {
+			MergeNetwork(sink, n)
+		}
+	})
+}
+
+// ParseMatterDevice returns the Matter device a _matter._tcp instance
+// announces, seen now, with its vendor looked up in ouis. It returns false
+// when the instance has no address.
+func ParseMatterDevice(inst Instance, ouis OUIDatabase) (MatterDevice, bool) {
+	d, ok := threaddiscovery.ParseDevice(inst, ouis)
+	if !ok {
+		return MatterDevice{}, false
+	}
+	return MatterDevice{
+		Name:      d.Name,
+		Hostname:  d.Host,
+		MAC:       d.MAC,
+		Vendor:    d.Vendor,
+		IPv6Addrs: d.IPv6Addrs,
+		IPv4Addrs: d.IPv4Addrs,
+		LastSeen:  d.Seen,
+	}, true
+}
+
+// BrowseBorderRouters continuously browses for Thread Border Routers.
+func BrowseBorderRouters(sink Sink, browser Browser, done <-chan struct{}) {
+	browser.Browse("_meshcop._udp", done, func(inst Instance) {
+		if n, ok := routerSighting(inst); ok {
+			MergeNetwork(sink, n)
+		}
+	})
+}
+
+// ParseBorderRouter returns the border router a _meshcop._udp instance
+// announces, seen now. It returns false when the instance has no IPv6 address.
+func ParseBorderRouter(inst Instance) (BorderRouter, bool) {
+	r, ok := threaddiscovery.ParseBorderRouter(inst)
+	if !ok {
+		return BorderRouter{}, false
+	}
+	return BorderRouter{
+		Name:       r.Name,
+		ExtAddress: r.ExtAddress,
+		ExtPANID:   r.ExtPANID,
+		OMRPrefix:  r.OMRPrefix,
+		Type:       r.Type,
+		IPv6Addrs:  r.IPv6Addrs,
+		LastSeen:   r.Seen,
+	}, true
+}
+
+// AppendUnique appends ip to the slice only if not already present.
+func AppendUnique(ips []netip.Addr, ip netip.Addr) []netip.Addr {
+	for _, existing := range ips {
+		if existing == ip {
+			return ips
+		}
+	}
+	return append(ips, ip)
+}
+
+// CIDR64 calculates the /64 CIDR block for an IPv6 address.
+// Returns the zero Prefix for invalid, IPv4, or IPv4-mapped addresses.
+func CIDR64(ip netip.Addr) netip.Prefix {
+	if !ip.Is6() || ip.Is4In6() {
+		return netip.Prefix{}
+	}
+	prefix, _ := ip.WithZone("").Prefix(64)
+	return prefix
+}
@@ -0,0 +1,89 @@
+package discovery
+
+import (
+	"net/netip"
+	"testing"
+)
+
+// TestMatterDeviceIPv4Only verifies only devices with IPv4 but no IPv6
+// addresses count as IPv4-only.
+func TestMatterDeviceIPv4Only(t *testing.T) {
+	v4 := []netip.Addr{netip.MustParseAddr("192.168.1.10")}
+	v6 := []netip.Addr{netip.MustParseAddr("fd00::1")}
+	tests := []struct {
+		device MatterDevice
+		want   bool
+	}{
+		{MatterDevice{Name: "WiFi Plug", IPv4Addrs: v4}, true},
+		{MatterDevice{Name: "Dual Stack", IPv4Addrs: v4, IPv6Addrs: v6}, false},
+		{MatterDevice{Name: "Thread Light", IPv6Addrs: v6}, false},
+		{MatterDevice{Name: "Nothing"}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.device.IPv4Only(); got != tt.want {
+			t.Errorf("Expected IPv4Only() of %s to be %v, got %v", tt.device.Name, tt.want, got)
+		}
+	}
+}
+
+func TestCIDR64(t *testing.T) {
+	tests := []struct {
+		name     string
+		ip       string
+		expected string
+	}{
+		{"ULA address", "fd00:1234:5678:9abc::1", "fd00:1234:5678:9abc::/64"},
+		{"Link-local address", "fe80::1", "fe80::/64"},
+		{"Documentation address", "2001:db8::1", "2001:db8::/64"},
+		{"Global unicast address", "2001:4860:4860::8888", "2001:4860:4860::/64"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ip, err := netip.ParseAddr(tt.ip)
+			if err != nil {
+				t.Fatalf("Failed to parse IP: %s", tt.ip)
+			}
+			result := CIDR64(ip).String()
+			if result != tt.expected {
+				t.Errorf("CIDR64(%s) = %s, want %s", tt.ip, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestCIDR64EdgeCases(t *testing.T) {
+	tests := []struct {
+		name       string
+		ip         string
+		expected   string
+		shouldFail bool
+	}{
+		{"IPv4 address returns empty string", "192.168.1.1", "", false},
+		{"Invalid IP should fail", "invalid-ip", "", true},
+		{"Empty string should fail", "", "", true},
+		{"IPv6 with /128 prefix", "2001:db8::1", "2001:db8::/64", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ip, err := netip.ParseAddr(tt.ip)
+			if tt.shouldFail {
+				if err == nil {
+					t.Errorf("Expected IP parsing to fail for %s, but got %v", tt.ip, ip)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Failed to parse IP: %s", tt.ip)
+			}
+			result := ""
+			if prefix := CIDR64(ip); prefix.IsValid() {
+				result = prefix.String()
+			}
+			if result != tt.expected {
+				t.Errorf("CIDR64(%s) = %s, want %s", tt.ip, result, tt.expected)
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+package discovery
+
+import (
+	"net/netip"
+	"testing"
+)
+
+// Addresses come from whatever happens to be on the network, so this fuzz
+// target checks that malformed input never panics and never produces values
+// the rest of the program would choke on.
+// Run it with go test -fuzz=FuzzCIDR64 ./internal/discovery
+
+func FuzzCIDR64(f *testing.F) {
+	for _, seed := range []string{
+		"fd00:1111:2222:3333::1",
+		"fe80::1%eth0",
+		"::ffff:192.168.1.1",
+		"192.168.1.1",
+		"::",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, s string) {
+		ip, err := netip.ParseAddr(s)
+		if err != nil {
+			return
+		}
+		prefix := CIDR64(ip)
+		if !prefix.IsValid() {
+			if ip.Is6() && !ip.Is4In6() {
+				t.Errorf("Expected a /64 for %s", ip)
+			}
+			return
+		}
+		if prefix.Bits() != 64 || prefix != prefix.Masked() || prefix.Addr().Zone() != "" {
+			t.Errorf("Expected a masked /64 without zone for %s, got %s", ip, prefix)
+		}
+		if !prefix.Contains(ip.WithZone("")) {
+			t.Errorf("Expected %s to contain %s", prefix, ip)
+		}
+	})
+}
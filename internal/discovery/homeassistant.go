@@ -1,32 +1,35 @@
-package main
+package discovery
 
 import (
 	"crypto/tls"
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
-	"net"
 	"net/http"
+	"net/netip"
 	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/config"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/logger"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/poller"
 )
 
 type haDataset struct {
 	Dataset string `json:"dataset"` // hex-encoded Thread operational dataset TLV
 }
 
-// pollHomeAssistant periodically fetches Thread datasets from Home Assistant and
+// PollHomeAssistant periodically fetches Thread datasets from Home Assistant and
 // extracts the Mesh Local Prefix (TLV type 0x07) as a Thread mesh prefix source.
-func pollHomeAssistant(state *DaemonState, done <-chan struct{}) {
-	cfg := state.HomeAssistantConfig
+func PollHomeAssistant(sink Sink, cfg config.HomeAssistant, done <-chan struct{}) {
 	if cfg.URL == "" || cfg.Token == "" {
 		return
 	}
-	runPoller(done, 5*time.Minute, "home assistant thread datasets", func() error {
-		return fetchHAThreadPrefixes(state, cfg)
+	poller.Run(done, 5*time.Minute, "home assistant thread datasets", func() error {
+		return fetchHAThreadPrefixes(sink, cfg)
 	})
 }
 
-func fetchHAThreadPrefixes(state *DaemonState, cfg HomeAssistantConfig) error {
+func fetchHAThreadPrefixes(sink Sink, cfg config.HomeAssistant) error {
 	url := cfg.URL + "/api/thread/datasets"
 	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
@@ -49,7 +52,7 @@ func fetchHAThreadPrefixes(state *DaemonState, cfg HomeAssistantConfig) error {
 	defer func() { _ = resp.Body.Close() }()
 
 	if resp.StatusCode == http.StatusNotFound {
-		logDebug("Home Assistant: thread datasets not available (requires OTBR)")
+		logger.Debug("Home Assistant: thread datasets not available (requires OTBR)")
 		return nil
 	}
 	if resp.StatusCode != http.StatusOK {
@@ -63,26 +66,23 @@ func fetchHAThreadPrefixes(state *DaemonState, cfg HomeAssistantConfig) error {
 
 	for _, ds := range datasets {
 		prefix := parseMeshLocalPrefix(ds.Dataset)
-		if prefix == "" {
+		if !prefix.IsValid() {
 			continue
 		}
-		state.mu.Lock()
-		if _, known := state.ThreadMeshPrefixes[prefix]; !known {
-			logInfo("Thread mesh prefix discovered from Home Assistant: %s", prefix)
+		if sink.ObservePrefix(prefix) {
+			logger.Info("Thread mesh prefix discovered from Home Assistant: %s", prefix)
 		}
-		state.ThreadMeshPrefixes[prefix] = time.Now()
-		state.mu.Unlock()
 	}
 	return nil
 }
 
 // parseMeshLocalPrefix decodes a hex-encoded Thread operational dataset TLV and
-// returns the Mesh Local Prefix (type 0x07) as a /64 CIDR, or "" if not found.
-func parseMeshLocalPrefix(hexDataset string) string {
+// returns the Mesh Local Prefix (type 0x07) as a /64, or the zero Prefix if not found.
+func parseMeshLocalPrefix(hexDataset string) netip.Prefix {
 	data, err := hex.DecodeString(hexDataset)
 	if err != nil {
-		logDebug("Home Assistant: invalid dataset hex: %v", err)
-		return ""
+		logger.Debug("Home Assistant: invalid dataset hex: %v", err)
+		return netip.Prefix{}
 	}
 	for i := 0; i+1 < len(data); {
 		tlvType := data[i]
@@ -96,14 +96,14 @@ func parseMeshLocalPrefix(hexDataset string) string {
 
 		// Type 0x07 = Mesh Local Prefix, always 8 bytes (/64)
 		if tlvType == 0x07 && tlvLen == 8 {
-			prefix := make(net.IP, 16)
-			copy(prefix, val)
-			if (prefix[0] & 0xfe) != 0xfc {
+			var raw [16]byte
+			copy(raw[:], val)
+			prefix := netip.AddrFrom16(raw)
+			if !prefix.IsPrivate() {
 				continue
 			}
-			masked := maskPrefix(prefix, 64)
-			return fmt.Sprintf("%s/64", masked.String())
+			return netip.PrefixFrom(prefix, 64)
 		}
 	}
-	return ""
+	return netip.Prefix{}
 }
@@ -0,0 +1,76 @@
+package discovery
+
+import (
+	"net/netip"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/metrics"
+)
+
+var (
+	announcements = metrics.NewCounter("discovery_announcements_total",
+		"Service instance announcements reported by the discovery backend.", "backend", "service")
+	duplicateAnnouncements = metrics.NewCounter("discovery_duplicate_announcements_total",
+		"Announcements identical to the instance's previous one; only its last-seen time is refreshed.", "backend", "service")
+	browseDuration = metrics.NewHistogram("discovery_browse_duration_seconds",
+		"Time from the start of a browse round until its last instance was reported.",
+		[]float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}, "backend", "service")
+	browseEntries = metrics.NewHistogram("discovery_browse_entries",
+		"Instances reported per browse round.",
+		[]float64{0, 1, 2, 5, 10, 25, 50, 100}, "backend", "service")
+)
+
+// meteredBrowser counts the announcements a Browser reports and how many repeat
+// the instance's previous announcement unchanged.
+type meteredBrowser struct {
+	Browser
+	backend string
+}
+
+// Browse implements Browser.
+func (b meteredBrowser) Browse(service string, done <-chan struct{}, handler func(Instance)) {
+	var mu sync.Mutex
+	last := make(map[string]string)
+	b.Browser.Browse(service, done, func(inst Instance) {
+		fp := fingerprint(inst)
+		mu.Lock()
+		duplicate := last[inst.Name] == fp
+		last[inst.Name] = fp
+		mu.Unlock()
+		announcements.Inc(b.backend, service)
+		if duplicate {
+			duplicateAnnouncements.Inc(b.backend, service)
+		}
+		handler(inst)
+	})
+}
+
+// fingerprint identifies the contents of an announcement, ignoring address order.
+func fingerprint(inst Instance) string {
+	addrs := append([]netip.Addr(nil), inst.Addrs...)
+	sort.Slice(addrs, func(i, j int) bool { return addrs[i].Less(addrs[j]) })
+	var sb strings.Builder
+	sb.WriteString(inst.Host)
+	for _, ip := range addrs {
+		sb.WriteString(" " + ip.String())
+	}
+	for _, txt := range inst.Text {
+		sb.WriteString(" " + txt)
+	}
+	return sb.String()
+}
+
+// roundMetrics records the browse rounds of the backends: the instances each
+// round reported and, if it found anything, how long that took.
+type roundMetrics struct{}
+
+// BrowseRound implements dnssd.Observer.
+func (roundMetrics) BrowseRound(backend, service string, entries int, start, last time.Time) {
+	browseEntries.Observe(float64(entries), backend, service)
+	if entries > 0 {
+		browseDuration.Observe(last.Sub(start).Seconds(), backend, service)
+	}
+}
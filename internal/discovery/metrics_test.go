@@ -0,0 +1,53 @@
+package discovery
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+)
+
+// staticBrowser reports a fixed list of instances once.
+type staticBrowser []Instance
+
+func (b staticBrowser) Browse(service string, done <-chan struct{}, handler func(Instance)) {
+	for _, inst := range b {
+		handler(inst)
+	}
+}
+
+func TestMeteredBrowserCountsDuplicates(t *testing.T) {
+	a := netip.MustParseAddr("fd00:1111:2222:3333::1")
+	b := netip.MustParseAddr("fd00:1111:2222:3333::2")
+	browser := meteredBrowser{backend: "test", Browser: staticBrowser{
+		{Name: "Lamp._matter._tcp.local.", Addrs: []netip.Addr{a, b}},
+		{Name: "Lamp._matter._tcp.local.", Addrs: []netip.Addr{b, a}}, // same addresses, reordered
+		{Name: "Plug._matter._tcp.local.", Addrs: []netip.Addr{a}},
+		{Name: "Lamp._matter._tcp.local.", Addrs: []netip.Addr{a}}, // changed
+	}}
+
+	reported := 0
+	browser.Browse("_metered._tcp", nil, func(Instance) { reported++ })
+
+	if reported != 4 {
+		t.Errorf("Expected all 4 announcements passed on, got %d", reported)
+	}
+	if got := announcements.Value("test", "_metered._tcp"); got != 4 {
+		t.Errorf("Expected 4 announcements counted, got %v", got)
+	}
+	if got := duplicateAnnouncements.Value("test", "_metered._tcp"); got != 1 {
+		t.Errorf("Expected 1 duplicate, got %v", got)
+	}
+}
+
+func TestRoundMetrics(t *testing.T) {
+	start := time.Now()
+	roundMetrics{}.BrowseRound("test", "_round._tcp", 0, start, time.Time{})
+	roundMetrics{}.BrowseRound("test", "_round._tcp", 2, start, start.Add(time.Second))
+
+	if got := browseEntries.Count("test", "_round._tcp"); got != 2 {
+		t.Errorf("Expected 2 rounds recorded, got %d", got)
+	}
+	if got := browseDuration.Count("test", "_round._tcp"); got != 1 {
+		t.Errorf("Expected a duration only for the round with entries, got %d", got)
+	}
+}
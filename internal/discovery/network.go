@@ -0,0 +1,173 @@
+package discovery
+
+import (
+	"net/netip"
+	"sync"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/logger"
+)
+
+// Network is what one discovery round found: the border routers and Matter
+// devices announced within it, and the mesh prefixes derived from them.
+type Network struct {
+	Routers  []BorderRouter
+	Devices  []MatterDevice
+	Prefixes []PrefixSighting
+}
+
+// PrefixSighting is a mesh prefix and where it was learned, for logging.
+type PrefixSighting struct {
+	Prefix netip.Prefix
+	Source string // e.g. "omr= (Apple TV)" or "Matter device 1A2B-3C4D"
+}
+
+// NetworkSink is a Sink that records a discovery round at once, so readers
+// never see the routers of a round without its devices or the other way round.
+type NetworkSink interface {
+	Sink
+	// MergeNetwork records a discovery round and returns the prefixes that were new.
+	MergeNetwork(n Network) []netip.Prefix
+}
+
+// BrowseNetwork browses for border routers and Matter devices in one session
+// on browser until done is closed, handing what both services report to sink
+// in rounds: a round opens with its first sighting and closes window later, a
+// deadline common to both services, or when done is closed. A sink that is not
+// a NetworkSink receives each round one sighting at a time.
+func BrowseNetwork(sink Sink, browser Browser, ouis OUIDatabase, window time.Duration, done <-chan struct{}) {
+	session := &networkSession{sink: sink, window: window}
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		browser.Browse("_meshcop._udp", done, func(inst Instance) {
+			if n, ok := routerSighting(inst); ok {
+				session.add(n)
+			}
+		})
+	}()
+	go func() {
+		defer wg.Done()
+		browser.Browse("_matter._tcp", done, func(inst Instance) {
+			if n, ok := deviceSighting(inst, ouis); ok {
+				session.add(n)
+			}
+		})
+	}()
+	wg.Wait()
+	session.flush()
+}
+
+// networkSession collects the sightings of a BrowseNetwork session into rounds.
+type networkSession struct {
+	sink   Sink
+	window time.Duration
+
+	mu    sync.Mutex
+	round Network
+	timer *time.Timer // closes the open round; nil while none is open
+}
+
+// add adds a sighting to the open round, opening one when there is none.
+func (s *networkSession) add(n Network) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.round.Routers = append(s.round.Routers, n.Routers...)
+	s.round.Devices = append(s.round.Devices, n.Devices...)
+	s.round.Prefixes = append(s.round.Prefixes, n.Prefixes...)
+	if s.timer == nil {
+		s.timer = time.AfterFunc(s.window, s.flush)
+	}
+}
+
+// flush closes the open round and records it in the sink.
+func (s *networkSession) flush() {
+	s.mu.Lock()
+	n := s.round
+	s.round = Network{}
+	if s.timer != nil {
+		s.timer.Stop()
+		s.timer = nil
+	}
+	s.mu.Unlock()
+	if len(n.Routers) > 0 || len(n.Devices) > 0 {
+		MergeNetwork(s.sink, n)
+	}
+}
+
+// routerSighting returns the border router a _meshcop._udp instance announces
+// and the mesh prefix of its omr= record as a round of its own.
+func routerSighting(inst Instance) (Network, bool) {
+	logger.Debug("DNS-SD _meshcop._udp: name=%s ips=%v txt=%v", inst.Name, inst.Addrs, inst.Text)
+	router, ok := ParseBorderRouter(inst)
+	if !ok {
+		return Network{}, false
+	}
+	n := Network{Routers: []BorderRouter{router}}
+	if router.OMRPrefix.IsValid() {
+		n.Prefixes = append(n.Prefixes, PrefixSighting{router.OMRPrefix, "omr= (" + router.Name + ")"})
+	}
+	return n, true
+}
+
+// deviceSighting returns the Matter device a _matter._tcp instance announces,
+// with its vendor looked up in ouis, and the mesh prefixes of its ULA
+// addresses as a round of its own.
+func deviceSighting(inst Instance, ouis OUIDatabase) (Network, bool) {
+	device, ok := ParseMatterDevice(inst, ouis)
+	if !ok {
+		return Network{}, false
+	}
+	n := Network{Devices: []MatterDevice{device}}
+	for _, cidr := range devicePrefixes(inst.Addrs) {
+		n.Prefixes = append(n.Prefixes, PrefixSighting{cidr, "Matter device " + device.Name})
+	}
+	return n, true
+}
+
+// MergeNetwork records a discovery round in sink, at once when it is a
+// NetworkSink, and logs the prefixes it discovered.
+func MergeNetwork(sink Sink, n Network) {
+	var added map[netip.Prefix]bool
+	if ns, ok := sink.(NetworkSink); ok {
+		added = make(map[netip.Prefix]bool)
+		for _, p := range ns.MergeNetwork(n) {
+			added[p] = true
+		}
+	} else {
+		for _, r := range n.Routers {
+			sink.MergeBorderRouter(r)
+		}
+		for _, d := range n.Devices {
+			sink.MergeDevice(d)
+		}
+		added = make(map[netip.Prefix]bool)
+		for _, p := range n.Prefixes {
+			if sink.ObservePrefix(p.Prefix) {
+				added[p.Prefix.Masked()] = true
+			}
+		}
+	}
+	for _, p := range n.Prefixes {
+		if prefix := p.Prefix.Masked(); added[prefix] {
+			logger.Info("Thread mesh prefix discovered from %s: %s", p.Source, prefix)
+			delete(added, prefix)
+		}
+	}
+}
+
+// devicePrefixes returns the /64s of the ULA addresses a Matter device
+// announces, the mesh prefixes of Thread devices.
+func devicePrefixes(addrs []netip.Addr) []netip.Prefix {
+	var out []netip.Prefix
+	for _, ip := range addrs {
+		if !ip.IsPrivate() {
+			continue
+		}
+		if cidr := CIDR64(ip); cidr.IsValid() {
+			out = append(out, cidr)
+		}
+	}
+	return out
+}
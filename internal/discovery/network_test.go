@@ -0,0 +1,114 @@
+package discovery
+
+import (
+	"net/netip"
+	"sync"
+	"testing"
+	"time"
+)
+
+// serviceBrowser reports fixed instances per service, then browses until done.
+type serviceBrowser map[string][]Instance
+
+func (b serviceBrowser) Browse(service string, done <-chan struct{}, handler func(Instance)) {
+	for _, inst := range b[service] {
+		handler(inst)
+	}
+	<-done
+}
+
+// networkSink records the discovery rounds merged into it.
+type networkSink struct {
+	prefixSink
+	mu     sync.Mutex
+	rounds []Network
+}
+
+func (s *networkSink) MergeNetwork(n Network) []netip.Prefix {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rounds = append(s.rounds, n)
+	var added []netip.Prefix
+	for _, p := range n.Prefixes {
+		added = append(added, p.Prefix)
+	}
+	return added
+}
+
+func TestBrowseNetwork(t *testing.T) {
+	browser := serviceBrowser{
+		"_meshcop._udp": {{Name: "Apple TV._meshcop._udp.local.", Addrs: []netip.Addr{netip.MustParseAddr("2001:db8::1")},
+			Text: []string{"omr=\\@\\253\\000\\017\\000\\000\\000\\000\\000"}}},
+		"_matter._tcp": {{Name: "1A2B-3C4D._matter._tcp.local.", Addrs: []netip.Addr{netip.MustParseAddr("fd00:1111:2222:3333::10")}}},
+	}
+	sink := &networkSink{}
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		BrowseNetwork(sink, browser, nil, time.Hour, done)
+		close(finished)
+	}()
+	time.Sleep(50 * time.Millisecond) // let both browses report
+	close(done)
+	<-finished
+
+	if len(sink.rounds) != 1 {
+		t.Fatalf("Expected one round, got %+v", sink.rounds)
+	}
+	n := sink.rounds[0]
+	if len(n.Routers) != 1 || n.Routers[0].Name != "Apple TV" || len(n.Devices) != 1 || n.Devices[0].Name != "1A2B-3C4D" {
+		t.Errorf("Expected the router and device in the same round, got %+v", n)
+	}
+	if len(n.Prefixes) != 2 {
+		t.Errorf("Expected the omr= and device prefixes, got %+v", n.Prefixes)
+	}
+}
+
+// TestBrowseNetworkRoundDeadline verifies a round is recorded window after
+// its first sighting, without waiting for the session to end.
+func TestBrowseNetworkRoundDeadline(t *testing.T) {
+	browser := serviceBrowser{
+		"_matter._tcp": {{Name: "1A2B-3C4D._matter._tcp.local.", Addrs: []netip.Addr{netip.MustParseAddr("fd00:1111:2222:3333::10")}}},
+	}
+	sink := &networkSink{}
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	go func() {
+		BrowseNetwork(sink, browser, nil, 10*time.Millisecond, done)
+		close(finished)
+	}()
+	deadline := time.Now().Add(time.Second)
+	for {
+		sink.mu.Lock()
+		rounds := len(sink.rounds)
+		sink.mu.Unlock()
+		if rounds > 0 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	close(done)
+	<-finished
+
+	if len(sink.rounds) != 1 || len(sink.rounds[0].Devices) != 1 {
+		t.Errorf("Expected the round recorded before the session ended, got %+v", sink.rounds)
+	}
+}
+
+// TestMergeNetworkSightings verifies a sink without MergeNetwork receives
+// the round one sighting at a time.
+func TestMergeNetworkSightings(t *testing.T) {
+	sink := &deviceSink{}
+	MergeNetwork(sink, Network{Devices: []MatterDevice{{Name: "a"}, {Name: "b"}}})
+	if len(sink.devices) != 2 {
+		t.Errorf("Expected both devices merged, got %+v", sink.devices)
+	}
+}
+
+// deviceSink records the Matter devices merged into it.
+type deviceSink struct {
+	prefixSink
+	devices []MatterDevice
+}
+
+func (s *deviceSink) MergeDevice(d MatterDevice) { s.devices = append(s.devices, d) }
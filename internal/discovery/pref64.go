@@ -0,0 +1,95 @@
+package discovery
+
+import (
+	"encoding/binary"
+	"net"
+	"net/netip"
+	"time"
+
+	"golang.org/x/net/icmp"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/logger"
+)
+
+const (
+	icmpv6RouterAdvertisement = 134
+	ndOptionPREF64            = 38
+)
+
+// pref64Lengths maps the PREF64 prefix length code to the prefix length.
+var pref64Lengths = []int{96, 64, 56, 48, 40, 32}
+
+// pref64 is a NAT64 prefix from an RFC 8781 PREF64 router advertisement option.
+type pref64 struct {
+	prefix   netip.Prefix
+	lifetime time.Duration
+}
+
+// ListenPREF64 listens for router advertisements on the infrastructure link and
+// records the NAT64 prefixes announced in their PREF64 options, such as a
+// border router's NAT64 translator. It needs a raw ICMPv6 socket (CAP_NET_RAW).
+func ListenPREF64(sink Sink, done <-chan struct{}) error {
+	return listenRouterAdvertisements("PREF64", done, func(msg []byte, from net.Addr) {
+		for _, p := range parsePREF64(msg) {
+			logger.Debug("PREF64 from %s: %s lifetime=%s", from, p.prefix, p.lifetime)
+			sink.ObserveNAT64(p.prefix, p.lifetime)
+		}
+	})
+}
+
+// listenRouterAdvertisements calls handle with each ICMPv6 message received on
+// a raw socket, until done is closed. name prefixes read errors in the log.
+func listenRouterAdvertisements(name string, done <-chan struct{}, handle func(msg []byte, from net.Addr)) error {
+	conn, err := icmp.ListenPacket("ip6:ipv6-icmp", "::")
+	if err != nil {
+		return err
+	}
+	go func() {
+		<-done
+		_ = conn.Close()
+	}()
+	go func() {
+		buf := make([]byte, 1500)
+		for {
+			n, from, err := conn.ReadFrom(buf)
+			if err != nil {
+				select {
+				case <-done:
+				default:
+					logger.Warn("%s: %v", name, err)
+				}
+				return
+			}
+			handle(buf[:n], from)
+		}
+	}()
+	return nil
+}
+
+// parsePREF64 returns the PREF64 options of an ICMPv6 router advertisement.
+func parsePREF64(msg []byte) []pref64 {
+	if len(msg) < 16 || msg[0] != icmpv6RouterAdvertisement || msg[1] != 0 {
+		return nil
+	}
+	var out []pref64
+	for opts := msg[16:]; len(opts) >= 2; {
+		size := int(opts[1]) * 8
+		if size == 0 || size > len(opts) {
+			return out
+		}
+		if opts[0] == ndOptionPREF64 && size == 16 {
+			field := binary.BigEndian.Uint16(opts[2:4])
+			if plc := field & 0x7; int(plc) < len(pref64Lengths) {
+				bits := pref64Lengths[plc]
+				var raw [16]byte
+				copy(raw[:12], opts[4:16])
+				out = append(out, pref64{
+					prefix:   netip.PrefixFrom(netip.AddrFrom16(raw), bits).Masked(),
+					lifetime: time.Duration(field>>3) * 8 * time.Second,
+				})
+			}
+		}
+		opts = opts[size:]
+	}
+	return out
+}
@@ -0,0 +1,40 @@
+package discovery
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestParsePREF64(t *testing.T) {
+	ra := []byte{
+		134, 0, 0, 0, 64, 0, 0x07, 0x08, 0, 0, 0, 0, 0, 0, 0, 0, // RA header
+		1, 1, 0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff, // source link-layer address
+		// PREF64: lifetime 1800s (225 << 3), PLC 0 (/96), fd00:1111:2222:2::
+		38, 2, 0x07, 0x08, 0xfd, 0x00, 0x11, 0x11, 0x22, 0x22, 0x00, 0x02, 0, 0, 0, 0,
+		// PREF64: lifetime 0, PLC 1 (/64), 64:ff9b:1::
+		38, 2, 0x00, 0x01, 0x00, 0x64, 0xff, 0x9b, 0x00, 0x01, 0, 0, 0, 0, 0, 0,
+		// PREF64 with an invalid PLC
+		38, 2, 0x00, 0x07, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0, 0,
+	}
+	got := parsePREF64(ra)
+	expected := []pref64{
+		{netip.MustParsePrefix("fd00:1111:2222:2::/96"), 1800 * time.Second},
+		{netip.MustParsePrefix("64:ff9b:1::/64"), 0},
+	}
+	if len(got) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("Expected %v, got %v", expected[i], got[i])
+		}
+	}
+
+	if got := parsePREF64(ra[:20]); len(got) != 0 {
+		t.Errorf("Expected nothing from a truncated RA, got %v", got)
+	}
+	if got := parsePREF64(append([]byte{135}, ra[1:]...)); len(got) != 0 {
+		t.Errorf("Expected nothing from a non-RA message, got %v", got)
+	}
+}
@@ -0,0 +1,91 @@
+package discovery
+
+import (
+	"net"
+	"os"
+	"runtime"
+	"strings"
+)
+
+// Preflight checks on Linux that the host's LAN can route Thread prefixes at
+// all: IPv6 enabled, a global or unique local prefix on a LAN interface, and
+// a default route learned from router advertisements. It returns one
+// actionable warning per failed check, or nil on other platforms.
+func Preflight() []string {
+	if runtime.GOOS != "linux" {
+		return nil
+	}
+	disabled, _ := os.ReadFile("/proc/sys/net/ipv6/conf/all/disable_ipv6")
+	routes, _ := os.ReadFile("/proc/net/ipv6_route")
+	return preflight(string(disabled), string(routes), lanAddrs())
+}
+
+// preflight runs the checks on the contents of disable_ipv6 and ipv6_route
+// and the LAN interface addresses. An empty routes table skips the route check.
+func preflight(disabled, routes string, addrs []net.IP) []string {
+	if strings.TrimSpace(disabled) == "1" {
+		return []string{"IPv6 is disabled on this host (net.ipv6.conf.all.disable_ipv6=1): " +
+			"enable it with sysctl -w net.ipv6.conf.all.disable_ipv6=0, or Thread devices cannot be reached"}
+	}
+	var warnings []string
+	if !hasLANPrefix(addrs) {
+		warnings = append(warnings, "no global or unique local IPv6 address on any LAN interface: "+
+			"enable IPv6 on the UniFi network (Settings > Networks > IPv6) so the LAN has a prefix")
+	}
+	if routes != "" && !hasDefaultRoute(routes) {
+		warnings = append(warnings, "no IPv6 default route, so no router advertisement was received: "+
+			"enable router advertisements on the UniFi network and accept_ra on this host")
+	}
+	return warnings
+}
+
+// hasLANPrefix reports whether any address is a global unicast or unique
+// local address, rather than link-local only.
+func hasLANPrefix(addrs []net.IP) bool {
+	for _, ip := range addrs {
+		if ip.To4() == nil && ip.IsGlobalUnicast() {
+			return true
+		}
+	}
+	return false
+}
+
+// hasDefaultRoute reports whether the /proc/net/ipv6_route table has a ::/0
+// route through a gateway on an interface other than loopback.
+func hasDefaultRoute(table string) bool {
+	const zero = "00000000000000000000000000000000"
+	for _, line := range strings.Split(table, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 10 {
+			continue
+		}
+		if fields[0] == zero && fields[1] == "00" && fields[4] != zero && fields[9] != "lo" {
+			return true
+		}
+	}
+	return false
+}
+
+// lanAddrs returns the IPv6 addresses of the up, non-loopback interfaces.
+func lanAddrs() []net.IP {
+	all, err := net.Interfaces()
+	if err != nil {
+		return nil
+	}
+	var ips []net.IP
+	for _, iface := range all {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.To4() == nil {
+				ips = append(ips, ipNet.IP)
+			}
+		}
+	}
+	return ips
+}
@@ -0,0 +1,36 @@
+package discovery
+
+import (
+	"net"
+	"testing"
+)
+
+func TestPreflight(t *testing.T) {
+	const defaultRoute = "00000000000000000000000000000000 00 00000000000000000000000000000000 00 fe800000000000000000000000000001 00000400 00000001 00000000 00000003 eth0\n"
+	const loopbackOnly = "00000000000000000000000000000000 00 00000000000000000000000000000000 00 00000000000000000000000000000000 ffffffff 00000001 00000000 00200200 lo\n"
+	gua := []net.IP{net.ParseIP("fe80::1"), net.ParseIP("2001:db8::10")}
+	ula := []net.IP{net.ParseIP("fd12:3456::10")}
+	linkLocal := []net.IP{net.ParseIP("fe80::1")}
+
+	tests := []struct {
+		name     string
+		disabled string
+		routes   string
+		addrs    []net.IP
+		expected int
+	}{
+		{"ready", "0\n", defaultRoute, gua, 0},
+		{"ula only", "0\n", defaultRoute, ula, 0},
+		{"ipv6 disabled", "1\n", defaultRoute, gua, 1},
+		{"link-local only", "0\n", defaultRoute, linkLocal, 1},
+		{"no router advertisement", "0\n", loopbackOnly, gua, 1},
+		{"nothing", "0\n", loopbackOnly, linkLocal, 2},
+		{"no route table", "", "", gua, 0},
+	}
+
+	for _, tt := range tests {
+		if got := preflight(tt.disabled, tt.routes, tt.addrs); len(got) != tt.expected {
+			t.Errorf("%s: expected %d warnings, got %v", tt.name, tt.expected, got)
+		}
+	}
+}
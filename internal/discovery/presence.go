@@ -0,0 +1,102 @@
+package discovery
+
+import (
+	"net/netip"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/grandcat/zeroconf"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/logger"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/pkg/dnssd"
+)
+
+// PresenceService is the DNS-SD service type each daemon registers, so
+// companion tools and other instances can find it.
+const PresenceService = "_thread-route-updater._tcp"
+
+// Peer is a daemon instance found through its presence service.
+type Peer struct {
+	Name      string       `json:"name"`
+	Version   string       `json:"version,omitempty"`
+	Port      int          `json:"port,omitempty"` // status API port
+	IPv6Addrs []netip.Addr `json:"ipv6_addrs"`
+	LastSeen  time.Time    `json:"last_seen"`
+}
+
+// Advertise registers the presence service as instance, announcing the status
+// API port and version in TXT records, until done is closed.
+func Advertise(instance string, port int, version string, done <-chan struct{}) error {
+	server, err := zeroconf.Register(instance, PresenceService, "local.", port,
+		[]string{"version=" + version, "port=" + strconv.Itoa(port), "path=/status"}, nil)
+	if err != nil {
+		return err
+	}
+	go func() {
+		<-done
+		server.Shutdown()
+	}()
+	return nil
+}
+
+// Peers tracks the other daemon instances announcing themselves. It is safe
+// for concurrent use.
+type Peers struct {
+	self string
+
+	mu    sync.Mutex
+	peers map[string]Peer
+}
+
+// NewPeers returns an empty peer list ignoring the instance named self.
+func NewPeers(self string) *Peers {
+	return &Peers{self: self, peers: make(map[string]Peer)}
+}
+
+// Browse records the instances of the presence service until done is closed.
+func (p *Peers) Browse(browser Browser, done <-chan struct{}) {
+	browser.Browse(PresenceService, done, func(inst Instance) {
+		name := dnssd.InstanceLabel(inst.Name)
+		if name == p.self {
+			return
+		}
+		peer := Peer{Name: name, Version: txtValue(inst.Text, "version"), IPv6Addrs: inst.Addrs, LastSeen: time.Now()}
+		peer.Port, _ = strconv.Atoi(txtValue(inst.Text, "port"))
+		p.mu.Lock()
+		_, known := p.peers[name]
+		p.peers[name] = peer
+		p.mu.Unlock()
+		if !known {
+			logger.Info("Found another thread-route-updater instance: %s (version %s)", name, peer.Version)
+		}
+	})
+}
+
+// List returns the peers seen within maxAge, sorted by name, forgetting older ones.
+func (p *Peers) List(maxAge time.Duration) []Peer {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	peers := []Peer{}
+	for name, peer := range p.peers {
+		if time.Since(peer.LastSeen) > maxAge {
+			delete(p.peers, name)
+			continue
+		}
+		peers = append(peers, peer)
+	}
+	sort.Slice(peers, func(i, j int) bool { return peers[i].Name < peers[j].Name })
+	return peers
+}
+
+// txtValue returns the value of TXT key, or "".
+func txtValue(txt []string, key string) string {
+	for _, field := range txt {
+		if v, ok := strings.CutPrefix(field, key+"="); ok {
+			return v
+		}
+	}
+	return ""
+}
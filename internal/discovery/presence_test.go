@@ -0,0 +1,33 @@
+package discovery
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestPeers(t *testing.T) {
+	addr := netip.MustParseAddr("fd00:1111:2222:3333::1")
+	peers := NewPeers("nas")
+	peers.Browse(staticBrowser{
+		{Name: `pi\032kitchen._thread-route-updater._tcp.local.`, Addrs: []netip.Addr{addr}, Text: []string{"version=1.4.0", "port=8080", "path=/status"}},
+		{Name: "nas._thread-route-updater._tcp.local.", Text: []string{"version=1.4.0", "port=8080"}},
+		{Name: "old._thread-route-updater._tcp.local.", Text: []string{"port=bogus"}},
+	}, nil)
+	peers.mu.Lock()
+	stale := peers.peers["old"]
+	stale.LastSeen = time.Now().Add(-time.Hour)
+	peers.peers["old"] = stale
+	peers.mu.Unlock()
+
+	got := peers.List(10 * time.Minute)
+	if len(got) != 1 {
+		t.Fatalf("Expected one peer besides this instance, got %+v", got)
+	}
+	if got[0].Name != "pi kitchen" || got[0].Version != "1.4.0" || got[0].Port != 8080 || len(got[0].IPv6Addrs) != 1 {
+		t.Errorf("Unexpected peer %+v", got[0])
+	}
+	if len(peers.List(time.Hour*2)) != 1 {
+		t.Error("Expected the stale peer forgotten")
+	}
+}
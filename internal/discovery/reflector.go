@@ -0,0 +1,303 @@
+package discovery
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miekg/dns"
+	"golang.org/x/net/ipv4"
+	"golang.org/x/net/ipv6"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/logger"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/metrics"
+)
+
+// mdnsGroup4 is the IPv4 mDNS multicast group.
+var mdnsGroup4 = &net.UDPAddr{IP: net.IPv4(224, 0, 0, 251), Port: 5353}
+
+// reflectorDedupWindow is how long a relayed packet is remembered, so a copy
+// bounced back by another reflector is not relayed again.
+const reflectorDedupWindow = time.Second
+
+// localAddrRefresh is how long the host's own addresses are cached before the
+// reflector lists them again, so a renumbered interface is picked up.
+const localAddrRefresh = 30 * time.Second
+
+var reflectedPackets = metrics.NewCounter("mdns_reflected_packets_total",
+	"mDNS packets relayed by the reflector, by receiving and sending interface.", "from", "to")
+
+// Reflector relays mDNS packets about the configured services between
+// interfaces, so Matter and HomeKit devices on one VLAN can be found from another.
+type Reflector struct {
+	ifaces   []net.Interface
+	services []string // service types as ".<type>." labels, e.g. "._matter._tcp."
+	local    localAddrs
+}
+
+// reflectConn is an mDNS socket of one address family, joined on every
+// reflected interface.
+type reflectConn interface {
+	read(buf []byte) (n, ifIndex int, src net.IP, err error)
+	write(b []byte, ifIndex int) error
+	close() error
+}
+
+// NewReflector returns a reflector between the named interfaces for the given
+// service types, e.g. "_matter._tcp". It needs at least two interfaces.
+func NewReflector(ifaceNames, services []string) (*Reflector, error) {
+	if len(ifaceNames) < 2 {
+		return nil, fmt.Errorf("need at least two interfaces, got %d", len(ifaceNames))
+	}
+	r := &Reflector{}
+	for _, name := range ifaceNames {
+		iface, err := net.InterfaceByName(name)
+		if err != nil {
+			return nil, fmt.Errorf("interface %s: %v", name, err)
+		}
+		r.ifaces = append(r.ifaces, *iface)
+	}
+	for _, s := range services {
+		r.services = append(r.services, "."+strings.ToLower(strings.Trim(s, "."))+".")
+	}
+	return r, nil
+}
+
+// Run relays packets over IPv6 and, where available, IPv4 until done is closed.
+func (r *Reflector) Run(done <-chan struct{}) error {
+	c6, err := listenReflect6(r.ifaces)
+	if err != nil {
+		return fmt.Errorf("IPv6: %v", err)
+	}
+	conns := []reflectConn{c6}
+	if c, err := listenReflect4(r.ifaces); err != nil {
+		logger.Warn("mDNS reflector: IPv4 unavailable, relaying IPv6 only: %v", err)
+	} else {
+		conns = append(conns, c)
+	}
+	go func() {
+		<-done
+		for _, c := range conns {
+			_ = c.close()
+		}
+	}()
+	for _, c := range conns {
+		go r.relay(c, done)
+	}
+	return nil
+}
+
+// relay reads packets from c and sends those about a reflected service out of
+// every other reflected interface. Responders often send the same payload over
+// IPv4 and IPv6, so each address family keeps its own dedup window.
+func (r *Reflector) relay(c reflectConn, done <-chan struct{}) {
+	buf := make([]byte, 9000)
+	recent := make(map[[32]byte]time.Time)
+	for {
+		n, ifIndex, src, err := c.read(buf)
+		if err != nil {
+			select {
+			case <-done:
+			default:
+				logger.Warn("mDNS reflector: %v", err)
+			}
+			return
+		}
+		from := r.iface(ifIndex)
+		if from == nil || r.local.contains(src) || !r.wanted(buf[:n], recent) {
+			continue
+		}
+		for _, to := range r.ifaces {
+			if to.Index == ifIndex {
+				continue
+			}
+			if err := c.write(buf[:n], to.Index); err != nil {
+				logger.Debug("mDNS reflector: relaying from %s to %s failed: %v", from.Name, to.Name, err)
+				continue
+			}
+			reflectedPackets.Inc(from.Name, to.Name)
+		}
+	}
+}
+
+// iface returns the reflected interface with the given index, or nil.
+func (r *Reflector) iface(index int) *net.Interface {
+	for i := range r.ifaces {
+		if r.ifaces[i].Index == index {
+			return &r.ifaces[i]
+		}
+	}
+	return nil
+}
+
+// wanted reports whether packet is an mDNS message about a reflected service
+// that was not relayed within the dedup window, recording it in recent.
+func (r *Reflector) wanted(packet []byte, recent map[[32]byte]time.Time) bool {
+	var msg dns.Msg
+	if err := msg.Unpack(packet); err != nil || !r.concernsService(&msg) {
+		return false
+	}
+	sum := sha256.Sum256(packet)
+	now := time.Now()
+	for k, t := range recent {
+		if now.Sub(t) > reflectorDedupWindow {
+			delete(recent, k)
+		}
+	}
+	if _, dup := recent[sum]; dup {
+		return false
+	}
+	recent[sum] = now
+	return true
+}
+
+// concernsService reports whether a question or record of msg names a
+// reflected service type or one of its instances.
+func (r *Reflector) concernsService(msg *dns.Msg) bool {
+	names := make([]string, 0, len(msg.Question)+len(msg.Answer)+len(msg.Ns)+len(msg.Extra))
+	for _, q := range msg.Question {
+		names = append(names, q.Name)
+	}
+	for _, section := range [][]dns.RR{msg.Answer, msg.Ns, msg.Extra} {
+		for _, rr := range section {
+			names = append(names, rr.Header().Name)
+		}
+	}
+	for _, name := range names {
+		name = "." + strings.ToLower(strings.TrimPrefix(name, "."))
+		for _, s := range r.services {
+			if strings.Contains(name, s) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// localAddrs caches this host's addresses, so the reflector can ignore its own
+// packets without listing the interfaces for every packet.
+type localAddrs struct {
+	list func() ([]net.Addr, error) // nil means net.InterfaceAddrs
+
+	mu      sync.Mutex
+	ips     []net.IP
+	fetched time.Time
+}
+
+// contains reports whether ip is one of this host's addresses, listing them
+// again once the cached ones are older than localAddrRefresh. When listing
+// fails, the previously cached addresses are kept.
+func (l *localAddrs) contains(ip net.IP) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if now := time.Now(); l.fetched.IsZero() || now.Sub(l.fetched) > localAddrRefresh {
+		l.fetched = now
+		list := l.list
+		if list == nil {
+			list = net.InterfaceAddrs
+		}
+		if addrs, err := list(); err != nil {
+			logger.Debug("mDNS reflector: listing local addresses failed: %v", err)
+		} else {
+			l.ips = l.ips[:0]
+			for _, addr := range addrs {
+				if ipNet, ok := addr.(*net.IPNet); ok {
+					l.ips = append(l.ips, ipNet.IP)
+				}
+			}
+		}
+	}
+	for _, local := range l.ips {
+		if local.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+type reflectConn6 struct{ p *ipv6.PacketConn }
+
+func listenReflect6(ifaces []net.Interface) (reflectConn, error) {
+	conn, err := net.ListenMulticastUDP("udp6", &ifaces[0], mdnsGroup)
+	if err != nil {
+		return nil, err
+	}
+	p := ipv6.NewPacketConn(conn)
+	for i := range ifaces[1:] {
+		if err := p.JoinGroup(&ifaces[i+1], mdnsGroup); err != nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("join %s on %s: %v", mdnsGroup, ifaces[i+1].Name, err)
+		}
+	}
+	if err := p.SetControlMessage(ipv6.FlagInterface|ipv6.FlagSrc, true); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	_ = p.SetMulticastLoopback(false)
+	_ = p.SetMulticastHopLimit(255)
+	return reflectConn6{p}, nil
+}
+
+func (c reflectConn6) read(buf []byte) (int, int, net.IP, error) {
+	n, cm, src, err := c.p.ReadFrom(buf)
+	if err != nil || cm == nil {
+		return n, 0, nil, err
+	}
+	ip := cm.Src
+	if udp, ok := src.(*net.UDPAddr); ok {
+		ip = udp.IP
+	}
+	return n, cm.IfIndex, ip, nil
+}
+
+func (c reflectConn6) write(b []byte, ifIndex int) error {
+	_, err := c.p.WriteTo(b, &ipv6.ControlMessage{IfIndex: ifIndex, HopLimit: 255}, mdnsGroup)
+	return err
+}
+
+func (c reflectConn6) close() error { return c.p.Close() }
+
+type reflectConn4 struct{ p *ipv4.PacketConn }
+
+func listenReflect4(ifaces []net.Interface) (reflectConn, error) {
+	conn, err := net.ListenMulticastUDP("udp4", &ifaces[0], mdnsGroup4)
+	if err != nil {
+		return nil, err
+	}
+	p := ipv4.NewPacketConn(conn)
+	for i := range ifaces[1:] {
+		if err := p.JoinGroup(&ifaces[i+1], mdnsGroup4); err != nil {
+			_ = conn.Close()
+			return nil, fmt.Errorf("join %s on %s: %v", mdnsGroup4, ifaces[i+1].Name, err)
+		}
+	}
+	if err := p.SetControlMessage(ipv4.FlagInterface|ipv4.FlagSrc, true); err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+	_ = p.SetMulticastLoopback(false)
+	_ = p.SetMulticastTTL(255)
+	return reflectConn4{p}, nil
+}
+
+func (c reflectConn4) read(buf []byte) (int, int, net.IP, error) {
+	n, cm, src, err := c.p.ReadFrom(buf)
+	if err != nil || cm == nil {
+		return n, 0, nil, err
+	}
+	ip := cm.Src
+	if udp, ok := src.(*net.UDPAddr); ok {
+		ip = udp.IP
+	}
+	return n, cm.IfIndex, ip, nil
+}
+
+func (c reflectConn4) write(b []byte, ifIndex int) error {
+	_, err := c.p.WriteTo(b, &ipv4.ControlMessage{IfIndex: ifIndex}, mdnsGroup4)
+	return err
+}
+
+func (c reflectConn4) close() error { return c.p.Close() }
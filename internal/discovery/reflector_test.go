@@ -0,0 +1,116 @@
+package discovery
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+func TestReflectorWanted(t *testing.T) {
+	r := &Reflector{services: []string{"._matter._tcp.", "._hap._tcp."}}
+
+	pack := func(msg *dns.Msg) []byte {
+		t.Helper()
+		b, err := msg.Pack()
+		if err != nil {
+			t.Fatalf("Pack failed: %v", err)
+		}
+		return b
+	}
+	query := func(name string) []byte {
+		msg := new(dns.Msg)
+		msg.SetQuestion(name, dns.TypePTR)
+		return pack(msg)
+	}
+	announce := func(instance string) []byte {
+		msg := new(dns.Msg)
+		msg.Response = true
+		msg.Answer = append(msg.Answer, &dns.SRV{
+			Hdr:    dns.RR_Header{Name: instance, Rrtype: dns.TypeSRV, Class: dns.ClassINET, Ttl: 120},
+			Target: "light.local.", Port: 5540,
+		})
+		return pack(msg)
+	}
+
+	tests := []struct {
+		name   string
+		packet []byte
+		want   bool
+	}{
+		{"Matter browse", query("_matter._tcp.local."), true},
+		{"Matter subtype browse", query("_V65521._sub._matter._tcp.local."), true},
+		{"HomeKit instance announcement", announce("Kitchen Light._hap._tcp.local."), true},
+		{"Case-insensitive", query("_MATTER._TCP.local."), true},
+		{"Other service", query("_printer._tcp.local."), false},
+		{"Lookalike service", query("_notmatter._tcp.local."), false},
+		{"Not DNS", []byte{1, 2, 3}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := r.wanted(tt.packet, make(map[[32]byte]time.Time)); got != tt.want {
+				t.Errorf("Expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+
+	recent := make(map[[32]byte]time.Time)
+	packet := query("_matter._tcp.local.")
+	if !r.wanted(packet, recent) {
+		t.Fatal("Expected the first copy relayed")
+	}
+	if r.wanted(packet, recent) {
+		t.Error("Expected a repeated copy within the dedup window dropped")
+	}
+	for k := range recent {
+		recent[k] = time.Now().Add(-2 * reflectorDedupWindow)
+	}
+	if !r.wanted(packet, recent) {
+		t.Error("Expected the packet relayed again after the dedup window")
+	}
+}
+
+func TestNewReflector(t *testing.T) {
+	if _, err := NewReflector([]string{"lo"}, []string{"_matter._tcp"}); err == nil {
+		t.Error("Expected an error for a single interface")
+	}
+	if _, err := NewReflector([]string{"lo", "no-such-iface0"}, []string{"_matter._tcp"}); err == nil {
+		t.Error("Expected an error for an unknown interface")
+	}
+}
+
+func TestLocalAddrsCached(t *testing.T) {
+	calls := 0
+	addr := "2001:db8::1"
+	l := &localAddrs{list: func() ([]net.Addr, error) {
+		calls++
+		return []net.Addr{&net.IPNet{IP: net.ParseIP(addr), Mask: net.CIDRMask(64, 128)}}, nil
+	}}
+
+	if !l.contains(net.ParseIP("2001:db8::1")) {
+		t.Error("Expected 2001:db8::1 to be local")
+	}
+	if l.contains(net.ParseIP("2001:db8::2")) {
+		t.Error("Expected 2001:db8::2 not to be local")
+	}
+	if calls != 1 {
+		t.Errorf("Expected addresses to be listed once, got %d", calls)
+	}
+
+	addr = "2001:db8::2"
+	l.fetched = time.Now().Add(-localAddrRefresh - time.Second)
+	if !l.contains(net.ParseIP("2001:db8::2")) {
+		t.Error("Expected refreshed 2001:db8::2 to be local")
+	}
+	if calls != 2 {
+		t.Errorf("Expected addresses to be listed again after %v, got %d calls", localAddrRefresh, calls)
+	}
+
+	l.list = func() ([]net.Addr, error) { return nil, errors.New("no interfaces") }
+	l.fetched = time.Time{}
+	if !l.contains(net.ParseIP("2001:db8::2")) {
+		t.Error("Expected cached addresses to be kept when listing fails")
+	}
+}
@@ -0,0 +1,56 @@
+package discovery
+
+import (
+	"net/netip"
+	"slices"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/pkg/threaddiscovery"
+)
+
+// Requery browses service once more, for up to timeout, and reports whether an
+// instance with an address within prefix answers. It confirms that devices are
+// really gone, not just silent, before their route is removed.
+func Requery(browser Browser, service string, prefix netip.Prefix, timeout time.Duration) bool {
+	return requery(browser, service, timeout, func(inst Instance) bool {
+		return slices.ContainsFunc(inst.Addrs, prefix.Contains)
+	})
+}
+
+// RequeryRouter browses border routers once more, for up to timeout, and
+// reports whether the one at nexthop answers while still announcing a mesh
+// prefix (omr=) containing network. A router that answers with another prefix,
+// e.g. after re-forming its Thread network, no longer routes network.
+func RequeryRouter(browser Browser, nexthop netip.Addr, network netip.Prefix, timeout time.Duration) bool {
+	return requery(browser, "_meshcop._udp", timeout, func(inst Instance) bool {
+		r, ok := threaddiscovery.ParseBorderRouter(inst)
+		return ok && slices.Contains(r.IPv6Addrs, nexthop) && r.OMRPrefix.IsValid() &&
+			r.OMRPrefix.Bits() <= network.Bits() && r.OMRPrefix.Contains(network.Addr())
+	})
+}
+
+// requery browses service for up to timeout and reports whether an instance
+// matching match answers, returning on the first one.
+func requery(browser Browser, service string, timeout time.Duration, match func(Instance) bool) bool {
+	done := make(chan struct{})
+	defer close(done)
+	found := make(chan struct{}, 1)
+	go browser.Browse(service, done, func(inst Instance) {
+		if !match(inst) {
+			return
+		}
+		select {
+		case found <- struct{}{}:
+		default:
+		}
+	})
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-found:
+		return true
+	case <-timer.C:
+		return false
+	}
+}
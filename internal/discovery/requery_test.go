@@ -0,0 +1,61 @@
+package discovery
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestRequery(t *testing.T) {
+	browser := staticBrowser{
+		{Name: "Light._matter._tcp.local.", Addrs: []netip.Addr{netip.MustParseAddr("fd00:1111:2222:3333::10")}},
+		{Name: "Plug._matter._tcp.local.", Addrs: []netip.Addr{netip.MustParseAddr("2001:db8::20")}},
+	}
+
+	tests := []struct {
+		prefix   string
+		expected bool
+	}{
+		{"fd00:1111:2222:3333::/64", true},
+		{"fd00:1111:2222:3333::10/128", true},
+		{"fd00:1111:2222:4444::/64", false},
+	}
+
+	for _, tt := range tests {
+		started := time.Now()
+		if got := Requery(browser, "_matter._tcp", netip.MustParsePrefix(tt.prefix), 50*time.Millisecond); got != tt.expected {
+			t.Errorf("Requery(%s) = %v, want %v", tt.prefix, got, tt.expected)
+		}
+		if tt.expected && time.Since(started) >= 50*time.Millisecond {
+			t.Errorf("Expected Requery(%s) to return on the first answer", tt.prefix)
+		}
+	}
+}
+
+// TestRequeryRouter verifies a border router only keeps a route while it
+// answers at the next hop and still announces the route's network, so a stale
+// prefix through a live router is not kept.
+func TestRequeryRouter(t *testing.T) {
+	browser := serviceBrowser{
+		"_meshcop._udp": {{Name: "Apple TV._meshcop._udp.local.", Addrs: []netip.Addr{netip.MustParseAddr("2001:db8::1")},
+			Text: []string{"omr=\\@\\253\\000\\017\\000\\000\\000\\000\\000"}}},
+	}
+
+	tests := []struct {
+		nexthop, network string
+		expected         bool
+	}{
+		{"2001:db8::1", "fd00:1100::/64", true},
+		{"2001:db8::1", "fd00:1100::10/128", true},
+		{"2001:db8::1", "fd00:1111:2222:3333::/64", false}, // stale prefix through a live router
+		{"2001:db8::1", "fd00::/56", false},
+		{"2001:db8::2", "fd00:1100::/64", false},
+	}
+
+	for _, tt := range tests {
+		got := RequeryRouter(browser, netip.MustParseAddr(tt.nexthop), netip.MustParsePrefix(tt.network), 50*time.Millisecond)
+		if got != tt.expected {
+			t.Errorf("RequeryRouter(%s, %s) = %v, want %v", tt.nexthop, tt.network, got, tt.expected)
+		}
+	}
+}
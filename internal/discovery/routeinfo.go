@@ -0,0 +1,80 @@
+package discovery
+
+import (
+	"encoding/binary"
+	"net"
+	"net/netip"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/logger"
+)
+
+const ndOptionRouteInfo = 24
+
+// InfiniteLifetime is the lifetime of a route announced without expiry.
+const InfiniteLifetime time.Duration = -1
+
+// LifetimeSink is a Sink that schedules the expiry of mesh prefixes from the
+// route lifetimes their border routers announce.
+type LifetimeSink interface {
+	Sink
+	// ObservePrefixLifetime records that router announced a route to prefix
+	// valid for lifetime: zero withdraws it and InfiniteLifetime leaves it to
+	// the grace period. It reports whether the prefix is a known mesh prefix.
+	ObservePrefixLifetime(prefix netip.Prefix, lifetime time.Duration, router string) bool
+}
+
+// routeInfo is a route from an RFC 4191 Route Information router advertisement option.
+type routeInfo struct {
+	prefix   netip.Prefix
+	lifetime time.Duration
+}
+
+// ListenRouteLifetimes listens for router advertisements on the infrastructure
+// link and records the lifetimes of the routes announced in their Route
+// Information options, such as a border router's off-mesh-routable prefix.
+// It needs a raw ICMPv6 socket (CAP_NET_RAW).
+func ListenRouteLifetimes(sink LifetimeSink, done <-chan struct{}) error {
+	return listenRouterAdvertisements("Route lifetimes", done, func(msg []byte, from net.Addr) {
+		router := from.String()
+		if ip, ok := from.(*net.IPAddr); ok {
+			router = ip.IP.String()
+		}
+		for _, r := range parseRouteInfo(msg) {
+			if sink.ObservePrefixLifetime(r.prefix, r.lifetime, router) {
+				logger.Debug("Route information from %s: %s lifetime=%s", router, r.prefix, r.lifetime)
+			}
+		}
+	})
+}
+
+// parseRouteInfo returns the Route Information options of an ICMPv6 router advertisement.
+func parseRouteInfo(msg []byte) []routeInfo {
+	if len(msg) < 16 || msg[0] != icmpv6RouterAdvertisement || msg[1] != 0 {
+		return nil
+	}
+	var out []routeInfo
+	for opts := msg[16:]; len(opts) >= 2; {
+		size := int(opts[1]) * 8
+		if size == 0 || size > len(opts) {
+			return out
+		}
+		if opts[0] == ndOptionRouteInfo && size >= 8 {
+			bits := int(opts[2])
+			if bits <= 128 && (size-8)*8 >= bits {
+				var raw [16]byte
+				copy(raw[:], opts[8:size])
+				lifetime := InfiniteLifetime
+				if secs := binary.BigEndian.Uint32(opts[4:8]); secs != 0xffffffff {
+					lifetime = time.Duration(secs) * time.Second
+				}
+				out = append(out, routeInfo{
+					prefix:   netip.PrefixFrom(netip.AddrFrom16(raw), bits).Masked(),
+					lifetime: lifetime,
+				})
+			}
+		}
+		opts = opts[size:]
+	}
+	return out
+}
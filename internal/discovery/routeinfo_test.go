@@ -0,0 +1,43 @@
+package discovery
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestParseRouteInfo(t *testing.T) {
+	ra := []byte{
+		134, 0, 0, 0, 64, 0, 0x07, 0x08, 0, 0, 0, 0, 0, 0, 0, 0, // RA header
+		1, 1, 0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff, // source link-layer address
+		// Route information: fd11:22::/64, lifetime 1800s
+		24, 2, 64, 0, 0, 0, 0x07, 0x08, 0xfd, 0x11, 0x00, 0x22, 0, 0, 0, 0,
+		// Route information: fd33::/48, withdrawn
+		24, 2, 48, 0x08, 0, 0, 0, 0, 0xfd, 0x33, 0, 0, 0, 0, 0, 0,
+		// Route information: ::/0, infinite lifetime, no prefix bytes
+		24, 1, 0, 0, 0xff, 0xff, 0xff, 0xff,
+		// Route information whose prefix length exceeds the option
+		24, 1, 64, 0, 0, 0, 0x07, 0x08,
+	}
+	got := parseRouteInfo(ra)
+	expected := []routeInfo{
+		{netip.MustParsePrefix("fd11:22::/64"), 1800 * time.Second},
+		{netip.MustParsePrefix("fd33::/48"), 0},
+		{netip.MustParsePrefix("::/0"), InfiniteLifetime},
+	}
+	if len(got) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, got)
+	}
+	for i := range expected {
+		if got[i] != expected[i] {
+			t.Errorf("Expected %v, got %v", expected[i], got[i])
+		}
+	}
+
+	if got := parseRouteInfo(ra[:30]); len(got) != 0 {
+		t.Errorf("Expected nothing from a truncated RA, got %v", got)
+	}
+	if got := parseRouteInfo(append([]byte{135}, ra[1:]...)); len(got) != 0 {
+		t.Errorf("Expected nothing from a non-RA message, got %v", got)
+	}
+}
@@ -0,0 +1,80 @@
+package discovery
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/pkg/dnssd"
+)
+
+// mdnsGroup is the IPv6 link-local mDNS multicast group.
+var mdnsGroup = &net.UDPAddr{IP: net.ParseIP("ff02::fb"), Port: 5353}
+
+// SelfTest checks that the host can take part in IPv6 mDNS: it joins ff02::fb,
+// sends a service enumeration query and waits for any mDNS packet — at least the
+// looped-back query itself. Without that the daemon would run with zero devices
+// and no hint why, most often because it runs in a Docker bridge network.
+func SelfTest(timeout time.Duration) error {
+	ifaces := dnssd.MulticastInterfaces()
+	if len(ifaces) == 0 {
+		return withContainerHint(errors.New("no multicast-capable interface with an IPv6 address"))
+	}
+
+	conn, err := net.ListenMulticastUDP("udp6", nil, mdnsGroup)
+	if err != nil {
+		return withContainerHint(fmt.Errorf("cannot join %s: %v", mdnsGroup, err))
+	}
+	defer func() { _ = conn.Close() }()
+
+	if _, err := conn.WriteToUDP(mdnsQuery("_services._dns-sd._udp.local."), mdnsGroup); err != nil {
+		return withContainerHint(fmt.Errorf("cannot send mDNS query on %s: %v", strings.Join(ifaces, ","), err))
+	}
+
+	if err := conn.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		return err
+	}
+	buf := make([]byte, 9000)
+	if _, _, err := conn.ReadFromUDP(buf); err != nil {
+		return withContainerHint(fmt.Errorf("no mDNS traffic received within %s on %s", timeout, strings.Join(ifaces, ",")))
+	}
+	return nil
+}
+
+// withContainerHint adds a remediation hint to err when running inside a container.
+func withContainerHint(err error) error {
+	if !inContainer() {
+		return err
+	}
+	return fmt.Errorf("%v: running in a container — mDNS multicast does not cross Docker bridge networks, run with --network host (Kubernetes: hostNetwork: true)", err)
+}
+
+// inContainer reports whether the process appears to run inside a container.
+func inContainer() bool {
+	if _, err := os.Stat("/.dockerenv"); err == nil {
+		return true
+	}
+	if data, err := os.ReadFile("/proc/1/cgroup"); err == nil {
+		s := string(data)
+		return strings.Contains(s, "docker") || strings.Contains(s, "kubepods") || strings.Contains(s, "containerd")
+	}
+	return false
+}
+
+// mdnsQuery encodes a one-question PTR query for name.
+func mdnsQuery(name string) []byte {
+	msg := make([]byte, 12, 64)
+	binary.BigEndian.PutUint16(msg[4:], 1) // QDCOUNT
+	for _, label := range strings.Split(strings.TrimSuffix(name, "."), ".") {
+		msg = append(msg, byte(len(label)))
+		msg = append(msg, label...)
+	}
+	msg = append(msg, 0)
+	msg = binary.BigEndian.AppendUint16(msg, 12) // QTYPE PTR
+	msg = binary.BigEndian.AppendUint16(msg, 1)  // QCLASS IN
+	return msg
+}
@@ -0,0 +1,21 @@
+package discovery
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestMDNSQuery(t *testing.T) {
+	got := mdnsQuery("_meshcop._udp.local.")
+	expected := []byte{
+		0, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0,
+		8, '_', 'm', 'e', 's', 'h', 'c', 'o', 'p',
+		4, '_', 'u', 'd', 'p',
+		5, 'l', 'o', 'c', 'a', 'l',
+		0,
+		0, 12, 0, 1,
+	}
+	if !bytes.Equal(got, expected) {
+		t.Errorf("Expected %v, got %v", expected, got)
+	}
+}
@@ -0,0 +1,34 @@
+package discovery
+
+import (
+	"sync"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/logger"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/pkg/dnssd"
+)
+
+// BrowseSRPServices browses services that Thread devices register over SRP and
+// border routers' advertising proxies publish, such as HomeKit accessories. Their
+// ULA addresses keep known Thread mesh prefixes alive when Matter announcements
+// are sparse. They never add prefixes: the same service types are also announced
+// by Wi-Fi and Ethernet devices, whose addresses are not on a Thread mesh.
+func BrowseSRPServices(sink Sink, browser Browser, services []string, done <-chan struct{}) {
+	var wg sync.WaitGroup
+	for _, service := range services {
+		wg.Add(1)
+		go func(service string) {
+			defer wg.Done()
+			browser.Browse(service, done, func(inst Instance) {
+				for _, ip := range inst.Addrs {
+					if !ip.IsPrivate() {
+						continue
+					}
+					if cidr := CIDR64(ip); cidr.IsValid() && sink.RefreshPrefix(cidr) {
+						logger.Debug("Thread mesh prefix %s refreshed by %s (%s)", cidr, dnssd.InstanceLabel(inst.Name), service)
+					}
+				}
+			})
+		}(service)
+	}
+	wg.Wait()
+}
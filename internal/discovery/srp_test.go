@@ -0,0 +1,40 @@
+package discovery
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+)
+
+// prefixSink records the prefixes refreshed through it; fd00:1111:2222:3333::/64
+// is known.
+type prefixSink struct {
+	refreshed []netip.Prefix
+}
+
+func (s *prefixSink) MergeBorderRouter(BorderRouter)           {}
+func (s *prefixSink) MergeDevice(MatterDevice)                 {}
+func (s *prefixSink) MergeTRELPeer(TRELPeer)                   {}
+func (s *prefixSink) ObserveNAT64(netip.Prefix, time.Duration) {}
+func (s *prefixSink) ObservePrefix(p netip.Prefix) bool        { panic("SRP services must not add prefixes") }
+func (s *prefixSink) RefreshPrefix(p netip.Prefix) bool {
+	s.refreshed = append(s.refreshed, p)
+	return p == netip.MustParsePrefix("fd00:1111:2222:3333::/64")
+}
+
+func TestBrowseSRPServices(t *testing.T) {
+	browser := staticBrowser{{
+		Name: "Eve\\ Door._hap._udp.local.",
+		Addrs: []netip.Addr{
+			netip.MustParseAddr("fd00:1111:2222:3333::10"),
+			netip.MustParseAddr("fd00:9999:8888:7777::10"),
+			netip.MustParseAddr("2001:4860:4860::10"), // not ULA
+		},
+	}}
+	sink := &prefixSink{}
+	BrowseSRPServices(sink, browser, []string{"_hap._udp"}, nil)
+
+	if len(sink.refreshed) != 2 {
+		t.Errorf("Expected both ULA /64s offered for refresh, got %v", sink.refreshed)
+	}
+}
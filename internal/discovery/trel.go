@@ -0,0 +1,34 @@
+package discovery
+
+import (
+	"net/netip"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/logger"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/pkg/dnssd"
+)
+
+// TRELPeer is a Thread Radio Encapsulation Link peer: a border router carrying
+// Thread frames to other border routers over the infrastructure link.
+type TRELPeer struct {
+	Name       string       `json:"name"`
+	ExtAddress string       `json:"ext_address,omitempty"`
+	ExtPANID   string       `json:"ext_pan_id,omitempty"`
+	IPv6Addrs  []netip.Addr `json:"ipv6_addrs"`
+	LastSeen   time.Time    `json:"last_seen"`
+}
+
+// BrowseTRELPeers browses _trel._udp for TREL peers, recording them so their
+// peering with border routers can be shown.
+func BrowseTRELPeers(sink Sink, browser Browser, done <-chan struct{}) {
+	browser.Browse("_trel._udp", done, func(inst Instance) {
+		logger.Debug("DNS-SD _trel._udp: name=%s ips=%v txt=%v", inst.Name, inst.Addrs, inst.Text)
+		sink.MergeTRELPeer(TRELPeer{
+			Name:       dnssd.InstanceLabel(inst.Name),
+			ExtAddress: dnssd.TXTHex(inst.Text, "xa", 8),
+			ExtPANID:   dnssd.TXTHex(inst.Text, "xp", 8),
+			IPv6Addrs:  inst.Addrs,
+			LastSeen:   time.Now(),
+		})
+	})
+}
@@ -0,0 +1,209 @@
+// Package dnszone serves a small authoritative DNS zone naming the discovered
+// Matter devices, so they can be reached by a stable name while their
+// addresses rotate.
+package dnszone
+
+import (
+	"net"
+	"net/netip"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/miekg/dns"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/discovery"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/logger"
+)
+
+// ttl is the TTL of the answers, short so rotated addresses are picked up soon.
+const ttl = 60
+
+// Devices returns the discovered Matter devices.
+type Devices func() []discovery.MatterDevice
+
+// Server answers queries for the zone from the current devices. Each device is
+// named after its instance name, e.g.
+// "8f2a1c3d4e5b6071-0000000000000001.thread.home.arpa.", with an AAAA record per
+// routable address it last announced.
+type Server struct {
+	zone    string
+	devices Devices
+}
+
+// New returns a server for zone (e.g. "thread.home.arpa") answering from devices.
+func New(zone string, devices Devices) *Server {
+	return &Server{zone: dns.Fqdn(strings.ToLower(zone)), devices: devices}
+}
+
+// Serve answers queries on addr over UDP and TCP until done is closed. It
+// returns an error when either socket cannot be opened.
+func (s *Server) Serve(addr string, done <-chan struct{}) error {
+	pc, err := net.ListenPacket("udp", addr)
+	if err != nil {
+		return err
+	}
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		_ = pc.Close()
+		return err
+	}
+	servers := []*dns.Server{{PacketConn: pc, Handler: s}, {Listener: ln, Handler: s}}
+	for _, srv := range servers {
+		go func() {
+			if err := srv.ActivateAndServe(); err != nil {
+				logger.Debug("DNS zone: %v", err)
+			}
+		}()
+	}
+	go func() {
+		<-done
+		for _, srv := range servers {
+			_ = srv.Shutdown()
+		}
+	}()
+	logger.Info("DNS zone %s served on %s", s.zone, addr)
+	return nil
+}
+
+// ServeDNS implements dns.Handler.
+func (s *Server) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
+	_ = w.WriteMsg(s.answer(r))
+}
+
+// answer returns the response to query r.
+func (s *Server) answer(r *dns.Msg) *dns.Msg {
+	m := new(dns.Msg)
+	m.SetReply(r)
+	m.Authoritative = true
+	if len(r.Question) != 1 {
+		m.Rcode = dns.RcodeFormatError
+		return m
+	}
+	q := r.Question[0]
+	name := strings.ToLower(q.Name)
+	if !dns.IsSubDomain(s.zone, name) {
+		m.Authoritative = false
+		m.Rcode = dns.RcodeRefused
+		return m
+	}
+
+	if name == s.zone {
+		switch q.Qtype {
+		case dns.TypeSOA, dns.TypeANY:
+			m.Answer = append(m.Answer, s.soa())
+		case dns.TypeNS:
+			m.Answer = append(m.Answer, s.ns())
+		}
+		if len(m.Answer) == 0 {
+			m.Ns = append(m.Ns, s.soa())
+		}
+		return m
+	}
+
+	addrs, ok := s.records()[name]
+	if !ok {
+		m.Rcode = dns.RcodeNameError
+		m.Ns = append(m.Ns, s.soa())
+		return m
+	}
+	if q.Qtype == dns.TypeAAAA || q.Qtype == dns.TypeANY {
+		for _, addr := range addrs {
+			m.Answer = append(m.Answer, &dns.AAAA{
+				Hdr:  dns.RR_Header{Name: q.Name, Rrtype: dns.TypeAAAA, Class: dns.ClassINET, Ttl: ttl},
+				AAAA: addr.AsSlice(),
+			})
+		}
+	}
+	if len(m.Answer) == 0 {
+		m.Ns = append(m.Ns, s.soa())
+	}
+	return m
+}
+
+// records maps each device name in the zone to its routable addresses as last
+// announced. Devices without one are left out; of devices whose names collide,
+// the first listed wins.
+func (s *Server) records() map[string][]netip.Addr {
+	records := make(map[string][]netip.Addr)
+	for _, d := range s.devices() {
+		label := Label(d.Name)
+		if label == "" {
+			continue
+		}
+		name := label + "." + s.zone
+		if _, taken := records[name]; taken {
+			continue
+		}
+		var addrs []netip.Addr
+		for _, addr := range d.IPv6Addrs {
+			if !addr.IsLinkLocalUnicast() && !addr.IsLoopback() {
+				addrs = append(addrs, addr)
+			}
+		}
+		if len(addrs) > 0 {
+			records[name] = addrs
+		}
+	}
+	return records
+}
+
+// Record is a name served in the zone.
+type Record struct {
+	Name      string       `json:"name"`
+	IPv6Addrs []netip.Addr `json:"ipv6_addrs"`
+}
+
+// Records returns the names served, sorted, for the status API.
+func (s *Server) Records() []Record {
+	var records []Record
+	for name, addrs := range s.records() {
+		records = append(records, Record{Name: name, IPv6Addrs: addrs})
+	}
+	slices.SortFunc(records, func(a, b Record) int { return strings.Compare(a.Name, b.Name) })
+	return records
+}
+
+// Label turns a device instance name into a DNS label: lower case, with runs
+// of anything but letters, digits and hyphens replaced by a hyphen, and at
+// most 63 characters.
+func Label(name string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(name) {
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			b.WriteRune(r)
+			hyphen = false
+		} else if !hyphen && b.Len() > 0 {
+			b.WriteByte('-')
+			hyphen = true
+		}
+	}
+	label := strings.TrimRight(b.String(), "-")
+	if len(label) > 63 {
+		label = strings.TrimRight(label[:63], "-")
+	}
+	return label
+}
+
+// soa returns the zone's SOA record.
+func (s *Server) soa() dns.RR {
+	return &dns.SOA{
+		Hdr:     dns.RR_Header{Name: s.zone, Rrtype: dns.TypeSOA, Class: dns.ClassINET, Ttl: ttl},
+		Ns:      "ns." + s.zone,
+		Mbox:    "hostmaster." + s.zone,
+		Serial:  1,
+		Refresh: 3600,
+		Retry:   600,
+		Expire:  86400,
+		Minttl:  ttl,
+	}
+}
+
+// ns returns the zone's NS record.
+func (s *Server) ns() dns.RR {
+	return &dns.NS{
+		Hdr: dns.RR_Header{Name: s.zone, Rrtype: dns.TypeNS, Class: dns.ClassINET, Ttl: ttl},
+		Ns:  "ns." + s.zone,
+	}
+}
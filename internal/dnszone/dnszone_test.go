@@ -0,0 +1,87 @@
+package dnszone
+
+import (
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/miekg/dns"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/discovery"
+)
+
+func testServer() *Server {
+	return New("Thread.Home.Arpa", func() []discovery.MatterDevice {
+		return []discovery.MatterDevice{
+			{Name: "8F2A-0001", IPv6Addrs: []netip.Addr{netip.MustParseAddr("fe80::1"), netip.MustParseAddr("fd00:1::1"), netip.MustParseAddr("fd00:1::2")}},
+			{Name: "8f2a 0001", IPv6Addrs: []netip.Addr{netip.MustParseAddr("fd00:1::9")}},
+			{Name: "link-local-only", IPv6Addrs: []netip.Addr{netip.MustParseAddr("fe80::2")}},
+		}
+	})
+}
+
+func TestAnswer(t *testing.T) {
+	s := testServer()
+	tests := []struct {
+		name    string
+		qname   string
+		qtype   uint16
+		rcode   int
+		answers int
+		soa     bool
+	}{
+		{"Device", "8f2a-0001.thread.home.arpa.", dns.TypeAAAA, dns.RcodeSuccess, 2, false},
+		{"Mixed case", "8F2A-0001.Thread.Home.Arpa.", dns.TypeAAAA, dns.RcodeSuccess, 2, false},
+		{"No A records", "8f2a-0001.thread.home.arpa.", dns.TypeA, dns.RcodeSuccess, 0, true},
+		{"Link-local only", "link-local-only.thread.home.arpa.", dns.TypeAAAA, dns.RcodeNameError, 0, true},
+		{"Unknown", "missing.thread.home.arpa.", dns.TypeAAAA, dns.RcodeNameError, 0, true},
+		{"Apex SOA", "thread.home.arpa.", dns.TypeSOA, dns.RcodeSuccess, 1, false},
+		{"Apex NS", "thread.home.arpa.", dns.TypeNS, dns.RcodeSuccess, 1, false},
+		{"Outside zone", "example.com.", dns.TypeAAAA, dns.RcodeRefused, 0, false},
+	}
+	for _, tt := range tests {
+		q := new(dns.Msg)
+		q.SetQuestion(tt.qname, tt.qtype)
+		m := s.answer(q)
+		if m.Rcode != tt.rcode {
+			t.Errorf("%s: expected rcode %s, got %s", tt.name, dns.RcodeToString[tt.rcode], dns.RcodeToString[m.Rcode])
+		}
+		if len(m.Answer) != tt.answers {
+			t.Errorf("%s: expected %d answers, got %v", tt.name, tt.answers, m.Answer)
+		}
+		if soa := len(m.Ns) == 1 && m.Ns[0].Header().Rrtype == dns.TypeSOA; soa != tt.soa {
+			t.Errorf("%s: expected SOA in authority %v, got %v", tt.name, tt.soa, m.Ns)
+		}
+	}
+}
+
+func TestRecords(t *testing.T) {
+	records := testServer().Records()
+	if len(records) != 1 {
+		t.Fatalf("Expected 1 record, got %v", records)
+	}
+	got := records[0]
+	if got.Name != "8f2a-0001.thread.home.arpa." {
+		t.Errorf("Expected the first device to win the name, got %s", got.Name)
+	}
+	if len(got.IPv6Addrs) != 2 || got.IPv6Addrs[0] != netip.MustParseAddr("fd00:1::1") {
+		t.Errorf("Expected the routable addresses in announced order, got %v", got.IPv6Addrs)
+	}
+}
+
+func TestLabel(t *testing.T) {
+	tests := []struct {
+		name, want string
+	}{
+		{"8F2A1C3D4E5B6071-0000000000000001", "8f2a1c3d4e5b6071-0000000000000001"},
+		{"Kitchen Light (2)", "kitchen-light-2"},
+		{"__café__", "caf"},
+		{"***", ""},
+		{strings.Repeat("a", 62) + "-bc", strings.Repeat("a", 62)},
+	}
+	for _, tt := range tests {
+		if got := Label(tt.name); got != tt.want {
+			t.Errorf("Label(%q): expected %q, got %q", tt.name, tt.want, got)
+		}
+	}
+}
@@ -0,0 +1,142 @@
+// Package events provides the in-process event bus that carries device, border
+// router, prefix, route and sync lifecycle changes to interested subscribers.
+package events
+
+import (
+	"sync"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/logger"
+)
+
+// Kind identifies the type of a lifecycle event
+type Kind int
+
+const (
+	DeviceAdded Kind = iota
+	DeviceUpdated
+	DeviceExpired
+	RouterAdded
+	RouterUpdated
+	RouterExpired
+	PrefixAdded
+	PrefixExpired
+	RouteCreated
+	RouteRemoved
+	SyncSucceeded
+	SyncFailed
+	Renumbered
+	RouteUpdated
+	PrefixConflict
+	RouteFailed
+)
+
+var kindNames = map[Kind]string{
+	DeviceAdded:    "device-added",
+	DeviceUpdated:  "device-updated",
+	DeviceExpired:  "device-expired",
+	RouterAdded:    "router-added",
+	RouterUpdated:  "router-updated",
+	RouterExpired:  "router-expired",
+	PrefixAdded:    "prefix-added",
+	PrefixExpired:  "prefix-expired",
+	RouteCreated:   "route-created",
+	RouteRemoved:   "route-removed",
+	SyncSucceeded:  "sync-succeeded",
+	SyncFailed:     "sync-failed",
+	Renumbered:     "renumbered",
+	RouteUpdated:   "route-updated",
+	PrefixConflict: "prefix-conflict",
+	RouteFailed:    "route-failed",
+}
+
+// String returns the kebab-case name of the kind.
+func (k Kind) String() string {
+	if name, ok := kindNames[k]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// Topology reports whether the event changes the set of detected routes.
+func (k Kind) Topology() bool {
+	switch k {
+	case RouterAdded, RouterUpdated, RouterExpired, PrefixAdded, PrefixExpired, PrefixConflict:
+		return true
+	}
+	return false
+}
+
+// Event is a single lifecycle change. Only the fields relevant to Kind are set.
+type Event struct {
+	Kind    Kind
+	Time    time.Time
+	Name    string // device or border router name
+	Prefix  string // Thread mesh prefix or route network
+	Nexthop string // route next hop
+	Detail  string // free-form context, e.g. a sync summary
+	Err     error  // set for SyncFailed and RouteFailed
+}
+
+// Bus fans published events out to every subscriber. Publishing never blocks:
+// a subscriber whose buffer is full misses the event. A nil *Bus discards events.
+type Bus struct {
+	mu     sync.RWMutex
+	subs   []chan Event
+	closed bool
+}
+
+// NewBus returns an empty event bus.
+func NewBus() *Bus {
+	return &Bus{}
+}
+
+// Subscribe returns a channel receiving every event published after the call.
+// The channel is closed when the bus is closed.
+func (b *Bus) Subscribe(buffer int) <-chan Event {
+	ch := make(chan Event, buffer)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		close(ch)
+		return ch
+	}
+	b.subs = append(b.subs, ch)
+	return ch
+}
+
+// Publish delivers e to all subscribers, stamping Time if unset.
+func (b *Bus) Publish(e Event) {
+	if b == nil {
+		return
+	}
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	if b.closed {
+		return
+	}
+	for _, ch := range b.subs {
+		select {
+		case ch <- e:
+		default:
+			logger.Debug("Event bus: subscriber full, dropping %s event", e.Kind)
+		}
+	}
+}
+
+// Close closes all subscriber channels. Later publishes are discarded.
+func (b *Bus) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.closed {
+		return
+	}
+	b.closed = true
+	for _, ch := range b.subs {
+		close(ch)
+	}
+	b.subs = nil
+}
@@ -0,0 +1,71 @@
+package events
+
+import (
+	"testing"
+)
+
+func TestBusPublishSubscribe(t *testing.T) {
+	bus := NewBus()
+	a := bus.Subscribe(4)
+	b := bus.Subscribe(4)
+
+	bus.Publish(Event{Kind: RouterAdded, Name: "Router1"})
+
+	for _, ch := range []<-chan Event{a, b} {
+		e := <-ch
+		if e.Kind != RouterAdded || e.Name != "Router1" {
+			t.Errorf("Unexpected event %+v", e)
+		}
+		if e.Time.IsZero() {
+			t.Error("Expected Publish to stamp the event time")
+		}
+	}
+}
+
+func TestBusDropsWhenFull(t *testing.T) {
+	bus := NewBus()
+	ch := bus.Subscribe(1)
+
+	bus.Publish(Event{Kind: PrefixAdded})
+	bus.Publish(Event{Kind: PrefixExpired}) // must not block
+
+	if e := <-ch; e.Kind != PrefixAdded {
+		t.Errorf("Expected first event to be kept, got %s", e.Kind)
+	}
+	select {
+	case e := <-ch:
+		t.Errorf("Expected second event to be dropped, got %s", e.Kind)
+	default:
+	}
+}
+
+func TestBusClose(t *testing.T) {
+	bus := NewBus()
+	ch := bus.Subscribe(1)
+	bus.Close()
+	bus.Publish(Event{Kind: SyncFailed})
+
+	if _, ok := <-ch; ok {
+		t.Error("Expected subscriber channel to be closed")
+	}
+	if _, ok := <-bus.Subscribe(1); ok {
+		t.Error("Expected subscription after close to be closed")
+	}
+}
+
+func TestNilBusPublish(t *testing.T) {
+	var bus *Bus
+	bus.Publish(Event{Kind: RouteCreated}) // must not panic
+}
+
+func TestKindString(t *testing.T) {
+	if RouteRemoved.String() != "route-removed" {
+		t.Errorf("Unexpected name %q", RouteRemoved.String())
+	}
+	if Kind(999).String() != "unknown" {
+		t.Errorf("Unexpected name %q for unknown kind", Kind(999).String())
+	}
+	if !PrefixAdded.Topology() || SyncSucceeded.Topology() {
+		t.Error("Unexpected Topology classification")
+	}
+}
@@ -0,0 +1,97 @@
+package events
+
+import (
+	"sync"
+	"time"
+)
+
+// Record is a sync or route event as kept in a Ring.
+type Record struct {
+	Time   time.Time `json:"time"`
+	Action string    `json:"action"`          // "sync", "create", "update" or "delete"
+	Route  string    `json:"route,omitempty"` // "<network> -> <nexthop>"
+	Name   string    `json:"name,omitempty"`  // route name
+	Result string    `json:"result"`          // "ok" or "failed"
+	Detail string    `json:"detail,omitempty"`
+	Error  string    `json:"error,omitempty"`
+}
+
+// Ring keeps the last sync and route events in memory, so recent changes can
+// be reviewed without persistent storage. Other events are ignored.
+type Ring struct {
+	mu      sync.Mutex
+	records []Record
+	next    int // index the next record overwrites once full
+}
+
+// NewRing returns a ring keeping the last size records.
+func NewRing(size int) *Ring {
+	if size < 1 {
+		size = 1
+	}
+	return &Ring{records: make([]Record, 0, size)}
+}
+
+// Run records the events received on ch until it is closed.
+func (r *Ring) Run(ch <-chan Event) {
+	for e := range ch {
+		r.Add(e)
+	}
+}
+
+// Add records e if it is a sync or route event, overwriting the oldest record
+// once the ring is full.
+func (r *Ring) Add(e Event) {
+	rec, ok := recordOf(e)
+	if !ok {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.records) < cap(r.records) {
+		r.records = append(r.records, rec)
+		return
+	}
+	r.records[r.next] = rec
+	r.next = (r.next + 1) % len(r.records)
+}
+
+// Records returns the kept records, newest first.
+func (r *Ring) Records() []Record {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make([]Record, 0, len(r.records))
+	for i := len(r.records) - 1; i >= 0; i-- {
+		out = append(out, r.records[(r.next+i)%len(r.records)])
+	}
+	return out
+}
+
+// recordOf converts a sync or route event to a Record.
+func recordOf(e Event) (Record, bool) {
+	rec := Record{Time: e.Time, Name: e.Name, Result: "ok"}
+	if e.Prefix != "" && e.Nexthop != "" {
+		rec.Route = e.Prefix + " -> " + e.Nexthop
+	}
+	switch e.Kind {
+	case SyncSucceeded, SyncFailed:
+		rec.Action, rec.Detail = "sync", e.Detail
+	case RouteCreated:
+		rec.Action, rec.Detail = "create", e.Detail
+	case RouteUpdated:
+		rec.Action, rec.Detail = "update", e.Detail
+	case RouteRemoved:
+		rec.Action, rec.Detail = "delete", e.Detail
+	case RouteFailed:
+		rec.Action = e.Detail
+	default:
+		return Record{}, false
+	}
+	if e.Kind == SyncFailed || e.Kind == RouteFailed {
+		rec.Result = "failed"
+	}
+	if e.Err != nil {
+		rec.Error = e.Err.Error()
+	}
+	return rec, true
+}
@@ -0,0 +1,58 @@
+package events
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRingRecords(t *testing.T) {
+	ring := NewRing(10)
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	ring.Add(Event{Kind: RouterAdded, Name: "Router1"}) // not a sync or route event
+	ring.Add(Event{Kind: RouteCreated, Time: at, Name: "Thread route via A", Prefix: "fd00:1::/64", Nexthop: "2001:db8::1"})
+	ring.Add(Event{Kind: RouteFailed, Prefix: "fd00:2::/64", Nexthop: "2001:db8::2", Detail: "delete", Err: errors.New("boom")})
+	ring.Add(Event{Kind: SyncFailed, Detail: "1 failed", Err: errors.New("boom")})
+	ring.Add(Event{Kind: SyncSucceeded, Detail: "1 added"})
+
+	records := ring.Records()
+	expected := []Record{
+		{Action: "sync", Result: "ok", Detail: "1 added"},
+		{Action: "sync", Result: "failed", Detail: "1 failed", Error: "boom"},
+		{Action: "delete", Route: "fd00:2::/64 -> 2001:db8::2", Result: "failed", Error: "boom"},
+		{Time: at, Action: "create", Route: "fd00:1::/64 -> 2001:db8::1", Name: "Thread route via A", Result: "ok"},
+	}
+	if len(records) != len(expected) {
+		t.Fatalf("Expected %d records, got %+v", len(expected), records)
+	}
+	for i, want := range expected {
+		if records[i] != want {
+			t.Errorf("Record %d: expected %+v, got %+v", i, want, records[i])
+		}
+	}
+}
+
+func TestRingOverwritesOldest(t *testing.T) {
+	ring := NewRing(3)
+	for _, detail := range []string{"1", "2", "3", "4", "5"} {
+		ring.Add(Event{Kind: SyncSucceeded, Detail: detail})
+	}
+
+	records := ring.Records()
+	if len(records) != 3 || records[0].Detail != "5" || records[1].Detail != "4" || records[2].Detail != "3" {
+		t.Errorf("Expected the last 3 records newest first, got %+v", records)
+	}
+}
+
+func TestRingRun(t *testing.T) {
+	bus := NewBus()
+	ring := NewRing(5)
+	ch := bus.Subscribe(4)
+	bus.Publish(Event{Kind: RouteRemoved, Prefix: "fd00:1::/64", Nexthop: "2001:db8::1"})
+	bus.Close()
+	ring.Run(ch)
+
+	if records := ring.Records(); len(records) != 1 || records[0].Action != "delete" || records[0].Time.IsZero() {
+		t.Errorf("Expected the published event recorded, got %+v", records)
+	}
+}
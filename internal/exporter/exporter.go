@@ -0,0 +1,284 @@
+// Package exporter writes the computed routes to files other routing tooling
+// consumes: radvd route stanzas, a bird static protocol and an ip -6 route script.
+package exporter
+
+import (
+	"bytes"
+	"fmt"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/config"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/hooks"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/logger"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/routes"
+)
+
+const header = "# Generated by thread-route-updater; do not edit.\n"
+
+// format is an output format: its file name, mode and renderer.
+type format struct {
+	file   string
+	mode   os.FileMode
+	render func(groups []group, withdrawn []netip.Prefix) string
+}
+
+var formats = map[string]format{
+	"radvd": {"thread-routes.radvd.conf", 0o644, renderRadvd},
+	"bird":  {"thread-routes.bird.conf", 0o644, renderBird},
+	"ip":    {"thread-routes.sh", 0o755, renderIP},
+}
+
+// group is a route network with its next hops, sorted.
+type group struct {
+	network  netip.Prefix
+	nexthops []netip.Addr
+}
+
+// Exporter rewrites the route files when the routes change. It is not safe for
+// concurrent use.
+type Exporter struct {
+	cfg config.RouteExport
+	// loaded reports whether routed and withdrawn were read back from the ip
+	// script a previous run left on disk.
+	loaded bool
+	// routed are the networks of the last export.
+	routed map[netip.Prefix]bool
+	// withdrawn are the networks exported once and gone since, which the ip
+	// script keeps deleting until they are routed again.
+	withdrawn map[netip.Prefix]bool
+	// pending are the changed files the hook has not reloaded yet, because it
+	// failed; the next export runs it again with them.
+	pending []string
+}
+
+// New returns an exporter for cfg, or nil when cfg.Dir is empty.
+func New(cfg config.RouteExport) *Exporter {
+	if cfg.Dir == "" {
+		return nil
+	}
+	return &Exporter{cfg: cfg}
+}
+
+// Export renders rs in every configured format, atomically replacing the files
+// whose contents changed, then runs the hook if any did, or if it failed on a
+// previous export. Files are compared with what is on disk, so restarting the
+// daemon doesn't reload the consumer.
+func (e *Exporter) Export(rs []routes.Route) error {
+	groups := groupRoutes(rs)
+	withdrawn := e.withdraw(groups)
+	var changed []string
+	for _, name := range e.cfg.Formats {
+		f, ok := formats[name]
+		if !ok {
+			continue
+		}
+		path := filepath.Join(e.cfg.Dir, f.file)
+		content := []byte(f.render(groups, withdrawn))
+		if old, err := os.ReadFile(path); err == nil && bytes.Equal(old, content) {
+			continue
+		}
+		if err := writeAtomic(path, content, f.mode); err != nil {
+			return fmt.Errorf("write %s: %v", path, err)
+		}
+		logger.Info("Route export: wrote %d routes to %s", len(rs), path)
+		changed = append(changed, path)
+	}
+	if e.cfg.Hook == "" {
+		return nil
+	}
+	for _, path := range changed {
+		if !slices.Contains(e.pending, path) {
+			e.pending = append(e.pending, path)
+		}
+	}
+	if len(e.pending) == 0 {
+		return nil
+	}
+	if err := runHook(e.cfg.Hook, e.pending); err != nil {
+		return err
+	}
+	e.pending = nil
+	return nil
+}
+
+// withdraw records the networks of groups as routed, returning the networks
+// routed by an earlier export and gone since, sorted. The first call reads the
+// networks back from the ip script on disk, so routes that disappeared while
+// the daemon was down are still deleted.
+func (e *Exporter) withdraw(groups []group) []netip.Prefix {
+	if !e.loaded {
+		e.loaded = true
+		e.routed, e.withdrawn = make(map[netip.Prefix]bool), make(map[netip.Prefix]bool)
+		if data, err := os.ReadFile(filepath.Join(e.cfg.Dir, formats["ip"].file)); err == nil {
+			e.routed, e.withdrawn = parseIP(string(data))
+		}
+	}
+	routed := make(map[netip.Prefix]bool, len(groups))
+	for _, g := range groups {
+		routed[g.network] = true
+		delete(e.withdrawn, g.network)
+	}
+	for network := range e.routed {
+		if !routed[network] {
+			e.withdrawn[network] = true
+		}
+	}
+	e.routed = routed
+
+	withdrawn := make([]netip.Prefix, 0, len(e.withdrawn))
+	for network := range e.withdrawn {
+		withdrawn = append(withdrawn, network)
+	}
+	sort.Slice(withdrawn, func(i, j int) bool { return lessPrefix(withdrawn[i], withdrawn[j]) })
+	return withdrawn
+}
+
+// Files returns the paths of the route files cfg has the exporter write, or
+// nil when the exporter is disabled.
+func Files(cfg config.RouteExport) []string {
+	if cfg.Dir == "" {
+		return nil
+	}
+	var files []string
+	for _, name := range cfg.Formats {
+		if f, ok := formats[name]; ok {
+			files = append(files, filepath.Join(cfg.Dir, f.file))
+		}
+	}
+	return files
+}
+
+// writeAtomic replaces path by content through a temporary file in the same
+// directory, so consumers never read a partial file.
+func writeAtomic(path string, content []byte, mode os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(tmp.Name()) }() // fails harmlessly once renamed
+	if _, err := tmp.Write(content); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Chmod(mode); err != nil {
+		_ = tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// runHook runs the reload hook with the changed files in ROUTE_EXPORT_FILES,
+// space-separated.
+func runHook(hook string, changed []string) error {
+	if err := hooks.Exec(hook, []string{"ROUTE_EXPORT_FILES=" + strings.Join(changed, " ")}); err != nil {
+		return fmt.Errorf("hook %v", err)
+	}
+	logger.Debug("Route export: hook %q done", hook)
+	return nil
+}
+
+// groupRoutes groups routes by network, sorting networks and next hops so the
+// files only change when the routes do.
+func groupRoutes(rs []routes.Route) []group {
+	byNetwork := make(map[netip.Prefix][]netip.Addr)
+	for _, r := range rs {
+		byNetwork[r.CIDR] = append(byNetwork[r.CIDR], r.ThreadRouterIPv6)
+	}
+	groups := make([]group, 0, len(byNetwork))
+	for network, nexthops := range byNetwork {
+		sort.Slice(nexthops, func(i, j int) bool { return nexthops[i].Less(nexthops[j]) })
+		groups = append(groups, group{network: network, nexthops: nexthops})
+	}
+	sort.Slice(groups, func(i, j int) bool { return lessPrefix(groups[i].network, groups[j].network) })
+	return groups
+}
+
+// lessPrefix orders networks by address, then by length.
+func lessPrefix(a, b netip.Prefix) bool {
+	if a.Addr() != b.Addr() {
+		return a.Addr().Less(b.Addr())
+	}
+	return a.Bits() < b.Bits()
+}
+
+// renderRadvd renders radvd route stanzas, advertising each network as a route
+// information option; include the file inside an interface block.
+func renderRadvd(groups []group, _ []netip.Prefix) string {
+	var sb strings.Builder
+	sb.WriteString(header)
+	for _, g := range groups {
+		fmt.Fprintf(&sb, "route %s {\n\tAdvRoutePreference medium;\n};\n", g.network)
+	}
+	return sb.String()
+}
+
+// renderBird renders a bird 2 static protocol, with a multipath route for
+// networks reachable through several border routers.
+func renderBird(groups []group, _ []netip.Prefix) string {
+	var sb strings.Builder
+	sb.WriteString(header)
+	sb.WriteString("protocol static thread_routes {\n\tipv6;\n")
+	for _, g := range groups {
+		fmt.Fprintf(&sb, "\troute %s", g.network)
+		for _, nh := range g.nexthops {
+			fmt.Fprintf(&sb, " via %s", nh)
+		}
+		sb.WriteString(";\n")
+	}
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+// renderIP renders a shell script of ip -6 route replace commands, using ECMP
+// next hops for networks reachable through several border routers. Withdrawn
+// networks are deleted first; the deletes ignore routes already gone, so the
+// script can be run any number of times.
+func renderIP(groups []group, withdrawn []netip.Prefix) string {
+	var sb strings.Builder
+	sb.WriteString("#!/bin/sh\n" + header)
+	for _, network := range withdrawn {
+		fmt.Fprintf(&sb, "ip -6 route del %s 2>/dev/null || true\n", network)
+	}
+	for _, g := range groups {
+		fmt.Fprintf(&sb, "ip -6 route replace %s", g.network)
+		if len(g.nexthops) == 1 {
+			fmt.Fprintf(&sb, " via %s\n", g.nexthops[0])
+			continue
+		}
+		for _, nh := range g.nexthops {
+			fmt.Fprintf(&sb, " nexthop via %s", nh)
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+// parseIP returns the networks an ip script routes and deletes.
+func parseIP(script string) (routed, withdrawn map[netip.Prefix]bool) {
+	routed, withdrawn = make(map[netip.Prefix]bool), make(map[netip.Prefix]bool)
+	for line := range strings.Lines(script) {
+		fields := strings.Fields(line)
+		if len(fields) < 5 || fields[0] != "ip" || fields[1] != "-6" || fields[2] != "route" {
+			continue
+		}
+		network, err := netip.ParsePrefix(fields[4])
+		if err != nil {
+			continue
+		}
+		switch fields[3] {
+		case "replace":
+			routed[network] = true
+		case "del":
+			withdrawn[network] = true
+		}
+	}
+	return routed, withdrawn
+}
@@ -0,0 +1,139 @@
+package exporter
+
+import (
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/config"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/routes"
+)
+
+var testRoutes = []routes.Route{
+	{CIDR: netip.MustParsePrefix("fd00:2::/64"), ThreadRouterIPv6: netip.MustParseAddr("2001:4860::1"), RouterName: "Router1"},
+	{CIDR: netip.MustParsePrefix("fd00:1::/64"), ThreadRouterIPv6: netip.MustParseAddr("2001:4860::2"), RouterName: "Router2"},
+	{CIDR: netip.MustParsePrefix("fd00:1::/64"), ThreadRouterIPv6: netip.MustParseAddr("2001:4860::1"), RouterName: "Router1"},
+}
+
+func TestRender(t *testing.T) {
+	groups := groupRoutes(testRoutes)
+	tests := []struct {
+		format    string
+		withdrawn []netip.Prefix
+		expected  string
+	}{
+		{"radvd", nil, header +
+			"route fd00:1::/64 {\n\tAdvRoutePreference medium;\n};\n" +
+			"route fd00:2::/64 {\n\tAdvRoutePreference medium;\n};\n"},
+		{"bird", nil, header + "protocol static thread_routes {\n\tipv6;\n" +
+			"\troute fd00:1::/64 via 2001:4860::1 via 2001:4860::2;\n" +
+			"\troute fd00:2::/64 via 2001:4860::1;\n}\n"},
+		{"ip", nil, "#!/bin/sh\n" + header +
+			"ip -6 route replace fd00:1::/64 nexthop via 2001:4860::1 nexthop via 2001:4860::2\n" +
+			"ip -6 route replace fd00:2::/64 via 2001:4860::1\n"},
+		{"ip", []netip.Prefix{netip.MustParsePrefix("fd00:3::/64")}, "#!/bin/sh\n" + header +
+			"ip -6 route del fd00:3::/64 2>/dev/null || true\n" +
+			"ip -6 route replace fd00:1::/64 nexthop via 2001:4860::1 nexthop via 2001:4860::2\n" +
+			"ip -6 route replace fd00:2::/64 via 2001:4860::1\n"},
+	}
+	for _, tt := range tests {
+		if got := formats[tt.format].render(groups, tt.withdrawn); got != tt.expected {
+			t.Errorf("Expected %s output:\n%s\ngot:\n%s", tt.format, tt.expected, got)
+		}
+	}
+}
+
+func TestExport(t *testing.T) {
+	dir := t.TempDir()
+	marker := filepath.Join(dir, "hook-runs")
+	exp := New(config.RouteExport{
+		Dir:     dir,
+		Formats: []string{"bird", "ip"},
+		Hook:    `echo "$ROUTE_EXPORT_FILES" >> ` + marker,
+	})
+
+	if err := exp.Export(testRoutes); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if err := exp.Export(testRoutes); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	if err := exp.Export(testRoutes[:1]); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+
+	data, err := os.ReadFile(marker)
+	if err != nil {
+		t.Fatalf("Expected the hook to run: %v", err)
+	}
+	runs := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(runs) != 2 {
+		t.Fatalf("Expected the hook to run only when files changed, got %d runs: %q", len(runs), runs)
+	}
+	if want := filepath.Join(dir, "thread-routes.bird.conf") + " " + filepath.Join(dir, "thread-routes.sh"); runs[0] != want {
+		t.Errorf("Expected ROUTE_EXPORT_FILES %q, got %q", want, runs[0])
+	}
+	if info, err := os.Stat(filepath.Join(dir, "thread-routes.sh")); err != nil || info.Mode().Perm() != 0o755 {
+		t.Errorf("Expected an executable ip script, got %v %v", info, err)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "thread-routes.radvd.conf")); !os.IsNotExist(err) {
+		t.Errorf("Expected no radvd file when the format is not configured, got %v", err)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 3 {
+		t.Errorf("Expected no temporary files left behind, got %d entries", len(entries))
+	}
+	script, _ := os.ReadFile(filepath.Join(dir, "thread-routes.sh"))
+	if !strings.Contains(string(script), "ip -6 route del fd00:1::/64 ") {
+		t.Errorf("Expected the ip script to delete the route that disappeared, got:\n%s", script)
+	}
+
+	// A restarted daemon reads the routed networks back from the script.
+	exp = New(config.RouteExport{Dir: dir, Formats: []string{"ip"}})
+	if err := exp.Export(nil); err != nil {
+		t.Fatalf("Export failed: %v", err)
+	}
+	script, _ = os.ReadFile(filepath.Join(dir, "thread-routes.sh"))
+	for _, network := range []string{"fd00:1::/64", "fd00:2::/64"} {
+		if !strings.Contains(string(script), "ip -6 route del "+network+" ") {
+			t.Errorf("Expected the ip script to delete %s after a restart, got:\n%s", network, script)
+		}
+	}
+}
+
+func TestExportHookFailure(t *testing.T) {
+	exp := New(config.RouteExport{Dir: t.TempDir(), Formats: []string{"ip"}, Hook: "echo reload failed; exit 1"})
+	if err := exp.Export(testRoutes); err == nil || !strings.Contains(err.Error(), "reload failed") {
+		t.Errorf("Expected hook failure with its output, got %v", err)
+	}
+
+	// A failed hook runs again on the next export, even without changes.
+	dir := t.TempDir()
+	ready := filepath.Join(dir, "ready")
+	marker := filepath.Join(dir, "hook-runs")
+	exp = New(config.RouteExport{
+		Dir:     dir,
+		Formats: []string{"ip"},
+		Hook:    `test -e ` + ready + ` && echo "$ROUTE_EXPORT_FILES" >> ` + marker,
+	})
+	if err := exp.Export(testRoutes); err == nil {
+		t.Fatal("Expected the hook to fail")
+	}
+	if err := os.WriteFile(ready, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for range 2 {
+		if err := exp.Export(testRoutes); err != nil {
+			t.Fatalf("Export failed: %v", err)
+		}
+	}
+	data, _ := os.ReadFile(marker)
+	if runs := strings.Split(strings.TrimSpace(string(data)), "\n"); len(runs) != 1 || runs[0] != filepath.Join(dir, "thread-routes.sh") {
+		t.Errorf("Expected the failed hook to run once more with the changed file, got %q", runs)
+	}
+	if New(config.RouteExport{}) != nil {
+		t.Error("Expected no exporter without a directory")
+	}
+}
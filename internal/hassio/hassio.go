@@ -0,0 +1,67 @@
+// Package hassio publishes the daemon's state to Home Assistant as an entity,
+// through the Core REST API or, in an add-on, the Supervisor's proxy of it.
+package hassio
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/config"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/poller"
+)
+
+// EntityID is the sensor the daemon's state is published as.
+const EntityID = "sensor.thread_route_updater"
+
+// Entity is the state and attributes of a Home Assistant entity.
+type Entity struct {
+	State      string                 `json:"state"`
+	Attributes map[string]interface{} `json:"attributes,omitempty"`
+}
+
+// Publish posts the entity returned by snapshot immediately and then every
+// interval until done is closed. It returns at once unless cfg enables
+// publishing and names the API.
+func Publish(cfg config.HomeAssistant, interval time.Duration, snapshot func() Entity, done <-chan struct{}) {
+	if !cfg.PublishState || cfg.URL == "" || cfg.Token == "" {
+		return
+	}
+	client := &http.Client{
+		Timeout: 10 * time.Second,
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: cfg.InsecureSSL},
+		},
+	}
+	poller.Run(done, interval, "home assistant state", func() error {
+		return postState(client, cfg, EntityID, snapshot())
+	})
+}
+
+// postState sets the state of entityID through POST /api/states/<entity_id>,
+// which creates the entity on first use.
+func postState(client *http.Client, cfg config.HomeAssistant, entityID string, entity Entity) error {
+	body, err := json.Marshal(entity)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", cfg.URL+"/api/states/"+entityID, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+cfg.Token)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		return fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+	return nil
+}
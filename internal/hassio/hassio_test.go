@@ -0,0 +1,69 @@
+package hassio
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/config"
+)
+
+func TestPostState(t *testing.T) {
+	var got Entity
+	var path, auth string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		auth = r.Header.Get("Authorization")
+		_ = json.NewDecoder(r.Body).Decode(&got)
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer srv.Close()
+	cfg := config.HomeAssistant{URL: srv.URL, Token: "supervisor-token", PublishState: true}
+
+	entity := Entity{State: "2", Attributes: map[string]interface{}{"devices": 5.0}}
+	if err := postState(srv.Client(), cfg, EntityID, entity); err != nil {
+		t.Fatalf("postState failed: %v", err)
+	}
+	if path != "/api/states/sensor.thread_route_updater" {
+		t.Errorf("Expected the sensor state endpoint, got %s", path)
+	}
+	if auth != "Bearer supervisor-token" {
+		t.Errorf("Expected the token sent, got %q", auth)
+	}
+	if got.State != "2" || got.Attributes["devices"] != 5.0 {
+		t.Errorf("Unexpected entity %+v", got)
+	}
+}
+
+func TestPostStateError(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer srv.Close()
+	cfg := config.HomeAssistant{URL: srv.URL, Token: "bad", PublishState: true}
+
+	if err := postState(srv.Client(), cfg, EntityID, Entity{State: "0"}); err == nil {
+		t.Error("Expected an error for a refused token")
+	}
+}
+
+// TestPublishDisabled verifies Publish returns at once unless enabled.
+func TestPublishDisabled(t *testing.T) {
+	done := make(chan struct{})
+	defer close(done)
+	finished := make(chan struct{})
+	go func() {
+		Publish(config.HomeAssistant{URL: "http://supervisor/core", Token: "t"}, time.Minute, func() Entity {
+			t.Error("Expected no snapshot with publishing disabled")
+			return Entity{}
+		}, done)
+		close(finished)
+	}()
+	select {
+	case <-finished:
+	case <-time.After(time.Second):
+		t.Error("Expected Publish to return with publishing disabled")
+	}
+}
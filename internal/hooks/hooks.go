@@ -0,0 +1,123 @@
+// Package hooks runs user commands on route lifecycle events, so local
+// integrations such as firewall updates or custom alerts need no code changes.
+package hooks
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/config"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/events"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/logger"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/metrics"
+)
+
+// Timeout bounds each command so a stuck script can't hold up later events.
+const Timeout = 30 * time.Second
+
+// queueSize bounds the hook runs waiting for the worker, so a stuck script
+// drops hook runs rather than holding up the event bus.
+var queueSize = 256
+
+var (
+	runs = metrics.NewCounter("hook_runs_total",
+		"Hook commands run, by hook and result (ok or failed).", "hook", "result")
+	dropped = metrics.NewCounter("hook_runs_dropped_total",
+		"Hook runs dropped because the hook queue was full, by hook.", "hook")
+)
+
+// job is a hook run waiting in the queue.
+type job struct {
+	name    string
+	command string
+	event   events.Event
+}
+
+// Exec runs command through the shell with env added to the daemon's
+// environment, returning its output in the error when it fails.
+func Exec(command string, env []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), Timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, "sh", "-c", command)
+	cmd.Env = append(os.Environ(), env...)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%q failed: %v: %s", command, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+// Run executes the hook configured for each event from ch, one at a time, until
+// ch closes. Route additions and updates run OnRouteAdd, removals OnRouteRemove
+// and failed syncs OnSyncFail. Events are taken off ch right away and queued
+// for a worker, so slow hooks never make the event bus drop events for other
+// subscribers; when the queue is full the hook run is dropped and logged.
+func Run(cfg config.Hooks, ch <-chan events.Event) {
+	queue := make(chan job, queueSize)
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for j := range queue {
+			runJob(j)
+		}
+	}()
+	for e := range ch {
+		name, command := hookFor(cfg, e.Kind)
+		if command == "" {
+			continue
+		}
+		select {
+		case queue <- job{name: name, command: command, event: e}:
+		default:
+			dropped.Inc(name)
+			logger.Warn("Hook %s: %d runs queued, dropping the run for %s %s", name, queueSize, e.Kind, e.Prefix)
+		}
+	}
+	close(queue)
+	<-done
+}
+
+// runJob runs a queued hook, counting and logging the result.
+func runJob(j job) {
+	if err := Exec(j.command, eventEnv(j.event)); err != nil {
+		runs.Inc(j.name, "failed")
+		logger.Warn("Hook %s: %v", j.name, err)
+		return
+	}
+	runs.Inc(j.name, "ok")
+	logger.Debug("Hook %s done for %s %s", j.name, j.event.Kind, j.event.Prefix)
+}
+
+// hookFor returns the hook name and command for events of kind k, if any.
+func hookFor(cfg config.Hooks, k events.Kind) (name, command string) {
+	switch k {
+	case events.RouteCreated, events.RouteUpdated:
+		return "ON_ROUTE_ADD", cfg.OnRouteAdd
+	case events.RouteRemoved:
+		return "ON_ROUTE_REMOVE", cfg.OnRouteRemove
+	case events.SyncFailed:
+		return "ON_SYNC_FAIL", cfg.OnSyncFail
+	}
+	return "", ""
+}
+
+// eventEnv describes e in environment variables for the hook command.
+func eventEnv(e events.Event) []string {
+	env := []string{
+		"HOOK_EVENT=" + e.Kind.String(),
+		"HOOK_TIME=" + e.Time.UTC().Format(time.RFC3339),
+	}
+	if e.Prefix != "" {
+		env = append(env, "ROUTE_NETWORK="+e.Prefix, "ROUTE_NEXTHOP="+e.Nexthop, "ROUTE_NAME="+e.Name)
+	}
+	if e.Detail != "" {
+		env = append(env, "HOOK_DETAIL="+e.Detail)
+	}
+	if e.Err != nil {
+		env = append(env, "SYNC_ERROR="+e.Err.Error())
+	}
+	return env
+}
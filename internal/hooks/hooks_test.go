@@ -0,0 +1,73 @@
+package hooks
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/config"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/events"
+)
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "out")
+	cfg := config.Hooks{
+		OnRouteAdd:    `echo "add $HOOK_EVENT $ROUTE_NETWORK $ROUTE_NEXTHOP $ROUTE_NAME" >> ` + out,
+		OnRouteRemove: `echo "remove $ROUTE_NETWORK" >> ` + out + `; exit 1`,
+		OnSyncFail:    `echo "fail $SYNC_ERROR" >> ` + out,
+	}
+
+	ch := make(chan events.Event, 8)
+	ch <- events.Event{Kind: events.RouteCreated, Name: "Thread route via Router1", Prefix: "fd00:1::/64", Nexthop: "2001:4860::1"}
+	ch <- events.Event{Kind: events.RouteRemoved, Prefix: "fd00:2::/64", Nexthop: "2001:4860::1"}
+	ch <- events.Event{Kind: events.PrefixAdded, Prefix: "fd00:3::/64"}
+	ch <- events.Event{Kind: events.SyncFailed, Err: errors.New("login failed")}
+	close(ch)
+	Run(cfg, ch)
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatalf("Expected hook output: %v", err)
+	}
+	expected := "add route-created fd00:1::/64 2001:4860::1 Thread route via Router1\n" +
+		"remove fd00:2::/64\n" +
+		"fail login failed\n"
+	if string(data) != expected {
+		t.Errorf("Expected hook output:\n%s\ngot:\n%s", expected, data)
+	}
+	if got := runs.Value("ON_ROUTE_REMOVE", "failed"); got != 1 {
+		t.Errorf("Expected 1 failed ON_ROUTE_REMOVE run, got %v", got)
+	}
+	if got := runs.Value("ON_ROUTE_ADD", "ok"); got != 1 {
+		t.Errorf("Expected 1 successful ON_ROUTE_ADD run, got %v", got)
+	}
+}
+
+func TestRunDropsWhenQueueFull(t *testing.T) {
+	defer func(size int) { queueSize = size }(queueSize)
+	queueSize = 1
+
+	ch := make(chan events.Event, 4)
+	for range 4 {
+		ch <- events.Event{Kind: events.RouteRemoved, Prefix: "fd00:2::/64"}
+	}
+	close(ch)
+	before := dropped.Value("ON_ROUTE_REMOVE")
+	Run(config.Hooks{OnRouteRemove: "sleep 0.2"}, ch)
+
+	if got := dropped.Value("ON_ROUTE_REMOVE") - before; got < 1 {
+		t.Errorf("Expected runs to be dropped behind a slow hook, got %v dropped", got)
+	}
+}
+
+func TestExec(t *testing.T) {
+	if err := Exec(`test "$GREETING" = hello`, []string{"GREETING=hello"}); err != nil {
+		t.Errorf("Expected success, got %v", err)
+	}
+	if err := Exec("echo broken >&2; exit 3", nil); err == nil || !strings.Contains(err.Error(), "broken") {
+		t.Errorf("Expected failure with output, got %v", err)
+	}
+}
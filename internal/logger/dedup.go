@@ -0,0 +1,138 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultDedup is the repeat window of each level when LOG_DEDUP is unset.
+const defaultDedup = "WARN=10m,ERROR=10m"
+
+// repeat tracks a message logged within its level's window.
+type repeat struct {
+	level Level
+	msg   string
+	since time.Time // when the message was last written
+	last  time.Time // when it was last suppressed
+	count int       // suppressed repeats
+}
+
+// line is a log line to write.
+type line struct {
+	level Level
+	msg   string
+}
+
+var (
+	dedupMu      sync.Mutex
+	dedupWindows = parseDedup(defaultDedup)
+	repeats      = make(map[string]*repeat)
+	now          = time.Now
+)
+
+// initDedup sets the repeat windows from LOG_DEDUP: comma-separated LEVEL=duration
+// pairs, such as "WARN=10m,ERROR=10m", or "off". An identical message logged
+// again within its level's window is suppressed and counted, and reported as
+// "last message repeated N times" once the window has passed.
+func initDedup() {
+	value := os.Getenv("LOG_DEDUP")
+	if value == "" {
+		value = defaultDedup
+	}
+	windows := parseDedup(value)
+	dedupMu.Lock()
+	defer dedupMu.Unlock()
+	dedupWindows = windows
+}
+
+// parseDedup parses LOG_DEDUP, skipping invalid pairs.
+func parseDedup(value string) map[Level]time.Duration {
+	windows := make(map[Level]time.Duration)
+	if strings.EqualFold(strings.TrimSpace(value), "off") {
+		return windows
+	}
+	for _, pair := range strings.Split(value, ",") {
+		name, d, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		level, known := parseLevel(name)
+		window, err := time.ParseDuration(strings.TrimSpace(d))
+		if !ok || !known || err != nil || window < 0 {
+			continue
+		}
+		windows[level] = window
+	}
+	return windows
+}
+
+// parseLevel returns the level named as in LOG_LEVEL.
+func parseLevel(name string) (Level, bool) {
+	switch strings.ToUpper(strings.TrimSpace(name)) {
+	case "DEBUG":
+		return DEBUG, true
+	case "INFO":
+		return INFO, true
+	case "WARN", "WARNING":
+		return WARN, true
+	case "ERROR":
+		return ERROR, true
+	}
+	return INFO, false
+}
+
+// throttle returns the lines to write for msg: the summaries of repeats whose
+// window has passed, then msg unless it repeats within its window.
+func throttle(level Level, msg string) []line {
+	dedupMu.Lock()
+	defer dedupMu.Unlock()
+	t := now()
+	out := expired(t, false)
+
+	window := dedupWindows[level]
+	if window <= 0 {
+		return append(out, line{level, msg})
+	}
+	key := level.String() + " " + msg
+	if r, ok := repeats[key]; ok {
+		r.count++
+		r.last = t
+		return out
+	}
+	repeats[key] = &repeat{level: level, msg: msg, since: t}
+	return append(out, line{level, msg})
+}
+
+// expired removes the repeats whose window has passed at t, or all of them
+// when all is set, and returns the summaries of those suppressed at least
+// once, oldest first.
+func expired(t time.Time, all bool) []line {
+	var done []*repeat
+	for key, r := range repeats {
+		if all || t.Sub(r.since) >= dedupWindows[r.level] {
+			delete(repeats, key)
+			if r.count > 0 {
+				done = append(done, r)
+			}
+		}
+	}
+	sort.Slice(done, func(i, j int) bool { return done[i].since.Before(done[j].since) })
+	out := make([]line, 0, len(done))
+	for _, r := range done {
+		out = append(out, line{r.level, fmt.Sprintf("last message repeated %d times in %s: %s",
+			r.count, FormatDuration(r.last.Sub(r.since)), r.msg)})
+	}
+	return out
+}
+
+// FlushRepeats writes the summaries of the repeats suppressed so far, so none
+// are lost on shutdown.
+func FlushRepeats() {
+	dedupMu.Lock()
+	lines := expired(now(), true)
+	dedupMu.Unlock()
+	for _, l := range lines {
+		write(l.level, l.msg)
+	}
+}
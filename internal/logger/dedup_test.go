@@ -0,0 +1,84 @@
+package logger
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseDedup(t *testing.T) {
+	tests := []struct {
+		value    string
+		expected map[Level]time.Duration
+	}{
+		{"WARN=10m,ERROR=10m", map[Level]time.Duration{WARN: 10 * time.Minute, ERROR: 10 * time.Minute}},
+		{"info=1m, warning=5m", map[Level]time.Duration{INFO: time.Minute, WARN: 5 * time.Minute}},
+		{"off", map[Level]time.Duration{}},
+		{"WARN=soon,LOUD=1m,ERROR", map[Level]time.Duration{}},
+	}
+	for _, tt := range tests {
+		if got := parseDedup(tt.value); !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("parseDedup(%q): expected %v, got %v", tt.value, tt.expected, got)
+		}
+	}
+}
+
+// TestThrottle verifies repeats within the window are suppressed and reported
+// once it has passed.
+func TestThrottle(t *testing.T) {
+	clock := time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	originalNow, originalWindows := now, dedupWindows
+	now = func() time.Time { return clock }
+	dedupWindows = map[Level]time.Duration{WARN: 10 * time.Minute}
+	t.Cleanup(func() {
+		now, dedupWindows = originalNow, originalWindows
+		repeats = make(map[string]*repeat)
+	})
+
+	var written []line
+	log := func(level Level, msg string) {
+		written = append(written, throttle(level, msg)...)
+	}
+
+	log(WARN, "All endpoints failed")
+	for i := 0; i < 3; i++ {
+		clock = clock.Add(2 * time.Minute)
+		log(WARN, "All endpoints failed")
+		log(INFO, "Status: 0 routes")
+	}
+	clock = clock.Add(5 * time.Minute)
+	log(WARN, "All endpoints failed")
+
+	want := []line{
+		{WARN, "All endpoints failed"},
+		{INFO, "Status: 0 routes"},
+		{INFO, "Status: 0 routes"},
+		{INFO, "Status: 0 routes"},
+		{WARN, "last message repeated 3 times in 6m: All endpoints failed"},
+		{WARN, "All endpoints failed"},
+	}
+	if !reflect.DeepEqual(written, want) {
+		t.Errorf("Expected %v, got %v", want, written)
+	}
+}
+
+func TestFlushRepeats(t *testing.T) {
+	originalWindows := dedupWindows
+	dedupWindows = map[Level]time.Duration{ERROR: time.Hour}
+	var got []string
+	SetSink(func(level Level, msg string) { got = append(got, msg) })
+	t.Cleanup(func() {
+		SetSink(nil)
+		dedupWindows = originalWindows
+		repeats = make(map[string]*repeat)
+	})
+
+	Error("sync failed")
+	Error("sync failed")
+	FlushRepeats()
+	FlushRepeats()
+
+	if len(got) != 2 || got[1] != "last message repeated 1 times in 0s: sync failed" {
+		t.Errorf("Expected the repeat reported once on flush, got %v", got)
+	}
+}
@@ -0,0 +1,164 @@
+// Package logger provides the levelled log output shared by the daemon's packages.
+package logger
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// Level represents the logging severity level
+type Level int
+
+const (
+	DEBUG Level = iota
+	INFO
+	WARN
+	ERROR
+)
+
+var (
+	currentLevel Level = INFO
+	// levelMu guards currentLevel, which InitLevel changes on config reloads.
+	levelMu sync.RWMutex
+
+	sinkMu sync.RWMutex
+	sink   func(level Level, msg string) // replaces the standard logger when set
+)
+
+// enabled reports whether messages of level l are logged.
+func enabled(l Level) bool {
+	levelMu.RLock()
+	defer levelMu.RUnlock()
+	return currentLevel <= l
+}
+
+// String returns the name of the level as accepted by LOG_LEVEL.
+func (l Level) String() string {
+	switch l {
+	case DEBUG:
+		return "DEBUG"
+	case INFO:
+		return "INFO"
+	case WARN:
+		return "WARN"
+	case ERROR:
+		return "ERROR"
+	}
+	return fmt.Sprintf("Level(%d)", int(l))
+}
+
+// CurrentLevel returns the logging level in effect.
+func CurrentLevel() Level {
+	levelMu.RLock()
+	defer levelMu.RUnlock()
+	return currentLevel
+}
+
+// InitLevel initializes the logging level from environment variable, and the
+// repeat windows from LOG_DEDUP.
+func InitLevel() {
+	initDedup()
+	level, _ := parseLevel(os.Getenv("LOG_LEVEL"))
+	levelMu.Lock()
+	defer levelMu.Unlock()
+	currentLevel = level
+}
+
+// Debug logs debug messages
+func Debug(format string, args ...interface{}) {
+	if enabled(DEBUG) {
+		output(DEBUG, format, args)
+	}
+}
+
+// Info logs info messages
+func Info(format string, args ...interface{}) {
+	if enabled(INFO) {
+		output(INFO, format, args)
+	}
+}
+
+// Warn logs warning messages
+func Warn(format string, args ...interface{}) {
+	if enabled(WARN) {
+		output(WARN, format, args)
+	}
+}
+
+// Error logs error messages
+func Error(format string, args ...interface{}) {
+	if enabled(ERROR) {
+		output(ERROR, format, args)
+	}
+}
+
+// Printf logs through the functions above, for the public packages under
+// pkg/ that take a logger rather than importing this one.
+type Printf struct{}
+
+// Debugf logs debug messages.
+func (Printf) Debugf(format string, args ...interface{}) { Debug(format, args...) }
+
+// Infof logs info messages.
+func (Printf) Infof(format string, args ...interface{}) { Info(format, args...) }
+
+// Warnf logs warning messages.
+func (Printf) Warnf(format string, args ...interface{}) { Warn(format, args...) }
+
+// Errorf logs error messages.
+func (Printf) Errorf(format string, args ...interface{}) { Error(format, args...) }
+
+// RegisterSecret scrubs value from the log, see RegisterSecret.
+func (Printf) RegisterSecret(value string) { RegisterSecret(value) }
+
+// ForgetSecret stops scrubbing value, see ForgetSecret.
+func (Printf) ForgetSecret(value string) { ForgetSecret(value) }
+
+// SetSink sends log lines to fn instead of the standard logger, for system
+// logs such as the Windows Event Log that record the level themselves. A nil
+// fn restores the standard logger.
+func SetSink(fn func(level Level, msg string)) {
+	sinkMu.Lock()
+	defer sinkMu.Unlock()
+	sink = fn
+}
+
+// output writes a log line, scrubbed of secrets, unless it repeats within
+// the LOG_DEDUP window of its level.
+func output(level Level, format string, args []interface{}) {
+	for _, l := range throttle(level, Scrub(fmt.Sprintf(format, args...))) {
+		write(l.level, l.msg)
+	}
+}
+
+// write sends a log line to the sink or the standard logger.
+func write(level Level, msg string) {
+	sinkMu.RLock()
+	fn := sink
+	sinkMu.RUnlock()
+	if fn != nil {
+		fn(level, msg)
+		return
+	}
+	log.Print("[" + level.String() + "] " + msg)
+}
+
+// FormatDuration formats a duration to a human-readable string (e.g., "1h30m",
+// "45m", "30s")
+func FormatDuration(d time.Duration) string {
+	if d < time.Minute {
+		return fmt.Sprintf("%.0fs", d.Seconds())
+	} else if d < time.Hour {
+		return fmt.Sprintf("%.0fm", d.Minutes())
+	} else {
+		hours := int(d.Hours())
+		minutes := int(d.Minutes()) % 60
+		if minutes == 0 {
+			return fmt.Sprintf("%dh", hours)
+		}
+		return fmt.Sprintf("%dh%dm", hours, minutes)
+	}
+}
@@ -0,0 +1,181 @@
+package logger
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+// TestInitLevel tests the log level initialization function
+func TestInitLevel(t *testing.T) {
+	tests := []struct {
+		name          string
+		envValue      string
+		expectedLevel Level
+	}{
+		{
+			name:          "DEBUG level",
+			envValue:      "DEBUG",
+			expectedLevel: DEBUG,
+		},
+		{
+			name:          "INFO level",
+			envValue:      "INFO",
+			expectedLevel: INFO,
+		},
+		{
+			name:          "WARN level",
+			envValue:      "WARN",
+			expectedLevel: WARN,
+		},
+		{
+			name:          "WARNING level",
+			envValue:      "WARNING",
+			expectedLevel: WARN,
+		},
+		{
+			name:          "ERROR level",
+			envValue:      "ERROR",
+			expectedLevel: ERROR,
+		},
+		{
+			name:          "Invalid level defaults to INFO",
+			envValue:      "INVALID",
+			expectedLevel: INFO,
+		},
+		{
+			name:          "Empty value defaults to INFO",
+			envValue:      "",
+			expectedLevel: INFO,
+		},
+		{
+			name:          "Case insensitive",
+			envValue:      "debug",
+			expectedLevel: DEBUG,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Save original value
+			originalValue := os.Getenv("LOG_LEVEL")
+			originalLevel := currentLevel
+
+			// Set test value
+			if err := os.Setenv("LOG_LEVEL", tt.envValue); err != nil {
+				t.Fatalf("Failed to set LOG_LEVEL: %v", err)
+			}
+
+			// Reset to default before testing
+			currentLevel = INFO
+
+			// Test the function
+			InitLevel()
+
+			// Check result
+			if currentLevel != tt.expectedLevel {
+				t.Errorf("InitLevel() with LOG_LEVEL=%s set level to %v, want %v",
+					tt.envValue, currentLevel, tt.expectedLevel)
+			}
+
+			// Restore original values
+			if err := os.Setenv("LOG_LEVEL", originalValue); err != nil {
+				t.Errorf("Failed to restore LOG_LEVEL: %v", err)
+			}
+			currentLevel = originalLevel
+		})
+	}
+}
+
+// TestLoggingFunctions tests the logging functions with different log levels
+func TestLoggingFunctions(t *testing.T) {
+	// Save original level
+	originalLevel := currentLevel
+	defer func() { currentLevel = originalLevel }()
+
+	// Test each log level
+	levels := []struct {
+		level Level
+		name  string
+	}{
+		{DEBUG, "DEBUG"},
+		{INFO, "INFO"},
+		{WARN, "WARN"},
+		{ERROR, "ERROR"},
+	}
+
+	for _, level := range levels {
+		t.Run(level.name, func(t *testing.T) {
+			currentLevel = level.level
+
+			// Test that Debug only logs when level is DEBUG or lower
+			if level.level <= DEBUG {
+				// Should log (we can't easily test the actual output, but we can
+				// test it doesn't panic)
+				Debug("Test debug message")
+			}
+
+			// Test that Info only logs when level is INFO or lower
+			if level.level <= INFO {
+				Info("Test info message")
+			}
+
+			// Test that Warn only logs when level is WARN or lower
+			if level.level <= WARN {
+				Warn("Test warning message")
+			}
+
+			// Test that Error always logs
+			Error("Test error message")
+		})
+	}
+}
+
+// TestSetSink verifies a sink receives the lines, scrubbed and without the
+// level prefix, until it is removed.
+func TestSetSink(t *testing.T) {
+	originalLevel := currentLevel
+	defer func() { currentLevel = originalLevel }()
+	currentLevel = INFO
+
+	type line struct {
+		level Level
+		msg   string
+	}
+	var got []line
+	SetSink(func(level Level, msg string) { got = append(got, line{level, msg}) })
+	Debug("hidden")
+	Warn("token=%s", "abc123")
+	SetSink(nil)
+	Error("not captured")
+
+	if len(got) != 1 || got[0].level != WARN || got[0].msg != "token=REDACTED" {
+		t.Errorf("Expected one scrubbed WARN line, got %+v", got)
+	}
+}
+
+func TestFormatDuration(t *testing.T) {
+	tests := []struct {
+		name     string
+		duration time.Duration
+		expected string
+	}{
+		{"Seconds only", 30 * time.Second, "30s"},
+		{"Minutes only", 5 * time.Minute, "5m"},
+		{"Hours only", 2 * time.Hour, "2h"},
+		{"Hours and minutes", 2*time.Hour + 30*time.Minute, "2h30m"},
+		{"Hours with zero minutes", 3 * time.Hour, "3h"},
+		{"Less than a minute", 45 * time.Second, "45s"},
+		{"One minute", time.Minute, "1m"},
+		{"One hour", time.Hour, "1h"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := FormatDuration(tt.duration)
+			if result != tt.expected {
+				t.Errorf("FormatDuration(%v) = %s, want %s", tt.duration, result, tt.expected)
+			}
+		})
+	}
+}
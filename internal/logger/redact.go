@@ -0,0 +1,79 @@
+package logger
+
+import (
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Redacted replaces secrets in log lines.
+const Redacted = "REDACTED"
+
+// minSecretLen is the shortest registered secret scrubbed from log lines;
+// shorter values would mangle unrelated text. Short passwords are still
+// scrubbed where they appear as the value of a password field.
+const minSecretLen = 6
+
+var (
+	secretsMu sync.RWMutex
+	secrets   []string
+
+	// secretPatterns match secrets by their context in a line: key=value and
+	// "key": "value" pairs, bearer credentials and session cookies.
+	secretPatterns = []*regexp.Regexp{
+		regexp.MustCompile(`(?i)("?\b[\w-]*(?:password|passwd|passphrase|secret|token|api[-_]?key|authorization|cookie)"?\s*[:=]\s*)((?:bearer\s+|basic\s+)?(?:"[^"]*"|[^\s,;&}\]]+))`),
+		regexp.MustCompile(`(?i)(\bbearer\s+)[^\s,;"]+`),
+		regexp.MustCompile(`(?i)(\bunifises=)[^\s,;"&]+`),
+	}
+)
+
+// RegisterSecret makes every later log line replace value with REDACTED, for
+// credentials and session tokens that could end up in a message verbatim.
+// Values shorter than 6 characters are ignored.
+func RegisterSecret(value string) {
+	if len(value) < minSecretLen {
+		return
+	}
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+	for _, s := range secrets {
+		if s == value {
+			return
+		}
+	}
+	secrets = append(secrets, value)
+}
+
+// ForgetSecret stops scrubbing a value registered with RegisterSecret, for
+// session tokens that were replaced.
+func ForgetSecret(value string) {
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+	for i, s := range secrets {
+		if s == value {
+			secrets = append(secrets[:i], secrets[i+1:]...)
+			return
+		}
+	}
+}
+
+// Scrub returns line with registered secrets and anything that looks like a
+// credential replaced by REDACTED.
+func Scrub(line string) string {
+	secretsMu.RLock()
+	for _, s := range secrets {
+		line = strings.ReplaceAll(line, s, Redacted)
+	}
+	secretsMu.RUnlock()
+	line = secretPatterns[0].ReplaceAllStringFunc(line, func(m string) string {
+		sub := secretPatterns[0].FindStringSubmatch(m)
+		if strings.HasPrefix(sub[2], `"`) {
+			return sub[1] + `"` + Redacted + `"`
+		}
+		return sub[1] + Redacted
+	})
+	for _, re := range secretPatterns[1:] {
+		line = re.ReplaceAllString(line, "${1}"+Redacted)
+	}
+	return line
+}
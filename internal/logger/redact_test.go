@@ -0,0 +1,72 @@
+package logger
+
+import (
+	"bytes"
+	"log"
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestScrub verifies credentials are redacted by context.
+func TestScrub(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want string
+	}{
+		{"key value", "login with password=hunter2 failed", "login with password=REDACTED failed"},
+		{"json field", `body: {"username":"admin","password":"hunter2"}`, `body: {"username":"admin","password":"REDACTED"}`},
+		{"json token", `{"deviceToken": "abc123", "name":"x"}`, `{"deviceToken": "REDACTED", "name":"x"}`},
+		{"csrf header", "X-CSRF-Token: 0f1e2d3c", "X-CSRF-Token: REDACTED"},
+		{"cookie header", "Set-Cookie: TOKEN=eyJhbGciOi; Path=/", "Set-Cookie: REDACTED; Path=/"},
+		{"bearer", "Authorization: Bearer eyJhbGciOi", "Authorization: REDACTED"},
+		{"bare bearer", "sent bearer eyJhbGciOi to the API", "sent bearer REDACTED to the API"},
+		{"session cookie", "cookie jar unifises=abcdef", "cookie jar unifises=REDACTED"},
+		{"struct dump", "cfg={Username:admin Password:hunter2 Enabled:true}", "cfg={Username:admin Password:REDACTED Enabled:true}"},
+		{"harmless", "Thread route via Kitchen HomePod: fd00::/64", "Thread route via Kitchen HomePod: fd00::/64"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Scrub(tt.line); got != tt.want {
+				t.Errorf("Expected %q, got %q", tt.want, got)
+			}
+		})
+	}
+}
+
+// TestRegisteredSecrets verifies registered values are scrubbed wherever they
+// appear, until they are forgotten.
+func TestRegisteredSecrets(t *testing.T) {
+	RegisterSecret("s3ss10n-v4lue")
+	RegisterSecret("ubnt1") // too short to scrub
+	if got := Scrub("request failed for s3ss10n-v4lue/ubnt1"); got != "request failed for REDACTED/ubnt1" {
+		t.Errorf("Expected the registered secret to be redacted, got %q", got)
+	}
+	ForgetSecret("s3ss10n-v4lue")
+	if got := Scrub("s3ss10n-v4lue"); got != "s3ss10n-v4lue" {
+		t.Errorf("Expected a forgotten secret to be kept, got %q", got)
+	}
+}
+
+// TestLogLinesScrubbed verifies every level writes scrubbed lines.
+func TestLogLinesScrubbed(t *testing.T) {
+	var buf bytes.Buffer
+	log.SetOutput(&buf)
+	defer log.SetOutput(os.Stderr)
+	originalLevel := currentLevel
+	currentLevel = DEBUG
+	defer func() { currentLevel = originalLevel }()
+
+	RegisterSecret("correct-horse")
+	defer ForgetSecret("correct-horse")
+	Debug("payload %s", `{"password":"correct-horse"}`)
+	Info("login as %+v", struct{ User, Pass string }{"admin", "correct-horse"})
+	Warn("csrf_token=%s", "deadbeef")
+	Error("Authorization: Bearer %s", "eyJhbGciOi")
+
+	if out := buf.String(); strings.Contains(out, "correct-horse") || strings.Contains(out, "deadbeef") ||
+		strings.Contains(out, "eyJhbGciOi") {
+		t.Errorf("Expected secrets to be scrubbed from the log, got:\n%s", out)
+	}
+}
@@ -0,0 +1,90 @@
+package metrics
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestWriteText(t *testing.T) {
+	c := NewCounter("test_announcements_total", "Announcements processed.", "service")
+	c.Inc("_matter._tcp")
+	c.Add(2, "_matter._tcp")
+	c.Inc("_meshcop._udp")
+	h := NewHistogram("test_browse_seconds", "Browse duration.", []float64{0.5, 1}, "service")
+	h.Observe(0.2, "_matter._tcp")
+	h.Observe(0.7, "_matter._tcp")
+	h.Observe(3, "_matter._tcp")
+
+	if got := c.Value("_matter._tcp"); got != 3 {
+		t.Errorf("Expected count 3, got %v", got)
+	}
+	if got := h.Count("_matter._tcp"); got != 3 {
+		t.Errorf("Expected 3 observations, got %d", got)
+	}
+
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/metrics", nil))
+	body := rec.Body.String()
+	for _, want := range []string{
+		"# TYPE test_announcements_total counter\n",
+		`test_announcements_total{service="_matter._tcp"} 3` + "\n",
+		`test_announcements_total{service="_meshcop._udp"} 1` + "\n",
+		"# TYPE test_browse_seconds histogram\n",
+		`test_browse_seconds_bucket{service="_matter._tcp",le="0.5"} 1` + "\n",
+		`test_browse_seconds_bucket{service="_matter._tcp",le="1"} 2` + "\n",
+		`test_browse_seconds_bucket{service="_matter._tcp",le="+Inf"} 3` + "\n",
+		`test_browse_seconds_sum{service="_matter._tcp"} 3.9` + "\n",
+		`test_browse_seconds_count{service="_matter._tcp"} 3` + "\n",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("Expected %q in output:\n%s", want, body)
+		}
+	}
+	if strings.Index(body, "test_announcements_total") > strings.Index(body, "test_browse_seconds") {
+		t.Error("Expected metrics sorted by name")
+	}
+}
+
+func TestGaugeFunc(t *testing.T) {
+	NewGaugeFunc("test_entries", "Entries per map.", "map", func() map[string]float64 {
+		return map[string]float64{"routes": 3, "devices": 2}
+	})
+	var sb strings.Builder
+	WriteText(&sb)
+	for _, want := range []string{
+		"# TYPE test_entries gauge\n",
+		`test_entries{map="devices"} 2` + "\n",
+		`test_entries{map="routes"} 3` + "\n",
+	} {
+		if !strings.Contains(sb.String(), want) {
+			t.Errorf("Expected %q in output:\n%s", want, sb.String())
+		}
+	}
+}
+
+func TestCounterFunc(t *testing.T) {
+	NewCounterFunc("test_rx_bytes_total", "Bytes received per device.", "mac", func() map[string]float64 {
+		return map[string]float64{"aa:bb:cc:dd:ee:ff": 1500}
+	})
+	var sb strings.Builder
+	WriteText(&sb)
+	for _, want := range []string{
+		"# TYPE test_rx_bytes_total counter\n",
+		`test_rx_bytes_total{mac="aa:bb:cc:dd:ee:ff"} 1500` + "\n",
+	} {
+		if !strings.Contains(sb.String(), want) {
+			t.Errorf("Expected %q in output:\n%s", want, sb.String())
+		}
+	}
+}
+
+func TestDuplicateRegistrationPanics(t *testing.T) {
+	NewCounter("test_duplicate_total", "First.")
+	defer func() {
+		if recover() == nil {
+			t.Error("Expected panic on duplicate registration")
+		}
+	}()
+	NewCounter("test_duplicate_total", "Second.")
+}
@@ -0,0 +1,259 @@
+// Package ndproxy answers IPv6 neighbor solicitations on the LAN for Thread
+// device addresses, for networks whose Thread prefix lies inside the LAN /64
+// where static routes can't help.
+package ndproxy
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"sync"
+	"time"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv6"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/logger"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/metrics"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/pkg/threaddiscovery"
+)
+
+const (
+	icmpv6NeighborSolicitation  = 135
+	icmpv6NeighborAdvertisement = 136
+	ndOptionTargetLinkAddr      = 2
+
+	naFlagSolicited = 0x40
+)
+
+// refreshInterval is how often the proxied addresses are re-read.
+const refreshInterval = 10 * time.Second
+
+var advertisements = metrics.NewCounter("ndproxy_advertisements_total",
+	"Neighbor advertisements sent on behalf of Thread devices, by kind (solicited or dad).", "kind")
+
+// allNodes is the link-local all-nodes multicast group.
+var allNodes = &net.IPAddr{IP: net.ParseIP("ff02::1")}
+
+// Targets returns the Thread device addresses to proxy, each with the next hop
+// (a border router address) that serves it.
+type Targets func() map[netip.Addr]netip.Addr
+
+// Proxy answers neighbor solicitations on one interface for the device
+// addresses inside the interface's on-link prefixes, with the link-layer
+// address of the border router serving the device, so LAN hosts send it
+// straight to the border router.
+type Proxy struct {
+	iface   *net.Interface
+	targets Targets
+
+	mu      sync.Mutex
+	proxied map[netip.Addr]net.HardwareAddr // target -> link-layer address to answer with
+	joined  map[netip.Addr]bool             // solicited-node groups joined
+}
+
+// New returns a proxy on the named interface for the addresses targets returns.
+func New(ifaceName string, targets Targets) (*Proxy, error) {
+	iface, err := net.InterfaceByName(ifaceName)
+	if err != nil {
+		return nil, fmt.Errorf("interface %s: %v", ifaceName, err)
+	}
+	return &Proxy{iface: iface, targets: targets, proxied: map[netip.Addr]net.HardwareAddr{}, joined: map[netip.Addr]bool{}}, nil
+}
+
+// Run starts answering solicitations until done is closed. It needs a raw
+// ICMPv6 socket (CAP_NET_RAW).
+func (p *Proxy) Run(done <-chan struct{}) error {
+	conn, err := icmp.ListenPacket("ip6:ipv6-icmp", "::")
+	if err != nil {
+		return err
+	}
+	pc := conn.IPv6PacketConn()
+	var filter ipv6.ICMPFilter
+	filter.SetAll(true)
+	filter.Accept(ipv6.ICMPTypeNeighborSolicitation)
+	if err := pc.SetICMPFilter(&filter); err != nil {
+		_ = conn.Close()
+		return err
+	}
+	if err := pc.SetControlMessage(ipv6.FlagInterface, true); err != nil {
+		_ = conn.Close()
+		return err
+	}
+	_ = pc.SetHopLimit(255)
+	_ = pc.SetMulticastHopLimit(255)
+
+	p.refresh(pc)
+	go func() {
+		ticker := time.NewTicker(refreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				p.refresh(pc)
+			case <-done:
+				_ = conn.Close()
+				return
+			}
+		}
+	}()
+	go p.serve(pc, done)
+	return nil
+}
+
+// serve answers the solicitations read from pc.
+func (p *Proxy) serve(pc *ipv6.PacketConn, done <-chan struct{}) {
+	buf := make([]byte, 1500)
+	for {
+		n, cm, src, err := pc.ReadFrom(buf)
+		if err != nil {
+			select {
+			case <-done:
+			default:
+				logger.Warn("ND proxy: %v", err)
+			}
+			return
+		}
+		if cm == nil || cm.IfIndex != p.iface.Index {
+			continue
+		}
+		target, ok := parseSolicitation(buf[:n])
+		if !ok {
+			continue
+		}
+		p.mu.Lock()
+		lladdr := p.proxied[target]
+		p.mu.Unlock()
+		if lladdr == nil {
+			continue
+		}
+		// A solicitation from the unspecified address is duplicate address
+		// detection; defend the address to all nodes, unsolicited.
+		dst, kind := src, "solicited"
+		if ip, ok := src.(*net.IPAddr); !ok || ip.IP.IsUnspecified() {
+			dst, kind = allNodes, "dad"
+		}
+		na := advertisement(target, lladdr, kind == "solicited")
+		if _, err := pc.WriteTo(na, &ipv6.ControlMessage{IfIndex: p.iface.Index, HopLimit: 255}, dst); err != nil {
+			logger.Debug("ND proxy: advertising %s to %s failed: %v", target, dst, err)
+			continue
+		}
+		advertisements.Inc(kind)
+		logger.Debug("ND proxy: answered %s for %s with %s", dst, target, lladdr)
+	}
+}
+
+// refresh re-reads the targets, keeps those on the interface's links and joins
+// their solicited-node multicast groups, leaving groups no longer needed.
+func (p *Proxy) refresh(pc *ipv6.PacketConn) {
+	proxied := p.answers(p.targets(), onLinkPrefixes(p.iface))
+	wanted := make(map[netip.Addr]bool, len(proxied))
+	for target := range proxied {
+		wanted[solicitedNode(target)] = true
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if len(proxied) != len(p.proxied) {
+		logger.Info("ND proxy: proxying %d Thread device addresses on %s", len(proxied), p.iface.Name)
+	}
+	p.proxied = proxied
+	for group := range wanted {
+		if p.joined[group] {
+			continue
+		}
+		if err := pc.JoinGroup(p.iface, &net.IPAddr{IP: group.AsSlice()}); err != nil {
+			logger.Warn("ND proxy: joining %s on %s failed: %v", group, p.iface.Name, err)
+			continue
+		}
+		p.joined[group] = true
+	}
+	for group := range p.joined {
+		if !wanted[group] {
+			_ = pc.LeaveGroup(p.iface, &net.IPAddr{IP: group.AsSlice()})
+			delete(p.joined, group)
+		}
+	}
+}
+
+// answers returns the targets inside the on-link prefixes with the link-layer
+// address to answer with: the border router's, derived from its EUI-64
+// address. Targets served by a border router without an EUI-64 address are
+// left unanswered, since answering with another host's address would
+// blackhole their traffic.
+func (p *Proxy) answers(targets map[netip.Addr]netip.Addr, onLink []netip.Prefix) map[netip.Addr]net.HardwareAddr {
+	proxied := make(map[netip.Addr]net.HardwareAddr)
+	for target, nexthop := range targets {
+		for _, prefix := range onLink {
+			if !prefix.Contains(target) {
+				continue
+			}
+			lladdr := threaddiscovery.MACFromEUI64(nexthop)
+			if lladdr == nil {
+				logger.Debug("ND proxy: not proxying %s, border router %s has no EUI-64 address", target, nexthop)
+				break
+			}
+			proxied[target] = lladdr
+			break
+		}
+	}
+	return proxied
+}
+
+// onLinkPrefixes returns the global IPv6 prefixes assigned to iface.
+func onLinkPrefixes(iface *net.Interface) []netip.Prefix {
+	addrs, err := iface.Addrs()
+	if err != nil {
+		return nil
+	}
+	var prefixes []netip.Prefix
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if !ok {
+			continue
+		}
+		ip, ok := netip.AddrFromSlice(ipNet.IP)
+		if !ok || !ip.Is6() || ip.Is4In6() || ip.IsLinkLocalUnicast() {
+			continue
+		}
+		bits, _ := ipNet.Mask.Size()
+		prefixes = append(prefixes, netip.PrefixFrom(ip, bits).Masked())
+	}
+	return prefixes
+}
+
+// solicitedNode returns the solicited-node multicast address of addr.
+func solicitedNode(addr netip.Addr) netip.Addr {
+	b := addr.As16()
+	return netip.AddrFrom16([16]byte{0xff, 0x02, 10: 0, 11: 0x01, 12: 0xff, 13: b[13], 14: b[14], 15: b[15]})
+}
+
+// parseSolicitation returns the target address of an ICMPv6 neighbor solicitation.
+func parseSolicitation(msg []byte) (netip.Addr, bool) {
+	if len(msg) < 24 || msg[0] != icmpv6NeighborSolicitation || msg[1] != 0 {
+		return netip.Addr{}, false
+	}
+	target := netip.AddrFrom16([16]byte(msg[8:24]))
+	if target.IsMulticast() {
+		return netip.Addr{}, false
+	}
+	return target, true
+}
+
+// advertisement encodes a neighbor advertisement for target with its
+// link-layer address. As a proxy it never sets the override flag (RFC 4861
+// section 7.2.8), so the device's own answers win. The kernel fills in the checksum.
+func advertisement(target netip.Addr, lladdr net.HardwareAddr, solicited bool) []byte {
+	msg := make([]byte, 24, 24+2+len(lladdr)+8)
+	msg[0] = icmpv6NeighborAdvertisement
+	if solicited {
+		msg[4] = naFlagSolicited
+	}
+	t := target.As16()
+	copy(msg[8:24], t[:])
+	optLen := (2 + len(lladdr) + 7) / 8
+	opt := make([]byte, optLen*8)
+	opt[0], opt[1] = ndOptionTargetLinkAddr, byte(optLen)
+	copy(opt[2:], lladdr)
+	return append(msg, opt...)
+}
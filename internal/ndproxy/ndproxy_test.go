@@ -0,0 +1,75 @@
+package ndproxy
+
+import (
+	"bytes"
+	"net"
+	"net/netip"
+	"testing"
+)
+
+func TestSolicitedNode(t *testing.T) {
+	got := solicitedNode(netip.MustParseAddr("fd00:1::12:3456:789a"))
+	if want := netip.MustParseAddr("ff02::1:ff56:789a"); got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+}
+
+func TestParseSolicitation(t *testing.T) {
+	ns := append([]byte{135, 0, 0, 0, 0, 0, 0, 0}, netip.MustParseAddr("fd00:1::5").AsSlice()...)
+	ns = append(ns, 1, 1, 0xaa, 0xbb, 0xcc, 0xdd, 0xee, 0xff) // source link-layer address
+
+	tests := []struct {
+		name string
+		msg  []byte
+		want string
+	}{
+		{"Solicitation", ns, "fd00:1::5"},
+		{"Advertisement", append([]byte{136}, ns[1:]...), ""},
+		{"Truncated", ns[:20], ""},
+		{"Multicast target", append([]byte{135, 0, 0, 0, 0, 0, 0, 0}, netip.MustParseAddr("ff02::1").AsSlice()...), ""},
+	}
+	for _, tt := range tests {
+		got := ""
+		if target, ok := parseSolicitation(tt.msg); ok {
+			got = target.String()
+		}
+		if got != tt.want {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.want, got)
+		}
+	}
+}
+
+func TestAdvertisement(t *testing.T) {
+	lladdr := net.HardwareAddr{0x02, 0x11, 0x22, 0x33, 0x44, 0x55}
+	na := advertisement(netip.MustParseAddr("fd00:1::5"), lladdr, true)
+	want := append([]byte{136, 0, 0, 0, naFlagSolicited, 0, 0, 0}, netip.MustParseAddr("fd00:1::5").AsSlice()...)
+	want = append(want, ndOptionTargetLinkAddr, 1, 0x02, 0x11, 0x22, 0x33, 0x44, 0x55)
+	if !bytes.Equal(na, want) {
+		t.Errorf("Expected %x, got %x", want, na)
+	}
+	if dad := advertisement(netip.MustParseAddr("fd00:1::5"), lladdr, false); dad[4] != 0 {
+		t.Errorf("Expected no flags when defending an address, got %#x", dad[4])
+	}
+}
+
+func TestAnswers(t *testing.T) {
+	p := &Proxy{iface: &net.Interface{Name: "eth0", HardwareAddr: net.HardwareAddr{0x02, 0, 0, 0, 0, 1}}}
+	eui64Router := netip.MustParseAddr("2001:4860::1211:22ff:fe33:4455")
+	privacyRouter := netip.MustParseAddr("2001:4860::8a3c:19d2:77e1:40b5")
+	targets := map[netip.Addr]netip.Addr{
+		netip.MustParseAddr("2001:4860::a"): eui64Router,
+		netip.MustParseAddr("2001:4860::b"): privacyRouter,
+		netip.MustParseAddr("fd00:9::c"):    eui64Router, // not on the LAN
+	}
+
+	got := p.answers(targets, []netip.Prefix{netip.MustParsePrefix("2001:4860::/64")})
+	if len(got) != 1 {
+		t.Fatalf("Expected the on-link target with an EUI-64 router, got %v", got)
+	}
+	if want := "10:11:22:33:44:55"; got[netip.MustParseAddr("2001:4860::a")].String() != want {
+		t.Errorf("Expected the border router's address %s, got %s", want, got[netip.MustParseAddr("2001:4860::a")])
+	}
+	if lladdr, ok := got[netip.MustParseAddr("2001:4860::b")]; ok {
+		t.Errorf("Expected no answer without an EUI-64 router address, got %s", lladdr)
+	}
+}
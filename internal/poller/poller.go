@@ -0,0 +1,34 @@
+// Package poller runs periodic background jobs.
+package poller
+
+import (
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/logger"
+)
+
+// Run calls fn immediately and then on every tick until done is closed. A
+// panic in fn is recovered and reported like a failed poll.
+func Run(done <-chan struct{}, interval time.Duration, label string, fn func() error) {
+	poll := func() (err error) {
+		if perr := Recover(label, func() { err = fn() }); perr != nil {
+			return perr
+		}
+		return err
+	}
+	if err := poll(); err != nil {
+		logger.Warn("%s poll failed: %v", label, err)
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := poll(); err != nil {
+				logger.Warn("%s poll failed: %v", label, err)
+			}
+		case <-done:
+			return
+		}
+	}
+}
@@ -0,0 +1,58 @@
+package poller
+
+import (
+	"fmt"
+	"runtime/debug"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/logger"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/metrics"
+)
+
+// Restart backoff of supervised goroutines: it doubles from minBackoff after
+// each panic up to maxBackoff, and starts over once a run outlasts maxBackoff.
+var (
+	minBackoff = time.Second
+	maxBackoff = time.Minute
+)
+
+var panics = metrics.NewCounter("goroutine_panics_total",
+	"Panics recovered in background goroutines, by goroutine.", "goroutine")
+
+// Recover runs fn and recovers a panic in it: the panic is logged with its
+// stack trace, counted in goroutine_panics_total under name and returned as
+// an error.
+func Recover(name string, fn func()) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			panics.Inc(name)
+			logger.Error("Panic in %s: %v\n%s", name, r, debug.Stack())
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	fn()
+	return nil
+}
+
+// Supervise runs fn until it returns or done is closed, restarting it with
+// backoff whenever it panics, so a bad entry or a library bug does not
+// silently stop a listener or worker for the rest of the daemon's life.
+func Supervise(name string, done <-chan struct{}, fn func()) {
+	backoff := minBackoff
+	for {
+		started := time.Now()
+		if Recover(name, fn) == nil {
+			return
+		}
+		if time.Since(started) > maxBackoff {
+			backoff = minBackoff
+		}
+		logger.Warn("Restarting %s in %s", name, backoff)
+		select {
+		case <-done:
+			return
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, maxBackoff)
+	}
+}
@@ -0,0 +1,78 @@
+package poller
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestRecover(t *testing.T) {
+	before := panics.Value("recover test")
+	err := Recover("recover test", func() {
+		var m map[string]int
+		m["boom"]++
+	})
+	if err == nil {
+		t.Fatal("Expected the panic returned as an error")
+	}
+	if got := panics.Value("recover test") - before; got != 1 {
+		t.Errorf("Expected one panic counted, got %v", got)
+	}
+	if err := Recover("recover test", func() {}); err != nil {
+		t.Errorf("Expected no error without a panic, got %v", err)
+	}
+}
+
+// TestSupervise verifies a panicking goroutine is restarted until it returns.
+func TestSupervise(t *testing.T) {
+	originalMin := minBackoff
+	minBackoff = time.Millisecond
+	t.Cleanup(func() { minBackoff = originalMin })
+
+	runs := 0
+	Supervise("supervise test", make(chan struct{}), func() {
+		runs++
+		if runs < 3 {
+			panic("unexpected nil entry")
+		}
+	})
+	if runs != 3 {
+		t.Errorf("Expected 3 runs, got %d", runs)
+	}
+}
+
+// TestSuperviseDone verifies no restart happens once done is closed.
+func TestSuperviseDone(t *testing.T) {
+	done := make(chan struct{})
+	close(done)
+	runs := 0
+	Supervise("supervise test", done, func() {
+		runs++
+		panic("boom")
+	})
+	if runs != 1 {
+		t.Errorf("Expected a single run after done, got %d", runs)
+	}
+}
+
+// TestRunRecovers verifies a panicking poll counts as a failed poll and the
+// poller keeps going.
+func TestRunRecovers(t *testing.T) {
+	done := make(chan struct{})
+	polls := 0
+	Run(done, time.Millisecond, "run test", func() error {
+		polls++
+		switch polls {
+		case 1:
+			panic("boom")
+		case 2:
+			return errors.New("failed")
+		case 3:
+			close(done)
+		}
+		return nil
+	})
+	if polls < 3 {
+		t.Errorf("Expected polling to go on after the panic, got %d polls", polls)
+	}
+}
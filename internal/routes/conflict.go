@@ -0,0 +1,81 @@
+package routes
+
+import (
+	"net/netip"
+	"sort"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/config"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/discovery"
+)
+
+// Conflict is a Thread mesh prefix announced (omr=) by border routers of several
+// Thread networks. Routing it through all of them would send traffic for one
+// mesh into another, so only the Winner network's routers are used.
+type Conflict struct {
+	Prefix   netip.Prefix `json:"prefix"`
+	Networks []string     `json:"networks"` // extended PAN IDs, sorted
+	Winner   string       `json:"winner"`   // "" when the prefix is withdrawn
+}
+
+// DetectConflicts returns the mesh prefixes announced by border routers of more
+// than one Thread network, sorted by prefix, with the network policy routes.
+// Routers without an extended PAN ID can't be told apart and are ignored.
+func DetectConflicts(routers []discovery.BorderRouter, policy config.ConflictPolicy) []Conflict {
+	networks := make(map[netip.Prefix]map[string]bool)
+	for _, r := range routers {
+		if !r.OMRPrefix.IsValid() || r.ExtPANID == "" {
+			continue
+		}
+		prefix := r.OMRPrefix.Masked()
+		if networks[prefix] == nil {
+			networks[prefix] = make(map[string]bool)
+		}
+		networks[prefix][r.ExtPANID] = true
+	}
+
+	var conflicts []Conflict
+	for prefix, set := range networks {
+		if len(set) < 2 {
+			continue
+		}
+		c := Conflict{Prefix: prefix}
+		for xp := range set {
+			c.Networks = append(c.Networks, xp)
+		}
+		sort.Strings(c.Networks)
+		switch policy {
+		case config.ConflictHighestExtPANID:
+			c.Winner = c.Networks[len(c.Networks)-1]
+		case config.ConflictWithdraw:
+		default:
+			c.Winner = c.Networks[0]
+		}
+		conflicts = append(conflicts, c)
+	}
+	sort.Slice(conflicts, func(i, j int) bool { return conflicts[i].Prefix.Addr().Less(conflicts[j].Prefix.Addr()) })
+	return conflicts
+}
+
+// ResolveConflicts drops the routes to conflicting prefixes that don't go
+// through a border router of the conflict's winning network.
+func ResolveConflicts(rs []Route, routers []discovery.BorderRouter, conflicts []Conflict) []Route {
+	if len(conflicts) == 0 {
+		return rs
+	}
+	winners := make(map[netip.Prefix]string, len(conflicts))
+	for _, c := range conflicts {
+		winners[c.Prefix] = c.Winner
+	}
+	network := make(map[string]string, len(routers))
+	for _, r := range routers {
+		network[r.Name] = r.ExtPANID
+	}
+	out := make([]Route, 0, len(rs))
+	for _, route := range rs {
+		if winner, ok := winners[route.CIDR]; ok && (winner == "" || network[route.RouterName] != winner) {
+			continue
+		}
+		out = append(out, route)
+	}
+	return out
+}
@@ -0,0 +1,68 @@
+package routes
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/config"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/discovery"
+)
+
+func conflictRouters() []discovery.BorderRouter {
+	shared := netip.MustParsePrefix("fd00:1111:2222:3333::/64")
+	return []discovery.BorderRouter{
+		{Name: "Kitchen", ExtPANID: "bbbbbbbbbbbbbbbb", OMRPrefix: shared,
+			IPv6Addrs: []netip.Addr{netip.MustParseAddr("2001:4860:4860:1234::b")}},
+		{Name: "Office", ExtPANID: "aaaaaaaaaaaaaaaa", OMRPrefix: shared,
+			IPv6Addrs: []netip.Addr{netip.MustParseAddr("2001:4860:4860:1234::a")}},
+		{Name: "Garage", ExtPANID: "aaaaaaaaaaaaaaaa", OMRPrefix: netip.MustParsePrefix("fd00:4444:5555:6666::/64"),
+			IPv6Addrs: []netip.Addr{netip.MustParseAddr("2001:4860:4860:1234::c")}},
+		{Name: "Unknown", OMRPrefix: shared,
+			IPv6Addrs: []netip.Addr{netip.MustParseAddr("2001:4860:4860:1234::d")}},
+	}
+}
+
+func TestDetectConflicts(t *testing.T) {
+	tests := []struct {
+		policy config.ConflictPolicy
+		winner string
+	}{
+		{config.ConflictLowestExtPANID, "aaaaaaaaaaaaaaaa"},
+		{config.ConflictHighestExtPANID, "bbbbbbbbbbbbbbbb"},
+		{config.ConflictWithdraw, ""},
+	}
+	for _, tt := range tests {
+		conflicts := DetectConflicts(conflictRouters(), tt.policy)
+		if len(conflicts) != 1 {
+			t.Fatalf("%s: expected 1 conflict, got %+v", tt.policy, conflicts)
+		}
+		c := conflicts[0]
+		if c.Prefix.String() != "fd00:1111:2222:3333::/64" || len(c.Networks) != 2 || c.Winner != tt.winner {
+			t.Errorf("%s: expected fd00:1111:2222:3333::/64 between 2 networks won by %q, got %+v", tt.policy, tt.winner, c)
+		}
+	}
+}
+
+func TestResolveConflicts(t *testing.T) {
+	routers := conflictRouters()
+	rs := Generate(prefixMap("fd00:1111:2222:3333::/64", "fd00:4444:5555:6666::/64"), routers)
+
+	got := ResolveConflicts(rs, routers, DetectConflicts(routers, config.ConflictLowestExtPANID))
+	shared := 0
+	for _, r := range got {
+		if r.CIDR.String() == "fd00:1111:2222:3333::/64" {
+			shared++
+			if r.RouterName != "Office" && r.RouterName != "Garage" {
+				t.Errorf("Expected conflicting prefix routed only through network aaaaaaaaaaaaaaaa, got %s", r.RouterName)
+			}
+		}
+	}
+	if shared != 2 || len(got) != 6 {
+		t.Errorf("Expected 2 routes to the conflicting prefix and 6 in total, got %d and %d", shared, len(got))
+	}
+
+	got = ResolveConflicts(rs, routers, DetectConflicts(routers, config.ConflictWithdraw))
+	if len(got) != 4 {
+		t.Errorf("Expected only the 4 routes to the unconflicted prefix, got %d", len(got))
+	}
+}
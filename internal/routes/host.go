@@ -0,0 +1,25 @@
+package routes
+
+import "net/netip"
+
+// HostRoutes turns prefix routes into /128 host routes: one for each address in
+// addrs that lies inside a route's prefix, through that route's border router.
+// Prefixes without a known device address get no route.
+func HostRoutes(prefixRoutes []Route, addrs []netip.Addr) []Route {
+	seen := make(map[Route]bool)
+	var hosts []Route
+	for _, r := range prefixRoutes {
+		for _, ip := range addrs {
+			ip = ip.WithZone("")
+			if !r.CIDR.Contains(ip) {
+				continue
+			}
+			host := Route{CIDR: netip.PrefixFrom(ip, 128), ThreadRouterIPv6: r.ThreadRouterIPv6, RouterName: r.RouterName}
+			if !seen[host] {
+				seen[host] = true
+				hosts = append(hosts, host)
+			}
+		}
+	}
+	return hosts
+}
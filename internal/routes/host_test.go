@@ -0,0 +1,30 @@
+package routes
+
+import (
+	"net/netip"
+	"testing"
+)
+
+func TestHostRoutes(t *testing.T) {
+	nexthop := netip.MustParseAddr("2001:4860::1")
+	prefixRoutes := []Route{
+		{CIDR: netip.MustParsePrefix("fd00:1::/64"), ThreadRouterIPv6: nexthop, RouterName: "Router1"},
+		{CIDR: netip.MustParsePrefix("fd00:2::/64"), ThreadRouterIPv6: nexthop, RouterName: "Router1"},
+	}
+	addrs := []netip.Addr{
+		netip.MustParseAddr("fd00:1::5"),
+		netip.MustParseAddr("fd00:1::5"), // reported by two devices
+		netip.MustParseAddr("fd00:1::6%eth0"),
+		netip.MustParseAddr("fd00:9::7"), // outside every routed prefix
+	}
+
+	got := HostRoutes(prefixRoutes, addrs)
+	if len(got) != 2 {
+		t.Fatalf("Expected 2 host routes, got %d: %+v", len(got), got)
+	}
+	for i, want := range []string{"fd00:1::5/128", "fd00:1::6/128"} {
+		if got[i].CIDR.String() != want || got[i].ThreadRouterIPv6 != nexthop || got[i].RouterName != "Router1" {
+			t.Errorf("Expected %s via Router1, got %+v", want, got[i])
+		}
+	}
+}
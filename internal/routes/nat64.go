@@ -0,0 +1,45 @@
+package routes
+
+import (
+	"net/netip"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/logger"
+)
+
+// wellKnownNAT64 are the NAT64 prefixes reserved by RFC 6052 and RFC 8215.
+var wellKnownNAT64 = []netip.Prefix{
+	netip.MustParsePrefix("64:ff9b::/96"),
+	netip.MustParsePrefix("64:ff9b:1::/48"),
+}
+
+// IsNAT64 reports whether prefix overlaps a well-known NAT64 prefix or one of nat64.
+func IsNAT64(prefix netip.Prefix, nat64 []netip.Prefix) bool {
+	for _, p := range wellKnownNAT64 {
+		if p.Overlaps(prefix) {
+			return true
+		}
+	}
+	for _, p := range nat64 {
+		if p.Overlaps(prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// ExcludeNAT64 returns the mesh prefixes that don't overlap a NAT64 prefix.
+// NAT64 traffic must reach the translator the border router advertises, so a
+// static route covering it on the edge router would break IPv4 connectivity
+// for Thread devices.
+func ExcludeNAT64(meshPrefixes map[netip.Prefix]time.Time, nat64 []netip.Prefix) map[netip.Prefix]time.Time {
+	out := make(map[netip.Prefix]time.Time, len(meshPrefixes))
+	for p, t := range meshPrefixes {
+		if IsNAT64(p, nat64) {
+			logger.Debug("Thread mesh prefix %s overlaps a NAT64 prefix, not routing it", p)
+			continue
+		}
+		out[p] = t
+	}
+	return out
+}
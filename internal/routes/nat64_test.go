@@ -0,0 +1,28 @@
+package routes
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestExcludeNAT64(t *testing.T) {
+	now := time.Now()
+	mesh := map[netip.Prefix]time.Time{
+		netip.MustParsePrefix("fd00:1111:2222:1::/64"): now, // OMR prefix
+		netip.MustParsePrefix("fd00:1111:2222:2::/64"): now, // contains the NAT64 /96
+		netip.MustParsePrefix("64:ff9b::/64"):          now, // well-known
+	}
+	nat64 := []netip.Prefix{netip.MustParsePrefix("fd00:1111:2222:2::/96")}
+
+	got := ExcludeNAT64(mesh, nat64)
+	if len(got) != 1 {
+		t.Fatalf("Expected only the OMR prefix, got %v", got)
+	}
+	if _, ok := got[netip.MustParsePrefix("fd00:1111:2222:1::/64")]; !ok {
+		t.Errorf("Expected the OMR prefix kept, got %v", got)
+	}
+	if len(mesh) != 3 {
+		t.Error("Expected the input left unchanged")
+	}
+}
@@ -0,0 +1,91 @@
+package routes
+
+import (
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/config"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/discovery"
+)
+
+// SelectNexthops applies the next hop policy. With policy.SkipUnreachable,
+// routes through border routers on networks the gateway does not route IPv6 on
+// are dropped. With policy.PreferWired, routes through border routers on Wi-Fi
+// are dropped when a wired border router announces the same network. With
+// policy.Single, each network keeps the one route through the best border
+// router: reachable first, then wired before unknown before Wi-Fi when
+// preferring wired, then by type rank, then by lowest router name and address
+// so the choice is stable between syncs.
+func SelectNexthops(rs []Route, routers []discovery.BorderRouter, policy config.NextHopPolicy) []Route {
+	if !policy.Single && !policy.PreferWired && !policy.SkipUnreachable {
+		return rs
+	}
+	byName := make(map[string]discovery.BorderRouter, len(routers))
+	for _, r := range routers {
+		byName[r.Name] = r
+	}
+	if policy.SkipUnreachable {
+		reachable := make([]Route, 0, len(rs))
+		for _, r := range rs {
+			if !byName[r.RouterName].Unreachable {
+				reachable = append(reachable, r)
+			}
+		}
+		rs = reachable
+	}
+	linkRank := func(r Route) int {
+		if !policy.PreferWired {
+			return 0
+		}
+		switch byName[r.RouterName].Link {
+		case discovery.LinkWired:
+			return 0
+		case discovery.LinkWireless:
+			return 2
+		}
+		return 1
+	}
+
+	if !policy.Single {
+		wired := make(map[string]bool)
+		for _, r := range rs {
+			if linkRank(r) == 0 {
+				wired[r.CIDR.String()] = true
+			}
+		}
+		out := make([]Route, 0, len(rs))
+		for _, r := range rs {
+			if linkRank(r) == 2 && wired[r.CIDR.String()] {
+				continue
+			}
+			out = append(out, r)
+		}
+		return out
+	}
+
+	better := func(a, b Route) bool {
+		if ua, ub := byName[a.RouterName].Unreachable, byName[b.RouterName].Unreachable; ua != ub {
+			return ub
+		}
+		if la, lb := linkRank(a), linkRank(b); la != lb {
+			return la < lb
+		}
+		if ra, rb := policy.Rank(byName[a.RouterName].Type), policy.Rank(byName[b.RouterName].Type); ra != rb {
+			return ra < rb
+		}
+		if a.RouterName != b.RouterName {
+			return a.RouterName < b.RouterName
+		}
+		return a.ThreadRouterIPv6.Less(b.ThreadRouterIPv6)
+	}
+	best := make(map[string]Route)
+	for _, r := range rs {
+		network := r.CIDR.String()
+		if current, ok := best[network]; !ok || better(r, current) {
+			best[network] = r
+		}
+	}
+	out := make([]Route, 0, len(best))
+	for _, r := range best {
+		out = append(out, r)
+	}
+	Sort(out)
+	return out
+}
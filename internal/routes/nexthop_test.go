@@ -0,0 +1,107 @@
+package routes
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/config"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/discovery"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/pkg/threaddiscovery"
+)
+
+// TestSelectNexthops verifies a single next hop keeps the route through the
+// best ranked border router type and all next hops keep every route.
+func TestSelectNexthops(t *testing.T) {
+	routers := []discovery.BorderRouter{
+		{Name: "Bedroom HomePod", Type: threaddiscovery.RouterTypeHomePod},
+		{Name: "Living Room", Type: threaddiscovery.RouterTypeAppleTV},
+		{Name: "Den", Type: threaddiscovery.RouterTypeAppleTV},
+		{Name: "Unknown"},
+	}
+	rs := []Route{
+		{CIDR: netip.MustParsePrefix("fd00:1::/64"), ThreadRouterIPv6: netip.MustParseAddr("2a02:8109::1"), RouterName: "Bedroom HomePod"},
+		{CIDR: netip.MustParsePrefix("fd00:1::/64"), ThreadRouterIPv6: netip.MustParseAddr("2a02:8109::3"), RouterName: "Living Room"},
+		{CIDR: netip.MustParsePrefix("fd00:1::/64"), ThreadRouterIPv6: netip.MustParseAddr("2a02:8109::2"), RouterName: "Den"},
+		{CIDR: netip.MustParsePrefix("fd00:2::/64"), ThreadRouterIPv6: netip.MustParseAddr("2a02:8109::4"), RouterName: "Unknown"},
+		{CIDR: netip.MustParsePrefix("fd00:2::/64"), ThreadRouterIPv6: netip.MustParseAddr("2a02:8109::1"), RouterName: "Bedroom HomePod"},
+	}
+	preference := []string{threaddiscovery.RouterTypeAppleTV, threaddiscovery.RouterTypeHomePod}
+
+	if got := SelectNexthops(rs, routers, config.NextHopPolicy{Preference: preference}); len(got) != len(rs) {
+		t.Errorf("Expected all next hops to keep %d routes, got %v", len(rs), got)
+	}
+
+	got := SelectNexthops(rs, routers, config.NextHopPolicy{Single: true, Preference: preference})
+	want := []Route{rs[2], rs[4]}
+	if len(got) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Expected route %d to be %v, got %v", i, want[i], got[i])
+		}
+	}
+}
+
+// TestSelectNexthopsPreferWired verifies Wi-Fi next hops give way to wired
+// ones announcing the same network, and rank after them for a single next hop.
+func TestSelectNexthopsPreferWired(t *testing.T) {
+	routers := []discovery.BorderRouter{
+		{Name: "Living Room", Type: threaddiscovery.RouterTypeAppleTV, Uplink: discovery.Uplink{Link: discovery.LinkWireless}},
+		{Name: "Bedroom HomePod", Type: threaddiscovery.RouterTypeHomePod, Uplink: discovery.Uplink{Link: discovery.LinkWired}},
+		{Name: "Office"},
+	}
+	rs := []Route{
+		{CIDR: netip.MustParsePrefix("fd00:1::/64"), ThreadRouterIPv6: netip.MustParseAddr("2a02:8109::1"), RouterName: "Living Room"},
+		{CIDR: netip.MustParsePrefix("fd00:1::/64"), ThreadRouterIPv6: netip.MustParseAddr("2a02:8109::2"), RouterName: "Bedroom HomePod"},
+		{CIDR: netip.MustParsePrefix("fd00:1::/64"), ThreadRouterIPv6: netip.MustParseAddr("2a02:8109::3"), RouterName: "Office"},
+		{CIDR: netip.MustParsePrefix("fd00:2::/64"), ThreadRouterIPv6: netip.MustParseAddr("2a02:8109::1"), RouterName: "Living Room"},
+	}
+	preference := []string{threaddiscovery.RouterTypeAppleTV, threaddiscovery.RouterTypeHomePod}
+
+	tests := []struct {
+		name   string
+		policy config.NextHopPolicy
+		want   []Route
+	}{
+		{"all", config.NextHopPolicy{PreferWired: true, Preference: preference}, []Route{rs[1], rs[2], rs[3]}},
+		{"single", config.NextHopPolicy{PreferWired: true, Single: true, Preference: preference}, []Route{rs[1], rs[3]}},
+		{"single by type", config.NextHopPolicy{Single: true, Preference: preference}, []Route{rs[0], rs[3]}},
+	}
+	for _, tt := range tests {
+		got := SelectNexthops(rs, routers, tt.policy)
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
+			continue
+		}
+		for i := range tt.want {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s: expected route %d to be %v, got %v", tt.name, i, tt.want[i], got[i])
+			}
+		}
+	}
+}
+
+// TestSelectNexthopsSkipUnreachable verifies routes through border routers on
+// networks the gateway does not route IPv6 on are dropped when asked, and
+// otherwise rank last for a single next hop.
+func TestSelectNexthopsSkipUnreachable(t *testing.T) {
+	routers := []discovery.BorderRouter{
+		{Name: "Living Room", Type: threaddiscovery.RouterTypeAppleTV, Uplink: discovery.Uplink{Network: "Cameras", VLAN: 40, Unreachable: true}},
+		{Name: "Bedroom HomePod", Type: threaddiscovery.RouterTypeHomePod, Uplink: discovery.Uplink{Network: "IoT", VLAN: 30}},
+	}
+	rs := []Route{
+		{CIDR: netip.MustParsePrefix("fd00:1::/64"), ThreadRouterIPv6: netip.MustParseAddr("2a02:8109::1"), RouterName: "Living Room"},
+		{CIDR: netip.MustParsePrefix("fd00:1::/64"), ThreadRouterIPv6: netip.MustParseAddr("2a02:8109::2"), RouterName: "Bedroom HomePod"},
+		{CIDR: netip.MustParsePrefix("fd00:2::/64"), ThreadRouterIPv6: netip.MustParseAddr("2a02:8109::1"), RouterName: "Living Room"},
+	}
+	preference := []string{threaddiscovery.RouterTypeAppleTV, threaddiscovery.RouterTypeHomePod}
+
+	if got := SelectNexthops(rs, routers, config.NextHopPolicy{SkipUnreachable: true}); len(got) != 1 || got[0] != rs[1] {
+		t.Errorf("Expected only %v, got %v", rs[1], got)
+	}
+	got := SelectNexthops(rs, routers, config.NextHopPolicy{Single: true, Preference: preference})
+	if len(got) != 2 || got[0] != rs[1] || got[1] != rs[2] {
+		t.Errorf("Expected %v and %v, got %v", rs[1], rs[2], got)
+	}
+}
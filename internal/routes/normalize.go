@@ -0,0 +1,24 @@
+package routes
+
+import "net/netip"
+
+// NormalizePrefix returns the canonical form of an IPv6 CIDR: host bits masked,
+// lower-case, zero runs compressed (e.g. "FD00:0:0:1::5/64" becomes "fd00:0:0:1::/64").
+// Strings that don't parse as a prefix are returned unchanged.
+func NormalizePrefix(cidr string) string {
+	prefix, err := netip.ParsePrefix(cidr)
+	if err != nil {
+		return cidr
+	}
+	return prefix.Masked().String()
+}
+
+// NormalizeAddr returns the canonical form of an IP address. Strings that don't
+// parse as an address are returned unchanged.
+func NormalizeAddr(addr string) string {
+	ip, err := netip.ParseAddr(addr)
+	if err != nil {
+		return addr
+	}
+	return ip.Unmap().String()
+}
@@ -0,0 +1,60 @@
+package routes
+
+import "testing"
+
+func TestNormalizePrefix(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"fd00:0:0:1::/64", "fd00:0:0:1::/64"},
+		{"FD00:0000:0000:0001:0000:0000:0000:0000/64", "fd00:0:0:1::/64"},
+		{"fd00:0:0:1::5/64", "fd00:0:0:1::/64"},
+		{"not-a-prefix", "not-a-prefix"},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizePrefix(tt.input); got != tt.expected {
+			t.Errorf("NormalizePrefix(%q): expected %q, got %q", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestNormalizeAddr(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected string
+	}{
+		{"2001:4860:0:0:0:0:0:1", "2001:4860::1"},
+		{"2001:DB8::AB", "2001:db8::ab"},
+		{"::ffff:192.168.1.1", "192.168.1.1"},
+		{"garbage", "garbage"},
+	}
+
+	for _, tt := range tests {
+		if got := NormalizeAddr(tt.input); got != tt.expected {
+			t.Errorf("NormalizeAddr(%q): expected %q, got %q", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestKeyNormalizes(t *testing.T) {
+	a := Key("fd00:0:0:1::/64", "2001:4860::1")
+	b := Key("FD00:0000:0000:0001::/64", "2001:4860:0:0:0:0:0:1")
+	if a != b {
+		t.Errorf("Expected equal keys, got %q and %q", a, b)
+	}
+}
+
+func TestHash(t *testing.T) {
+	a := Hash("fd00:0:0:1::/64", "2001:4860::1")
+	if len(a) != 8 {
+		t.Errorf("Expected 8 hex digits, got %q", a)
+	}
+	if b := Hash("FD00:0000:0000:0001::/64", "2001:4860:0:0:0:0:0:1"); a != b {
+		t.Errorf("Expected equal hashes, got %q and %q", a, b)
+	}
+	if c := Hash("fd00:0:0:1::/64", "2001:4860::2"); a == c {
+		t.Errorf("Expected different next hops to hash differently, both got %q", a)
+	}
+}
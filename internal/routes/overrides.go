@@ -0,0 +1,29 @@
+package routes
+
+import "github.com/rafaelgaspar/unifi-thread-route-updater/internal/config"
+
+// PinnedRouterName is the router name of routes created from ROUTE_PINS.
+const PinnedRouterName = "pinned"
+
+// ApplyOverrides merges the static overrides with discovered routes: routes
+// into ignored prefixes are dropped, and routes within a pinned network are
+// replaced by the pins. Pins into an ignored prefix are dropped too.
+func ApplyOverrides(rs []Route, o config.RouteOverrides) []Route {
+	if len(o.Pins) == 0 && len(o.Ignore) == 0 {
+		return rs
+	}
+	out := make([]Route, 0, len(rs)+len(o.Pins))
+	for _, r := range rs {
+		if o.Ignored(r.CIDR.String()) || o.PinnedNetwork(r.CIDR) {
+			continue
+		}
+		out = append(out, r)
+	}
+	for _, p := range o.Pins {
+		if o.Ignored(p.Network.String()) {
+			continue
+		}
+		out = append(out, Route{CIDR: p.Network, ThreadRouterIPv6: p.Nexthop, RouterName: PinnedRouterName})
+	}
+	return out
+}
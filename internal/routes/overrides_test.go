@@ -0,0 +1,43 @@
+package routes
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/config"
+)
+
+// TestApplyOverrides verifies pins replace the discovered routes to their
+// network and ignored prefixes get no routes.
+func TestApplyOverrides(t *testing.T) {
+	discovered := []Route{
+		{CIDR: netip.MustParsePrefix("fd00:1::/64"), ThreadRouterIPv6: netip.MustParseAddr("2001:db8::a"), RouterName: "Kitchen"},
+		{CIDR: netip.MustParsePrefix("fd00:2::/64"), ThreadRouterIPv6: netip.MustParseAddr("2001:db8::a"), RouterName: "Kitchen"},
+		{CIDR: netip.MustParsePrefix("fd00:9:0:1::/64"), ThreadRouterIPv6: netip.MustParseAddr("2001:db8::b"), RouterName: "Office"},
+	}
+	o := config.RouteOverrides{
+		Pins: []config.RoutePin{
+			{Network: netip.MustParsePrefix("fd00:1::/64"), Nexthop: netip.MustParseAddr("2001:db8::1")},
+			{Network: netip.MustParsePrefix("fd00:9:0:2::/64"), Nexthop: netip.MustParseAddr("2001:db8::1")},
+		},
+		Ignore: []netip.Prefix{netip.MustParsePrefix("fd00:9::/48")},
+	}
+
+	got := ApplyOverrides(discovered, o)
+	want := []Route{
+		discovered[1],
+		{CIDR: netip.MustParsePrefix("fd00:1::/64"), ThreadRouterIPv6: netip.MustParseAddr("2001:db8::1"), RouterName: PinnedRouterName},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("Expected %v, got %v", want, got)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("Expected route %d to be %v, got %v", i, want[i], got[i])
+		}
+	}
+
+	if got := ApplyOverrides(discovered, config.RouteOverrides{}); len(got) != len(discovered) {
+		t.Errorf("Expected no overrides to keep every route, got %v", got)
+	}
+}
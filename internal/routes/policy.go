@@ -0,0 +1,78 @@
+package routes
+
+import (
+	"net/netip"
+	"slices"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/config"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/discovery"
+)
+
+// Policy decides which routes the discovered network gets. Routes applies its
+// steps in order, each a pure function of the policy and its input, so a
+// change of behaviour is a change to one step and its tests:
+//
+//  1. prefixes: mesh prefixes overlapping a NAT64 prefix are left out
+//  2. Generate: a route per mesh prefix and routable border router address
+//  3. withdrawn: routes through a border router that withdrew the prefix,
+//     or let its announced route lifetime run out, are dropped
+//  4. ResolveConflicts: a prefix several Thread networks announce is routed
+//     through the network its conflict policy picks
+//  5. SelectNexthops: the next hop policy drops or ranks border routers
+//  6. HostRoutes: in host route mode, each prefix route becomes a route per
+//     device address
+//  7. ApplyOverrides: ignored prefixes are dropped and pins merged in
+//
+// Prefixes are not aggregated: each mesh prefix keeps a route of its own. Caps
+// on the number of routes depend on what the controller already holds, so they
+// are applied when syncing rather than here.
+type Policy struct {
+	NAT64     []netip.Prefix
+	Conflicts []Conflict
+	NextHops  config.NextHopPolicy
+	Mode      config.RouteMode
+	HostAddrs []netip.Addr // device addresses, routed in host route mode only
+	Overrides config.RouteOverrides
+	Withdrawn map[netip.Prefix][]netip.Addr // next hops no longer routing a prefix, by prefix
+}
+
+// Routes returns the routes to meshPrefixes through routers, sorted as by Sort.
+func (p Policy) Routes(meshPrefixes map[netip.Prefix]time.Time, routers []discovery.BorderRouter) []Route {
+	rs := Generate(p.prefixes(meshPrefixes), routers)
+	rs = p.withdrawn(rs)
+	rs = ResolveConflicts(rs, routers, p.Conflicts)
+	rs = SelectNexthops(rs, routers, p.NextHops)
+	rs = p.hosts(rs)
+	rs = ApplyOverrides(rs, p.Overrides)
+	Sort(rs)
+	return rs
+}
+
+// prefixes returns the mesh prefixes that may be routed.
+func (p Policy) prefixes(meshPrefixes map[netip.Prefix]time.Time) map[netip.Prefix]time.Time {
+	return ExcludeNAT64(meshPrefixes, p.NAT64)
+}
+
+// withdrawn drops the routes through next hops whose border router withdrew
+// the route's prefix.
+func (p Policy) withdrawn(rs []Route) []Route {
+	if len(p.Withdrawn) == 0 {
+		return rs
+	}
+	out := rs[:0]
+	for _, r := range rs {
+		if !slices.Contains(p.Withdrawn[r.CIDR], r.ThreadRouterIPv6) {
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+// hosts turns prefix routes into host routes in host route mode.
+func (p Policy) hosts(rs []Route) []Route {
+	if p.Mode != config.RouteModeHost {
+		return rs
+	}
+	return HostRoutes(rs, p.HostAddrs)
+}
@@ -0,0 +1,68 @@
+package routes
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/config"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/discovery"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/pkg/threaddiscovery"
+)
+
+// TestPolicyRoutes verifies each step of the route policy on the same network:
+// two Thread networks announcing fd00:1::/64, another mesh prefix and one
+// overlapping a NAT64 prefix.
+func TestPolicyRoutes(t *testing.T) {
+	mesh := netip.MustParsePrefix("fd00:1::/64")
+	other := netip.MustParsePrefix("fd00:2::/64")
+	nat64 := netip.MustParsePrefix("fd00:64::/96")
+	now := time.Now()
+	prefixes := map[netip.Prefix]time.Time{mesh: now, other: now, netip.MustParsePrefix("fd00:64::/64"): now}
+	routers := []discovery.BorderRouter{
+		{Name: "Apple TV", ExtPANID: "aaaa", OMRPrefix: mesh, Type: threaddiscovery.RouterTypeAppleTV,
+			IPv6Addrs: []netip.Addr{netip.MustParseAddr("2a02:8109::1"), netip.MustParseAddr("fe80::1")}},
+		{Name: "Nest Hub", ExtPANID: "bbbb", OMRPrefix: mesh,
+			IPv6Addrs: []netip.Addr{netip.MustParseAddr("2a02:8109::2")}},
+	}
+
+	tests := []struct {
+		name   string
+		policy Policy
+		want   []string
+	}{
+		{"Every prefix through every routable address", Policy{},
+			[]string{"fd00:1::/64->2a02:8109::1", "fd00:1::/64->2a02:8109::2", "fd00:2::/64->2a02:8109::1", "fd00:2::/64->2a02:8109::2", "fd00:64::/64->2a02:8109::1", "fd00:64::/64->2a02:8109::2"}},
+		{"NAT64 prefixes left out", Policy{NAT64: []netip.Prefix{nat64}},
+			[]string{"fd00:1::/64->2a02:8109::1", "fd00:1::/64->2a02:8109::2", "fd00:2::/64->2a02:8109::1", "fd00:2::/64->2a02:8109::2"}},
+		{"Withdrawn next hop dropped", Policy{NAT64: []netip.Prefix{nat64}, Withdrawn: map[netip.Prefix][]netip.Addr{other: {netip.MustParseAddr("2a02:8109::2")}}},
+			[]string{"fd00:1::/64->2a02:8109::1", "fd00:1::/64->2a02:8109::2", "fd00:2::/64->2a02:8109::1"}},
+		{"Conflict routed through the winner", Policy{NAT64: []netip.Prefix{nat64}, Conflicts: DetectConflicts(routers, config.ConflictLowestExtPANID)},
+			[]string{"fd00:1::/64->2a02:8109::1", "fd00:2::/64->2a02:8109::1", "fd00:2::/64->2a02:8109::2"}},
+		{"Single next hop", Policy{NAT64: []netip.Prefix{nat64}, NextHops: config.NextHopPolicy{Single: true, Preference: []string{threaddiscovery.RouterTypeAppleTV}}},
+			[]string{"fd00:1::/64->2a02:8109::1", "fd00:2::/64->2a02:8109::1"}},
+		{"Host routes", Policy{NAT64: []netip.Prefix{nat64}, NextHops: config.NextHopPolicy{Single: true}, Mode: config.RouteModeHost,
+			HostAddrs: []netip.Addr{netip.MustParseAddr("fd00:2::10")}},
+			[]string{"fd00:2::10/128->2a02:8109::1"}},
+		{"Pinned and ignored", Policy{NAT64: []netip.Prefix{nat64}, Overrides: config.RouteOverrides{
+			Pins:   []config.RoutePin{{Network: mesh, Nexthop: netip.MustParseAddr("2a02:8109::9")}},
+			Ignore: []netip.Prefix{other},
+		}}, []string{"fd00:1::/64->2a02:8109::9"}},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, r := range tt.policy.Routes(prefixes, routers) {
+			got = append(got, r.Key())
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s: expected %v, got %v", tt.name, tt.want, got)
+				break
+			}
+		}
+	}
+}
@@ -0,0 +1,118 @@
+package routes
+
+import (
+	"net/netip"
+	"sort"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/discovery"
+)
+
+// Renumbering describes an ISP prefix change: next-hop /64s that vanished from the
+// detected routes in the same cycle that new ones appeared.
+type Renumbering struct {
+	Old []string
+	New []string
+}
+
+// Contains reports whether nexthop lies in one of the vanished /64s.
+func (r Renumbering) Contains(nexthop string) bool {
+	prefix := NexthopPrefix(nexthop)
+	for _, old := range r.Old {
+		if old == prefix {
+			return true
+		}
+	}
+	return false
+}
+
+// NexthopPrefix returns the /64 containing a next-hop address, or "" if it does
+// not parse.
+func NexthopPrefix(nexthop string) string {
+	ip, err := netip.ParseAddr(nexthop)
+	if err != nil {
+		return ""
+	}
+	prefix := discovery.CIDR64(ip)
+	if !prefix.IsValid() {
+		return ""
+	}
+	return prefix.String()
+}
+
+// DetectRenumbering compares the next hops of the routes currently programmed with
+// those of the detected routes, each mapped to the identity of the border router
+// behind it. When a /64 disappears entirely while the same router announces a
+// previously unknown /64, the delegated prefix has been renumbered and routes
+// through the old /64 will never come back, so they need not wait out the grace
+// period. A /64 that vanishes while an unrelated router appears elsewhere is not
+// a renumbering.
+func DetectRenumbering(currentNexthops, desiredNexthops map[string]string) (Renumbering, bool) {
+	current := nexthopPrefixes(currentNexthops)
+	desired := nexthopPrefixes(desiredNexthops)
+
+	oldSet := make(map[string]bool)
+	newSet := make(map[string]bool)
+	for router, prefixes := range current {
+		var gone, appeared []string
+		for p := range prefixes {
+			if !anyHas(desired, p) {
+				gone = append(gone, p)
+			}
+		}
+		for p := range desired[router] {
+			if !anyHas(current, p) {
+				appeared = append(appeared, p)
+			}
+		}
+		if len(gone) == 0 || len(appeared) == 0 {
+			continue
+		}
+		for _, p := range gone {
+			oldSet[p] = true
+		}
+		for _, p := range appeared {
+			newSet[p] = true
+		}
+	}
+	if len(oldSet) == 0 {
+		return Renumbering{}, false
+	}
+
+	var r Renumbering
+	for p := range oldSet {
+		r.Old = append(r.Old, p)
+	}
+	for p := range newSet {
+		r.New = append(r.New, p)
+	}
+	sort.Strings(r.Old)
+	sort.Strings(r.New)
+	return r, true
+}
+
+// nexthopPrefixes returns the routable /64s used by the given next hops,
+// grouped by the router identity each next hop maps to.
+func nexthopPrefixes(nexthops map[string]string) map[string]map[string]bool {
+	out := make(map[string]map[string]bool)
+	for nh, router := range nexthops {
+		ip, err := netip.ParseAddr(nh)
+		if err != nil || !IsRoutableRouterAddress(ip) {
+			continue
+		}
+		if out[router] == nil {
+			out[router] = make(map[string]bool)
+		}
+		out[router][discovery.CIDR64(ip).String()] = true
+	}
+	return out
+}
+
+// anyHas reports whether any router in byRouter uses prefix.
+func anyHas(byRouter map[string]map[string]bool, prefix string) bool {
+	for _, prefixes := range byRouter {
+		if prefixes[prefix] {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,78 @@
+package routes
+
+import (
+	"testing"
+)
+
+func TestDetectRenumbering(t *testing.T) {
+	tests := []struct {
+		name    string
+		current map[string]string
+		desired map[string]string
+		want    bool
+		old     []string
+	}{
+		{
+			name:    "No change",
+			current: map[string]string{"2001:4860:4860:1234::ff": "r1", "2001:4860:4860:1234::fe": "r2"},
+			desired: map[string]string{"2001:4860:4860:1234::ff": "r1", "2001:4860:4860:1234::fe": "r2"},
+			want:    false,
+		},
+		{
+			name:    "Router gone without new prefix",
+			current: map[string]string{"2001:4860:4860:1234::ff": "r1", "2001:4860:4860:1234::fe": "r2"},
+			desired: map[string]string{"2001:4860:4860:1234::ff": "r1"},
+			want:    false,
+		},
+		{
+			name:    "New router in existing prefix",
+			current: map[string]string{"2001:4860:4860:1234::ff": "r1"},
+			desired: map[string]string{"2001:4860:4860:1234::ff": "r1", "2001:4860:4860:1234::fe": "r2"},
+			want:    false,
+		},
+		{
+			name:    "Whole prefix replaced",
+			current: map[string]string{"2001:4860:4860:1234::ff": "r1", "2001:4860:4860:1234::fe": "r2"},
+			desired: map[string]string{"2001:4860:4860:5678::ff": "r1", "2001:4860:4860:5678::fe": "r2"},
+			want:    true,
+			old:     []string{"2001:4860:4860:1234::/64"},
+		},
+		{
+			name:    "Unrelated router in a new prefix",
+			current: map[string]string{"2001:4860:4860:1234::ff": "r1"},
+			desired: map[string]string{"2001:4860:4860:5678::ff": "r2"},
+			want:    false,
+		},
+		{
+			name:    "Non-routable next hops ignored",
+			current: map[string]string{"fe80::1": "r1"},
+			desired: map[string]string{"2001:4860:4860:5678::ff": "r1"},
+			want:    false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, ok := DetectRenumbering(tt.current, tt.desired)
+			if ok != tt.want {
+				t.Fatalf("DetectRenumbering() = %v, want %v", ok, tt.want)
+			}
+			if !ok {
+				return
+			}
+			if len(r.Old) != len(tt.old) || r.Old[0] != tt.old[0] {
+				t.Errorf("Old = %v, want %v", r.Old, tt.old)
+			}
+			for nh := range tt.current {
+				if !r.Contains(nh) {
+					t.Errorf("Expected %s to be in a renumbered prefix", nh)
+				}
+			}
+			for nh := range tt.desired {
+				if r.Contains(nh) {
+					t.Errorf("Expected %s not to be in a renumbered prefix", nh)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,107 @@
+// Package routes derives the static routes needed to reach Thread mesh prefixes
+// through the discovered border routers.
+package routes
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/netip"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/discovery"
+)
+
+// Route represents a routing entry
+type Route struct {
+	CIDR             netip.Prefix
+	ThreadRouterIPv6 netip.Addr
+	RouterName       string
+}
+
+// Key returns the identity of the route, as built by Key.
+func (r Route) Key() string {
+	return Key(r.CIDR.String(), r.ThreadRouterIPv6.String())
+}
+
+// Key returns the identity of a route as "<network>-><nexthop>", with both
+// parts normalized so equivalent spellings of the same route share a key.
+func Key(network, nexthop string) string {
+	return fmt.Sprintf("%s->%s", NormalizePrefix(network), NormalizeAddr(nexthop))
+}
+
+// Hash returns a short deterministic identifier of a route: the first 8 hex
+// digits of the SHA-256 of its Key, so equivalent spellings share a hash.
+func Hash(network, nexthop string) string {
+	sum := sha256.Sum256([]byte(Key(network, nexthop)))
+	return hex.EncodeToString(sum[:4])
+}
+
+// Generate generates routing entries from discovered Thread mesh prefixes and
+// border routers. For each Thread mesh prefix × each routable border router IP,
+// one route is created, deduplicated by Key. Border router IPs are stable
+// (MAC-based EUI-64); prefixes are dynamic and sourced from mDNS and Home
+// Assistant. The routes are sorted as by Sort.
+func Generate(meshPrefixes map[netip.Prefix]time.Time, routers []discovery.BorderRouter) []Route {
+	routeMap := make(map[string]Route)
+
+	for raw := range meshPrefixes {
+		prefix := raw.Masked()
+		for _, router := range routers {
+			for _, ip := range router.IPv6Addrs {
+				if !IsRoutableRouterAddress(ip) {
+					continue
+				}
+				route := Route{
+					CIDR:             prefix,
+					ThreadRouterIPv6: ip.WithZone(""),
+					RouterName:       router.Name,
+				}
+				// Two router records can share an address (a rename, or the
+				// same router seen under two instance names); keep one route
+				// per network and next hop, named after the first name in
+				// order so the result is deterministic.
+				if existing, ok := routeMap[route.Key()]; ok && existing.RouterName <= route.RouterName {
+					continue
+				}
+				routeMap[route.Key()] = route
+			}
+		}
+	}
+
+	routes := make([]Route, 0, len(routeMap))
+	for _, route := range routeMap {
+		routes = append(routes, route)
+	}
+	Sort(routes)
+	return routes
+}
+
+var (
+	documentationRange = netip.MustParsePrefix("2001:db8::/32")
+	teredoRange        = netip.MustParsePrefix("2001::/32")
+	sixToFourRange     = netip.MustParsePrefix("2002::/16")
+)
+
+// isReserved reports whether ip is unusable as a route destination or next hop:
+// unspecified, loopback, link-local, multicast, documentation, Teredo or 6to4.
+func isReserved(ip netip.Addr) bool {
+	return ip.IsUnspecified() || ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsMulticast() ||
+		documentationRange.Contains(ip) || teredoRange.Contains(ip) || sixToFourRange.Contains(ip)
+}
+
+// IsRoutableCIDR checks if a CIDR block is routable (not link-local, loopback, etc.)
+func IsRoutableCIDR(prefix netip.Prefix) bool {
+	if !prefix.IsValid() {
+		return false
+	}
+	return !isReserved(prefix.Masked().Addr())
+}
+
+// IsRoutableRouterAddress checks if a Thread Border Router IPv6 address is routable
+func IsRoutableRouterAddress(ip netip.Addr) bool {
+	if !ip.Is6() || ip.Is4In6() {
+		return false
+	}
+	return !ip.IsPrivate() && !isReserved(ip)
+}
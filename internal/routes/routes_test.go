@@ -0,0 +1,188 @@
+package routes
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/discovery"
+)
+
+func prefixMap(prefixes ...string) map[netip.Prefix]time.Time {
+	m := make(map[netip.Prefix]time.Time)
+	for _, p := range prefixes {
+		m[netip.MustParsePrefix(p)] = time.Now()
+	}
+	return m
+}
+
+func TestGenerate(t *testing.T) {
+	prefixes := prefixMap("fd00:1111:2222:3333::/64")
+
+	routers := []discovery.BorderRouter{
+		{Name: "ThreadRouter1", IPv6Addrs: []netip.Addr{netip.MustParseAddr("2001:4860:4860:1234::ff")}},
+		{Name: "ThreadRouter2", IPv6Addrs: []netip.Addr{netip.MustParseAddr("2001:4860:4860:1234::fe")}},
+	}
+
+	routes := Generate(prefixes, routers)
+
+	if len(routes) != 2 {
+		t.Errorf("Expected 2 routes, got %d", len(routes))
+	}
+	for _, route := range routes {
+		if route.CIDR.String() != "fd00:1111:2222:3333::/64" {
+			t.Errorf("Expected CIDR fd00:1111:2222:3333::/64, got %s", route.CIDR)
+		}
+	}
+}
+
+func TestGenerateEdgeCases(t *testing.T) {
+	t.Run("No prefixes", func(t *testing.T) {
+		routes := Generate(nil, []discovery.BorderRouter{
+			{Name: "Router1", IPv6Addrs: []netip.Addr{netip.MustParseAddr("2001:4860:4860:1234::ff")}},
+		})
+		if len(routes) != 0 {
+			t.Errorf("Expected 0 routes with no prefixes, got %d", len(routes))
+		}
+	})
+
+	t.Run("No routers", func(t *testing.T) {
+		routes := Generate(prefixMap("fd00:1234:5678:9abc::/64"), nil)
+		if len(routes) != 0 {
+			t.Errorf("Expected 0 routes with no routers, got %d", len(routes))
+		}
+	})
+
+	t.Run("Multiple prefixes with multiple routers", func(t *testing.T) {
+		prefixes := prefixMap("fd00:1111:2222:3333::/64", "fd00:4444:5555:6666::/64")
+		routers := []discovery.BorderRouter{
+			{Name: "Router1", IPv6Addrs: []netip.Addr{netip.MustParseAddr("2001:4860:4860:1234::ff")}},
+			{Name: "Router2", IPv6Addrs: []netip.Addr{netip.MustParseAddr("2001:4860:4860:1234::fe")}},
+		}
+
+		routes := Generate(prefixes, routers)
+		expected := len(prefixes) * len(routers) // 4 routes
+		if len(routes) != expected {
+			t.Errorf("Expected %d routes, got %d", expected, len(routes))
+		}
+	})
+
+	t.Run("Router with non-routable IP generates no routes", func(t *testing.T) {
+		routes := Generate(
+			prefixMap("fd00:1111:2222:3333::/64"),
+			[]discovery.BorderRouter{
+				{Name: "Router1", IPv6Addrs: []netip.Addr{netip.MustParseAddr("fe80::1")}},
+			},
+		)
+		if len(routes) != 0 {
+			t.Errorf("Expected 0 routes with non-routable router IP, got %d", len(routes))
+		}
+	})
+
+	t.Run("Router with mixed IPs uses only routable ones", func(t *testing.T) {
+		routes := Generate(
+			prefixMap("fd00:1111:2222:3333::/64"),
+			[]discovery.BorderRouter{
+				{
+					Name: "Router1",
+					IPv6Addrs: []netip.Addr{
+						netip.MustParseAddr("fe80::1"),                 // link-local, skipped
+						netip.MustParseAddr("2001:4860:4860:1234::ff"), // routable
+					},
+				},
+			},
+		)
+		if len(routes) != 1 {
+			t.Errorf("Expected 1 route (only routable IP used), got %d", len(routes))
+		}
+	})
+
+	t.Run("Deduplication: same prefix produces one route per router", func(t *testing.T) {
+		// Maps deduplicate keys automatically
+		prefixes := prefixMap("fd00:1111:2222:3333::/64")
+		routes := Generate(
+			prefixes,
+			[]discovery.BorderRouter{
+				{Name: "Router1", IPv6Addrs: []netip.Addr{netip.MustParseAddr("2001:4860:4860:1234::ff")}},
+			},
+		)
+		if len(routes) != 1 {
+			t.Errorf("Expected 1 route, got %d", len(routes))
+		}
+	})
+
+	t.Run("Deduplication: routers sharing a next hop produce one route", func(t *testing.T) {
+		ip := netip.MustParseAddr("2001:4860:4860:1234::ff")
+		routes := Generate(
+			prefixMap("fd00:1111:2222:3333::/64"),
+			[]discovery.BorderRouter{
+				{Name: "Router2", IPv6Addrs: []netip.Addr{ip}},
+				{Name: "Router1", IPv6Addrs: []netip.Addr{ip}},
+			},
+		)
+		if len(routes) != 1 {
+			t.Fatalf("Expected 1 route, got %d", len(routes))
+		}
+		if routes[0].RouterName != "Router1" {
+			t.Errorf("Expected router name Router1, got %s", routes[0].RouterName)
+		}
+	})
+}
+
+func TestIsRoutableCIDR(t *testing.T) {
+	tests := []struct {
+		name     string
+		cidr     string
+		expected bool
+	}{
+		{"ULA CIDR", "fd00:1234:5678:9abc::/64", true},
+		{"Global unicast CIDR", "2001:4860:4860::/64", true},
+		{"Link-local CIDR", "fe80::/64", false},
+		{"Loopback CIDR", "::1/128", false},
+		{"Multicast CIDR", "ff00::/8", false},
+		{"Documentation CIDR", "2001:db8::/32", false},
+		{"Invalid CIDR", "invalid", false},
+		{"Empty CIDR", "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			prefix, _ := netip.ParsePrefix(tt.cidr)
+			result := IsRoutableCIDR(prefix)
+			if result != tt.expected {
+				t.Errorf("IsRoutableCIDR(%s) = %v, want %v", tt.cidr, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestIsRoutableRouterAddress(t *testing.T) {
+	tests := []struct {
+		name     string
+		ip       string
+		expected bool
+	}{
+		{"Public IPv6", "2001:4860:4860::1", true},
+		{"ULA address", "fd00:1234:5678:9abc::1", false},
+		{"Link-local", "fe80::1", false},
+		{"Loopback", "::1", false},
+		{"Unspecified", "::", false},
+		{"Multicast", "ff02::1", false},
+		{"Documentation", "2001:db8::1", false},
+		{"Teredo", "2001::1", false},
+		{"6to4", "2002::1", false},
+		{"IPv4", "192.168.1.1", false},
+		{"Nil IP", "", false},
+		{"Invalid IP", "invalid", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ip, _ := netip.ParseAddr(tt.ip)
+			result := IsRoutableRouterAddress(ip)
+			if result != tt.expected {
+				t.Errorf("IsRoutableRouterAddress(%s) = %v, want %v", tt.ip, result, tt.expected)
+			}
+		})
+	}
+}
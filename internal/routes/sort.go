@@ -0,0 +1,51 @@
+package routes
+
+import (
+	"cmp"
+	"net/netip"
+	"slices"
+	"strings"
+)
+
+// Sort orders rs by destination prefix, then next hop, then router name, so
+// logs, plans and controller calls come out the same on every run.
+func Sort(rs []Route) {
+	slices.SortFunc(rs, func(a, b Route) int {
+		if c := ComparePrefixes(a.CIDR, b.CIDR); c != 0 {
+			return c
+		}
+		if c := a.ThreadRouterIPv6.Compare(b.ThreadRouterIPv6); c != 0 {
+			return c
+		}
+		return strings.Compare(a.RouterName, b.RouterName)
+	})
+}
+
+// Compare orders two routes given as network and next hop strings the way
+// Sort does: by prefix, then next hop, comparing addresses numerically when
+// they parse and as text otherwise.
+func Compare(network1, nexthop1, network2, nexthop2 string) int {
+	p1, err1 := netip.ParsePrefix(network1)
+	p2, err2 := netip.ParsePrefix(network2)
+	if err1 == nil && err2 == nil {
+		if c := ComparePrefixes(p1, p2); c != 0 {
+			return c
+		}
+	} else if c := strings.Compare(network1, network2); c != 0 {
+		return c
+	}
+	a1, err1 := netip.ParseAddr(nexthop1)
+	a2, err2 := netip.ParseAddr(nexthop2)
+	if err1 == nil && err2 == nil {
+		return a1.Compare(a2)
+	}
+	return strings.Compare(nexthop1, nexthop2)
+}
+
+// ComparePrefixes orders prefixes by address, then length.
+func ComparePrefixes(a, b netip.Prefix) int {
+	if c := a.Addr().Compare(b.Addr()); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.Bits(), b.Bits())
+}
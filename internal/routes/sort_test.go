@@ -0,0 +1,79 @@
+package routes
+
+import (
+	"net/netip"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/discovery"
+)
+
+func TestSort(t *testing.T) {
+	route := func(cidr, nexthop string) Route {
+		return Route{CIDR: netip.MustParsePrefix(cidr), ThreadRouterIPv6: netip.MustParseAddr(nexthop)}
+	}
+	rs := []Route{
+		route("fd00:10::/64", "2001:db9::1"),
+		route("fd00:9::/64", "2001:db9::10"),
+		route("fd00:9::/64", "2001:db9::9"),
+		route("fd00::/48", "2001:db9::1"),
+		route("fd00::/64", "2001:db9::1"),
+	}
+	Sort(rs)
+
+	var got []string
+	for _, r := range rs {
+		got = append(got, r.Key())
+	}
+	want := []string{
+		"fd00::/48->2001:db9::1",
+		"fd00::/64->2001:db9::1",
+		"fd00:9::/64->2001:db9::9",
+		"fd00:9::/64->2001:db9::10",
+		"fd00:10::/64->2001:db9::1",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		net1, hop1, net2, hop2 string
+		expected               int
+	}{
+		{"fd00:9::/64", "fe80::1", "fd00:10::/64", "fe80::1", -1},
+		{"fd00:9::/64", "2001:db9::10", "fd00:9::/64", "2001:db9::9", 1},
+		{"fd00:9::/64", "2001:db9::9", "fd00:9::/64", "2001:db9::9", 0},
+		{"bogus", "x", "fd00::/64", "fe80::1", -1}, // compared as text
+	}
+	for _, tt := range tests {
+		if got := Compare(tt.net1, tt.hop1, tt.net2, tt.hop2); got != tt.expected {
+			t.Errorf("Compare(%s %s, %s %s): expected %d, got %d", tt.net1, tt.hop1, tt.net2, tt.hop2, tt.expected, got)
+		}
+	}
+}
+
+// TestGenerateOrder verifies Generate returns the same order on every call,
+// whatever the map iteration order.
+func TestGenerateOrder(t *testing.T) {
+	prefixes := make(map[netip.Prefix]time.Time)
+	for _, p := range []string{"fd00:3::/64", "fd00:1::/64", "fd00:2::/64", "fd00:10::/64"} {
+		prefixes[netip.MustParsePrefix(p)] = time.Now()
+	}
+	routers := []discovery.BorderRouter{
+		{Name: "B", IPv6Addrs: []netip.Addr{netip.MustParseAddr("2001:4860::2")}},
+		{Name: "A", IPv6Addrs: []netip.Addr{netip.MustParseAddr("2001:4860::1")}},
+	}
+
+	first := Generate(prefixes, routers)
+	if first[0].Key() != "fd00:1::/64->2001:4860::1" || first[len(first)-1].Key() != "fd00:10::/64->2001:4860::2" {
+		t.Errorf("Expected routes sorted by prefix then next hop, got %v", first)
+	}
+	for i := 0; i < 20; i++ {
+		if got := Generate(prefixes, routers); !slices.Equal(got, first) {
+			t.Fatalf("Expected a stable order, got %v then %v", first, got)
+		}
+	}
+}
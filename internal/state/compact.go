@@ -0,0 +1,150 @@
+package state
+
+import (
+	"net/netip"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/config"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/logger"
+)
+
+// ConfigureLimits bounds the route tracking maps and the addresses kept per device.
+func (s *State) ConfigureLimits(limits config.TrackingLimits) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.limits = limits
+}
+
+// touchAddrs merges announced into addrs, ordered from least to most recently
+// announced, and keeps at most limit addresses (all when limit is 0), so the
+// addresses a device rotated away from are evicted first. It reports whether
+// announced held an address not in addrs.
+func touchAddrs(addrs, announced []netip.Addr, limit int) ([]netip.Addr, bool) {
+	known := make(map[netip.Addr]bool, len(addrs))
+	for _, ip := range addrs {
+		known[ip] = true
+	}
+	fresh := make(map[netip.Addr]bool, len(announced))
+	added := false
+	for _, ip := range announced {
+		fresh[ip] = true
+		added = added || !known[ip]
+	}
+	out := make([]netip.Addr, 0, len(addrs)+len(announced))
+	for _, ip := range addrs {
+		if !fresh[ip] {
+			out = append(out, ip)
+		}
+	}
+	for _, ip := range announced {
+		if fresh[ip] {
+			out = append(out, ip)
+			delete(fresh, ip)
+		}
+	}
+	if limit > 0 && len(out) > limit {
+		out = out[len(out)-limit:]
+	}
+	return out, added
+}
+
+// CompactTracking drops route tracking that no longer matters and enforces the
+// configured limits, returning the number of entries removed. Last-seen times of
+// routes the daemon has not programmed are dropped once older than their grace
+// period: a route without one starts a fresh grace period, so nothing is removed
+// early. Ended route lifetimes are dropped once their prefix's grace period has
+// passed too, and the others once their prefix expired. Address history older
+// than its age limit is dropped. Beyond the limits, the least recently seen
+// routes the daemon has not programmed and the devices' oldest addresses are
+// evicted.
+func (s *State) CompactTracking(grace config.GracePolicy) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	removed := 0
+	for key, lastSeen := range s.routeLastSeen {
+		if !s.addedRoutes[key] && now.Sub(lastSeen) > grace.For(routeNetwork(key)) {
+			delete(s.routeLastSeen, key)
+			removed++
+		}
+	}
+	for prefix, byRouter := range s.lifetimes {
+		_, known := s.meshPrefixes[prefix]
+		for router, l := range byRouter {
+			if !known && !l.endedBy(now) || l.endedBy(now) && now.Sub(l.deadline) > grace.For(prefix.String()) {
+				delete(byRouter, router)
+				removed++
+			}
+		}
+		if len(byRouter) == 0 {
+			delete(s.lifetimes, prefix)
+		}
+	}
+	removed += s.expireHistory(now)
+
+	if limit := s.limits.Routes; limit > 0 && len(s.routeLastSeen) > limit {
+		// Programmed routes are never evicted: forgetting one would leave it
+		// on the controller without the daemon ever removing it.
+		lru := make([]string, 0, len(s.routeLastSeen))
+		for key := range s.routeLastSeen {
+			if !s.addedRoutes[key] {
+				lru = append(lru, key)
+			}
+		}
+		sort.Slice(lru, func(i, j int) bool { return s.routeLastSeen[lru[i]].Before(s.routeLastSeen[lru[j]]) })
+		excess := min(len(s.routeLastSeen)-limit, len(lru))
+		for _, key := range lru[:excess] {
+			logger.Debug("Evicting route tracking for %s", key)
+			delete(s.routeLastSeen, key)
+			removed++
+		}
+	}
+
+	if limit := s.limits.DeviceAddrs; limit > 0 {
+		for name, d := range s.devices {
+			if len(d.IPv6Addrs) > limit {
+				removed += len(d.IPv6Addrs) - limit
+				d.IPv6Addrs = append([]netip.Addr(nil), d.IPv6Addrs[len(d.IPv6Addrs)-limit:]...)
+				s.devices[name] = d
+			}
+		}
+	}
+	return removed
+}
+
+// routeNetwork returns the network part of a route key ("<network>-><nexthop>").
+func routeNetwork(key string) string {
+	network, _, _ := strings.Cut(key, "->")
+	return network
+}
+
+// Sizes returns the number of entries in each tracking map, for metrics.
+func (s *State) Sizes() map[string]float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	addrs, history, lifetimes := 0, 0, 0
+	for _, d := range s.devices {
+		addrs += len(d.IPv6Addrs)
+	}
+	for _, h := range s.history {
+		history += len(h)
+	}
+	for _, byRouter := range s.lifetimes {
+		lifetimes += len(byRouter)
+	}
+	return map[string]float64{
+		"devices":          float64(len(s.devices)),
+		"device_addresses": float64(addrs),
+		"border_routers":   float64(len(s.borderRouters)),
+		"mesh_prefixes":    float64(len(s.meshPrefixes)),
+		"added_routes":     float64(len(s.addedRoutes)),
+		"route_last_seen":  float64(len(s.routeLastSeen)),
+		"expired_nexthops": float64(len(s.expiredNexthops)),
+		"trel_peers":       float64(len(s.trelPeers)),
+		"prefix_stability": float64(len(s.stability)),
+		"address_history":  float64(history),
+		"prefix_lifetimes": float64(lifetimes),
+	}
+}
@@ -0,0 +1,79 @@
+package state
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/config"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/discovery"
+)
+
+func TestTouchAddrs(t *testing.T) {
+	a := netip.MustParseAddr("fd00::a")
+	b := netip.MustParseAddr("fd00::b")
+	c := netip.MustParseAddr("fd00::c")
+	tests := []struct {
+		addrs, announced []netip.Addr
+		limit            int
+		expected         []netip.Addr
+		added            bool
+	}{
+		{nil, []netip.Addr{a, a, b}, 0, []netip.Addr{a, b}, true},
+		{[]netip.Addr{a, b}, []netip.Addr{a}, 0, []netip.Addr{b, a}, false},
+		{[]netip.Addr{a, b}, []netip.Addr{c}, 2, []netip.Addr{b, c}, true},
+		{[]netip.Addr{a, b}, []netip.Addr{a, c}, 2, []netip.Addr{a, c}, true},
+	}
+	for _, tt := range tests {
+		got, added := touchAddrs(tt.addrs, tt.announced, tt.limit)
+		if len(got) != len(tt.expected) || added != tt.added {
+			t.Errorf("touchAddrs(%v, %v, %d): expected %v added=%v, got %v added=%v",
+				tt.addrs, tt.announced, tt.limit, tt.expected, tt.added, got, added)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.expected[i] {
+				t.Errorf("touchAddrs(%v, %v, %d): expected %v, got %v", tt.addrs, tt.announced, tt.limit, tt.expected, got)
+			}
+		}
+	}
+}
+
+func TestCompactTracking(t *testing.T) {
+	s := New(nil)
+	s.ConfigureLimits(config.TrackingLimits{Routes: 2, DeviceAddrs: 3})
+	grace := config.GracePolicy{Default: 10 * time.Minute}
+	now := time.Now()
+
+	s.routeLastSeen["fd00:1::/64->2001:db8::1"] = now.Add(-time.Hour) // stale, not programmed
+	s.routeLastSeen["fd00:2::/64->2001:db8::1"] = now.Add(-time.Hour) // stale but programmed
+	s.addedRoutes["fd00:2::/64->2001:db8::1"] = true
+	s.routeLastSeen["fd00:3::/64->2001:db8::1"] = now.Add(-time.Minute)
+	s.routeLastSeen["fd00:4::/64->2001:db8::1"] = now
+	s.addedRoutes["fd00:5::/64->2001:db8::1"] = true // deleted out of band, never seen
+
+	s.MergeDevice(discovery.MatterDevice{Name: "Lamp", IPv6Addrs: []netip.Addr{
+		netip.MustParseAddr("fd00::1"), netip.MustParseAddr("fd00::2"),
+		netip.MustParseAddr("fd00::3"), netip.MustParseAddr("fd00::4")}})
+	if got := s.Devices()[0].IPv6Addrs; len(got) != 3 || got[0] != netip.MustParseAddr("fd00::2") {
+		t.Errorf("Expected the 3 most recent addresses, got %v", got)
+	}
+
+	if removed := s.CompactTracking(grace); removed != 2 {
+		t.Errorf("Expected 2 entries removed, got %d", removed)
+	}
+	for _, key := range []string{"fd00:2::/64->2001:db8::1", "fd00:4::/64->2001:db8::1"} {
+		if _, ok := s.routeLastSeen[key]; !ok {
+			t.Errorf("Expected route %s kept", key)
+		}
+	}
+	for _, key := range []string{"fd00:2::/64->2001:db8::1", "fd00:5::/64->2001:db8::1"} {
+		if !s.addedRoutes[key] {
+			t.Errorf("Expected programmed route %s never evicted", key)
+		}
+	}
+	sizes := s.Sizes()
+	if sizes["route_last_seen"] != 2 || sizes["added_routes"] != 2 || sizes["device_addresses"] != 3 {
+		t.Errorf("Unexpected sizes after compaction: %v", sizes)
+	}
+}
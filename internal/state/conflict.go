@@ -0,0 +1,52 @@
+package state
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/config"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/events"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/logger"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/routes"
+)
+
+// ConfigurePrefixConflicts sets the policy picking the network routed for a mesh
+// prefix announced by several Thread networks.
+func (s *State) ConfigurePrefixConflicts(policy config.ConflictPolicy) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.conflictPolicy = policy
+	s.checkConflicts()
+}
+
+// checkConflicts alerts when a mesh prefix conflict appears, changes or is
+// resolved, once per change rather than every sync. s.mu must be held.
+func (s *State) checkConflicts() {
+	current := make(map[netip.Prefix]string)
+	for _, c := range routes.DetectConflicts(s.borderRouters, s.conflictPolicy) {
+		current[c.Prefix] = describeConflict(c, s.conflictPolicy)
+		if s.conflicts[c.Prefix] == current[c.Prefix] {
+			continue
+		}
+		logger.Warn("Thread mesh prefix %s announced by several Thread networks: %s", c.Prefix, current[c.Prefix])
+		s.bus.Publish(events.Event{Kind: events.PrefixConflict, Prefix: c.Prefix.String(), Detail: current[c.Prefix]})
+	}
+	for prefix := range s.conflicts {
+		if _, ok := current[prefix]; !ok {
+			logger.Info("Thread mesh prefix conflict resolved: %s", prefix)
+			s.bus.Publish(events.Event{Kind: events.PrefixConflict, Prefix: prefix.String(), Detail: "resolved"})
+		}
+	}
+	s.conflicts = current
+}
+
+// describeConflict summarizes a conflict, e.g. "networks aaaa, bbbb; routing
+// aaaa (lowest-ext-panid)".
+func describeConflict(c routes.Conflict, policy config.ConflictPolicy) string {
+	action := "routing none"
+	if c.Winner != "" {
+		action = "routing " + c.Winner
+	}
+	return fmt.Sprintf("networks %s; %s (%s)", strings.Join(c.Networks, ", "), action, policy)
+}
@@ -0,0 +1,49 @@
+package state
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/config"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/discovery"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/events"
+)
+
+func TestPrefixConflicts(t *testing.T) {
+	bus := events.NewBus()
+	ch := bus.Subscribe(16)
+	s := New(bus)
+	s.ConfigurePrefixConflicts(config.ConflictHighestExtPANID)
+	shared := netip.MustParsePrefix("fd00:1111:2222:3333::/64")
+	router := func(name, xp string) discovery.BorderRouter {
+		return discovery.BorderRouter{Name: name, ExtPANID: xp, OMRPrefix: shared,
+			IPv6Addrs: []netip.Addr{netip.MustParseAddr("2001:4860:4860::1")}}
+	}
+
+	s.MergeBorderRouter(router("Kitchen", "aaaaaaaaaaaaaaaa"))
+	s.MergeBorderRouter(router("Office", "bbbbbbbbbbbbbbbb"))
+	s.MergeBorderRouter(router("Office", "bbbbbbbbbbbbbbbb")) // same conflict, no new alert
+
+	conflicts := s.Snapshot().PrefixConflicts
+	if len(conflicts) != 1 || conflicts[0].Prefix != shared || conflicts[0].Winner != "bbbbbbbbbbbbbbbb" {
+		t.Fatalf("Expected %s won by bbbbbbbbbbbbbbbb, got %+v", shared, conflicts)
+	}
+
+	s.borderRouters[1].LastSeen = time.Now().Add(-time.Hour)
+	s.RemoveExpiredRouters(10 * time.Minute)
+	if conflicts := s.Snapshot().PrefixConflicts; len(conflicts) != 0 {
+		t.Errorf("Expected conflict resolved once Office expired, got %+v", conflicts)
+	}
+
+	var details []string
+	for len(ch) > 0 {
+		if e := <-ch; e.Kind == events.PrefixConflict {
+			details = append(details, e.Detail)
+		}
+	}
+	want := []string{"networks aaaaaaaaaaaaaaaa, bbbbbbbbbbbbbbbb; routing bbbbbbbbbbbbbbbb (highest-ext-panid)", "resolved"}
+	if len(details) != len(want) || details[0] != want[0] || details[1] != want[1] {
+		t.Errorf("Expected conflict events %q, got %q", want, details)
+	}
+}
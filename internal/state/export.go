@@ -0,0 +1,140 @@
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/netip"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/discovery"
+)
+
+// exportVersion is the format version written by Export and accepted by Import.
+const exportVersion = 1
+
+// Export is the full serialisable state: discovered border routers, Matter
+// devices and mesh prefixes together with the route tracking the syncer relies on.
+type Export struct {
+	Version         int                        `json:"version"`
+	ExportedAt      time.Time                  `json:"exported_at"`
+	BorderRouters   []discovery.BorderRouter   `json:"border_routers"`
+	Devices         []discovery.MatterDevice   `json:"devices"`
+	MeshPrefixes    map[netip.Prefix]time.Time `json:"mesh_prefixes"`
+	AddedRoutes     []string                   `json:"added_routes"`
+	RouteLastSeen   map[string]time.Time       `json:"route_last_seen"`
+	ExpiredNexthops map[netip.Addr]time.Time   `json:"expired_nexthops"`
+	TRELPeers       []discovery.TRELPeer       `json:"trel_peers,omitempty"`
+	// AddressHistory is the IPv6 address history per device name, least
+	// recently announced first.
+	AddressHistory map[string][]AddressSighting `json:"address_history,omitempty"`
+}
+
+// Export returns a copy of the full state.
+func (s *State) Export() Export {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	e := Export{
+		Version:         exportVersion,
+		ExportedAt:      time.Now(),
+		BorderRouters:   make([]discovery.BorderRouter, len(s.borderRouters)),
+		Devices:         s.sortedDevices(),
+		MeshPrefixes:    make(map[netip.Prefix]time.Time, len(s.meshPrefixes)),
+		AddedRoutes:     make([]string, 0, len(s.addedRoutes)),
+		RouteLastSeen:   make(map[string]time.Time, len(s.routeLastSeen)),
+		ExpiredNexthops: make(map[netip.Addr]time.Time, len(s.expiredNexthops)),
+	}
+	for i, r := range s.borderRouters {
+		r.IPv6Addrs = append([]netip.Addr(nil), r.IPv6Addrs...)
+		e.BorderRouters[i] = r
+	}
+	for p, t := range s.meshPrefixes {
+		e.MeshPrefixes[p] = t
+	}
+	for key := range s.addedRoutes {
+		e.AddedRoutes = append(e.AddedRoutes, key)
+	}
+	sort.Strings(e.AddedRoutes)
+	for key, t := range s.routeLastSeen {
+		e.RouteLastSeen[key] = t
+	}
+	for ip, t := range s.expiredNexthops {
+		e.ExpiredNexthops[ip] = t
+	}
+	for _, peer := range s.trelPeers {
+		peer.IPv6Addrs = append([]netip.Addr(nil), peer.IPv6Addrs...)
+		e.TRELPeers = append(e.TRELPeers, peer)
+	}
+	sort.Slice(e.TRELPeers, func(i, j int) bool { return e.TRELPeers[i].Name < e.TRELPeers[j].Name })
+	if len(s.history) > 0 {
+		e.AddressHistory = make(map[string][]AddressSighting, len(s.history))
+		for device, history := range s.history {
+			e.AddressHistory[device] = append([]AddressSighting(nil), history...)
+		}
+	}
+	return e
+}
+
+// Import replaces the state with e. Entries keep their recorded last-seen
+// times, so anything no longer announced expires on the usual schedule. No
+// events are published; the next periodic sync programs the imported routes.
+func (s *State) Import(e Export) error {
+	if e.Version != exportVersion {
+		return fmt.Errorf("unsupported state export version %d", e.Version)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.borderRouters = append([]discovery.BorderRouter{}, e.BorderRouters...)
+	s.devices = make(map[string]discovery.MatterDevice, len(e.Devices))
+	for _, d := range e.Devices {
+		s.devices[d.Name] = d
+	}
+	s.meshPrefixes = make(map[netip.Prefix]time.Time, len(e.MeshPrefixes))
+	for p, t := range e.MeshPrefixes {
+		s.meshPrefixes[p.Masked()] = t
+	}
+	s.addedRoutes = make(map[string]bool, len(e.AddedRoutes))
+	for _, key := range e.AddedRoutes {
+		s.addedRoutes[key] = true
+	}
+	s.routeLastSeen = make(map[string]time.Time, len(e.RouteLastSeen))
+	for key, t := range e.RouteLastSeen {
+		s.routeLastSeen[key] = t
+	}
+	s.expiredNexthops = make(map[netip.Addr]time.Time, len(e.ExpiredNexthops))
+	for ip, t := range e.ExpiredNexthops {
+		s.expiredNexthops[ip] = t
+	}
+	s.trelPeers = make(map[string]discovery.TRELPeer, len(e.TRELPeers))
+	for _, peer := range e.TRELPeers {
+		s.trelPeers[peer.Name] = peer
+	}
+	s.history = make(map[string][]AddressSighting, len(e.AddressHistory))
+	for device, history := range e.AddressHistory {
+		s.history[device] = append([]AddressSighting(nil), history...)
+	}
+	return nil
+}
+
+// ExportFile writes the full state to path as indented JSON.
+func (s *State) ExportFile(path string) error {
+	data, err := json.MarshalIndent(s.Export(), "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o600)
+}
+
+// ImportFile replaces the state with the export stored at path.
+func (s *State) ImportFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var e Export
+	if err := json.Unmarshal(data, &e); err != nil {
+		return fmt.Errorf("parse %s: %v", path, err)
+	}
+	return s.Import(e)
+}
@@ -0,0 +1,62 @@
+package state
+
+import (
+	"net/netip"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/discovery"
+)
+
+// TestExportImportRoundTrip verifies a state written to a file is restored intact.
+func TestExportImportRoundTrip(t *testing.T) {
+	seen := time.Now().Add(-time.Minute).Round(time.Second)
+	s := New(nil)
+	s.borderRouters = []discovery.BorderRouter{
+		{Name: "Router1", IPv6Addrs: []netip.Addr{netip.MustParseAddr("2001:4860:4860:1234::ff")}, LastSeen: seen},
+	}
+	s.devices["Light"] = discovery.MatterDevice{Name: "Light", LastSeen: seen}
+	s.meshPrefixes[netip.MustParsePrefix("fd00:1111:2222:3333::/64")] = seen
+	s.addedRoutes["fd00:1111:2222:3333::/64|2001:4860:4860:1234::ff"] = true
+	s.routeLastSeen["fd00:1111:2222:3333::/64|2001:4860:4860:1234::ff"] = seen
+	s.expiredNexthops[netip.MustParseAddr("2001:4860:4860:1234::fe")] = seen
+	s.history["Gone"] = []AddressSighting{{Addr: netip.MustParseAddr("fd00:1::1"), FirstSeen: seen, LastSeen: seen}}
+
+	path := filepath.Join(t.TempDir(), "state.json")
+	if err := s.ExportFile(path); err != nil {
+		t.Fatalf("ExportFile failed: %v", err)
+	}
+
+	restored := New(nil)
+	if err := restored.ImportFile(path); err != nil {
+		t.Fatalf("ImportFile failed: %v", err)
+	}
+	snap := restored.Snapshot()
+	if len(snap.BorderRouters) != 1 || snap.BorderRouters[0].Name != "Router1" || !snap.BorderRouters[0].LastSeen.Equal(seen) {
+		t.Errorf("Expected Router1 with its last-seen time, got %+v", snap.BorderRouters)
+	}
+	if snap.Devices != 1 {
+		t.Errorf("Expected 1 device, got %d", snap.Devices)
+	}
+	if !snap.MeshPrefixes[netip.MustParsePrefix("fd00:1111:2222:3333::/64")].Equal(seen) {
+		t.Errorf("Expected mesh prefix to be restored, got %v", snap.MeshPrefixes)
+	}
+	if !restored.addedRoutes["fd00:1111:2222:3333::/64|2001:4860:4860:1234::ff"] || len(restored.RouteLastSeen()) != 1 {
+		t.Errorf("Expected route tracking to be restored")
+	}
+	if !restored.ExpiredNexthops()["2001:4860:4860:1234::fe"] {
+		t.Errorf("Expected expired next hops to be restored")
+	}
+	if history := restored.AddressHistory(); len(history) != 1 || history[0].Name != "Gone" || !history[0].Addrs[0].LastSeen.Equal(seen) {
+		t.Errorf("Expected the address history to be restored, got %+v", history)
+	}
+}
+
+// TestImportRejectsUnknownVersion verifies exports from other format versions are
+// refused.
+func TestImportRejectsUnknownVersion(t *testing.T) {
+	if err := New(nil).Import(Export{Version: 99}); err == nil {
+		t.Errorf("Expected an error for an unknown version")
+	}
+}
@@ -0,0 +1,89 @@
+package state
+
+import (
+	"net/netip"
+	"slices"
+	"sort"
+	"time"
+)
+
+// AddressSighting is an address a Matter device announced, with when it was
+// first and last announced.
+type AddressSighting struct {
+	Addr      netip.Addr `json:"addr"`
+	FirstSeen time.Time  `json:"first_seen"`
+	LastSeen  time.Time  `json:"last_seen"`
+	// Current reports whether the address is still among the device's addresses.
+	Current bool `json:"current"`
+}
+
+// DeviceHistory is the IPv6 address history of a Matter device, most recently
+// announced first.
+type DeviceHistory struct {
+	Name  string            `json:"name"`
+	Addrs []AddressSighting `json:"addrs"`
+}
+
+// addressSighted records that device announced addrs at now, keeping at most
+// limit addresses (all when limit is 0). The history is ordered from least to
+// most recently announced, like the device's addresses, so the addresses
+// announced longest ago are evicted first. s.mu must be held.
+func (s *State) addressSighted(device string, addrs []netip.Addr, now time.Time, limit int) {
+	history := s.history[device]
+	for _, addr := range addrs {
+		sighting := AddressSighting{Addr: addr, FirstSeen: now}
+		if i := slices.IndexFunc(history, func(a AddressSighting) bool { return a.Addr == addr }); i >= 0 {
+			sighting.FirstSeen = history[i].FirstSeen
+			history = slices.Delete(history, i, i+1)
+		}
+		sighting.LastSeen = now
+		history = append(history, sighting)
+	}
+	if limit > 0 && len(history) > limit {
+		history = slices.Delete(history, 0, len(history)-limit)
+	}
+	s.history[device] = history
+}
+
+// expireHistory drops the addresses last announced longer than the history
+// age limit ago, and the history of devices left without any, returning the
+// number of addresses dropped. The history outlives expired devices, so an
+// address a controller still uses can be traced back after the device left.
+// s.mu must be held.
+func (s *State) expireHistory(now time.Time) int {
+	age := s.limits.AddressHistoryAge
+	if age <= 0 {
+		return 0
+	}
+	removed := 0
+	for device, history := range s.history {
+		kept := slices.DeleteFunc(history, func(a AddressSighting) bool { return now.Sub(a.LastSeen) > age })
+		removed += len(history) - len(kept)
+		if len(kept) == 0 {
+			delete(s.history, device)
+		} else {
+			s.history[device] = kept
+		}
+	}
+	return removed
+}
+
+// AddressHistory returns the IPv6 address history of each Matter device seen
+// within the history age limit, sorted by name, so an address a controller
+// still uses can be matched against what the device announced and when.
+func (s *State) AddressHistory() []DeviceHistory {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]DeviceHistory, 0, len(s.history))
+	for name, history := range s.history {
+		current := s.devices[name].IPv6Addrs
+		addrs := make([]AddressSighting, 0, len(history))
+		for _, a := range slices.Backward(history) {
+			a.Current = slices.Contains(current, a.Addr)
+			addrs = append(addrs, a)
+		}
+		out = append(out, DeviceHistory{Name: name, Addrs: addrs})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
@@ -0,0 +1,78 @@
+package state
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/config"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/discovery"
+)
+
+func TestAddressSighted(t *testing.T) {
+	a, b, c := netip.MustParseAddr("fd00:1::a"), netip.MustParseAddr("fd00:1::b"), netip.MustParseAddr("fd00:1::c")
+	start := time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time { return start.Add(time.Duration(minutes) * time.Minute) }
+
+	s := New(nil)
+	s.addressSighted("dev", []netip.Addr{a, b}, at(0), 2)
+	s.addressSighted("dev", []netip.Addr{a}, at(10), 2)
+	s.addressSighted("dev", []netip.Addr{c}, at(20), 2)
+
+	history := s.history["dev"]
+	if len(history) != 2 || history[0].Addr != a || history[1].Addr != c {
+		t.Fatalf("Expected the least recently announced address evicted, got %+v", history)
+	}
+	if !history[0].FirstSeen.Equal(at(0)) || !history[0].LastSeen.Equal(at(10)) {
+		t.Errorf("Expected %s seen from %v to %v, got %+v", a, at(0), at(10), history[0])
+	}
+}
+
+func TestAddressHistory(t *testing.T) {
+	old, fresh := netip.MustParseAddr("fd00:1::1"), netip.MustParseAddr("fd00:1::2")
+	s := New(nil)
+	s.ConfigureLimits(config.TrackingLimits{DeviceAddrs: 1})
+	s.MergeDevice(discovery.MatterDevice{Name: "b", IPv6Addrs: []netip.Addr{old}})
+	s.MergeDevice(discovery.MatterDevice{Name: "b", IPv6Addrs: []netip.Addr{fresh}})
+	s.MergeDevice(discovery.MatterDevice{Name: "a", IPv6Addrs: []netip.Addr{old}})
+
+	got := s.AddressHistory()
+	if len(got) != 2 || got[0].Name != "a" || got[1].Name != "b" {
+		t.Fatalf("Expected the history of a and b, got %+v", got)
+	}
+	addrs := got[1].Addrs
+	if len(addrs) != 2 || addrs[0].Addr != fresh || !addrs[0].Current || addrs[1].Addr != old || addrs[1].Current {
+		t.Errorf("Expected %s current and %s rotated away, got %+v", fresh, old, addrs)
+	}
+
+	if removed := s.RemoveExpiredDevices(-time.Second); removed != 2 {
+		t.Fatalf("Expected both devices expired, got %d", removed)
+	}
+	got = s.AddressHistory()
+	if len(got) != 2 || got[1].Addrs[0].Current {
+		t.Errorf("Expected the history kept past the devices' expiry, got %+v", got)
+	}
+}
+
+// TestExpireHistory verifies addresses older than the history age limit are
+// dropped, along with devices left without any.
+func TestExpireHistory(t *testing.T) {
+	now := time.Now()
+	s := New(nil)
+	s.history["a"] = []AddressSighting{{Addr: netip.MustParseAddr("fd00:1::1"), LastSeen: now.Add(-2 * time.Hour)}}
+	s.history["b"] = []AddressSighting{
+		{Addr: netip.MustParseAddr("fd00:1::2"), LastSeen: now.Add(-2 * time.Hour)},
+		{Addr: netip.MustParseAddr("fd00:1::3"), LastSeen: now},
+	}
+
+	if removed := s.expireHistory(now); removed != 0 || len(s.history) != 2 {
+		t.Errorf("Expected nothing expired without an age limit, got %d", removed)
+	}
+	s.ConfigureLimits(config.TrackingLimits{AddressHistoryAge: time.Hour})
+	if removed := s.CompactTracking(config.GracePolicy{}); removed != 2 {
+		t.Errorf("Expected 2 addresses expired, got %d", removed)
+	}
+	if _, ok := s.history["a"]; ok || len(s.history["b"]) != 1 || s.history["b"][0].Addr != netip.MustParseAddr("fd00:1::3") {
+		t.Errorf("Expected only b's current address left, got %+v", s.history)
+	}
+}
@@ -0,0 +1,257 @@
+package state
+
+import (
+	"net/netip"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/config"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/discovery"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/events"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/logger"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/routes"
+)
+
+// prefixLifetime is the route lifetime one border router last announced for a
+// mesh prefix.
+type prefixLifetime struct {
+	lifetime  time.Duration // discovery.InfiniteLifetime for a route announced without expiry
+	announced time.Time
+	deadline  time.Time // zero for an infinite lifetime
+	ended     bool      // withdrawn, or the deadline passed and was acted on
+	// nexthops are the routable addresses of the border router the
+	// advertisement came from, when its link-local source is one of them.
+	nexthops []netip.Addr
+}
+
+// endedBy reports whether the lifetime was withdrawn or ran out by now.
+func (l prefixLifetime) endedBy(now time.Time) bool {
+	return l.ended || (!l.deadline.IsZero() && !now.Before(l.deadline))
+}
+
+// PrefixLifetime is the route lifetime a border router announced for a mesh prefix.
+type PrefixLifetime struct {
+	Prefix      netip.Prefix `json:"prefix"`
+	Router      string       `json:"router"`
+	Nexthops    []netip.Addr `json:"nexthops,omitempty"`
+	Lifetime    string       `json:"lifetime"` // "infinite" for a route announced without expiry
+	AnnouncedAt time.Time    `json:"announced_at"`
+	ExpiresAt   time.Time    `json:"expires_at,omitzero"`
+	// Ended marks lifetimes that ran out or were withdrawn; the routes
+	// through the router's next hops are removed without a grace period.
+	Ended bool `json:"ended,omitempty"`
+}
+
+// ObservePrefixLifetime records that router, the link-local source of a router
+// advertisement, announced a route to a known mesh prefix valid for lifetime.
+// A zero lifetime withdraws the prefix from that router only: the routes
+// through its next hops are removed at the next sync. The prefix itself
+// expires once the lifetimes of all routers announcing it ended, unless mDNS
+// sighted it since. A negative lifetime, announced without expiry, leaves the
+// prefix to its grace period. It reports whether the prefix is a known mesh
+// prefix; others are ignored.
+func (s *State) ObservePrefixLifetime(prefix netip.Prefix, lifetime time.Duration, router string) bool {
+	prefix = prefix.Masked()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	_, known := s.meshPrefixes[prefix]
+	if !known && len(s.lifetimes[prefix]) == 0 {
+		return false
+	}
+	if s.lifetimes[prefix] == nil {
+		s.lifetimes[prefix] = make(map[string]prefixLifetime)
+	}
+	now := time.Now()
+	entry := prefixLifetime{lifetime: lifetime, announced: now, nexthops: s.routerNexthops(router)}
+
+	if lifetime == 0 {
+		if !known {
+			return true // expired with the lifetimes of all its routers already
+		}
+		logger.Info("Border router %s withdrew Thread mesh prefix %s", router, prefix)
+		entry.deadline = now
+		s.lifetimes[prefix][router] = entry
+		s.endLifetime(prefix, router)
+		if s.lifetimeEnded(prefix, now) {
+			logger.Info("Thread mesh prefix %s withdrawn by all its border routers", prefix)
+			s.expirePrefix(prefix, now)
+		}
+		return true
+	}
+
+	if lifetime > 0 {
+		entry.deadline = now.Add(lifetime)
+	}
+	if known {
+		s.meshPrefixes[prefix] = now
+		s.prefixSighted(prefix, now)
+	} else {
+		s.observePrefix(prefix) // announced again after its lifetimes ended
+	}
+	if s.lifetimes[prefix] == nil {
+		s.lifetimes[prefix] = make(map[string]prefixLifetime)
+	}
+	if _, scheduled := s.lifetimes[prefix][router]; !scheduled && lifetime > 0 {
+		logger.Info("Thread mesh prefix %s expires with the route lifetime announced by %s", prefix, router)
+	}
+	s.lifetimes[prefix][router] = entry
+	return true
+}
+
+// routerNexthops returns the routable addresses of the border router that has
+// the link-local address router, or nil when no known router has it. s.mu
+// must be held.
+func (s *State) routerNexthops(router string) []netip.Addr {
+	addr, err := netip.ParseAddr(router)
+	if err != nil {
+		return nil
+	}
+	addr = addr.WithZone("")
+	for _, r := range s.borderRouters {
+		match := false
+		for _, ip := range r.IPv6Addrs {
+			if ip.WithZone("") == addr {
+				match = true
+				break
+			}
+		}
+		if !match {
+			continue
+		}
+		var out []netip.Addr
+		for _, ip := range r.IPv6Addrs {
+			if routes.IsRoutableRouterAddress(ip) {
+				out = append(out, ip.WithZone(""))
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+// endLifetime marks the lifetime router announced for prefix ended and lets
+// the routes to prefix through the router's next hops be removed at the next
+// sync, without a grace period. s.mu must be held.
+func (s *State) endLifetime(prefix netip.Prefix, router string) {
+	l := s.lifetimes[prefix][router]
+	l.ended = true
+	s.lifetimes[prefix][router] = l
+	if len(l.nexthops) == 0 {
+		logger.Debug("Border router %s matches no known router, its routes to %s keep their grace period", router, prefix)
+		return
+	}
+	nexthops := make(map[string]bool, len(l.nexthops))
+	for _, ip := range l.nexthops {
+		nexthops[ip.String()] = true
+	}
+	for key := range s.routeLastSeen {
+		network, nexthop, _ := strings.Cut(key, "->")
+		n, err := netip.ParsePrefix(network)
+		if err != nil || !nexthops[nexthop] || n.Bits() < prefix.Bits() || !prefix.Contains(n.Addr()) {
+			continue
+		}
+		s.routeLastSeen[key] = time.Time{}
+	}
+}
+
+// expirePrefix forgets a mesh prefix and announces its expiry. s.mu must be held.
+func (s *State) expirePrefix(prefix netip.Prefix, now time.Time) {
+	delete(s.meshPrefixes, prefix)
+	s.prefixExpired(prefix, now)
+	s.bus.Publish(events.Event{Kind: events.PrefixExpired, Prefix: prefix.String()})
+}
+
+// lifetimeEnded reports whether every border router announcing prefix
+// withdrew it or let its lifetime run out by now, and mDNS did not sight the
+// prefix since. s.mu must be held.
+func (s *State) lifetimeEnded(prefix netip.Prefix, now time.Time) bool {
+	byRouter := s.lifetimes[prefix]
+	if len(byRouter) == 0 {
+		return false
+	}
+	var last time.Time
+	for _, l := range byRouter {
+		if !l.endedBy(now) {
+			return false
+		}
+		if l.deadline.After(last) {
+			last = l.deadline
+		}
+	}
+	seen, known := s.meshPrefixes[prefix]
+	return !known || !seen.After(last)
+}
+
+// lifetimeActive reports whether a border router announced prefix with a
+// lifetime that has not run out by now. s.mu must be held.
+func (s *State) lifetimeActive(prefix netip.Prefix, now time.Time) bool {
+	for _, l := range s.lifetimes[prefix] {
+		if !l.deadline.IsZero() && !l.endedBy(now) {
+			return true
+		}
+	}
+	return false
+}
+
+// withdrawnNexthops returns, per mesh prefix, the next hops of the border
+// routers that withdrew it or let its lifetime run out. s.mu must be held.
+func (s *State) withdrawnNexthops(now time.Time) map[netip.Prefix][]netip.Addr {
+	out := make(map[netip.Prefix][]netip.Addr)
+	for prefix, byRouter := range s.lifetimes {
+		for _, l := range byRouter {
+			if l.endedBy(now) {
+				out[prefix] = append(out[prefix], l.nexthops...)
+			}
+		}
+	}
+	return out
+}
+
+// LifetimeGrace returns grace with no grace period for routes to the mesh
+// prefixes whose announced lifetimes all ran out, so they are removed at the
+// next sync.
+func (s *State) LifetimeGrace(grace config.GracePolicy) config.GracePolicy {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	for prefix := range s.lifetimes {
+		if s.lifetimeEnded(prefix, now) {
+			grace = grace.Override(prefix, 0)
+		}
+	}
+	return grace
+}
+
+// PrefixLifetimes returns the announced route lifetimes of the mesh prefixes,
+// sorted by prefix and router.
+func (s *State) PrefixLifetimes() []PrefixLifetime {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	out := []PrefixLifetime{}
+	for prefix, byRouter := range s.lifetimes {
+		for router, l := range byRouter {
+			lifetime := "infinite"
+			if l.lifetime != discovery.InfiniteLifetime && l.lifetime >= 0 {
+				lifetime = logger.FormatDuration(l.lifetime)
+			}
+			out = append(out, PrefixLifetime{
+				Prefix:      prefix,
+				Router:      router,
+				Nexthops:    l.nexthops,
+				Lifetime:    lifetime,
+				AnnouncedAt: l.announced,
+				ExpiresAt:   l.deadline,
+				Ended:       l.endedBy(now),
+			})
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Prefix != out[j].Prefix {
+			return out[i].Prefix.String() < out[j].Prefix.String()
+		}
+		return out[i].Router < out[j].Router
+	})
+	return out
+}
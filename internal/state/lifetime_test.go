@@ -0,0 +1,134 @@
+package state
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/config"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/discovery"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/routes"
+)
+
+func TestObservePrefixLifetime(t *testing.T) {
+	s := New(nil)
+	prefix := netip.MustParsePrefix("fd11:22::/64")
+	grace := config.GracePolicy{Default: 10 * time.Minute}
+
+	if s.ObservePrefixLifetime(prefix, time.Hour, "fe80::1") {
+		t.Error("Expected a lifetime for an unknown prefix to be ignored")
+	}
+	if len(s.PrefixLifetimes()) != 0 {
+		t.Errorf("Expected no lifetimes, got %+v", s.PrefixLifetimes())
+	}
+
+	s.ObservePrefix(prefix)
+	if !s.ObservePrefixLifetime(prefix, time.Hour, "fe80::1") {
+		t.Fatal("Expected the lifetime of a known prefix to be recorded")
+	}
+	// Unseen for longer than its grace period, but within its lifetime.
+	s.meshPrefixes[prefix] = time.Now().Add(-time.Hour)
+	if removed := s.RemoveExpiredPrefixes(grace); removed != 0 {
+		t.Errorf("Expected the prefix to outlive its grace period, removed %d", removed)
+	}
+
+	// Its lifetime ran out, and mDNS last sighted it before that.
+	l := s.lifetimes[prefix]["fe80::1"]
+	l.deadline = time.Now().Add(-time.Second)
+	s.lifetimes[prefix]["fe80::1"] = l
+	if _, ok := s.Snapshot().MeshPrefixes[prefix]; ok {
+		t.Error("Expected a prefix whose lifetime ran out to be left out of snapshots")
+	}
+	if removed := s.RemoveExpiredPrefixes(grace); removed != 1 {
+		t.Errorf("Expected the prefix to expire with its lifetime, removed %d", removed)
+	}
+	lifetimes := s.PrefixLifetimes()
+	if len(lifetimes) != 1 || !lifetimes[0].Ended || lifetimes[0].Router != "fe80::1" {
+		t.Errorf("Expected an ended lifetime from fe80::1, got %+v", lifetimes)
+	}
+
+	// Announced again over mDNS, the prefix starts over with its grace period.
+	s.ObservePrefix(prefix)
+	if len(s.PrefixLifetimes()) != 0 {
+		t.Errorf("Expected the ended lifetime to be dropped, got %+v", s.PrefixLifetimes())
+	}
+}
+
+// TestPrefixLifetimeSightedSinceEnd verifies an mDNS sighting after a
+// lifetime ran out keeps the prefix alive for its grace period.
+func TestPrefixLifetimeSightedSinceEnd(t *testing.T) {
+	s := New(nil)
+	prefix := netip.MustParsePrefix("fd11:22::/64")
+	s.ObservePrefix(prefix)
+	s.ObservePrefixLifetime(prefix, time.Hour, "fe80::1")
+	l := s.lifetimes[prefix]["fe80::1"]
+	l.deadline = time.Now().Add(-time.Minute)
+	s.lifetimes[prefix]["fe80::1"] = l
+
+	s.RefreshPrefix(prefix)
+	if removed := s.RemoveExpiredPrefixes(config.GracePolicy{Default: 10 * time.Minute}); removed != 0 {
+		t.Errorf("Expected the prefix sighted since its lifetime ended kept, removed %d", removed)
+	}
+	if _, ok := s.Snapshot().MeshPrefixes[prefix]; !ok {
+		t.Error("Expected the prefix sighted since its lifetime ended in snapshots")
+	}
+}
+
+// TestPrefixLifetimeWithdrawal verifies a router withdrawing a prefix that
+// another router still announces only loses its own routes.
+func TestPrefixLifetimeWithdrawal(t *testing.T) {
+	s := New(nil)
+	prefix := netip.MustParsePrefix("fd11:22::/64")
+	appleTV := netip.MustParseAddr("2a02:8109::1")
+	homePod := netip.MustParseAddr("2a02:8109::2")
+	s.MergeBorderRouter(discovery.BorderRouter{Name: "Apple TV", IPv6Addrs: []netip.Addr{netip.MustParseAddr("fe80::1"), appleTV}})
+	s.MergeBorderRouter(discovery.BorderRouter{Name: "HomePod", IPv6Addrs: []netip.Addr{netip.MustParseAddr("fe80::2"), homePod}})
+	s.ObservePrefix(prefix)
+	s.ObservePrefixLifetime(prefix, time.Hour, "fe80::1")
+	s.ObservePrefixLifetime(prefix, discovery.InfiniteLifetime, "fe80::2")
+	viaAppleTV := routes.Key(prefix.String(), appleTV.String())
+	viaHomePod := routes.Key(prefix.String(), homePod.String())
+	s.UpdateRouteLastSeen(func(routeLastSeen map[string]time.Time) {
+		routeLastSeen[viaAppleTV] = time.Now()
+		routeLastSeen[viaHomePod] = time.Now()
+	})
+
+	s.ObservePrefixLifetime(prefix, 0, "fe80::1")
+	snap := s.Snapshot()
+	if _, ok := snap.MeshPrefixes[prefix]; !ok {
+		t.Fatal("Expected the prefix another router announces to stay")
+	}
+	rs := snap.Policy().Routes(snap.MeshPrefixes, snap.BorderRouters)
+	if len(rs) != 1 || rs[0].ThreadRouterIPv6 != homePod {
+		t.Errorf("Expected only the route via the HomePod, got %+v", rs)
+	}
+	lastSeen := s.RouteLastSeen()
+	if !lastSeen[viaAppleTV].IsZero() || lastSeen[viaHomePod].IsZero() {
+		t.Errorf("Expected only the withdrawn route to lose its grace period, got %v", lastSeen)
+	}
+	if got := s.LifetimeGrace(config.GracePolicy{Default: time.Hour}).For(prefix.String()); got != time.Hour {
+		t.Errorf("Expected the prefix to keep its grace period, got %v", got)
+	}
+
+	s.ObservePrefixLifetime(prefix, 0, "fe80::2")
+	if _, ok := s.Snapshot().MeshPrefixes[prefix]; ok {
+		t.Error("Expected a prefix withdrawn by all its routers to be removed at once")
+	}
+	if got := s.LifetimeGrace(config.GracePolicy{Default: time.Hour}).For(prefix.String()); got != 0 {
+		t.Errorf("Expected no grace period for routes to a withdrawn prefix, got %v", got)
+	}
+	if got := s.LifetimeGrace(config.GracePolicy{Default: time.Hour}).For("fd11:23::/64"); got != time.Hour {
+		t.Errorf("Expected other networks to keep their grace period, got %v", got)
+	}
+
+	for router, l := range s.lifetimes[prefix] {
+		l.deadline = time.Now().Add(-2 * time.Hour)
+		s.lifetimes[prefix][router] = l
+	}
+	if removed := s.CompactTracking(config.GracePolicy{Default: time.Hour}); removed < 2 {
+		t.Errorf("Expected the ended lifetimes to be compacted after the grace period, removed %d", removed)
+	}
+	if len(s.PrefixLifetimes()) != 0 {
+		t.Errorf("Expected no lifetimes left, got %+v", s.PrefixLifetimes())
+	}
+}
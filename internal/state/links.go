@@ -0,0 +1,37 @@
+package state
+
+import (
+	"fmt"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/discovery"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/events"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/logger"
+)
+
+// SetRouterUplinks records where border routers attach to the LAN, by name,
+// publishing RouterUpdated for each router whose uplink changed. Routers
+// missing from uplinks keep their last known uplink.
+func (s *State) SetRouterUplinks(uplinks map[string]discovery.Uplink) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, r := range s.borderRouters {
+		uplink, ok := uplinks[r.Name]
+		if !ok || uplink == r.Uplink {
+			continue
+		}
+		if r.Link != "" {
+			logger.Info("Thread Border Router %s moved: %s on %s -> %s on %s",
+				r.Name, r.Link, uplinkNetwork(r.Uplink), uplink.Link, uplinkNetwork(uplink))
+		}
+		s.borderRouters[i].Uplink = uplink
+		s.bus.Publish(events.Event{Kind: events.RouterUpdated, Name: r.Name})
+	}
+}
+
+// uplinkNetwork names the network of an uplink with its VLAN, e.g. "IoT (VLAN 30)".
+func uplinkNetwork(u discovery.Uplink) string {
+	if u.VLAN == 0 {
+		return u.Network
+	}
+	return fmt.Sprintf("%s (VLAN %d)", u.Network, u.VLAN)
+}
@@ -0,0 +1,42 @@
+package state
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/discovery"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/events"
+)
+
+// TestSetRouterUplinks verifies uplinks are recorded, survive later sightings
+// and publish RouterUpdated only when they change.
+func TestSetRouterUplinks(t *testing.T) {
+	bus := events.NewBus()
+	s := New(bus)
+	router := discovery.BorderRouter{Name: "Kitchen", IPv6Addrs: []netip.Addr{netip.MustParseAddr("2001:4860:4860::1")}}
+	s.MergeBorderRouter(router)
+	ch := bus.Subscribe(16)
+
+	wifi := discovery.Uplink{Link: discovery.LinkWireless, Network: "IoT", VLAN: 30}
+	s.SetRouterUplinks(map[string]discovery.Uplink{"Kitchen": wifi, "Gone": {Link: discovery.LinkWired}})
+	s.SetRouterUplinks(map[string]discovery.Uplink{"Kitchen": wifi}) // no change
+	s.SetRouterUplinks(map[string]discovery.Uplink{})                // unknown keeps the uplink
+	s.MergeBorderRouter(router)
+
+	if routers := s.Snapshot().BorderRouters; len(routers) != 1 || routers[0].Uplink != wifi {
+		t.Fatalf("Expected Kitchen on Wi-Fi in VLAN 30, got %+v", routers)
+	}
+	select {
+	case e := <-ch:
+		if e.Kind != events.RouterUpdated || e.Name != "Kitchen" {
+			t.Errorf("Expected router-updated for Kitchen, got %s %s", e.Kind, e.Name)
+		}
+	default:
+		t.Fatal("Expected router-updated event, got none")
+	}
+	select {
+	case e := <-ch:
+		t.Errorf("Expected no further events, got %s", e.Kind)
+	default:
+	}
+}
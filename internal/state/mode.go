@@ -0,0 +1,41 @@
+package state
+
+import (
+	"net/netip"
+	"sort"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/config"
+)
+
+// ConfigureRouteMode sets whether routes cover whole mesh prefixes or, in host
+// mode, each Matter device address; host mode adds the device addresses to
+// snapshots.
+func (s *State) ConfigureRouteMode(mode config.RouteMode) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.routeMode = mode
+}
+
+// DeviceAddrs returns the sorted, distinct addresses of all Matter devices.
+func (s *State) DeviceAddrs() []netip.Addr {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.hostAddrs()
+}
+
+// hostAddrs returns the sorted, distinct addresses of all Matter devices. s.mu
+// must be held.
+func (s *State) hostAddrs() []netip.Addr {
+	seen := make(map[netip.Addr]bool)
+	addrs := []netip.Addr{}
+	for _, d := range s.devices {
+		for _, ip := range d.IPv6Addrs {
+			if ip = ip.WithZone(""); !seen[ip] {
+				seen[ip] = true
+				addrs = append(addrs, ip)
+			}
+		}
+	}
+	sort.Slice(addrs, func(i, j int) bool { return addrs[i].Less(addrs[j]) })
+	return addrs
+}
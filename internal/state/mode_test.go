@@ -0,0 +1,28 @@
+package state
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/config"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/discovery"
+)
+
+func TestHostAddrsSnapshot(t *testing.T) {
+	s := New(nil)
+	s.MergeDevice(discovery.MatterDevice{Name: "Light", LastSeen: time.Now(),
+		IPv6Addrs: []netip.Addr{netip.MustParseAddr("fd00:1::6"), netip.MustParseAddr("fd00:1::5")}})
+	s.MergeDevice(discovery.MatterDevice{Name: "Plug", LastSeen: time.Now(),
+		IPv6Addrs: []netip.Addr{netip.MustParseAddr("fd00:1::5")}})
+
+	if snap := s.Snapshot(); snap.RouteMode != config.RouteModePrefix || snap.HostAddrs != nil {
+		t.Errorf("Expected prefix mode without host addresses, got %s %v", snap.RouteMode, snap.HostAddrs)
+	}
+
+	s.ConfigureRouteMode(config.RouteModeHost)
+	got := s.Snapshot().HostAddrs
+	if len(got) != 2 || got[0].String() != "fd00:1::5" || got[1].String() != "fd00:1::6" {
+		t.Errorf("Expected fd00:1::5 and fd00:1::6, got %v", got)
+	}
+}
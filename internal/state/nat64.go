@@ -0,0 +1,59 @@
+package state
+
+import (
+	"net/netip"
+	"sort"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/logger"
+)
+
+// ObserveNAT64 records a NAT64 prefix advertised for lifetime; a zero lifetime
+// withdraws it. Thread mesh prefixes overlapping it are not routed.
+func (s *State) ObserveNAT64(prefix netip.Prefix, lifetime time.Duration) {
+	prefix = prefix.Masked()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if lifetime <= 0 {
+		if _, known := s.nat64Prefixes[prefix]; known {
+			logger.Info("NAT64 prefix withdrawn: %s", prefix)
+			delete(s.nat64Prefixes, prefix)
+		}
+		return
+	}
+	if _, known := s.nat64Prefixes[prefix]; !known {
+		logger.Info("NAT64 prefix discovered: %s (excluded from static routes)", prefix)
+	}
+	s.nat64Prefixes[prefix] = time.Now().Add(lifetime)
+}
+
+// ConfigureNAT64 sets the NAT64 prefixes that never expire, e.g. from
+// configuration, replacing those configured before. Discovered prefixes stay.
+func (s *State) ConfigureNAT64(prefixes []netip.Prefix) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for p, expires := range s.nat64Prefixes {
+		if expires.IsZero() {
+			delete(s.nat64Prefixes, p)
+		}
+	}
+	for _, p := range prefixes {
+		s.nat64Prefixes[p.Masked()] = time.Time{}
+	}
+}
+
+// nat64List returns the NAT64 prefixes, sorted, dropping expired ones. s.mu must be held.
+func (s *State) nat64List() []netip.Prefix {
+	now := time.Now()
+	out := []netip.Prefix{}
+	for p, expires := range s.nat64Prefixes {
+		if !expires.IsZero() && !now.Before(expires) {
+			logger.Info("NAT64 prefix expired: %s", p)
+			delete(s.nat64Prefixes, p)
+			continue
+		}
+		out = append(out, p)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].String() < out[j].String() })
+	return out
+}
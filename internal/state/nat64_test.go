@@ -0,0 +1,29 @@
+package state
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+)
+
+func TestNAT64Prefixes(t *testing.T) {
+	s := New(nil)
+	configured := netip.MustParsePrefix("fd00:aaaa::/96")
+	learned := netip.MustParsePrefix("fd00:1111:2222:2::/96")
+	stale := netip.MustParsePrefix("fd00:3333:4444:2::/96")
+
+	s.ConfigureNAT64([]netip.Prefix{configured})
+	s.ObserveNAT64(learned, 30*time.Minute)
+	s.ObserveNAT64(stale, 30*time.Minute)
+	s.nat64Prefixes[stale] = time.Now().Add(-time.Second)
+
+	got := s.Snapshot().NAT64Prefixes
+	if len(got) != 2 || got[0] != learned || got[1] != configured {
+		t.Errorf("Expected %s and %s, got %v", learned, configured, got)
+	}
+
+	s.ObserveNAT64(learned, 0)
+	if got := s.Snapshot().NAT64Prefixes; len(got) != 1 || got[0] != configured {
+		t.Errorf("Expected withdrawn prefix removed, got %v", got)
+	}
+}
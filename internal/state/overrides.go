@@ -0,0 +1,25 @@
+package state
+
+import "github.com/rafaelgaspar/unifi-thread-route-updater/internal/config"
+
+// ConfigureOverrides sets the route pins and ignored prefixes merged with the
+// discovered routes.
+func (s *State) ConfigureOverrides(o config.RouteOverrides) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.overrides = o
+}
+
+// RouteOverrides returns the configured route pins and ignored prefixes.
+func (s *State) RouteOverrides() config.RouteOverrides {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.overrides
+}
+
+// ConfigureNextHops sets which border routers a mesh prefix is routed through.
+func (s *State) ConfigureNextHops(policy config.NextHopPolicy) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextHops = policy
+}
@@ -0,0 +1,181 @@
+package state
+
+import (
+	"net/netip"
+	"sort"
+	"time"
+)
+
+// maxSightings bounds the sighting times kept per prefix for the average
+// announcement gap.
+const maxSightings = 1024
+
+// PrefixStability is the availability of a Thread mesh prefix over the
+// stability window, from the first sighting within it.
+type PrefixStability struct {
+	Prefix netip.Prefix `json:"prefix"`
+	Up     bool         `json:"up"`
+	// Availability is the percentage of the tracked time the prefix was known.
+	Availability float64 `json:"availability_percent"`
+	// Flaps counts the times the prefix expired and was announced again.
+	Flaps int `json:"flaps"`
+	// AverageGap is the mean time between sightings in seconds, 0 with fewer
+	// than two.
+	AverageGap float64   `json:"average_announcement_gap_seconds"`
+	LastSeen   time.Time `json:"last_seen"`
+	Window     float64   `json:"window_seconds"`
+}
+
+// stability is the availability history of one prefix.
+type stability struct {
+	periods   []period    // times the prefix was known, oldest first; the last may be open
+	flaps     []time.Time // times the prefix came back after expiring
+	sightings []time.Time // announcement times, oldest first
+}
+
+// period is a span of time a prefix was known; a zero end means it still is.
+type period struct {
+	start, end time.Time
+}
+
+// sighted records an announcement of the prefix at now, opening a period when
+// it was not known.
+func (st *stability) sighted(now time.Time) {
+	if n := len(st.periods); n == 0 || !st.periods[n-1].end.IsZero() {
+		if n > 0 {
+			st.flaps = append(st.flaps, now)
+		}
+		st.periods = append(st.periods, period{start: now})
+	}
+	// Devices of one network often announce together: count a burst once.
+	if n := len(st.sightings); n == 0 || now.Sub(st.sightings[n-1]) >= time.Second {
+		st.sightings = append(st.sightings, now)
+	}
+	if len(st.sightings) > maxSightings {
+		st.sightings = st.sightings[len(st.sightings)-maxSightings:]
+	}
+}
+
+// expired closes the open period at now.
+func (st *stability) expired(now time.Time) {
+	if n := len(st.periods); n > 0 && st.periods[n-1].end.IsZero() {
+		st.periods[n-1].end = now
+	}
+}
+
+// trim drops the history before since, reporting whether anything is left.
+func (st *stability) trim(since time.Time) bool {
+	periods := st.periods[:0]
+	for _, p := range st.periods {
+		if p.end.IsZero() || p.end.After(since) {
+			periods = append(periods, p)
+		}
+	}
+	st.periods = periods
+	st.flaps = after(st.flaps, since)
+	st.sightings = after(st.sightings, since)
+	return len(st.periods) > 0
+}
+
+// after returns the times after since, which are sorted.
+func after(times []time.Time, since time.Time) []time.Time {
+	i := sort.Search(len(times), func(i int) bool { return times[i].After(since) })
+	return times[i:]
+}
+
+// report summarises the history over the window ending at now.
+func (st *stability) report(prefix netip.Prefix, window time.Duration, now time.Time) PrefixStability {
+	since := now.Add(-window)
+	report := PrefixStability{Prefix: prefix, Flaps: len(st.flaps), Window: window.Seconds()}
+	if len(st.periods) == 0 {
+		return report
+	}
+	start := st.periods[0].start
+	if start.Before(since) {
+		start = since
+	}
+	var up time.Duration
+	for _, p := range st.periods {
+		from, to := p.start, p.end
+		if from.Before(since) {
+			from = since
+		}
+		if to.IsZero() {
+			to = now
+			report.Up = true
+		}
+		up += to.Sub(from)
+	}
+	report.Availability = 100
+	if tracked := now.Sub(start); tracked > 0 {
+		report.Availability = min(100, 100*float64(up)/float64(tracked))
+	}
+	if n := len(st.sightings); n > 0 {
+		report.LastSeen = st.sightings[n-1]
+		if n > 1 {
+			report.AverageGap = st.sightings[n-1].Sub(st.sightings[0]).Seconds() / float64(n-1)
+		}
+	}
+	return report
+}
+
+// PrefixStability returns the availability of every mesh prefix seen within
+// the stability window, sorted by prefix, forgetting older history.
+func (s *State) PrefixStability() []PrefixStability {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	window := s.stabilityWindow()
+	out := []PrefixStability{}
+	for prefix, st := range s.stability {
+		if !st.trim(now.Add(-window)) {
+			delete(s.stability, prefix)
+			continue
+		}
+		out = append(out, st.report(prefix, window, now))
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Prefix.String() < out[j].Prefix.String() })
+	return out
+}
+
+// PrefixStabilityMetrics returns a gauge function reporting value of each
+// prefix's PrefixStability, labelled by prefix.
+func (s *State) PrefixStabilityMetrics(value func(PrefixStability) float64) func() map[string]float64 {
+	return func() map[string]float64 {
+		out := make(map[string]float64)
+		for _, p := range s.PrefixStability() {
+			out[p.Prefix.String()] = value(p)
+		}
+		return out
+	}
+}
+
+// stabilityWindow returns the configured window, 24 hours by default.
+func (s *State) stabilityWindow() time.Duration {
+	if s.limits.StabilityWindow > 0 {
+		return s.limits.StabilityWindow
+	}
+	return 24 * time.Hour
+}
+
+// prefixSighted records an announcement of prefix, dropping its history from
+// before the stability window so it stays bounded between reports. s.mu must
+// be held.
+func (s *State) prefixSighted(prefix netip.Prefix, now time.Time) {
+	st, ok := s.stability[prefix]
+	if !ok {
+		st = &stability{}
+		s.stability[prefix] = st
+	}
+	st.sighted(now)
+	st.trim(now.Add(-s.stabilityWindow()))
+}
+
+// prefixExpired records the expiry of prefix and drops its history from
+// before the stability window. s.mu must be held.
+func (s *State) prefixExpired(prefix netip.Prefix, now time.Time) {
+	if st, ok := s.stability[prefix]; ok {
+		st.expired(now)
+		st.trim(now.Add(-s.stabilityWindow()))
+	}
+}
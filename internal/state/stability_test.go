@@ -0,0 +1,89 @@
+package state
+
+import (
+	"math"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/config"
+)
+
+func TestStabilityReport(t *testing.T) {
+	prefix := netip.MustParsePrefix("fd00:1::/64")
+	start := time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC)
+	at := func(minutes int) time.Time { return start.Add(time.Duration(minutes) * time.Minute) }
+
+	st := &stability{}
+	st.sighted(at(0))
+	st.sighted(at(10))
+	st.sighted(at(10)) // same burst
+	st.expired(at(30))
+	st.sighted(at(60))
+	st.sighted(at(70))
+
+	got := st.report(prefix, 24*time.Hour, at(100))
+	if !got.Up || got.Flaps != 1 {
+		t.Errorf("Expected the prefix up after one flap, got %+v", got)
+	}
+	// Known 0-30 and 60-100 of 100 minutes.
+	if math.Abs(got.Availability-70) > 0.01 {
+		t.Errorf("Expected 70%% availability, got %v", got.Availability)
+	}
+	if got.AverageGap != (70 * time.Minute / 3).Seconds() {
+		t.Errorf("Expected an average gap of %v, got %vs", 70*time.Minute/3, got.AverageGap)
+	}
+	if !got.LastSeen.Equal(at(70)) {
+		t.Errorf("Expected last seen at %v, got %v", at(70), got.LastSeen)
+	}
+
+	// A 50-minute window starting at 50 keeps only the second period.
+	if !st.trim(at(50)) {
+		t.Fatal("Expected history left in the window")
+	}
+	got = st.report(prefix, 50*time.Minute, at(100))
+	if math.Abs(got.Availability-100) > 0.01 || got.Flaps != 1 {
+		t.Errorf("Expected full availability within the window, got %+v", got)
+	}
+
+	st.expired(at(110))
+	if st.trim(at(120)) {
+		t.Errorf("Expected history outside the window dropped, got %+v", st)
+	}
+}
+
+func TestPrefixStability(t *testing.T) {
+	s := New(nil)
+	prefix := netip.MustParsePrefix("fd00:2::/64")
+	s.ObservePrefix(prefix)
+	s.RefreshPrefix(prefix)
+
+	got := s.PrefixStability()
+	if len(got) != 1 || got[0].Prefix != prefix || !got[0].Up || got[0].Flaps != 0 {
+		t.Errorf("Expected one prefix up, got %+v", got)
+	}
+	if v := s.PrefixStabilityMetrics(func(p PrefixStability) float64 { return float64(p.Flaps) })(); len(v) != 1 {
+		t.Errorf("Expected one gauge value, got %v", v)
+	}
+}
+
+// TestPrefixStabilityTrimsOnUpdate verifies the history is bounded by the
+// window as it is recorded, not only when reported.
+func TestPrefixStabilityTrimsOnUpdate(t *testing.T) {
+	s := New(nil)
+	s.ConfigureLimits(config.TrackingLimits{StabilityWindow: time.Hour})
+	prefix := netip.MustParsePrefix("fd00:2::/64")
+	now := time.Now()
+
+	s.prefixSighted(prefix, now.Add(-3*time.Hour))
+	s.prefixExpired(prefix, now.Add(-150*time.Minute))
+	s.prefixSighted(prefix, now)
+
+	st := s.stability[prefix]
+	if len(st.periods) != 1 || !st.periods[0].start.Equal(now) {
+		t.Errorf("Expected only the period within the window, got %+v", st.periods)
+	}
+	if len(st.sightings) != 1 || len(st.flaps) != 1 {
+		t.Errorf("Expected 1 sighting and 1 flap within the window, got %d and %d", len(st.sightings), len(st.flaps))
+	}
+}
@@ -0,0 +1,492 @@
+// Package state holds the daemon's view of discovered border routers, Thread mesh
+// prefixes and the routes it has programmed.
+package state
+
+import (
+	"net/netip"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/config"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/discovery"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/events"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/logger"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/routes"
+)
+
+// expiredNexthopRetention bounds how long an expired router's addresses are
+// remembered for failover; routes still using them then fall back to the grace period.
+const expiredNexthopRetention = 24 * time.Hour
+
+// State holds the current state of discovered routers and Thread mesh prefixes.
+// It implements discovery.Sink, publishes lifecycle changes to its event bus and
+// is safe for concurrent use.
+type State struct {
+	mu            sync.Mutex
+	bus           *events.Bus
+	borderRouters []discovery.BorderRouter
+	devices       map[string]discovery.MatterDevice
+	meshPrefixes  map[netip.Prefix]time.Time                 // fd:: prefixes → last seen time
+	lifetimes     map[netip.Prefix]map[string]prefixLifetime // announced route lifetimes by prefix and router
+	addedRoutes   map[string]bool
+	routeLastSeen map[string]time.Time
+	// expiredNexthops holds the routable addresses of expired routers, until
+	// they are announced again, so their routes can fail over immediately.
+	expiredNexthops map[netip.Addr]time.Time
+	trelPeers       map[string]discovery.TRELPeer
+	nat64Prefixes   map[netip.Prefix]time.Time // expiry, zero for configured prefixes
+	conflictPolicy  config.ConflictPolicy
+	routeMode       config.RouteMode
+	conflicts       map[netip.Prefix]string // reported conflicts → networks and winner
+	limits          config.TrackingLimits
+	overrides       config.RouteOverrides
+	nextHops        config.NextHopPolicy
+	warnIPv4Only    bool
+	stability       map[netip.Prefix]*stability  // availability history per mesh prefix
+	history         map[string][]AddressSighting // IPv6 address history per device name
+}
+
+// Snapshot is a point-in-time copy of the discovered routers and prefixes.
+type Snapshot struct {
+	BorderRouters []discovery.BorderRouter   `json:"border_routers"`
+	Devices       int                        `json:"devices"`
+	MeshPrefixes  map[netip.Prefix]time.Time `json:"mesh_prefixes"`
+	NAT64Prefixes []netip.Prefix             `json:"nat64_prefixes"`
+	// PrefixConflicts are mesh prefixes announced by several Thread networks.
+	PrefixConflicts []routes.Conflict `json:"prefix_conflicts"`
+	RouteMode       config.RouteMode  `json:"route_mode"`
+	// HostAddrs are the Matter device addresses, set in host route mode only.
+	HostAddrs []netip.Addr `json:"host_addrs,omitempty"`
+	// Overrides are the configured route pins and ignored prefixes.
+	Overrides config.RouteOverrides `json:"overrides"`
+	// NextHops decides which border routers a mesh prefix is routed through.
+	NextHops config.NextHopPolicy `json:"-"`
+	// Withdrawn are the next hops of the border routers that withdrew a mesh
+	// prefix or let its announced route lifetime run out.
+	Withdrawn map[netip.Prefix][]netip.Addr `json:"-"`
+}
+
+// Policy returns the route policy the snapshot's configuration and conflicts
+// make up.
+func (snap Snapshot) Policy() routes.Policy {
+	return routes.Policy{
+		NAT64:     snap.NAT64Prefixes,
+		Conflicts: snap.PrefixConflicts,
+		NextHops:  snap.NextHops,
+		Mode:      snap.RouteMode,
+		HostAddrs: snap.HostAddrs,
+		Overrides: snap.Overrides,
+		Withdrawn: snap.Withdrawn,
+	}
+}
+
+// New returns an empty State publishing to bus, which may be nil.
+func New(bus *events.Bus) *State {
+	return &State{
+		bus:           bus,
+		borderRouters: []discovery.BorderRouter{},
+		devices:       make(map[string]discovery.MatterDevice),
+		meshPrefixes:  make(map[netip.Prefix]time.Time),
+		lifetimes:     make(map[netip.Prefix]map[string]prefixLifetime),
+		addedRoutes:   make(map[string]bool),
+		routeLastSeen: make(map[string]time.Time),
+
+		expiredNexthops: make(map[netip.Addr]time.Time),
+		trelPeers:       make(map[string]discovery.TRELPeer),
+		nat64Prefixes:   make(map[netip.Prefix]time.Time),
+		conflictPolicy:  config.ConflictLowestExtPANID,
+		routeMode:       config.RouteModePrefix,
+		conflicts:       make(map[netip.Prefix]string),
+		stability:       make(map[netip.Prefix]*stability),
+		history:         make(map[string][]AddressSighting),
+	}
+}
+
+// Snapshot returns a copy of the discovered routers and prefixes.
+func (s *State) Snapshot() Snapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	snap := Snapshot{
+		BorderRouters:   make([]discovery.BorderRouter, len(s.borderRouters)),
+		Devices:         len(s.devices),
+		MeshPrefixes:    make(map[netip.Prefix]time.Time, len(s.meshPrefixes)),
+		NAT64Prefixes:   s.nat64List(),
+		PrefixConflicts: routes.DetectConflicts(s.borderRouters, s.conflictPolicy),
+		RouteMode:       s.routeMode,
+		Overrides:       s.overrides,
+		NextHops:        s.nextHops,
+	}
+	if s.routeMode == config.RouteModeHost {
+		snap.HostAddrs = s.hostAddrs()
+	}
+	for i, r := range s.borderRouters {
+		r.IPv6Addrs = append([]netip.Addr(nil), r.IPv6Addrs...)
+		snap.BorderRouters[i] = r
+	}
+	now := time.Now()
+	for p, t := range s.meshPrefixes {
+		if !s.lifetimeEnded(p, now) {
+			snap.MeshPrefixes[p] = t
+		}
+	}
+	snap.Withdrawn = s.withdrawnNexthops(now)
+	return snap
+}
+
+// ObservePrefix records a sighting of a Thread mesh prefix and reports whether it
+// was new.
+func (s *State) ObservePrefix(prefix netip.Prefix) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.observePrefix(prefix)
+}
+
+// observePrefix records a sighting of a Thread mesh prefix and reports whether
+// it was new. s.mu must be held.
+func (s *State) observePrefix(prefix netip.Prefix) bool {
+	prefix = prefix.Masked()
+	_, known := s.meshPrefixes[prefix]
+	now := time.Now()
+	s.meshPrefixes[prefix] = now
+	s.prefixSighted(prefix, now)
+	if !known {
+		delete(s.lifetimes, prefix)
+		s.bus.Publish(events.Event{Kind: events.PrefixAdded, Prefix: prefix.String()})
+	}
+	return !known
+}
+
+// RefreshPrefix updates the last-seen time of a known Thread mesh prefix and
+// reports whether it was known.
+func (s *State) RefreshPrefix(prefix netip.Prefix) bool {
+	prefix = prefix.Masked()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, known := s.meshPrefixes[prefix]; !known {
+		return false
+	}
+	now := time.Now()
+	s.meshPrefixes[prefix] = now
+	s.prefixSighted(prefix, now)
+	return true
+}
+
+// MergeBorderRouter merges a newly discovered router with existing ones,
+// accumulating IPs per router.
+func (s *State) MergeBorderRouter(newRouter discovery.BorderRouter) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.checkConflicts()
+	s.mergeBorderRouter(newRouter)
+}
+
+// mergeBorderRouter merges a router sighting. s.mu must be held; the caller
+// checks for prefix conflicts afterwards.
+func (s *State) mergeBorderRouter(newRouter discovery.BorderRouter) {
+	now := time.Now()
+	for _, ip := range newRouter.IPv6Addrs {
+		delete(s.expiredNexthops, ip)
+	}
+	for i, existing := range s.borderRouters {
+		if existing.Name == newRouter.Name {
+			s.borderRouters[i].LastSeen = now
+			if newRouter.ExtAddress != "" {
+				s.borderRouters[i].ExtAddress = newRouter.ExtAddress
+			}
+			if newRouter.ExtPANID != "" {
+				s.borderRouters[i].ExtPANID = newRouter.ExtPANID
+			}
+			if newRouter.OMRPrefix.IsValid() {
+				s.borderRouters[i].OMRPrefix = newRouter.OMRPrefix
+			}
+			if newRouter.Type != "" {
+				s.borderRouters[i].Type = newRouter.Type
+			}
+			changed := false
+			if renumbered(existing.IPv6Addrs, newRouter.IPv6Addrs) {
+				logger.Info("Thread Border Router %s renumbered: %v -> %v", newRouter.Name, existing.IPv6Addrs, newRouter.IPv6Addrs)
+				s.borderRouters[i].IPv6Addrs = nonRoutable(existing.IPv6Addrs)
+				changed = true
+			}
+			before := len(s.borderRouters[i].IPv6Addrs)
+			for _, ip := range newRouter.IPv6Addrs {
+				s.borderRouters[i].IPv6Addrs = discovery.AppendUnique(s.borderRouters[i].IPv6Addrs, ip)
+			}
+			logger.Debug("Thread Border Router updated: %s %v", newRouter.Name, s.borderRouters[i].IPv6Addrs)
+			if changed || len(s.borderRouters[i].IPv6Addrs) != before {
+				s.bus.Publish(events.Event{Kind: events.RouterUpdated, Name: newRouter.Name})
+			}
+			return
+		}
+	}
+	newRouter.LastSeen = now
+	s.borderRouters = append(s.borderRouters, newRouter)
+	logger.Debug("Thread Border Router added: %s %v", newRouter.Name, newRouter.IPv6Addrs)
+	s.bus.Publish(events.Event{Kind: events.RouterAdded, Name: newRouter.Name})
+}
+
+// MergeNetwork records the border routers, Matter devices and mesh prefixes
+// of a discovery round at once, so a snapshot never holds half of a round. It
+// returns the prefixes that were new. It implements discovery.NetworkSink.
+func (s *State) MergeNetwork(n discovery.Network) []netip.Prefix {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.checkConflicts()
+	for _, r := range n.Routers {
+		s.mergeBorderRouter(r)
+	}
+	for _, d := range n.Devices {
+		s.mergeDevice(d)
+	}
+	var added []netip.Prefix
+	for _, p := range n.Prefixes {
+		if s.observePrefix(p.Prefix) {
+			added = append(added, p.Prefix.Masked())
+		}
+	}
+	return added
+}
+
+// renumbered reports whether an announcement carries routable addresses only in
+// /64s the router has never used, while the router's known routable addresses all
+// sit in /64s the announcement no longer mentions — i.e. its delegated prefix changed.
+func renumbered(known, announced []netip.Addr) bool {
+	knownPrefixes := routablePrefixes(known)
+	announcedPrefixes := routablePrefixes(announced)
+	if len(knownPrefixes) == 0 || len(announcedPrefixes) == 0 {
+		return false
+	}
+	for p := range announcedPrefixes {
+		if knownPrefixes[p] {
+			return false
+		}
+	}
+	return true
+}
+
+// routablePrefixes returns the set of /64s of the routable addresses in ips.
+func routablePrefixes(ips []netip.Addr) map[netip.Prefix]bool {
+	set := make(map[netip.Prefix]bool)
+	for _, ip := range ips {
+		if routes.IsRoutableRouterAddress(ip) {
+			set[discovery.CIDR64(ip)] = true
+		}
+	}
+	return set
+}
+
+// nonRoutable returns the addresses in ips that are not routable next hops.
+func nonRoutable(ips []netip.Addr) []netip.Addr {
+	var out []netip.Addr
+	for _, ip := range ips {
+		if !routes.IsRoutableRouterAddress(ip) {
+			out = append(out, ip)
+		}
+	}
+	return out
+}
+
+// MergeDevice records a Matter device sighting, accumulating IPs per device up
+// to the configured limit, most recently announced last.
+func (s *State) MergeDevice(device discovery.MatterDevice) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.mergeDevice(device)
+}
+
+// mergeDevice records a Matter device sighting. s.mu must be held.
+func (s *State) mergeDevice(device discovery.MatterDevice) {
+	now := time.Now()
+	existing, known := s.devices[device.Name]
+	s.addressSighted(device.Name, device.IPv6Addrs, now, s.limits.AddressHistory)
+	if !known {
+		device.LastSeen = now
+		device.IPv6Addrs, _ = touchAddrs(nil, device.IPv6Addrs, s.limits.DeviceAddrs)
+		device.IPv4Addrs, _ = touchAddrs(nil, device.IPv4Addrs, s.limits.DeviceAddrs)
+		s.devices[device.Name] = device
+		if s.warnIPv4Only && device.IPv4Only() {
+			logger.Warn("Matter device %s announces only IPv4 addresses %v: it is not on Thread, so no route can reach it",
+				device.Description(), device.IPv4Addrs)
+		}
+		s.bus.Publish(events.Event{Kind: events.DeviceAdded, Name: device.Name, Detail: device.Description()})
+		return
+	}
+	before := existing
+	var addrsAdded, ipv4Added bool
+	existing.IPv6Addrs, addrsAdded = touchAddrs(existing.IPv6Addrs, device.IPv6Addrs, s.limits.DeviceAddrs)
+	existing.IPv4Addrs, ipv4Added = touchAddrs(existing.IPv4Addrs, device.IPv4Addrs, s.limits.DeviceAddrs)
+	// Metadata a later sighting lacks, e.g. from a backend that didn't resolve
+	// it, is kept.
+	if device.Hostname != "" {
+		existing.Hostname = device.Hostname
+	}
+	if device.MAC != "" {
+		existing.MAC = device.MAC
+		existing.Vendor = device.Vendor
+	}
+	existing.LastSeen = now
+	s.devices[device.Name] = existing
+	if addrsAdded || ipv4Added || existing.Hostname != before.Hostname ||
+		existing.MAC != before.MAC || existing.Vendor != before.Vendor {
+		s.bus.Publish(events.Event{Kind: events.DeviceUpdated, Name: device.Name, Detail: existing.Description()})
+	}
+}
+
+// ConfigureIPv4OnlyWarning sets whether newly discovered Matter devices that
+// announce only IPv4 addresses are logged as a warning.
+func (s *State) ConfigureIPv4OnlyWarning(enabled bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.warnIPv4Only = enabled
+}
+
+// Devices returns a copy of the discovered Matter devices, sorted by name.
+func (s *State) Devices() []discovery.MatterDevice {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.sortedDevices()
+}
+
+// sortedDevices returns a copy of the devices sorted by name. s.mu must be held.
+func (s *State) sortedDevices() []discovery.MatterDevice {
+	devices := make([]discovery.MatterDevice, 0, len(s.devices))
+	for _, d := range s.devices {
+		d.IPv6Addrs = append([]netip.Addr(nil), d.IPv6Addrs...)
+		d.IPv4Addrs = append([]netip.Addr(nil), d.IPv4Addrs...)
+		devices = append(devices, d)
+	}
+	sort.Slice(devices, func(i, j int) bool { return devices[i].Name < devices[j].Name })
+	return devices
+}
+
+// RemoveExpiredDevices removes Matter devices that haven't been seen for the
+// expiration period.
+func (s *State) RemoveExpiredDevices(expiration time.Duration) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	removed := 0
+	for name, device := range s.devices {
+		if now.Sub(device.LastSeen) > expiration {
+			delete(s.devices, name)
+			s.bus.Publish(events.Event{Kind: events.DeviceExpired, Name: name})
+			removed++
+		}
+	}
+	return removed
+}
+
+// RemoveExpiredRouters removes routers that haven't been seen for the expiration period.
+func (s *State) RemoveExpiredRouters(expiration time.Duration) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	defer s.checkConflicts()
+	now := time.Now()
+	var remaining []discovery.BorderRouter
+	removed := 0
+	for _, router := range s.borderRouters {
+		if now.Sub(router.LastSeen) > expiration {
+			logger.Debug("Expiring Thread Border Router %s: last-seen=%s ago", router.Name, now.Sub(router.LastSeen).Round(time.Second))
+			for _, ip := range router.IPv6Addrs {
+				if routes.IsRoutableRouterAddress(ip) {
+					s.expiredNexthops[ip] = now
+				}
+			}
+			s.bus.Publish(events.Event{Kind: events.RouterExpired, Name: router.Name})
+			removed++
+		} else {
+			remaining = append(remaining, router)
+		}
+	}
+	s.borderRouters = remaining
+	for ip, expired := range s.expiredNexthops {
+		if now.Sub(expired) > expiredNexthopRetention {
+			delete(s.expiredNexthops, ip)
+		}
+	}
+	return removed
+}
+
+// ExpiredNexthops returns the routable addresses of routers that expired and
+// have not been announced again.
+func (s *State) ExpiredNexthops() map[string]bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make(map[string]bool, len(s.expiredNexthops))
+	for ip := range s.expiredNexthops {
+		out[ip.String()] = true
+	}
+	return out
+}
+
+// RemoveExpiredPrefixes removes Thread mesh prefixes not seen for their grace
+// period, or whose announced route lifetime ran out.
+func (s *State) RemoveExpiredPrefixes(grace config.GracePolicy) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	removed := 0
+	for prefix, lastSeen := range s.meshPrefixes {
+		for router, l := range s.lifetimes[prefix] {
+			if !l.ended && l.endedBy(now) {
+				logger.Debug("Route lifetime of Thread mesh prefix %s from %s ended", prefix, router)
+				s.endLifetime(prefix, router)
+			}
+		}
+		switch {
+		case s.lifetimeEnded(prefix, now):
+			logger.Debug("Expiring Thread mesh prefix %s: route lifetimes from all its border routers ended", prefix)
+		case s.lifetimeActive(prefix, now):
+			continue
+		case now.Sub(lastSeen) > grace.For(prefix.String()):
+			logger.Debug("Expiring Thread mesh prefix %s: last-seen=%s ago", prefix, now.Sub(lastSeen).Round(time.Second))
+		default:
+			continue
+		}
+		s.expirePrefix(prefix, now)
+		removed++
+	}
+	return removed
+}
+
+// RouteLastSeen returns a copy of the last time each route key was detected.
+func (s *State) RouteLastSeen() map[string]time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make(map[string]time.Time, len(s.routeLastSeen))
+	for k, t := range s.routeLastSeen {
+		out[k] = t
+	}
+	return out
+}
+
+// UpdateRouteLastSeen calls fn with the live route last-seen map while holding
+// the state lock.
+func (s *State) UpdateRouteLastSeen(fn func(routeLastSeen map[string]time.Time)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fn(s.routeLastSeen)
+}
+
+// MarkRouteAdded records that the daemon programmed the route with the given key.
+func (s *State) MarkRouteAdded(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.addedRoutes[key] = true
+}
+
+// MarkRouteRemoved records that the route with the given key was deleted.
+func (s *State) MarkRouteRemoved(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.addedRoutes, key)
+}
+
+// ForgetRoute drops all tracking for the route with the given key.
+func (s *State) ForgetRoute(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.routeLastSeen, key)
+	delete(s.addedRoutes, key)
+}
@@ -0,0 +1,256 @@
+package state
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/config"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/discovery"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/events"
+)
+
+func TestNew(t *testing.T) {
+	s := New(nil)
+
+	if s.borderRouters == nil {
+		t.Error("borderRouters should be initialised")
+	}
+	if s.meshPrefixes == nil {
+		t.Error("meshPrefixes should be initialised")
+	}
+	if s.addedRoutes == nil {
+		t.Error("addedRoutes should be initialised")
+	}
+	if s.routeLastSeen == nil {
+		t.Error("routeLastSeen should be initialised")
+	}
+	if s.devices == nil {
+		t.Error("devices should be initialised")
+	}
+}
+
+func TestObservePrefix(t *testing.T) {
+	s := New(nil)
+	if !s.ObservePrefix(netip.MustParsePrefix("fd00:1111:2222:3333::/64")) {
+		t.Error("Expected first sighting to report a new prefix")
+	}
+	if s.ObservePrefix(netip.MustParsePrefix("fd00:1111:2222:3333::/64")) {
+		t.Error("Expected second sighting to report a known prefix")
+	}
+}
+
+func TestRefreshPrefix(t *testing.T) {
+	s := New(nil)
+	prefix := netip.MustParsePrefix("fd00:1111:2222:3333::/64")
+	if s.RefreshPrefix(prefix) {
+		t.Error("Expected unknown prefix not to be refreshed")
+	}
+	if len(s.Snapshot().MeshPrefixes) != 0 {
+		t.Error("Expected refresh not to add a prefix")
+	}
+	s.meshPrefixes[prefix] = time.Now().Add(-time.Hour)
+	if !s.RefreshPrefix(prefix) || time.Since(s.meshPrefixes[prefix]) > time.Minute {
+		t.Error("Expected known prefix to be refreshed")
+	}
+}
+
+func TestMergeBorderRouter(t *testing.T) {
+	s := New(nil)
+	s.MergeBorderRouter(discovery.BorderRouter{Name: "Router1", IPv6Addrs: []netip.Addr{netip.MustParseAddr("2001:4860:4860::1")}})
+	s.MergeBorderRouter(discovery.BorderRouter{Name: "Router1", IPv6Addrs: []netip.Addr{netip.MustParseAddr("2001:4860:4860::2")}})
+	s.MergeBorderRouter(discovery.BorderRouter{Name: "Router1", IPv6Addrs: []netip.Addr{netip.MustParseAddr("2001:4860:4860::1")}})
+
+	snap := s.Snapshot()
+	if len(snap.BorderRouters) != 1 {
+		t.Fatalf("Expected 1 border router, got %d", len(snap.BorderRouters))
+	}
+	if len(snap.BorderRouters[0].IPv6Addrs) != 2 {
+		t.Errorf("Expected 2 accumulated addresses, got %d", len(snap.BorderRouters[0].IPv6Addrs))
+	}
+}
+
+func TestMergeBorderRouterRenumbered(t *testing.T) {
+	s := New(nil)
+	s.MergeBorderRouter(discovery.BorderRouter{Name: "Router1", IPv6Addrs: []netip.Addr{
+		netip.MustParseAddr("fe80::1"),
+		netip.MustParseAddr("2001:4860:4860:1234::1"),
+	}})
+	s.MergeBorderRouter(discovery.BorderRouter{Name: "Router1", IPv6Addrs: []netip.Addr{
+		netip.MustParseAddr("2001:4860:4860:5678::1"),
+	}})
+
+	addrs := s.Snapshot().BorderRouters[0].IPv6Addrs
+	if len(addrs) != 2 {
+		t.Fatalf("Expected link-local and new address only, got %v", addrs)
+	}
+	for _, ip := range addrs {
+		if ip == netip.MustParseAddr("2001:4860:4860:1234::1") {
+			t.Errorf("Expected renumbered address to be dropped, got %v", addrs)
+		}
+	}
+}
+
+func TestRemoveExpired(t *testing.T) {
+	s := New(nil)
+	s.borderRouters = []discovery.BorderRouter{
+		{Name: "Fresh", LastSeen: time.Now()},
+		{Name: "Stale", LastSeen: time.Now().Add(-time.Hour)},
+	}
+	s.meshPrefixes[netip.MustParsePrefix("fd00:1111:2222:3333::/64")] = time.Now()
+	s.meshPrefixes[netip.MustParsePrefix("fd00:4444:5555:6666::/64")] = time.Now().Add(-time.Hour)
+
+	if n := s.RemoveExpiredRouters(10 * time.Minute); n != 1 {
+		t.Errorf("Expected 1 router removed, got %d", n)
+	}
+	if n := s.RemoveExpiredPrefixes(config.GracePolicy{Default: 10 * time.Minute}); n != 1 {
+		t.Errorf("Expected 1 prefix removed, got %d", n)
+	}
+	snap := s.Snapshot()
+	if len(snap.BorderRouters) != 1 || snap.BorderRouters[0].Name != "Fresh" {
+		t.Errorf("Expected only Fresh router to remain, got %v", snap.BorderRouters)
+	}
+	if _, ok := snap.MeshPrefixes[netip.MustParsePrefix("fd00:1111:2222:3333::/64")]; !ok || len(snap.MeshPrefixes) != 1 {
+		t.Errorf("Expected only the fresh prefix to remain, got %v", snap.MeshPrefixes)
+	}
+}
+
+func TestStatePublishesLifecycleEvents(t *testing.T) {
+	bus := events.NewBus()
+	ch := bus.Subscribe(16)
+	s := New(bus)
+
+	s.MergeBorderRouter(discovery.BorderRouter{Name: "Router1", IPv6Addrs: []netip.Addr{netip.MustParseAddr("2001:4860:4860::1")}})
+	s.MergeBorderRouter(discovery.BorderRouter{Name: "Router1", IPv6Addrs: []netip.Addr{netip.MustParseAddr("2001:4860:4860::1")}}) // no change
+	s.MergeBorderRouter(discovery.BorderRouter{Name: "Router1", IPv6Addrs: []netip.Addr{netip.MustParseAddr("2001:4860:4860::2")}})
+	s.ObservePrefix(netip.MustParsePrefix("fd00:1111:2222:3333::/64"))
+	s.ObservePrefix(netip.MustParsePrefix("fd00:1111:2222:3333::/64")) // no change
+	s.MergeDevice(discovery.MatterDevice{Name: "Lamp", IPv6Addrs: []netip.Addr{netip.MustParseAddr("fd00:1111:2222:3333::5")}})
+	s.devices["Lamp"] = discovery.MatterDevice{Name: "Lamp", LastSeen: time.Now().Add(-time.Hour)}
+	s.RemoveExpiredDevices(10 * time.Minute)
+
+	want := []events.Kind{events.RouterAdded, events.RouterUpdated, events.PrefixAdded, events.DeviceAdded, events.DeviceExpired}
+	for _, kind := range want {
+		select {
+		case e := <-ch:
+			if e.Kind != kind {
+				t.Errorf("Expected %s event, got %s", kind, e.Kind)
+			}
+		default:
+			t.Fatalf("Expected %s event, got none", kind)
+		}
+	}
+	select {
+	case e := <-ch:
+		t.Errorf("Unexpected extra event %s", e.Kind)
+	default:
+	}
+}
+
+// TestExpiredNexthops verifies expired router addresses are listed until announced again.
+func TestExpiredNexthops(t *testing.T) {
+	s := New(nil)
+	router := discovery.BorderRouter{
+		Name:      "Router1",
+		IPv6Addrs: []netip.Addr{netip.MustParseAddr("2001:4860:4860:1234::ff"), netip.MustParseAddr("fd00:aaaa::1")},
+		LastSeen:  time.Now().Add(-time.Hour),
+	}
+	s.borderRouters = []discovery.BorderRouter{router}
+
+	s.RemoveExpiredRouters(10 * time.Minute)
+	expired := s.ExpiredNexthops()
+	if !expired["2001:4860:4860:1234::ff"] || len(expired) != 1 {
+		t.Errorf("Expected only the routable address to be listed, got %v", expired)
+	}
+
+	router.LastSeen = time.Now()
+	s.MergeBorderRouter(router)
+	if expired := s.ExpiredNexthops(); len(expired) != 0 {
+		t.Errorf("Expected no expired next hops after re-announcement, got %v", expired)
+	}
+}
+
+// TestMergeDeviceMetadata verifies device metadata is recorded, kept when a later
+// sighting lacks it and reported through DeviceUpdated events.
+func TestMergeDeviceMetadata(t *testing.T) {
+	bus := events.NewBus()
+	ch := bus.Subscribe(16)
+	s := New(bus)
+	addr := []netip.Addr{netip.MustParseAddr("fd00:1111:2222:3333::5")}
+
+	s.MergeDevice(discovery.MatterDevice{Name: "Lamp", IPv6Addrs: addr})
+	s.MergeDevice(discovery.MatterDevice{Name: "Lamp", IPv6Addrs: addr, Hostname: "001788010203.local", MAC: "00:17:88:01:02:03", Vendor: "Signify (Philips Hue)"})
+	s.MergeDevice(discovery.MatterDevice{Name: "Lamp", IPv6Addrs: addr}) // no change
+
+	devices := s.Devices()
+	if len(devices) != 1 || devices[0].MAC != "00:17:88:01:02:03" || devices[0].Hostname != "001788010203.local" {
+		t.Fatalf("Expected Lamp with metadata, got %+v", devices)
+	}
+	for _, want := range []events.Event{
+		{Kind: events.DeviceAdded, Detail: "Lamp"},
+		{Kind: events.DeviceUpdated, Detail: "Lamp (Signify (Philips Hue), 00:17:88:01:02:03)"},
+	} {
+		select {
+		case e := <-ch:
+			if e.Kind != want.Kind || e.Detail != want.Detail {
+				t.Errorf("Expected %s event %q, got %s %q", want.Kind, want.Detail, e.Kind, e.Detail)
+			}
+		default:
+			t.Fatalf("Expected %s event, got none", want.Kind)
+		}
+	}
+	select {
+	case e := <-ch:
+		t.Errorf("Unexpected extra event %s", e.Kind)
+	default:
+	}
+}
+
+// TestMergeDeviceIPv4 verifies IPv4 addresses are accumulated apart from the
+// IPv6 ones and a new IPv4 address is reported as an update.
+func TestMergeDeviceIPv4(t *testing.T) {
+	bus := events.NewBus()
+	ch := bus.Subscribe(16)
+	s := New(bus)
+	s.ConfigureIPv4OnlyWarning(true)
+
+	s.MergeDevice(discovery.MatterDevice{Name: "Plug", IPv4Addrs: []netip.Addr{netip.MustParseAddr("192.168.1.10")}})
+	s.MergeDevice(discovery.MatterDevice{Name: "Plug", IPv4Addrs: []netip.Addr{netip.MustParseAddr("192.168.1.11")}})
+
+	devices := s.Devices()
+	if len(devices) != 1 || len(devices[0].IPv4Addrs) != 2 || len(devices[0].IPv6Addrs) != 0 || !devices[0].IPv4Only() {
+		t.Fatalf("Expected an IPv4-only Plug with 2 addresses, got %+v", devices)
+	}
+	for _, kind := range []events.Kind{events.DeviceAdded, events.DeviceUpdated} {
+		select {
+		case e := <-ch:
+			if e.Kind != kind {
+				t.Errorf("Expected %s event, got %s", kind, e.Kind)
+			}
+		default:
+			t.Fatalf("Expected %s event, got none", kind)
+		}
+	}
+}
+
+// TestMergeNetwork verifies a discovery round is recorded with its routers,
+// devices and prefixes, reporting only the new prefixes.
+func TestMergeNetwork(t *testing.T) {
+	s := New(nil)
+	known := netip.MustParsePrefix("fd00:1::/64")
+	fresh := netip.MustParsePrefix("fd00:2::/64")
+	s.ObservePrefix(known)
+
+	added := s.MergeNetwork(discovery.Network{
+		Routers:  []discovery.BorderRouter{{Name: "Apple TV", IPv6Addrs: []netip.Addr{netip.MustParseAddr("2001:db8::1")}}},
+		Devices:  []discovery.MatterDevice{{Name: "Lamp", IPv6Addrs: []netip.Addr{netip.MustParseAddr("fd00:2::10")}}},
+		Prefixes: []discovery.PrefixSighting{{Prefix: known}, {Prefix: fresh}},
+	})
+	if len(added) != 1 || added[0] != fresh {
+		t.Errorf("Expected only %s new, got %v", fresh, added)
+	}
+	snap := s.Snapshot()
+	if len(snap.BorderRouters) != 1 || snap.Devices != 1 || len(snap.MeshPrefixes) != 2 {
+		t.Errorf("Expected the whole round recorded, got %+v", snap)
+	}
+}
@@ -0,0 +1,113 @@
+package state
+
+import (
+	"net/netip"
+	"sort"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/discovery"
+)
+
+// ThreadNetwork groups the border routers and TREL peers announcing the same
+// Thread network, identified by its extended PAN ID.
+type ThreadNetwork struct {
+	ExtPANID      string     `json:"ext_pan_id"`
+	BorderRouters []string   `json:"border_routers"`
+	TRELPeers     []TRELLink `json:"trel_peers"`
+	// TRELLinked is set when the network has several border routers and each
+	// is a TREL peer: they exchange Thread traffic over the infrastructure link,
+	// so any one of them can forward for the whole mesh.
+	TRELLinked bool `json:"trel_linked"`
+}
+
+// TRELLink is a TREL peer with the border router it belongs to, if known.
+type TRELLink struct {
+	discovery.TRELPeer
+	BorderRouter string `json:"border_router,omitempty"`
+}
+
+// MergeTRELPeer records a TREL peer sighting, accumulating IPs per peer.
+func (s *State) MergeTRELPeer(peer discovery.TRELPeer) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	existing, known := s.trelPeers[peer.Name]
+	if known {
+		for _, ip := range existing.IPv6Addrs {
+			peer.IPv6Addrs = discovery.AppendUnique(peer.IPv6Addrs, ip)
+		}
+	}
+	peer.LastSeen = time.Now()
+	s.trelPeers[peer.Name] = peer
+}
+
+// RemoveExpiredTRELPeers removes TREL peers that haven't been seen for the
+// expiration period.
+func (s *State) RemoveExpiredTRELPeers(expiration time.Duration) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	removed := 0
+	for name, peer := range s.trelPeers {
+		if now.Sub(peer.LastSeen) > expiration {
+			delete(s.trelPeers, name)
+			removed++
+		}
+	}
+	return removed
+}
+
+// ThreadNetworks returns the Thread networks seen through border router and
+// TREL announcements, sorted by extended PAN ID. Peers are matched to border
+// routers by extended address; those without an extended PAN ID are grouped
+// under an empty one.
+func (s *State) ThreadNetworks() []ThreadNetwork {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	networks := make(map[string]*ThreadNetwork)
+	network := func(xp string) *ThreadNetwork {
+		if n, ok := networks[xp]; ok {
+			return n
+		}
+		n := &ThreadNetwork{ExtPANID: xp, BorderRouters: []string{}, TRELPeers: []TRELLink{}}
+		networks[xp] = n
+		return n
+	}
+	byExtAddress := make(map[string]discovery.BorderRouter)
+	for _, r := range s.borderRouters {
+		n := network(r.ExtPANID)
+		n.BorderRouters = append(n.BorderRouters, r.Name)
+		if r.ExtAddress != "" {
+			byExtAddress[r.ExtAddress] = r
+		}
+	}
+	for _, peer := range s.trelPeers {
+		peer.IPv6Addrs = append([]netip.Addr(nil), peer.IPv6Addrs...)
+		link := TRELLink{TRELPeer: peer}
+		xp := peer.ExtPANID
+		if r, ok := byExtAddress[peer.ExtAddress]; ok && peer.ExtAddress != "" {
+			link.BorderRouter = r.Name
+			if xp == "" {
+				xp = r.ExtPANID
+			}
+		}
+		n := network(xp)
+		n.TRELPeers = append(n.TRELPeers, link)
+	}
+
+	out := make([]ThreadNetwork, 0, len(networks))
+	for _, n := range networks {
+		sort.Strings(n.BorderRouters)
+		sort.Slice(n.TRELPeers, func(i, j int) bool { return n.TRELPeers[i].Name < n.TRELPeers[j].Name })
+		peered := make(map[string]bool)
+		for _, link := range n.TRELPeers {
+			peered[link.BorderRouter] = true
+		}
+		n.TRELLinked = len(n.BorderRouters) > 1
+		for _, name := range n.BorderRouters {
+			n.TRELLinked = n.TRELLinked && peered[name]
+		}
+		out = append(out, *n)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ExtPANID < out[j].ExtPANID })
+	return out
+}
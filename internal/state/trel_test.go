@@ -0,0 +1,46 @@
+package state
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/discovery"
+)
+
+func TestThreadNetworks(t *testing.T) {
+	s := New(nil)
+	addr := []netip.Addr{netip.MustParseAddr("2001:4860:4860:1234::ff")}
+	s.MergeBorderRouter(discovery.BorderRouter{Name: "Kitchen", ExtAddress: "aa01", ExtPANID: "x1", IPv6Addrs: addr})
+	s.MergeBorderRouter(discovery.BorderRouter{Name: "Office", ExtAddress: "aa02", ExtPANID: "x1", IPv6Addrs: addr})
+	s.MergeBorderRouter(discovery.BorderRouter{Name: "Garage", ExtAddress: "bb01", ExtPANID: "x2", IPv6Addrs: addr})
+	s.MergeTRELPeer(discovery.TRELPeer{Name: "trel-kitchen", ExtAddress: "aa01", ExtPANID: "x1"})
+	s.MergeTRELPeer(discovery.TRELPeer{Name: "trel-office", ExtAddress: "aa02"}) // xp from its border router
+	s.MergeTRELPeer(discovery.TRELPeer{Name: "trel-unknown", ExtAddress: "cc01", ExtPANID: "x3"})
+
+	networks := s.ThreadNetworks()
+	if len(networks) != 3 {
+		t.Fatalf("Expected 3 networks, got %+v", networks)
+	}
+	x1 := networks[0]
+	if x1.ExtPANID != "x1" || len(x1.BorderRouters) != 2 || len(x1.TRELPeers) != 2 || !x1.TRELLinked {
+		t.Errorf("Expected x1 with 2 TREL-linked border routers, got %+v", x1)
+	}
+	if x1.TRELPeers[1].BorderRouter != "Office" {
+		t.Errorf("Expected trel-office matched to Office, got %+v", x1.TRELPeers[1])
+	}
+	if x2 := networks[1]; x2.TRELLinked || len(x2.BorderRouters) != 1 {
+		t.Errorf("Expected a single unlinked border router in x2, got %+v", x2)
+	}
+	if x3 := networks[2]; len(x3.TRELPeers) != 1 || x3.TRELPeers[0].BorderRouter != "" {
+		t.Errorf("Expected an unmatched TREL peer in x3, got %+v", x3)
+	}
+
+	s.trelPeers["trel-kitchen"] = discovery.TRELPeer{Name: "trel-kitchen", LastSeen: time.Now().Add(-time.Hour)}
+	if removed := s.RemoveExpiredTRELPeers(10 * time.Minute); removed != 1 {
+		t.Errorf("Expected 1 expired TREL peer, got %d", removed)
+	}
+	if s.ThreadNetworks()[0].TRELLinked {
+		t.Error("Expected x1 no longer TREL-linked once a peer expired")
+	}
+}
@@ -0,0 +1,96 @@
+package status
+
+import (
+	"expvar"
+	"math"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"runtime/metrics"
+	"sync"
+)
+
+var publishRuntime sync.Once
+
+// RuntimeStats is the "runtime" variable of /debug/vars: goroutine, heap and
+// scheduler figures for spotting leaks and memory growth in the field.
+type RuntimeStats struct {
+	Goroutines   int     `json:"goroutines"`
+	GOMAXPROCS   int     `json:"gomaxprocs"`
+	NumCPU       int     `json:"num_cpu"`
+	HeapAlloc    uint64  `json:"heap_alloc_bytes"`
+	HeapInuse    uint64  `json:"heap_inuse_bytes"`
+	HeapObjects  uint64  `json:"heap_objects"`
+	NextGC       uint64  `json:"next_gc_bytes"`
+	NumGC        uint32  `json:"num_gc"`
+	PauseTotalNs uint64  `json:"gc_pause_total_ns"`
+	SchedLatency float64 `json:"sched_latency_p99_seconds"` // time runnable goroutines wait to run
+}
+
+// readRuntimeStats samples the runtime.
+func readRuntimeStats() RuntimeStats {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+	samples := []metrics.Sample{{Name: "/sched/latencies:seconds"}}
+	metrics.Read(samples)
+	stats := RuntimeStats{
+		Goroutines:   runtime.NumGoroutine(),
+		GOMAXPROCS:   runtime.GOMAXPROCS(0),
+		NumCPU:       runtime.NumCPU(),
+		HeapAlloc:    mem.HeapAlloc,
+		HeapInuse:    mem.HeapInuse,
+		HeapObjects:  mem.HeapObjects,
+		NextGC:       mem.NextGC,
+		NumGC:        mem.NumGC,
+		PauseTotalNs: mem.PauseTotalNs,
+	}
+	if samples[0].Value.Kind() == metrics.KindFloat64Histogram {
+		stats.SchedLatency = quantile(samples[0].Value.Float64Histogram(), 0.99)
+	}
+	return stats
+}
+
+// quantile returns the upper bound of the bucket holding quantile q of h.
+func quantile(h *metrics.Float64Histogram, q float64) float64 {
+	var total uint64
+	for _, c := range h.Counts {
+		total += c
+	}
+	if total == 0 {
+		return 0
+	}
+	target := uint64(float64(total) * q)
+	var seen uint64
+	for i, c := range h.Counts {
+		seen += c
+		if seen > target {
+			if math.IsInf(h.Buckets[i+1], 1) {
+				return h.Buckets[i]
+			}
+			return h.Buckets[i+1]
+		}
+	}
+	return h.Buckets[len(h.Buckets)-1]
+}
+
+// EnableDebug serves net/http/pprof under /debug/pprof/ and expvar under
+// /debug/vars, with runtime statistics as the "runtime" variable. Profiles
+// expose internals and cost CPU, so this is off unless configured.
+func (s *Server) EnableDebug() {
+	publishRuntime.Do(func() {
+		expvar.Publish("runtime", expvar.Func(func() interface{} { return readRuntimeStats() }))
+	})
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.debug = true
+}
+
+// mountDebug adds the debug endpoints to mux.
+func mountDebug(mux *http.ServeMux) {
+	mux.Handle("/debug/vars", expvar.Handler())
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+}
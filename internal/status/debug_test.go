@@ -0,0 +1,50 @@
+package status
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDebugEndpoints(t *testing.T) {
+	s := NewServer()
+	disabled := httptest.NewServer(s.Handler())
+	defer disabled.Close()
+	resp, err := http.Get(disabled.URL + "/debug/pprof/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected pprof disabled by default, got %d", resp.StatusCode)
+	}
+
+	s.EnableDebug()
+	srv := httptest.NewServer(s.Handler())
+	defer srv.Close()
+
+	resp, err = http.Get(srv.URL + "/debug/pprof/goroutine?debug=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("Expected goroutine profile, got %d", resp.StatusCode)
+	}
+
+	resp, err = http.Get(srv.URL + "/debug/vars")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	var vars struct {
+		Runtime RuntimeStats `json:"runtime"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&vars); err != nil {
+		t.Fatal(err)
+	}
+	if vars.Runtime.Goroutines == 0 || vars.Runtime.HeapAlloc == 0 || vars.Runtime.NumCPU == 0 {
+		t.Errorf("Expected runtime statistics, got %+v", vars.Runtime)
+	}
+}
@@ -0,0 +1,41 @@
+package status
+
+import (
+	"html/template"
+	"net/http"
+)
+
+// indexPage lists the status sections. Its links are relative, so the page
+// also works behind a path prefix such as Home Assistant ingress.
+var indexPage = template.Must(template.New("index").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>thread-route-updater</title>
+<style>body{font-family:sans-serif;margin:2em}li{margin:.3em 0}</style>
+</head>
+<body>
+<h1>thread-route-updater</h1>
+<ul>
+<li><a href="status">All sections</a></li>
+{{range .Sections}}<li><a href="status/{{.}}">{{.}}</a></li>
+{{end}}<li><a href="api/v1/events">Recent events</a></li>
+<li><a href="metrics">Metrics</a></li>
+<li><a href="api/openapi.json">OpenAPI spec</a></li>
+</ul>
+</body>
+</html>
+`))
+
+// serveIndex writes the index page for / and 404 for any other unmatched path.
+func (s *Server) serveIndex(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/" {
+		http.NotFound(w, r)
+		return
+	}
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	data := struct{ Sections []string }{s.Sections()}
+	if err := indexPage.Execute(w, data); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
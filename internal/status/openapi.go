@@ -0,0 +1,65 @@
+package status
+
+import (
+	_ "embed"
+	"encoding/json"
+	"net/http"
+	"sort"
+)
+
+// openAPISpec is the OpenAPI 3 description of the status API, served at
+// /api/openapi.json. Its info.version is the contract version: fields are only
+// added within a major version.
+//
+//go:embed openapi.json
+var openAPISpec []byte
+
+// serveOpenAPI writes the embedded spec.
+func serveOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(openAPISpec)
+}
+
+// specPaths returns the paths the embedded spec documents.
+func specPaths() (map[string]bool, error) {
+	var spec struct {
+		Paths map[string]json.RawMessage `json:"paths"`
+	}
+	if err := json.Unmarshal(openAPISpec, &spec); err != nil {
+		return nil, err
+	}
+	paths := make(map[string]bool, len(spec.Paths))
+	for path := range spec.Paths {
+		paths[path] = true
+	}
+	return paths, nil
+}
+
+// Undocumented returns the paths of the registered sections, actions and
+// endpoints the OpenAPI spec does not describe, in sorted order.
+func (s *Server) Undocumented() []string {
+	documented, err := specPaths()
+	if err != nil {
+		return []string{"/api/openapi.json"}
+	}
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	var missing []string
+	for name := range s.sections {
+		if !documented["/status/"+name] {
+			missing = append(missing, "/status/"+name)
+		}
+	}
+	for name := range s.actions {
+		if !documented["/actions/"+name] {
+			missing = append(missing, "/actions/"+name)
+		}
+	}
+	for path := range s.endpoints {
+		if !documented[path] {
+			missing = append(missing, path)
+		}
+	}
+	sort.Strings(missing)
+	return missing
+}
@@ -0,0 +1,67 @@
+package status
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+)
+
+func TestOpenAPISpec(t *testing.T) {
+	srv := httptest.NewServer(NewServer().Handler())
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/api/openapi.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "application/json" {
+		t.Fatalf("Expected a JSON spec, got %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+	var spec struct {
+		OpenAPI string `json:"openapi"`
+		Info    struct {
+			Version string `json:"version"`
+		} `json:"info"`
+		Paths map[string]map[string]struct {
+			OperationID string `json:"operationId"`
+		} `json:"paths"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&spec); err != nil {
+		t.Fatalf("Spec is not valid JSON: %v", err)
+	}
+	if spec.OpenAPI != "3.0.3" || spec.Info.Version == "" {
+		t.Errorf("Expected an OpenAPI 3 spec with a version, got %q %q", spec.OpenAPI, spec.Info.Version)
+	}
+	operations := map[string]bool{}
+	for path, methods := range spec.Paths {
+		for method, op := range methods {
+			if op.OperationID == "" || operations[op.OperationID] {
+				t.Errorf("Expected a unique operationId for %s %s, got %q", method, path, op.OperationID)
+			}
+			operations[op.OperationID] = true
+		}
+	}
+	for _, path := range []string{"/healthz", "/metrics", "/status", "/api/v1/events", "/actions/approve"} {
+		if _, ok := spec.Paths[path]; !ok {
+			t.Errorf("Expected %s documented", path)
+		}
+	}
+}
+
+func TestUndocumented(t *testing.T) {
+	s := NewServer()
+	s.Register("version", func() interface{} { return nil })
+	s.Register("mystery", func() interface{} { return nil })
+	s.RegisterAction("approve", func(r *http.Request) (interface{}, error) { return nil, nil })
+	s.RegisterAction("reboot", func(r *http.Request) (interface{}, error) { return nil, nil })
+	s.RegisterEndpoint("/api/v1/events", func() interface{} { return nil })
+	s.RegisterEndpoint("/api/v1/other", func() interface{} { return nil })
+
+	want := []string{"/actions/reboot", "/api/v1/other", "/status/mystery"}
+	if got := s.Undocumented(); !slices.Equal(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
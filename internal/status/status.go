@@ -0,0 +1,224 @@
+// Package status serves the daemon's JSON status API and health endpoint.
+package status
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/netip"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/logger"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/metrics"
+)
+
+// Server exposes named status sections as JSON. Components register a section
+// with a function returning a JSON-serialisable snapshot, and actions that
+// operators trigger with a POST.
+type Server struct {
+	mu        sync.RWMutex
+	sections  map[string]func() interface{}
+	actions   map[string]Action
+	endpoints map[string]func() interface{} // served at their own path, e.g. /api/v1/events
+	debug     bool                          // serve /debug/pprof/ and /debug/vars
+	// actionToken is the bearer token actions require; without one, only
+	// loopback clients may run them.
+	actionToken string
+}
+
+// Action handles a POST to /actions/<name>. It returns a JSON-serialisable
+// result, or an error reported to the caller as 409 Conflict.
+type Action func(r *http.Request) (interface{}, error)
+
+// NewServer returns a server with no sections.
+func NewServer() *Server {
+	return &Server{
+		sections:  make(map[string]func() interface{}),
+		actions:   make(map[string]Action),
+		endpoints: make(map[string]func() interface{}),
+	}
+}
+
+// Register adds or replaces the section served at /status/<name>.
+func (s *Server) Register(name string, fn func() interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sections[name] = fn
+}
+
+// RegisterAction adds or replaces the action served at /actions/<name>.
+func (s *Server) RegisterAction(name string, fn Action) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.actions[name] = fn
+}
+
+// RequireActionToken makes actions require an "Authorization: Bearer <token>"
+// header. Without a token, actions are only run for loopback clients, as they
+// change routes on the controller.
+func (s *Server) RequireActionToken(token string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.actionToken = token
+}
+
+// RegisterEndpoint adds or replaces the JSON served at path, such as
+// /api/v1/events. It must be called before Handler.
+func (s *Server) RegisterEndpoint(path string, fn func() interface{}) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.endpoints[path] = fn
+}
+
+// Handler returns the HTTP handler for the status API:
+//
+//	GET  /                      an HTML index of the sections
+//	GET  /healthz               liveness, always 200 while the process runs
+//	GET  /metrics               Prometheus metrics
+//	GET  /status                all sections keyed by name
+//	GET  /status/<name>         a single section
+//	POST /actions/<name>        run an action, see RequireActionToken
+//	GET  /api/v1/...            endpoints added with RegisterEndpoint
+//	GET  /api/openapi.json      the OpenAPI spec of this API
+//	GET  /debug/...             pprof and runtime variables, once EnableDebug is called
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	s.mu.RLock()
+	if s.debug {
+		mountDebug(mux)
+	}
+	for path, fn := range s.endpoints {
+		fn := fn
+		mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
+			writeJSON(w, fn())
+		})
+	}
+	s.mu.RUnlock()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte("ok\n"))
+	})
+	mux.Handle("/metrics", metrics.Handler())
+	mux.HandleFunc("/api/openapi.json", serveOpenAPI)
+	mux.HandleFunc("/", s.serveIndex)
+	mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, s.snapshot())
+	})
+	mux.HandleFunc("/status/", func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(r.URL.Path, "/status/")
+		s.mu.RLock()
+		fn, ok := s.sections[name]
+		s.mu.RUnlock()
+		if !ok {
+			http.Error(w, "unknown status section", http.StatusNotFound)
+			return
+		}
+		writeJSON(w, fn())
+	})
+	mux.HandleFunc("/actions/", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "actions require POST", http.StatusMethodNotAllowed)
+			return
+		}
+		if !s.authorizeAction(w, r) {
+			return
+		}
+		name := strings.TrimPrefix(r.URL.Path, "/actions/")
+		s.mu.RLock()
+		fn, ok := s.actions[name]
+		s.mu.RUnlock()
+		if !ok {
+			http.Error(w, "unknown action", http.StatusNotFound)
+			return
+		}
+		result, err := fn(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		writeJSON(w, result)
+	})
+	return mux
+}
+
+// authorizeAction reports whether r may run an action: it carries the action
+// token, or comes from a loopback address when no token is set. Otherwise it
+// answers 401 or 403.
+func (s *Server) authorizeAction(w http.ResponseWriter, r *http.Request) bool {
+	s.mu.RLock()
+	token := s.actionToken
+	s.mu.RUnlock()
+	if token != "" {
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="actions"`)
+			http.Error(w, "actions require the STATUS_ACTION_TOKEN bearer token", http.StatusUnauthorized)
+			return false
+		}
+		return true
+	}
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if ip, perr := netip.ParseAddr(host); err != nil || perr != nil || !ip.Unmap().IsLoopback() {
+		http.Error(w, "actions are only accepted from localhost unless STATUS_ACTION_TOKEN is set", http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+// Sections returns the registered section names in sorted order.
+func (s *Server) Sections() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	names := make([]string, 0, len(s.sections))
+	for name := range s.sections {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// snapshot evaluates every registered section.
+func (s *Server) snapshot() map[string]interface{} {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make(map[string]interface{}, len(s.sections))
+	for name, fn := range s.sections {
+		out[name] = fn()
+	}
+	return out
+}
+
+// Serve listens on addr until done is closed.
+func (s *Server) Serve(addr string, done <-chan struct{}) {
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	go func() {
+		<-done
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = srv.Shutdown(ctx)
+	}()
+	logger.Info("Status API listening on %s", addr)
+	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		logger.Error("Status API: %v", err)
+	}
+}
+
+// writeJSON encodes v as indented JSON.
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		logger.Warn("Status API: failed to encode response: %v", err)
+	}
+}
@@ -0,0 +1,194 @@
+package status
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestServerSections(t *testing.T) {
+	s := NewServer()
+	s.Register("counts", func() interface{} { return map[string]int{"routers": 2} })
+
+	srv := httptest.NewServer(s.Handler())
+	defer srv.Close()
+
+	t.Run("all sections", func(t *testing.T) {
+		resp, err := http.Get(srv.URL + "/status")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() { _ = resp.Body.Close() }()
+		var body map[string]map[string]int
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		if body["counts"]["routers"] != 2 {
+			t.Errorf("Unexpected body %v", body)
+		}
+	})
+
+	t.Run("single section", func(t *testing.T) {
+		resp, err := http.Get(srv.URL + "/status/counts")
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer func() { _ = resp.Body.Close() }()
+		var body map[string]int
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		if body["routers"] != 2 {
+			t.Errorf("Unexpected body %v", body)
+		}
+	})
+
+	t.Run("unknown section", func(t *testing.T) {
+		resp, err := http.Get(srv.URL + "/status/nope")
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode != http.StatusNotFound {
+			t.Errorf("Expected 404, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("healthz", func(t *testing.T) {
+		resp, err := http.Get(srv.URL + "/healthz")
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Errorf("Expected 200, got %d", resp.StatusCode)
+		}
+	})
+
+	t.Run("metrics", func(t *testing.T) {
+		resp, err := http.Get(srv.URL + "/metrics")
+		if err != nil {
+			t.Fatal(err)
+		}
+		_ = resp.Body.Close()
+		if resp.StatusCode != http.StatusOK || !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/plain") {
+			t.Errorf("Expected 200 text/plain, got %d %s", resp.StatusCode, resp.Header.Get("Content-Type"))
+		}
+	})
+}
+
+func TestServerActions(t *testing.T) {
+	s := NewServer()
+	calls := 0
+	s.RegisterAction("approve", func(r *http.Request) (interface{}, error) {
+		if r.URL.Query().Get("plan") != "abc" {
+			return nil, errors.New("plan changed")
+		}
+		calls++
+		return map[string]int{"calls": calls}, nil
+	})
+
+	srv := httptest.NewServer(s.Handler())
+	defer srv.Close()
+
+	tests := []struct {
+		name     string
+		method   string
+		path     string
+		expected int
+	}{
+		{"success", http.MethodPost, "/actions/approve?plan=abc", http.StatusOK},
+		{"action error", http.MethodPost, "/actions/approve?plan=old", http.StatusConflict},
+		{"GET not allowed", http.MethodGet, "/actions/approve?plan=abc", http.StatusMethodNotAllowed},
+		{"unknown action", http.MethodPost, "/actions/nope", http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(tt.method, srv.URL+tt.path, nil)
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_ = resp.Body.Close()
+			if resp.StatusCode != tt.expected {
+				t.Errorf("Expected %d, got %d", tt.expected, resp.StatusCode)
+			}
+		})
+	}
+	if calls != 1 {
+		t.Errorf("Expected the action to run once, got %d", calls)
+	}
+}
+
+func TestServerActionAuth(t *testing.T) {
+	s := NewServer()
+	s.RegisterAction("sync", func(r *http.Request) (interface{}, error) { return "ok", nil })
+
+	post := func(remote, auth string) int {
+		req := httptest.NewRequest(http.MethodPost, "/actions/sync", nil)
+		req.RemoteAddr = remote
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		rec := httptest.NewRecorder()
+		s.Handler().ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	tests := []struct {
+		name     string
+		token    string
+		remote   string
+		auth     string
+		expected int
+	}{
+		{"loopback without token", "", "127.0.0.1:4000", "", http.StatusOK},
+		{"IPv6 loopback without token", "", "[::1]:4000", "", http.StatusOK},
+		{"LAN client without token", "", "192.0.2.10:4000", "", http.StatusForbidden},
+		{"token required", "s3cret", "127.0.0.1:4000", "", http.StatusUnauthorized},
+		{"wrong token", "s3cret", "192.0.2.10:4000", "Bearer nope", http.StatusUnauthorized},
+		{"right token", "s3cret", "192.0.2.10:4000", "Bearer s3cret", http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s.RequireActionToken(tt.token)
+			if got := post(tt.remote, tt.auth); got != tt.expected {
+				t.Errorf("Expected %d, got %d", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestServerEndpoints(t *testing.T) {
+	s := NewServer()
+	s.RegisterEndpoint("/api/v1/events", func() interface{} { return []string{"a", "b"} })
+
+	srv := httptest.NewServer(s.Handler())
+	defer srv.Close()
+
+	resp, err := http.Get(srv.URL + "/api/v1/events")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	var got []string
+	if err := json.NewDecoder(resp.Body).Decode(&got); err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusOK || len(got) != 2 || got[0] != "a" {
+		t.Errorf("Expected the endpoint's JSON, got %d %v", resp.StatusCode, got)
+	}
+
+	resp, err = http.Get(srv.URL + "/api/v1/nope")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected 404 for an unregistered endpoint, got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,70 @@
+package unifi
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/logger"
+)
+
+// approvalQueue holds the plan waiting for operator approval in manual approval mode.
+type approvalQueue struct {
+	mu       sync.Mutex
+	pending  *Plan
+	approved string
+}
+
+// admit reports whether plan may be applied: it must match the plan the operator
+// approved. Any other plan replaces the pending one and waits for approval.
+func (q *approvalQueue) admit(plan Plan) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.approved != "" && q.approved == plan.ID {
+		q.pending = nil
+		q.approved = ""
+		return true
+	}
+	if q.pending != nil && q.pending.ID == plan.ID {
+		return false
+	}
+	q.pending = &plan
+	q.approved = ""
+	logger.Info("UniFi: route changes awaiting approval (plan %s): %s", plan.ID, plan.summary())
+	return false
+}
+
+// clear drops the pending plan once there is nothing left to change.
+func (q *approvalQueue) clear() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.pending = nil
+	q.approved = ""
+}
+
+// approve marks the pending plan with the given ID for the next sync.
+func (q *approvalQueue) approve(id string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.pending == nil {
+		return errors.New("no route changes are pending")
+	}
+	if id != q.pending.ID {
+		return fmt.Errorf("plan %s is no longer pending, review plan %s", id, q.pending.ID)
+	}
+	q.approved = id
+	logger.Info("UniFi: plan %s approved, applying on the next sync", id)
+	return nil
+}
+
+// plan returns a copy of the pending plan, or nil.
+func (q *approvalQueue) plan() *Plan {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.pending == nil {
+		return nil
+	}
+	p := *q.pending
+	p.Changes = append([]Change(nil), p.Changes...)
+	return &p
+}
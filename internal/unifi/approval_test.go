@@ -0,0 +1,56 @@
+package unifi
+
+import "testing"
+
+func TestNewPlanID(t *testing.T) {
+	a := StaticRoute{Name: "Thread route via Router1", StaticRouteNetwork: "fd00:1::/64", StaticRouteNexthop: "2001:4860:4860:1234::1"}
+	b := StaticRoute{Name: "Thread route via Router2", StaticRouteNetwork: "fd00:2::/64", StaticRouteNexthop: "2001:4860:4860:1234::2"}
+
+	p1 := newPlan(nil, []StaticRoute{a}, []StaticRoute{b})
+	p2 := newPlan(nil, []StaticRoute{a}, []StaticRoute{b})
+	if p1.ID != p2.ID {
+		t.Errorf("Expected identical changes to share an ID, got %s and %s", p1.ID, p2.ID)
+	}
+	if p3 := newPlan(nil, nil, []StaticRoute{a, b}); p3.ID == p1.ID {
+		t.Errorf("Expected different changes to get a different ID")
+	}
+	if len(p1.Changes) != 2 || p1.Changes[0].Action != "remove" || p1.Changes[1].Action != "add" {
+		t.Errorf("Unexpected changes %+v", p1.Changes)
+	}
+}
+
+func TestApprovalQueue(t *testing.T) {
+	route := StaticRoute{Name: "Thread route via Router1", StaticRouteNetwork: "fd00:1::/64", StaticRouteNexthop: "2001:4860:4860:1234::1"}
+	plan := newPlan(nil, nil, []StaticRoute{route})
+	q := &approvalQueue{}
+
+	if err := q.approve(plan.ID); err == nil {
+		t.Errorf("Expected an error approving with nothing pending")
+	}
+	if q.admit(plan) {
+		t.Fatalf("Expected an unapproved plan to be held")
+	}
+	if pending := q.plan(); pending == nil || pending.ID != plan.ID {
+		t.Fatalf("Expected plan %s to be pending, got %+v", plan.ID, pending)
+	}
+	if err := q.approve("stale"); err == nil {
+		t.Errorf("Expected an error approving a stale plan")
+	}
+	if err := q.approve(plan.ID); err != nil {
+		t.Fatalf("Approve failed: %v", err)
+	}
+
+	changed := newPlan(nil, []StaticRoute{route}, nil)
+	if q.admit(changed) {
+		t.Errorf("Expected a plan that changed after approval to be held")
+	}
+	if err := q.approve(changed.ID); err != nil {
+		t.Fatalf("Approve failed: %v", err)
+	}
+	if !q.admit(changed) {
+		t.Errorf("Expected the approved plan to be admitted")
+	}
+	if q.plan() != nil {
+		t.Errorf("Expected no pending plan after it was admitted")
+	}
+}
@@ -0,0 +1,122 @@
+package unifi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/logger"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/routes"
+)
+
+// Backup is a snapshot of the controller's static routes, taken before the
+// daemon changes them for the first time.
+type Backup struct {
+	Created    time.Time     `json:"created"`
+	Controller string        `json:"controller"`
+	Routes     []StaticRoute `json:"routes"`
+}
+
+// backupRoutes writes every static route of the controller, of any address
+// family, to path, unless a backup already exists there: only the table as it
+// was before the daemon's first change is kept. An empty path disables it.
+func (c *Client) backupRoutes(path string) error {
+	if path == "" {
+		return nil
+	}
+	if _, err := os.Stat(path); err == nil {
+		return nil
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	all, err := c.Routes()
+	if err != nil {
+		return fmt.Errorf("listing routes: %w", err)
+	}
+	backup := Backup{Created: time.Now().UTC(), Controller: c.cfg.APIBaseURL, Routes: []StaticRoute{}}
+	for _, r := range all {
+		if r.IsStatic() {
+			backup.Routes = append(backup.Routes, r)
+		}
+	}
+	data, err := json.MarshalIndent(backup, "", "  ")
+	if err != nil {
+		return err
+	}
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return err
+		}
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, append(data, '\n'), 0o600); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		return err
+	}
+	logger.Info("UniFi: backed up %d static routes to %s before the first change", len(backup.Routes), path)
+	return nil
+}
+
+// LoadBackup reads a route backup written before the daemon's first change.
+func LoadBackup(path string) (Backup, error) {
+	var backup Backup
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return backup, err
+	}
+	if err := json.Unmarshal(data, &backup); err != nil {
+		return backup, fmt.Errorf("%s: %w", path, err)
+	}
+	return backup, nil
+}
+
+// RestoreResult lists the routes RestoreBackup recreated, or would recreate in
+// a dry run, and the ones it failed to recreate.
+type RestoreResult struct {
+	Routes   []StaticRoute
+	Failures []error
+}
+
+// RestoreBackup recreates the backed-up static routes missing from the
+// controller, matched by network and next hop. It never deletes or changes
+// existing routes. With dryRun it only lists them. An error is returned when
+// the controller cannot be reached or listed.
+func (c *Client) RestoreBackup(backup Backup, dryRun bool) (RestoreResult, error) {
+	var result RestoreResult
+	if err := c.Login(); err != nil {
+		return result, fmt.Errorf("login: %w", err)
+	}
+	current, err := c.Routes()
+	if err != nil {
+		return result, fmt.Errorf("listing routes: %w", err)
+	}
+	present := make(map[string]bool, len(current))
+	for _, r := range current {
+		if r.IsStatic() {
+			present[routes.Key(r.StaticRouteNetwork, r.StaticRouteNexthop)] = true
+		}
+	}
+	for _, r := range backup.Routes {
+		if present[routes.Key(r.StaticRouteNetwork, r.StaticRouteNexthop)] {
+			continue
+		}
+		if !dryRun {
+			r.ID = ""
+			if err := c.AddStaticRoute(r); err != nil {
+				result.Failures = append(result.Failures, fmt.Errorf("route %s via %s: %w", r.StaticRouteNetwork, r.StaticRouteNexthop, err))
+				continue
+			}
+		}
+		result.Routes = append(result.Routes, r)
+	}
+	return result, nil
+}
+
+// errNoBackup is returned when no route backup could be taken, so the sync
+// refuses to change routes.
+var errNoBackup = errors.New("no route backup, refusing to change routes (set ROUTE_BACKUP_FILE=off to skip it)")
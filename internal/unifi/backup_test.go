@@ -0,0 +1,105 @@
+package unifi
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// backupHandler serves a routing table with a static IPv6 route, a static
+// IPv4 route and an interface entry, recording the routes added.
+func backupHandler(t *testing.T, added *[]StaticRoute) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/auth/login":
+			_, _ = w.Write([]byte(`{"meta":{"rc":"ok"}}`))
+		case r.URL.Path == "/proxy/network/api/s/default/rest/routing" && r.Method == "GET":
+			_, _ = w.Write([]byte(`{"meta":{"rc":"ok"},"data":[` +
+				`{"_id":"r1","name":"Office","type":"static-route","static-route_network":"fd00:9::/64","static-route_nexthop":"2001:4860::9"},` +
+				`{"_id":"r2","name":"Lab","type":"static-route","static-route_network":"10.9.0.0/16","static-route_nexthop":"192.168.1.9"},` +
+				`{"_id":"i1","name":"LAN","type":"interface-route","static-route_network":"192.168.1.0/24"}]}`))
+		case r.URL.Path == "/proxy/network/api/s/default/rest/routing" && r.Method == "POST":
+			var route StaticRoute
+			_ = json.NewDecoder(r.Body).Decode(&route)
+			*added = append(*added, route)
+			_, _ = w.Write([]byte(`{"meta":{"rc":"ok"}}`))
+		default:
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+}
+
+func TestBackupRoutes(t *testing.T) {
+	client := newLegacyTestClient(t, backupHandler(t, nil))
+	path := filepath.Join(t.TempDir(), "backup", "routes.json")
+
+	if err := client.backupRoutes(path); err != nil {
+		t.Fatalf("backupRoutes failed: %v", err)
+	}
+	backup, err := LoadBackup(path)
+	if err != nil {
+		t.Fatalf("LoadBackup failed: %v", err)
+	}
+	if len(backup.Routes) != 2 || backup.Routes[0].ID != "r1" || backup.Routes[1].ID != "r2" || backup.Created.IsZero() {
+		t.Errorf("Expected both static routes backed up, got %+v", backup)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"routes":[]}`), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := client.backupRoutes(path); err != nil {
+		t.Fatalf("backupRoutes failed: %v", err)
+	}
+	if backup, _ := LoadBackup(path); len(backup.Routes) != 0 {
+		t.Errorf("Expected an existing backup kept, got %+v", backup)
+	}
+}
+
+func TestRestoreBackup(t *testing.T) {
+	var added []StaticRoute
+	client := newLegacyTestClient(t, backupHandler(t, &added))
+	backup := Backup{Routes: []StaticRoute{
+		{ID: "r1", Name: "Office", Type: RouteTypeStatic, StaticRouteNetwork: "fd00:9:0::/64", StaticRouteNexthop: "2001:4860:0::9"},
+		{ID: "r3", Name: "Garage", Type: RouteTypeStatic, StaticRouteNetwork: "fd00:3::/64", StaticRouteNexthop: "2001:4860::3"},
+	}}
+
+	result, err := client.RestoreBackup(backup, true)
+	if err != nil {
+		t.Fatalf("RestoreBackup failed: %v", err)
+	}
+	if len(result.Routes) != 1 || result.Routes[0].Name != "Garage" || len(added) != 0 {
+		t.Errorf("Expected only Garage listed in a dry run, got %+v, added %+v", result.Routes, added)
+	}
+
+	result, err = client.RestoreBackup(backup, false)
+	if err != nil {
+		t.Fatalf("RestoreBackup failed: %v", err)
+	}
+	if len(result.Routes) != 1 || len(added) != 1 || added[0].Name != "Garage" || added[0].ID != "" {
+		t.Errorf("Expected Garage recreated without its old ID, got %+v", added)
+	}
+}
+
+// TestSyncerBackupRequired verifies only a backup configured explicitly holds
+// route changes back when it cannot be written.
+func TestSyncerBackupRequired(t *testing.T) {
+	client := newLegacyTestClient(t, backupHandler(t, nil))
+	blocker := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(blocker, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	client.cfg.BackupFile = filepath.Join(blocker, "route-backup.json") // under a file, so unwritable
+	s := &Syncer{client: client}
+
+	if err := s.backup(); err != nil {
+		t.Errorf("Expected the default backup to be best effort, got %v", err)
+	}
+	client.cfg.BackupRequired = true
+	if err := s.backup(); !errors.Is(err, errNoBackup) {
+		t.Errorf("Expected a configured backup to be required, got %v", err)
+	}
+}
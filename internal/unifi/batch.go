@@ -0,0 +1,103 @@
+package unifi
+
+import "sync"
+
+// defaultWorkers bounds the number of concurrent controller API calls per sync.
+const defaultWorkers = 4
+
+// outcome is the result of a single route operation.
+type outcome int
+
+const (
+	outcomeAdded outcome = iota
+	outcomeUpdated
+	outcomeRemoved
+	outcomeRejected
+	outcomeFailed
+	outcomeSkipped // not attempted: the controller became unavailable
+)
+
+// batchResult aggregates the outcomes of one sync cycle's route operations.
+type batchResult struct {
+	added, updated, removed, rejected, failed, skipped int
+}
+
+func (b *batchResult) record(o outcome) {
+	switch o {
+	case outcomeAdded:
+		b.added++
+	case outcomeUpdated:
+		b.updated++
+	case outcomeRemoved:
+		b.removed++
+	case outcomeRejected:
+		b.rejected++
+	case outcomeFailed:
+		b.failed++
+	case outcomeSkipped:
+		b.skipped++
+	}
+}
+
+func (b *batchResult) merge(other batchResult) {
+	b.added += other.added
+	b.updated += other.updated
+	b.removed += other.removed
+	b.rejected += other.rejected
+	b.failed += other.failed
+	b.skipped += other.skipped
+}
+
+// applyHalt records the first unavailable answer the controller gives during
+// one sync's route operations. The sync then pauses once for it, and the
+// operations not started yet are skipped instead of each meeting the same
+// answer. A nil applyHalt never halts.
+type applyHalt struct {
+	mu  sync.Mutex
+	err error
+}
+
+// halt records err unless an earlier operation already did.
+func (h *applyHalt) halt(err error) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.err == nil {
+		h.err = err
+	}
+}
+
+// halted returns the unavailable answer that stopped the operations, or nil.
+func (h *applyHalt) halted() error {
+	if h == nil {
+		return nil
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.err
+}
+
+// runPool runs jobs on at most workers goroutines and waits for all of them.
+func runPool(workers int, jobs []func()) {
+	if workers < 1 {
+		workers = 1
+	}
+	queue := make(chan func())
+	var wg sync.WaitGroup
+	for i := 0; i < workers && i < len(jobs); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for job := range queue {
+				job()
+			}
+		}()
+	}
+	for _, job := range jobs {
+		queue <- job
+	}
+	close(queue)
+	wg.Wait()
+}
@@ -0,0 +1,52 @@
+package unifi
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRunPool(t *testing.T) {
+	var running, peak, done int32
+	var mu sync.Mutex
+	jobs := make([]func(), 10)
+	for i := range jobs {
+		jobs[i] = func() {
+			n := atomic.AddInt32(&running, 1)
+			mu.Lock()
+			if n > peak {
+				peak = n
+			}
+			mu.Unlock()
+			time.Sleep(5 * time.Millisecond)
+			atomic.AddInt32(&running, -1)
+			atomic.AddInt32(&done, 1)
+		}
+	}
+
+	runPool(3, jobs)
+
+	if done != 10 {
+		t.Errorf("Expected 10 jobs run, got %d", done)
+	}
+	if peak > 3 {
+		t.Errorf("Expected at most 3 concurrent jobs, got %d", peak)
+	}
+}
+
+func TestBatchResult(t *testing.T) {
+	var a, b batchResult
+	a.record(outcomeAdded)
+	a.record(outcomeAdded)
+	a.record(outcomeFailed)
+	b.record(outcomeRemoved)
+	b.record(outcomeRejected)
+	a.merge(b)
+
+	a.record(outcomeUpdated)
+	expected := batchResult{added: 2, updated: 1, removed: 1, rejected: 1, failed: 1}
+	if a != expected {
+		t.Errorf("Expected %+v, got %+v", expected, a)
+	}
+}
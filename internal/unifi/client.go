@@ -0,0 +1,216 @@
+// Package unifi talks to the UniFi Network controller API and keeps its static
+// route table in sync with the routes derived from Thread discovery.
+package unifi
+
+import (
+	"context"
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/config"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/logger"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/routes"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/pkg/unifiroutes"
+)
+
+// APIError is returned when the controller answers with an unexpected status.
+type APIError = unifiroutes.APIError
+
+// Client is an authenticated UniFi controller API client. It is safe for
+// concurrent use. Login and the route endpoints go through unifiroutes; this
+// type adds the login budget and the endpoints only the daemon needs.
+type Client struct {
+	cfg    config.UniFi
+	http   *http.Client
+	logins *loginBudget
+	routes *unifiroutes.Client
+}
+
+// NewClient returns a client for the controller described by cfg.
+func NewClient(cfg config.UniFi) *Client {
+	httpClient := createHTTPClient(cfg)
+	return &Client{
+		cfg:    cfg,
+		http:   httpClient,
+		logins: &loginBudget{limits: cfg.Logins},
+		routes: unifiroutes.New(unifiroutes.Config{
+			BaseURL:    cfg.APIBaseURL,
+			Username:   cfg.Username,
+			Password:   cfg.Password,
+			APIVersion: cfg.APIVersion,
+			HTTPClient: httpClient,
+			Logger:     logger.Printf{},
+		}),
+	}
+}
+
+// HasValidSession returns true if the session is present and less than 5 minutes old.
+func (c *Client) HasValidSession() bool {
+	return c.routes.HasSession() && c.routes.SessionAge() < 5*time.Minute
+}
+
+// SessionAge returns how long ago the current session was established.
+func (c *Client) SessionAge() time.Duration {
+	return c.routes.SessionAge()
+}
+
+// ClearSession invalidates the cached session tokens.
+func (c *Client) ClearSession() {
+	c.routes.ClearSession()
+}
+
+// ForgetAPI makes the next request detect the controller version and route
+// API family again.
+func (c *Client) ForgetAPI() {
+	c.routes.ForgetAPI()
+}
+
+// ControllerVersion returns the UniFi Network application version from /stat/sysinfo.
+func (c *Client) ControllerVersion() (string, error) {
+	return c.routes.ControllerVersion(context.Background())
+}
+
+// APIFamily returns the route API in use: "legacy", "v2" or "traffic".
+func (c *Client) APIFamily() string {
+	return c.routes.APIFamily(context.Background())
+}
+
+// Routes retrieves every entry of the router's routing table, whatever its type.
+func (c *Client) Routes() ([]StaticRoute, error) {
+	all, err := c.routes.List(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	out := make([]StaticRoute, len(all))
+	for i, r := range all {
+		out[i] = StaticRoute(r)
+	}
+	return out, nil
+}
+
+// StaticRoutes retrieves the IPv6 static routes from the router, with networks
+// and next hops normalized. Entries of other types or address families are left
+// out so they are never diffed against.
+func (c *Client) StaticRoutes() ([]StaticRoute, error) {
+	all, err := c.Routes()
+	if err != nil {
+		return nil, err
+	}
+	static := make([]StaticRoute, 0, len(all))
+	for _, r := range all {
+		if r.IsStatic() && r.IsIPv6() {
+			r.StaticRouteNetwork = routes.NormalizePrefix(r.StaticRouteNetwork)
+			r.StaticRouteNexthop = routes.NormalizeAddr(r.StaticRouteNexthop)
+			static = append(static, r)
+		}
+	}
+	if skipped := len(all) - len(static); skipped > 0 {
+		logger.Debug("UniFi: ignoring %d routing entries that are not IPv6 static routes", skipped)
+	}
+	return static, nil
+}
+
+// AddStaticRoute adds a new static route to the router
+func (c *Client) AddStaticRoute(route StaticRoute) error {
+	return c.routes.Create(context.Background(), unifiroutes.Route(route))
+}
+
+// UpdateStaticRoute replaces the static route with route.ID by route.
+func (c *Client) UpdateStaticRoute(route StaticRoute) error {
+	return c.routes.Update(context.Background(), unifiroutes.Route(route))
+}
+
+// UsesTrafficRoutes reports whether routes are written as traffic routes
+// rather than static routes.
+func (c *Client) UsesTrafficRoutes() bool {
+	return c.routes.UsesTrafficRoutes(context.Background())
+}
+
+// DeleteStaticRoute deletes a static route from the router
+func (c *Client) DeleteStaticRoute(routeID string) error {
+	return c.routes.Delete(context.Background(), routeID)
+}
+
+// Reachable reports whether the controller answers HTTP at all, with any
+// status, without logging in.
+func (c *Client) Reachable() bool {
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, c.cfg.APIBaseURL, nil)
+	if err != nil {
+		return false
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return false
+	}
+	closeBody(resp)
+	return true
+}
+
+// LoginBudget returns the logins spent and left in the current hour.
+func (c *Client) LoginBudget() LoginBudgetStatus {
+	return c.logins.status(time.Now())
+}
+
+// Login authenticates with the UniFi controller and stores the session token.
+// It returns an ErrLoginBudget error without contacting the controller when
+// another attempt would exceed the login budget.
+func (c *Client) Login() error {
+	now := time.Now()
+	if err := c.logins.take(now); err != nil {
+		return err
+	}
+	err := c.login()
+	c.logins.done(now, err)
+	return err
+}
+
+// login performs one login request.
+func (c *Client) login() error {
+	return c.routes.Login(context.Background())
+}
+
+// applyAuth sets the authentication headers and cookie on a request.
+func (c *Client) applyAuth(req *http.Request) {
+	c.routes.Authorize(req)
+}
+
+// closeBody drains and closes the response body, logging any error.
+func closeBody(resp *http.Response) {
+	if err := resp.Body.Close(); err != nil {
+		logger.Warn("UniFi: failed to close response: %v", err)
+	}
+}
+
+// createHTTPClient creates an HTTP client with appropriate settings, going
+// through a proxy, failing over to the fallback hosts and recording the
+// controller traffic to a debug bundle or replaying it from one when configured.
+func createHTTPClient(cfg config.UniFi) *http.Client {
+	var transport http.RoundTripper = &http.Transport{
+		TLSClientConfig: &tls.Config{InsecureSkipVerify: cfg.InsecureSSL},
+		DialContext:     (&net.Dialer{Timeout: 10 * time.Second}).DialContext,
+		Proxy:           proxyFunc(cfg),
+	}
+	if len(cfg.FallbackHosts) > 0 {
+		hosts := []string{cfg.RouterHostname}
+		if u, err := url.Parse(cfg.APIBaseURL); err == nil && u.Host != "" {
+			hosts[0] = u.Host
+		}
+		transport = &failoverTransport{next: transport, hosts: append(hosts, cfg.FallbackHosts...)}
+	}
+	switch {
+	case cfg.ReplayFile != "":
+		transport = newReplayTransport(cfg.ReplayFile)
+	case cfg.RecordFile != "":
+		logger.Warn("UniFi: recording controller traffic to %s", cfg.RecordFile)
+		transport = &recordingTransport{next: transport, path: cfg.RecordFile}
+	}
+	return &http.Client{
+		Transport: transport,
+		Timeout:   30 * time.Second,
+	}
+}
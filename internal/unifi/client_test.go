@@ -0,0 +1,183 @@
+package unifi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/config"
+)
+
+// newTestClient returns a client pointed at a test server running handler.
+func newTestClient(t *testing.T, handler http.Handler) *Client {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return NewClient(config.UniFi{
+		APIBaseURL: srv.URL,
+		Username:   "tester",
+		Password:   "secret",
+	})
+}
+
+// newLegacyTestClient returns a test client driving the legacy route API
+// without detecting the controller version.
+func newLegacyTestClient(t *testing.T, handler http.Handler) *Client {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return NewClient(config.UniFi{
+		APIBaseURL: srv.URL,
+		APIVersion: "v1",
+		Username:   "tester",
+		Password:   "secret",
+	})
+}
+
+// TestCreateHTTPClient tests the HTTP client creation with different configurations
+func TestCreateHTTPClient(t *testing.T) {
+	tests := []struct {
+		name           string
+		config         config.UniFi
+		expectInsecure bool
+	}{
+		{
+			name: "Secure SSL configuration",
+			config: config.UniFi{
+				InsecureSSL: false,
+			},
+			expectInsecure: false,
+		},
+		{
+			name: "Insecure SSL configuration",
+			config: config.UniFi{
+				InsecureSSL: true,
+			},
+			expectInsecure: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := createHTTPClient(tt.config)
+
+			// Check that client is not nil
+			if client == nil {
+				t.Fatal("Expected HTTP client to be created, got nil")
+			}
+
+			// Check timeout is set
+			if client.Timeout != 30*time.Second {
+				t.Errorf("Expected timeout to be 30s, got %v", client.Timeout)
+			}
+
+			// Check transport is configured
+			transport, ok := client.Transport.(*http.Transport)
+			if !ok {
+				t.Fatal("Expected *http.Transport to be configured")
+			}
+			if transport.TLSClientConfig.InsecureSkipVerify != tt.expectInsecure {
+				t.Errorf("Expected InsecureSkipVerify %v, got %v", tt.expectInsecure, transport.TLSClientConfig.InsecureSkipVerify)
+			}
+		})
+	}
+}
+
+// TestLogin tests session establishment against a fake controller
+func TestLogin(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/auth/login" {
+			t.Errorf("Unexpected path %s", r.URL.Path)
+		}
+		var req struct{ Username, Password string }
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Username != "tester" || req.Password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("X-CSRF-Token", "csrf123")
+		http.SetCookie(w, &http.Cookie{Name: "TOKEN", Value: "tok456"})
+		_, _ = w.Write([]byte(`{"username":"tester"}`))
+	}))
+
+	if client.HasValidSession() {
+		t.Fatal("Expected no session before login")
+	}
+	if err := client.Login(); err != nil {
+		t.Fatalf("Login failed: %v", err)
+	}
+	if !client.HasValidSession() {
+		t.Error("Expected valid session after login")
+	}
+	client.ClearSession()
+	if client.HasValidSession() {
+		t.Error("Expected session to be cleared")
+	}
+}
+
+// TestStaticRoutes tests route listing, type filtering and authentication headers
+func TestStaticRoutes(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/auth/login":
+			w.Header().Set("X-CSRF-Token", "csrf123")
+			http.SetCookie(w, &http.Cookie{Name: "TOKEN", Value: "tok456"})
+			_, _ = w.Write([]byte(`{"meta":{"rc":"ok"}}`))
+		case "/proxy/network/api/s/default/rest/routing":
+			if r.Header.Get("X-CSRF-Token") != "csrf123" || r.Header.Get("Authorization") != "Bearer tok456" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_, _ = w.Write([]byte(`{"meta":{"rc":"ok"},"data":[` +
+				`{"_id":"r1","name":"Thread route via Router1","type":"static-route","static-route_network":"fd00::/64","static-route_nexthop":"2001:4860::1"},` +
+				`{"_id":"r2","name":"LAN","type":"interface-route","static-route_network":"fd01::/64"},` +
+				`{"_id":"r3","name":"Legacy v4","type":"static-route","static-route_network":"10.0.0.0/24","static-route_nexthop":"192.168.1.1"}]}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	if _, err := client.StaticRoutes(); err == nil {
+		t.Error("Expected error without a session")
+	}
+	if err := client.Login(); err != nil {
+		t.Fatalf("Login failed: %v", err)
+	}
+	current, err := client.StaticRoutes()
+	if err != nil {
+		t.Fatalf("StaticRoutes failed: %v", err)
+	}
+	if len(current) != 1 || current[0].ID != "r1" || current[0].StaticRouteNetwork != "fd00::/64" {
+		t.Errorf("Unexpected routes: %+v", current)
+	}
+}
+
+// TestAddAndDeleteStaticRoute tests route creation and deletion requests
+func TestAddAndDeleteStaticRoute(t *testing.T) {
+	var added StaticRoute
+	var deletedPath string
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			_ = json.NewDecoder(r.Body).Decode(&added)
+		case http.MethodDelete:
+			deletedPath = r.URL.Path
+		}
+		_, _ = w.Write([]byte(`{"meta":{"rc":"ok"}}`))
+	}))
+
+	route := StaticRoute{Name: "Thread route via Router1", StaticRouteNetwork: "fd00::/64", StaticRouteNexthop: "2001:4860::1"}
+	if err := client.AddStaticRoute(route); err != nil {
+		t.Fatalf("AddStaticRoute failed: %v", err)
+	}
+	if added.StaticRouteNetwork != route.StaticRouteNetwork || added.StaticRouteNexthop != route.StaticRouteNexthop {
+		t.Errorf("Unexpected payload: %+v", added)
+	}
+	if err := client.DeleteStaticRoute("r1"); err != nil {
+		t.Fatalf("DeleteStaticRoute failed: %v", err)
+	}
+	if deletedPath != "/proxy/network/api/s/default/rest/routing/r1" {
+		t.Errorf("Unexpected delete path %s", deletedPath)
+	}
+}
@@ -0,0 +1,171 @@
+package unifi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"strings"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/discovery"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/pkg/threaddiscovery"
+)
+
+// NetworkClient is a client station the controller sees, from /stat/sta.
+type NetworkClient struct {
+	MAC        string   `json:"mac"`
+	Hostname   string   `json:"hostname,omitempty"`
+	Name       string   `json:"name,omitempty"`
+	IP         string   `json:"ip,omitempty"`
+	IPv6Addrs  []string `json:"ipv6_addresses,omitempty"`
+	IsWired    bool     `json:"is_wired"`
+	SwitchMAC  string   `json:"sw_mac,omitempty"`  // switch a wired client is plugged into
+	SwitchPort int      `json:"sw_port,omitempty"` // port on that switch
+	APMAC      string   `json:"ap_mac,omitempty"`  // access point a Wi-Fi client is associated with
+	ESSID      string   `json:"essid,omitempty"`   // Wi-Fi network name
+	Network    string   `json:"network,omitempty"` // controller network (LAN, VLAN) the client is on
+	NetworkID  string   `json:"network_id,omitempty"`
+	VLAN       int      `json:"vlan,omitempty"` // VLAN ID of that network, 0 for untagged
+	Counters            // traffic the controller counted for the client
+}
+
+// Counters are the traffic counters of a client, as /stat/sta reports them.
+type Counters struct {
+	RxBytes   float64 `json:"rx_bytes"`
+	TxBytes   float64 `json:"tx_bytes"`
+	RxPackets float64 `json:"rx_packets"`
+	TxPackets float64 `json:"tx_packets"`
+}
+
+// UnmarshalJSON decodes a client, tolerating numbers and booleans sent as strings.
+func (n *NetworkClient) UnmarshalJSON(data []byte) error {
+	type plain NetworkClient
+	aux := struct {
+		*plain
+		IsWired    flexBool    `json:"is_wired"`
+		SwitchPort flexInt     `json:"sw_port"`
+		VLAN       flexInt     `json:"vlan"`
+		RxBytes    flexCounter `json:"rx_bytes"`
+		TxBytes    flexCounter `json:"tx_bytes"`
+		RxPackets  flexCounter `json:"rx_packets"`
+		TxPackets  flexCounter `json:"tx_packets"`
+	}{plain: (*plain)(n)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	n.IsWired, n.SwitchPort, n.VLAN = bool(aux.IsWired), int(aux.SwitchPort), int(aux.VLAN)
+	n.Counters = Counters{float64(aux.RxBytes), float64(aux.TxBytes), float64(aux.RxPackets), float64(aux.TxPackets)}
+	n.MAC, n.SwitchMAC, n.APMAC = strings.ToLower(n.MAC), strings.ToLower(n.SwitchMAC), strings.ToLower(n.APMAC)
+	return nil
+}
+
+// NetworkClients retrieves the connected clients from /stat/sta.
+func (c *Client) NetworkClients() ([]NetworkClient, error) {
+	url := fmt.Sprintf("%s/proxy/network/api/s/default/stat/sta", c.cfg.APIBaseURL)
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	c.applyAuth(req)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &APIError{StatusCode: resp.StatusCode, Body: resp.Status}
+	}
+	var result struct {
+		Data []NetworkClient `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	return result.Data, nil
+}
+
+// MatchClient returns the client that is the border router: the one holding
+// one of its addresses, else the one whose MAC its EUI-64 addresses embed, else
+// the one named like it.
+func MatchClient(router discovery.BorderRouter, clients []NetworkClient) (NetworkClient, bool) {
+	return matchClient(clients, "", router.IPv6Addrs, router.Name)
+}
+
+// matchClient returns the client with hardware address mac or holding one of
+// addrs, else the one whose MAC an EUI-64 address of addrs embeds, else the
+// one named or with a host name like one of names.
+func matchClient(clients []NetworkClient, mac string, addrs []netip.Addr, names ...string) (NetworkClient, bool) {
+	for _, c := range clients {
+		if mac != "" && c.MAC == strings.ToLower(mac) {
+			return c, true
+		}
+		for _, s := range c.IPv6Addrs {
+			ip, err := netip.ParseAddr(s)
+			if err != nil {
+				continue
+			}
+			for _, addr := range addrs {
+				if ip == addr {
+					return c, true
+				}
+			}
+		}
+	}
+	for _, addr := range addrs {
+		eui := threaddiscovery.MACFromEUI64(addr)
+		if eui == nil {
+			continue
+		}
+		for _, c := range clients {
+			if c.MAC == eui.String() {
+				return c, true
+			}
+		}
+	}
+	for _, name := range names {
+		name = clientName(name)
+		if name == "" {
+			continue
+		}
+		for _, c := range clients {
+			if clientName(c.Name) == name || clientName(c.Hostname) == name {
+				return c, true
+			}
+		}
+	}
+	return NetworkClient{}, false
+}
+
+// clientName normalizes a device name for matching, so "Living Room" matches
+// the hostname "living-room".
+func clientName(name string) string {
+	name, _, _ = strings.Cut(name, ".")
+	return strings.ToLower(strings.NewReplacer(" ", "-", "_", "-").Replace(strings.TrimSpace(name)))
+}
+
+// RouterUplinks returns where each border router the controller knows as a
+// client attaches to the LAN, by router name: wired or on Wi-Fi, its network
+// and VLAN, and whether, by networks, the gateway routes IPv6 there. Unknown
+// routers are left out.
+func RouterUplinks(routers []discovery.BorderRouter, clients []NetworkClient, networks []NetworkConf) map[string]discovery.Uplink {
+	uplinks := make(map[string]discovery.Uplink)
+	for _, r := range routers {
+		c, ok := MatchClient(r, clients)
+		if !ok {
+			continue
+		}
+		uplink := discovery.Uplink{Link: discovery.LinkWireless, Network: c.Network, VLAN: c.VLAN}
+		if c.IsWired {
+			uplink.Link = discovery.LinkWired
+		}
+		if n, ok := findNetwork(networks, c); ok {
+			uplink.Network, uplink.VLAN = n.Name, n.VLANID()
+			uplink.Unreachable = !n.RoutesIPv6()
+		}
+		uplinks[r.Name] = uplink
+	}
+	return uplinks
+}
@@ -0,0 +1,69 @@
+package unifi
+
+import (
+	"net/http"
+	"net/netip"
+	"testing"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/discovery"
+)
+
+// TestNetworkClients verifies /stat/sta is decoded, tolerating loose types.
+func TestNetworkClients(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/proxy/network/api/s/default/stat/sta" {
+			t.Errorf("Expected /stat/sta, got %s", r.URL.Path)
+		}
+		_, _ = w.Write([]byte(`{"meta":{"rc":"ok"},"data":[
+			{"mac":"AA:BB:CC:DD:EE:01","hostname":"Living-Room","is_wired":true,"ipv6_addresses":["2a02:8109::1"],"rx_bytes":5368709120,"tx_packets":"42"},
+			{"mac":"aa:bb:cc:dd:ee:02","name":"Bedroom","is_wired":"false"}]}`))
+	}))
+
+	clients, err := client.NetworkClients()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(clients) != 2 || clients[0].MAC != "aa:bb:cc:dd:ee:01" || !clients[0].IsWired || clients[1].IsWired {
+		t.Errorf("Expected a wired and a wireless client, got %+v", clients)
+	}
+	if clients[0].RxBytes != 5368709120 || clients[0].TxPackets != 42 {
+		t.Errorf("Expected the traffic counters decoded, got %+v", clients[0].Counters)
+	}
+}
+
+// TestRouterUplinks verifies border routers match clients by address, by
+// EUI-64 MAC and by name, take their network from the controller's networks
+// and unmatched routers are left out.
+func TestRouterUplinks(t *testing.T) {
+	clients := []NetworkClient{
+		{MAC: "aa:bb:cc:dd:ee:01", IsWired: true, NetworkID: "n2", IPv6Addrs: []string{"2a02:8109::1", "bogus"}},
+		{MAC: "02:11:22:33:44:55", IsWired: false},
+		{MAC: "aa:bb:cc:dd:ee:03", Hostname: "Bedroom-HomePod", IsWired: false, Network: "Default"},
+	}
+	routers := []discovery.BorderRouter{
+		{Name: "Living Room", IPv6Addrs: []netip.Addr{netip.MustParseAddr("2a02:8109::1")}},
+		{Name: "Kitchen", IPv6Addrs: []netip.Addr{netip.MustParseAddr("fe80::11:22ff:fe33:4455")}},
+		{Name: "Bedroom HomePod"},
+		{Name: "Office", IPv6Addrs: []netip.Addr{netip.MustParseAddr("2a02:8109::9")}},
+	}
+
+	networks := []NetworkConf{
+		{ID: "n1", Name: "Default", Purpose: "corporate"},
+		{ID: "n2", Name: "Cameras", Purpose: "vlan-only", VLANEnabled: true, VLAN: 40},
+	}
+
+	uplinks := RouterUplinks(routers, clients, networks)
+	want := map[string]discovery.Uplink{
+		"Living Room":     {Link: discovery.LinkWired, Network: "Cameras", VLAN: 40, Unreachable: true},
+		"Kitchen":         {Link: discovery.LinkWireless},
+		"Bedroom HomePod": {Link: discovery.LinkWireless, Network: "Default"},
+	}
+	if len(uplinks) != len(want) {
+		t.Errorf("Expected %v, got %v", want, uplinks)
+	}
+	for name, uplink := range want {
+		if uplinks[name] != uplink {
+			t.Errorf("Expected %s to be %+v, got %+v", name, uplink, uplinks[name])
+		}
+	}
+}
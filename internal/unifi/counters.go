@@ -0,0 +1,88 @@
+package unifi
+
+import (
+	"net/netip"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/discovery"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/routes"
+)
+
+// RouteTraffic is the traffic the controller counted for the border router a
+// route goes through. The controller has no per-route counters, so these are
+// the counters of the router's client entry: all of its traffic, of which the
+// route's is part.
+type RouteTraffic struct {
+	Network string `json:"network"`
+	Nexthop string `json:"nexthop"`
+	Router  string `json:"router,omitempty"`
+	MAC     string `json:"mac,omitempty"` // client entry the counters are from; empty when none matched
+	Counters
+	// Idle is set when the counters did not change between the last two
+	// client list reads: the route may be a blackhole.
+	Idle      bool      `json:"idle"`
+	IdleSince time.Time `json:"idle_since,omitzero"`
+}
+
+// noteCounters records which clients' counters changed since the previous
+// read. t.mu must be held.
+func (t *ClientTable) noteCounters(clients []NetworkClient, now time.Time) {
+	previous := make(map[string]Counters, len(t.clients))
+	for _, c := range t.clients {
+		previous[c.MAC] = c.Counters
+	}
+	changed := make(map[string]time.Time, len(clients))
+	for _, c := range clients {
+		if last, ok := t.changed[c.MAC]; ok && previous[c.MAC] == c.Counters {
+			changed[c.MAC] = last
+			continue
+		}
+		changed[c.MAC] = now
+	}
+	t.changed = changed
+}
+
+// RouteTraffic returns the counters of the border router of each route, in
+// the order of rs. Routes whose router no client entry matches are listed
+// without counters.
+func (t *ClientTable) RouteTraffic(rs []routes.Route, routers []discovery.BorderRouter) []RouteTraffic {
+	t.mu.Lock()
+	clients, readAt := t.clients, t.readAt
+	changed := t.changed
+	t.mu.Unlock()
+
+	byName := make(map[string]discovery.BorderRouter, len(routers))
+	for _, r := range routers {
+		byName[r.Name] = r
+	}
+	out := make([]RouteTraffic, 0, len(rs))
+	for _, r := range rs {
+		traffic := RouteTraffic{Network: r.CIDR.String(), Nexthop: r.ThreadRouterIPv6.String(), Router: r.RouterName}
+		addrs := append([]netip.Addr{r.ThreadRouterIPv6}, byName[r.RouterName].IPv6Addrs...)
+		if c, ok := matchClient(clients, "", addrs, r.RouterName); ok {
+			traffic.MAC = c.MAC
+			traffic.Counters = c.Counters
+			if last := changed[c.MAC]; last.Before(readAt) {
+				traffic.Idle, traffic.IdleSince = true, last
+			}
+		}
+		out = append(out, traffic)
+	}
+	return out
+}
+
+// TrafficMetric returns a counter function reporting value of the counters
+// of each border router client the routes rs returns go through, labelled by
+// the client's MAC address. Routes through the same router share its
+// counters, so each router is reported once.
+func (t *ClientTable) TrafficMetric(rs func() ([]routes.Route, []discovery.BorderRouter), value func(Counters) float64) func() map[string]float64 {
+	return func() map[string]float64 {
+		out := make(map[string]float64)
+		for _, traffic := range t.RouteTraffic(rs()) {
+			if traffic.MAC != "" {
+				out[traffic.MAC] = value(traffic.Counters)
+			}
+		}
+		return out
+	}
+}
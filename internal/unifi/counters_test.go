@@ -0,0 +1,50 @@
+package unifi
+
+import (
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/discovery"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/routes"
+)
+
+func TestRouteTraffic(t *testing.T) {
+	router := discovery.BorderRouter{Name: "HomePod", IPv6Addrs: []netip.Addr{netip.MustParseAddr("2001:db8::10")}}
+	rs := []routes.Route{
+		{CIDR: netip.MustParsePrefix("fd00:1::/64"), ThreadRouterIPv6: netip.MustParseAddr("2001:db8::10"), RouterName: "HomePod"},
+		{CIDR: netip.MustParsePrefix("fd00:2::/64"), ThreadRouterIPv6: netip.MustParseAddr("2001:db8::99"), RouterName: "Gone"},
+	}
+	table := &ClientTable{}
+	read := func(at time.Time, counters Counters) {
+		clients := []NetworkClient{{MAC: "aa:bb:cc:dd:ee:ff", IPv6Addrs: []string{"2001:db8::10"}, Counters: counters}}
+		table.noteCounters(clients, at)
+		table.clients, table.readAt = clients, at
+	}
+	start := time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC)
+
+	read(start, Counters{RxBytes: 100, TxBytes: 200, RxPackets: 1, TxPackets: 2})
+	got := table.RouteTraffic(rs, []discovery.BorderRouter{router})
+	if len(got) != 2 || got[0].MAC != "aa:bb:cc:dd:ee:ff" || got[0].RxBytes != 100 || got[0].TxPackets != 2 || got[0].Idle {
+		t.Errorf("Expected the router's counters, not idle, got %+v", got)
+	}
+	if got[1].MAC != "" || got[1].Idle {
+		t.Errorf("Expected no counters for an unmatched router, got %+v", got[1])
+	}
+
+	read(start.Add(time.Minute), Counters{RxBytes: 100, TxBytes: 200, RxPackets: 1, TxPackets: 2})
+	if got := table.RouteTraffic(rs, nil); !got[0].Idle || !got[0].IdleSince.Equal(start) {
+		t.Errorf("Expected the route idle since %v, got %+v", start, got[0])
+	}
+
+	read(start.Add(2*time.Minute), Counters{RxBytes: 150, TxBytes: 200, RxPackets: 2, TxPackets: 2})
+	if got := table.RouteTraffic(rs, nil); got[0].Idle {
+		t.Errorf("Expected the route active again, got %+v", got[0])
+	}
+
+	metric := table.TrafficMetric(func() ([]routes.Route, []discovery.BorderRouter) { return rs, nil },
+		func(c Counters) float64 { return c.RxBytes })()
+	if len(metric) != 1 || metric["aa:bb:cc:dd:ee:ff"] != 150 {
+		t.Errorf("Expected the matched router's counter by MAC in the metric, got %v", metric)
+	}
+}
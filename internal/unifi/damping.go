@@ -0,0 +1,143 @@
+package unifi
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/config"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/logger"
+)
+
+// flapPenalty is the penalty added each time a route is added or removed.
+const flapPenalty = 1000
+
+// DampedRoute is a route tracked by flap damping.
+type DampedRoute struct {
+	Network    string    `json:"network"`
+	Nexthop    string    `json:"nexthop"`
+	Flaps      int       `json:"flaps"`
+	Penalty    int       `json:"penalty"`
+	Suppressed bool      `json:"suppressed"`
+	LastFlap   time.Time `json:"last_flap"`
+}
+
+// flapDamper tracks how often each route is added and removed. Penalties decay
+// exponentially and are capped so that no route stays suppressed longer than
+// MaxSuppress after its last flap.
+type flapDamper struct {
+	mu      sync.Mutex
+	cfg     config.RouteDamping
+	ceiling float64
+	entries map[string]*dampedEntry
+	now     func() time.Time
+}
+
+type dampedEntry struct {
+	DampedRoute
+	penalty float64
+	updated time.Time
+}
+
+func newFlapDamper(cfg config.RouteDamping) *flapDamper {
+	return &flapDamper{
+		cfg:     cfg,
+		ceiling: float64(cfg.Reuse) * math.Pow(2, float64(cfg.MaxSuppress)/float64(cfg.HalfLife)),
+		entries: make(map[string]*dampedEntry),
+		now:     time.Now,
+	}
+}
+
+// decay brings e's penalty up to date and lifts its suppression once the
+// penalty has fallen below the reuse threshold. d.mu must be held.
+func (d *flapDamper) decay(e *dampedEntry) {
+	now := d.now()
+	e.penalty *= math.Pow(0.5, float64(now.Sub(e.updated))/float64(d.cfg.HalfLife))
+	e.updated = now
+	e.Penalty = int(e.penalty)
+	if e.Suppressed && e.penalty < float64(d.cfg.Reuse) {
+		e.Suppressed = false
+		logger.Info("UniFi: route %s -> %s stopped flapping, no longer suppressed", e.Network, e.Nexthop)
+	}
+}
+
+// reset forgets every route's flaps, lifting all suppressions.
+func (d *flapDamper) reset() {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.entries = make(map[string]*dampedEntry)
+}
+
+// flap records that the route with the given key was added or removed.
+func (d *flapDamper) flap(key string, route StaticRoute) {
+	if d == nil {
+		return
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	e, ok := d.entries[key]
+	if !ok {
+		e = &dampedEntry{DampedRoute: DampedRoute{Network: route.StaticRouteNetwork, Nexthop: route.StaticRouteNexthop}}
+		d.entries[key] = e
+	}
+	d.decay(e)
+	e.penalty = math.Min(e.penalty+flapPenalty, d.ceiling)
+	e.Penalty = int(e.penalty)
+	e.Flaps++
+	e.LastFlap = e.updated
+	if !e.Suppressed && e.penalty > float64(d.cfg.Suppress) {
+		e.Suppressed = true
+		logger.Warn("UniFi: route %s -> %s is flapping (%d changes), suppressing it until it settles",
+			e.Network, e.Nexthop, e.Flaps)
+	}
+}
+
+// suppressedKeys returns the keys of the damped routes.
+func (d *flapDamper) suppressedKeys() map[string]bool {
+	out := make(map[string]bool)
+	for key, e := range d.update() {
+		if e.Suppressed {
+			out[key] = true
+		}
+	}
+	return out
+}
+
+// list returns the tracked routes ordered by network and next hop.
+func (d *flapDamper) list() []DampedRoute {
+	out := []DampedRoute{}
+	for _, e := range d.update() {
+		out = append(out, e)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Network != out[j].Network {
+			return out[i].Network < out[j].Network
+		}
+		return out[i].Nexthop < out[j].Nexthop
+	})
+	return out
+}
+
+// update decays every penalty, forgets the routes whose penalty has decayed
+// away and returns the rest by key.
+func (d *flapDamper) update() map[string]DampedRoute {
+	out := make(map[string]DampedRoute)
+	if d == nil {
+		return out
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for key, e := range d.entries {
+		d.decay(e)
+		if !e.Suppressed && e.penalty < 1 {
+			delete(d.entries, key)
+			continue
+		}
+		out[key] = e.DampedRoute
+	}
+	return out
+}
@@ -0,0 +1,99 @@
+package unifi
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/config"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/state"
+)
+
+func TestFlapDamper(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	d := newFlapDamper(config.RouteDamping{Enabled: true, HalfLife: 15 * time.Minute,
+		Suppress: 2000, Reuse: 750, MaxSuppress: time.Hour})
+	d.now = func() time.Time { return now }
+	route := StaticRoute{StaticRouteNetwork: "fd00::/64", StaticRouteNexthop: "2001:4860::1"}
+
+	d.flap("k", route)
+	d.flap("k", route)
+	if d.suppressedKeys()["k"] {
+		t.Error("Expected route not suppressed at the threshold")
+	}
+	d.flap("k", route)
+	if !d.suppressedKeys()["k"] {
+		t.Error("Expected route suppressed after 3 quick flaps")
+	}
+
+	now = now.Add(45 * time.Minute) // 3000 decays to 375
+	if d.suppressedKeys()["k"] {
+		t.Error("Expected route reused once its penalty decayed below reuse")
+	}
+	listed := d.list()
+	if len(listed) != 1 || listed[0].Flaps != 3 || listed[0].Penalty != 375 {
+		t.Errorf("Expected 3 flaps with penalty 375, got %+v", listed)
+	}
+
+	for i := 0; i < 50; i++ {
+		d.flap("k", route)
+	}
+	now = now.Add(time.Hour + time.Minute)
+	if d.suppressedKeys()["k"] {
+		t.Error("Expected suppression to end after the maximum suppression time")
+	}
+
+	now = now.Add(24 * time.Hour)
+	if got := d.list(); len(got) != 0 {
+		t.Errorf("Expected decayed route forgotten, got %+v", got)
+	}
+}
+
+func TestSkipDamped(t *testing.T) {
+	s := &Syncer{}
+	ok := StaticRoute{StaticRouteNetwork: "fd00::/64", StaticRouteNexthop: "2001:4860::2"}
+	if out, damped := s.skipDamped([]StaticRoute{ok}); len(out) != 1 || len(damped) != 0 {
+		t.Errorf("Expected nothing damped with damping disabled, got %+v %+v", out, damped)
+	}
+
+	now := time.Now()
+	s.damping = newFlapDamper(config.RouteDamping{HalfLife: time.Hour, Suppress: 1500, Reuse: 750, MaxSuppress: 2 * time.Hour})
+	s.damping.now = func() time.Time { return now }
+	flapping := StaticRoute{StaticRouteNetwork: "fd00::/64", StaticRouteNexthop: "2001:4860::1"}
+	s.damping.flap("fd00::/64->2001:4860::1", flapping)
+	s.damping.flap("fd00::/64->2001:4860::1", flapping)
+
+	out, damped := s.skipDamped([]StaticRoute{flapping, ok})
+	if len(out) != 1 || out[0] != ok || len(damped) != 1 || damped[0] != flapping {
+		t.Errorf("Expected only the flapping route held back, got %+v %+v", out, damped)
+	}
+	if got := s.DampedRoutes(); len(got) != 1 || !got[0].Suppressed {
+		t.Errorf("Expected the suppressed route listed, got %+v", got)
+	}
+}
+
+// TestDropCaches verifies dropping the caches lifts flap damping and makes
+// the next request detect the controller version again.
+func TestDropCaches(t *testing.T) {
+	versions := 0
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/proxy/network/api/s/default/stat/sysinfo" {
+			versions++
+			_, _ = w.Write([]byte(`{"meta":{"rc":"ok"},"data":[{"version":"8.6.9"}]}`))
+		}
+	}))
+	client.cfg.Damping = config.RouteDamping{Enabled: true, HalfLife: 15 * time.Minute,
+		Suppress: 2000, Reuse: 750, MaxSuppress: time.Hour}
+	s := NewSyncer(client, state.New(nil), nil, config.GracePolicy{Default: time.Minute})
+	s.damping.flap("k", StaticRoute{StaticRouteNetwork: "fd00::/64", StaticRouteNexthop: "2001:4860::1"})
+	client.UsesTrafficRoutes()
+
+	s.DropCaches()
+	if routes := s.DampedRoutes(); len(routes) != 0 {
+		t.Errorf("Expected the damping penalties dropped, got %+v", routes)
+	}
+	client.UsesTrafficRoutes()
+	if versions != 2 {
+		t.Errorf("Expected the controller version detected again, got %d detections", versions)
+	}
+}
@@ -0,0 +1,51 @@
+package unifi
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/pkg/unifiroutes"
+)
+
+// codeDestinationNetworkExisted is the controller's answer to a route whose
+// network and distance collide with an existing route.
+const codeDestinationNetworkExisted = "api.err.DestinationNetworkExisted"
+
+// ControllerError is the structured form of a controller error payload.
+type ControllerError = unifiroutes.ControllerError
+
+// controllerError returns the structured controller error wrapped in err.
+func controllerError(err error) (ControllerError, bool) {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return ControllerError{}, false
+	}
+	return apiErr.Controller()
+}
+
+// hasCode reports whether err carries the controller error code.
+func hasCode(err error, code string) bool {
+	ce, ok := controllerError(err)
+	return ok && ce.Code == code
+}
+
+// isDuplicate reports whether the controller refused to create an object
+// because one with the same name or identity already exists. Distance
+// collisions, which a different distance resolves, are not duplicates.
+func isDuplicate(err error) bool {
+	ce, ok := controllerError(err)
+	if !ok || ce.Code == codeDestinationNetworkExisted {
+		return false
+	}
+	code := strings.TrimPrefix(ce.Code, "api.err.")
+	return strings.Contains(code, "Existed") || strings.Contains(code, "Exists") || strings.Contains(code, "Duplicate")
+}
+
+// rejectionReason describes a controller rejection, preferring the structured
+// controller error over the raw response.
+func rejectionReason(err error) string {
+	if ce, ok := controllerError(err); ok {
+		return ce.String()
+	}
+	return err.Error()
+}
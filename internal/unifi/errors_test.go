@@ -0,0 +1,105 @@
+package unifi
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/routes"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/state"
+)
+
+func TestIsDuplicate(t *testing.T) {
+	apiErr := func(code string) error {
+		return fmt.Errorf("wrapped: %w", &APIError{StatusCode: 400, Body: `{"meta":{"rc":"error","msg":"` + code + `"}}`})
+	}
+
+	tests := []struct {
+		err      error
+		expected bool
+	}{
+		{apiErr("api.err.NameExisted"), true},
+		{apiErr("api.err.DuplicateName"), true},
+		{apiErr(codeDestinationNetworkExisted), false}, // resolved by another distance
+		{apiErr("api.err.InvalidPayload"), false},
+		{errors.New("api.err.NameExisted"), false},
+	}
+
+	for _, tt := range tests {
+		if got := isDuplicate(tt.err); got != tt.expected {
+			t.Errorf("isDuplicate(%v) = %v, want %v", tt.err, got, tt.expected)
+		}
+	}
+	if !hasCode(apiErr(codeDestinationNetworkExisted), codeDestinationNetworkExisted) {
+		t.Error("Expected the distance collision code to be found")
+	}
+}
+
+func TestAddRouteAdoptsDuplicate(t *testing.T) {
+	network, nexthop := "fd00:1::/64", "2001:db8::1"
+	route := StaticRoute{Enabled: true, Name: RouteName("Apple TV", network, nexthop), Type: RouteTypeStatic,
+		StaticRouteNetwork: network, StaticRouteNexthop: nexthop, StaticRouteDistance: 1, GatewayDevice: "aa:bb:cc:dd:ee:ff"}
+
+	tests := []struct {
+		name     string
+		existing StaticRoute
+		expected outcome
+		updated  bool
+	}{
+		{"identical route", StaticRoute{ID: "r1", Enabled: true, Name: route.Name, Type: RouteTypeStatic,
+			StaticRouteNetwork: network, StaticRouteNexthop: nexthop, StaticRouteDistance: 3, GatewayDevice: "aa:bb:cc:dd:ee:ff"},
+			outcomeAdded, false},
+		{"disabled copy", StaticRoute{ID: "r1", Enabled: false, Name: route.Name, Type: RouteTypeStatic,
+			StaticRouteNetwork: network, StaticRouteNexthop: nexthop, StaticRouteDistance: 3},
+			outcomeAdded, true},
+		{"name taken by a user route", StaticRoute{ID: "r1", Enabled: true, Name: route.Name, Type: RouteTypeStatic,
+			StaticRouteNetwork: "fd00:9::/64", StaticRouteNexthop: nexthop},
+			outcomeRejected, false},
+		{"same route owned by the user", StaticRoute{ID: "r1", Enabled: true, Name: "Office", Type: RouteTypeStatic,
+			StaticRouteNetwork: network, StaticRouteNexthop: nexthop},
+			outcomeRejected, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var updated *StaticRoute
+			client := newLegacyTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.Method {
+				case http.MethodGet:
+					_ = json.NewEncoder(w).Encode(map[string]interface{}{"meta": map[string]string{"rc": "ok"}, "data": []StaticRoute{tt.existing}})
+				case http.MethodPost:
+					w.WriteHeader(http.StatusBadRequest)
+					_, _ = w.Write([]byte(`{"meta":{"rc":"error","msg":"api.err.NameExisted"},"data":[]}`))
+				case http.MethodPut:
+					updated = &StaticRoute{}
+					_ = json.NewDecoder(r.Body).Decode(updated)
+					_, _ = w.Write([]byte(`{"meta":{"rc":"ok"}}`))
+				}
+			}))
+			st := state.New(nil)
+			s := &Syncer{client: client, state: st, rejections: newRejectionCache(time.Minute, time.Hour)}
+
+			var mu sync.Mutex
+			got := s.addRoute(route, newDistanceAllocator(nil, nil), &mu, nil)
+			if got != tt.expected {
+				t.Errorf("Expected outcome %d, got %d", tt.expected, got)
+			}
+			if (updated != nil) != tt.updated {
+				t.Fatalf("Expected update %v, got %+v", tt.updated, updated)
+			}
+			if updated != nil && (updated.ID != "r1" || !updated.Enabled || updated.StaticRouteDistance != 3 ||
+				updated.GatewayDevice != "aa:bb:cc:dd:ee:ff") {
+				t.Errorf("Expected the existing route updated to the desired spec, got %+v", updated)
+			}
+			key := routes.Key(network, nexthop)
+			_, rejected := s.rejections.blocked(key)
+			if rejected != (tt.expected == outcomeRejected) {
+				t.Errorf("Expected rejected %v, got %v", tt.expected == outcomeRejected, rejected)
+			}
+		})
+	}
+}
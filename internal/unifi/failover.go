@@ -0,0 +1,94 @@
+package unifi
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/logger"
+)
+
+// failoverTransport sends each request to the controller endpoint that last
+// answered, trying the others in order when it cannot be reached, so syncing
+// goes on when, say, unifi.local stops resolving but the LAN address still
+// works. Only transport errors fail over; any HTTP answer counts as reached.
+// Requests that are not idempotent, such as a login or a route creation, only
+// fail over while the endpoint could not be connected to, so one the
+// controller may have applied is never sent twice.
+type failoverTransport struct {
+	next  http.RoundTripper
+	hosts []string // host[:port] of each endpoint, the configured one first
+
+	mu     sync.Mutex
+	active int // index into hosts of the endpoint that last answered
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *failoverTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.mu.Lock()
+	start := t.active
+	t.mu.Unlock()
+
+	var lastErr error
+	for i := range t.hosts {
+		idx := (start + i) % len(t.hosts)
+		r := req.Clone(req.Context())
+		r.URL.Host = t.hosts[idx]
+		r.Host = ""
+		if i > 0 && req.Body != nil {
+			if req.GetBody == nil {
+				break
+			}
+			body, err := req.GetBody()
+			if err != nil {
+				break
+			}
+			r.Body = body
+		}
+		resp, err := t.next.RoundTrip(r)
+		if err == nil {
+			if idx != start {
+				t.switchTo(idx)
+			}
+			return resp, nil
+		}
+		lastErr = err
+		if req.Context().Err() != nil {
+			break
+		}
+		logger.Debug("UniFi: controller endpoint %s failed: %v", t.hosts[idx], err)
+		if !idempotent(req.Method) && !isDialError(err) {
+			break
+		}
+	}
+	return nil, lastErr
+}
+
+// idempotent reports whether sending a request with method twice has the
+// same effect as sending it once (RFC 9110, section 9.2.2).
+func idempotent(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return false
+}
+
+// isDialError reports whether err is a failure to connect, such as a name that
+// does not resolve or a refused connection, before anything was sent.
+func isDialError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+// switchTo makes hosts[idx] the endpoint requests go to first.
+func (t *failoverTransport) switchTo(idx int) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.active == idx {
+		return
+	}
+	logger.Warn("UniFi: controller unreachable at %s, switched to %s", t.hosts[t.active], t.hosts[idx])
+	t.active = idx
+}
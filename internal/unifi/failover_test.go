@@ -0,0 +1,125 @@
+package unifi
+
+import (
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/config"
+)
+
+// hostTransport answers for up hosts, loses the connection after sending the
+// request to lost hosts and fails to connect to the others, recording the host
+// and body of every request.
+type hostTransport struct {
+	up     map[string]bool
+	lost   map[string]bool
+	hosts  []string
+	bodies []string
+}
+
+func (t *hostTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	t.hosts = append(t.hosts, req.URL.Host)
+	if req.Body != nil {
+		data, _ := io.ReadAll(req.Body)
+		t.bodies = append(t.bodies, string(data))
+	}
+	if t.lost[req.URL.Host] {
+		return nil, io.ErrUnexpectedEOF
+	}
+	if !t.up[req.URL.Host] {
+		return nil, &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("no such host")}
+	}
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("ok"))}, nil
+}
+
+func TestFailoverTransport(t *testing.T) {
+	next := &hostTransport{up: map[string]bool{"192.168.1.1": true, "10.8.0.1": true}}
+	transport := &failoverTransport{next: next, hosts: []string{"unifi.local", "192.168.1.1", "10.8.0.1"}}
+
+	req, _ := http.NewRequest(http.MethodPost, "https://unifi.local/api/auth/login", strings.NewReader(`{"username":"u"}`))
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("Expected the fallback host to answer, got %v", err)
+	}
+	_ = resp.Body.Close()
+	if strings.Join(next.hosts, ",") != "unifi.local,192.168.1.1" {
+		t.Errorf("Expected the hosts tried in order, got %v", next.hosts)
+	}
+	if len(next.bodies) != 2 || next.bodies[1] != `{"username":"u"}` {
+		t.Errorf("Expected the body sent again to the fallback host, got %q", next.bodies)
+	}
+	if req.URL.Host != "unifi.local" {
+		t.Errorf("Expected the original request left untouched, got host %s", req.URL.Host)
+	}
+
+	next.hosts = nil
+	req, _ = http.NewRequest(http.MethodGet, "https://unifi.local/proxy/network/api/s/default/stat/sysinfo", nil)
+	resp, err = transport.RoundTrip(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = resp.Body.Close()
+	if strings.Join(next.hosts, ",") != "192.168.1.1" {
+		t.Errorf("Expected requests to stick to the working host, got %v", next.hosts)
+	}
+
+	next.up = map[string]bool{}
+	next.hosts = nil
+	if _, err := transport.RoundTrip(req); err == nil {
+		t.Error("Expected an error when no host answers")
+	}
+	if strings.Join(next.hosts, ",") != "192.168.1.1,10.8.0.1,unifi.local" {
+		t.Errorf("Expected every host tried from the sticky one, got %v", next.hosts)
+	}
+}
+
+// TestFailoverTransportSentRequests verifies a request that may have reached
+// the controller is only sent again when it is idempotent.
+func TestFailoverTransportSentRequests(t *testing.T) {
+	tests := []struct {
+		method   string
+		expected string
+	}{
+		{http.MethodPost, "192.168.1.1"},
+		{http.MethodPut, "192.168.1.1,10.8.0.1"},
+		{http.MethodDelete, "192.168.1.1,10.8.0.1"},
+		{http.MethodGet, "192.168.1.1,10.8.0.1"},
+	}
+	for _, tt := range tests {
+		next := &hostTransport{up: map[string]bool{"10.8.0.1": true}, lost: map[string]bool{"192.168.1.1": true}}
+		transport := &failoverTransport{next: next, hosts: []string{"192.168.1.1", "10.8.0.1"}}
+		req, _ := http.NewRequest(tt.method, "https://192.168.1.1/proxy/network/api/s/default/rest/routing", nil)
+		resp, err := transport.RoundTrip(req)
+		if err == nil {
+			_ = resp.Body.Close()
+		}
+		if got := strings.Join(next.hosts, ","); got != tt.expected {
+			t.Errorf("%s: expected hosts %s, got %s (%v)", tt.method, tt.expected, got, err)
+		}
+	}
+}
+
+// TestClientFallbackHosts verifies a client reaches the controller through a
+// fallback host when the configured hostname does not resolve.
+func TestClientFallbackHosts(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"meta":{"rc":"ok"}}`))
+	}))
+	defer srv.Close()
+
+	client := NewClient(config.UniFi{
+		RouterHostname: "unifi.invalid",
+		APIBaseURL:     "http://unifi.invalid",
+		FallbackHosts:  []string{strings.TrimPrefix(srv.URL, "http://")},
+		Username:       "tester",
+		Password:       "secret",
+	})
+	if err := client.Login(); err != nil {
+		t.Errorf("Expected login through the fallback host, got %v", err)
+	}
+}
@@ -0,0 +1,196 @@
+package unifi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/config"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/logger"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/routes"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/pkg/unifiroutes"
+)
+
+// threadFirewallNamePrefix marks the firewall rules managed by this daemon.
+const threadFirewallNamePrefix = "Thread firewall for "
+
+// FirewallRule is a UniFi firewall rule from /rest/firewallrule.
+type FirewallRule struct {
+	ID                 string `json:"_id,omitempty"`
+	Name               string `json:"name"`
+	Enabled            bool   `json:"enabled"`
+	Ruleset            string `json:"ruleset"`
+	RuleIndex          int    `json:"rule_index"`
+	Action             string `json:"action"`
+	ProtocolV6         string `json:"protocol_v6"`
+	SrcNetworkconfType string `json:"src_networkconf_type"`
+	DstNetworkconfType string `json:"dst_networkconf_type"`
+	DstAddress         string `json:"dst_address"`
+	Logging            bool   `json:"logging"`
+	SiteID             string `json:"site_id,omitempty"`
+}
+
+// IsThreadRule reports whether the rule is managed by this daemon.
+func (r FirewallRule) IsThreadRule() bool {
+	return strings.HasPrefix(r.Name, threadFirewallNamePrefix)
+}
+
+// FirewallRules retrieves the controller's firewall rules.
+func (c *Client) FirewallRules() ([]FirewallRule, error) {
+	var rules []FirewallRule
+	err := c.firewallRequest("GET", "", nil, &rules)
+	return rules, err
+}
+
+// AddFirewallRule creates a firewall rule.
+func (c *Client) AddFirewallRule(rule FirewallRule) error {
+	return c.firewallRequest("POST", "", rule, nil)
+}
+
+// DeleteFirewallRule deletes the firewall rule with the given id.
+func (c *Client) DeleteFirewallRule(ruleID string) error {
+	return c.firewallRequest("DELETE", "/"+ruleID, nil, nil)
+}
+
+// firewallRequest sends a request to /rest/firewallrule, decoding the data of
+// the response into out when it is not nil.
+func (c *Client) firewallRequest(method, path string, payload, out interface{}) error {
+	url := fmt.Sprintf("%s/proxy/network/api/s/default/rest/firewallrule%s", c.cfg.APIBaseURL, path)
+
+	var body bytes.Buffer
+	if payload != nil {
+		if err := json.NewEncoder(&body).Encode(payload); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequest(method, url, &body)
+	if err != nil {
+		return err
+	}
+	c.applyAuth(req)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer closeBody(resp)
+
+	data, err := unifiroutes.ReadBody(resp)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return &APIError{StatusCode: resp.StatusCode, Body: string(data)}
+	}
+	if out == nil {
+		return nil
+	}
+	var result struct {
+		Meta struct {
+			RC string `json:"rc"`
+		} `json:"meta"`
+		Data json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return err
+	}
+	if result.Meta.RC != "ok" {
+		return fmt.Errorf("API returned error: %s", result.Meta.RC)
+	}
+	return json.Unmarshal(result.Data, out)
+}
+
+// newFirewallRule returns the rule accepting traffic to a routed Thread network.
+func newFirewallRule(cfg config.Firewall, network string, index int) FirewallRule {
+	return FirewallRule{
+		Name:               threadFirewallNamePrefix + network,
+		Enabled:            true,
+		Ruleset:            cfg.Ruleset,
+		RuleIndex:          index,
+		Action:             "accept",
+		ProtocolV6:         "all",
+		SrcNetworkconfType: "NETv6",
+		DstNetworkconfType: "ADDRv6",
+		DstAddress:         network,
+	}
+}
+
+// syncFirewall keeps one allow rule per network that a managed static route
+// routes. Rules follow the routes on the controller, so a rule is only removed
+// once the last route to its network is, after that route's grace period.
+// Failures are logged and retried on the next sync; they never fail the route sync.
+func (s *Syncer) syncFirewall() {
+	if !s.client.HasValidSession() {
+		return
+	}
+	current, err := s.client.StaticRoutes()
+	if err != nil {
+		logger.Warn("UniFi: firewall sync: reading routes failed: %v", err)
+		return
+	}
+	rules, err := s.client.FirewallRules()
+	if err != nil {
+		logger.Warn("UniFi: firewall sync: reading firewall rules failed: %v", err)
+		return
+	}
+	add, remove := diffFirewall(s.firewall, current, rules)
+	for _, r := range remove {
+		if err := s.client.DeleteFirewallRule(r.ID); err != nil {
+			logger.Warn("UniFi: failed to delete firewall rule %q: %v", r.Name, err)
+			continue
+		}
+		s.logChange("UniFi: deleted firewall rule %q", r.Name)
+	}
+	for _, r := range add {
+		if err := s.client.AddFirewallRule(r); err != nil {
+			logger.Warn("UniFi: failed to add firewall rule %q: %v", r.Name, err)
+			continue
+		}
+		s.logChange("UniFi: added firewall rule %q (%s #%d)", r.Name, r.Ruleset, r.RuleIndex)
+	}
+}
+
+// diffFirewall returns the managed rules to add for routed networks lacking one,
+// at the lowest free indexes of the ruleset from cfg.RuleIndex on, and those to
+// remove because no managed route uses their network any more.
+func diffFirewall(cfg config.Firewall, current []StaticRoute, rules []FirewallRule) (add, remove []FirewallRule) {
+	routed := make(map[string]bool)
+	var networks []string
+	for _, r := range current {
+		network := routes.NormalizePrefix(r.StaticRouteNetwork)
+		if r.IsThreadRoute() && !routed[network] {
+			routed[network] = true
+			networks = append(networks, network)
+		}
+	}
+	covered := make(map[string]bool)
+	used := make(map[int]bool)
+	for _, r := range rules {
+		if r.Ruleset == cfg.Ruleset {
+			used[r.RuleIndex] = true
+		}
+		if !r.IsThreadRule() {
+			continue
+		}
+		network := routes.NormalizePrefix(r.DstAddress)
+		if routed[network] && !covered[network] {
+			covered[network] = true
+			continue
+		}
+		remove = append(remove, r)
+	}
+	index := cfg.RuleIndex
+	for _, network := range networks {
+		if covered[network] {
+			continue
+		}
+		for used[index] {
+			index++
+		}
+		used[index] = true
+		add = append(add, newFirewallRule(cfg, network, index))
+	}
+	return add, remove
+}
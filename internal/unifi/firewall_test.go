@@ -0,0 +1,80 @@
+package unifi
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/config"
+)
+
+func TestDiffFirewall(t *testing.T) {
+	cfg := config.Firewall{Enabled: true, Ruleset: "LANv6_IN", RuleIndex: 2000}
+	current := []StaticRoute{
+		{Name: "Thread route via Router1", StaticRouteNetwork: "fd00:1::/64", StaticRouteNexthop: "2001:4860::1"},
+		{Name: "Thread route via Router2", StaticRouteNetwork: "fd00:1::/64", StaticRouteNexthop: "2001:4860::2"},
+		{Name: "Thread route via Router1", StaticRouteNetwork: "FD00:2::/64", StaticRouteNexthop: "2001:4860::1"},
+		{Name: "Office", StaticRouteNetwork: "fd00:9::/64", StaticRouteNexthop: "2001:4860::9"},
+	}
+	rules := []FirewallRule{
+		{ID: "f1", Name: threadFirewallNamePrefix + "fd00:1::/64", Ruleset: "LANv6_IN", RuleIndex: 2000, DstAddress: "fd00:1::/64"},
+		{ID: "f2", Name: threadFirewallNamePrefix + "fd00:1::/64", Ruleset: "LANv6_IN", RuleIndex: 2002, DstAddress: "fd00:1::/64"},
+		{ID: "f3", Name: threadFirewallNamePrefix + "fd00:3::/64", Ruleset: "LANv6_IN", RuleIndex: 2003, DstAddress: "fd00:3::/64"},
+		{ID: "u1", Name: "Allow printers", Ruleset: "LANv6_IN", RuleIndex: 2001, DstAddress: "fd00:9::/64"},
+	}
+
+	add, remove := diffFirewall(cfg, current, rules)
+	if len(add) != 1 || add[0].DstAddress != "fd00:2::/64" || add[0].RuleIndex != 2004 || add[0].Ruleset != "LANv6_IN" ||
+		add[0].Action != "accept" || !add[0].IsThreadRule() {
+		t.Errorf("Expected an accept rule for fd00:2::/64 at the first free index 2004, got %+v", add)
+	}
+	if len(remove) != 2 || remove[0].ID != "f2" || remove[1].ID != "f3" {
+		t.Errorf("Expected the duplicate and the unrouted rule removed, got %+v", remove)
+	}
+}
+
+func TestSyncFirewall(t *testing.T) {
+	var added []FirewallRule
+	var deleted []string
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/proxy/network/api/s/default/stat/sysinfo":
+			_, _ = w.Write([]byte(`{"data":[{"version":"8.0.7"}]}`))
+		case r.URL.Path == "/api/auth/login":
+			w.Header().Set("X-CSRF-Token", "csrf123")
+			http.SetCookie(w, &http.Cookie{Name: "TOKEN", Value: "tok456"})
+			_, _ = w.Write([]byte(`{"meta":{"rc":"ok"}}`))
+		case r.URL.Path == "/proxy/network/api/s/default/rest/routing":
+			_, _ = w.Write([]byte(`{"meta":{"rc":"ok"},"data":[` +
+				`{"_id":"r1","name":"Thread route via Router1","type":"static-route","static-route_network":"fd00:1::/64","static-route_nexthop":"2001:4860::1"}]}`))
+		case r.URL.Path == "/proxy/network/api/s/default/rest/firewallrule" && r.Method == "GET":
+			_, _ = w.Write([]byte(`{"meta":{"rc":"ok"},"data":[` +
+				`{"_id":"f9","name":"Thread firewall for fd00:9::/64","ruleset":"LANv6_IN","rule_index":2000,"dst_address":"fd00:9::/64"}]}`))
+		case r.URL.Path == "/proxy/network/api/s/default/rest/firewallrule" && r.Method == "POST":
+			var rule FirewallRule
+			if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+				t.Errorf("Invalid firewall rule payload: %v", err)
+			}
+			added = append(added, rule)
+			_, _ = w.Write([]byte(`{"meta":{"rc":"ok"}}`))
+		case r.URL.Path == "/proxy/network/api/s/default/rest/firewallrule/f9" && r.Method == "DELETE":
+			deleted = append(deleted, "f9")
+			_, _ = w.Write([]byte(`{"meta":{"rc":"ok"}}`))
+		default:
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	if err := client.Login(); err != nil {
+		t.Fatalf("Login failed: %v", err)
+	}
+	s := &Syncer{client: client, firewall: config.Firewall{Enabled: true, Ruleset: "LANv6_IN", RuleIndex: 2000}}
+	s.syncFirewall()
+
+	if len(added) != 1 || added[0].DstAddress != "fd00:1::/64" || added[0].RuleIndex != 2001 {
+		t.Errorf("Expected a rule for fd00:1::/64 at index 2001, got %+v", added)
+	}
+	if len(deleted) != 1 {
+		t.Errorf("Expected the stale rule deleted, got %v", deleted)
+	}
+}
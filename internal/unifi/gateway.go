@@ -0,0 +1,220 @@
+package unifi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/config"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/logger"
+)
+
+// gatewayRefreshInterval is how often the gateway devices are re-read from the
+// controller, so routes follow a replaced gateway.
+const gatewayRefreshInterval = time.Hour
+
+// GatewayDevice is a gateway-capable device adopted by the controller.
+type GatewayDevice struct {
+	MAC   string `json:"mac"`
+	Type  string `json:"type"`
+	Model string `json:"model,omitempty"`
+	Name  string `json:"name,omitempty"`
+	State int    `json:"state"` // 1 while connected
+}
+
+// Connected reports whether the controller currently reaches the device.
+func (d GatewayDevice) Connected() bool {
+	return d.State == 1
+}
+
+// isGatewayType reports whether a /stat/device type is a gateway: UniFi OS
+// consoles ("udm"), Security Gateways ("ugw") and Next-Gen Gateways ("uxg").
+func isGatewayType(t string) bool {
+	return t == "udm" || t == "ugw" || t == "uxg"
+}
+
+// GatewayDevices retrieves the gateway devices from /stat/device.
+func (c *Client) GatewayDevices() ([]GatewayDevice, error) {
+	url := fmt.Sprintf("%s/proxy/network/api/s/default/stat/device", c.cfg.APIBaseURL)
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	c.applyAuth(req)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("device list failed with status %d", resp.StatusCode)
+	}
+	var result struct {
+		Data []GatewayDevice `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	var gateways []GatewayDevice
+	for _, d := range result.Data {
+		if isGatewayType(d.Type) && d.MAC != "" {
+			d.MAC = strings.ToLower(d.MAC)
+			gateways = append(gateways, d)
+		}
+	}
+	if len(gateways) == 0 {
+		return nil, fmt.Errorf("no gateway device found in /stat/device response")
+	}
+	return gateways, nil
+}
+
+// GatewayStatus lists the controller's gateway devices and the one routes
+// are programmed on when no rule or configured device applies.
+type GatewayStatus struct {
+	Active    string          `json:"active,omitempty"`
+	Devices   []GatewayDevice `json:"devices"`
+	CheckedAt time.Time       `json:"checked_at,omitempty"`
+}
+
+// gatewayPicker picks the gateway device of each route: the first matching
+// rule, else the configured device, else the auto-detected active gateway. A
+// rule or configured device the controller no longer knows, say after the
+// hardware was replaced, falls back to the active gateway.
+type gatewayPicker struct {
+	configured string
+	rules      []config.GatewayRule
+
+	mu        sync.Mutex
+	devices   []GatewayDevice
+	checkedAt time.Time
+	active    string
+	missing   map[string]bool // unknown devices already warned about
+}
+
+func newGatewayPicker(cfg config.UniFi) *gatewayPicker {
+	return &gatewayPicker{
+		configured: strings.ToLower(cfg.GatewayDevice),
+		rules:      cfg.GatewayRules,
+		missing:    make(map[string]bool),
+	}
+}
+
+// refresh re-reads the gateway devices when they are stale or were never read
+// and re-selects the active gateway. current are the controller's routes: while
+// the device list is unavailable, the device of the managed routes is kept.
+func (g *gatewayPicker) refresh(client *Client, current []StaticRoute, now time.Time) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.checkedAt.IsZero() || now.Sub(g.checkedAt) >= gatewayRefreshInterval {
+		devices, err := client.GatewayDevices()
+		if err != nil {
+			logger.Warn("UniFi: could not read gateway devices: %v", err)
+		} else {
+			g.devices = devices
+			g.missing = make(map[string]bool)
+		}
+		g.checkedAt = now
+	}
+
+	inUse := make(map[string]int)
+	for _, r := range current {
+		if r.IsThreadRoute() && r.GatewayDevice != "" {
+			inUse[strings.ToLower(r.GatewayDevice)]++
+		}
+	}
+	active := selectActiveGateway(g.devices, inUse, g.active)
+	if active != g.active {
+		if g.active != "" {
+			logger.Info("UniFi: gateway device %s is gone, programming routes on %s", g.active, active)
+		} else {
+			logger.Debug("UniFi: using gateway device %s", active)
+		}
+		g.active = active
+	}
+}
+
+// selectActiveGateway keeps the previous active gateway while it is still
+// connected, else prefers the connected gateway carrying most managed routes,
+// else the first connected one. Without a device list it falls back to the
+// device most managed routes use.
+func selectActiveGateway(devices []GatewayDevice, inUse map[string]int, previous string) string {
+	var candidates []GatewayDevice
+	for _, d := range devices {
+		if d.Connected() {
+			candidates = append(candidates, d)
+		}
+	}
+	if len(candidates) == 0 {
+		candidates = devices
+	}
+	if len(candidates) == 0 {
+		best := previous
+		for mac, n := range inUse {
+			if best == "" || n > inUse[best] || (n == inUse[best] && mac < best) {
+				best = mac
+			}
+		}
+		return best
+	}
+	best := ""
+	for _, d := range candidates {
+		if d.MAC == previous {
+			return previous
+		}
+		if best == "" || inUse[d.MAC] > inUse[best] {
+			best = d.MAC
+		}
+	}
+	return best
+}
+
+// forNetwork returns the gateway device for routes to network.
+func (g *gatewayPicker) forNetwork(network string) string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if mac, ok := config.GatewayFor(g.rules, network); ok && g.known(mac) {
+		return mac
+	}
+	if g.configured != "" && g.known(g.configured) {
+		return g.configured
+	}
+	return g.active
+}
+
+// known reports whether the controller has the gateway device mac, warning once
+// about devices it lacks. Any device is accepted while the list is unavailable.
+func (g *gatewayPicker) known(mac string) bool {
+	if len(g.devices) == 0 {
+		return true
+	}
+	for _, d := range g.devices {
+		if d.MAC == mac {
+			return true
+		}
+	}
+	if !g.missing[mac] {
+		g.missing[mac] = true
+		logger.Warn("UniFi: gateway device %s is not known to the controller, ignoring it", mac)
+	}
+	return false
+}
+
+// invalidate makes the next refresh re-read the gateway devices.
+func (g *gatewayPicker) invalidate() {
+	g.mu.Lock()
+	g.checkedAt = time.Time{}
+	g.mu.Unlock()
+}
+
+// status returns the gateway devices and the active one.
+func (g *gatewayPicker) status() GatewayStatus {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	return GatewayStatus{Active: g.active, Devices: append([]GatewayDevice{}, g.devices...), CheckedAt: g.checkedAt}
+}
@@ -0,0 +1,106 @@
+package unifi
+
+import (
+	"net/http"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/config"
+)
+
+// TestGatewayDevices verifies only consoles are listed, with a state sent as a
+// string decoded too.
+func TestGatewayDevices(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"meta":{"rc":"ok"},"data":[` +
+			`{"type":"uap","mac":"aa:bb:cc:00:00:01","state":1},` +
+			`{"type":"udm","mac":"AA:BB:CC:00:00:02","model":"UDMPRO","state":"1"},` +
+			`{"type":"udm","mac":"aa:bb:cc:00:00:03","model":"UDMPRO","state":0}]}`))
+	}))
+
+	devices, err := client.GatewayDevices()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if len(devices) != 2 || devices[0].MAC != "aa:bb:cc:00:00:02" || !devices[0].Connected() || devices[1].Connected() {
+		t.Errorf("Expected the two consoles with lower-case MACs, got %+v", devices)
+	}
+}
+
+func TestSelectActiveGateway(t *testing.T) {
+	pair := []GatewayDevice{
+		{MAC: "aa:bb:cc:00:00:01", Type: "udm", State: 1},
+		{MAC: "aa:bb:cc:00:00:02", Type: "udm", State: 1},
+		{MAC: "aa:bb:cc:00:00:03", Type: "udm", State: 0},
+	}
+
+	tests := []struct {
+		name     string
+		devices  []GatewayDevice
+		inUse    map[string]int
+		previous string
+		expected string
+	}{
+		{"first connected", pair, nil, "", "aa:bb:cc:00:00:01"},
+		{"keeps previous", pair, map[string]int{"aa:bb:cc:00:00:01": 3}, "aa:bb:cc:00:00:02", "aa:bb:cc:00:00:02"},
+		{"prefers device in use", pair, map[string]int{"aa:bb:cc:00:00:02": 3}, "", "aa:bb:cc:00:00:02"},
+		{"replaced hardware", pair, map[string]int{"aa:bb:cc:00:00:09": 3}, "aa:bb:cc:00:00:09", "aa:bb:cc:00:00:01"},
+		{"disconnected previous", pair, nil, "aa:bb:cc:00:00:03", "aa:bb:cc:00:00:01"},
+		{"none connected", pair[2:], nil, "", "aa:bb:cc:00:00:03"},
+		{"no device list", nil, map[string]int{"aa:bb:cc:00:00:09": 1}, "", "aa:bb:cc:00:00:09"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := selectActiveGateway(tt.devices, tt.inUse, tt.previous); got != tt.expected {
+				t.Errorf("Expected %s, got %s", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestGatewayPicker(t *testing.T) {
+	devices := `{"type":"udm","mac":"aa:bb:cc:00:00:01","state":1},{"type":"udm","mac":"aa:bb:cc:00:00:02","state":1}`
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"meta":{"rc":"ok"},"data":[` + devices + `]}`))
+	}))
+	g := newGatewayPicker(config.UniFi{
+		GatewayDevice: "AA:BB:CC:00:00:02",
+		GatewayRules: []config.GatewayRule{
+			{Network: netip.MustParsePrefix("fd00:1::/48"), Device: "aa:bb:cc:00:00:01"},
+			{Network: netip.MustParsePrefix("fd00:2::/48"), Device: "aa:bb:cc:00:00:09"},
+		},
+	})
+
+	now := time.Now()
+	g.refresh(client, nil, now)
+	tests := []struct {
+		network  string
+		expected string
+	}{
+		{"fd00:1:0:1::/64", "aa:bb:cc:00:00:01"}, // rule
+		{"fd00:2:0:1::/64", "aa:bb:cc:00:00:02"}, // rule device unknown, configured device
+		{"fd00:3::/64", "aa:bb:cc:00:00:02"},     // configured device
+	}
+	for _, tt := range tests {
+		if got := g.forNetwork(tt.network); got != tt.expected {
+			t.Errorf("forNetwork(%s) = %s, want %s", tt.network, got, tt.expected)
+		}
+	}
+
+	// The configured console is replaced; routes move to the active gateway
+	// once the device list is refreshed.
+	devices = `{"type":"udm","mac":"aa:bb:cc:00:00:01","state":1},{"type":"udm","mac":"aa:bb:cc:00:00:07","state":1}`
+	g.refresh(client, nil, now.Add(time.Minute))
+	if got := g.forNetwork("fd00:3::/64"); got != "aa:bb:cc:00:00:02" {
+		t.Errorf("Expected the device list cached until the refresh interval, got %s", got)
+	}
+	g.refresh(client, nil, now.Add(gatewayRefreshInterval))
+	if got := g.forNetwork("fd00:3::/64"); got != "aa:bb:cc:00:00:01" {
+		t.Errorf("Expected the active gateway after the replacement, got %s", got)
+	}
+	if st := g.status(); st.Active != "aa:bb:cc:00:00:01" || len(st.Devices) != 2 {
+		t.Errorf("Unexpected status %+v", st)
+	}
+}
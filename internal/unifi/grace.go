@@ -0,0 +1,133 @@
+package unifi
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/config"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/logger"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/routes"
+)
+
+// GraceTimer is the removal countdown of a managed route that is no longer
+// detected.
+type GraceTimer struct {
+	Name        string    `json:"name"`
+	Network     string    `json:"network"`
+	Nexthop     string    `json:"nexthop"`
+	LastSeen    time.Time `json:"last_seen"`
+	GracePeriod string    `json:"grace_period"`
+	RemovesAt   time.Time `json:"removes_at"`
+	RemovesIn   string    `json:"removes_in"`
+	// Overdue marks routes whose grace period has passed but whose removal is
+	// held back, by the removal window or the deletions per sync limit.
+	Overdue bool `json:"overdue,omitempty"`
+}
+
+// graceTracker remembers the managed routes the last sync found undetected, so
+// their grace timers can be read between syncs.
+type graceTracker struct {
+	mu     sync.Mutex
+	routes map[string]StaticRoute
+}
+
+func newGraceTracker() *graceTracker {
+	return &graceTracker{routes: make(map[string]StaticRoute)}
+}
+
+// track replaces the tracked routes with the managed routes in current that
+// are not desired.
+func (g *graceTracker) track(current, desired []StaticRoute) {
+	if g == nil {
+		return
+	}
+	wanted := make(map[string]bool, len(desired))
+	for _, r := range desired {
+		wanted[routes.Key(r.StaticRouteNetwork, r.StaticRouteNexthop)] = true
+	}
+	undetected := make(map[string]StaticRoute)
+	for _, r := range current {
+		key := routes.Key(r.StaticRouteNetwork, r.StaticRouteNexthop)
+		if r.IsThreadRoute() && !wanted[key] {
+			undetected[key] = r
+		}
+	}
+	g.mu.Lock()
+	g.routes = undetected
+	g.mu.Unlock()
+}
+
+// forget stops tracking the route with the given key once it is removed or replaced.
+func (g *graceTracker) forget(key string) {
+	if g == nil {
+		return
+	}
+	g.mu.Lock()
+	delete(g.routes, key)
+	g.mu.Unlock()
+}
+
+// timers returns the grace timers of the tracked routes, soonest removal first.
+func (g *graceTracker) timers(lastSeen map[string]time.Time, grace config.GracePolicy, now time.Time) []GraceTimer {
+	if g == nil {
+		return nil
+	}
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	timers := make([]GraceTimer, 0, len(g.routes))
+	for key, r := range g.routes {
+		seen, ok := lastSeen[key]
+		if !ok {
+			continue
+		}
+		period := grace.For(r.StaticRouteNetwork)
+		removesAt := seen.Add(period)
+		remaining := removesAt.Sub(now)
+		if remaining < 0 {
+			remaining = 0
+		}
+		timers = append(timers, GraceTimer{
+			Name:        r.Name,
+			Network:     r.StaticRouteNetwork,
+			Nexthop:     r.StaticRouteNexthop,
+			LastSeen:    seen,
+			GracePeriod: logger.FormatDuration(period),
+			RemovesAt:   removesAt,
+			RemovesIn:   logger.FormatDuration(remaining),
+			Overdue:     !now.Before(removesAt),
+		})
+	}
+	sort.Slice(timers, func(i, j int) bool {
+		if !timers[i].RemovesAt.Equal(timers[j].RemovesAt) {
+			return timers[i].RemovesAt.Before(timers[j].RemovesAt)
+		}
+		return routes.Key(timers[i].Network, timers[i].Nexthop) < routes.Key(timers[j].Network, timers[j].Nexthop)
+	})
+	return timers
+}
+
+// GraceTimers returns the removal countdowns of the managed routes the last
+// sync found undetected, soonest removal first.
+func (s *Syncer) GraceTimers() []GraceTimer {
+	s.settingsMu.RLock()
+	grace := s.grace
+	s.settingsMu.RUnlock()
+	return s.graceTimers.timers(s.state.RouteLastSeen(), s.state.LifetimeGrace(grace), time.Now())
+}
+
+// GraceRemaining returns the seconds until removal of each undetected managed
+// route, keyed by route, for the route_grace_remaining_seconds gauge.
+func (s *Syncer) GraceRemaining() map[string]float64 {
+	timers := s.GraceTimers()
+	remaining := make(map[string]float64, len(timers))
+	now := time.Now()
+	for _, t := range timers {
+		seconds := t.RemovesAt.Sub(now).Seconds()
+		if seconds < 0 {
+			seconds = 0
+		}
+		remaining[routes.Key(t.Network, t.Nexthop)] = seconds
+	}
+	return remaining
+}
@@ -0,0 +1,56 @@
+package unifi
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/config"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/routes"
+)
+
+func TestGraceTracker(t *testing.T) {
+	managed := func(network, nexthop string) StaticRoute {
+		return StaticRoute{Name: RouteName("HomePod", network, nexthop), StaticRouteNetwork: network, StaticRouteNexthop: nexthop}
+	}
+	current := []StaticRoute{
+		managed("fd00:1::/64", "2001:db8::1"),
+		managed("fd00:2::/64", "2001:db8::2"),
+		managed("fd00:3::/64", "2001:db8::3"),
+		managed("fd00:4::/64", "2001:db8::4"),
+		{Name: "Office", StaticRouteNetwork: "fd00:9::/64", StaticRouteNexthop: "2001:db8::9"},
+	}
+	desired := []StaticRoute{managed("fd00:1::/64", "2001:db8::1")}
+
+	now := time.Now()
+	lastSeen := map[string]time.Time{
+		routes.Key("fd00:2::/64", "2001:db8::2"): now.Add(-3 * time.Minute),
+		routes.Key("fd00:3::/64", "2001:db8::3"): now.Add(-20 * time.Minute),
+		routes.Key("fd00:4::/64", "2001:db8::4"): now.Add(-time.Minute),
+	}
+	grace := config.GracePolicy{Default: 10 * time.Minute}
+
+	g := newGraceTracker()
+	g.track(current, desired)
+	timers := g.timers(lastSeen, grace, now)
+	if len(timers) != 3 {
+		t.Fatalf("Expected timers for the three undetected managed routes, got %+v", timers)
+	}
+	if timers[0].Network != "fd00:3::/64" || !timers[0].Overdue || timers[0].RemovesIn != "0s" {
+		t.Errorf("Expected the overdue route first, got %+v", timers[0])
+	}
+	if timers[1].Network != "fd00:2::/64" || timers[1].Overdue || timers[1].RemovesIn != "7m" ||
+		!timers[1].RemovesAt.Equal(now.Add(7*time.Minute)) || timers[1].GracePeriod != "10m" {
+		t.Errorf("Expected fd00:2::/64 removed in 7m, got %+v", timers[1])
+	}
+
+	g.forget(routes.Key("fd00:3::/64", "2001:db8::3"))
+	if timers := g.timers(lastSeen, grace, now); len(timers) != 2 || timers[0].Network != "fd00:2::/64" {
+		t.Errorf("Expected the removed route forgotten, got %+v", timers)
+	}
+
+	var none *graceTracker
+	none.track(current, desired)
+	if timers := none.timers(lastSeen, grace, now); timers != nil {
+		t.Errorf("Expected no timers from a nil tracker, got %+v", timers)
+	}
+}
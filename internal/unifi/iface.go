@@ -0,0 +1,93 @@
+package unifi
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/logger"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/routes"
+)
+
+const (
+	// routeTypeNexthop routes a network via a next hop address.
+	routeTypeNexthop = "nexthop-route"
+	// routeTypeInterface routes a network out of a gateway interface.
+	routeTypeInterface = "interface-route"
+)
+
+// ResolveRouteInterface checks the network configured for interface routes
+// against the controller's networks and remembers its ID. Routes stay next hop
+// routes when none is configured, when the controller version detected picks
+// traffic routes, which have no interface, or when the network is unknown or
+// has no IPv6 gateway interface; all but the first are returned as errors.
+// The legacy API takes the ID in static-route_interface, the v2 API in
+// interface.
+func (s *Syncer) ResolveRouteInterface() error {
+	want := s.client.cfg.RouteInterface
+	if want == "" {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.client.HasValidSession() {
+		if err := s.client.Login(); err != nil {
+			return err
+		}
+	}
+	version, err := s.client.ControllerVersion()
+	if err != nil {
+		return fmt.Errorf("detecting the controller version: %w", err)
+	}
+	if s.client.UsesTrafficRoutes() {
+		return fmt.Errorf("controller version %s only has traffic routes, which cannot go out of an interface", version)
+	}
+	networks, err := s.client.NetworkConfs()
+	if err != nil {
+		return fmt.Errorf("reading networks: %w", err)
+	}
+	for _, n := range networks {
+		if n.ID != want && !strings.EqualFold(n.Name, want) {
+			continue
+		}
+		if !n.RoutesIPv6() {
+			return fmt.Errorf("network %q has no IPv6 gateway interface", n.Name)
+		}
+		s.routeInterface, s.routeInterfaceName = n.ID, n.Name
+		logger.Info("UniFi: controller version %s, routing Thread networks out of the %s interface (%s)", version, n.Name, n.ID)
+		return nil
+	}
+	return fmt.Errorf("no network named %q", want)
+}
+
+// interfaceRoutes turns desired into one interface route per network out of
+// the interface network iface named ifaceName. An interface route has no next
+// hop, so its name and key derive from the network alone and stay the same
+// whichever border routers advertise it.
+func interfaceRoutes(desired []StaticRoute, iface, ifaceName string) []StaticRoute {
+	seen := make(map[string]bool, len(desired))
+	out := desired[:0]
+	for _, r := range desired {
+		network := routes.NormalizePrefix(r.StaticRouteNetwork)
+		if seen[network] {
+			continue
+		}
+		seen[network] = true
+		r.Name = RouteName(ifaceName, r.StaticRouteNetwork, "")
+		r.StaticRouteNexthop = ""
+		r.StaticRouteType = routeTypeInterface
+		r.StaticRouteInterface = iface
+		out = append(out, r)
+	}
+	return out
+}
+
+// clearInterfaceNexthops clears the next hop of the interface routes in
+// current, which controllers may list with one, so they key by network alone
+// like the desired interface routes.
+func clearInterfaceNexthops(current []StaticRoute) {
+	for i := range current {
+		if current[i].StaticRouteType == routeTypeInterface {
+			current[i].StaticRouteNexthop = ""
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package unifi
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/state"
+)
+
+func TestInterfaceRoutes(t *testing.T) {
+	desired := []StaticRoute{
+		{Name: RouteName("A", "fd00:1::/64", "2001:db8::1"), StaticRouteNetwork: "fd00:1::/64", StaticRouteNexthop: "2001:db8::1", StaticRouteType: routeTypeNexthop},
+		{Name: RouteName("B", "fd00:1::/64", "2001:db8::2"), StaticRouteNetwork: "fd00:1::/64", StaticRouteNexthop: "2001:db8::2", StaticRouteType: routeTypeNexthop},
+		{Name: RouteName("B", "fd00:2::/64", "2001:db8::2"), StaticRouteNetwork: "fd00:2::/64", StaticRouteNexthop: "2001:db8::2", StaticRouteType: routeTypeNexthop},
+	}
+
+	got := interfaceRoutes(desired, "net-lan", "LAN")
+	if len(got) != 2 || got[0].StaticRouteNetwork != "fd00:1::/64" || got[1].StaticRouteNetwork != "fd00:2::/64" {
+		t.Fatalf("Expected one route per network, got %+v", got)
+	}
+	for _, r := range got {
+		if r.StaticRouteType != routeTypeInterface || r.StaticRouteInterface != "net-lan" || r.StaticRouteNexthop != "" {
+			t.Errorf("Expected an interface route out of net-lan without a next hop, got %+v", r)
+		}
+		if r.Name != RouteName("LAN", r.StaticRouteNetwork, "") || !r.IsThreadRoute() {
+			t.Errorf("Expected a name derived from the network, got %q", r.Name)
+		}
+	}
+
+	// Another border router advertising the network first yields the same route.
+	again := interfaceRoutes([]StaticRoute{
+		{Name: RouteName("B", "fd00:1::/64", "2001:db8::2"), StaticRouteNetwork: "fd00:1::/64", StaticRouteNexthop: "2001:db8::2"},
+	}, "net-lan", "LAN")
+	if again[0] != got[0] {
+		t.Errorf("Expected the route independent of the border router, got %+v and %+v", again[0], got[0])
+	}
+}
+
+// TestClearInterfaceNexthops verifies interface routes listed with a next hop
+// lose it, and next hop routes keep theirs.
+func TestClearInterfaceNexthops(t *testing.T) {
+	current := []StaticRoute{
+		{StaticRouteNetwork: "fd00:1::/64", StaticRouteNexthop: "2001:db8::1", StaticRouteType: routeTypeInterface},
+		{StaticRouteNetwork: "fd00:2::/64", StaticRouteNexthop: "2001:db8::2", StaticRouteType: routeTypeNexthop},
+	}
+
+	clearInterfaceNexthops(current)
+	if current[0].StaticRouteNexthop != "" || current[1].StaticRouteNexthop != "2001:db8::2" {
+		t.Errorf("Expected only the interface route's next hop cleared, got %+v", current)
+	}
+}
+
+// TestRefreshRoutesType verifies managed routes are switched between next hop
+// and interface routes in place, and default next hop routes are left alone.
+func TestRefreshRoutesType(t *testing.T) {
+	name := RouteName("A", "fd00:1::/64", "2001:db8::1")
+	nexthop := StaticRoute{ID: "r1", Name: name, StaticRouteNetwork: "fd00:1::/64", StaticRouteNexthop: "2001:db8::1"}
+	iface := nexthop
+	iface.StaticRouteType, iface.StaticRouteInterface = routeTypeInterface, "net-lan"
+	want := nexthop
+	want.StaticRouteType = routeTypeNexthop
+
+	if updates := refreshRoutes([]StaticRoute{nexthop}, []StaticRoute{want}, nil); len(updates) != 0 {
+		t.Errorf("Expected no update of a next hop route listed without a type, got %+v", updates)
+	}
+	updates := refreshRoutes([]StaticRoute{nexthop}, []StaticRoute{iface}, nil)
+	if len(updates) != 1 || updates[0].to.StaticRouteInterface != "net-lan" || updates[0].changes() != "type interface-route" {
+		t.Errorf("Expected the route switched to an interface route, got %+v", updates)
+	}
+	updates = refreshRoutes([]StaticRoute{iface}, []StaticRoute{want}, nil)
+	if len(updates) != 1 || updates[0].to.StaticRouteType != routeTypeNexthop || updates[0].to.StaticRouteInterface != "" {
+		t.Errorf("Expected the route switched back to a next hop route, got %+v", updates)
+	}
+}
+
+func TestResolveRouteInterface(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/auth/login":
+			_, _ = w.Write([]byte(`{"meta":{"rc":"ok"}}`))
+		case "/proxy/network/api/s/default/stat/sysinfo":
+			_, _ = w.Write([]byte(`{"meta":{"rc":"ok"},"data":[{"version":"8.6.9"}]}`))
+		case "/proxy/network/api/s/default/rest/networkconf":
+			_, _ = w.Write([]byte(`{"meta":{"rc":"ok"},"data":[` +
+				`{"_id":"net-lan","name":"LAN","purpose":"corporate"},` +
+				`{"_id":"net-iot","name":"IoT","purpose":"corporate","ipv6_interface_type":"none"}]}`))
+		}
+	})
+	tests := []struct {
+		name     string
+		iface    string
+		expected string
+		wantErr  bool
+	}{
+		{"none configured", "", "", false},
+		{"by name", "lan", "net-lan", false},
+		{"by ID", "net-lan", "net-lan", false},
+		{"no IPv6", "IoT", "", true},
+		{"unknown", "Guest", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := newTestClient(t, handler)
+			client.cfg.RouteInterface = tt.iface
+			s := &Syncer{client: client, state: state.New(nil)}
+			err := s.ResolveRouteInterface()
+			if (err != nil) != tt.wantErr || s.routeInterface != tt.expected {
+				t.Errorf("Expected interface %q (error %v), got %q, %v", tt.expected, tt.wantErr, s.routeInterface, err)
+			}
+		})
+	}
+}
+
+// TestResolveRouteInterfaceTrafficRoutes verifies interface routes are refused
+// on a controller whose version only has traffic routes.
+func TestResolveRouteInterfaceTrafficRoutes(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/auth/login":
+			_, _ = w.Write([]byte(`{"meta":{"rc":"ok"}}`))
+		case "/proxy/network/api/s/default/stat/sysinfo":
+			_, _ = w.Write([]byte(`{"meta":{"rc":"ok"},"data":[{"version":"9.1.2"}]}`))
+		case "/proxy/network/v2/api/site/default/trafficroutes":
+			_, _ = w.Write([]byte(`[]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	client.cfg.RouteInterface = "LAN"
+	s := &Syncer{client: client, state: state.New(nil)}
+
+	if err := s.ResolveRouteInterface(); err == nil || !strings.Contains(err.Error(), "traffic routes") || s.routeInterface != "" {
+		t.Errorf("Expected interface routes refused, got %q, %v", s.routeInterface, err)
+	}
+}
@@ -0,0 +1,45 @@
+package unifi
+
+import (
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/config"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/routes"
+)
+
+// applyLimits trims one sync cycle's changes to the safety caps. Deletions past
+// MaxDeletesPerSync are deferred to later cycles. Additions are held back once
+// the cycle, their network or the managed total reaches its cap; they are tried
+// again every cycle, so they go through once routes are removed or a cap is raised.
+func applyLimits(limits config.RouteLimits, current, toAdd, toRemove []StaticRoute) (add, remove, heldAdds, deferredDeletes []StaticRoute) {
+	remove = toRemove
+	if limits.MaxDeletesPerSync > 0 && len(remove) > limits.MaxDeletesPerSync {
+		deferredDeletes = remove[limits.MaxDeletesPerSync:]
+		remove = remove[:limits.MaxDeletesPerSync]
+	}
+
+	removed := make(map[string]bool, len(remove))
+	for _, r := range remove {
+		removed[routes.Key(r.StaticRouteNetwork, r.StaticRouteNexthop)] = true
+	}
+	total := 0
+	perNetwork := make(map[string]int)
+	for _, r := range current {
+		if r.IsThreadRoute() && !removed[routes.Key(r.StaticRouteNetwork, r.StaticRouteNexthop)] {
+			total++
+			perNetwork[routes.NormalizePrefix(r.StaticRouteNetwork)]++
+		}
+	}
+
+	for _, r := range toAdd {
+		network := routes.NormalizePrefix(r.StaticRouteNetwork)
+		if (limits.MaxAddsPerSync > 0 && len(add) >= limits.MaxAddsPerSync) ||
+			(limits.MaxRoutes > 0 && total >= limits.MaxRoutes) ||
+			(limits.MaxPerNetwork > 0 && perNetwork[network] >= limits.MaxPerNetwork) {
+			heldAdds = append(heldAdds, r)
+			continue
+		}
+		add = append(add, r)
+		total++
+		perNetwork[network]++
+	}
+	return add, remove, heldAdds, deferredDeletes
+}
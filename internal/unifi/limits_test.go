@@ -0,0 +1,46 @@
+package unifi
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/config"
+)
+
+func TestApplyLimits(t *testing.T) {
+	route := func(network string, i int) StaticRoute {
+		return StaticRoute{Name: "Thread route via Router", StaticRouteNetwork: network,
+			StaticRouteNexthop: fmt.Sprintf("2001:4860:4860:1234::%x", i)}
+	}
+	current := []StaticRoute{route("fd00:1::/64", 1), route("fd00:1::/64", 2), route("fd00:2::/64", 3)}
+	toAdd := []StaticRoute{route("fd00:1::/64", 4), route("fd00:3::/64", 5), route("fd00:4::/64", 6), route("fd00:5::/64", 7)}
+	toRemove := []StaticRoute{current[2]}
+
+	tests := []struct {
+		name            string
+		limits          config.RouteLimits
+		toRemove        []StaticRoute
+		expectedAdds    int
+		expectedRemoves int
+		expectedHeld    int
+		expectedDefer   int
+	}{
+		{"no limits", config.RouteLimits{}, toRemove, 4, 1, 0, 0},
+		{"per sync adds", config.RouteLimits{MaxAddsPerSync: 2}, toRemove, 2, 1, 2, 0},
+		{"per network", config.RouteLimits{MaxPerNetwork: 2}, toRemove, 3, 1, 1, 0},
+		{"managed total counts removals", config.RouteLimits{MaxRoutes: 4}, toRemove, 2, 1, 2, 0},
+		{"per sync deletes", config.RouteLimits{MaxDeletesPerSync: 1, MaxRoutes: 4}, current, 2, 1, 2, 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			add, remove, held, deferred := applyLimits(tt.limits, current, toAdd, tt.toRemove)
+			if len(add) != tt.expectedAdds || len(remove) != tt.expectedRemoves {
+				t.Errorf("Expected %d adds and %d removes, got %d and %d", tt.expectedAdds, tt.expectedRemoves, len(add), len(remove))
+			}
+			if len(held) != tt.expectedHeld || len(deferred) != tt.expectedDefer {
+				t.Errorf("Expected %d held and %d deferred, got %d and %d", tt.expectedHeld, tt.expectedDefer, len(held), len(deferred))
+			}
+		})
+	}
+}
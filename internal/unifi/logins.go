@@ -0,0 +1,159 @@
+package unifi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/config"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/metrics"
+)
+
+const (
+	// loginWindow is the period login budgets are counted over.
+	loginWindow = time.Hour
+	// lockoutBackoff is how long logins pause after the controller reports
+	// that too many logins failed.
+	lockoutBackoff = 15 * time.Minute
+)
+
+// ErrLoginBudget is returned by Login when another attempt would exceed the
+// login budget, before the controller is contacted.
+var ErrLoginBudget = errors.New("login budget exhausted")
+
+var controllerLogins = metrics.NewCounter("controller_logins_total",
+	"Logins to the controller, by result: ok, failed or refused by the login budget.", "result")
+
+// LoginBudgetStatus reports the logins spent and left in the current hour.
+type LoginBudgetStatus struct {
+	Limit        int        `json:"limit"` // 0 means unlimited
+	Used         int        `json:"used"`
+	Remaining    int        `json:"remaining"`
+	FailureLimit int        `json:"failure_limit"` // 0 means unlimited
+	Failures     int        `json:"failures"`
+	LockedUntil  *time.Time `json:"locked_until,omitempty"` // controller reported a lockout
+	NextAttempt  *time.Time `json:"next_attempt,omitempty"` // when the budget allows a login again
+}
+
+// loginBudget counts login attempts and failures over the last hour, so the
+// daemon stops logging in before the controller locks the account out.
+type loginBudget struct {
+	mu          sync.Mutex
+	limits      config.LoginBudget
+	attempts    []time.Time
+	failures    []time.Time
+	lockedUntil time.Time
+}
+
+// take spends one login attempt at now, or returns an ErrLoginBudget error
+// telling when the next attempt is allowed.
+func (b *loginBudget) take(now time.Time) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.prune(now)
+	if next := b.nextAttempt(now); !next.IsZero() {
+		controllerLogins.Inc("refused")
+		return fmt.Errorf("%w (%s), next attempt at %s", ErrLoginBudget, b.reason(now), next.Format(time.RFC3339))
+	}
+	b.attempts = append(b.attempts, now)
+	return nil
+}
+
+// done records the outcome of the login attempt taken at now. Failures the
+// controller answered count against the failure budget; a lockout pauses
+// logins for lockoutBackoff.
+func (b *loginBudget) done(now time.Time, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err == nil {
+		controllerLogins.Inc("ok")
+		return
+	}
+	controllerLogins.Inc("failed")
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return // the controller was not reached
+	}
+	b.failures = append(b.failures, now)
+	if apiErr.StatusCode == http.StatusTooManyRequests || strings.Contains(apiErr.Body, "AUTHENTICATION_FAILED_LIMIT_REACHED") {
+		b.lockedUntil = now.Add(lockoutBackoff)
+	}
+}
+
+// status reports the budget at now.
+func (b *loginBudget) status(now time.Time) LoginBudgetStatus {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.prune(now)
+	st := LoginBudgetStatus{
+		Limit:        b.limits.PerHour,
+		Used:         len(b.attempts),
+		FailureLimit: b.limits.FailuresPerHour,
+		Failures:     len(b.failures),
+	}
+	if b.limits.PerHour > 0 {
+		st.Remaining = max(b.limits.PerHour-len(b.attempts), 0)
+	}
+	if now.Before(b.lockedUntil) {
+		locked := b.lockedUntil
+		st.LockedUntil = &locked
+	}
+	if next := b.nextAttempt(now); !next.IsZero() {
+		st.NextAttempt = &next
+	}
+	return st
+}
+
+// nextAttempt returns when a login is allowed again, or the zero time when
+// one is allowed now. b.mu must be held.
+func (b *loginBudget) nextAttempt(now time.Time) time.Time {
+	var next time.Time
+	if now.Before(b.lockedUntil) {
+		next = b.lockedUntil
+	}
+	if b.limits.PerHour > 0 && len(b.attempts) >= b.limits.PerHour {
+		next = later(next, b.attempts[len(b.attempts)-b.limits.PerHour].Add(loginWindow))
+	}
+	if b.limits.FailuresPerHour > 0 && len(b.failures) >= b.limits.FailuresPerHour {
+		next = later(next, b.failures[len(b.failures)-b.limits.FailuresPerHour].Add(loginWindow))
+	}
+	return next
+}
+
+// reason describes why no login is allowed at now. b.mu must be held.
+func (b *loginBudget) reason(now time.Time) string {
+	switch {
+	case now.Before(b.lockedUntil):
+		return "controller reported too many failed logins"
+	case b.limits.FailuresPerHour > 0 && len(b.failures) >= b.limits.FailuresPerHour:
+		return fmt.Sprintf("%d failed logins in the last hour", len(b.failures))
+	}
+	return fmt.Sprintf("%d logins in the last hour", len(b.attempts))
+}
+
+// prune forgets attempts and failures loginWindow old or older. b.mu must be held.
+func (b *loginBudget) prune(now time.Time) {
+	cutoff := now.Add(-loginWindow)
+	b.attempts = dropUntil(b.attempts, cutoff)
+	b.failures = dropUntil(b.failures, cutoff)
+}
+
+// dropUntil removes the leading times of ts up to cutoff; ts is sorted.
+func dropUntil(ts []time.Time, cutoff time.Time) []time.Time {
+	i := 0
+	for i < len(ts) && !ts[i].After(cutoff) {
+		i++
+	}
+	return ts[i:]
+}
+
+// later returns the later of a and b.
+func later(a, b time.Time) time.Time {
+	if b.After(a) {
+		return b
+	}
+	return a
+}
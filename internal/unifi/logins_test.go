@@ -0,0 +1,96 @@
+package unifi
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/config"
+)
+
+func TestLoginBudgetAttempts(t *testing.T) {
+	b := &loginBudget{limits: config.LoginBudget{PerHour: 2}}
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	for i := 0; i < 2; i++ {
+		now := start.Add(time.Duration(i) * 10 * time.Minute)
+		if err := b.take(now); err != nil {
+			t.Fatalf("Expected attempt %d allowed, got %v", i+1, err)
+		}
+		b.done(now, nil)
+	}
+	if err := b.take(start.Add(30 * time.Minute)); !errors.Is(err, ErrLoginBudget) {
+		t.Errorf("Expected the third attempt refused, got %v", err)
+	}
+	st := b.status(start.Add(30 * time.Minute))
+	if st.Used != 2 || st.Remaining != 0 || st.NextAttempt == nil || !st.NextAttempt.Equal(start.Add(time.Hour)) {
+		t.Errorf("Expected the budget spent until an hour after the first attempt, got %+v", st)
+	}
+	if err := b.take(start.Add(time.Hour)); err != nil {
+		t.Errorf("Expected an attempt allowed once the first one is an hour old, got %v", err)
+	}
+}
+
+func TestLoginBudgetFailures(t *testing.T) {
+	b := &loginBudget{limits: config.LoginBudget{PerHour: 10, FailuresPerHour: 2}}
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	wrong := fmt.Errorf("login failed: %w", &APIError{StatusCode: http.StatusUnauthorized})
+
+	_ = b.take(now)
+	b.done(now, errors.New("dial tcp: connection refused")) // never reached the controller
+	_ = b.take(now)
+	b.done(now, wrong)
+	_ = b.take(now)
+	b.done(now, wrong)
+
+	err := b.take(now)
+	if !errors.Is(err, ErrLoginBudget) {
+		t.Fatalf("Expected logins refused after 2 failures, got %v", err)
+	}
+	if st := b.status(now); st.Failures != 2 || st.Used != 3 || st.Remaining != 7 {
+		t.Errorf("Unexpected status %+v", st)
+	}
+}
+
+func TestLoginBudgetLockout(t *testing.T) {
+	b := &loginBudget{}
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	_ = b.take(now)
+	b.done(now, fmt.Errorf("login failed: %w", &APIError{StatusCode: http.StatusTooManyRequests,
+		Body: `{"code":"AUTHENTICATION_FAILED_LIMIT_REACHED"}`}))
+
+	if err := b.take(now.Add(time.Minute)); !errors.Is(err, ErrLoginBudget) {
+		t.Errorf("Expected logins paused after a lockout, got %v", err)
+	}
+	if st := b.status(now.Add(time.Minute)); st.LockedUntil == nil || !st.LockedUntil.Equal(now.Add(lockoutBackoff)) {
+		t.Errorf("Expected the lockout reported, got %+v", st)
+	}
+	if err := b.take(now.Add(lockoutBackoff)); err != nil {
+		t.Errorf("Expected logins allowed after the lockout, got %v", err)
+	}
+}
+
+// TestLoginRefusedByBudget verifies a refused login never reaches the controller.
+func TestLoginRefusedByBudget(t *testing.T) {
+	calls := 0
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	client.logins.limits = config.LoginBudget{FailuresPerHour: 1}
+
+	if err := client.Login(); err == nil || errors.Is(err, ErrLoginBudget) {
+		t.Errorf("Expected the first login to fail at the controller, got %v", err)
+	}
+	if err := client.Login(); !errors.Is(err, ErrLoginBudget) {
+		t.Errorf("Expected the second login refused, got %v", err)
+	}
+	if calls != 1 {
+		t.Errorf("Expected 1 request to the controller, got %d", calls)
+	}
+	if st := client.LoginBudget(); st.Failures != 1 || st.NextAttempt == nil {
+		t.Errorf("Unexpected status %+v", st)
+	}
+}
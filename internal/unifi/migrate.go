@@ -0,0 +1,123 @@
+package unifi
+
+import (
+	"path"
+	"strings"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/logger"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/routes"
+)
+
+// MigrationResult counts what MigrateLegacyRoutes did.
+type MigrationResult struct {
+	Removed int // legacy routes deleted as duplicates of managed ones
+	Kept    int // duplicates left in place, as approval mode never deletes unreviewed
+	Failed  int // deletions the controller refused
+}
+
+// legacyLabel reports whether name matches one of patterns (path.Match globs,
+// e.g. "Thread Route - *"), the names of the routes an older release or a
+// manual setup created. It returns the label to name the adopted route with:
+// what follows the literal prefix of the matching pattern. Names of managed
+// routes never match; hashless "Thread route via <router>" routes are managed
+// and renamed by refreshRoutes.
+func legacyLabel(name string, patterns []string) (string, bool) {
+	if strings.HasPrefix(name, threadRouteNamePrefix) {
+		return "", false
+	}
+	if _, hashed := nameHash(name); hashed {
+		return "", false
+	}
+	for _, pattern := range patterns {
+		if ok, err := path.Match(pattern, name); err != nil || !ok {
+			continue
+		}
+		literal := pattern
+		if i := strings.IndexAny(pattern, `*?[\`); i >= 0 {
+			literal = pattern[:i]
+		}
+		if label := strings.TrimSpace(strings.TrimPrefix(name, literal)); label != "" {
+			return label, true
+		}
+		return name, true
+	}
+	return "", false
+}
+
+// planMigration returns updates renaming the legacy routes among current into
+// the managed naming scheme, and the legacy routes duplicating a managed
+// route, or an earlier legacy route, to the same network and next hop.
+func planMigration(current []StaticRoute, patterns []string) (renames []routeUpdate, duplicates []StaticRoute) {
+	if len(patterns) == 0 {
+		return nil, nil
+	}
+	managed := make(map[string]bool)
+	for _, r := range current {
+		if r.IsThreadRoute() {
+			managed[routes.Key(r.StaticRouteNetwork, r.StaticRouteNexthop)] = true
+		}
+	}
+	for _, r := range current {
+		if !r.IsStatic() || !r.IsIPv6() {
+			continue
+		}
+		label, ok := legacyLabel(r.Name, patterns)
+		if !ok {
+			continue
+		}
+		key := routes.Key(r.StaticRouteNetwork, r.StaticRouteNexthop)
+		if managed[key] {
+			duplicates = append(duplicates, r)
+			continue
+		}
+		managed[key] = true
+		next := r
+		next.Name = RouteName(label, r.StaticRouteNetwork, r.StaticRouteNexthop)
+		renames = append(renames, routeUpdate{from: r, to: next})
+	}
+	return renames, duplicates
+}
+
+// MigrateLegacyRoutes deletes the legacy routes duplicating a managed route,
+// so adopting the others adds no duplicates; in approval mode they are only
+// reported. The others are renamed and adopted by the syncs, through the plan
+// and its approval. It runs once, before the first sync.
+func (s *Syncer) MigrateLegacyRoutes() (MigrationResult, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var result MigrationResult
+	if len(s.client.cfg.LegacyRouteNames) == 0 {
+		return result, nil
+	}
+	if !s.client.HasValidSession() {
+		if err := s.client.Login(); err != nil {
+			return result, err
+		}
+	}
+	current, err := s.client.StaticRoutes()
+	if err != nil {
+		return result, err
+	}
+
+	_, duplicates := planMigration(current, s.client.cfg.LegacyRouteNames)
+	if len(duplicates) > 0 && s.approval == nil {
+		if err := s.backup(); err != nil {
+			return result, err
+		}
+	}
+	for _, r := range duplicates {
+		if s.approval != nil {
+			logger.Warn("UniFi: migration: route %q duplicates a managed route, delete it by hand", r.Name)
+			result.Kept++
+			continue
+		}
+		if err := s.client.DeleteStaticRoute(r.ID); err != nil {
+			logger.Warn("UniFi: migration: could not delete duplicate route %q: %v", r.Name, err)
+			result.Failed++
+			continue
+		}
+		logger.Info("UniFi: migration: deleted route %q, a duplicate of a managed route", r.Name)
+		result.Removed++
+	}
+	return result, nil
+}
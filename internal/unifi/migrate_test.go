@@ -0,0 +1,145 @@
+package unifi
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/config"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/routes"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/state"
+)
+
+func TestLegacyLabel(t *testing.T) {
+	patterns := []string{"Thread Route - *", "tbr-*", "[bad", "Thread route via *"}
+	tests := []struct {
+		name   string
+		label  string
+		legacy bool
+	}{
+		{"Thread route via Living Room", "", false}, // managed, renamed by refreshRoutes
+		{RouteName("Living Room", "fd00:1::/64", "2001:db8::1"), "", false},
+		{"Thread Route - Copy [00000000]", "", false},
+		{"Thread Route - Kitchen", "Kitchen", true},
+		{"tbr-", "tbr-", true},
+		{"Office uplink", "", false},
+	}
+	for _, tt := range tests {
+		label, legacy := legacyLabel(tt.name, patterns)
+		if label != tt.label || legacy != tt.legacy {
+			t.Errorf("legacyLabel(%q): expected %q, %v, got %q, %v", tt.name, tt.label, tt.legacy, label, legacy)
+		}
+	}
+}
+
+// TestPlanMigration verifies legacy routes are renamed into the managed
+// scheme unless they duplicate a managed or an earlier legacy route.
+func TestPlanMigration(t *testing.T) {
+	managed := StaticRoute{ID: "m1", Type: RouteTypeStatic, Name: RouteName("Kitchen", "fd00:1::/64", "2001:db8::1"),
+		StaticRouteNetwork: "fd00:1::/64", StaticRouteNexthop: "2001:db8::1"}
+	current := []StaticRoute{
+		managed,
+		{ID: "l1", Type: RouteTypeStatic, Name: "Thread Route - Kitchen", StaticRouteNetwork: "FD00:1::/64", StaticRouteNexthop: "2001:db8::1"},
+		{ID: "l2", Type: RouteTypeStatic, Name: "Thread Route - Office", StaticRouteNetwork: "fd00:2::/64", StaticRouteNexthop: "2001:db8::2"},
+		{ID: "l3", Type: RouteTypeStatic, Name: "tbr-Office", StaticRouteNetwork: "fd00:2::/64", StaticRouteNexthop: "2001:db8::2"},
+		{ID: "h1", Type: RouteTypeStatic, Name: "Thread route via Attic", StaticRouteNetwork: "fd00:4::/64", StaticRouteNexthop: "2001:db8::4"},
+		{ID: "v4", Type: RouteTypeStatic, Name: "Thread Route - NAS", StaticRouteNetwork: "10.0.0.0/8", StaticRouteNexthop: "192.168.1.2"},
+		{ID: "u1", Type: RouteTypeStatic, Name: "Office uplink", StaticRouteNetwork: "fd00:3::/64", StaticRouteNexthop: "2001:db8::3"},
+	}
+
+	if renames, duplicates := planMigration(current, nil); len(renames) != 0 || len(duplicates) != 0 {
+		t.Errorf("Expected nothing migrated without patterns, got %+v, %+v", renames, duplicates)
+	}
+	renames, duplicates := planMigration(current, []string{"Thread Route - *", "tbr-*"})
+	if len(renames) != 1 || renames[0].from.ID != "l2" || renames[0].to.Name != RouteName("Office", "fd00:2::/64", "2001:db8::2") || !renames[0].to.IsThreadRoute() {
+		t.Errorf("Expected l2 renamed into the managed scheme, got %+v", renames)
+	}
+	if len(duplicates) != 2 || duplicates[0].ID != "l1" || duplicates[1].ID != "l3" {
+		t.Errorf("Expected l1 and l3 as duplicates, got %+v", duplicates)
+	}
+}
+
+// legacyController serves a routing table of two legacy routes to the same
+// network and next hop, recording the routes renamed and deleted.
+func legacyController(t *testing.T, renamed *[]StaticRoute, deleted *[]string) *Client {
+	return newLegacyTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/auth/login":
+			w.Header().Set("X-CSRF-Token", "csrf123")
+			http.SetCookie(w, &http.Cookie{Name: "TOKEN", Value: "tok456"})
+			_, _ = w.Write([]byte(`{"meta":{"rc":"ok"}}`))
+		case r.Method == http.MethodGet:
+			_, _ = w.Write([]byte(`{"meta":{"rc":"ok"},"data":[` +
+				`{"_id":"l1","name":"Thread Route - Kitchen","type":"static-route","static-route_network":"fd00:1::/64","static-route_nexthop":"2001:db8::1"},` +
+				`{"_id":"l2","name":"Thread Route - Kitchen","type":"static-route","static-route_network":"fd00:1::/64","static-route_nexthop":"2001:db8::1"}]}`))
+		case r.Method == http.MethodPut:
+			var route StaticRoute
+			_ = json.NewDecoder(r.Body).Decode(&route)
+			*renamed = append(*renamed, route)
+			_, _ = w.Write([]byte(`{"meta":{"rc":"ok"}}`))
+		case r.Method == http.MethodDelete:
+			*deleted = append(*deleted, r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:])
+			_, _ = w.Write([]byte(`{"meta":{"rc":"ok"}}`))
+		}
+	}))
+}
+
+// TestMigrateLegacyRoutes verifies duplicate legacy routes are deleted at
+// startup, and kept in approval mode, while none is renamed.
+func TestMigrateLegacyRoutes(t *testing.T) {
+	var renamed []StaticRoute
+	var deleted []string
+	client := legacyController(t, &renamed, &deleted)
+	client.cfg.LegacyRouteNames = []string{"Thread Route - *"}
+	s := &Syncer{client: client, state: state.New(nil)}
+
+	result, err := s.MigrateLegacyRoutes()
+	if err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	if result != (MigrationResult{Removed: 1}) {
+		t.Errorf("Expected 1 removed, got %+v", result)
+	}
+	if len(renamed) != 0 {
+		t.Errorf("Expected no route renamed at startup, got %+v", renamed)
+	}
+	if len(deleted) != 1 || deleted[0] != "l2" {
+		t.Errorf("Expected l2 deleted, got %v", deleted)
+	}
+
+	s.approval = &approvalQueue{}
+	deleted = nil
+	if result, _ := s.MigrateLegacyRoutes(); result.Kept != 1 || len(deleted) != 0 {
+		t.Errorf("Expected the duplicate kept in approval mode, got %+v, deleted %v", result, deleted)
+	}
+}
+
+// TestSyncAdoptsLegacyRoutes verifies a sync renames legacy routes only once
+// the plan adopting them is approved, tracking them as added.
+func TestSyncAdoptsLegacyRoutes(t *testing.T) {
+	var renamed []StaticRoute
+	var deleted []string
+	client := legacyController(t, &renamed, &deleted)
+	client.cfg.LegacyRouteNames = []string{"Thread Route - *"}
+	client.cfg.Approval = true
+	st := state.New(nil)
+	s := NewSyncer(client, st, nil, config.GracePolicy{Default: time.Hour})
+
+	s.Sync(nil)
+	plan := s.PendingPlan()
+	if len(renamed) != 0 || plan == nil || len(plan.Changes) != 1 || plan.Changes[0].Action != "update" {
+		t.Fatalf("Expected the rename awaiting approval, got %+v, renamed %+v", plan, renamed)
+	}
+	if err := s.Approve(plan.ID); err != nil {
+		t.Fatalf("Approve failed: %v", err)
+	}
+	s.Sync(nil)
+	if len(renamed) != 1 || renamed[0].Name != RouteName("Kitchen", "fd00:1::/64", "2001:db8::1") {
+		t.Errorf("Expected l1 renamed, got %+v", renamed)
+	}
+	if added := st.Export().AddedRoutes; len(added) != 1 || added[0] != routes.Key("fd00:1::/64", "2001:db8::1") {
+		t.Errorf("Expected the adopted route tracked as added, got %v", added)
+	}
+}
@@ -0,0 +1,51 @@
+package unifi
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// maxRouteNameLength is the longest route name, in bytes, created on the
+	// controller; longer names fail validation on some firmwares.
+	maxRouteNameLength = 64
+	// unnamedRouter labels routes through border routers whose name has
+	// nothing left once sanitized.
+	unnamedRouter = "border router"
+)
+
+// routeLabel returns the border router name as it appears in a route name:
+// letters and digits of any script, spaces and common punctuation are kept,
+// emoji, symbols, variation selectors and control characters dropped and
+// whitespace collapsed. The label is cut at a character boundary to fit
+// maxRouteNameLength alongside the prefix and the hash suffix, so the hash always
+// survives.
+func routeLabel(routerName string) string {
+	label := strings.Join(strings.Fields(strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsSpace(r):
+			return ' '
+		case unicode.Is(unicode.Variation_Selector, r):
+			return -1
+		case unicode.IsLetter(r), unicode.IsDigit(r), unicode.In(r, unicode.Mn, unicode.Mc):
+			return r
+		case strings.ContainsRune("-_.,'()&+#@/", r):
+			return r
+		}
+		return -1
+	}, strings.ToValidUTF8(routerName, ""))), " ")
+
+	limit := maxRouteNameLength - len(threadRouteNamePrefix) - len(" [00000000]")
+	if len(label) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(label[cut]) {
+			cut--
+		}
+		label = strings.TrimRight(label[:cut], " ")
+	}
+	if strings.TrimFunc(label, func(r rune) bool { return !unicode.IsLetter(r) && !unicode.IsDigit(r) }) == "" {
+		return unnamedRouter
+	}
+	return label
+}
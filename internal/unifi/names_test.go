@@ -0,0 +1,57 @@
+package unifi
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestRouteLabel(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{"Living Room", "Living Room"},
+		{"Küche", "Küche"},
+		{"Ke\u0301vin's HomePod", "Ke\u0301vin's HomePod"}, // combining accent kept
+		{"客厅 Apple TV", "客厅 Apple TV"},
+		{"🏠 Home Hub ✨", "Home Hub"},
+		{"Nest 👨‍👩‍👧 Hub", "Nest Hub"},
+		{"Tab\there\nnew line", "Tab here new line"},
+		{"1️⃣ Office", "1 Office"},
+		{"bad\xffutf8", "badutf8"},
+		{"🔥🔥🔥", unnamedRouter},
+		{"", unnamedRouter},
+		{" -- ", unnamedRouter},
+	}
+
+	for _, tt := range tests {
+		if got := routeLabel(tt.name); got != tt.expected {
+			t.Errorf("routeLabel(%q): expected %q, got %q", tt.name, tt.expected, got)
+		}
+	}
+}
+
+// TestRouteNameLength verifies long names are cut at a character boundary and
+// keep the hash that marks them as managed.
+func TestRouteNameLength(t *testing.T) {
+	network, nexthop := "fd00:1::/64", "2001:db8::1"
+	for _, router := range []string{
+		strings.Repeat("Living Room ", 10),
+		strings.Repeat("客厅", 40),
+		strings.Repeat("é", 60),
+		strings.Repeat("🏠a", 60),
+	} {
+		name := RouteName(router, network, nexthop)
+		if len(name) > maxRouteNameLength || !utf8.ValidString(name) {
+			t.Errorf("Expected a valid name of at most %d bytes, got %q (%d)", maxRouteNameLength, name, len(name))
+		}
+		if strings.Contains(name, "  ") || !strings.Contains(name, " [") {
+			t.Errorf("Unexpected spacing in %q", name)
+		}
+		r := StaticRoute{Name: name, StaticRouteNetwork: network, StaticRouteNexthop: nexthop}
+		if !r.IsThreadRoute() {
+			t.Errorf("Expected %q to keep its route hash", name)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package unifi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// NetworkConf is a network configured on the controller, from /rest/networkconf.
+type NetworkConf struct {
+	ID                string `json:"_id"`
+	Name              string `json:"name"`
+	Purpose           string `json:"purpose"` // "corporate", "guest", "vlan-only", "wan", ...
+	VLANEnabled       bool   `json:"vlan_enabled"`
+	VLAN              int    `json:"vlan,omitempty"`
+	IPv6InterfaceType string `json:"ipv6_interface_type,omitempty"` // "none", "static", "pd", ...
+}
+
+// UnmarshalJSON decodes a network, tolerating numbers and booleans sent as strings.
+func (n *NetworkConf) UnmarshalJSON(data []byte) error {
+	type plain NetworkConf
+	aux := struct {
+		*plain
+		VLANEnabled flexBool `json:"vlan_enabled"`
+		VLAN        flexInt  `json:"vlan"`
+	}{plain: (*plain)(n)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	n.VLANEnabled, n.VLAN = bool(aux.VLANEnabled), int(aux.VLAN)
+	return nil
+}
+
+// VLANID returns the VLAN ID of the network, 0 when it is untagged.
+func (n NetworkConf) VLANID() int {
+	if !n.VLANEnabled {
+		return 0
+	}
+	return n.VLAN
+}
+
+// RoutesIPv6 reports whether the gateway has an IPv6 interface on the network,
+// so it can reach a next hop there. VLAN-only networks have no gateway
+// interface at all; networks with IPv6 turned off have no IPv6 one.
+func (n NetworkConf) RoutesIPv6() bool {
+	switch strings.ToLower(n.Purpose) {
+	case "vlan-only", "wan", "remote-user-vpn", "site-vpn":
+		return false
+	}
+	return !strings.EqualFold(n.IPv6InterfaceType, "none")
+}
+
+// NetworkConfs retrieves the networks configured on the controller.
+func (c *Client) NetworkConfs() ([]NetworkConf, error) {
+	url := fmt.Sprintf("%s/proxy/network/api/s/default/rest/networkconf", c.cfg.APIBaseURL)
+
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	c.applyAuth(req)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer closeBody(resp)
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &APIError{StatusCode: resp.StatusCode, Body: resp.Status}
+	}
+	var result struct {
+		Data []NetworkConf `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, err
+	}
+	return result.Data, nil
+}
+
+// findNetwork returns the network a client is on, by network ID, else by name.
+func findNetwork(networks []NetworkConf, c NetworkClient) (NetworkConf, bool) {
+	for _, n := range networks {
+		if c.NetworkID != "" && n.ID == c.NetworkID {
+			return n, true
+		}
+	}
+	for _, n := range networks {
+		if c.Network != "" && n.Name == c.Network {
+			return n, true
+		}
+	}
+	return NetworkConf{}, false
+}
@@ -0,0 +1,39 @@
+package unifi
+
+import (
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/config"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/logger"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/routes"
+)
+
+// splitOverridden takes the routes the differ must never touch out of a sync:
+// existing routes matching a pin, which are dropped from desired as well since
+// they need no adding, and existing routes into ignored prefixes. It returns
+// the remaining current and desired routes.
+func splitOverridden(current, desired []StaticRoute, o config.RouteOverrides) ([]StaticRoute, []StaticRoute) {
+	if len(o.Pins) == 0 && len(o.Ignore) == 0 {
+		return current, desired
+	}
+	pinned := make(map[string]bool)
+	var kept []StaticRoute
+	for _, r := range current {
+		switch {
+		case o.Pinned(r.StaticRouteNetwork, r.StaticRouteNexthop):
+			pinned[routes.Key(r.StaticRouteNetwork, r.StaticRouteNexthop)] = true
+		case o.Ignored(r.StaticRouteNetwork):
+			logger.Debug("UniFi: leaving %s -> %s alone, its network is ignored", r.StaticRouteNetwork, r.StaticRouteNexthop)
+		default:
+			kept = append(kept, r)
+		}
+	}
+	var wanted []StaticRoute
+	for _, r := range desired {
+		if !pinned[routes.Key(r.StaticRouteNetwork, r.StaticRouteNexthop)] {
+			wanted = append(wanted, r)
+		}
+	}
+	if len(pinned) > 0 {
+		logger.Debug("UniFi: %d pinned routes present, leaving them untouched", len(pinned))
+	}
+	return kept, wanted
+}
@@ -0,0 +1,36 @@
+package unifi
+
+import (
+	"net/netip"
+	"testing"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/config"
+)
+
+// TestSplitOverridden verifies pinned and ignored routes are kept out of the
+// diff, so they are never updated or deleted.
+func TestSplitOverridden(t *testing.T) {
+	o := config.RouteOverrides{
+		Pins:   []config.RoutePin{{Network: netip.MustParsePrefix("fd00:1::/64"), Nexthop: netip.MustParseAddr("2001:db8::1")}},
+		Ignore: []netip.Prefix{netip.MustParsePrefix("fd00:9::/48")},
+	}
+	pinned := StaticRoute{Name: RouteName("pinned", "fd00:1::/64", "2001:db8::1"), StaticRouteNetwork: "fd00:1::/64", StaticRouteNexthop: "2001:db8::1"}
+	ignored := StaticRoute{Name: RouteName("Office", "fd00:9:0:1::/64", "2001:db8::b"), StaticRouteNetwork: "fd00:9:0:1::/64", StaticRouteNexthop: "2001:db8::b"}
+	managed := StaticRoute{Name: RouteName("Kitchen", "fd00:2::/64", "2001:db8::a"), StaticRouteNetwork: "fd00:2::/64", StaticRouteNexthop: "2001:db8::a"}
+	added := StaticRoute{StaticRouteNetwork: "fd00:3::/64", StaticRouteNexthop: "2001:db8::3"}
+
+	current, desired := splitOverridden(
+		[]StaticRoute{pinned, ignored, managed},
+		[]StaticRoute{pinned, managed, added}, o)
+	if len(current) != 1 || current[0] != managed {
+		t.Errorf("Expected only the managed route in current, got %+v", current)
+	}
+	if len(desired) != 2 || desired[0] != managed || desired[1] != added {
+		t.Errorf("Expected the present pin dropped from desired, got %+v", desired)
+	}
+
+	current, desired = splitOverridden([]StaticRoute{pinned}, nil, config.RouteOverrides{})
+	if len(current) != 1 || desired != nil {
+		t.Errorf("Expected no overrides to change nothing, got %+v %+v", current, desired)
+	}
+}
@@ -0,0 +1,106 @@
+package unifi
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Sync pauses while the controller is unavailable: the pause doubles from
+// minPause after each unavailable answer up to maxPause.
+const (
+	minPause = 30 * time.Second
+	maxPause = 10 * time.Minute
+)
+
+// ControllerPause describes syncs paused while the controller restarts,
+// provisions or updates its firmware.
+type ControllerPause struct {
+	Since    time.Time `json:"since"`
+	Until    time.Time `json:"until"`
+	Attempts int       `json:"attempts"` // unavailable answers since the pause began
+	Reason   string    `json:"reason"`
+}
+
+// isUnavailable reports whether err is the controller saying it can't serve
+// requests for now, e.g. while it restarts, provisions or updates its
+// firmware, rather than refusing the request itself.
+func isUnavailable(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	switch apiErr.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	body := strings.ToLower(apiErr.Body)
+	return apiErr.StatusCode >= 500 &&
+		(strings.Contains(body, "provision") || strings.Contains(body, "upgrad") || strings.Contains(body, "maintenance"))
+}
+
+// syncPause tracks the pause of syncs while the controller is unavailable.
+type syncPause struct {
+	mu    sync.Mutex
+	pause *ControllerPause // nil while the controller is available
+	now   func() time.Time
+}
+
+func newSyncPause() *syncPause {
+	return &syncPause{now: time.Now}
+}
+
+// fail extends the pause after the controller answered err while
+// unavailable, doubling it each time, and returns it.
+func (p *syncPause) fail(err error) ControllerPause {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	if p.pause == nil {
+		p.pause = &ControllerPause{Since: now}
+	}
+	p.pause.Attempts++
+	p.pause.Reason = rejectionReason(err)
+	wait := minPause
+	for i := 1; i < p.pause.Attempts && wait < maxPause; i++ {
+		wait *= 2
+	}
+	p.pause.Until = now.Add(min(wait, maxPause))
+	return *p.pause
+}
+
+// waiting returns the pause and true while syncs are paused.
+func (p *syncPause) waiting() (ControllerPause, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.pause == nil || !p.now().Before(p.pause.Until) {
+		return ControllerPause{}, false
+	}
+	return *p.pause, true
+}
+
+// resume ends the pause once the controller answered again, returning it and
+// true when there was one.
+func (p *syncPause) resume() (ControllerPause, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.pause == nil {
+		return ControllerPause{}, false
+	}
+	pause := *p.pause
+	p.pause = nil
+	return pause, true
+}
+
+// status returns a copy of the pause, or nil while the controller is available.
+func (p *syncPause) status() *ControllerPause {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.pause == nil {
+		return nil
+	}
+	pause := *p.pause
+	return &pause
+}
@@ -0,0 +1,138 @@
+package unifi
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/config"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/state"
+)
+
+func TestIsUnavailable(t *testing.T) {
+	tests := []struct {
+		err      error
+		expected bool
+	}{
+		{&APIError{StatusCode: http.StatusServiceUnavailable}, true},
+		{&APIError{StatusCode: http.StatusBadGateway}, true},
+		{&APIError{StatusCode: http.StatusGatewayTimeout}, true},
+		{&APIError{StatusCode: http.StatusInternalServerError, Body: `{"meta":{"rc":"error","msg":"api.err.DeviceProvisioning"}}`}, true},
+		{&APIError{StatusCode: http.StatusInternalServerError, Body: "internal error"}, false},
+		{&APIError{StatusCode: http.StatusBadRequest, Body: "provisioning"}, false},
+		{errors.New("connection refused"), false},
+	}
+	for _, tt := range tests {
+		if got := isUnavailable(tt.err); got != tt.expected {
+			t.Errorf("isUnavailable(%v) = %v, expected %v", tt.err, got, tt.expected)
+		}
+	}
+}
+
+func TestSyncPauseBackoff(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	p := newSyncPause()
+	p.now = func() time.Time { return now }
+	err := &APIError{StatusCode: http.StatusServiceUnavailable, Body: "updating"}
+
+	if _, paused := p.waiting(); paused || p.status() != nil {
+		t.Fatal("Expected no pause before the controller was unavailable")
+	}
+	expected := []time.Duration{30 * time.Second, time.Minute, 2 * time.Minute, 4 * time.Minute, 8 * time.Minute, 10 * time.Minute, 10 * time.Minute}
+	for i, wait := range expected {
+		if pause := p.fail(err); pause.Until.Sub(now) != wait || pause.Attempts != i+1 {
+			t.Errorf("Attempt %d: expected a %s pause, got %+v", i+1, wait, pause)
+		}
+	}
+	if _, paused := p.waiting(); !paused {
+		t.Error("Expected syncs to be paused")
+	}
+	now = now.Add(11 * time.Minute)
+	if _, paused := p.waiting(); paused {
+		t.Error("Expected the pause to be over")
+	}
+	if pause, ok := p.resume(); !ok || pause.Attempts != len(expected) {
+		t.Errorf("Expected resume to end the pause, got %+v, %v", pause, ok)
+	}
+	if _, ok := p.resume(); ok {
+		t.Error("Expected no pause to resume twice")
+	}
+}
+
+// TestSyncPausesWhileUnavailable verifies syncs stop contacting a controller
+// that answers 503, without counting failures, and resume once it answers again.
+func TestSyncPausesWhileUnavailable(t *testing.T) {
+	var unavailable atomic.Bool
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if unavailable.Load() {
+			http.Error(w, "Service Unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		switch r.URL.Path {
+		case "/api/auth/login":
+			http.SetCookie(w, &http.Cookie{Name: "TOKEN", Value: "session"})
+			_, _ = w.Write([]byte(`{"meta":{"rc":"ok"}}`))
+		case "/proxy/network/api/s/default/rest/routing":
+			_, _ = w.Write([]byte(`{"meta":{"rc":"ok"},"data":[]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer srv.Close()
+
+	client := NewClient(config.UniFi{APIBaseURL: srv.URL, Enabled: true, APIVersion: "v1"})
+	syncer := NewSyncer(client, state.New(nil), nil, config.GracePolicy{Default: time.Minute})
+
+	unavailable.Store(true)
+	syncer.Sync(nil)
+	status := syncer.SyncStatus()
+	if status.Paused == nil || status.Paused.Attempts != 1 || status.ConsecutiveFailures != 0 {
+		t.Fatalf("Expected syncs paused without a failure, got %+v", status)
+	}
+
+	seen := requests.Load()
+	syncer.Sync(nil)
+	if requests.Load() != seen {
+		t.Error("Expected a paused sync not to contact the controller")
+	}
+
+	unavailable.Store(false)
+	syncer.pause.now = func() time.Time { return time.Now().Add(time.Hour) }
+	syncer.Sync(nil)
+	status = syncer.SyncStatus()
+	if status.Paused != nil || status.LastSuccess.IsZero() || status.ConsecutiveFailures != 0 {
+		t.Errorf("Expected the sync to resume and succeed, got %+v", status)
+	}
+}
+
+// TestApplyHaltsWhenUnavailable verifies the route operations of a sync stop
+// at the first unavailable answer instead of each meeting it.
+func TestApplyHaltsWhenUnavailable(t *testing.T) {
+	var posts atomic.Int32
+	client := newLegacyTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPost {
+			posts.Add(1)
+		}
+		http.Error(w, "Service Unavailable", http.StatusServiceUnavailable)
+	}))
+	s := &Syncer{client: client, state: state.New(nil), rejections: newRejectionCache(time.Minute, time.Hour), workers: 1}
+	toAdd := []StaticRoute{
+		{Name: "a", StaticRouteNetwork: "fd00:1::/64", StaticRouteNexthop: "2001:db8::1"},
+		{Name: "b", StaticRouteNetwork: "fd00:2::/64", StaticRouteNexthop: "2001:db8::1"},
+		{Name: "c", StaticRouteNetwork: "fd00:3::/64", StaticRouteNexthop: "2001:db8::1"},
+	}
+
+	halt := &applyHalt{}
+	result := s.addRoutes(toAdd, newDistanceAllocator(nil, nil), halt)
+	if !isUnavailable(halt.halted()) {
+		t.Fatalf("Expected the unavailable answer recorded, got %v", halt.halted())
+	}
+	if posts.Load() != 1 || result.failed != 1 || result.skipped != 2 {
+		t.Errorf("Expected one attempt and the rest skipped, got %d requests and %+v", posts.Load(), result)
+	}
+}
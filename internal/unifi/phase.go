@@ -0,0 +1,148 @@
+package unifi
+
+import (
+	"sync"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/metrics"
+)
+
+// Phase is a step of a sync cycle. A sync runs Authenticate, Fetch, Diff,
+// Apply and Verify in order, stopping early when it fails or has nothing to
+// apply, and is Idle in between.
+type Phase string
+
+const (
+	PhaseIdle         Phase = "idle"
+	PhaseAuthenticate Phase = "authenticate"
+	PhaseFetch        Phase = "fetch"
+	PhaseDiff         Phase = "diff"
+	PhaseApply        Phase = "apply"
+	PhaseVerify       Phase = "verify"
+)
+
+var (
+	syncPhaseDuration = metrics.NewHistogram("sync_phase_duration_seconds",
+		"Time spent in each phase of a route sync.",
+		[]float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30}, "phase")
+	syncFailures = metrics.NewCounter("sync_failures_total",
+		"Route syncs that failed, by the phase they failed in.", "phase")
+)
+
+// SyncError records where and why a sync failed.
+type SyncError struct {
+	Phase Phase     `json:"phase"`
+	Error string    `json:"error"`
+	At    time.Time `json:"at"`
+}
+
+// SyncStatus is the machine-readable trace of the current or last sync.
+type SyncStatus struct {
+	Phase       Phase             `json:"phase"`
+	Started     time.Time         `json:"started,omitzero"`
+	Finished    time.Time         `json:"finished,omitzero"`
+	Durations   map[Phase]float64 `json:"durations_seconds"` // phases of the current or last sync
+	LastSuccess time.Time         `json:"last_success,omitzero"`
+	LastError   *SyncError        `json:"last_error,omitempty"`
+	// ConsecutiveFailures counts the failed syncs since the last successful one.
+	ConsecutiveFailures int `json:"consecutive_failures"`
+	// Paused is set while syncs wait for an unavailable controller.
+	Paused *ControllerPause `json:"paused,omitempty"`
+}
+
+// syncPhases tracks the phases of the running sync.
+type syncPhases struct {
+	mu      sync.Mutex
+	status  SyncStatus
+	entered time.Time
+	failed  bool
+	paused  bool // the controller was unavailable; counts neither way
+	now     func() time.Time
+}
+
+func newSyncPhases() *syncPhases {
+	return &syncPhases{status: SyncStatus{Phase: PhaseIdle, Durations: map[Phase]float64{}}, now: time.Now}
+}
+
+// begin starts a sync in the Authenticate phase.
+func (p *syncPhases) begin() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := p.now()
+	p.status.Phase = PhaseAuthenticate
+	p.status.Started = now
+	p.status.Finished = time.Time{}
+	p.status.Durations = map[Phase]float64{}
+	p.entered = now
+	p.failed = false
+	p.paused = false
+}
+
+// enter ends the current phase and starts next.
+func (p *syncPhases) enter(next Phase) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.endPhase()
+	p.status.Phase = next
+}
+
+// fail records err as the reason the current phase failed.
+func (p *syncPhases) fail(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.failed = true
+	p.status.LastError = &SyncError{Phase: p.status.Phase, Error: err.Error(), At: p.now()}
+	syncFailures.Inc(string(p.status.Phase))
+}
+
+// pause records err, from a controller that is unavailable for now, as the
+// reason the current phase stopped, without counting the sync as failed.
+func (p *syncPhases) pause(err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.paused = true
+	p.status.LastError = &SyncError{Phase: p.status.Phase, Error: err.Error(), At: p.now()}
+}
+
+// finish ends the sync, counting it as a success unless a phase failed or the
+// controller was unavailable.
+func (p *syncPhases) finish() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.endPhase()
+	p.status.Phase = PhaseIdle
+	p.status.Finished = p.entered
+	switch {
+	case p.failed:
+		p.status.ConsecutiveFailures++
+	case p.paused:
+	default:
+		p.status.LastSuccess = p.entered
+		p.status.ConsecutiveFailures = 0
+	}
+}
+
+// endPhase records the duration of the current phase. p.mu must be held.
+func (p *syncPhases) endPhase() {
+	now := p.now()
+	d := now.Sub(p.entered)
+	p.status.Durations[p.status.Phase] += d.Seconds()
+	syncPhaseDuration.Observe(d.Seconds(), string(p.status.Phase))
+	p.entered = now
+}
+
+// get returns a copy of the status.
+func (p *syncPhases) get() SyncStatus {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	status := p.status
+	status.Durations = make(map[Phase]float64, len(p.status.Durations))
+	for phase, d := range p.status.Durations {
+		status.Durations[phase] = d
+	}
+	if p.status.LastError != nil {
+		e := *p.status.LastError
+		status.LastError = &e
+	}
+	return status
+}
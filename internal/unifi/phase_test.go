@@ -0,0 +1,67 @@
+package unifi
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestSyncPhases(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	p := newSyncPhases()
+	p.now = func() time.Time { return now }
+	step := func(d time.Duration) { now = now.Add(d) }
+
+	p.begin()
+	step(time.Second)
+	p.enter(PhaseFetch)
+	if got := p.get().Phase; got != PhaseFetch {
+		t.Errorf("Expected phase %s, got %s", PhaseFetch, got)
+	}
+	step(2 * time.Second)
+	p.enter(PhaseDiff)
+	p.enter(PhaseApply)
+	step(3 * time.Second)
+	p.enter(PhaseVerify)
+	p.finish()
+
+	status := p.get()
+	if status.Phase != PhaseIdle || status.LastError != nil || !status.LastSuccess.Equal(now) {
+		t.Errorf("Expected an idle successful sync, got %+v", status)
+	}
+	want := map[Phase]float64{PhaseAuthenticate: 1, PhaseFetch: 2, PhaseDiff: 0, PhaseApply: 3, PhaseVerify: 0}
+	for phase, d := range want {
+		if status.Durations[phase] != d {
+			t.Errorf("Expected %s to take %vs, got %v", phase, d, status.Durations)
+		}
+	}
+
+	lastSuccess := status.LastSuccess
+	step(time.Minute)
+	p.begin()
+	p.enter(PhaseFetch)
+	p.fail(errors.New("connection refused"))
+	p.finish()
+
+	status = p.get()
+	if status.LastError == nil || status.LastError.Phase != PhaseFetch || status.LastError.Error != "connection refused" {
+		t.Errorf("Expected the failure recorded in the fetch phase, got %+v", status.LastError)
+	}
+	if status.ConsecutiveFailures != 1 {
+		t.Errorf("Expected 1 consecutive failure, got %d", status.ConsecutiveFailures)
+	}
+	if !status.LastSuccess.Equal(lastSuccess) {
+		t.Errorf("Expected last success unchanged by a failed sync, got %v", status.LastSuccess)
+	}
+	if _, ok := status.Durations[PhaseApply]; ok {
+		t.Errorf("Expected only the failed sync's phases, got %v", status.Durations)
+	}
+	p.begin()
+	p.finish()
+	if got := p.get().ConsecutiveFailures; got != 0 {
+		t.Errorf("Expected a successful sync to reset consecutive failures, got %d", got)
+	}
+	if got := syncFailures.Value(string(PhaseFetch)); got < 1 {
+		t.Errorf("Expected the failure counted, got %v", got)
+	}
+}
@@ -0,0 +1,258 @@
+package unifi
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/config"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/logger"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/routes"
+)
+
+// Plan statuses.
+const (
+	PlanUpToDate         = "up-to-date"
+	PlanAwaitingApproval = "awaiting-approval"
+	PlanApplying         = "applying"
+)
+
+// Change is one route of a Plan and why it is changed, kept or held back.
+type Change struct {
+	Action    string `json:"action"` // "add", "update", "remove" or "keep"
+	Name      string `json:"name"`
+	Network   string `json:"network"`
+	Nexthop   string `json:"nexthop"`
+	From      string `json:"from,omitempty"` // previous next hop of an update
+	Reason    string `json:"reason,omitempty"`
+	RemovesIn string `json:"removes_in,omitempty"` // remaining grace period of an undetected route
+}
+
+// Plan describes what one sync cycle decided, like a Terraform plan: the route
+// changes, the managed routes kept and the changes held back. Its ID is derived
+// from the changes only, so a recomputed plan keeps its ID while nothing changes.
+type Plan struct {
+	ID         string    `json:"id"`
+	ComputedAt time.Time `json:"computed_at"`
+	Status     string    `json:"status,omitempty"`
+	Changes    []Change  `json:"changes"`
+	Kept       []Change  `json:"kept,omitempty"`
+	Held       []Change  `json:"held,omitempty"`
+}
+
+// newPlan describes the given route operations as a Plan.
+func newPlan(updates []routeUpdate, toRemove, toAdd []StaticRoute) Plan {
+	var changes []Change
+	for _, u := range updates {
+		changes = append(changes, Change{Action: "update", Name: u.to.Name, Network: u.to.StaticRouteNetwork,
+			Nexthop: u.to.StaticRouteNexthop, From: u.from.StaticRouteNexthop})
+	}
+	changes = appendChanges(changes, "remove", toRemove)
+	changes = appendChanges(changes, "add", toAdd)
+	sortChanges(changes)
+
+	h := sha256.New()
+	for _, c := range changes {
+		fmt.Fprintf(h, "%s %s %s %s\n", c.Action, c.Network, c.Nexthop, c.From)
+	}
+	return Plan{ID: hex.EncodeToString(h.Sum(nil))[:12], ComputedAt: time.Now(), Changes: changes}
+}
+
+func appendChanges(changes []Change, action string, rs []StaticRoute) []Change {
+	for _, r := range rs {
+		changes = append(changes, Change{Action: action, Name: r.Name, Network: r.StaticRouteNetwork, Nexthop: r.StaticRouteNexthop})
+	}
+	return changes
+}
+
+// sortChanges orders changes by route, as routes.Sort does, then action.
+func sortChanges(changes []Change) {
+	sort.Slice(changes, func(i, j int) bool {
+		a, b := changes[i], changes[j]
+		if c := routes.Compare(a.Network, a.Nexthop, b.Network, b.Nexthop); c != 0 {
+			return c < 0
+		}
+		return a.Action < b.Action
+	})
+}
+
+// sortStaticRoutes orders rs by network, then next hop, as routes.Sort does.
+func sortStaticRoutes(rs []StaticRoute) {
+	sort.SliceStable(rs, func(i, j int) bool {
+		return routes.Compare(rs[i].StaticRouteNetwork, rs[i].StaticRouteNexthop,
+			rs[j].StaticRouteNetwork, rs[j].StaticRouteNexthop) < 0
+	})
+}
+
+// sortUpdates orders updates by the route they replace.
+func sortUpdates(updates []routeUpdate) {
+	sort.SliceStable(updates, func(i, j int) bool {
+		return routes.Compare(updates[i].from.StaticRouteNetwork, updates[i].from.StaticRouteNexthop,
+			updates[j].from.StaticRouteNetwork, updates[j].from.StaticRouteNexthop) < 0
+	})
+}
+
+// planReasons maps route keys to why the route is changed or held back.
+type planReasons map[string]string
+
+// set records reason for each of rs.
+func (p planReasons) set(rs []StaticRoute, reason string) {
+	for _, r := range rs {
+		p[routes.Key(r.StaticRouteNetwork, r.StaticRouteNexthop)] = reason
+	}
+}
+
+// queued records, for the routes of toAdd and toRemove whose operation was
+// queued while the controller was unreachable, that it was.
+func (p planReasons) queued(ops map[string]QueuedOp, toAdd, toRemove []StaticRoute) {
+	for op, rs := range map[string][]StaticRoute{"add": toAdd, "remove": toRemove} {
+		for _, r := range rs {
+			key := routes.Key(r.StaticRouteNetwork, r.StaticRouteNexthop)
+			if q, ok := ops[key]; !ok || q.Op != op {
+				continue
+			}
+			if reason, ok := p[key]; ok {
+				p[key] = reason + ", queued while the controller was unreachable"
+			} else {
+				p[key] = "queued while the controller was unreachable"
+			}
+		}
+	}
+}
+
+// describe fills in the reasons of the plan's changes and lists the managed
+// routes kept and the changes held back. held lists the changes held back with
+// their action; reasons covers both changes and held routes.
+func (p *Plan) describe(current, desired []StaticRoute, held map[string][]StaticRoute, reasons planReasons,
+	lastSeen map[string]time.Time, grace config.GracePolicy) {
+	key := func(network, nexthop string) string { return routes.Key(network, nexthop) }
+	for i, c := range p.Changes {
+		k := key(c.Network, c.Nexthop)
+		if c.Action == "update" {
+			k = key(c.Network, c.From)
+		}
+		if reason, ok := reasons[k]; ok {
+			p.Changes[i].Reason = reason
+		} else if c.Action == "add" {
+			p.Changes[i].Reason = "detected, not configured"
+		}
+	}
+
+	for action, rs := range held {
+		for _, c := range appendChanges(nil, action, rs) {
+			c.Reason = reasons[key(c.Network, c.Nexthop)]
+			p.Held = append(p.Held, c)
+		}
+	}
+	sortChanges(p.Held)
+
+	touched := make(map[string]bool)
+	for _, c := range append(append([]Change(nil), p.Changes...), p.Held...) {
+		touched[key(c.Network, c.Nexthop)] = true
+		if c.From != "" {
+			touched[key(c.Network, c.From)] = true
+		}
+	}
+	wanted := make(map[string]bool, len(desired))
+	for _, r := range desired {
+		wanted[key(r.StaticRouteNetwork, r.StaticRouteNexthop)] = true
+	}
+	for _, r := range current {
+		k := key(r.StaticRouteNetwork, r.StaticRouteNexthop)
+		if !r.IsThreadRoute() || touched[k] {
+			continue
+		}
+		c := Change{Action: "keep", Name: r.Name, Network: r.StaticRouteNetwork, Nexthop: r.StaticRouteNexthop, Reason: "detected"}
+		if !wanted[k] {
+			c.Reason = "not detected, within grace period"
+			if seen, ok := lastSeen[k]; ok {
+				c.RemovesIn = logger.FormatDuration(grace.For(r.StaticRouteNetwork) - time.Since(seen))
+			}
+		}
+		p.Kept = append(p.Kept, c)
+	}
+	sortChanges(p.Kept)
+}
+
+// planLog keeps the latest plan for the status API and, when dir is set, writes
+// every plan with changes to a JSON file there, keeping the newest keep files.
+type planLog struct {
+	dir  string
+	keep int
+
+	mu      sync.Mutex
+	last    *Plan
+	written string // ID of the last plan written to dir
+}
+
+// record stores plan as the latest one and writes it to dir if it has changes
+// not written before.
+func (l *planLog) record(plan Plan) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.last = &plan
+	if l.dir == "" || len(plan.Changes) == 0 || plan.ID == l.written {
+		return
+	}
+	if err := l.write(plan); err != nil {
+		logger.Warn("UniFi: failed to write route plan: %v", err)
+		return
+	}
+	l.written = plan.ID
+}
+
+// write saves plan as plan-<time>-<id>.json and removes the oldest plans past keep.
+func (l *planLog) write(plan Plan) error {
+	if err := os.MkdirAll(l.dir, 0o755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(plan, "", "  ")
+	if err != nil {
+		return err
+	}
+	name := fmt.Sprintf("plan-%s-%s.json", plan.ComputedAt.UTC().Format("20060102T150405Z"), plan.ID)
+	if err := os.WriteFile(filepath.Join(l.dir, name), append(data, '\n'), 0o644); err != nil {
+		return err
+	}
+
+	files, err := PlanFiles(l.dir)
+	if err != nil || l.keep <= 0 || len(files) <= l.keep {
+		return err
+	}
+	for _, f := range files[:len(files)-l.keep] {
+		if err := os.Remove(f); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// PlanFiles returns the plan files written to dir, oldest first.
+func PlanFiles(dir string) ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(dir, "plan-*.json"))
+	sort.Strings(files)
+	return files, err
+}
+
+// latest returns the most recent plan, or nil before the first sync.
+func (l *planLog) latest() *Plan {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.last
+}
+
+// summary formats the plan's changes on one line for logging.
+func (p Plan) summary() string {
+	actions := make([]string, 0, len(p.Changes))
+	for _, c := range p.Changes {
+		actions = append(actions, fmt.Sprintf("%s %s -> %s", c.Action, c.Network, c.Nexthop))
+	}
+	return strings.Join(actions, ", ")
+}
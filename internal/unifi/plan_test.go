@@ -0,0 +1,101 @@
+package unifi
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/config"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/routes"
+)
+
+func TestPlanDescribe(t *testing.T) {
+	kept := StaticRoute{Name: "Thread route via Router1", StaticRouteNetwork: "fd00:1::/64", StaticRouteNexthop: "2001:4860:4860:1234::1"}
+	fading := StaticRoute{Name: "Thread route via Router2", StaticRouteNetwork: "fd00:1::/64", StaticRouteNexthop: "2001:4860:4860:1234::2"}
+	gone := StaticRoute{Name: "Thread route via Router3", StaticRouteNetwork: "fd00:2::/64", StaticRouteNexthop: "2001:4860:4860:1234::3"}
+	deferred := StaticRoute{Name: "Thread route via Router4", StaticRouteNetwork: "fd00:3::/64", StaticRouteNexthop: "2001:4860:4860:1234::4"}
+	added := StaticRoute{Name: "Thread route via Router5", StaticRouteNetwork: "fd00:4::/64", StaticRouteNexthop: "2001:4860:4860:1234::5"}
+	manual := StaticRoute{Name: "Manual route", StaticRouteNetwork: "fd00:9::/64", StaticRouteNexthop: "2001:4860:4860:1234::9"}
+
+	current := []StaticRoute{kept, fading, gone, deferred, manual}
+	desired := []StaticRoute{kept, added}
+	reasons := make(planReasons)
+	reasons.set([]StaticRoute{gone}, "not detected for the grace period")
+	reasons.set([]StaticRoute{deferred}, "grace period passed, waiting for the removal window")
+	lastSeen := map[string]time.Time{
+		routes.Key(fading.StaticRouteNetwork, fading.StaticRouteNexthop): time.Now().Add(-4 * time.Minute),
+	}
+
+	plan := newPlan(nil, []StaticRoute{gone}, []StaticRoute{added})
+	plan.describe(current, desired, map[string][]StaticRoute{"remove": {deferred}}, reasons, lastSeen,
+		config.GracePolicy{Default: 10 * time.Minute})
+
+	if len(plan.Changes) != 2 || plan.Changes[0].Reason != "not detected for the grace period" ||
+		plan.Changes[1].Reason != "detected, not configured" {
+		t.Errorf("Unexpected changes %+v", plan.Changes)
+	}
+	if len(plan.Held) != 1 || plan.Held[0].Action != "remove" || plan.Held[0].Nexthop != deferred.StaticRouteNexthop {
+		t.Errorf("Unexpected held changes %+v", plan.Held)
+	}
+	if len(plan.Kept) != 2 {
+		t.Fatalf("Expected 2 kept routes, got %+v", plan.Kept)
+	}
+	if plan.Kept[0].Reason != "detected" {
+		t.Errorf("Expected the detected route to be kept as detected, got %+v", plan.Kept[0])
+	}
+	if plan.Kept[1].Reason != "not detected, within grace period" || plan.Kept[1].RemovesIn == "" {
+		t.Errorf("Expected the undetected route to show its grace timer, got %+v", plan.Kept[1])
+	}
+}
+
+func TestPlanLogRotation(t *testing.T) {
+	dir := t.TempDir()
+	log := &planLog{dir: dir, keep: 2}
+	for i := 0; i < 4; i++ {
+		route := StaticRoute{Name: "Thread route via Router", StaticRouteNetwork: "fd00:1::/64",
+			StaticRouteNexthop: "2001:4860:4860:1234::" + string(rune('a'+i))}
+		plan := newPlan(nil, nil, []StaticRoute{route})
+		plan.ComputedAt = time.Date(2026, 1, 1, 0, i, 0, 0, time.UTC)
+		log.record(plan)
+		log.record(plan) // unchanged plans are written once
+	}
+	log.record(Plan{ID: "empty"}) // plans without changes are not written
+
+	files, _ := filepath.Glob(filepath.Join(dir, "plan-*.json"))
+	if len(files) != 2 {
+		t.Fatalf("Expected 2 plan files, got %v", files)
+	}
+	if filepath.Base(files[0])[:21] != "plan-20260101T000200Z" {
+		t.Errorf("Expected the oldest plans to be removed, got %v", files)
+	}
+	if log.latest().ID != "empty" {
+		t.Errorf("Expected the latest plan to be kept for the status API")
+	}
+}
+
+// TestPlanOrder verifies plan changes and applied routes are sorted by prefix
+// and next hop, comparing addresses numerically.
+func TestPlanOrder(t *testing.T) {
+	r := func(network, nexthop string) StaticRoute {
+		return StaticRoute{Name: "Thread route via R", StaticRouteNetwork: network, StaticRouteNexthop: nexthop}
+	}
+	toAdd := []StaticRoute{r("fd00:10::/64", "2001:4860::1"), r("fd00:9::/64", "2001:4860::a"), r("fd00:9::/64", "2001:4860::2")}
+	toRemove := []StaticRoute{r("fd00:20::/64", "2001:4860::1"), r("fd00:3::/64", "2001:4860::1")}
+
+	sortStaticRoutes(toAdd)
+	if toAdd[0].StaticRouteNexthop != "2001:4860::2" || toAdd[2].StaticRouteNetwork != "fd00:10::/64" {
+		t.Errorf("Expected the additions sorted, got %+v", toAdd)
+	}
+
+	plan := newPlan(nil, toRemove, toAdd)
+	var got []string
+	for _, c := range plan.Changes {
+		got = append(got, c.Network+" "+c.Nexthop)
+	}
+	want := []string{"fd00:3::/64 2001:4860::1", "fd00:9::/64 2001:4860::2", "fd00:9::/64 2001:4860::a",
+		"fd00:10::/64 2001:4860::1", "fd00:20::/64 2001:4860::1"}
+	if !slices.Equal(got, want) {
+		t.Errorf("Expected %v, got %v", want, got)
+	}
+}
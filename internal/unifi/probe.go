@@ -0,0 +1,103 @@
+package unifi
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+)
+
+// ProbeResult classifies the outcome of the startup credential probe.
+type ProbeResult string
+
+// Probe results, from a working login to the misconfigurations worth naming.
+const (
+	ProbeOK               ProbeResult = "ok"
+	ProbeUnreachable      ProbeResult = "unreachable"
+	ProbeTLSFailure       ProbeResult = "tls_failure"
+	ProbeNotUniFiOS       ProbeResult = "not_unifi_os"
+	ProbeWrongCredentials ProbeResult = "wrong_credentials"
+	ProbeMFARequired      ProbeResult = "mfa_required"
+	ProbeRateLimited      ProbeResult = "rate_limited"
+	ProbeNoPermission     ProbeResult = "no_permission"
+	ProbeUnexpected       ProbeResult = "unexpected"
+)
+
+// Hint tells the operator what to check for the result.
+func (r ProbeResult) Hint() string {
+	switch r {
+	case ProbeUnreachable:
+		return "check UBIQUITY_ROUTER_HOSTNAME and that the controller is reachable from this host"
+	case ProbeTLSFailure:
+		return "the controller certificate is not trusted; install its CA or set UBIQUITY_INSECURE_SSL=true"
+	case ProbeNotUniFiOS:
+		return "UBIQUITY_ROUTER_HOSTNAME does not point at a UniFi OS console (UDM, UCG, Cloud Key Gen2+)"
+	case ProbeWrongCredentials:
+		return "check UBIQUITY_USERNAME and UBIQUITY_PASSWORD"
+	case ProbeMFARequired:
+		return "the account requires multi-factor authentication; use a local admin account without MFA"
+	case ProbeRateLimited:
+		return "the controller is rate limiting logins; wait a few minutes before restarting"
+	case ProbeNoPermission:
+		return "the account logged in but may not read the Network application; grant it a Network admin role"
+	case ProbeUnexpected:
+		return "the controller answered unexpectedly; see the error"
+	}
+	return ""
+}
+
+// Probe logs in and makes a read-only authenticated call, classifying any
+// failure so misconfigured credentials are reported at startup rather than at
+// the first sync. The error is nil only for ProbeOK.
+func (c *Client) Probe() (ProbeResult, error) {
+	if err := c.Login(); err != nil {
+		return classifyLoginError(err), err
+	}
+	if _, err := c.ControllerVersion(); err != nil {
+		var apiErr *APIError
+		if errors.As(err, &apiErr) && (apiErr.StatusCode == http.StatusUnauthorized || apiErr.StatusCode == http.StatusForbidden) {
+			return ProbeNoPermission, err
+		}
+		return classifyLoginError(err), err
+	}
+	return ProbeOK, nil
+}
+
+// classifyLoginError maps a login failure to a ProbeResult.
+func classifyLoginError(err error) ProbeResult {
+	var (
+		apiErr      *APIError
+		verifyErr   *tls.CertificateVerificationError
+		unknownCA   x509.UnknownAuthorityError
+		hostnameErr x509.HostnameError
+		recordErr   tls.RecordHeaderError
+		syntaxErr   *json.SyntaxError
+		netErr      net.Error
+	)
+	switch {
+	case errors.As(err, &verifyErr), errors.As(err, &unknownCA), errors.As(err, &hostnameErr), errors.As(err, &recordErr):
+		return ProbeTLSFailure
+	case errors.As(err, &apiErr):
+		body := strings.ToLower(apiErr.Body)
+		switch {
+		case apiErr.StatusCode == http.StatusTooManyRequests:
+			return ProbeRateLimited
+		// UniFi OS answers 499 with an MFA challenge when the account has 2FA enabled.
+		case apiErr.StatusCode == 499 || strings.Contains(body, "mfa") || strings.Contains(body, "2fa"):
+			return ProbeMFARequired
+		case apiErr.StatusCode == http.StatusUnauthorized || apiErr.StatusCode == http.StatusForbidden:
+			return ProbeWrongCredentials
+		case apiErr.StatusCode == http.StatusNotFound || apiErr.StatusCode == http.StatusMethodNotAllowed:
+			return ProbeNotUniFiOS
+		}
+		return ProbeUnexpected
+	case errors.As(err, &syntaxErr):
+		return ProbeNotUniFiOS
+	case errors.As(err, &netErr):
+		return ProbeUnreachable
+	}
+	return ProbeUnexpected
+}
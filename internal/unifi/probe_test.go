@@ -0,0 +1,79 @@
+package unifi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/config"
+)
+
+// TestProbe tests the classification of startup credential probe failures.
+func TestProbe(t *testing.T) {
+	login := func(status int, body string) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			switch r.URL.Path {
+			case "/api/auth/login":
+				if status == http.StatusOK {
+					w.Header().Set("X-CSRF-Token", "csrf123")
+					http.SetCookie(w, &http.Cookie{Name: "TOKEN", Value: "tok456"})
+				}
+				w.WriteHeader(status)
+				_, _ = w.Write([]byte(body))
+			case "/proxy/network/api/s/default/stat/sysinfo":
+				if r.Header.Get("Authorization") != "Bearer tok456" {
+					w.WriteHeader(http.StatusForbidden)
+					return
+				}
+				_, _ = w.Write([]byte(`{"data":[{"version":"9.0.114"}]}`))
+			default:
+				w.WriteHeader(http.StatusNotFound)
+			}
+		}
+	}
+
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		want    ProbeResult
+	}{
+		{"Working credentials", login(http.StatusOK, `{"meta":{"rc":"ok"}}`), ProbeOK},
+		{"Wrong password", login(http.StatusUnauthorized, `{"code":"AUTHENTICATION_FAILED_INVALID_CREDENTIALS"}`), ProbeWrongCredentials},
+		{"MFA required", login(499, `{"code":"MFA_AUTH_REQUIRED"}`), ProbeMFARequired},
+		{"Rate limited", login(http.StatusTooManyRequests, `{"code":"AUTHENTICATION_FAILED_LIMIT_REACHED"}`), ProbeRateLimited},
+		{"Not UniFi OS", login(http.StatusOK, `<html>Welcome</html>`), ProbeNotUniFiOS},
+		{"No login endpoint", func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusNotFound) }, ProbeNotUniFiOS},
+		{"No permission", func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/api/auth/login" {
+				_, _ = w.Write([]byte(`{"username":"tester"}`))
+				return
+			}
+			w.WriteHeader(http.StatusForbidden)
+		}, ProbeNoPermission},
+		{"Server error", login(http.StatusInternalServerError, "oops"), ProbeUnexpected},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := newTestClient(t, tt.handler).Probe()
+			if got != tt.want {
+				t.Errorf("Expected %s, got %s (%v)", tt.want, got, err)
+			}
+			if (err == nil) != (tt.want == ProbeOK) {
+				t.Errorf("Expected error only on failure, got %v", err)
+			}
+		})
+	}
+}
+
+// TestProbeTransportFailures tests TLS and connection failures.
+func TestProbeTransportFailures(t *testing.T) {
+	srv := httptest.NewTLSServer(http.NotFoundHandler())
+	client := NewClient(config.UniFi{APIBaseURL: srv.URL})
+	if got, err := client.Probe(); got != ProbeTLSFailure {
+		t.Errorf("Expected %s for an untrusted certificate, got %s (%v)", ProbeTLSFailure, got, err)
+	}
+	srv.Close()
+	if got, err := client.Probe(); got != ProbeUnreachable {
+		t.Errorf("Expected %s for a closed port, got %s (%v)", ProbeUnreachable, got, err)
+	}
+}
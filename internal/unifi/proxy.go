@@ -0,0 +1,25 @@
+package unifi
+
+import (
+	"net/http"
+	"net/url"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/config"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/logger"
+)
+
+// proxyFunc returns the proxy selection of the controller connection: the
+// configured proxy URL, else HTTPS_PROXY, HTTP_PROXY and NO_PROXY from the
+// environment.
+func proxyFunc(cfg config.UniFi) func(*http.Request) (*url.URL, error) {
+	if cfg.ProxyURL == "" {
+		return http.ProxyFromEnvironment
+	}
+	proxy, err := url.Parse(cfg.ProxyURL)
+	if err != nil || proxy.Host == "" {
+		logger.Warn("UniFi: invalid proxy URL %q, using the environment", cfg.ProxyURL)
+		return http.ProxyFromEnvironment
+	}
+	logger.Info("UniFi: connecting to the controller through %s", proxy.Redacted())
+	return http.ProxyURL(proxy)
+}
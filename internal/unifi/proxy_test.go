@@ -0,0 +1,49 @@
+package unifi
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/config"
+)
+
+// testJWT returns an unsigned JWT with payload as its claims.
+func testJWT(payload string) string {
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString([]byte(`{"alg":"HS256"}`)) + "." + enc.EncodeToString([]byte(payload)) + ".sig"
+}
+
+// TestLoginThroughProxy verifies requests go through the configured proxy and
+// a session stays valid when the proxy drops the CSRF header.
+func TestLoginThroughProxy(t *testing.T) {
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = append(proxied, r.URL.String())
+		http.SetCookie(w, &http.Cookie{Name: "TOKEN", Value: testJWT(`{"csrfToken":"from-jwt"}`)})
+		_, _ = w.Write([]byte(`{"meta":{"rc":"ok"}}`))
+	}))
+	defer proxy.Close()
+
+	client := NewClient(config.UniFi{
+		APIBaseURL: "http://unifi.example",
+		ProxyURL:   proxy.URL,
+		Username:   "tester",
+		Password:   "secret",
+	})
+	if err := client.Login(); err != nil {
+		t.Fatalf("Expected login through the proxy, got %v", err)
+	}
+	if len(proxied) != 1 || proxied[0] != "http://unifi.example/api/auth/login" {
+		t.Errorf("Expected the login request sent to the proxy, got %v", proxied)
+	}
+	if !client.HasValidSession() {
+		t.Error("Expected a valid session with the CSRF token taken from the session JWT")
+	}
+	req, _ := http.NewRequest(http.MethodGet, "http://unifi.example/", nil)
+	client.applyAuth(req)
+	if got := req.Header.Get("X-CSRF-Token"); got != "from-jwt" {
+		t.Errorf("Expected X-CSRF-Token from-jwt, got %q", got)
+	}
+}
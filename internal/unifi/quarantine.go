@@ -0,0 +1,165 @@
+package unifi
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/routes"
+)
+
+// QuarantinedRoute is a managed route whose grace period passed, disabled on
+// the controller until it is deleted or detected again.
+type QuarantinedRoute struct {
+	Name      string    `json:"name"`
+	Network   string    `json:"network"`
+	Nexthop   string    `json:"nexthop"`
+	Since     time.Time `json:"since"`
+	DeletesAt time.Time `json:"deletes_at"`
+}
+
+// quarantine remembers when each quarantined route was disabled. It is kept in
+// memory only: after a restart, disabled managed routes that are not detected
+// start a new quarantine.
+type quarantine struct {
+	mu     sync.Mutex
+	period time.Duration
+	routes map[string]quarantinedEntry
+}
+
+type quarantinedEntry struct {
+	route StaticRoute
+	since time.Time
+}
+
+func newQuarantine(period time.Duration) *quarantine {
+	return &quarantine{period: period, routes: make(map[string]quarantinedEntry)}
+}
+
+// split sorts the routes whose grace period passed: enabled routes are
+// disabled into quarantine, quarantined routes whose period passed are
+// deleted and the rest stay held. Disabled routes the quarantine does not
+// know, e.g. after a restart, start their quarantine at now.
+func (q *quarantine) split(toRemove []StaticRoute, now time.Time) (disable []routeUpdate, remove, held []StaticRoute) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, r := range toRemove {
+		key := routes.Key(r.StaticRouteNetwork, r.StaticRouteNexthop)
+		e, known := q.routes[key]
+		switch {
+		case r.Enabled && r.ID != "":
+			next := r
+			next.Enabled = false
+			disable = append(disable, routeUpdate{from: r, to: next})
+		case !known:
+			q.routes[key] = quarantinedEntry{route: r, since: now}
+			held = append(held, r)
+		case now.Sub(e.since) >= q.period:
+			remove = append(remove, r)
+		default:
+			held = append(held, r)
+		}
+	}
+	return disable, remove, held
+}
+
+// release enables the quarantined routes in current that are desired again:
+// updates already refreshing such a route enable it too, the others get an
+// update of their own.
+func (q *quarantine) release(updates []routeUpdate, current, desired []StaticRoute) []routeUpdate {
+	if q == nil {
+		return updates
+	}
+	wanted := make(map[string]bool, len(desired))
+	for _, r := range desired {
+		wanted[routes.Key(r.StaticRouteNetwork, r.StaticRouteNexthop)] = true
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	updating := make(map[string]int, len(updates))
+	for i, u := range updates {
+		updating[routes.Key(u.from.StaticRouteNetwork, u.from.StaticRouteNexthop)] = i
+	}
+	for _, r := range current {
+		key := routes.Key(r.StaticRouteNetwork, r.StaticRouteNexthop)
+		if _, ok := q.routes[key]; !ok || !wanted[key] || r.Enabled {
+			continue
+		}
+		if i, ok := updating[key]; ok {
+			updates[i].to.Enabled = true
+			continue
+		}
+		next := r
+		next.Enabled = true
+		updates = append(updates, routeUpdate{from: r, to: next})
+	}
+	return updates
+}
+
+// record tracks a route after a successful update: a disabled route enters
+// quarantine at now, an enabled one leaves it.
+func (q *quarantine) record(route StaticRoute, now time.Time) {
+	if q == nil {
+		return
+	}
+	key := routes.Key(route.StaticRouteNetwork, route.StaticRouteNexthop)
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if route.Enabled {
+		delete(q.routes, key)
+	} else if _, ok := q.routes[key]; !ok {
+		q.routes[key] = quarantinedEntry{route: route, since: now}
+	}
+}
+
+// forget stops tracking the route with the given key once it is deleted.
+func (q *quarantine) forget(key string) {
+	if q == nil {
+		return
+	}
+	q.mu.Lock()
+	delete(q.routes, key)
+	q.mu.Unlock()
+}
+
+// entries returns the quarantined routes as they were disabled, by network
+// and next hop.
+func (q *quarantine) entries() []StaticRoute {
+	if q == nil {
+		return nil
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	out := make([]StaticRoute, 0, len(q.routes))
+	for _, e := range q.routes {
+		out = append(out, e.route)
+	}
+	sortStaticRoutes(out)
+	return out
+}
+
+// list returns the quarantined routes, soonest deletion first.
+func (q *quarantine) list() []QuarantinedRoute {
+	out := []QuarantinedRoute{}
+	if q == nil {
+		return out
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for _, e := range q.routes {
+		out = append(out, QuarantinedRoute{
+			Name:      e.route.Name,
+			Network:   e.route.StaticRouteNetwork,
+			Nexthop:   e.route.StaticRouteNexthop,
+			Since:     e.since,
+			DeletesAt: e.since.Add(q.period),
+		})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].DeletesAt.Equal(out[j].DeletesAt) {
+			return out[i].DeletesAt.Before(out[j].DeletesAt)
+		}
+		return out[i].Network+out[i].Nexthop < out[j].Network+out[j].Nexthop
+	})
+	return out
+}
@@ -0,0 +1,85 @@
+package unifi
+
+import (
+	"testing"
+	"time"
+)
+
+func TestQuarantineSplit(t *testing.T) {
+	start := time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC)
+	q := newQuarantine(time.Hour)
+	route := StaticRoute{ID: "r1", Enabled: true, Name: RouteName("Apple TV", "fd00:1::/64", "2001:db8::1"),
+		StaticRouteNetwork: "fd00:1::/64", StaticRouteNexthop: "2001:db8::1"}
+
+	disable, remove, held := q.split([]StaticRoute{route}, start)
+	if len(disable) != 1 || disable[0].to.Enabled || len(remove) != 0 || len(held) != 0 {
+		t.Fatalf("Expected the enabled route disabled, got %+v %+v %+v", disable, remove, held)
+	}
+	if got := disable[0].changes(); got != "disabled" {
+		t.Errorf("Expected the update described as disabled, got %q", got)
+	}
+	q.record(disable[0].to, start)
+
+	disabled := disable[0].to
+	if _, remove, held = q.split([]StaticRoute{disabled}, start.Add(30*time.Minute)); len(remove) != 0 || len(held) != 1 {
+		t.Errorf("Expected the route held within the quarantine period, got %+v %+v", remove, held)
+	}
+	if _, remove, _ = q.split([]StaticRoute{disabled}, start.Add(time.Hour)); len(remove) != 1 {
+		t.Errorf("Expected the route deleted after the quarantine period, got %+v", remove)
+	}
+
+	list := q.list()
+	if len(list) != 1 || !list[0].DeletesAt.Equal(start.Add(time.Hour)) {
+		t.Errorf("Expected the route listed until %v, got %+v", start.Add(time.Hour), list)
+	}
+	q.forget("fd00:1::/64->2001:db8::1")
+	if list := q.list(); len(list) != 0 {
+		t.Errorf("Expected the deleted route forgotten, got %+v", list)
+	}
+}
+
+// TestQuarantineAfterRestart verifies a disabled managed route the quarantine
+// does not know starts its quarantine instead of being deleted.
+func TestQuarantineAfterRestart(t *testing.T) {
+	now := time.Date(2025, 9, 1, 0, 0, 0, 0, time.UTC)
+	q := newQuarantine(time.Hour)
+	route := StaticRoute{ID: "r1", StaticRouteNetwork: "fd00:1::/64", StaticRouteNexthop: "2001:db8::1"}
+	disable, remove, held := q.split([]StaticRoute{route}, now)
+	if len(disable) != 0 || len(remove) != 0 || len(held) != 1 {
+		t.Errorf("Expected the route held, got %+v %+v %+v", disable, remove, held)
+	}
+	if list := q.list(); len(list) != 1 || !list[0].Since.Equal(now) {
+		t.Errorf("Expected the quarantine started at %v, got %+v", now, list)
+	}
+}
+
+func TestQuarantineRelease(t *testing.T) {
+	q := newQuarantine(time.Hour)
+	name := RouteName("Apple TV", "fd00:1::/64", "2001:db8::1")
+	quarantined := StaticRoute{ID: "r1", Name: name, StaticRouteNetwork: "fd00:1::/64", StaticRouteNexthop: "2001:db8::1"}
+	other := StaticRoute{ID: "r2", Name: RouteName("Apple TV", "fd00:2::/64", "2001:db8::2"), StaticRouteNetwork: "fd00:2::/64", StaticRouteNexthop: "2001:db8::2"}
+	unknown := StaticRoute{ID: "r3", Name: RouteName("Apple TV", "fd00:3::/64", "2001:db8::3"), StaticRouteNetwork: "fd00:3::/64", StaticRouteNexthop: "2001:db8::3"}
+	q.record(quarantined, time.Now())
+	q.record(other, time.Now())
+
+	desired := []StaticRoute{
+		{Enabled: true, Name: name, StaticRouteNetwork: "fd00:1::/64", StaticRouteNexthop: "2001:db8::1"},
+		{Enabled: true, Name: other.Name, StaticRouteNetwork: "fd00:2::/64", StaticRouteNexthop: "2001:db8::2"},
+		{Enabled: true, Name: unknown.Name, StaticRouteNetwork: "fd00:3::/64", StaticRouteNexthop: "2001:db8::3"},
+	}
+	refreshing := []routeUpdate{{from: other, to: StaticRoute{ID: "r2", Name: other.Name, Description: "Thread",
+		StaticRouteNetwork: "fd00:2::/64", StaticRouteNexthop: "2001:db8::2"}}}
+	updates := q.release(refreshing, []StaticRoute{quarantined, other, unknown}, desired)
+	if len(updates) != 2 {
+		t.Fatalf("Expected r2's update and r1 enabled, got %+v", updates)
+	}
+	if !updates[0].to.Enabled || updates[0].to.Description != "Thread" {
+		t.Errorf("Expected r2's refresh to enable it too, got %+v", updates[0])
+	}
+	if updates[1].from.ID != "r1" || !updates[1].to.Enabled {
+		t.Errorf("Expected r1 enabled, got %+v", updates[1])
+	}
+	if got := updates[1].changes(); got != "enabled" {
+		t.Errorf("Expected the update described as enabled, got %q", got)
+	}
+}
@@ -0,0 +1,182 @@
+package unifi
+
+import (
+	"errors"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/config"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/logger"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/routes"
+)
+
+// QueueRetry is how often the sync loop checks whether an unreachable
+// controller answers again while route operations are queued.
+const QueueRetry = 5 * time.Second
+
+// QueuedOp is a route addition or removal computed while the controller was
+// unreachable. Queued operations are never replayed: they make the daemon sync
+// as soon as the controller answers, and that sync recomputes its plan from
+// the routes it reads then, marking the changes that were queued.
+type QueuedOp struct {
+	Op        string    `json:"op"` // "add" or "remove"
+	Name      string    `json:"name"`
+	Network   string    `json:"network"`
+	Nexthop   string    `json:"nexthop"`
+	QueuedAt  time.Time `json:"queued_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// isUnreachable reports whether err means the controller could not be
+// reached at all, or answered that it is unavailable for now.
+func isUnreachable(err error) bool {
+	var urlErr *url.Error
+	return errors.As(err, &urlErr) || isUnavailable(err)
+}
+
+// opQueue holds the route operations computed while the controller was
+// unreachable, one per route, against the routes it last returned.
+type opQueue struct {
+	mu     sync.Mutex
+	expiry time.Duration
+	known  []StaticRoute // the controller's routes as last read
+	ops    map[string]QueuedOp
+	now    func() time.Time
+}
+
+func newOpQueue(expiry time.Duration) *opQueue {
+	return &opQueue{expiry: expiry, ops: make(map[string]QueuedOp), now: time.Now}
+}
+
+// remember stores the routes the controller returned, to diff against while it
+// is unreachable.
+func (q *opQueue) remember(current []StaticRoute) {
+	if q == nil {
+		return
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.known = make([]StaticRoute, len(current))
+	copy(q.known, current)
+}
+
+// lastRead returns the routes the controller last returned, nil before the first read.
+func (q *opQueue) lastRead() []StaticRoute {
+	if q == nil {
+		return nil
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return append([]StaticRoute(nil), q.known...)
+}
+
+// replan replaces the queued operations with those turning current, the
+// routes last read, into desired: additions of desired routes that were
+// missing and removals of managed routes not desired whose grace period
+// passed. An operation queued before keeps its queue time, so it still
+// expires on time. It returns the number of operations queued.
+func (q *opQueue) replan(current, desired []StaticRoute, lastSeen map[string]time.Time, grace config.GracePolicy) int {
+	if q == nil {
+		return 0
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if q.known == nil {
+		return 0 // nothing read yet to diff against
+	}
+	now := q.now()
+	wanted := make(map[string]bool, len(desired))
+	for _, r := range desired {
+		wanted[routes.Key(r.StaticRouteNetwork, r.StaticRouteNexthop)] = true
+	}
+	present := make(map[string]bool, len(current))
+	ops := make(map[string]QueuedOp)
+	queue := func(op string, r StaticRoute) {
+		key := routes.Key(r.StaticRouteNetwork, r.StaticRouteNexthop)
+		queued := now
+		if prev, ok := q.ops[key]; ok && prev.Op == op {
+			queued = prev.QueuedAt
+		}
+		ops[key] = QueuedOp{Op: op, Name: r.Name, Network: r.StaticRouteNetwork, Nexthop: r.StaticRouteNexthop,
+			QueuedAt: queued, ExpiresAt: queued.Add(q.expiry)}
+	}
+	for _, r := range current {
+		key := routes.Key(r.StaticRouteNetwork, r.StaticRouteNexthop)
+		present[key] = true
+		if !r.IsThreadRoute() || wanted[key] {
+			continue
+		}
+		if seen, ok := lastSeen[key]; ok && now.Sub(seen) >= grace.For(r.StaticRouteNetwork) {
+			queue("remove", r)
+		}
+	}
+	for _, r := range desired {
+		if !present[routes.Key(r.StaticRouteNetwork, r.StaticRouteNexthop)] {
+			queue("add", r)
+		}
+	}
+	q.ops = ops
+	return len(ops)
+}
+
+// take empties the queue, returning the operations that have not expired by
+// key, for the plan to mark. Expired operations are dropped with a log line;
+// the sync applies whatever its plan calls for all the same.
+func (q *opQueue) take() map[string]QueuedOp {
+	if q == nil {
+		return nil
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	now := q.now()
+	live := make(map[string]QueuedOp, len(q.ops))
+	for key, op := range q.ops {
+		if !now.Before(op.ExpiresAt) {
+			logger.Info("UniFi: queued %s of route %s -> %s expired, queued %s ago",
+				op.Op, op.Network, op.Nexthop, logger.FormatDuration(now.Sub(op.QueuedAt)))
+			continue
+		}
+		live[key] = op
+	}
+	q.ops = make(map[string]QueuedOp)
+	return live
+}
+
+// list returns the queued operations, oldest first.
+func (q *opQueue) list() []QueuedOp {
+	if q == nil {
+		return nil
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	out := make([]QueuedOp, 0, len(q.ops))
+	for _, op := range q.ops {
+		out = append(out, op)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].QueuedAt.Equal(out[j].QueuedAt) {
+			return out[i].QueuedAt.Before(out[j].QueuedAt)
+		}
+		return routes.Key(out[i].Network, out[i].Nexthop) < routes.Key(out[j].Network, out[j].Nexthop)
+	})
+	return out
+}
+
+// size returns the number of queued operations that have not expired.
+func (q *opQueue) size() int {
+	if q == nil {
+		return 0
+	}
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	now := q.now()
+	n := 0
+	for _, op := range q.ops {
+		if now.Before(op.ExpiresAt) {
+			n++
+		}
+	}
+	return n
+}
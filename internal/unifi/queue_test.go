@@ -0,0 +1,119 @@
+package unifi
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/config"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/routes"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/state"
+)
+
+func TestOpQueueReplan(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	q := newOpQueue(15 * time.Minute)
+	q.now = func() time.Time { return now }
+	grace := config.GracePolicy{Default: 10 * time.Minute}
+
+	managed := ConvertRoutes([]routes.Route{
+		{CIDR: netip.MustParsePrefix("fd11:22::/64"), ThreadRouterIPv6: netip.MustParseAddr("fd00::1"), RouterName: "Apple TV"},
+		{CIDR: netip.MustParsePrefix("fd33:44::/64"), ThreadRouterIPv6: netip.MustParseAddr("fd00::2"), RouterName: "HomePod"},
+		{CIDR: netip.MustParsePrefix("fd55:66::/64"), ThreadRouterIPv6: netip.MustParseAddr("fd00::3"), RouterName: "Nest Hub"},
+	}, "")
+	manual := StaticRoute{Name: "manual", StaticRouteNetwork: "fd77::/64", StaticRouteNexthop: "fd00::9"}
+	current := []StaticRoute{managed[0], managed[1], manual}
+	lastSeen := map[string]time.Time{
+		routes.Key("fd11:22::/64", "fd00::1"): now.Add(-time.Hour),   // grace period passed
+		routes.Key("fd33:44::/64", "fd00::2"): now.Add(-time.Minute), // within its grace period
+	}
+
+	if n := q.replan(current, managed[2:], lastSeen, grace); n != 0 {
+		t.Errorf("Expected nothing queued before the controller was read, got %d", n)
+	}
+
+	q.remember(current)
+	if n := q.replan(current, managed[2:], lastSeen, grace); n != 2 {
+		t.Fatalf("Expected 2 operations queued, got %+v", q.list())
+	}
+	ops := q.list()
+	if ops[0].Op != "remove" || ops[0].Network != "fd11:22::/64" || ops[1].Op != "add" || ops[1].Network != "fd55:66::/64" {
+		t.Errorf("Expected the removal of fd11:22::/64 and the addition of fd55:66::/64, got %+v", ops)
+	}
+
+	// Replanned later, the addition keeps its queue time and the removal is
+	// dropped once the route is detected again.
+	now = now.Add(5 * time.Minute)
+	q.replan(current, []StaticRoute{managed[0], managed[2]}, lastSeen, grace)
+	ops = q.list()
+	if len(ops) != 1 || ops[0].Op != "add" || !ops[0].QueuedAt.Equal(now.Add(-5*time.Minute)) {
+		t.Errorf("Expected the addition queued 5 minutes ago only, got %+v", ops)
+	}
+
+	now = now.Add(11 * time.Minute)
+	if q.size() != 0 {
+		t.Errorf("Expected expired operations not to count, got %d", q.size())
+	}
+	if live := q.take(); len(live) != 0 {
+		t.Errorf("Expected expired operations to be dropped, got %+v", live)
+	}
+	if len(q.list()) != 0 {
+		t.Errorf("Expected take to empty the queue, got %+v", q.list())
+	}
+}
+
+func TestPlanReasonsQueued(t *testing.T) {
+	add := StaticRoute{StaticRouteNetwork: "fd11:22::/64", StaticRouteNexthop: "fd00::1"}
+	remove := StaticRoute{StaticRouteNetwork: "fd33:44::/64", StaticRouteNexthop: "fd00::2"}
+	reasons := planReasons{}
+	reasons.set([]StaticRoute{remove}, "not detected for the grace period")
+	reasons.queued(map[string]QueuedOp{
+		routes.Key(add.StaticRouteNetwork, add.StaticRouteNexthop):       {Op: "add"},
+		routes.Key(remove.StaticRouteNetwork, remove.StaticRouteNexthop): {Op: "remove"},
+	}, []StaticRoute{add}, []StaticRoute{remove})
+
+	if got := reasons[routes.Key(add.StaticRouteNetwork, add.StaticRouteNexthop)]; got != "queued while the controller was unreachable" {
+		t.Errorf("Unexpected reason for the addition: %q", got)
+	}
+	if got := reasons[routes.Key(remove.StaticRouteNetwork, remove.StaticRouteNexthop)]; got != "not detected for the grace period, queued while the controller was unreachable" {
+		t.Errorf("Unexpected reason for the removal: %q", got)
+	}
+}
+
+// TestSyncQueuesWhileUnreachable verifies a sync that can't reach the
+// controller queues the routes it would add against the routes last read.
+func TestSyncQueuesWhileUnreachable(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/auth/login":
+			http.SetCookie(w, &http.Cookie{Name: "TOKEN", Value: "session"})
+			_, _ = w.Write([]byte(`{"meta":{"rc":"ok"}}`))
+		case "/proxy/network/api/s/default/rest/routing":
+			_, _ = w.Write([]byte(`{"meta":{"rc":"ok"},"data":[]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+
+	client := NewClient(config.UniFi{APIBaseURL: srv.URL, Enabled: true, APIVersion: "v1", QueueExpiry: time.Minute})
+	syncer := NewSyncer(client, state.New(nil), nil, config.GracePolicy{Default: time.Minute})
+	syncer.Sync(nil)
+	if !syncer.ControllerReachable() {
+		t.Error("Expected the controller to be reachable")
+	}
+
+	srv.Close()
+	if syncer.ControllerReachable() {
+		t.Error("Expected the closed controller to be unreachable")
+	}
+	syncer.client.ClearSession()
+	syncer.Sync([]routes.Route{
+		{CIDR: netip.MustParsePrefix("fd11:22::/64"), ThreadRouterIPv6: netip.MustParseAddr("fd00::1"), RouterName: "Apple TV"},
+	})
+	ops := syncer.QueuedOps()
+	if len(ops) != 1 || ops[0].Op != "add" || ops[0].Network != "fd11:22::/64" || !syncer.HasQueuedOps() {
+		t.Errorf("Expected the route addition to be queued, got %+v", ops)
+	}
+}
@@ -0,0 +1,276 @@
+package unifi
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/logger"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/pkg/unifiroutes"
+)
+
+// redacted replaces secrets in recorded traffic.
+const redacted = "REDACTED"
+
+// exchange is one recorded request and its response, a line of a debug bundle.
+type exchange struct {
+	Time            time.Time   `json:"time"`
+	Method          string      `json:"method"`
+	URL             string      `json:"url"` // path and query; the controller host is left out
+	RequestHeaders  http.Header `json:"request_headers,omitempty"`
+	RequestBody     string      `json:"request_body,omitempty"`
+	Status          int         `json:"status,omitempty"`
+	ResponseHeaders http.Header `json:"response_headers,omitempty"`
+	ResponseBody    string      `json:"response_body,omitempty"`
+	Error           string      `json:"error,omitempty"`
+}
+
+// recordingTransport appends each exchange with the controller to a debug
+// bundle, a JSON Lines file, with credentials, session tokens and secret
+// fields redacted so users can attach it to bug reports.
+type recordingTransport struct {
+	next http.RoundTripper
+	path string
+
+	mu sync.Mutex
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	e := exchange{
+		Time:           time.Now().UTC(),
+		Method:         req.Method,
+		URL:            req.URL.RequestURI(),
+		RequestHeaders: redactHeaders(req.Header),
+	}
+	if req.Body != nil && req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			data, _ := io.ReadAll(body)
+			e.RequestBody = redactBody(data)
+		}
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		e.Error = err.Error()
+		t.write(e)
+		return nil, err
+	}
+	data, err := io.ReadAll(io.LimitReader(resp.Body, unifiroutes.MaxResponseBytes+1))
+	_ = resp.Body.Close()
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+	if err != nil {
+		e.Error = err.Error()
+	}
+	e.Status = resp.StatusCode
+	e.ResponseHeaders = redactHeaders(resp.Header)
+	e.ResponseBody = redactBody(data)
+	t.write(e)
+	return resp, nil
+}
+
+// write appends e to the bundle, logging failures rather than failing the request.
+func (t *recordingTransport) write(e exchange) {
+	line, err := json.Marshal(e)
+	if err != nil {
+		logger.Warn("UniFi: failed to record exchange: %v", err)
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	f, err := os.OpenFile(t.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		logger.Warn("UniFi: failed to record exchange: %v", err)
+		return
+	}
+	defer func() { _ = f.Close() }()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		logger.Warn("UniFi: failed to record exchange: %v", err)
+	}
+}
+
+// replayTransport answers requests from a debug bundle instead of a
+// controller. Exchanges are replayed in order per method and URL; once only
+// the last one is left it answers every further request, so periodic syncs
+// keep seeing the final recorded state.
+type replayTransport struct {
+	mu        sync.Mutex
+	exchanges map[string][]exchange
+	err       error
+}
+
+// newReplayTransport loads the debug bundle at path. A bundle that cannot be
+// read fails every request with the load error.
+func newReplayTransport(path string) *replayTransport {
+	t := &replayTransport{exchanges: make(map[string][]exchange)}
+	t.err = t.load(path)
+	if t.err == nil {
+		logger.Info("UniFi: replaying controller traffic from %s", path)
+	}
+	return t
+}
+
+// load reads the exchanges of a debug bundle.
+func (t *replayTransport) load(path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("replay bundle: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 2*unifiroutes.MaxResponseBytes)
+	for n := 1; scanner.Scan(); n++ {
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var e exchange
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			return fmt.Errorf("replay bundle %s line %d: %w", path, n, err)
+		}
+		key := replayKey(e.Method, e.URL)
+		t.exchanges[key] = append(t.exchanges[key], e)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("replay bundle %s: %w", path, err)
+	}
+	return nil
+}
+
+// RoundTrip implements http.RoundTripper.
+func (t *replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		_ = req.Body.Close()
+	}
+	if t.err != nil {
+		return nil, t.err
+	}
+	key := replayKey(req.Method, req.URL.RequestURI())
+	t.mu.Lock()
+	queue := t.exchanges[key]
+	if len(queue) == 0 {
+		t.mu.Unlock()
+		return nil, fmt.Errorf("replay bundle has no exchange for %s", key)
+	}
+	e := queue[0]
+	if len(queue) > 1 {
+		t.exchanges[key] = queue[1:]
+	}
+	t.mu.Unlock()
+
+	if e.Error != "" && e.Status == 0 {
+		return nil, fmt.Errorf("replayed: %s", e.Error)
+	}
+	header := e.ResponseHeaders
+	if header == nil {
+		header = http.Header{}
+	}
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", e.Status, http.StatusText(e.Status)),
+		StatusCode:    e.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header.Clone(),
+		Body:          io.NopCloser(strings.NewReader(e.ResponseBody)),
+		ContentLength: int64(len(e.ResponseBody)),
+		Request:       req,
+	}, nil
+}
+
+// replayKey identifies the exchanges that answer a request.
+func replayKey(method, url string) string {
+	return method + " " + url
+}
+
+// redactHeaders returns a copy of h with credentials and session tokens
+// redacted. Cookie names are kept so the session handling can be followed.
+func redactHeaders(h http.Header) http.Header {
+	out := h.Clone()
+	for name, values := range out {
+		switch http.CanonicalHeaderKey(name) {
+		case "Authorization", "Proxy-Authorization", "X-Csrf-Token", "X-Updated-Csrf-Token":
+			for i := range values {
+				values[i] = redacted
+			}
+		case "Cookie":
+			for i, v := range values {
+				cookies := strings.Split(v, ";")
+				for j, c := range cookies {
+					if name, _, ok := strings.Cut(c, "="); ok {
+						cookies[j] = name + "=" + redacted
+					}
+				}
+				values[i] = strings.Join(cookies, ";")
+			}
+		case "Set-Cookie":
+			for i, v := range values {
+				cookie, attrs, _ := strings.Cut(v, ";")
+				if name, _, ok := strings.Cut(cookie, "="); ok {
+					cookie = name + "=" + redacted
+				}
+				if attrs != "" {
+					cookie += ";" + attrs
+				}
+				values[i] = cookie
+			}
+		}
+	}
+	return out
+}
+
+// redactBody returns a JSON body with the values of secret fields redacted.
+// Bodies that are not JSON are kept as they are.
+func redactBody(data []byte) string {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var v interface{}
+	if err := dec.Decode(&v); err != nil {
+		return string(data)
+	}
+	out, err := json.Marshal(redactValue(v))
+	if err != nil {
+		return string(data)
+	}
+	return string(out)
+}
+
+// redactValue redacts the secret fields of a decoded JSON value, recursively.
+func redactValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		for k, field := range v {
+			if isSecretField(k) {
+				v[k] = redacted
+			} else {
+				v[k] = redactValue(field)
+			}
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = redactValue(item)
+		}
+	}
+	return v
+}
+
+// isSecretField reports whether a JSON field holds a secret: passwords, keys
+// and tokens, and UniFi's x_ fields (x_passphrase, x_ssh_password, ...).
+func isSecretField(name string) bool {
+	name = strings.ToLower(name)
+	if strings.HasPrefix(name, "x_") {
+		return true
+	}
+	for _, secret := range []string{"password", "passphrase", "secret", "token", "apikey", "api_key", "private"} {
+		if strings.Contains(name, secret) {
+			return true
+		}
+	}
+	return false
+}
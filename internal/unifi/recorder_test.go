@@ -0,0 +1,143 @@
+package unifi
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/config"
+)
+
+// fakeRecordedController answers a login and a route listing like a controller.
+func fakeRecordedController(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/auth/login":
+			http.SetCookie(w, &http.Cookie{Name: "TOKEN", Value: "session-secret", Path: "/"})
+			w.Header().Set("X-CSRF-Token", "csrf-secret")
+			_, _ = w.Write([]byte(`{"username":"tester","deviceToken":"device-secret"}`))
+		case "/proxy/network/api/s/default/rest/routing":
+			_, _ = w.Write([]byte(`{"meta":{"rc":"ok"},"data":[{"_id":"r1","type":"static-route","name":"Thread route via Kitchen",` +
+				`"static-route_network":"fd00:1::/64","static-route_nexthop":"2001:db8::1","x_secret_hint":"hint-value"}]}`))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+// TestRecordAndReplay verifies a recorded session is redacted and that
+// replaying the bundle reproduces it without a controller.
+func TestRecordAndReplay(t *testing.T) {
+	srv := fakeRecordedController(t)
+	bundle := filepath.Join(t.TempDir(), "bundle.jsonl")
+
+	recorder := NewClient(config.UniFi{APIBaseURL: srv.URL, Username: "tester", Password: "hunter2",
+		APIVersion: "v1", RecordFile: bundle})
+	if err := recorder.Login(); err != nil {
+		t.Fatalf("Expected login to succeed, got %v", err)
+	}
+	recorded, err := recorder.StaticRoutes()
+	if err != nil || len(recorded) != 1 {
+		t.Fatalf("Expected one route, got %+v (%v)", recorded, err)
+	}
+
+	data, err := os.ReadFile(bundle)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, secret := range []string{"hunter2", "session-secret", "csrf-secret", "device-secret", "hint-value"} {
+		if strings.Contains(string(data), secret) {
+			t.Errorf("Expected %q to be redacted from the bundle:\n%s", secret, data)
+		}
+	}
+	if lines := strings.Count(string(data), "\n"); lines != 2 {
+		t.Errorf("Expected 2 recorded exchanges, got %d", lines)
+	}
+
+	srv.Close()
+	replayer := NewClient(config.UniFi{APIBaseURL: "https://unifi.invalid", Username: "tester",
+		APIVersion: "v1", ReplayFile: bundle})
+	if err := replayer.Login(); err != nil {
+		t.Fatalf("Expected the replayed login to succeed, got %v", err)
+	}
+	if !replayer.HasValidSession() {
+		t.Error("Expected the replayed login to establish a session")
+	}
+	for range 2 {
+		replayed, err := replayer.StaticRoutes()
+		if err != nil || len(replayed) != 1 || replayed[0].ID != "r1" {
+			t.Errorf("Expected the recorded route, got %+v (%v)", replayed, err)
+		}
+	}
+	if err := replayer.DeleteStaticRoute("r1"); err == nil {
+		t.Error("Expected a request missing from the bundle to fail")
+	}
+}
+
+// TestReplayMissingBundle verifies an unreadable bundle fails every request.
+func TestReplayMissingBundle(t *testing.T) {
+	client := NewClient(config.UniFi{APIBaseURL: "https://unifi.invalid", ReplayFile: filepath.Join(t.TempDir(), "missing.jsonl")})
+	if err := client.Login(); err == nil || !strings.Contains(err.Error(), "replay bundle") {
+		t.Errorf("Expected a replay bundle error, got %v", err)
+	}
+}
+
+// TestRedactHeaders verifies credentials are redacted while cookie names and
+// attributes are kept.
+func TestRedactHeaders(t *testing.T) {
+	h := http.Header{}
+	h.Set("Authorization", "Bearer abc")
+	h.Set("X-Csrf-Token", "def")
+	h.Set("Cookie", "TOKEN=abc; theme=dark")
+	h.Add("Set-Cookie", "TOKEN=abc; Path=/; HttpOnly")
+	h.Set("Content-Type", "application/json")
+
+	got := redactHeaders(h)
+	want := map[string]string{
+		"Authorization": redacted,
+		"X-Csrf-Token":  redacted,
+		"Cookie":        "TOKEN=REDACTED; theme=REDACTED",
+		"Set-Cookie":    "TOKEN=REDACTED; Path=/; HttpOnly",
+		"Content-Type":  "application/json",
+	}
+	for name, value := range want {
+		if got.Get(name) != value {
+			t.Errorf("Expected %s %q, got %q", name, value, got.Get(name))
+		}
+	}
+	if h.Get("Authorization") != "Bearer abc" {
+		t.Error("Expected the original headers to be left untouched")
+	}
+}
+
+// TestRedactBody verifies secret fields are redacted at any depth.
+func TestRedactBody(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want string
+	}{
+		{"login", `{"username":"u","password":"p"}`, `{"password":"REDACTED","username":"u"}`},
+		{"nested", `{"data":[{"x_passphrase":"p","name":"wifi","distance":1}]}`, `{"data":[{"distance":1,"name":"wifi","x_passphrase":"REDACTED"}]}`},
+		{"tokens", `{"deviceToken":"t","apiKey":"k"}`, `{"apiKey":"REDACTED","deviceToken":"REDACTED"}`},
+		{"not json", `<html>error</html>`, `<html>error</html>`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := redactBody([]byte(tt.body))
+			if got != tt.want {
+				t.Errorf("Expected %s, got %s", tt.want, got)
+			}
+			if tt.name != "not json" && !json.Valid([]byte(got)) {
+				t.Errorf("Expected valid JSON, got %s", got)
+			}
+		})
+	}
+}
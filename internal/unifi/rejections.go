@@ -0,0 +1,124 @@
+package unifi
+
+import (
+	"errors"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Rejection records a route the controller refused with a validation error.
+type Rejection struct {
+	Network       string    `json:"network"`
+	Nexthop       string    `json:"nexthop"`
+	Reason        string    `json:"reason"`
+	Attempts      int       `json:"attempts"`
+	FirstRejected time.Time `json:"first_rejected"`
+	LastRejected  time.Time `json:"last_rejected"`
+	RetryAt       time.Time `json:"retry_at"`
+}
+
+// rejectionCache is a negative cache of rejected routes. Each further rejection
+// doubles the wait before the route is offered to the controller again, so a
+// persistent misconfiguration does not produce a failed API call every cycle.
+type rejectionCache struct {
+	mu        sync.Mutex
+	entries   map[string]*Rejection
+	baseDelay time.Duration
+	maxDelay  time.Duration
+	now       func() time.Time
+}
+
+func newRejectionCache(baseDelay, maxDelay time.Duration) *rejectionCache {
+	return &rejectionCache{
+		entries:   make(map[string]*Rejection),
+		baseDelay: baseDelay,
+		maxDelay:  maxDelay,
+		now:       time.Now,
+	}
+}
+
+// isRejection reports whether err is a controller validation error (HTTP 400)
+// rather than a transient or authentication failure.
+func isRejection(err error) bool {
+	var apiErr *APIError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusBadRequest
+}
+
+// record notes a rejection of the route with the given key and schedules its next retry.
+func (c *rejectionCache) record(key string, route StaticRoute, reason string) Rejection {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := c.now()
+	r, ok := c.entries[key]
+	if !ok {
+		r = &Rejection{Network: route.StaticRouteNetwork, Nexthop: route.StaticRouteNexthop, FirstRejected: now}
+		c.entries[key] = r
+	}
+	r.Reason = reason
+	r.Attempts++
+	r.LastRejected = now
+	delay := c.baseDelay
+	for i := 1; i < r.Attempts && delay < c.maxDelay; i++ {
+		delay *= 2
+	}
+	if delay > c.maxDelay {
+		delay = c.maxDelay
+	}
+	r.RetryAt = now.Add(delay)
+	return *r
+}
+
+// blocked reports whether the route with the given key is still backing off.
+func (c *rejectionCache) blocked(key string) (Rejection, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	r, ok := c.entries[key]
+	if !ok || !c.now().Before(r.RetryAt) {
+		return Rejection{}, false
+	}
+	return *r, true
+}
+
+// clear forgets the route with the given key, e.g. after it was accepted.
+func (c *rejectionCache) clear(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.entries, key)
+}
+
+// reset forgets every rejection, so all routes are offered again.
+func (c *rejectionCache) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.entries)
+}
+
+// retain drops entries for routes no longer wanted.
+func (c *rejectionCache) retain(wanted map[string]bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key := range c.entries {
+		if !wanted[key] {
+			delete(c.entries, key)
+		}
+	}
+}
+
+// list returns the cached rejections ordered by network and next hop.
+func (c *rejectionCache) list() []Rejection {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	out := make([]Rejection, 0, len(c.entries))
+	for _, r := range c.entries {
+		out = append(out, *r)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Network != out[j].Network {
+			return out[i].Network < out[j].Network
+		}
+		return out[i].Nexthop < out[j].Nexthop
+	})
+	return out
+}
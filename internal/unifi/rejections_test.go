@@ -0,0 +1,72 @@
+package unifi
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestRejectionCacheBackoff(t *testing.T) {
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := newRejectionCache(time.Minute, 5*time.Minute)
+	c.now = func() time.Time { return now }
+	route := StaticRoute{StaticRouteNetwork: "fd00::/64", StaticRouteNexthop: "2001:4860::1"}
+
+	wantDelays := []time.Duration{time.Minute, 2 * time.Minute, 4 * time.Minute, 5 * time.Minute, 5 * time.Minute}
+	for i, want := range wantDelays {
+		r := c.record("k", route, "invalid")
+		if r.Attempts != i+1 {
+			t.Errorf("Expected attempt %d, got %d", i+1, r.Attempts)
+		}
+		if got := r.RetryAt.Sub(now); got != want {
+			t.Errorf("Attempt %d: expected delay %v, got %v", i+1, want, got)
+		}
+	}
+
+	if _, blocked := c.blocked("k"); !blocked {
+		t.Error("Expected route to be blocked during backoff")
+	}
+	now = now.Add(5 * time.Minute)
+	if _, blocked := c.blocked("k"); blocked {
+		t.Error("Expected route to be retried after backoff")
+	}
+
+	c.retain(map[string]bool{})
+	if len(c.list()) != 0 {
+		t.Error("Expected unwanted rejection to be dropped")
+	}
+}
+
+func TestSkipRejected(t *testing.T) {
+	s := &Syncer{rejections: newRejectionCache(time.Minute, time.Hour)}
+	rejected := StaticRoute{StaticRouteNetwork: "fd00::/64", StaticRouteNexthop: "2001:4860::1"}
+	ok := StaticRoute{StaticRouteNetwork: "fd00::/64", StaticRouteNexthop: "2001:4860::2"}
+	s.rejections.record("fd00::/64->2001:4860::1", rejected, "bad nexthop")
+
+	out := s.skipRejected([]StaticRoute{rejected, ok}, []StaticRoute{rejected, ok})
+	if len(out) != 1 || out[0].StaticRouteNexthop != ok.StaticRouteNexthop {
+		t.Errorf("Expected only the non-rejected route, got %+v", out)
+	}
+	if len(s.Rejections()) != 1 {
+		t.Errorf("Expected rejection to be listed, got %+v", s.Rejections())
+	}
+}
+
+func TestIsRejection(t *testing.T) {
+	if !isRejection(fmt.Errorf("wrapped: %w", &APIError{StatusCode: 400})) {
+		t.Error("Expected 400 to be a rejection")
+	}
+	if isRejection(&APIError{StatusCode: 401}) || isRejection(errors.New("timeout")) {
+		t.Error("Expected non-400 errors not to be rejections")
+	}
+}
+
+func TestRejectionCacheReset(t *testing.T) {
+	c := newRejectionCache(time.Minute, 5*time.Minute)
+	c.record("k", StaticRoute{StaticRouteNetwork: "fd00::/64", StaticRouteNexthop: "2001:4860::1"}, "invalid")
+	c.reset()
+	if _, blocked := c.blocked("k"); blocked {
+		t.Error("Expected the route offered again after a reset")
+	}
+}
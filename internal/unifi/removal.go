@@ -0,0 +1,135 @@
+package unifi
+
+import (
+	"sync"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/logger"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/routes"
+)
+
+// removalChecks confirms that the border routers of routes due for removal are
+// gone or no longer announce the route's network. The check runs before a sync
+// takes the syncer's lock, against the routes the controller last returned, so
+// a slow query holds up neither syncs nor status calls.
+type removalChecks struct {
+	mu    sync.Mutex
+	check func(network, nexthop string) bool // nil disables the check
+	known []StaticRoute                      // the controller's routes as last read
+	gone  map[string]bool                    // route keys not confirmed by the last check
+}
+
+// set installs check, or disables the check when it is nil.
+func (c *removalChecks) set(check func(network, nexthop string) bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.check = check
+	c.gone = nil
+}
+
+// enabled reports whether routes are checked before their removal.
+func (c *removalChecks) enabled() bool {
+	if c == nil {
+		return false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.check != nil
+}
+
+// remember stores the routes the controller returned, to pick the next
+// check's candidates from.
+func (c *removalChecks) remember(current []StaticRoute) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.check == nil {
+		return
+	}
+	c.known = append([]StaticRoute(nil), current...)
+}
+
+// run checks each route last read that due selects, one query per route, and
+// returns those whose router still answers for their network. The others may be
+// removed until the next check.
+func (c *removalChecks) run(due func(StaticRoute) bool) []StaticRoute {
+	c.mu.Lock()
+	check := c.check
+	known := c.known
+	c.mu.Unlock()
+	if check == nil {
+		return nil
+	}
+
+	gone := make(map[string]bool)
+	var alive []StaticRoute
+	for _, r := range known {
+		if !due(r) {
+			continue
+		}
+		key := routes.Key(r.StaticRouteNetwork, r.StaticRouteNexthop)
+		if check(r.StaticRouteNetwork, r.StaticRouteNexthop) {
+			alive = append(alive, r)
+		} else {
+			gone[key] = true
+		}
+	}
+
+	c.mu.Lock()
+	c.gone = gone
+	c.mu.Unlock()
+	return alive
+}
+
+// confirmed splits toRemove into the routes the last check did not confirm
+// and those not checked yet, which wait for the next one.
+func (c *removalChecks) confirmed(toRemove []StaticRoute) (remove, unchecked []StaticRoute) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, r := range toRemove {
+		if c.gone[routes.Key(r.StaticRouteNetwork, r.StaticRouteNexthop)] {
+			remove = append(remove, r)
+		} else {
+			unchecked = append(unchecked, r)
+		}
+	}
+	return remove, unchecked
+}
+
+// requeryRemovals asks the removal check whether the border routers of the
+// managed routes due for removal still answer and announce the route's
+// network. Routes whose router does have their grace period restarted. It runs
+// before Sync takes s.mu.
+func (s *Syncer) requeryRemovals(detected []routes.Route) {
+	if !s.removals.enabled() {
+		return
+	}
+	desired := make(map[string]bool, len(detected))
+	for _, r := range ConvertRoutes(detected, "") {
+		desired[routes.Key(r.StaticRouteNetwork, r.StaticRouteNexthop)] = true
+	}
+	s.settingsMu.RLock()
+	grace := s.state.LifetimeGrace(s.grace)
+	s.settingsMu.RUnlock()
+	lastSeen := s.state.RouteLastSeen()
+	now := time.Now()
+
+	alive := s.removals.run(func(r StaticRoute) bool {
+		key := routes.Key(r.StaticRouteNetwork, r.StaticRouteNexthop)
+		seen, ok := lastSeen[key]
+		return r.IsThreadRoute() && !desired[key] && ok && now.Sub(seen) >= grace.For(r.StaticRouteNetwork)
+	})
+	if len(alive) == 0 {
+		return
+	}
+	now = time.Now()
+	s.state.UpdateRouteLastSeen(func(routeLastSeen map[string]time.Time) {
+		for _, r := range alive {
+			routeLastSeen[routes.Key(r.StaticRouteNetwork, r.StaticRouteNexthop)] = now
+			logger.Info("UniFi: border router %s still announces %s, keeping its route for another grace period",
+				r.StaticRouteNexthop, r.StaticRouteNetwork)
+		}
+	})
+}
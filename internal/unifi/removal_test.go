@@ -0,0 +1,55 @@
+package unifi
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/config"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/routes"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/state"
+)
+
+// TestRequeryRemovals verifies each route due for removal is checked against
+// its own border router and network, so a router that is gone loses its route
+// even while another router still answers for the same network, and a live
+// router loses its route to a prefix it no longer announces.
+func TestRequeryRemovals(t *testing.T) {
+	st := state.New(nil)
+	s := &Syncer{state: st, grace: config.GracePolicy{Default: time.Minute}, removals: &removalChecks{}}
+	var checked []string
+	s.SetRemovalCheck(func(network, nexthop string) bool {
+		checked = append(checked, nexthop)
+		return nexthop == "2001:db8::1" && network == "fd00:1::/64"
+	})
+
+	alive := StaticRoute{Name: RouteName("Apple TV", "fd00:1::/64", "2001:db8::1"), StaticRouteNetwork: "fd00:1::/64", StaticRouteNexthop: "2001:db8::1"}
+	dead := StaticRoute{Name: RouteName("HomePod", "fd00:1::/64", "2001:db8::2"), StaticRouteNetwork: "fd00:1::/64", StaticRouteNexthop: "2001:db8::2"}
+	fresh := StaticRoute{Name: RouteName("Nest Hub", "fd00:2::/64", "2001:db8::3"), StaticRouteNetwork: "fd00:2::/64", StaticRouteNexthop: "2001:db8::3"}
+	manual := StaticRoute{Name: "Office", StaticRouteNetwork: "fd00:3::/64", StaticRouteNexthop: "2001:db8::4"}
+	stale := StaticRoute{Name: RouteName("Apple TV", "fd00:9::/64", "2001:db8::1"), StaticRouteNetwork: "fd00:9::/64", StaticRouteNexthop: "2001:db8::1"}
+	past := time.Now().Add(-time.Hour)
+	st.UpdateRouteLastSeen(func(routeLastSeen map[string]time.Time) {
+		for _, r := range []StaticRoute{alive, dead, manual, stale} {
+			routeLastSeen[routes.Key(r.StaticRouteNetwork, r.StaticRouteNexthop)] = past
+		}
+		routeLastSeen[routes.Key(fresh.StaticRouteNetwork, fresh.StaticRouteNexthop)] = time.Now()
+	})
+	s.removals.remember([]StaticRoute{alive, dead, fresh, manual, stale})
+
+	s.requeryRemovals(nil)
+	if len(checked) != 3 {
+		t.Errorf("Expected the three managed routes past their grace period checked, got %v", checked)
+	}
+	if seen := st.RouteLastSeen()[routes.Key(alive.StaticRouteNetwork, alive.StaticRouteNexthop)]; time.Since(seen) > time.Minute {
+		t.Errorf("Expected the grace period of the route via the answering router restarted, last seen %v", seen)
+	}
+
+	late := StaticRoute{StaticRouteNetwork: "fd00:4::/64", StaticRouteNexthop: "2001:db8::5"}
+	remove, unchecked := s.removals.confirmed([]StaticRoute{dead, stale, late})
+	if len(remove) != 2 || remove[0].StaticRouteNexthop != "2001:db8::2" || remove[1].StaticRouteNetwork != "fd00:9::/64" {
+		t.Errorf("Expected the routes via the silent router and to the stale prefix removed, got %+v", remove)
+	}
+	if len(unchecked) != 1 || unchecked[0].StaticRouteNexthop != "2001:db8::5" {
+		t.Errorf("Expected the route not checked yet held, got %+v", unchecked)
+	}
+}
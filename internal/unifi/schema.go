@@ -0,0 +1,46 @@
+package unifi
+
+import (
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/pkg/unifiroutes"
+)
+
+// flexInt and flexBool decode the loosely typed fields of controller objects.
+type (
+	flexInt  = unifiroutes.FlexInt
+	flexBool = unifiroutes.FlexBool
+)
+
+// UnmarshalJSON decodes a legacy static route, tolerating loosely typed fields.
+func (r *StaticRoute) UnmarshalJSON(data []byte) error {
+	return (*unifiroutes.Route)(r).UnmarshalJSON(data)
+}
+
+// UnmarshalJSON decodes a device, tolerating a state sent as a string.
+func (d *GatewayDevice) UnmarshalJSON(data []byte) error {
+	type plain GatewayDevice
+	aux := struct {
+		*plain
+		State flexInt `json:"state"`
+	}{plain: (*plain)(d)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	d.State = int(aux.State)
+	return nil
+}
+
+// flexCounter is a traffic counter the controller may send as a number or a
+// numeric string; anything else reads as 0. Counters exceed 32 bits, so they
+// are kept as float64 rather than int.
+type flexCounter float64
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (f *flexCounter) UnmarshalJSON(data []byte) error {
+	v, _ := strconv.ParseFloat(strings.Trim(string(data), `"`), 64)
+	*f = flexCounter(v)
+	return nil
+}
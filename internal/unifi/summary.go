@@ -0,0 +1,65 @@
+package unifi
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/logger"
+)
+
+// syncSummary is the one-line account of a sync cycle, e.g.
+// "+2 -1 ~0 kept=5 damped=1 backend=unifi dur=840ms".
+type syncSummary struct {
+	batchResult
+	kept, damped, held int
+	duration           time.Duration
+}
+
+// String formats the summary; held, rejected, failed and skipped routes are only
+// listed when there are any.
+func (s syncSummary) String() string {
+	parts := []string{
+		fmt.Sprintf("+%d -%d ~%d", s.added, s.removed, s.updated),
+		fmt.Sprintf("kept=%d", s.kept),
+		fmt.Sprintf("damped=%d", s.damped),
+	}
+	for _, extra := range []struct {
+		name  string
+		count int
+	}{{"held", s.held}, {"rejected", s.rejected}, {"failed", s.failed}, {"skipped", s.skipped}} {
+		if extra.count > 0 {
+			parts = append(parts, fmt.Sprintf("%s=%d", extra.name, extra.count))
+		}
+	}
+	parts = append(parts, "backend=unifi", "dur="+s.duration.Round(time.Millisecond).String())
+	return strings.Join(parts, " ")
+}
+
+// changed reports whether the sync changed any route or failed to.
+func (s syncSummary) changed() bool {
+	return s.added+s.removed+s.updated+s.rejected+s.failed > 0
+}
+
+// logSummary logs the sync summary at INFO, or at DEBUG when the "quiet"
+// verbosity is set and nothing changed. Syncs with errors are logged at WARN.
+func (s *Syncer) logSummary(summary syncSummary) {
+	switch {
+	case summary.rejected+summary.failed > 0:
+		logger.Warn("UniFi: sync %s", summary)
+	case s.syncLog == "quiet" && !summary.changed():
+		logger.Debug("UniFi: sync %s", summary)
+	default:
+		logger.Info("UniFi: sync %s", summary)
+	}
+}
+
+// logChange logs a route change, at INFO with the "detail" verbosity and at DEBUG
+// otherwise.
+func (s *Syncer) logChange(format string, args ...interface{}) {
+	if s.syncLog == "detail" {
+		logger.Info(format, args...)
+		return
+	}
+	logger.Debug(format, args...)
+}
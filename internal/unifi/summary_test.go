@@ -0,0 +1,29 @@
+package unifi
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSyncSummary(t *testing.T) {
+	tests := []struct {
+		summary  syncSummary
+		expected string
+		changed  bool
+	}{
+		{syncSummary{kept: 5, duration: 12 * time.Millisecond},
+			"+0 -0 ~0 kept=5 damped=0 backend=unifi dur=12ms", false},
+		{syncSummary{batchResult: batchResult{added: 2, removed: 1}, kept: 5, damped: 1, duration: 840400 * time.Microsecond},
+			"+2 -1 ~0 kept=5 damped=1 backend=unifi dur=840ms", true},
+		{syncSummary{batchResult: batchResult{updated: 1, rejected: 1, failed: 2}, held: 3, duration: 2 * time.Second},
+			"+0 -0 ~1 kept=0 damped=0 held=3 rejected=1 failed=2 backend=unifi dur=2s", true},
+	}
+	for _, tt := range tests {
+		if got := tt.summary.String(); got != tt.expected {
+			t.Errorf("Expected %q, got %q", tt.expected, got)
+		}
+		if got := tt.summary.changed(); got != tt.changed {
+			t.Errorf("%q: expected changed=%v, got %v", tt.expected, tt.changed, got)
+		}
+	}
+}
@@ -0,0 +1,1334 @@
+package unifi
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/config"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/events"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/logger"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/routes"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/state"
+)
+
+// Syncer reconciles the controller's static routes with the detected routes.
+type Syncer struct {
+	client *Client
+	state  *state.State
+	bus    *events.Bus
+	grace  config.GracePolicy
+	limits config.RouteLimits
+	window config.Windows // when routes may be removed; additions are never held
+
+	mu            sync.Mutex   // serialises route sync runs
+	settingsMu    sync.RWMutex // guards grace, limits and syncLog against Reconfigure outside syncs
+	gatewayPicker *gatewayPicker
+	rejections    *rejectionCache
+	graceTimers   *graceTracker
+	approval      *approvalQueue // nil unless changes need approval
+	damping       *flapDamper    // nil unless flap damping is enabled
+	quarantine    *quarantine    // nil unless removed routes are quarantined first
+	firewall      config.Firewall
+	plans         *planLog
+	workers       int
+	syncLog       string // "quiet", "summary" or "detail"
+	phases        *syncPhases
+	pause         *syncPause
+	queue         *opQueue // nil unless operations are queued while the controller is unreachable
+	backupWarned  bool     // the default route backup failed and was warned about
+	// routeInterface and routeInterfaceName are the ID and name of the
+	// network routes go out of as interface routes; empty for next hop routes.
+	routeInterface     string
+	routeInterfaceName string
+	// removals, when a check is set, confirms that a route's border router
+	// is gone before the route is removed.
+	removals *removalChecks
+}
+
+// NewSyncer returns a Syncer that programs routes through client, tracking route
+// lifecycle in st and holding vanished routes for the grace period grace assigns
+// to their network. Route and sync outcomes are published to bus, which may be nil.
+func NewSyncer(client *Client, st *state.State, bus *events.Bus, grace config.GracePolicy) *Syncer {
+	var approval *approvalQueue
+	if client.cfg.Approval {
+		approval = &approvalQueue{}
+	}
+	var damping *flapDamper
+	if client.cfg.Damping.Enabled && client.cfg.Damping.HalfLife > 0 {
+		damping = newFlapDamper(client.cfg.Damping)
+	}
+	var held *quarantine
+	if client.cfg.Quarantine > 0 {
+		held = newQuarantine(client.cfg.Quarantine)
+	}
+	var queue *opQueue
+	if client.cfg.QueueExpiry > 0 {
+		queue = newOpQueue(client.cfg.QueueExpiry)
+	}
+	return &Syncer{
+		client:        client,
+		state:         st,
+		bus:           bus,
+		grace:         grace,
+		limits:        client.cfg.Limits,
+		window:        client.cfg.RemovalWindows,
+		gatewayPicker: newGatewayPicker(client.cfg),
+		rejections:    newRejectionCache(time.Minute, 6*time.Hour),
+		graceTimers:   newGraceTracker(),
+		approval:      approval,
+		damping:       damping,
+		quarantine:    held,
+		firewall:      client.cfg.Firewall,
+		plans:         &planLog{dir: client.cfg.PlanDir, keep: client.cfg.PlanHistory},
+		workers:       defaultWorkers,
+		syncLog:       client.cfg.SyncLog,
+		phases:        newSyncPhases(),
+		pause:         newSyncPause(),
+		queue:         queue,
+		removals:      &removalChecks{},
+	}
+}
+
+// Reconfigure replaces the grace policy, route limits and sync log verbosity,
+// e.g. after a configuration reload. It waits for a running sync to finish.
+func (s *Syncer) Reconfigure(grace config.GracePolicy, limits config.RouteLimits, syncLog string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.settingsMu.Lock()
+	defer s.settingsMu.Unlock()
+	s.grace, s.limits, s.syncLog = grace, limits, syncLog
+}
+
+// SetRemovalCheck makes the syncer ask check, before removing routes whose
+// grace period passed, whether the border router each goes through still
+// answers for the route's network. Routes whose router answers have their grace
+// period restarted instead; routes not checked yet wait for the next sync.
+func (s *Syncer) SetRemovalCheck(check func(network, nexthop string) bool) {
+	s.removals.set(check)
+}
+
+// PendingPlan returns the route changes awaiting approval, or nil when none are
+// pending or approval mode is off.
+func (s *Syncer) PendingPlan() *Plan {
+	if s.approval == nil {
+		return nil
+	}
+	return s.approval.plan()
+}
+
+// Approve lets the next sync apply the pending plan with the given ID.
+func (s *Syncer) Approve(id string) error {
+	if s.approval == nil {
+		return errors.New("route approval is not enabled")
+	}
+	return s.approval.approve(id)
+}
+
+// DropCaches forgets the controller rejections still backing off, the flap
+// damping penalties, the gateway devices read and the route API detected, so
+// the next sync offers every route again and re-reads the controller, its
+// version included, e.g. after routes were changed there by hand or the
+// controller was upgraded. It waits for a running sync to finish.
+func (s *Syncer) DropCaches() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.rejections.reset()
+	s.damping.reset()
+	s.gatewayPicker.invalidate()
+	s.client.ForgetAPI()
+}
+
+// LastPlan returns the plan of the most recent sync, or nil before the first one.
+func (s *Syncer) LastPlan() *Plan {
+	return s.plans.latest()
+}
+
+// Rejections returns the routes the controller refused that are backing off.
+func (s *Syncer) Rejections() []Rejection {
+	return s.rejections.list()
+}
+
+// DampedRoutes returns the routes tracked by flap damping, including those
+// suppressed; it is empty when damping is disabled.
+func (s *Syncer) DampedRoutes() []DampedRoute {
+	return s.damping.list()
+}
+
+// QuarantinedRoutes returns the routes disabled on the controller until their
+// quarantine period passes; it is empty when quarantine is disabled.
+func (s *Syncer) QuarantinedRoutes() []QuarantinedRoute {
+	return s.quarantine.list()
+}
+
+// RestoreQuarantined enables every quarantined route on the controller right
+// away and restarts its grace period, returning the routes restored. Routes
+// the controller fails to enable stay quarantined and are reported in the error.
+func (s *Syncer) RestoreQuarantined() ([]QuarantinedRoute, error) {
+	if s.quarantine == nil {
+		return nil, errors.New("route quarantine is not enabled")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.client.HasValidSession() {
+		if err := s.client.Login(); err != nil {
+			return nil, err
+		}
+	}
+	restored := []QuarantinedRoute{}
+	var failed []string
+	for _, r := range s.quarantine.entries() {
+		next := r
+		next.Enabled = true
+		if err := s.client.UpdateStaticRoute(next); err != nil {
+			logger.Error("UniFi: restoring quarantined route %s -> %s failed: %v", r.StaticRouteNetwork, r.StaticRouteNexthop, err)
+			s.publishFailure("update", next, err)
+			failed = append(failed, r.StaticRouteNetwork+" -> "+r.StaticRouteNexthop)
+			continue
+		}
+		now := time.Now()
+		key := routes.Key(r.StaticRouteNetwork, r.StaticRouteNexthop)
+		s.quarantine.record(next, now)
+		s.state.UpdateRouteLastSeen(func(routeLastSeen map[string]time.Time) { routeLastSeen[key] = now })
+		s.logChange("UniFi: restored quarantined route %s -> %s (%s)", r.StaticRouteNetwork, r.StaticRouteNexthop, r.Name)
+		s.bus.Publish(events.Event{Kind: events.RouteUpdated, Name: r.Name,
+			Prefix: r.StaticRouteNetwork, Nexthop: r.StaticRouteNexthop, Detail: "restored from quarantine"})
+		restored = append(restored, QuarantinedRoute{Name: r.Name, Network: r.StaticRouteNetwork, Nexthop: r.StaticRouteNexthop})
+	}
+	if len(failed) > 0 {
+		return restored, fmt.Errorf("could not restore %s", strings.Join(failed, ", "))
+	}
+	return restored, nil
+}
+
+// Gateways returns the controller's gateway devices and the one routes are
+// programmed on unless a rule or UBIQUITY_GATEWAY_DEVICE picks another.
+func (s *Syncer) Gateways() GatewayStatus {
+	return s.gatewayPicker.status()
+}
+
+// SyncStatus returns the phase of the running sync, the phase durations of the
+// current or last sync, where the last failed sync stopped and whether syncs
+// are paused for an unavailable controller.
+func (s *Syncer) SyncStatus() SyncStatus {
+	status := s.phases.get()
+	status.Paused = s.pause.status()
+	return status
+}
+
+// failSync records err against the current phase and publishes the failure.
+// An unavailable controller pauses syncs instead.
+func (s *Syncer) failSync(err error) {
+	if isUnavailable(err) {
+		s.pauseSync(err)
+		return
+	}
+	s.phases.fail(err)
+	s.bus.Publish(events.Event{Kind: events.SyncFailed, Err: err})
+}
+
+// failFetch fails a sync that could not read the controller's routes. When
+// the controller is unreachable, the route operations detected would call
+// for are queued, so the daemon syncs as soon as it returns.
+func (s *Syncer) failFetch(err error, detected []routes.Route) {
+	if isUnreachable(err) {
+		s.queueOffline(detected)
+	}
+	s.failSync(err)
+}
+
+// queueOffline replans the queued route operations from detected against the
+// routes the controller last returned.
+func (s *Syncer) queueOffline(detected []routes.Route) {
+	if s.queue == nil {
+		return
+	}
+	known := s.queue.lastRead()
+	desired := ConvertRoutes(detected, "")
+	if s.routeInterface != "" {
+		desired = interfaceRoutes(desired, s.routeInterface, s.routeInterfaceName)
+	}
+	clearInterfaceNexthops(known)
+	known, desired = splitOverridden(known, desired, s.state.RouteOverrides())
+	if n := s.queue.replan(known, desired, s.state.RouteLastSeen(), s.state.LifetimeGrace(s.grace)); n > 0 {
+		logger.Info("UniFi: controller unreachable, %d route operations queued", n)
+	}
+}
+
+// QueuedOps returns the route operations queued while the controller is
+// unreachable, oldest first.
+func (s *Syncer) QueuedOps() []QueuedOp {
+	return s.queue.list()
+}
+
+// HasQueuedOps reports whether unexpired route operations wait for the
+// controller, which the daemon then checks for every QueueRetry.
+func (s *Syncer) HasQueuedOps() bool {
+	return s.queue.size() > 0
+}
+
+// ControllerReachable reports whether the controller answers HTTP, without
+// logging in or counting a failed sync.
+func (s *Syncer) ControllerReachable() bool {
+	return s.client.Reachable()
+}
+
+// pauseSync pauses syncs with backoff after the controller answered err while
+// restarting, provisioning or updating, warning only when the pause begins.
+func (s *Syncer) pauseSync(err error) {
+	s.phases.pause(err)
+	pause := s.pause.fail(err)
+	if pause.Attempts == 1 {
+		logger.Warn("UniFi: controller unavailable (%s), pausing syncs until %s",
+			pause.Reason, pause.Until.Format(time.RFC3339))
+		return
+	}
+	logger.Debug("UniFi: controller still unavailable after %d attempts, pausing syncs until %s",
+		pause.Attempts, pause.Until.Format(time.RFC3339))
+}
+
+// resumeSync ends a pause once the controller answers again, dropping the
+// rejections and gateways read before it so the sync reconciles every route
+// against what the controller holds now. s.mu must be held.
+func (s *Syncer) resumeSync() {
+	pause, ok := s.pause.resume()
+	if !ok {
+		return
+	}
+	logger.Info("UniFi: controller available again after %s, resyncing all routes",
+		logger.FormatDuration(time.Since(pause.Since)))
+	s.rejections.reset()
+	s.gatewayPicker.invalidate()
+}
+
+// backup takes the route backup before the daemon's first change to the
+// controller, when none was taken yet. Only a backup configured explicitly
+// holds changes back: the default one is retried at later syncs with a
+// warning. s.mu must be held.
+func (s *Syncer) backup() error {
+	err := s.client.backupRoutes(s.client.cfg.BackupFile)
+	switch {
+	case err == nil:
+		return nil
+	case s.client.cfg.BackupRequired:
+		return fmt.Errorf("%w: %v", errNoBackup, err)
+	case !s.backupWarned:
+		s.backupWarned = true
+		logger.Warn("UniFi: could not back up the routes before changing them, continuing without a backup: %v", err)
+	default:
+		logger.Debug("UniFi: route backup still failing: %v", err)
+	}
+	return nil
+}
+
+// Sync updates the UniFi controller with the current routes
+func (s *Syncer) Sync(detected []routes.Route) {
+	s.requeryRemovals(detected)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if pause, paused := s.pause.waiting(); paused {
+		logger.Debug("UniFi: controller unavailable, sync paused until %s", pause.Until.Format(time.RFC3339))
+		return
+	}
+	if s.firewall.Enabled {
+		defer s.syncFirewall()
+	}
+
+	started := time.Now()
+	s.phases.begin()
+	defer s.phases.finish()
+	logger.Debug("UniFi: syncing static routes...")
+
+	if !s.client.HasValidSession() {
+		logger.Debug("UniFi: authenticating...")
+		if err := s.client.Login(); err != nil {
+			if !isUnavailable(err) {
+				logger.Error("UniFi: login failed: %v", err)
+			}
+			s.failFetch(err, detected)
+			return
+		}
+	} else {
+		logger.Debug("UniFi: reusing session (age %s)", logger.FormatDuration(s.client.SessionAge()))
+	}
+
+	s.phases.enter(PhaseFetch)
+	currentRoutes, err := s.client.StaticRoutes()
+	if err != nil && isUnavailable(err) {
+		s.failFetch(err, detected)
+		return
+	}
+	if err != nil {
+		logger.Error("UniFi: failed to get current routes: %v", err)
+		if strings.Contains(err.Error(), "429") || strings.Contains(err.Error(), "AUTHENTICATION_FAILED_LIMIT_REACHED") {
+			logger.Warn("UniFi: rate limit reached, skipping")
+			s.client.ClearSession()
+			s.failFetch(err, detected)
+			return
+		}
+		s.client.ClearSession()
+		if err = s.client.Login(); err != nil {
+			if !isUnavailable(err) {
+				logger.Error("UniFi: re-login failed: %v", err)
+			}
+			s.failFetch(err, detected)
+			return
+		}
+		currentRoutes, err = s.client.StaticRoutes()
+		if err != nil {
+			if !isUnavailable(err) {
+				logger.Error("UniFi: failed to get routes after re-login: %v", err)
+			}
+			s.failFetch(err, detected)
+			return
+		}
+	}
+
+	s.resumeSync()
+	s.queue.remember(currentRoutes)
+	s.removals.remember(currentRoutes)
+	queued := s.queue.take()
+	if len(queued) > 0 {
+		logger.Info("UniFi: controller reachable again, resyncing after %d queued route operations", len(queued))
+	}
+	s.gatewayPicker.refresh(s.client, currentRoutes, time.Now())
+
+	s.phases.enter(PhaseDiff)
+	desiredRoutes := ConvertRoutes(detected, "")
+	for i := range desiredRoutes {
+		desiredRoutes[i].GatewayDevice = s.gatewayPicker.forNetwork(desiredRoutes[i].StaticRouteNetwork)
+		_, desiredRoutes[i].Description = s.client.cfg.RouteFields.For(desiredRoutes[i].StaticRouteNetwork)
+	}
+	if s.client.UsesTrafficRoutes() {
+		trafficRoutes(desiredRoutes)
+	}
+	if s.routeInterface != "" {
+		desiredRoutes = interfaceRoutes(desiredRoutes, s.routeInterface, s.routeInterfaceName)
+	}
+	clearInterfaceNexthops(currentRoutes)
+	currentRoutes, desiredRoutes = splitOverridden(currentRoutes, desiredRoutes, s.state.RouteOverrides())
+
+	renumberedRoutes, retainedRoutes := s.splitRenumbered(currentRoutes, desiredRoutes)
+	failedOverRoutes, retainedRoutes := s.splitFailedOver(retainedRoutes, desiredRoutes)
+	reasons := make(planReasons)
+	reasons.set(renumberedRoutes, "next hop renumbered")
+	reasons.set(failedOverRoutes, "border router expired, another next hop serves the network")
+
+	grace := s.state.LifetimeGrace(s.grace)
+	var routesToAdd, routesToRemove []StaticRoute
+	s.state.UpdateRouteLastSeen(func(routeLastSeen map[string]time.Time) {
+		routeUpdateTime := time.Now()
+		for _, route := range desiredRoutes {
+			routeLastSeen[routes.Key(route.StaticRouteNetwork, route.StaticRouteNexthop)] = routeUpdateTime
+		}
+		routesToAdd, routesToRemove = compareRoutesWithGracePeriod(retainedRoutes, desiredRoutes, routeLastSeen, grace)
+	})
+	s.graceTimers.track(retainedRoutes, desiredRoutes)
+	reasons.set(routesToRemove, "not detected for the grace period")
+	reasons.queued(queued, routesToAdd, routesToRemove)
+	routesToAdd = s.skipRejected(routesToAdd, desiredRoutes)
+	routesToAdd, dampedRoutes := s.skipDamped(routesToAdd)
+	routesToUpdate, routesToAdd, replacedRoutes := pairReplacements(
+		append(renumberedRoutes, failedOverRoutes...), routesToAdd)
+	distances := newDistanceAllocator(currentRoutes, s.baseDistance)
+	refreshed := s.quarantine.release(refreshRoutes(retainedRoutes, desiredRoutes, distances), retainedRoutes, desiredRoutes)
+	for _, u := range refreshed {
+		reasons[routes.Key(u.from.StaticRouteNetwork, u.from.StaticRouteNexthop)] = u.changes()
+	}
+	routesToUpdate = append(routesToUpdate, refreshed...)
+	adopted, _ := planMigration(retainedRoutes, s.client.cfg.LegacyRouteNames)
+	for _, u := range adopted {
+		reasons[routes.Key(u.from.StaticRouteNetwork, u.from.StaticRouteNexthop)] = "legacy route adopted, " + u.changes()
+	}
+	routesToUpdate = append(routesToUpdate, adopted...)
+
+	held := make(map[string][]StaticRoute)
+	if len(dampedRoutes) > 0 {
+		reasons.set(dampedRoutes, "flapping, suppressed by route flap damping")
+		held["add"] = dampedRoutes
+	}
+	if len(routesToRemove) > 0 && !s.window.Open(time.Now()) {
+		logger.Debug("UniFi: outside the route removal window, deferring %d route removals", len(routesToRemove))
+		reasons.set(routesToRemove, "grace period passed, waiting for the removal window")
+		held["remove"] = routesToRemove
+		routesToRemove = nil
+	}
+	if len(routesToRemove) > 0 && s.removals.enabled() {
+		var unchecked []StaticRoute
+		routesToRemove, unchecked = s.removals.confirmed(routesToRemove)
+		reasons.set(unchecked, "grace period passed, waiting for its border router to be queried")
+		held["remove"] = append(held["remove"], unchecked...)
+	}
+	if len(routesToRemove) > 0 && s.quarantine != nil {
+		var disabled []routeUpdate
+		var quarantined []StaticRoute
+		disabled, routesToRemove, quarantined = s.quarantine.split(routesToRemove, time.Now())
+		for _, u := range disabled {
+			reasons[routes.Key(u.from.StaticRouteNetwork, u.from.StaticRouteNexthop)] = "grace period passed, disabled for the quarantine period"
+		}
+		routesToUpdate = append(routesToUpdate, disabled...)
+		reasons.set(routesToRemove, "quarantine period passed")
+		reasons.set(quarantined, "quarantined, disabled until the quarantine period passes")
+		held["remove"] = append(held["remove"], quarantined...)
+	}
+	// Routes through renumbered or expired next hops are appended only now, so
+	// that neither the removal window nor the removal check or quarantine holds
+	// them back: their next hop no longer works, and keeping the route only
+	// blackholes the traffic it attracts.
+	routesToRemove = append(routesToRemove, replacedRoutes...)
+	routesToAdd, routesToRemove, heldAdds, deferredDeletes := applyLimits(s.limits, currentRoutes, routesToAdd, routesToRemove)
+	if len(heldAdds) > 0 {
+		logger.Warn("UniFi: safety caps reached, holding back %d route additions (managed limit %d, per network %d, per sync %d)",
+			len(heldAdds), s.limits.MaxRoutes, s.limits.MaxPerNetwork, s.limits.MaxAddsPerSync)
+		reasons.set(heldAdds, "safety cap reached")
+		held["add"] = append(held["add"], heldAdds...)
+	}
+	if len(deferredDeletes) > 0 {
+		logger.Warn("UniFi: deferring %d route deletions to later syncs (limit %d per sync)",
+			len(deferredDeletes), s.limits.MaxDeletesPerSync)
+		reasons.set(deferredDeletes, "deletions per sync limit reached")
+		held["remove"] = append(held["remove"], deferredDeletes...)
+	}
+
+	// Apply in a stable order, which also makes the assigned distances stable.
+	sortUpdates(routesToUpdate)
+	sortStaticRoutes(routesToRemove)
+	sortStaticRoutes(routesToAdd)
+	distances.assign(routesToAdd)
+
+	plan := newPlan(routesToUpdate, routesToRemove, routesToAdd)
+	plan.describe(currentRoutes, desiredRoutes, held, reasons, s.state.RouteLastSeen(), grace)
+	summary := syncSummary{kept: len(plan.Kept), damped: len(dampedRoutes), held: len(plan.Held) - len(dampedRoutes)}
+
+	if len(plan.Changes) == 0 {
+		if s.approval != nil {
+			s.approval.clear()
+		}
+		plan.Status = PlanUpToDate
+		s.plans.record(plan)
+		summary.duration = time.Since(started)
+		s.logSummary(summary)
+		s.bus.Publish(events.Event{Kind: events.SyncSucceeded, Detail: summary.String()})
+		return
+	}
+
+	if s.approval != nil {
+		if !s.approval.admit(plan) {
+			plan.Status = PlanAwaitingApproval
+			s.plans.record(plan)
+			s.bus.Publish(events.Event{Kind: events.SyncSucceeded,
+				Detail: fmt.Sprintf("%d changes awaiting approval (plan %s)", len(plan.Changes), plan.ID)})
+			return
+		}
+		logger.Info("UniFi: applying approved plan %s", plan.ID)
+	}
+	plan.Status = PlanApplying
+	s.plans.record(plan)
+
+	s.phases.enter(PhaseApply)
+	if err := s.backup(); err != nil {
+		logger.Error("UniFi: %v", err)
+		s.failSync(err)
+		return
+	}
+	halt := &applyHalt{}
+	summary.batchResult = s.updateRoutes(routesToUpdate, halt)
+	summary.merge(s.removeRoutes(routesToRemove, halt))
+	if len(routesToAdd) > 0 && halt.halted() == nil {
+		// Give the controller a moment to settle the removals and updates
+		// before adding routes next to them, or it may still reject an
+		// addition as a duplicate of a route it is deleting.
+		time.Sleep(addSettle)
+	}
+	summary.merge(s.addRoutes(routesToAdd, distances, halt))
+	if err := halt.halted(); err != nil {
+		s.gatewayPicker.invalidate()
+		summary.duration = time.Since(started)
+		s.logSummary(summary)
+		s.failSync(err)
+		return
+	}
+	if summary.failed > 0 || summary.rejected > 0 {
+		s.gatewayPicker.invalidate()
+		summary.duration = time.Since(started)
+		s.logSummary(summary)
+		err := fmt.Errorf("%d route operations failed", summary.failed+summary.rejected)
+		s.phases.fail(err)
+		s.bus.Publish(events.Event{Kind: events.SyncFailed, Detail: summary.String(), Err: err})
+		return
+	}
+
+	s.phases.enter(PhaseVerify)
+	err = s.verify(routesToUpdate, routesToRemove, routesToAdd)
+	summary.duration = time.Since(started)
+	s.logSummary(summary)
+	if err != nil {
+		logger.Warn("UniFi: %v", err)
+		s.phases.fail(err)
+		s.bus.Publish(events.Event{Kind: events.SyncFailed, Detail: summary.String(), Err: err})
+		return
+	}
+	s.bus.Publish(events.Event{Kind: events.SyncSucceeded, Detail: summary.String()})
+}
+
+// verify reads the routes back from the controller and checks that the applied
+// changes took effect: updated and added routes are present, removed ones gone.
+func (s *Syncer) verify(updated []routeUpdate, removed, added []StaticRoute) error {
+	current, err := s.client.StaticRoutes()
+	if err != nil {
+		return fmt.Errorf("verify: reading routes back failed: %v", err)
+	}
+	present := make(map[string]bool, len(current))
+	for _, r := range current {
+		present[routes.Key(r.StaticRouteNetwork, r.StaticRouteNexthop)] = true
+	}
+	missing, lingering := 0, 0
+	for _, u := range updated {
+		if !present[routes.Key(u.to.StaticRouteNetwork, u.to.StaticRouteNexthop)] {
+			missing++
+		}
+	}
+	for _, r := range added {
+		if !present[routes.Key(r.StaticRouteNetwork, r.StaticRouteNexthop)] {
+			missing++
+		}
+	}
+	for _, r := range removed {
+		if present[routes.Key(r.StaticRouteNetwork, r.StaticRouteNexthop)] {
+			lingering++
+		}
+	}
+	if missing > 0 || lingering > 0 {
+		return fmt.Errorf("verify: %d applied routes missing on the controller, %d removed routes still present", missing, lingering)
+	}
+	return nil
+}
+
+// addSettle is how long a sync waits between its removals and additions.
+const addSettle = 2 * time.Second
+
+// routeUpdate moves an existing controller route to a new next hop.
+type routeUpdate struct {
+	from, to StaticRoute
+}
+
+// changes describes what an in-place update of a route keeping its next hop
+// changes.
+func (u routeUpdate) changes() string {
+	var parts []string
+	if u.from.Enabled != u.to.Enabled {
+		if u.to.Enabled {
+			parts = append(parts, "enabled")
+		} else {
+			parts = append(parts, "disabled")
+		}
+	}
+	if u.from.Name != u.to.Name {
+		parts = append(parts, fmt.Sprintf("renamed from %q", u.from.Name))
+	}
+	if !strings.EqualFold(u.from.GatewayDevice, u.to.GatewayDevice) {
+		parts = append(parts, fmt.Sprintf("gateway device %s -> %s", u.from.GatewayDevice, u.to.GatewayDevice))
+	}
+	if u.from.Description != u.to.Description {
+		parts = append(parts, fmt.Sprintf("description %q", u.to.Description))
+	}
+	if u.from.StaticRouteType != u.to.StaticRouteType || u.from.StaticRouteInterface != u.to.StaticRouteInterface {
+		parts = append(parts, fmt.Sprintf("type %s", u.to.StaticRouteType))
+	}
+	if u.from.StaticRouteDistance != u.to.StaticRouteDistance {
+		parts = append(parts, fmt.Sprintf("distance %d -> %d", u.from.StaticRouteDistance, u.to.StaticRouteDistance))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// refreshRoutes returns in-place updates for the managed routes in current that
+// are still desired but carry an outdated name, such as one without the route
+// hash or description, are of another route type, sit on another gateway
+// device than desired for them, or have a distance below the base distance of
+// their prefix, which distances then raises them to.
+func refreshRoutes(current, desired []StaticRoute, distances *distanceAllocator) []routeUpdate {
+	want := make(map[string]StaticRoute, len(desired))
+	for _, r := range desired {
+		want[routes.Key(r.StaticRouteNetwork, r.StaticRouteNexthop)] = r
+	}
+	var updates []routeUpdate
+	for _, r := range current {
+		d, ok := want[routes.Key(r.StaticRouteNetwork, r.StaticRouteNexthop)]
+		if !ok || r.ID == "" || !r.IsThreadRoute() {
+			continue
+		}
+		next := r
+		if d.Name != "" {
+			next.Name = d.Name
+		}
+		if d.GatewayDevice != "" {
+			next.GatewayDevice = d.GatewayDevice
+		}
+		if d.Description != "" {
+			next.Description = d.Description
+		}
+		if d.StaticRouteType == routeTypeInterface || r.StaticRouteType == routeTypeInterface {
+			next.StaticRouteType, next.StaticRouteInterface = d.StaticRouteType, d.StaticRouteInterface
+		}
+		if distance, ok := distances.raise(r.StaticRouteNetwork, r.StaticRouteDistance); ok {
+			next.StaticRouteDistance = distance
+		}
+		if next.Name != r.Name || next.StaticRouteDistance != r.StaticRouteDistance || !strings.EqualFold(next.GatewayDevice, r.GatewayDevice) || next.Description != r.Description ||
+			next.StaticRouteType != r.StaticRouteType || next.StaticRouteInterface != r.StaticRouteInterface {
+			updates = append(updates, routeUpdate{from: r, to: next})
+		}
+	}
+	return updates
+}
+
+// pairReplacements matches routes being replaced, through a renumbered or expired
+// next hop, with pending additions to the same network. Each pair becomes an in-place
+// update of the controller route, which keeps its ID and distance so the new next
+// hop takes over the old one's rank without a window where the route is missing.
+// Replaced routes and additions left unpaired are returned for removal and addition.
+func pairReplacements(replaced, toAdd []StaticRoute) (updates []routeUpdate, remainingAdds, remainingReplaced []StaticRoute) {
+	pending := make(map[string][]int)
+	for i, r := range toAdd {
+		network := routes.NormalizePrefix(r.StaticRouteNetwork)
+		pending[network] = append(pending[network], i)
+	}
+
+	paired := make(map[int]bool)
+	for _, old := range replaced {
+		network := routes.NormalizePrefix(old.StaticRouteNetwork)
+		candidates := pending[network]
+		if old.ID == "" || len(candidates) == 0 {
+			remainingReplaced = append(remainingReplaced, old)
+			continue
+		}
+		pending[network] = candidates[1:]
+		paired[candidates[0]] = true
+
+		next := old
+		next.Enabled = true
+		next.Name = toAdd[candidates[0]].Name
+		next.StaticRouteNexthop = toAdd[candidates[0]].StaticRouteNexthop
+		next.GatewayDevice = toAdd[candidates[0]].GatewayDevice
+		updates = append(updates, routeUpdate{from: old, to: next})
+	}
+
+	for i, r := range toAdd {
+		if !paired[i] {
+			remainingAdds = append(remainingAdds, r)
+		}
+	}
+	return updates, remainingAdds, remainingReplaced
+}
+
+// updateRoutes moves routes to their new next hops concurrently, skipping
+// those not started once halt records an unavailable controller.
+func (s *Syncer) updateRoutes(toUpdate []routeUpdate, halt *applyHalt) batchResult {
+	var result batchResult
+	var mu sync.Mutex
+	jobs := make([]func(), 0, len(toUpdate))
+	for _, u := range toUpdate {
+		u := u
+		jobs = append(jobs, func() {
+			outcome := outcomeSkipped
+			if halt.halted() == nil {
+				outcome = s.updateRoute(u, halt)
+			}
+			mu.Lock()
+			result.record(outcome)
+			mu.Unlock()
+		})
+	}
+	runPool(s.workers, jobs)
+	return result
+}
+
+// updateRoute moves one route to its new next hop, updating route tracking.
+// An unavailable controller is recorded in halt.
+func (s *Syncer) updateRoute(u routeUpdate, halt *applyHalt) outcome {
+	key := routes.Key(u.to.StaticRouteNetwork, u.to.StaticRouteNexthop)
+	if err := s.client.UpdateStaticRoute(u.to); err != nil {
+		if isRejection(err) {
+			r := s.rejections.record(key, u.to, rejectionReason(err))
+			logger.Warn("UniFi: route update %s -> %s rejected (attempt %d), retrying in %s: %v",
+				u.to.StaticRouteNetwork, u.to.StaticRouteNexthop, r.Attempts,
+				logger.FormatDuration(time.Until(r.RetryAt)), err)
+			s.publishFailure("update", u.to, err)
+			return outcomeRejected
+		}
+		if isUnavailable(err) {
+			halt.halt(err)
+		}
+		logger.Error("UniFi: update failed %s (id=%s): %v", u.to.StaticRouteNetwork, u.to.ID, err)
+		s.publishFailure("update", u.to, err)
+		return outcomeFailed
+	}
+	if u.from.Enabled != u.to.Enabled {
+		s.quarantine.record(u.to, time.Now())
+	}
+	if u.from.StaticRouteNexthop == u.to.StaticRouteNexthop {
+		if !u.from.IsThreadRoute() { // a legacy route adopted by its new name
+			s.state.MarkRouteAdded(key)
+		}
+		s.logChange("UniFi: updated route %s -> %s (%s): %s",
+			u.to.StaticRouteNetwork, u.to.StaticRouteNexthop, u.to.Name, u.changes())
+		s.rejections.clear(key)
+		s.bus.Publish(events.Event{Kind: events.RouteUpdated, Name: u.to.Name,
+			Prefix: u.to.StaticRouteNetwork, Nexthop: u.to.StaticRouteNexthop, Detail: u.changes()})
+		return outcomeUpdated
+	}
+	s.logChange("UniFi: switched route %s from %s to %s (%s)",
+		u.to.StaticRouteNetwork, u.from.StaticRouteNexthop, u.to.StaticRouteNexthop, u.to.Name)
+	s.state.MarkRouteRemoved(routes.Key(u.from.StaticRouteNetwork, u.from.StaticRouteNexthop))
+	s.graceTimers.forget(routes.Key(u.from.StaticRouteNetwork, u.from.StaticRouteNexthop))
+	s.state.MarkRouteAdded(key)
+	s.damping.flap(routes.Key(u.from.StaticRouteNetwork, u.from.StaticRouteNexthop), u.from)
+	s.damping.flap(key, u.to)
+	s.rejections.clear(key)
+	s.bus.Publish(events.Event{Kind: events.RouteUpdated, Name: u.to.Name,
+		Prefix: u.to.StaticRouteNetwork, Nexthop: u.to.StaticRouteNexthop,
+		Detail: "from " + u.from.StaticRouteNexthop})
+	return outcomeUpdated
+}
+
+// removeRoutes deletes routes concurrently, skipping those not started once
+// halt records an unavailable controller.
+func (s *Syncer) removeRoutes(toRemove []StaticRoute, halt *applyHalt) batchResult {
+	var result batchResult
+	var mu sync.Mutex
+	jobs := make([]func(), 0, len(toRemove))
+	for _, route := range toRemove {
+		route := route
+		jobs = append(jobs, func() {
+			outcome := outcomeSkipped
+			if halt.halted() == nil {
+				outcome = s.removeRoute(route, halt)
+			}
+			mu.Lock()
+			result.record(outcome)
+			mu.Unlock()
+		})
+	}
+	runPool(s.workers, jobs)
+	return result
+}
+
+// removeRoute deletes one route, updating route tracking. An unavailable
+// controller is recorded in halt.
+func (s *Syncer) removeRoute(route StaticRoute, halt *applyHalt) outcome {
+	logger.Debug("UniFi: deleting route %s -> %s (id=%s)...",
+		route.StaticRouteNetwork, route.StaticRouteNexthop, route.ID)
+	key := routes.Key(route.StaticRouteNetwork, route.StaticRouteNexthop)
+	if err := s.client.DeleteStaticRoute(route.ID); err != nil {
+		if strings.Contains(err.Error(), "IdInvalid") {
+			logger.Warn("UniFi: route id invalid, already deleted: %s -> %s", route.StaticRouteNetwork, route.StaticRouteNexthop)
+			s.state.ForgetRoute(key)
+			s.graceTimers.forget(key)
+			s.quarantine.forget(key)
+			return outcomeRemoved
+		}
+		if isUnavailable(err) {
+			halt.halt(err)
+		}
+		logger.Error("UniFi: delete failed %s (id=%s): %v", route.StaticRouteNetwork, route.ID, err)
+		s.publishFailure("delete", route, err)
+		return outcomeFailed
+	}
+	s.logChange("UniFi: deleted route %s -> %s", route.StaticRouteNetwork, route.StaticRouteNexthop)
+	s.state.MarkRouteRemoved(key)
+	s.graceTimers.forget(key)
+	s.quarantine.forget(key)
+	s.damping.flap(key, route)
+	s.bus.Publish(events.Event{Kind: events.RouteRemoved, Name: route.Name,
+		Prefix: route.StaticRouteNetwork, Nexthop: route.StaticRouteNexthop})
+	return outcomeRemoved
+}
+
+// addRoutes creates routes concurrently. Routes sharing a destination network are
+// added by the same worker, one after another, so their distances don't collide.
+// Routes not started once halt records an unavailable controller are skipped.
+func (s *Syncer) addRoutes(toAdd []StaticRoute, distances *distanceAllocator, halt *applyHalt) batchResult {
+	var groups [][]StaticRoute
+	index := make(map[string]int)
+	for _, route := range toAdd {
+		i, ok := index[route.StaticRouteNetwork]
+		if !ok {
+			i = len(groups)
+			index[route.StaticRouteNetwork] = i
+			groups = append(groups, nil)
+		}
+		groups[i] = append(groups[i], route)
+	}
+
+	var result batchResult
+	var mu sync.Mutex
+	jobs := make([]func(), 0, len(groups))
+	for _, group := range groups {
+		group := group
+		jobs = append(jobs, func() {
+			for _, route := range group {
+				outcome := outcomeSkipped
+				if halt.halted() == nil {
+					outcome = s.addRoute(route, distances, &mu, halt)
+				}
+				mu.Lock()
+				result.record(outcome)
+				mu.Unlock()
+			}
+		})
+	}
+	runPool(s.workers, jobs)
+	return result
+}
+
+// addRoute creates one route, retrying with the next free distance on a collision.
+// mu guards distances, which is shared between workers. An unavailable
+// controller is recorded in halt.
+func (s *Syncer) addRoute(route StaticRoute, distances *distanceAllocator, mu *sync.Mutex, halt *applyHalt) outcome {
+	key := routes.Key(route.StaticRouteNetwork, route.StaticRouteNexthop)
+	for attempt := 0; attempt < 5; attempt++ {
+		err := s.client.AddStaticRoute(route)
+		if err == nil {
+			s.logChange("UniFi: added route %s -> %s (%s)", route.StaticRouteNetwork, route.StaticRouteNexthop, route.Name)
+			s.state.MarkRouteAdded(key)
+			s.rejections.clear(key)
+			s.damping.flap(key, route)
+			s.bus.Publish(events.Event{Kind: events.RouteCreated, Name: route.Name,
+				Prefix: route.StaticRouteNetwork, Nexthop: route.StaticRouteNexthop})
+			return outcomeAdded
+		}
+		if hasCode(err, codeDestinationNetworkExisted) && attempt < 4 {
+			prefix := route.StaticRouteNetwork
+			mu.Lock()
+			distances.markUsed(prefix, route.StaticRouteDistance)
+			next, ok := distances.nextFree(prefix)
+			for !ok {
+				distances.count[prefix]++
+				next, ok = distances.nextFree(prefix)
+			}
+			distances.markUsed(prefix, next)
+			mu.Unlock()
+			route.StaticRouteDistance = next
+			logger.Warn("UniFi: distance collision for %s, retrying with distance %d",
+				prefix, next)
+			continue
+		}
+		reason := rejectionReason(err)
+		if isDuplicate(err) {
+			existing, found := s.findDuplicate(route)
+			if found && existing.IsThreadRoute() &&
+				routes.Key(existing.StaticRouteNetwork, existing.StaticRouteNexthop) == key {
+				return s.adoptRoute(existing, route)
+			}
+			if found {
+				reason = fmt.Sprintf("%s: taken by %q (%s -> %s)", reason, existing.Name,
+					existing.StaticRouteNetwork, existing.StaticRouteNexthop)
+			}
+		}
+		if isRejection(err) {
+			r := s.rejections.record(key, route, reason)
+			logger.Warn("UniFi: route %s -> %s rejected (attempt %d), retrying in %s: %s",
+				route.StaticRouteNetwork, route.StaticRouteNexthop, r.Attempts,
+				logger.FormatDuration(time.Until(r.RetryAt)), reason)
+			s.publishFailure("create", route, errors.New(reason))
+			return outcomeRejected
+		}
+		if isUnavailable(err) {
+			halt.halt(err)
+		}
+		logger.Error("UniFi: add failed %s: %v", route.StaticRouteNetwork, err)
+		s.publishFailure("create", route, err)
+		return outcomeFailed
+	}
+	return outcomeFailed
+}
+
+// publishFailure announces a route change the controller refused or failed.
+// action is "create", "update" or "delete".
+func (s *Syncer) publishFailure(action string, route StaticRoute, err error) {
+	s.bus.Publish(events.Event{Kind: events.RouteFailed, Name: route.Name,
+		Prefix: route.StaticRouteNetwork, Nexthop: route.StaticRouteNexthop, Detail: action, Err: err})
+}
+
+// findDuplicate looks up the controller route that a route creation collided
+// with: one with the same network and next hop, else one with the same name.
+func (s *Syncer) findDuplicate(route StaticRoute) (StaticRoute, bool) {
+	all, err := s.client.Routes()
+	if err != nil {
+		logger.Warn("UniFi: could not look up the route colliding with %s -> %s: %v",
+			route.StaticRouteNetwork, route.StaticRouteNexthop, err)
+		return StaticRoute{}, false
+	}
+	key := routes.Key(route.StaticRouteNetwork, route.StaticRouteNexthop)
+	var named *StaticRoute
+	for i, r := range all {
+		if !r.IsStatic() {
+			continue
+		}
+		if routes.Key(r.StaticRouteNetwork, r.StaticRouteNexthop) == key {
+			return r, true
+		}
+		if named == nil && r.Name == route.Name {
+			named = &all[i]
+		}
+	}
+	if named != nil {
+		return *named, true
+	}
+	return StaticRoute{}, false
+}
+
+// adoptRoute takes over existing, a managed route to the same network and next
+// hop as route that the controller already has, instead of failing to create
+// route every cycle. An existing route differing from route is updated to it,
+// keeping its ID and distance.
+func (s *Syncer) adoptRoute(existing, route StaticRoute) outcome {
+	key := routes.Key(route.StaticRouteNetwork, route.StaticRouteNexthop)
+	next := route
+	next.ID = existing.ID
+	next.SiteID = existing.SiteID
+	next.StaticRouteDistance = existing.StaticRouteDistance
+	if existing.Name != next.Name || existing.Enabled != next.Enabled ||
+		!strings.EqualFold(existing.GatewayDevice, next.GatewayDevice) {
+		if err := s.client.UpdateStaticRoute(next); err != nil {
+			logger.Error("UniFi: adopting existing route %s -> %s (id=%s) failed: %v",
+				route.StaticRouteNetwork, route.StaticRouteNexthop, existing.ID, err)
+			s.publishFailure("create", route, err)
+			return outcomeFailed
+		}
+	}
+	s.logChange("UniFi: adopted existing route %s -> %s (id=%s, %s)",
+		route.StaticRouteNetwork, route.StaticRouteNexthop, existing.ID, route.Name)
+	s.state.MarkRouteAdded(key)
+	s.rejections.clear(key)
+	s.bus.Publish(events.Event{Kind: events.RouteCreated, Name: route.Name,
+		Prefix: route.StaticRouteNetwork, Nexthop: route.StaticRouteNexthop, Detail: "adopted existing route " + existing.ID})
+	return outcomeAdded
+}
+
+// skipRejected drops routes still backing off after a controller rejection and
+// forgets rejections for routes that are no longer desired.
+func (s *Syncer) skipRejected(toAdd, desired []StaticRoute) []StaticRoute {
+	wanted := make(map[string]bool, len(desired))
+	for _, r := range desired {
+		wanted[routes.Key(r.StaticRouteNetwork, r.StaticRouteNexthop)] = true
+	}
+	s.rejections.retain(wanted)
+
+	var out []StaticRoute
+	for _, r := range toAdd {
+		if rej, blocked := s.rejections.blocked(routes.Key(r.StaticRouteNetwork, r.StaticRouteNexthop)); blocked {
+			logger.Debug("UniFi: skipping rejected route %s -> %s until %s: %s",
+				r.StaticRouteNetwork, r.StaticRouteNexthop, rej.RetryAt.Format(time.RFC3339), rej.Reason)
+			continue
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+// skipDamped holds back the additions of routes suppressed by flap damping.
+func (s *Syncer) skipDamped(toAdd []StaticRoute) (out, damped []StaticRoute) {
+	suppressed := s.damping.suppressedKeys()
+	for _, r := range toAdd {
+		if suppressed[routes.Key(r.StaticRouteNetwork, r.StaticRouteNexthop)] {
+			logger.Debug("UniFi: holding back flapping route %s -> %s", r.StaticRouteNetwork, r.StaticRouteNexthop)
+			damped = append(damped, r)
+			continue
+		}
+		out = append(out, r)
+	}
+	return out, damped
+}
+
+// splitRenumbered separates the managed routes whose next hop lies in a /64 that
+// was renumbered away from the rest, so they can be removed without waiting out
+// the grace period. The border router a route goes through is told by the label
+// in its name.
+func (s *Syncer) splitRenumbered(current, desired []StaticRoute) (renumbered, retained []StaticRoute) {
+	currentNexthops := make(map[string]string)
+	desiredNexthops := make(map[string]string)
+	for _, r := range current {
+		if r.IsThreadRoute() {
+			currentNexthops[r.StaticRouteNexthop] = routeRouter(r.Name)
+		}
+	}
+	for _, r := range desired {
+		desiredNexthops[r.StaticRouteNexthop] = routeRouter(r.Name)
+	}
+
+	renumbering, ok := routes.DetectRenumbering(currentNexthops, desiredNexthops)
+	if !ok {
+		return nil, current
+	}
+
+	for _, r := range current {
+		if r.IsThreadRoute() && renumbering.Contains(r.StaticRouteNexthop) {
+			renumbered = append(renumbered, r)
+		} else {
+			retained = append(retained, r)
+		}
+	}
+	logger.Info("UniFi: prefix renumbering detected %v -> %v, replacing %d old routes immediately",
+		renumbering.Old, renumbering.New, len(renumbered))
+	s.bus.Publish(events.Event{Kind: events.Renumbered,
+		Detail: fmt.Sprintf("%s -> %s", strings.Join(renumbering.Old, ","), strings.Join(renumbering.New, ","))})
+	return renumbered, retained
+}
+
+// splitFailedOver separates the managed routes through an expired border router
+// whose network is still reachable through another detected next hop. Traffic
+// switches to the remaining routes as soon as the dead next hop is gone, so these
+// are removed, or moved to a newly detected next hop, without waiting out the
+// grace period. Routes to networks with no alternative next hop stay in retained
+// and keep their grace period.
+func (s *Syncer) splitFailedOver(current, desired []StaticRoute) (failedOver, retained []StaticRoute) {
+	expired := s.state.ExpiredNexthops()
+	if len(expired) == 0 {
+		return nil, current
+	}
+
+	alternatives := make(map[string]int)
+	wanted := make(map[string]bool, len(desired))
+	for _, r := range desired {
+		alternatives[routes.NormalizePrefix(r.StaticRouteNetwork)]++
+		wanted[routes.Key(r.StaticRouteNetwork, r.StaticRouteNexthop)] = true
+	}
+
+	for _, r := range current {
+		key := routes.Key(r.StaticRouteNetwork, r.StaticRouteNexthop)
+		if r.IsThreadRoute() && expired[routes.NormalizeAddr(r.StaticRouteNexthop)] && !wanted[key] &&
+			alternatives[routes.NormalizePrefix(r.StaticRouteNetwork)] > 0 {
+			failedOver = append(failedOver, r)
+			continue
+		}
+		retained = append(retained, r)
+	}
+	if len(failedOver) > 0 {
+		logger.Info("UniFi: %d routes via expired border routers have alternative next hops, failing over immediately",
+			len(failedOver))
+	}
+	return failedOver, retained
+}
+
+// LogConfiguredRoutes fetches and logs the routes currently programmed on the router.
+func (s *Syncer) LogConfiguredRoutes(detectedRoutes []routes.Route) {
+	if !s.client.HasValidSession() {
+		logger.Debug("Skipping route status check: no valid session")
+		return
+	}
+
+	configuredRoutes, err := s.client.StaticRoutes()
+	if err != nil {
+		logger.Warn("UniFi: failed to get configured routes: %v", err)
+		return
+	}
+
+	var threadRoutes []StaticRoute
+	for _, route := range configuredRoutes {
+		if route.IsThreadRoute() {
+			threadRoutes = append(threadRoutes, route)
+		}
+	}
+
+	sortStaticRoutes(threadRoutes)
+	logger.Info("UniFi: %d Thread routes configured", len(threadRoutes))
+
+	detected := make(map[string]bool, len(detectedRoutes))
+	for _, r := range detectedRoutes {
+		detected[r.Key()] = true
+	}
+	for _, route := range threadRoutes {
+		if detected[routes.Key(route.StaticRouteNetwork, route.StaticRouteNexthop)] {
+			logger.Debug("Route configured: %s -> %s (%s)", route.StaticRouteNetwork, route.StaticRouteNexthop, route.Name)
+		}
+	}
+
+	timers := s.GraceTimers()
+	overdue := 0
+	for _, t := range timers {
+		if t.Overdue {
+			overdue++
+		}
+	}
+	if len(timers) > 0 {
+		next := timers[0]
+		logger.Info("UniFi: %d undetected routes pending removal (%d overdue), next %s -> %s in %s",
+			len(timers), overdue, next.Network, next.Nexthop, next.RemovesIn)
+	}
+}
+
+// ConvertRoutes converts our Route format to UniFi format.
+// Distance is left as 0 for new routes; callers should assign distances with a
+// distanceAllocator after fetching current routes from UniFi to avoid metric collisions.
+func ConvertRoutes(detected []routes.Route, gatewayDevice string) []StaticRoute {
+	var unifiRoutes []StaticRoute
+	for _, route := range detected {
+		unifiRoutes = append(unifiRoutes, StaticRoute{
+			Enabled:            true,
+			Name:               RouteName(route.RouterName, route.CIDR.String(), route.ThreadRouterIPv6.String()),
+			Type:               RouteTypeStatic,
+			StaticRouteNexthop: route.ThreadRouterIPv6.String(),
+			StaticRouteNetwork: route.CIDR.String(),
+			StaticRouteType:    routeTypeNexthop,
+			GatewayType:        "default",
+			GatewayDevice:      gatewayDevice,
+		})
+	}
+	return unifiRoutes
+}
+
+// trafficRoutes clears the fields traffic routes do not keep, so routes
+// listed back from the controller diff equal to the desired ones.
+func trafficRoutes(desired []StaticRoute) {
+	for i := range desired {
+		desired[i].GatewayDevice = ""
+		desired[i].Description = ""
+	}
+}
+
+// baseDistance returns the lowest distance of new routes to prefix.
+func (s *Syncer) baseDistance(prefix string) int {
+	distance, _ := s.client.cfg.RouteFields.For(prefix)
+	return distance
+}
+
+// distanceAllocator picks the lowest unused distance in B..B+N-1 per destination
+// prefix, where B is the prefix's base distance, 1 unless configured, and N is
+// the total route count for that prefix (existing + pending adds).
+type distanceAllocator struct {
+	used  map[string]map[int]bool
+	count map[string]int
+	base  func(prefix string) int // nil or 0 means 1
+}
+
+func newDistanceAllocator(current []StaticRoute, base func(prefix string) int) *distanceAllocator {
+	a := &distanceAllocator{
+		used:  make(map[string]map[int]bool),
+		count: make(map[string]int),
+		base:  base,
+	}
+	zeroDist := make(map[string]int)
+	for _, r := range current {
+		prefix := r.StaticRouteNetwork
+		a.count[prefix]++
+		if a.used[prefix] == nil {
+			a.used[prefix] = make(map[int]bool)
+		}
+		if r.StaticRouteDistance > 0 {
+			a.used[prefix][r.StaticRouteDistance] = true
+		} else {
+			zeroDist[prefix]++
+		}
+	}
+	// GET often omits static-route_distance; reserve the lowest slots for those routes.
+	for prefix, zeros := range zeroDist {
+		for z := 0; z < zeros; z++ {
+			if d, ok := a.nextFree(prefix); ok {
+				a.markUsed(prefix, d)
+			}
+		}
+	}
+	return a
+}
+
+func (a *distanceAllocator) markUsed(prefix string, distance int) {
+	if a.used[prefix] == nil {
+		a.used[prefix] = make(map[int]bool)
+	}
+	a.used[prefix][distance] = true
+}
+
+// nextFree returns the lowest unused distance in base..base+count[prefix]-1.
+func (a *distanceAllocator) nextFree(prefix string) (int, bool) {
+	first := 1
+	if a.base != nil && a.base(prefix) > 0 {
+		first = a.base(prefix)
+	}
+	for d := first; d < first+a.count[prefix]; d++ {
+		if used := a.used[prefix]; used == nil || !used[d] {
+			return d, true
+		}
+	}
+	return 0, false
+}
+
+// raise returns the lowest unused distance at or above the base of prefix for
+// a route to it at distance, and false when distance is already at or above
+// the base or unknown. The new distance is marked used and the old one freed.
+func (a *distanceAllocator) raise(prefix string, distance int) (int, bool) {
+	if a == nil || a.base == nil || distance <= 0 || distance >= a.base(prefix) {
+		return 0, false
+	}
+	d, ok := a.nextFree(prefix)
+	if !ok {
+		return 0, false
+	}
+	delete(a.used[prefix], distance)
+	a.markUsed(prefix, d)
+	return d, true
+}
+
+func (a *distanceAllocator) assign(toAdd []StaticRoute) {
+	for i := range toAdd {
+		prefix := toAdd[i].StaticRouteNetwork
+		a.count[prefix]++
+		d, ok := a.nextFree(prefix)
+		if !ok {
+			// Should not happen: N routes should always have a free slot among N
+			// distances.
+			d = a.count[prefix]
+		}
+		a.markUsed(prefix, d)
+		toAdd[i].StaticRouteDistance = d
+	}
+}
+
+// compareRoutesWithGracePeriod compares current and desired routes with grace
+// period consideration
+func compareRoutesWithGracePeriod(current, desired []StaticRoute, routeLastSeen map[string]time.Time, grace config.GracePolicy) ([]StaticRoute, []StaticRoute) {
+	var toAdd, toRemove []StaticRoute
+	now := time.Now()
+
+	desiredMap := make(map[string]StaticRoute, len(desired))
+	for _, route := range desired {
+		key := routes.Key(route.StaticRouteNetwork, route.StaticRouteNexthop)
+		desiredMap[key] = route
+	}
+
+	for _, cur := range current {
+		key := routes.Key(cur.StaticRouteNetwork, cur.StaticRouteNexthop)
+		if _, exists := desiredMap[key]; exists {
+			continue
+		}
+		if !cur.IsThreadRoute() {
+			continue
+		}
+		if lastSeen, seen := routeLastSeen[key]; seen {
+			if now.Sub(lastSeen) < grace.For(cur.StaticRouteNetwork) {
+				continue // within grace period
+			}
+		} else {
+			logger.Debug("UniFi: route %s -> %s not in detected routes, grace period started",
+				cur.StaticRouteNetwork, cur.StaticRouteNexthop)
+			routeLastSeen[key] = now
+			continue
+		}
+		toRemove = append(toRemove, cur)
+	}
+
+	currentMap := make(map[string]bool, len(current))
+	for _, route := range current {
+		key := routes.Key(route.StaticRouteNetwork, route.StaticRouteNexthop)
+		currentMap[key] = true
+	}
+	for _, des := range desired {
+		key := routes.Key(des.StaticRouteNetwork, des.StaticRouteNexthop)
+		if !currentMap[key] {
+			toAdd = append(toAdd, des)
+		}
+	}
+
+	return toAdd, toRemove
+}
@@ -0,0 +1,486 @@
+package unifi
+
+import (
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/config"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/discovery"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/routes"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/state"
+)
+
+// TestConvertRoutes tests the conversion to UniFi route format
+func TestConvertRoutes(t *testing.T) {
+	detected := []routes.Route{
+		{
+			CIDR:             netip.MustParsePrefix("fd00:1234:5678:9abc::/64"),
+			ThreadRouterIPv6: netip.MustParseAddr("fd00:1234:5678:9abc::ff"),
+			RouterName:       "Test Router",
+		},
+		{
+			CIDR:             netip.MustParsePrefix("fd00:5678:9abc:def0::/64"),
+			ThreadRouterIPv6: netip.MustParseAddr("fd00:5678:9abc:def0::fe"),
+			RouterName:       "Another Router",
+		},
+	}
+
+	unifiRoutes := ConvertRoutes(detected, "aa:bb:cc:dd:ee:ff")
+
+	if len(unifiRoutes) != len(detected) {
+		t.Errorf("Expected %d UniFi routes, got %d", len(detected), len(unifiRoutes))
+	}
+
+	for i, unifiRoute := range unifiRoutes {
+		originalRoute := detected[i]
+
+		// Check required fields
+		if !unifiRoute.Enabled {
+			t.Error("Expected route to be enabled")
+		}
+
+		expectedName := RouteName(originalRoute.RouterName, originalRoute.CIDR.String(), originalRoute.ThreadRouterIPv6.String())
+		if unifiRoute.Name != expectedName {
+			t.Errorf("Expected route name %q, got %q", expectedName, unifiRoute.Name)
+		}
+
+		if unifiRoute.StaticRouteNetwork != originalRoute.CIDR.String() {
+			t.Errorf("Expected StaticRouteNetwork %s, got %s",
+				originalRoute.CIDR, unifiRoute.StaticRouteNetwork)
+		}
+
+		if unifiRoute.StaticRouteNexthop != originalRoute.ThreadRouterIPv6.String() {
+			t.Errorf("Expected StaticRouteNexthop %s, got %s",
+				originalRoute.ThreadRouterIPv6, unifiRoute.StaticRouteNexthop)
+		}
+
+		// Check that name contains router name
+		if !strings.Contains(unifiRoute.Name, originalRoute.RouterName) {
+			t.Errorf("Expected route name to contain router name '%s', got '%s'",
+				originalRoute.RouterName, unifiRoute.Name)
+		}
+	}
+}
+
+// TestCompareRoutesWithGracePeriod tests the grace period logic for route comparison
+func TestCompareRoutesWithGracePeriod(t *testing.T) {
+	now := time.Now()
+	gracePeriod := 10 * time.Minute
+
+	tests := []struct {
+		name           string
+		current        []StaticRoute
+		desired        []StaticRoute
+		routeLastSeen  map[string]time.Time
+		gracePeriod    time.Duration
+		expectedAdd    int
+		expectedRemove int
+	}{
+		{
+			name:           "No routes to add or remove",
+			current:        []StaticRoute{},
+			desired:        []StaticRoute{},
+			routeLastSeen:  map[string]time.Time{},
+			gracePeriod:    gracePeriod,
+			expectedAdd:    0,
+			expectedRemove: 0,
+		},
+		{
+			name:    "Add new route",
+			current: []StaticRoute{},
+			desired: []StaticRoute{
+				{
+					StaticRouteNetwork: "fd00:1111:2222:3333::/64",
+					StaticRouteNexthop: "2001:4860:4860:1234::ff",
+					Name:               "Thread route via Router1",
+				},
+			},
+			routeLastSeen:  map[string]time.Time{},
+			gracePeriod:    gracePeriod,
+			expectedAdd:    1,
+			expectedRemove: 0,
+		},
+		{
+			name: "Route never seen before gets grace period",
+			current: []StaticRoute{
+				{
+					ID:                 "route1",
+					StaticRouteNetwork: "fd00:1111:2222:3333::/64",
+					StaticRouteNexthop: "2001:4860:4860:1234::ff",
+					Name:               "Thread route via Router1",
+				},
+			},
+			desired:        []StaticRoute{},
+			routeLastSeen:  map[string]time.Time{},
+			gracePeriod:    gracePeriod,
+			expectedAdd:    0,
+			expectedRemove: 0, // Gets grace period when never seen before
+		},
+		{
+			name: "Route within grace period should not be removed",
+			current: []StaticRoute{
+				{
+					ID:                 "route1",
+					StaticRouteNetwork: "fd00:1111:2222:3333::/64",
+					StaticRouteNexthop: "2001:4860:4860:1234::ff",
+					Name:               "Thread route via Router1",
+				},
+			},
+			desired: []StaticRoute{},
+			routeLastSeen: map[string]time.Time{
+				"fd00:1111:2222:3333::/64->2001:4860:4860:1234::ff": now.Add(-5 * time.Minute), // 5 minutes ago
+			},
+			gracePeriod:    gracePeriod,
+			expectedAdd:    0,
+			expectedRemove: 0, // Should not be removed yet
+		},
+		{
+			name: "Route beyond grace period should be removed",
+			current: []StaticRoute{
+				{
+					ID:                 "route1",
+					StaticRouteNetwork: "fd00:1111:2222:3333::/64",
+					StaticRouteNexthop: "2001:4860:4860:1234::ff",
+					Name:               "Thread route via Router1",
+				},
+			},
+			desired: []StaticRoute{},
+			routeLastSeen: map[string]time.Time{
+				"fd00:1111:2222:3333::/64->2001:4860:4860:1234::ff": now.Add(-15 * time.Minute), // 15 minutes ago
+			},
+			gracePeriod:    gracePeriod,
+			expectedAdd:    0,
+			expectedRemove: 1, // Should be removed
+		},
+		{
+			name: "Mixed scenario: add new, keep existing, remove old",
+			current: []StaticRoute{
+				{
+					ID:                 "route1",
+					StaticRouteNetwork: "fd00:1111:2222:3333::/64",
+					StaticRouteNexthop: "2001:4860:4860:1234::ff",
+					Name:               "Thread route via Router1",
+				},
+				{
+					ID:                 "route2",
+					StaticRouteNetwork: "fd00:2222:3333:4444::/64",
+					StaticRouteNexthop: "2001:4860:4860:1234::fe",
+					Name:               "Thread route via Router2",
+				},
+			},
+			desired: []StaticRoute{
+				{
+					StaticRouteNetwork: "fd00:1111:2222:3333::/64",
+					StaticRouteNexthop: "2001:4860:4860:1234::ff",
+					Name:               "Thread route via Router1",
+				},
+				{
+					StaticRouteNetwork: "fd00:3333:4444:5555::/64",
+					StaticRouteNexthop: "2001:4860:4860:1234::fd",
+					Name:               "Thread route via Router3",
+				},
+			},
+			routeLastSeen: map[string]time.Time{
+				"fd00:2222:3333:4444::/64->2001:4860:4860:1234::fe": now.Add(-15 * time.Minute), // Old, should be removed
+			},
+			gracePeriod:    gracePeriod,
+			expectedAdd:    1, // New route
+			expectedRemove: 1, // Old route beyond grace period
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			toAdd, toRemove := compareRoutesWithGracePeriod(tt.current, tt.desired, tt.routeLastSeen, config.GracePolicy{Default: tt.gracePeriod})
+
+			if len(toAdd) != tt.expectedAdd {
+				t.Errorf("Expected %d routes to add, got %d", tt.expectedAdd, len(toAdd))
+			}
+
+			if len(toRemove) != tt.expectedRemove {
+				t.Errorf("Expected %d routes to remove, got %d", tt.expectedRemove, len(toRemove))
+			}
+		})
+	}
+}
+
+// TestCompareRoutesGraceRules verifies the grace period follows the route
+// network's class.
+func TestCompareRoutesGraceRules(t *testing.T) {
+	lastSeen := time.Now().Add(-15 * time.Minute)
+	current := []StaticRoute{
+		{Name: "Thread route via Router1", StaticRouteNetwork: "fd00:1111:2222:3333::/64", StaticRouteNexthop: "2001:4860:4860:1234::ff"},
+		{Name: "Thread route via Router1", StaticRouteNetwork: "2001:4860:4860:9999::/64", StaticRouteNexthop: "2001:4860:4860:1234::ff"},
+	}
+	routeLastSeen := map[string]time.Time{
+		routes.Key(current[0].StaticRouteNetwork, current[0].StaticRouteNexthop): lastSeen,
+		routes.Key(current[1].StaticRouteNetwork, current[1].StaticRouteNexthop): lastSeen,
+	}
+	grace := config.GracePolicy{
+		Default: 10 * time.Minute,
+		Rules:   []config.GraceRule{{Match: "ula", Period: time.Hour}},
+	}
+
+	_, toRemove := compareRoutesWithGracePeriod(current, nil, routeLastSeen, grace)
+	if len(toRemove) != 1 || toRemove[0].StaticRouteNetwork != "2001:4860:4860:9999::/64" {
+		t.Errorf("Expected only the GUA route to expire, got %+v", toRemove)
+	}
+}
+
+// TestDistanceAllocator verifies compact distance assignment in 1..N per prefix.
+func TestDistanceAllocator(t *testing.T) {
+	prefix := "fd36:1fa8:d5a::/64"
+
+	t.Run("empty current, batch of three", func(t *testing.T) {
+		toAdd := []StaticRoute{
+			{StaticRouteNetwork: prefix, StaticRouteNexthop: "2001::1"},
+			{StaticRouteNetwork: prefix, StaticRouteNexthop: "2001::2"},
+			{StaticRouteNetwork: prefix, StaticRouteNexthop: "2001::3"},
+		}
+		newDistanceAllocator(nil, nil).assign(toAdd)
+		if toAdd[0].StaticRouteDistance != 1 || toAdd[1].StaticRouteDistance != 2 || toAdd[2].StaticRouteDistance != 3 {
+			t.Errorf("expected distances 1,2,3 got %d,%d,%d",
+				toAdd[0].StaticRouteDistance, toAdd[1].StaticRouteDistance, toAdd[2].StaticRouteDistance)
+		}
+	})
+
+	t.Run("current routes with missing distance field", func(t *testing.T) {
+		current := []StaticRoute{
+			{StaticRouteNetwork: prefix, StaticRouteNexthop: "2001::1", StaticRouteDistance: 0},
+			{StaticRouteNetwork: prefix, StaticRouteNexthop: "2001::2", StaticRouteDistance: 0},
+		}
+		toAdd := []StaticRoute{
+			{StaticRouteNetwork: prefix, StaticRouteNexthop: "2001::3"},
+		}
+		newDistanceAllocator(current, nil).assign(toAdd)
+		if toAdd[0].StaticRouteDistance != 3 {
+			t.Errorf("expected distance 3 when two existing routes omit distance, got %d", toAdd[0].StaticRouteDistance)
+		}
+	})
+
+	t.Run("fills gap instead of max+1", func(t *testing.T) {
+		current := []StaticRoute{
+			{StaticRouteNetwork: prefix, StaticRouteNexthop: "2001::1", StaticRouteDistance: 1},
+			{StaticRouteNetwork: prefix, StaticRouteNexthop: "2001::2", StaticRouteDistance: 4},
+		}
+		toAdd := []StaticRoute{
+			{StaticRouteNetwork: prefix, StaticRouteNexthop: "2001::3"},
+		}
+		newDistanceAllocator(current, nil).assign(toAdd)
+		if toAdd[0].StaticRouteDistance != 2 {
+			t.Errorf("expected distance 2 (gap fill), got %d", toAdd[0].StaticRouteDistance)
+		}
+	})
+	t.Run("configured base distance", func(t *testing.T) {
+		current := []StaticRoute{
+			{StaticRouteNetwork: prefix, StaticRouteNexthop: "2001::1", StaticRouteDistance: 1}, // manual route
+			{StaticRouteNetwork: prefix, StaticRouteNexthop: "2001::2", StaticRouteDistance: 200},
+		}
+		toAdd := []StaticRoute{
+			{StaticRouteNetwork: prefix, StaticRouteNexthop: "2001::3"},
+			{StaticRouteNetwork: "fd00:2::/64", StaticRouteNexthop: "2001::3"},
+		}
+		base := func(p string) int {
+			if p == prefix {
+				return 200
+			}
+			return 0
+		}
+		newDistanceAllocator(current, base).assign(toAdd)
+		if toAdd[0].StaticRouteDistance != 201 || toAdd[1].StaticRouteDistance != 1 {
+			t.Errorf("expected distances 201 and 1, got %d and %d", toAdd[0].StaticRouteDistance, toAdd[1].StaticRouteDistance)
+		}
+	})
+}
+
+// TestSplitRenumbered verifies routes through a vanished /64 skip the grace period.
+func TestSplitRenumbered(t *testing.T) {
+	s := &Syncer{}
+	current := []StaticRoute{
+		{Name: "Thread route via Router1", StaticRouteNetwork: "fd00:1111:2222:3333::/64", StaticRouteNexthop: "2001:4860:4860:1234::ff"},
+		{Name: "Thread route via Router2", StaticRouteNetwork: "fd00:1111:2222:3333::/64", StaticRouteNexthop: "2001:4860:4860:1234::fe"},
+		{Name: "Manual route", StaticRouteNetwork: "fd00:9999::/64", StaticRouteNexthop: "2001:4860:4860:1234::1"},
+	}
+
+	t.Run("stable prefix keeps grace period", func(t *testing.T) {
+		desired := []StaticRoute{current[0]}
+		renumbered, retained := s.splitRenumbered(current, desired)
+		if len(renumbered) != 0 || len(retained) != len(current) {
+			t.Errorf("Expected no renumbered routes, got %d renumbered %d retained", len(renumbered), len(retained))
+		}
+	})
+
+	t.Run("renumbered prefix fast-tracked", func(t *testing.T) {
+		desired := []StaticRoute{
+			{Name: RouteName("Router1", "fd00:1111:2222:3333::/64", "2001:4860:4860:5678::ff"),
+				StaticRouteNetwork: "fd00:1111:2222:3333::/64", StaticRouteNexthop: "2001:4860:4860:5678::ff"},
+		}
+		renumbered, retained := s.splitRenumbered(current, desired)
+		if len(renumbered) != 2 {
+			t.Errorf("Expected 2 renumbered routes, got %d", len(renumbered))
+		}
+		if len(retained) != 1 || retained[0].Name != "Manual route" {
+			t.Errorf("Expected only the unmanaged route to be retained, got %+v", retained)
+		}
+	})
+
+	t.Run("unrelated router in a new prefix keeps grace period", func(t *testing.T) {
+		desired := []StaticRoute{
+			{Name: RouteName("Router3", "fd00:1111:2222:3333::/64", "2001:4860:4860:5678::ff"),
+				StaticRouteNetwork: "fd00:1111:2222:3333::/64", StaticRouteNexthop: "2001:4860:4860:5678::ff"},
+		}
+		renumbered, retained := s.splitRenumbered(current, desired)
+		if len(renumbered) != 0 || len(retained) != len(current) {
+			t.Errorf("Expected no renumbered routes, got %d renumbered %d retained", len(renumbered), len(retained))
+		}
+	})
+}
+
+// TestSplitFailedOver verifies routes through an expired router skip the grace
+// period only when another next hop serves the same network.
+func TestSplitFailedOver(t *testing.T) {
+	st := state.New(nil)
+	st.MergeBorderRouter(discovery.BorderRouter{
+		Name:      "Router1",
+		IPv6Addrs: []netip.Addr{netip.MustParseAddr("2001:4860:4860:1234::ff")},
+	})
+	st.RemoveExpiredRouters(-time.Second)
+	s := &Syncer{state: st}
+
+	current := []StaticRoute{
+		{Name: "Thread route via Router1", StaticRouteNetwork: "fd00:1111:2222:3333::/64", StaticRouteNexthop: "2001:4860:4860:1234::ff"},
+		{Name: "Thread route via Router1", StaticRouteNetwork: "fd00:4444:5555:6666::/64", StaticRouteNexthop: "2001:4860:4860:1234::ff"},
+		{Name: "Thread route via Router2", StaticRouteNetwork: "fd00:1111:2222:3333::/64", StaticRouteNexthop: "2001:4860:4860:1234::fe"},
+		{Name: "Manual route", StaticRouteNetwork: "fd00:1111:2222:3333::/64", StaticRouteNexthop: "2001:4860:4860:1234::ff"},
+	}
+	desired := []StaticRoute{current[2]}
+
+	failedOver, retained := s.splitFailedOver(current, desired)
+	if len(failedOver) != 1 || failedOver[0].StaticRouteNetwork != "fd00:1111:2222:3333::/64" {
+		t.Errorf("Expected the route with an alternative next hop to fail over, got %+v", failedOver)
+	}
+	if len(retained) != 3 {
+		t.Errorf("Expected 3 retained routes, got %d", len(retained))
+	}
+}
+
+// TestPairReplacements verifies replaced routes are moved in place to a pending
+// next hop for the same network, keeping their ID and distance.
+func TestPairReplacements(t *testing.T) {
+	replaced := []StaticRoute{
+		{ID: "r1", Name: "Thread route via Router1", StaticRouteNetwork: "fd00:1111:2222:3333::/64", StaticRouteNexthop: "2001:4860:4860:1234::ff", StaticRouteDistance: 1},
+		{ID: "r2", Name: "Thread route via Router1", StaticRouteNetwork: "fd00:4444:5555:6666::/64", StaticRouteNexthop: "2001:4860:4860:1234::ff", StaticRouteDistance: 1},
+	}
+	toAdd := []StaticRoute{
+		{Name: "Thread route via Router3", StaticRouteNetwork: "fd00:1111:2222:3333::/64", StaticRouteNexthop: "2001:4860:4860:1234::fd"},
+		{Name: "Thread route via Router3", StaticRouteNetwork: "fd00:7777::/64", StaticRouteNexthop: "2001:4860:4860:1234::fd"},
+	}
+
+	updates, adds, removals := pairReplacements(replaced, toAdd)
+	if len(updates) != 1 {
+		t.Fatalf("Expected 1 update, got %d", len(updates))
+	}
+	u := updates[0].to
+	if u.ID != "r1" || u.StaticRouteDistance != 1 || u.StaticRouteNexthop != "2001:4860:4860:1234::fd" || u.Name != "Thread route via Router3" {
+		t.Errorf("Unexpected update %+v", u)
+	}
+	if len(adds) != 1 || adds[0].StaticRouteNetwork != "fd00:7777::/64" {
+		t.Errorf("Expected only the unpaired addition to remain, got %+v", adds)
+	}
+	if len(removals) != 1 || removals[0].ID != "r2" {
+		t.Errorf("Expected only the unpaired route to be removed, got %+v", removals)
+	}
+}
+
+func TestRefreshRoutes(t *testing.T) {
+	named := func(network, nexthop string) string { return RouteName("Apple TV", network, nexthop) }
+	current := []StaticRoute{
+		{ID: "r1", Name: named("fd00:1::/64", "2001:db8::1"), StaticRouteNetwork: "fd00:1::/64", StaticRouteNexthop: "2001:db8::1", GatewayDevice: "aa:bb:cc:00:00:09"},
+		{ID: "r2", Name: named("fd00:2::/64", "2001:db8::2"), StaticRouteNetwork: "fd00:2::/64", StaticRouteNexthop: "2001:db8::2", GatewayDevice: "AA:BB:CC:00:00:01"},
+		{ID: "r3", Name: "Thread route via Apple TV", StaticRouteNetwork: "fd00:3::/64", StaticRouteNexthop: "2001:db8::3", GatewayDevice: "aa:bb:cc:00:00:01"},
+		{ID: "r4", Name: named("fd00:4::/64", "2001:db8::4"), StaticRouteNetwork: "fd00:4::/64", StaticRouteNexthop: "2001:db8::4", GatewayDevice: "aa:bb:cc:00:00:09"},
+		{ID: "r5", Name: "Office", StaticRouteNetwork: "fd00:5::/64", StaticRouteNexthop: "2001:db8::5", GatewayDevice: "aa:bb:cc:00:00:09"},
+	}
+	var desired []StaticRoute
+	for _, r := range current[:2] {
+		desired = append(desired, StaticRoute{Name: r.Name, StaticRouteNetwork: r.StaticRouteNetwork,
+			StaticRouteNexthop: r.StaticRouteNexthop, GatewayDevice: "aa:bb:cc:00:00:01"})
+	}
+	desired = append(desired,
+		StaticRoute{Name: named("fd00:3::/64", "2001:db8::3"), StaticRouteNetwork: "fd00:3::/64", StaticRouteNexthop: "2001:db8::3", GatewayDevice: "aa:bb:cc:00:00:01"},
+		StaticRoute{Name: "Thread route via Office", StaticRouteNetwork: "fd00:5::/64", StaticRouteNexthop: "2001:db8::5", GatewayDevice: "aa:bb:cc:00:00:01"})
+
+	updates := refreshRoutes(current, desired, nil)
+	if len(updates) != 2 {
+		t.Fatalf("Expected r1 moved and r3 renamed, got %+v", updates)
+	}
+	if updates[0].from.ID != "r1" || updates[0].to.GatewayDevice != "aa:bb:cc:00:00:01" || updates[0].to.StaticRouteNexthop != "2001:db8::1" {
+		t.Errorf("Expected r1 moved to the new gateway, got %+v", updates[0])
+	}
+	if updates[1].from.ID != "r3" || updates[1].to.Name != named("fd00:3::/64", "2001:db8::3") {
+		t.Errorf("Expected r3 renamed with its route hash, got %+v", updates[1])
+	}
+	if got := updates[1].changes(); got != `renamed from "Thread route via Apple TV"` {
+		t.Errorf("Unexpected change description %q", got)
+	}
+}
+
+// TestRefreshRoutesDescription verifies a configured description is written
+// to managed routes lacking it, and left alone when none is configured.
+func TestRefreshRoutesDescription(t *testing.T) {
+	name := RouteName("Apple TV", "fd00:1::/64", "2001:db8::1")
+	current := []StaticRoute{{ID: "r1", Name: name, StaticRouteNetwork: "fd00:1::/64", StaticRouteNexthop: "2001:db8::1", Description: "old"}}
+
+	if updates := refreshRoutes(current, []StaticRoute{{Name: name, StaticRouteNetwork: "fd00:1::/64", StaticRouteNexthop: "2001:db8::1"}}, nil); len(updates) != 0 {
+		t.Errorf("Expected no update without a configured description, got %+v", updates)
+	}
+	updates := refreshRoutes(current, []StaticRoute{{Name: name, StaticRouteNetwork: "fd00:1::/64", StaticRouteNexthop: "2001:db8::1", Description: "Thread"}}, nil)
+	if len(updates) != 1 || updates[0].to.Description != "Thread" || updates[0].changes() != `description "Thread"` {
+		t.Errorf("Expected the description updated, got %+v", updates)
+	}
+}
+
+// TestRefreshRoutesDistance verifies managed routes below the configured base
+// distance of their prefix are raised to the lowest free distance above it.
+func TestRefreshRoutesDistance(t *testing.T) {
+	name := RouteName("Apple TV", "fd00:1::/64", "2001:db8::1")
+	current := []StaticRoute{
+		{ID: "r1", Name: name, StaticRouteNetwork: "fd00:1::/64", StaticRouteNexthop: "2001:db8::1", StaticRouteDistance: 1},
+		{ID: "r2", Name: "LAN", StaticRouteNetwork: "fd00:1::/64", StaticRouteNexthop: "2001:db8::9", StaticRouteDistance: 200},
+	}
+	desired := []StaticRoute{{Name: name, StaticRouteNetwork: "fd00:1::/64", StaticRouteNexthop: "2001:db8::1"}}
+	base := func(string) int { return 200 }
+
+	updates := refreshRoutes(current, desired, newDistanceAllocator(current, base))
+	if len(updates) != 1 || updates[0].to.StaticRouteDistance != 201 || updates[0].changes() != "distance 1 -> 201" {
+		t.Errorf("Expected r1 raised to distance 201, got %+v", updates)
+	}
+	current[0].StaticRouteDistance = 201
+	if updates := refreshRoutes(current, desired, newDistanceAllocator(current, base)); len(updates) != 0 {
+		t.Errorf("Expected no update at or above the base distance, got %+v", updates)
+	}
+}
+
+func TestIsThreadRoute(t *testing.T) {
+	network, nexthop := "fd00:1::/64", "2001:db8::1"
+	tests := []struct {
+		name     string
+		expected bool
+	}{
+		{RouteName("Apple TV", network, nexthop), true},
+		{"Renamed in the UI [" + routes.Hash(network, nexthop) + "]", true},
+		{RouteName("Apple TV", network, "2001:db8::2"), false}, // hash of another route
+		{"Thread route via Apple TV", true},                    // named before route hashes
+		{"Thread route via Apple TV [notahash]", true},
+		{"Office", false},
+	}
+
+	for _, tt := range tests {
+		r := StaticRoute{Name: tt.name, StaticRouteNetwork: network, StaticRouteNexthop: nexthop}
+		if got := r.IsThreadRoute(); got != tt.expected {
+			t.Errorf("IsThreadRoute(%q) = %v, want %v", tt.name, got, tt.expected)
+		}
+	}
+}
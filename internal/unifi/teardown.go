@@ -0,0 +1,57 @@
+package unifi
+
+import "fmt"
+
+// TeardownResult lists what Teardown removed from the controller, or would
+// remove in a dry run, and what it failed to remove.
+type TeardownResult struct {
+	Routes        []StaticRoute
+	FirewallRules []FirewallRule
+	Failures      []error
+}
+
+// Teardown removes every route and firewall rule this daemon manages from the
+// controller, leaving everything else alone. With dryRun it only lists them.
+// Failures to delete one entry are collected and the rest are still removed;
+// an error is returned when the controller cannot be reached or listed.
+func (c *Client) Teardown(dryRun bool) (TeardownResult, error) {
+	var result TeardownResult
+	if err := c.Login(); err != nil {
+		return result, fmt.Errorf("login: %w", err)
+	}
+
+	current, err := c.StaticRoutes()
+	if err != nil {
+		return result, fmt.Errorf("listing routes: %w", err)
+	}
+	for _, r := range current {
+		if !r.IsThreadRoute() {
+			continue
+		}
+		if !dryRun {
+			if err := c.DeleteStaticRoute(r.ID); err != nil {
+				result.Failures = append(result.Failures, fmt.Errorf("route %s via %s: %w", r.StaticRouteNetwork, r.StaticRouteNexthop, err))
+				continue
+			}
+		}
+		result.Routes = append(result.Routes, r)
+	}
+
+	rules, err := c.FirewallRules()
+	if err != nil {
+		return result, fmt.Errorf("listing firewall rules: %w", err)
+	}
+	for _, r := range rules {
+		if !r.IsThreadRule() {
+			continue
+		}
+		if !dryRun {
+			if err := c.DeleteFirewallRule(r.ID); err != nil {
+				result.Failures = append(result.Failures, fmt.Errorf("firewall rule %q: %w", r.Name, err))
+				continue
+			}
+		}
+		result.FirewallRules = append(result.FirewallRules, r)
+	}
+	return result, nil
+}
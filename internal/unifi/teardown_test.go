@@ -0,0 +1,73 @@
+package unifi
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+)
+
+// teardownHandler serves two managed routes, one user route and a managed
+// and a user firewall rule, recording deletions. Deleting r2 fails.
+func teardownHandler(t *testing.T, deleted *[]string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/api/auth/login":
+			_, _ = w.Write([]byte(`{"meta":{"rc":"ok"}}`))
+		case r.URL.Path == "/proxy/network/api/s/default/rest/routing" && r.Method == "GET":
+			_, _ = w.Write([]byte(`{"meta":{"rc":"ok"},"data":[` +
+				`{"_id":"r1","name":"Thread route via Kitchen","type":"static-route","static-route_network":"fd00:1::/64","static-route_nexthop":"2001:4860::1"},` +
+				`{"_id":"r2","name":"Thread route via Office","type":"static-route","static-route_network":"fd00:2::/64","static-route_nexthop":"2001:4860::2"},` +
+				`{"_id":"u1","name":"Office","type":"static-route","static-route_network":"fd00:9::/64","static-route_nexthop":"2001:4860::9"}]}`))
+		case r.URL.Path == "/proxy/network/api/s/default/rest/firewallrule" && r.Method == "GET":
+			_, _ = w.Write([]byte(`{"meta":{"rc":"ok"},"data":[` +
+				`{"_id":"f1","name":"Thread firewall for fd00:1::/64","ruleset":"LANv6_IN","rule_index":2000},` +
+				`{"_id":"u2","name":"Allow printers","ruleset":"LANv6_IN","rule_index":2001}]}`))
+		case r.URL.Path == "/proxy/network/api/s/default/rest/routing/r2" && r.Method == "DELETE":
+			w.WriteHeader(http.StatusInternalServerError)
+		case r.Method == "DELETE":
+			*deleted = append(*deleted, r.URL.Path[len("/proxy/network/api/s/default/rest/"):])
+			_, _ = w.Write([]byte(`{"meta":{"rc":"ok"}}`))
+		default:
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	})
+}
+
+func TestTeardown(t *testing.T) {
+	var deleted []string
+	client := newLegacyTestClient(t, teardownHandler(t, &deleted))
+
+	result, err := client.Teardown(false)
+	if err != nil {
+		t.Fatalf("Teardown failed: %v", err)
+	}
+	if want := []string{"routing/r1", "firewallrule/f1"}; !slices.Equal(deleted, want) {
+		t.Errorf("Expected %v deleted, got %v", want, deleted)
+	}
+	if len(result.Routes) != 1 || result.Routes[0].ID != "r1" {
+		t.Errorf("Expected route r1 reported removed, got %+v", result.Routes)
+	}
+	if len(result.FirewallRules) != 1 || result.FirewallRules[0].ID != "f1" {
+		t.Errorf("Expected firewall rule f1 reported removed, got %+v", result.FirewallRules)
+	}
+	if len(result.Failures) != 1 {
+		t.Errorf("Expected the failed deletion of r2 reported, got %v", result.Failures)
+	}
+}
+
+func TestTeardownDryRun(t *testing.T) {
+	var deleted []string
+	client := newLegacyTestClient(t, teardownHandler(t, &deleted))
+
+	result, err := client.Teardown(true)
+	if err != nil {
+		t.Fatalf("Teardown failed: %v", err)
+	}
+	if len(deleted) != 0 {
+		t.Errorf("Expected nothing deleted in a dry run, got %v", deleted)
+	}
+	if len(result.Routes) != 2 || len(result.FirewallRules) != 1 || len(result.Failures) != 0 {
+		t.Errorf("Expected two routes and one firewall rule listed, got %+v", result)
+	}
+}
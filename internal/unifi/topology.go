@@ -0,0 +1,218 @@
+package unifi
+
+import (
+	"fmt"
+	"net/netip"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/discovery"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/logger"
+)
+
+// ClientTable caches the controller's client list between reads.
+type ClientTable struct {
+	client *Client
+
+	mu       sync.Mutex
+	clients  []NetworkClient
+	networks []NetworkConf
+	readAt   time.Time
+	changed  map[string]time.Time // client MAC → last read its counters changed
+}
+
+// NewClientTable returns an empty table read through client.
+func NewClientTable(client *Client) *ClientTable {
+	return &ClientTable{client: client}
+}
+
+// Refresh re-reads the client list and the networks, logging in first when
+// the session expired. The previous lists are kept when a read fails.
+func (t *ClientTable) Refresh() error {
+	if !t.client.HasValidSession() {
+		if err := t.client.Login(); err != nil {
+			return err
+		}
+	}
+	clients, err := t.client.NetworkClients()
+	if err != nil {
+		return err
+	}
+	networks, err := t.client.NetworkConfs()
+	if err != nil {
+		logger.Warn("UniFi: could not read networks, keeping the last known: %v", err)
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := time.Now()
+	t.noteCounters(clients, now)
+	t.clients = clients
+	if err == nil {
+		t.networks = networks
+	}
+	t.readAt = now
+	return nil
+}
+
+// Clients returns the client list and when it was read, zero before the first read.
+func (t *ClientTable) Clients() ([]NetworkClient, time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]NetworkClient(nil), t.clients...), t.readAt
+}
+
+// TopologyNode is a border router or Matter device with where the controller
+// sees it on the network. The client fields are empty when no client matched.
+type TopologyNode struct {
+	Name        string       `json:"name"`
+	IPv6Addrs   []netip.Addr `json:"ipv6_addrs,omitempty"`
+	MAC         string       `json:"mac,omitempty"`
+	Hostname    string       `json:"hostname,omitempty"`
+	Matched     bool         `json:"matched"`
+	Wired       bool         `json:"wired,omitempty"`
+	SwitchMAC   string       `json:"switch_mac,omitempty"`
+	SwitchPort  int          `json:"switch_port,omitempty"`
+	AccessPoint string       `json:"access_point,omitempty"`
+	ESSID       string       `json:"essid,omitempty"`
+	Network     string       `json:"network,omitempty"`
+	VLAN        int          `json:"vlan,omitempty"`
+	Unreachable bool         `json:"unreachable,omitempty"` // the gateway does not route IPv6 on Network
+}
+
+// TopologyNetwork lists the Thread infrastructure on one controller network.
+type TopologyNetwork struct {
+	Network       string   `json:"network"`
+	VLAN          int      `json:"vlan,omitempty"`
+	BorderRouters []string `json:"border_routers,omitempty"`
+	Devices       []string `json:"devices,omitempty"`
+	Unreachable   bool     `json:"unreachable,omitempty"` // the gateway does not route IPv6 here
+}
+
+// Topology joins the discovered border routers and Matter devices with the
+// controller's client list.
+type Topology struct {
+	ClientsReadAt time.Time         `json:"clients_read_at,omitzero"`
+	BorderRouters []TopologyNode    `json:"border_routers"`
+	Devices       []TopologyNode    `json:"devices"`
+	Networks      []TopologyNetwork `json:"networks"` // by network; unmatched nodes are left out
+}
+
+// Networks returns the cached networks.
+func (t *ClientTable) Networks() []NetworkConf {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]NetworkConf(nil), t.networks...)
+}
+
+// Uplinks returns where the routers attach to the LAN, from the cached lists.
+func (t *ClientTable) Uplinks(routers []discovery.BorderRouter) map[string]discovery.Uplink {
+	clients, _ := t.Clients()
+	return RouterUplinks(routers, clients, t.Networks())
+}
+
+// Topology joins routers and devices with the cached lists.
+func (t *ClientTable) Topology(routers []discovery.BorderRouter, devices []discovery.MatterDevice) Topology {
+	clients, readAt := t.Clients()
+	topo := BuildTopology(routers, devices, clients, t.Networks())
+	topo.ClientsReadAt = readAt
+	return topo
+}
+
+// BuildTopology joins routers and devices with clients, matching them by
+// hardware address, IPv6 address, EUI-64 MAC or name, and with the networks
+// the clients are on.
+func BuildTopology(routers []discovery.BorderRouter, devices []discovery.MatterDevice, clients []NetworkClient, networks []NetworkConf) Topology {
+	topo := Topology{BorderRouters: []TopologyNode{}, Devices: []TopologyNode{}, Networks: []TopologyNetwork{}}
+	groups := make(map[string]*TopologyNetwork)
+	network := func(n TopologyNode) *TopologyNetwork {
+		if !n.Matched {
+			return nil
+		}
+		if groups[n.Network] == nil {
+			groups[n.Network] = &TopologyNetwork{Network: n.Network, VLAN: n.VLAN, Unreachable: n.Unreachable}
+		}
+		return groups[n.Network]
+	}
+
+	for _, r := range routers {
+		c, ok := matchClient(clients, "", r.IPv6Addrs, r.Name)
+		n := topologyNode(r.Name, r.IPv6Addrs, c, ok, networks)
+		topo.BorderRouters = append(topo.BorderRouters, n)
+		if group := network(n); group != nil {
+			group.BorderRouters = append(group.BorderRouters, r.Name)
+		}
+	}
+	for _, d := range devices {
+		c, ok := matchClient(clients, d.MAC, d.IPv6Addrs, d.Hostname, d.Name)
+		n := topologyNode(d.Name, d.IPv6Addrs, c, ok, networks)
+		if !ok {
+			n.MAC, n.Hostname = d.MAC, d.Hostname
+		}
+		topo.Devices = append(topo.Devices, n)
+		if group := network(n); group != nil {
+			group.Devices = append(group.Devices, d.Name)
+		}
+	}
+	for _, n := range groups {
+		topo.Networks = append(topo.Networks, *n)
+	}
+	sort.Slice(topo.Networks, func(i, j int) bool {
+		if topo.Networks[i].VLAN != topo.Networks[j].VLAN {
+			return topo.Networks[i].VLAN < topo.Networks[j].VLAN
+		}
+		return topo.Networks[i].Network < topo.Networks[j].Network
+	})
+	return topo
+}
+
+// topologyNode describes a discovered node and, when matched, its client and
+// the network that client is on.
+func topologyNode(name string, addrs []netip.Addr, c NetworkClient, matched bool, networks []NetworkConf) TopologyNode {
+	n := TopologyNode{Name: name, IPv6Addrs: addrs, Matched: matched}
+	if !matched {
+		return n
+	}
+	n.MAC = c.MAC
+	n.Hostname = c.Hostname
+	n.Wired = c.IsWired
+	n.SwitchMAC, n.SwitchPort = c.SwitchMAC, c.SwitchPort
+	n.AccessPoint, n.ESSID = c.APMAC, c.ESSID
+	n.Network, n.VLAN = c.Network, c.VLAN
+	if conf, ok := findNetwork(networks, c); ok {
+		n.Network, n.VLAN = conf.Name, conf.VLANID()
+		n.Unreachable = !conf.RoutesIPv6()
+	}
+	return n
+}
+
+// VLANWarnings describes VLAN layouts that break routing to Thread: border
+// routers on networks the gateway does not route IPv6 on, and Matter devices
+// on other networks than every border router, which then depend on
+// cross-VLAN mDNS and firewall rules to reach Thread devices.
+func VLANWarnings(topo Topology) []string {
+	var warnings []string
+	var routerNets, deviceNets []string
+	for _, n := range topo.Networks {
+		name := n.Network
+		if n.VLAN != 0 {
+			name = fmt.Sprintf("%s (VLAN %d)", n.Network, n.VLAN)
+		}
+		if len(n.BorderRouters) > 0 {
+			routerNets = append(routerNets, name)
+			if n.Unreachable {
+				warnings = append(warnings, fmt.Sprintf("border routers %s are on %s, where the gateway does not route IPv6: routes through them cannot work",
+					strings.Join(n.BorderRouters, ", "), name))
+			}
+		} else if len(n.Devices) > 0 {
+			deviceNets = append(deviceNets, name)
+		}
+	}
+	if len(routerNets) > 0 && len(deviceNets) > 0 {
+		warnings = append(warnings, fmt.Sprintf("Matter devices on %s share no network with the border routers on %s: "+
+			"they reach Thread only through the gateway routes and need mDNS reflection and inter-VLAN firewall rules",
+			strings.Join(deviceNets, ", "), strings.Join(routerNets, ", ")))
+	}
+	return warnings
+}
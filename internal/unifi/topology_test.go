@@ -0,0 +1,135 @@
+package unifi
+
+import (
+	"net/http"
+	"net/netip"
+	"strings"
+	"testing"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/discovery"
+)
+
+// TestBuildTopology verifies border routers and devices are joined with their
+// clients and grouped by network, leaving unmatched ones ungrouped.
+func TestBuildTopology(t *testing.T) {
+	clients := []NetworkClient{
+		{MAC: "aa:bb:cc:dd:ee:01", IsWired: true, SwitchMAC: "f0:9f:c2:00:00:01", SwitchPort: 7, Network: "IoT", VLAN: 30, IPv6Addrs: []string{"2a02:8109::1"}},
+		{MAC: "00:17:88:01:02:03", APMAC: "f0:9f:c2:00:00:02", ESSID: "home-iot", Network: "IoT", VLAN: 30},
+		{MAC: "aa:bb:cc:dd:ee:03", Hostname: "Bedroom", APMAC: "f0:9f:c2:00:00:02", Network: "Default"},
+	}
+	routers := []discovery.BorderRouter{
+		{Name: "Living Room", IPv6Addrs: []netip.Addr{netip.MustParseAddr("2a02:8109::1")}},
+		{Name: "Bedroom"},
+		{Name: "Garage"},
+	}
+	devices := []discovery.MatterDevice{
+		{Name: "Lamp", MAC: "00:17:88:01:02:03"},
+		{Name: "Plug", MAC: "11:22:33:44:55:66"},
+	}
+
+	topo := BuildTopology(routers, devices, clients, nil)
+	if len(topo.BorderRouters) != 3 || len(topo.Devices) != 2 {
+		t.Fatalf("Expected 3 border routers and 2 devices, got %+v", topo)
+	}
+	living := topo.BorderRouters[0]
+	if !living.Matched || !living.Wired || living.SwitchPort != 7 || living.VLAN != 30 {
+		t.Errorf("Expected Living Room wired on port 7 of VLAN 30, got %+v", living)
+	}
+	if topo.BorderRouters[2].Matched {
+		t.Errorf("Expected Garage unmatched, got %+v", topo.BorderRouters[2])
+	}
+	if lamp := topo.Devices[0]; !lamp.Matched || lamp.ESSID != "home-iot" {
+		t.Errorf("Expected Lamp on home-iot, got %+v", lamp)
+	}
+	if plug := topo.Devices[1]; plug.Matched || plug.MAC != "11:22:33:44:55:66" {
+		t.Errorf("Expected Plug unmatched with its own MAC, got %+v", plug)
+	}
+
+	want := []TopologyNetwork{
+		{Network: "Default", BorderRouters: []string{"Bedroom"}},
+		{Network: "IoT", VLAN: 30, BorderRouters: []string{"Living Room"}, Devices: []string{"Lamp"}},
+	}
+	if len(topo.Networks) != len(want) {
+		t.Fatalf("Expected networks %+v, got %+v", want, topo.Networks)
+	}
+	for i, w := range want {
+		got := topo.Networks[i]
+		if got.Network != w.Network || got.VLAN != w.VLAN || len(got.BorderRouters) != len(w.BorderRouters) || len(got.Devices) != len(w.Devices) {
+			t.Errorf("Expected network %d to be %+v, got %+v", i, w, got)
+		}
+	}
+}
+
+// TestClientTableRefresh verifies the table logs in, caches the client list
+// and keeps it when a later read fails.
+func TestClientTableRefresh(t *testing.T) {
+	fail := false
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/auth/login":
+			w.Header().Set("X-Csrf-Token", "csrf-token")
+			http.SetCookie(w, &http.Cookie{Name: "TOKEN", Value: "session-cookie"})
+			_, _ = w.Write([]byte(`{"meta":{"rc":"ok"}}`))
+		case "/proxy/network/api/s/default/rest/networkconf":
+			_, _ = w.Write([]byte(`{"data":[{"_id":"n1","name":"IoT","purpose":"corporate","vlan_enabled":true,"vlan":"30"}]}`))
+		case "/proxy/network/api/s/default/stat/sta":
+			if fail {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			_, _ = w.Write([]byte(`{"data":[{"mac":"aa:bb:cc:dd:ee:01","is_wired":true,"network":"IoT","vlan":"30"}]}`))
+		default:
+			t.Errorf("Unexpected request %s", r.URL.Path)
+		}
+	}))
+	table := NewClientTable(client)
+	if _, readAt := table.Clients(); !readAt.IsZero() {
+		t.Errorf("Expected no read yet, got %v", readAt)
+	}
+	if err := table.Refresh(); err != nil {
+		t.Fatalf("Expected no error, got %v", err)
+	}
+	fail = true
+	if err := table.Refresh(); err == nil {
+		t.Errorf("Expected the failed read to be reported")
+	}
+	clients, readAt := table.Clients()
+	if len(clients) != 1 || clients[0].VLAN != 30 || readAt.IsZero() {
+		t.Errorf("Expected the cached client on VLAN 30, got %+v at %v", clients, readAt)
+	}
+	if networks := table.Networks(); len(networks) != 1 || networks[0].VLANID() != 30 {
+		t.Errorf("Expected the cached IoT network on VLAN 30, got %+v", networks)
+	}
+}
+
+// TestVLANWarnings verifies warnings for border routers the gateway cannot
+// reach and for Matter devices sharing no network with any border router.
+func TestVLANWarnings(t *testing.T) {
+	networks := []NetworkConf{
+		{ID: "n1", Name: "IoT", Purpose: "corporate", VLANEnabled: true, VLAN: 30},
+		{ID: "n2", Name: "Cameras", Purpose: "vlan-only", VLANEnabled: true, VLAN: 40},
+		{ID: "n3", Name: "Default", Purpose: "corporate"},
+	}
+	clients := []NetworkClient{
+		{MAC: "aa:bb:cc:dd:ee:01", NetworkID: "n2"},
+		{MAC: "aa:bb:cc:dd:ee:02", NetworkID: "n3"},
+	}
+	routers := []discovery.BorderRouter{{Name: "Living Room", IPv6Addrs: []netip.Addr{netip.MustParseAddr("fe80::a8bb:ccff:fedd:ee01")}}}
+	devices := []discovery.MatterDevice{{Name: "Lamp", MAC: "aa:bb:cc:dd:ee:02"}}
+
+	warnings := VLANWarnings(BuildTopology(routers, devices, clients, networks))
+	if len(warnings) != 2 {
+		t.Fatalf("Expected 2 warnings, got %q", warnings)
+	}
+	if !strings.Contains(warnings[0], "Living Room") || !strings.Contains(warnings[0], "Cameras (VLAN 40)") {
+		t.Errorf("Expected an unreachable warning for Living Room on Cameras, got %q", warnings[0])
+	}
+	if !strings.Contains(warnings[1], "Matter devices on Default") {
+		t.Errorf("Expected a cross-VLAN warning for Default, got %q", warnings[1])
+	}
+
+	clients[1].NetworkID = "n2"
+	if warnings := VLANWarnings(BuildTopology(routers, devices, clients, networks[:1])); len(warnings) != 0 {
+		t.Errorf("Expected no warnings without the network of the clients, got %q", warnings)
+	}
+}
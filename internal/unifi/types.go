@@ -0,0 +1,72 @@
+package unifi
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/routes"
+	"github.com/rafaelgaspar/unifi-thread-route-updater/pkg/unifiroutes"
+)
+
+const (
+	// RouteTypeStatic is the type of user-defined static routes in /rest/routing.
+	RouteTypeStatic = unifiroutes.RouteTypeStatic
+	// threadRouteNamePrefix marks the static routes managed by this daemon.
+	threadRouteNamePrefix = "Thread route via "
+)
+
+// StaticRoute represents a static route in UniFi format. It converts to and
+// from unifiroutes.Route, adding what only the daemon needs to know about it.
+type StaticRoute unifiroutes.Route
+
+// IsStatic reports whether the entry is a user-defined static route. Other
+// entry kinds returned by /rest/routing are never diffed or deleted.
+func (r StaticRoute) IsStatic() bool {
+	return unifiroutes.Route(r).IsStatic()
+}
+
+// IsIPv6 reports whether the destination network is an IPv6 prefix.
+func (r StaticRoute) IsIPv6() bool {
+	return unifiroutes.Route(r).IsIPv6()
+}
+
+// RouteName returns the name of the managed route to network via nexthop, the
+// border router routerName: "Thread route via <router> [<hash>]". The hash of
+// network and next hop tells apart routes through routers sharing a name. The
+// router name is sanitized and shortened to what the controller accepts.
+func RouteName(routerName, network, nexthop string) string {
+	return fmt.Sprintf("%s%s [%s]", threadRouteNamePrefix, routeLabel(routerName), routes.Hash(network, nexthop))
+}
+
+// IsThreadRoute reports whether the route is managed by this daemon: its name
+// ends with the hash of its own network and next hop. Routes named before hash
+// suffixes, with only the "Thread route via " prefix, are managed too and get
+// renamed by the next sync.
+func (r StaticRoute) IsThreadRoute() bool {
+	if hash, ok := nameHash(r.Name); ok {
+		return hash == routes.Hash(r.StaticRouteNetwork, r.StaticRouteNexthop)
+	}
+	return strings.HasPrefix(r.Name, threadRouteNamePrefix)
+}
+
+// routeRouter returns the border router label in a managed route's name, so
+// routes through the same router can be told apart from routes through others.
+func routeRouter(name string) string {
+	label := strings.TrimPrefix(name, threadRouteNamePrefix)
+	if _, ok := nameHash(label); ok {
+		label = label[:len(label)-11]
+	}
+	return label
+}
+
+// nameHash returns the route hash a name ends with, as in "... [1a2b3c4d]".
+func nameHash(name string) (string, bool) {
+	if len(name) < 11 || name[len(name)-11:len(name)-9] != " [" || name[len(name)-1] != ']' {
+		return "", false
+	}
+	hash := name[len(name)-9 : len(name)-1]
+	if strings.Trim(hash, "0123456789abcdef") != "" {
+		return "", false
+	}
+	return hash, true
+}
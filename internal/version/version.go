@@ -0,0 +1,167 @@
+// Package version reports the build's version metadata and checks GitHub for
+// newer releases.
+package version
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/internal/logger"
+)
+
+// Set at build time with
+//
+//	-ldflags "-X unifi-thread-route-updater/internal/version.Version=v1.2.3
+//	          -X unifi-thread-route-updater/internal/version.Commit=<sha>
+//	          -X unifi-thread-route-updater/internal/version.Date=<RFC 3339 time>"
+var (
+	Version = "dev"
+	Commit  = ""
+	Date    = ""
+)
+
+// releasesURL is the GitHub API endpoint for the latest release.
+const releasesURL = "https://api.github.com/repos/rafaelgaspar/unifi-thread-route-updater/releases/latest"
+
+// Info is the build metadata of the running binary.
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit,omitempty"`
+	Date      string `json:"date,omitempty"`
+	GoVersion string `json:"go_version"`
+	Platform  string `json:"platform"`
+}
+
+// Get returns the build metadata. Commit and date missing from the ldflags are
+// taken from the VCS stamp the Go toolchain embeds in module builds.
+func Get() Info {
+	info := Info{
+		Version:   Version,
+		Commit:    Commit,
+		Date:      Date,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, s := range bi.Settings {
+			switch {
+			case s.Key == "vcs.revision" && info.Commit == "":
+				info.Commit = s.Value
+			case s.Key == "vcs.time" && info.Date == "":
+				info.Date = s.Value
+			}
+		}
+	}
+	if len(info.Commit) > 12 {
+		info.Commit = info.Commit[:12]
+	}
+	return info
+}
+
+// String formats the metadata on one line, e.g. "v1.2.3 (commit 0123456789ab,
+// built 2026-01-01T00:00:00Z, go1.26.4 linux/amd64)".
+func (i Info) String() string {
+	details := []string{}
+	if i.Commit != "" {
+		details = append(details, "commit "+i.Commit)
+	}
+	if i.Date != "" {
+		details = append(details, "built "+i.Date)
+	}
+	details = append(details, i.GoVersion+" "+i.Platform)
+	return fmt.Sprintf("%s (%s)", i.Version, strings.Join(details, ", "))
+}
+
+// CheckForUpdates logs when GitHub has a newer release than the running version,
+// checking every interval until done is closed. Development builds are not checked.
+func CheckForUpdates(done <-chan struct{}, interval time.Duration) {
+	if _, ok := parseSemver(Version); !ok {
+		logger.Debug("Update check skipped for development build %s", Version)
+		return
+	}
+	client := &http.Client{Timeout: 10 * time.Second}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if latest, err := latestRelease(client, releasesURL); err != nil {
+			logger.Debug("Update check failed: %v", err)
+		} else if newer(latest, Version) {
+			logger.Info("A newer version is available: %s (running %s), see https://github.com/rafaelgaspar/unifi-thread-route-updater/releases", latest, Version)
+		}
+		select {
+		case <-ticker.C:
+		case <-done:
+			return
+		}
+	}
+}
+
+// latestRelease returns the tag of the latest release published at url.
+func latestRelease(client *http.Client, url string) (string, error) {
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("User-Agent", "thread-route-updater/"+Version)
+	resp, err := client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("GitHub API returned %s", resp.Status)
+	}
+	var release struct {
+		TagName string `json:"tag_name"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&release); err != nil {
+		return "", err
+	}
+	if release.TagName == "" {
+		return "", fmt.Errorf("no tag in latest release")
+	}
+	return release.TagName, nil
+}
+
+// newer reports whether version a is a later release than b.
+func newer(a, b string) bool {
+	va, okA := parseSemver(a)
+	vb, okB := parseSemver(b)
+	if !okA || !okB {
+		return false
+	}
+	for i := range va {
+		if va[i] != vb[i] {
+			return va[i] > vb[i]
+		}
+	}
+	return false
+}
+
+// parseSemver parses "v1.2.3" or "1.2.3", ignoring any pre-release or build suffix.
+func parseSemver(s string) ([3]int, bool) {
+	var v [3]int
+	s = strings.TrimPrefix(s, "v")
+	if i := strings.IndexAny(s, "-+"); i >= 0 {
+		s = s[:i]
+	}
+	parts := strings.Split(s, ".")
+	if len(parts) != 3 {
+		return v, false
+	}
+	for i, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil || n < 0 {
+			return v, false
+		}
+		v[i] = n
+	}
+	return v, true
+}
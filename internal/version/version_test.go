@@ -0,0 +1,52 @@
+package version
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewer(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected bool
+	}{
+		{"v1.2.4", "v1.2.3", true},
+		{"v1.10.0", "v1.9.9", true},
+		{"2.0.0", "v1.99.99", true},
+		{"v1.2.3", "v1.2.3", false},
+		{"v1.2.2", "v1.2.3", false},
+		{"v1.3.0-rc1", "v1.2.3", true},
+		{"v1.2.3", "dev", false},
+		{"latest", "v1.2.3", false},
+	}
+
+	for _, tt := range tests {
+		if got := newer(tt.a, tt.b); got != tt.expected {
+			t.Errorf("newer(%q, %q): expected %v, got %v", tt.a, tt.b, tt.expected, got)
+		}
+	}
+}
+
+func TestLatestRelease(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"tag_name":"v1.4.0","name":"v1.4.0"}`))
+	}))
+	defer srv.Close()
+
+	tag, err := latestRelease(srv.Client(), srv.URL)
+	if err != nil {
+		t.Fatalf("latestRelease failed: %v", err)
+	}
+	if tag != "v1.4.0" {
+		t.Errorf("Expected v1.4.0, got %s", tag)
+	}
+}
+
+func TestInfoString(t *testing.T) {
+	info := Info{Version: "v1.2.3", Commit: "0123456789ab", GoVersion: "go1.26.4", Platform: "linux/amd64"}
+	expected := "v1.2.3 (commit 0123456789ab, go1.26.4 linux/amd64)"
+	if got := info.String(); got != expected {
+		t.Errorf("Expected %q, got %q", expected, got)
+	}
+}
@@ -0,0 +1,255 @@
+package dnssd
+
+import (
+	"errors"
+	"fmt"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// Avahi D-Bus names, see avahi-daemon's org.freedesktop.Avahi.*.xml introspection files.
+const (
+	avahiBus            = "org.freedesktop.Avahi"
+	avahiServer         = "org.freedesktop.Avahi.Server"
+	avahiServiceBrowser = "org.freedesktop.Avahi.ServiceBrowser"
+	avahiRecordBrowser  = "org.freedesktop.Avahi.RecordBrowser"
+
+	avahiIfUnspec    = int32(-1)
+	avahiProtoUnspec = int32(-1)
+	avahiProtoInet6  = int32(1)
+
+	dnsClassIN  = uint16(1)
+	dnsTypeA    = uint16(1)
+	dnsTypeAAAA = uint16(28)
+)
+
+// avahiBrowser browses through a running avahi-daemon over the system D-Bus,
+// sharing Avahi's cache and reflector instead of running a second mDNS stack.
+// Avahi reports each instance once, so known instances are re-resolved every
+// refresh interval to keep them reported while they remain visible.
+type avahiBrowser struct {
+	domain   string
+	refresh  time.Duration
+	timeout  time.Duration // how long to collect a host's addresses
+	log      Logger
+	observer Observer
+}
+
+// avahiService identifies a service instance reported by an Avahi ServiceBrowser.
+type avahiService struct {
+	iface    int32
+	protocol int32
+	name     string
+	stype    string
+	domain   string
+}
+
+// Browse implements Browser, reconnecting to D-Bus after failures.
+func (b avahiBrowser) Browse(service string, done <-chan struct{}, handler func(Instance)) {
+	for {
+		err := b.run(service, done, handler)
+		select {
+		case <-done:
+			return
+		default:
+		}
+		b.log.Warnf("Avahi browse %s: %v, retrying in 5s", service, err)
+		select {
+		case <-done:
+			return
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
+
+// run browses service on one D-Bus connection until done is closed or Avahi fails.
+func (b avahiBrowser) run(service string, done <-chan struct{}, handler func(Instance)) error {
+	conn, err := dbus.ConnectSystemBus()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = conn.Close() }()
+
+	resolver, err := dbus.ConnectSystemBus()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = resolver.Close() }()
+
+	// Subscribe before creating the browser so no ItemNew is missed.
+	if err := conn.AddMatchSignal(dbus.WithMatchInterface(avahiServiceBrowser)); err != nil {
+		return err
+	}
+	signals := make(chan *dbus.Signal, 64)
+	conn.Signal(signals)
+
+	var path dbus.ObjectPath
+	err = conn.Object(avahiBus, "/").Call(avahiServer+".ServiceBrowserNew", 0,
+		avahiIfUnspec, avahiProtoUnspec, service, strings.TrimSuffix(b.domain, "."), uint32(0)).Store(&path)
+	if err != nil {
+		return fmt.Errorf("ServiceBrowserNew: %v", err)
+	}
+	defer conn.Object(avahiBus, path).Call(avahiServiceBrowser+".Free", 0)
+
+	known := make(map[avahiService]bool)
+	ticker := time.NewTicker(b.refresh)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case sig, ok := <-signals:
+			if !ok {
+				return errors.New("D-Bus connection closed")
+			}
+			if sig.Path != path {
+				continue
+			}
+			switch sig.Name {
+			case avahiServiceBrowser + ".ItemNew":
+				svc, ok := parseAvahiItem(sig.Body)
+				if !ok {
+					continue
+				}
+				known[svc] = true
+				b.report(resolver, svc, handler)
+			case avahiServiceBrowser + ".ItemRemove":
+				if svc, ok := parseAvahiItem(sig.Body); ok {
+					delete(known, svc)
+				}
+			case avahiServiceBrowser + ".Failure":
+				return fmt.Errorf("browser failure: %v", sig.Body)
+			}
+		case <-ticker.C:
+			round := newBrowseRound(b.observer, "avahi", service)
+			for svc := range known {
+				if b.report(resolver, svc, handler) {
+					round.entry()
+				}
+			}
+			round.finish()
+		case <-done:
+			return nil
+		}
+	}
+}
+
+// report resolves svc and passes it to handler, logging failures. It reports
+// whether the instance resolved.
+func (b avahiBrowser) report(conn *dbus.Conn, svc avahiService, handler func(Instance)) bool {
+	inst, err := avahiResolve(conn, svc, b.timeout)
+	if err != nil {
+		b.log.Debugf("Avahi: resolve %s failed: %v", svc.name, err)
+		return false
+	}
+	handler(inst)
+	return true
+}
+
+// parseAvahiItem decodes the body of an ItemNew or ItemRemove signal:
+// (interface, protocol, name, type, domain, flags).
+func parseAvahiItem(body []interface{}) (avahiService, bool) {
+	if len(body) < 5 {
+		return avahiService{}, false
+	}
+	iface, ok1 := body[0].(int32)
+	protocol, ok2 := body[1].(int32)
+	name, ok3 := body[2].(string)
+	stype, ok4 := body[3].(string)
+	domain, ok5 := body[4].(string)
+	if !ok1 || !ok2 || !ok3 || !ok4 || !ok5 {
+		return avahiService{}, false
+	}
+	return avahiService{iface: iface, protocol: protocol, name: name, stype: stype, domain: domain}, true
+}
+
+// avahiResolve resolves a service instance to its TXT records and all IP
+// addresses of its host. ResolveService returns only one address, so the host's
+// AAAA and A records are collected with short-lived RecordBrowsers.
+func avahiResolve(conn *dbus.Conn, svc avahiService, timeout time.Duration) (Instance, error) {
+	var (
+		rIface, rProto, aProto int32
+		rName, rType, rDomain  string
+		host, address          string
+		port                   uint16
+		txt                    [][]byte
+		flags                  uint32
+	)
+	err := conn.Object(avahiBus, "/").Call(avahiServer+".ResolveService", 0,
+		svc.iface, svc.protocol, svc.name, svc.stype, svc.domain, avahiProtoInet6, uint32(0)).
+		Store(&rIface, &rProto, &rName, &rType, &rDomain, &host, &aProto, &address, &port, &txt, &flags)
+	if err != nil {
+		return Instance{}, err
+	}
+
+	inst := Instance{Name: instanceName(svc.name, svc.stype, svc.domain), Host: host}
+	for _, t := range txt {
+		inst.Text = append(inst.Text, escapeTxt(t))
+	}
+	if ip, err := netip.ParseAddr(address); err == nil && ip.Is6() {
+		inst.Addrs = appendUnique(inst.Addrs, ip.WithZone(""))
+	}
+	v6, v4 := avahiHostAddrs(conn, svc.iface, host, timeout)
+	for _, ip := range v6 {
+		inst.Addrs = appendUnique(inst.Addrs, ip)
+	}
+	inst.IPv4Addrs = v4
+	return inst, nil
+}
+
+// avahiHostAddrs returns the AAAA and A records of host seen on iface. Both
+// are browsed at once, on one signal subscription, until Avahi reports it has
+// delivered everything it knows for each or a timeout passes, so resolving an
+// instance waits for the timeout at most once.
+func avahiHostAddrs(conn *dbus.Conn, iface int32, host string, timeout time.Duration) (v6, v4 []netip.Addr) {
+	if err := conn.AddMatchSignal(dbus.WithMatchInterface(avahiRecordBrowser)); err != nil {
+		return nil, nil
+	}
+	defer func() { _ = conn.RemoveMatchSignal(dbus.WithMatchInterface(avahiRecordBrowser)) }()
+	signals := make(chan *dbus.Signal, 16)
+	conn.Signal(signals)
+	defer conn.RemoveSignal(signals)
+
+	browsing := make(map[dbus.ObjectPath]bool, 2)
+	for _, rrtype := range []uint16{dnsTypeAAAA, dnsTypeA} {
+		var path dbus.ObjectPath
+		err := conn.Object(avahiBus, "/").Call(avahiServer+".RecordBrowserNew", 0,
+			iface, avahiProtoUnspec, host, dnsClassIN, rrtype, uint32(0)).Store(&path)
+		if err != nil {
+			continue
+		}
+		defer conn.Object(avahiBus, path).Call(avahiRecordBrowser+".Free", 0)
+		browsing[path] = true
+	}
+
+	expired := time.After(timeout)
+	for len(browsing) > 0 {
+		select {
+		case sig := <-signals:
+			if !browsing[sig.Path] {
+				continue
+			}
+			switch sig.Name {
+			case avahiRecordBrowser + ".ItemNew":
+				// (interface, protocol, name, clazz, type, rdata, flags)
+				if len(sig.Body) < 6 {
+					continue
+				}
+				if rdata, ok := sig.Body[5].([]byte); ok {
+					if ip, ok := netip.AddrFromSlice(rdata); ok && ip.Is4() {
+						v4 = appendUnique(v4, ip)
+					} else if ok {
+						v6 = appendUnique(v6, ip)
+					}
+				}
+			case avahiRecordBrowser + ".AllForNow", avahiRecordBrowser + ".Failure":
+				delete(browsing, sig.Path)
+			}
+		case <-expired:
+			return v6, v4
+		}
+	}
+	return v6, v4
+}
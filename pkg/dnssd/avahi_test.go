@@ -0,0 +1,34 @@
+package dnssd
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestEscapeTxtRoundTrip(t *testing.T) {
+	raw := append([]byte("omr="), 0x40, 0xfd, 0x5c, 0x22, 0x00, 'a')
+	escaped := escapeTxt(raw)
+	if escaped != `omr=@\253\092\034\000a` {
+		t.Errorf("Unexpected escaping %q", escaped)
+	}
+	if got := UnescapeTXT(escaped); !bytes.Equal(got, raw) {
+		t.Errorf("Expected round trip to %x, got %x", raw, got)
+	}
+}
+
+func TestParseAvahiItem(t *testing.T) {
+	svc, ok := parseAvahiItem([]interface{}{int32(2), int32(1), "Router 1", "_meshcop._udp", "local", uint32(0)})
+	if !ok {
+		t.Fatal("Expected item to parse")
+	}
+	if name := instanceName(svc.name, svc.stype, svc.domain); name != "Router 1._meshcop._udp.local." {
+		t.Errorf("Unexpected instance name %q", name)
+	}
+	if InstanceLabel(instanceName(svc.name, svc.stype, svc.domain)) != "Router 1" {
+		t.Errorf("Unexpected router name for %q", instanceName(svc.name, svc.stype, svc.domain))
+	}
+
+	if _, ok := parseAvahiItem([]interface{}{"bad"}); ok {
+		t.Error("Expected malformed item to be rejected")
+	}
+}
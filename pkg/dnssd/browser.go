@@ -0,0 +1,337 @@
+// Package dnssd browses DNS-SD services (RFC 6763) with a choice of backends:
+// an embedded mDNS stack, unicast queries against a DNS server, a running
+// avahi-daemon or the operating system's dns-sd tool. It is what
+// thread-route-updater discovers border routers and Matter devices with, for
+// other Go programs to reuse without the daemon:
+//
+//	browser, err := dnssd.NewBrowser(dnssd.Options{Backend: "avahi"})
+//	if err != nil {
+//		return err
+//	}
+//	browser.Browse("_matter._tcp", done, func(inst dnssd.Instance) {
+//		fmt.Println(inst.Name, inst.Addrs)
+//	})
+package dnssd
+
+import (
+	"fmt"
+	"net/netip"
+	"runtime/debug"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Instance is a resolved DNS-SD service instance.
+type Instance struct {
+	Name  string       // full instance name, e.g. "Router1._meshcop._udp.local."
+	Host  string       // target host name from the SRV record, e.g. "E2A1B3C4D5E6.local."
+	Addrs []netip.Addr // IPv6 addresses of the instance's host
+	Text  []string     // TXT record key=value strings
+	// IPv4Addrs are the IPv4 addresses of the instance's host, tracked for
+	// display only: routes are only ever created for IPv6.
+	IPv4Addrs []netip.Addr
+}
+
+// Browser is a DNS-SD discovery backend.
+type Browser interface {
+	// Browse reports instances of service (e.g. "_meshcop._udp") to handler until
+	// done is closed. Instances are reported repeatedly while they remain visible.
+	Browse(service string, done <-chan struct{}, handler func(Instance))
+}
+
+// Logger receives the messages of the browsers: failures they recover from
+// at Warnf, panics in handlers at Errorf and per-query details at Debugf.
+type Logger interface {
+	Debugf(format string, args ...interface{})
+	Warnf(format string, args ...interface{})
+	Errorf(format string, args ...interface{})
+}
+
+// Observer is told about every browse round — a zeroconf refresh cycle, a
+// unicast poll or an Avahi, dns-sd or passive re-report pass — for metrics.
+// last is when the round reported its last instance; it is zero when the
+// round found nothing.
+type Observer interface {
+	BrowseRound(backend, service string, entries int, start, last time.Time)
+}
+
+// Options selects how a Browser discovers. The zero value browses the local.
+// domain with the embedded mDNS implementation.
+type Options struct {
+	Backend       string        // "zeroconf" (default), "unicast", "avahi" or "dnssd"
+	Mode          string        // "hybrid" (default), "passive" (announcements only) or "active" (polling only)
+	Server        string        // unicast: DNS server or mDNS proxy as host:port
+	Domains       []string      // browse domains; default "local."
+	PollInterval  time.Duration // unicast and active mode: query interval; default 30s
+	RenewInterval time.Duration // how often to renew a browse or re-resolve known instances; 0 uses the backend default
+	Timeout       time.Duration // bound on each resolve or query; 0 uses the backend default
+	Logger        Logger        // nil discards the messages
+	Observer      Observer      // nil observes nothing
+}
+
+// NewBrowser returns the backend selected by opts, browsing every domain.
+func NewBrowser(opts Options) (Browser, error) {
+	backend := opts.Backend
+	if backend == "" {
+		backend = "zeroconf"
+	}
+	domains := normalizeDomains(opts.Domains)
+	browsers := make(multiDomainBrowser, 0, len(domains))
+	for _, domain := range domains {
+		browser, err := newDomainBrowser(opts, backend, domain)
+		if err != nil {
+			return nil, err
+		}
+		browsers = append(browsers, browser)
+	}
+	if len(browsers) == 1 {
+		return browsers[0], nil
+	}
+	return browsers, nil
+}
+
+// withDefaults returns opts with a Logger and an Observer that discard
+// everything where none is set.
+func (opts Options) withDefaults() Options {
+	if opts.Logger == nil {
+		opts.Logger = discard{}
+	}
+	if opts.Observer == nil {
+		opts.Observer = discard{}
+	}
+	return opts
+}
+
+// newDomainBrowser returns the browser of a single domain for the discovery
+// mode: "passive" listens for announcements without querying, "active" only
+// polls, and the default "hybrid" browses continuously with the backend.
+func newDomainBrowser(opts Options, backend, domain string) (Browser, error) {
+	opts = opts.withDefaults()
+	switch opts.Mode {
+	case "passive":
+		if backend != "zeroconf" {
+			return nil, fmt.Errorf("passive mode needs the zeroconf backend, not %q", backend)
+		}
+		return passiveBrowser{domain: domain, refresh: orDefault(opts.RenewInterval, time.Minute), log: opts.Logger, observer: opts.Observer}, nil
+	case "active":
+		browser, err := newBackendBrowser(opts, backend, domain)
+		if err != nil || backend == "unicast" { // unicast DNS-SD only polls already
+			return browser, err
+		}
+		return pollingBrowser{Browser: browser, interval: orDefault(opts.PollInterval, 30*time.Second),
+			window: orDefault(opts.Timeout, 3*time.Second), log: opts.Logger}, nil
+	}
+	return newBackendBrowser(opts, backend, domain)
+}
+
+// newBackendBrowser returns the backend browsing a single domain.
+func newBackendBrowser(opts Options, backend, domain string) (Browser, error) {
+	switch backend {
+	case "zeroconf":
+		return zeroconfBrowser{domain: domain, refresh: orDefault(opts.RenewInterval, 5*time.Minute), log: opts.Logger, observer: opts.Observer}, nil
+	case "unicast":
+		return newUnicastBrowser(opts, domain)
+	case "avahi":
+		return avahiBrowser{
+			domain:   domain,
+			refresh:  orDefault(opts.RenewInterval, time.Minute),
+			timeout:  orDefault(opts.Timeout, 3*time.Second),
+			log:      opts.Logger,
+			observer: opts.Observer,
+		}, nil
+	case "dnssd":
+		return dnssdBrowser{
+			command:  "dns-sd",
+			domain:   domain,
+			refresh:  orDefault(opts.RenewInterval, time.Minute),
+			timeout:  orDefault(opts.Timeout, 3*time.Second),
+			log:      opts.Logger,
+			observer: opts.Observer,
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown discovery backend %q", opts.Backend)
+}
+
+// pollingBrowser runs its backend only for a short window every interval, so
+// discovery happens in bursts of queries and nothing listens in between.
+type pollingBrowser struct {
+	Browser
+	interval time.Duration
+	window   time.Duration
+	log      Logger
+}
+
+// Browse implements Browser.
+func (b pollingBrowser) Browse(service string, done <-chan struct{}, handler func(Instance)) {
+	poll(done, b.interval, "active browse "+service, b.log, func() error {
+		window := make(chan struct{})
+		timer := time.AfterFunc(b.window, func() { close(window) })
+		stop := make(chan struct{})
+		go func() {
+			select {
+			case <-done:
+				if timer.Stop() {
+					close(window)
+				}
+			case <-stop:
+			}
+		}()
+		b.Browser.Browse(service, window, handler)
+		close(stop)
+		return nil
+	})
+}
+
+// poll calls fn immediately and then on every tick until done is closed,
+// logging failures and panics.
+func poll(done <-chan struct{}, interval time.Duration, label string, log Logger, fn func() error) {
+	run := func() (err error) {
+		if perr := recovered(label, log, func() { err = fn() }); perr != nil {
+			return perr
+		}
+		return err
+	}
+	if err := run(); err != nil {
+		log.Warnf("%s poll failed: %v", label, err)
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := run(); err != nil {
+				log.Warnf("%s poll failed: %v", label, err)
+			}
+		case <-done:
+			return
+		}
+	}
+}
+
+// recovered runs fn and recovers a panic in it, logging it with its stack
+// trace and returning it as an error.
+func recovered(label string, log Logger, fn func()) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Errorf("Panic in %s: %v\n%s", label, r, debug.Stack())
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	fn()
+	return nil
+}
+
+// normalizeDomains returns the domains as lower-case FQDNs without duplicates,
+// or just "local." when there are none.
+func normalizeDomains(domains []string) []string {
+	var out []string
+	seen := make(map[string]bool)
+	for _, d := range domains {
+		d = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(d), ".")) + "."
+		if d == "." || seen[d] {
+			continue
+		}
+		seen[d] = true
+		out = append(out, d)
+	}
+	if len(out) == 0 {
+		return []string{"local."}
+	}
+	return out
+}
+
+// multiDomainBrowser browses the same service in several domains, one backend
+// per domain, and merges the results. An instance announced in more than one
+// domain — over mDNS in local. and in a wide-area DNS-SD zone, say — is
+// reported under the name it was first seen with, so it stays one device.
+type multiDomainBrowser []Browser
+
+// Browse implements Browser, returning once every domain's browse has stopped.
+func (b multiDomainBrowser) Browse(service string, done <-chan struct{}, handler func(Instance)) {
+	var mu sync.Mutex
+	names := make(map[string]string) // instance identity → first full name seen
+	var wg sync.WaitGroup
+	for _, browser := range b {
+		wg.Add(1)
+		go func(browser Browser) {
+			defer wg.Done()
+			browser.Browse(service, done, func(inst Instance) {
+				id := instanceIdentity(inst.Name, service)
+				mu.Lock()
+				if name, ok := names[id]; ok {
+					inst.Name = name
+				} else {
+					names[id] = inst.Name
+				}
+				mu.Unlock()
+				handler(inst)
+			})
+		}(browser)
+	}
+	wg.Wait()
+}
+
+// instanceIdentity returns the domain-independent part of an instance name: its
+// instance label and service type, compared case-insensitively as in DNS.
+func instanceIdentity(name, service string) string {
+	lower := strings.ToLower(name)
+	if i := strings.Index(lower, "."+strings.ToLower(service)+"."); i != -1 {
+		return lower[:i+len(service)+1]
+	}
+	return lower
+}
+
+// browseRound measures one browse round for the Observer.
+type browseRound struct {
+	observer         Observer
+	backend, service string
+	mu               sync.Mutex
+	start, last      time.Time
+	entries          int
+}
+
+func newBrowseRound(observer Observer, backend, service string) *browseRound {
+	return &browseRound{observer: observer, backend: backend, service: service, start: time.Now()}
+}
+
+// entry records that the round reported an instance.
+func (r *browseRound) entry() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.entries++
+	r.last = time.Now()
+}
+
+// finish reports the round to the Observer.
+func (r *browseRound) finish() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.observer.BrowseRound(r.backend, r.service, r.entries, r.start, r.last)
+}
+
+// discard is the Logger and Observer used when none is given.
+type discard struct{}
+
+func (discard) Debugf(string, ...interface{})                         {}
+func (discard) Warnf(string, ...interface{})                          {}
+func (discard) Errorf(string, ...interface{})                         {}
+func (discard) BrowseRound(string, string, int, time.Time, time.Time) {}
+
+// orDefault returns d, or def when d is not positive.
+func orDefault(d, def time.Duration) time.Duration {
+	if d > 0 {
+		return d
+	}
+	return def
+}
+
+// appendUnique appends ip to the slice only if not already present.
+func appendUnique(ips []netip.Addr, ip netip.Addr) []netip.Addr {
+	for _, existing := range ips {
+		if existing == ip {
+			return ips
+		}
+	}
+	return append(ips, ip)
+}
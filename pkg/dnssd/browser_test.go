@@ -0,0 +1,80 @@
+package dnssd
+
+import (
+	"net/netip"
+	"reflect"
+	"sync"
+	"testing"
+)
+
+// staticBrowser reports a fixed list of instances once.
+type staticBrowser []Instance
+
+func (b staticBrowser) Browse(service string, done <-chan struct{}, handler func(Instance)) {
+	for _, inst := range b {
+		handler(inst)
+	}
+}
+
+func TestNormalizeDomains(t *testing.T) {
+	tests := []struct {
+		domains  []string
+		expected []string
+	}{
+		{nil, []string{"local."}},
+		{[]string{"local"}, []string{"local."}},
+		{[]string{"local.", "Home.Arpa", "home.arpa.", " "}, []string{"local.", "home.arpa."}},
+	}
+	for _, tt := range tests {
+		if got := normalizeDomains(tt.domains); !reflect.DeepEqual(got, tt.expected) {
+			t.Errorf("normalizeDomains(%q): expected %q, got %q", tt.domains, tt.expected, got)
+		}
+	}
+}
+
+func TestNewBrowserDomains(t *testing.T) {
+	b, err := NewBrowser(Options{Backend: "dnssd", Domains: []string{"local", "home.arpa"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	multi, ok := b.(multiDomainBrowser)
+	if !ok || len(multi) != 2 {
+		t.Fatalf("Expected a browser per domain, got %#v", b)
+	}
+	if multi[0].(dnssdBrowser).domain != "local." || multi[1].(dnssdBrowser).domain != "home.arpa." {
+		t.Errorf("Unexpected domains %#v", multi)
+	}
+}
+
+func TestMultiDomainBrowserMergesInstances(t *testing.T) {
+	addr := []netip.Addr{netip.MustParseAddr("fd00:1111:2222:3333::1")}
+	browser := multiDomainBrowser{
+		staticBrowser{{Name: "Router1._meshcop._udp.local.", Addrs: addr}},
+		staticBrowser{
+			{Name: "router1._meshcop._udp.home.arpa.", Addrs: addr},
+			{Name: "Router2._meshcop._udp.home.arpa.", Addrs: addr},
+		},
+	}
+
+	var mu sync.Mutex
+	names := make(map[string]int)
+	browser.Browse("_meshcop._udp", nil, func(inst Instance) {
+		mu.Lock()
+		defer mu.Unlock()
+		names[InstanceLabel(inst.Name)]++
+	})
+
+	// Either domain may report Router1 first; both sightings use that name.
+	if len(names) != 2 || names["Router2"] != 1 || (names["Router1"] != 2 && names["router1"] != 2) {
+		t.Errorf("Expected Router1 reported twice under one name and Router2 once, got %v", names)
+	}
+}
+
+func TestInstanceIdentity(t *testing.T) {
+	if a, b := instanceIdentity("Router1._meshcop._udp.local.", "_meshcop._udp"), instanceIdentity("router1._meshcop._udp.home.arpa.", "_meshcop._udp"); a != b {
+		t.Errorf("Expected the same identity across domains, got %q and %q", a, b)
+	}
+	if a, b := instanceIdentity("Router1._meshcop._udp.local.", "_meshcop._udp"), instanceIdentity("Router2._meshcop._udp.local.", "_meshcop._udp"); a == b {
+		t.Errorf("Expected different identities, got %q", a)
+	}
+}
@@ -0,0 +1,235 @@
+package dnssd
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"net/netip"
+	"os/exec"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// dnssdBrowser browses through the operating system's DNS-SD service
+// (mDNSResponder on macOS, Bonjour on Windows) by driving its dns-sd tool, which
+// sidesteps the multicast socket issues grandcat/zeroconf has on those systems.
+// Like Avahi, dns-sd reports each instance once, so known instances are
+// re-resolved every refresh interval.
+type dnssdBrowser struct {
+	command  string
+	domain   string
+	refresh  time.Duration
+	timeout  time.Duration // bounds each dns-sd -L and -G invocation; both run until killed
+	log      Logger
+	observer Observer
+}
+
+// Browse implements Browser, restarting dns-sd -B if it exits.
+func (b dnssdBrowser) Browse(service string, done <-chan struct{}, handler func(Instance)) {
+	for {
+		err := b.run(service, done, handler)
+		select {
+		case <-done:
+			return
+		default:
+		}
+		b.log.Warnf("dns-sd browse %s: %v, retrying in 5s", service, err)
+		select {
+		case <-done:
+			return
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
+
+// run follows one dns-sd -B process until done is closed or the process exits.
+func (b dnssdBrowser) run(service string, done <-chan struct{}, handler func(Instance)) error {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	cmd := exec.CommandContext(ctx, b.command, "-B", service, b.domain)
+	out, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	defer func() { _ = cmd.Wait() }()
+
+	lines := make(chan string)
+	go func() {
+		defer close(lines)
+		scanner := bufio.NewScanner(out)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+
+	known := make(map[string]bool)
+	ticker := time.NewTicker(b.refresh)
+	defer ticker.Stop()
+	for {
+		select {
+		case line, ok := <-lines:
+			if !ok {
+				return errors.New("dns-sd exited")
+			}
+			added, name, ok := parseDNSSDBrowseLine(line, service)
+			if !ok {
+				continue
+			}
+			if !added {
+				delete(known, name)
+				continue
+			}
+			if !known[name] {
+				known[name] = true
+				b.report(name, service, handler)
+			}
+		case <-ticker.C:
+			round := newBrowseRound(b.observer, "dnssd", service)
+			for name := range known {
+				if b.report(name, service, handler) {
+					round.entry()
+				}
+			}
+			round.finish()
+		case <-done:
+			return nil
+		}
+	}
+}
+
+// report resolves the instance and passes it to handler. It reports whether the
+// instance resolved.
+func (b dnssdBrowser) report(name, service string, handler func(Instance)) bool {
+	out := b.output("-L", name, service, b.domain)
+	host, txt, ok := parseDNSSDResolve(out)
+	if !ok {
+		b.log.Debugf("dns-sd: could not resolve %s", name)
+		return false
+	}
+	inst := Instance{Name: instanceName(name, service, b.domain), Host: host, Text: txt}
+	for _, ip := range parseDNSSDAddrs(b.output("-G", "v4v6", host)) {
+		if ip.Is4() {
+			inst.IPv4Addrs = append(inst.IPv4Addrs, ip)
+		} else {
+			inst.Addrs = append(inst.Addrs, ip)
+		}
+	}
+	handler(inst)
+	return true
+}
+
+// output runs dns-sd for the browser's timeout and returns what it printed.
+func (b dnssdBrowser) output(args ...string) string {
+	ctx, cancel := context.WithTimeout(context.Background(), b.timeout)
+	defer cancel()
+	out, _ := exec.CommandContext(ctx, b.command, args...).Output()
+	return string(out)
+}
+
+// parseDNSSDBrowseLine parses a dns-sd -B result line such as
+//
+//	12:00:00.001  Add        3  14 local.               _meshcop._udp.       My Router
+//
+// returning whether the instance was added or removed and its name.
+func parseDNSSDBrowseLine(line, service string) (added bool, name string, ok bool) {
+	fields := strings.Fields(line)
+	if len(fields) < 7 || (fields[1] != "Add" && fields[1] != "Rmv") {
+		return false, "", false
+	}
+	stype := strings.TrimSuffix(fields[5], ".")
+	if stype != service {
+		return false, "", false
+	}
+	idx := strings.Index(line, fields[5])
+	name = strings.TrimSpace(line[idx+len(fields[5]):])
+	if name == "" {
+		return false, "", false
+	}
+	return fields[1] == "Add", name, true
+}
+
+// parseDNSSDResolve parses dns-sd -L output:
+//
+//	12:00:00.001  My\032Router._meshcop._udp.local. can be reached at router.local.:49154 (interface 14)
+//	 rv=1 nn=home omr=@\xFD\x00...
+//
+// returning the target host and the TXT strings, escaped as UnescapeTXT expects.
+func parseDNSSDResolve(out string) (host string, txt []string, ok bool) {
+	lines := strings.Split(out, "\n")
+	for i, line := range lines {
+		_, rest, found := strings.Cut(line, " can be reached at ")
+		if !found {
+			continue
+		}
+		target, _, _ := strings.Cut(strings.TrimSpace(rest), " ")
+		if colon := strings.LastIndex(target, ":"); colon > 0 {
+			target = target[:colon]
+		}
+		if i+1 < len(lines) && strings.HasPrefix(lines[i+1], " ") {
+			txt = parseDNSSDTxt(strings.TrimSpace(lines[i+1]))
+		}
+		return target, txt, target != ""
+	}
+	return "", nil, false
+}
+
+// parseDNSSDTxt splits a dns-sd TXT line into strings. dns-sd separates strings
+// with spaces, escapes spaces and backslashes with a backslash and prints other
+// non-printable bytes as \xHH.
+func parseDNSSDTxt(line string) []string {
+	var out []string
+	var cur []byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case c == ' ':
+			if len(cur) > 0 {
+				out = append(out, escapeTxt(cur))
+				cur = nil
+			}
+		case c == '\\' && i+3 < len(line) && line[i+1] == 'x':
+			if v, err := strconv.ParseUint(line[i+2:i+4], 16, 8); err == nil {
+				cur = append(cur, byte(v))
+				i += 3
+				continue
+			}
+			cur = append(cur, c)
+		case c == '\\' && i+1 < len(line):
+			i++
+			cur = append(cur, line[i])
+		default:
+			cur = append(cur, c)
+		}
+	}
+	if len(cur) > 0 {
+		out = append(out, escapeTxt(cur))
+	}
+	return out
+}
+
+// parseDNSSDAddrs collects the addresses from dns-sd -G v4v6 output lines such as
+//
+//	12:00:00.001  Add  40000002  14  router.local.  FD00:1111:2222:3333:0000:0000:0000:0001%<0>  120
+func parseDNSSDAddrs(out string) []netip.Addr {
+	var addrs []netip.Addr
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || fields[1] != "Add" {
+			continue
+		}
+		for _, f := range fields[2:] {
+			if pct := strings.Index(f, "%"); pct >= 0 {
+				f = f[:pct]
+			}
+			if ip, err := netip.ParseAddr(f); err == nil {
+				addrs = appendUnique(addrs, ip)
+				break
+			}
+		}
+	}
+	return addrs
+}
@@ -0,0 +1,75 @@
+package dnssd
+
+import (
+	"bytes"
+	"net/netip"
+	"testing"
+)
+
+func TestParseDNSSDBrowseLine(t *testing.T) {
+	tests := []struct {
+		line      string
+		wantOK    bool
+		wantAdded bool
+		wantName  string
+	}{
+		{"12:00:00.001  Add        3  14 local.               _meshcop._udp.       My Router", true, true, "My Router"},
+		{"12:00:05.002  Rmv        0  14 local.               _meshcop._udp.       My Router", true, false, "My Router"},
+		{"12:00:00.001  Add        3  14 local.               _matter._tcp.        ABCD-1234", false, false, ""},
+		{"Timestamp     A/R    Flags  if Domain               Service Type         Instance Name", false, false, ""},
+		{"12:00:00.000  ...STARTING...", false, false, ""},
+	}
+
+	for _, tt := range tests {
+		added, name, ok := parseDNSSDBrowseLine(tt.line, "_meshcop._udp")
+		if ok != tt.wantOK || added != tt.wantAdded || name != tt.wantName {
+			t.Errorf("parseDNSSDBrowseLine(%q) = %v, %q, %v; want %v, %q, %v",
+				tt.line, added, name, ok, tt.wantAdded, tt.wantName, tt.wantOK)
+		}
+	}
+}
+
+func TestParseDNSSDResolve(t *testing.T) {
+	out := "Lookup My Router._meshcop._udp.local\n" +
+		"12:00:00.000  ...STARTING...\n" +
+		"12:00:00.001  My\\032Router._meshcop._udp.local. can be reached at my-router.local.:49154 (interface 14)\n" +
+		" rv=1 nn=home\\ net omr=@\\xFD\\x00\\x5C\n"
+
+	host, txt, ok := parseDNSSDResolve(out)
+	if !ok || host != "my-router.local." {
+		t.Fatalf("Expected host my-router.local., got %q (ok=%v)", host, ok)
+	}
+	if len(txt) != 3 || txt[0] != "rv=1" || txt[1] != "nn=home net" {
+		t.Fatalf("Unexpected TXT %q", txt)
+	}
+	if got := UnescapeTXT(txt[2]); !bytes.Equal(got, []byte{'o', 'm', 'r', '=', '@', 0xfd, 0x00, 0x5c}) {
+		t.Errorf("Unexpected omr bytes %x", got)
+	}
+}
+
+func TestParseDNSSDAddrs(t *testing.T) {
+	out := "Timestamp     A/R  Flags         IF  Hostname          Address                                      TTL\n" +
+		"12:00:00.001  Add  40000002      14  my-router.local.  FD00:1111:2222:3333:0000:0000:0000:0001%<0>  120\n" +
+		"12:00:00.001  Add  40000002      14  my-router.local.  2001:4860:4860:1234::ff%en0                  120\n" +
+		"12:00:00.002  Rmv  0             14  my-router.local.  2001:4860:4860:1234::fe%en0                  0\n"
+
+	addrs := parseDNSSDAddrs(out)
+	expected := []netip.Addr{
+		netip.MustParseAddr("fd00:1111:2222:3333::1"),
+		netip.MustParseAddr("2001:4860:4860:1234::ff"),
+	}
+	if len(addrs) != len(expected) || addrs[0] != expected[0] || addrs[1] != expected[1] {
+		t.Errorf("Expected %v, got %v", expected, addrs)
+	}
+}
+
+func TestParseDNSSDAddrsIPv4(t *testing.T) {
+	out := "Timestamp     A/R  Flags         IF  Hostname          Address                                      TTL\n" +
+		"12:00:00.001  Add  40000002      14  my-plug.local.    192.168.1.10                                 120\n" +
+		"12:00:00.001  Add  40000002      14  my-plug.local.    FE80:0000:0000:0000:0000:0000:0000:0001%en0  120\n"
+
+	addrs := parseDNSSDAddrs(out)
+	if len(addrs) != 2 || addrs[0] != netip.MustParseAddr("192.168.1.10") || !addrs[1].Is6() {
+		t.Errorf("Expected the IPv4 and the IPv6 address, got %v", addrs)
+	}
+}
@@ -0,0 +1,88 @@
+package dnssd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Instance names and TXT records come from whatever happens to be on the
+// network, so these fuzz targets check that malformed input never panics and
+// never produces values the rest of the program would choke on.
+// Run one with e.g. go test -fuzz=FuzzInstanceLabel ./pkg/dnssd
+
+func FuzzInstanceLabel(f *testing.F) {
+	for _, seed := range []string{
+		"ThreadRouter1._meshcop._udp.local.",
+		"Living\\ Room\\ \\(Main\\)._meshcop._udp.local.",
+		"Google\\032Nest\\032Hub._meshcop._udp.local.",
+		"Café ☃._meshcop._udp.local.",
+		"dotted\\.name._meshcop._udp.local.",
+		"Caf\\195\\169\\ \\226\\152\\131._meshcop._udp.local.",
+		"bad\\999\\000._meshcop._udp.local.",
+		"trailing\\",
+		"\\\\\\",
+		"",
+		".",
+		strings.Repeat("a", 300) + "._meshcop._udp.local.",
+		"\xff\xfe\x00._matter._tcp.local.",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, fqdn string) {
+		name := InstanceLabel(fqdn)
+		if !utf8.ValidString(name) {
+			t.Errorf("Expected valid UTF-8 from %q, got %q", fqdn, name)
+		}
+		if strings.IndexFunc(name, unicode.IsControl) != -1 {
+			t.Errorf("Expected no control characters from %q, got %q", fqdn, name)
+		}
+		if got := InstanceLabel(instanceName(name, "_meshcop._udp", "local")); got != name {
+			t.Errorf("Expected %q after round trip, got %q", name, got)
+		}
+	})
+}
+
+func FuzzTxtEscapeRoundTrip(f *testing.F) {
+	for _, seed := range [][]byte{
+		[]byte("rv=1"),
+		[]byte("nn=Home \"mesh\""),
+		{'o', 'm', 'r', '=', 64, 0xfd, 0x00, 0x11, 0x11, 0x22, 0x22, 0x44, 0x44},
+		[]byte("back\\slash"),
+		{0x00, 0x7f, 0x80, 0xff},
+		{},
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, raw []byte) {
+		escaped := escapeTxt(raw)
+		if !utf8.ValidString(escaped) {
+			t.Errorf("Expected printable escaping of %x, got %q", raw, escaped)
+		}
+		if got := UnescapeTXT(escaped); !bytes.Equal(got, raw) {
+			t.Errorf("Expected %x after round trip, got %x", raw, got)
+		}
+	})
+}
+
+func FuzzParseDNSSDTxt(f *testing.F) {
+	for _, seed := range []string{
+		"rv=1 nn=Home\\ mesh omr=\\x40\\xfd\\x00",
+		"\\x",
+		"\\xZZ",
+		"trailing\\",
+		"   ",
+		"a\\\\b \\ ",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, line string) {
+		for _, s := range parseDNSSDTxt(line) {
+			if len(UnescapeTXT(s)) == 0 {
+				t.Errorf("Expected no empty TXT strings from %q, got %q", line, s)
+			}
+		}
+	})
+}
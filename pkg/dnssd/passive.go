@@ -0,0 +1,312 @@
+package dnssd
+
+import (
+	"fmt"
+	"net"
+	"net/netip"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+	"golang.org/x/net/ipv6"
+)
+
+// mdnsGroup is the IPv6 link-local mDNS multicast group.
+var mdnsGroup = &net.UDPAddr{IP: net.ParseIP("ff02::fb"), Port: 5353}
+
+// cacheFlushWindow is how long records are kept after a cache-flush record for
+// the same name and type, per RFC 6762 section 10.2.
+const cacheFlushWindow = time.Second
+
+// passiveBrowser discovers mDNS services only from the responses other hosts
+// multicast — announcements and answers to their queries — and never sends a
+// query itself. Instances are reported as their records arrive and again from
+// the record cache every refresh interval, until the records expire.
+type passiveBrowser struct {
+	domain   string
+	refresh  time.Duration
+	log      Logger
+	observer Observer
+}
+
+// Browse implements Browser, reopening the mDNS socket after failures.
+func (b passiveBrowser) Browse(service string, done <-chan struct{}, handler func(Instance)) {
+	for {
+		err := b.run(service, done, handler)
+		select {
+		case <-done:
+			return
+		default:
+		}
+		b.log.Warnf("Passive mDNS browse %s: %v, retrying in 5s", service, err)
+		select {
+		case <-done:
+			return
+		case <-time.After(5 * time.Second):
+		}
+	}
+}
+
+// run listens for mDNS responses on one socket until done is closed or the socket fails.
+func (b passiveBrowser) run(service string, done <-chan struct{}, handler func(Instance)) error {
+	conn, err := b.listen()
+	if err != nil {
+		return err
+	}
+	defer func() { _ = conn.Close() }()
+
+	packets := make(chan []byte)
+	failed := make(chan error, 1)
+	go func() {
+		buf := make([]byte, 9000)
+		for {
+			n, _, err := conn.ReadFrom(buf)
+			if err != nil {
+				failed <- err
+				return
+			}
+			select {
+			case packets <- append([]byte(nil), buf[:n]...):
+			case <-done:
+				return
+			}
+		}
+	}()
+
+	cache := newRecordCache(service + "." + b.domain)
+	ticker := time.NewTicker(b.refresh)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return nil
+		case err := <-failed:
+			return err
+		case packet := <-packets:
+			var msg dns.Msg
+			if err := msg.Unpack(packet); err != nil || !msg.Response {
+				continue
+			}
+			for _, inst := range cache.observe(&msg, time.Now()) {
+				handler(inst)
+			}
+		case <-ticker.C:
+			round := newBrowseRound(b.observer, "passive", service)
+			for _, inst := range cache.instances(time.Now()) {
+				round.entry()
+				handler(inst)
+			}
+			round.finish()
+		}
+	}
+}
+
+// listen joins the IPv6 mDNS group on every multicast interface.
+func (b passiveBrowser) listen() (net.PacketConn, error) {
+	conn, err := net.ListenMulticastUDP("udp6", nil, mdnsGroup)
+	if err != nil {
+		return nil, err
+	}
+	p := ipv6.NewPacketConn(conn)
+	for _, name := range MulticastInterfaces() {
+		iface, err := net.InterfaceByName(name)
+		if err != nil {
+			continue
+		}
+		if err := p.JoinGroup(iface, mdnsGroup); err != nil {
+			b.log.Debugf("Passive mDNS: joining %s on %s: %v", mdnsGroup, name, err)
+		}
+	}
+	return conn, nil
+}
+
+// cachedRecord is a resource record with the time it expires.
+type cachedRecord struct {
+	rr      dns.RR
+	seen    time.Time
+	expires time.Time
+}
+
+// recordCache keeps the mDNS records seen on the network and assembles the
+// instances of one service from them.
+type recordCache struct {
+	service string                             // PTR owner name, e.g. "_matter._tcp.local."
+	records map[string]map[string]cachedRecord // owner name → record identity → record
+}
+
+func newRecordCache(service string) *recordCache {
+	return &recordCache{service: strings.ToLower(dns.Fqdn(service)), records: make(map[string]map[string]cachedRecord)}
+}
+
+// observe caches the records of an mDNS response and returns the complete
+// instances of the service it concerned.
+func (c *recordCache) observe(msg *dns.Msg, now time.Time) []Instance {
+	touched := make(map[string]bool)
+	hosts := make(map[string]bool)
+	for _, rr := range append(append([]dns.RR(nil), msg.Answer...), msg.Extra...) {
+		c.add(rr, now)
+		name := strings.ToLower(rr.Header().Name)
+		switch r := rr.(type) {
+		case *dns.PTR:
+			if name == c.service {
+				touched[strings.ToLower(r.Ptr)] = true
+			}
+		case *dns.SRV, *dns.TXT:
+			if strings.HasSuffix(name, "."+c.service) {
+				touched[name] = true
+			}
+		case *dns.AAAA:
+			hosts[name] = true
+		}
+	}
+	if len(hosts) > 0 {
+		for _, name := range c.instanceNames(now) {
+			for _, rr := range c.get(name, dns.TypeSRV, now) {
+				if hosts[strings.ToLower(rr.(*dns.SRV).Target)] {
+					touched[name] = true
+				}
+			}
+		}
+	}
+
+	listed := make(map[string]bool)
+	for _, name := range c.instanceNames(now) {
+		listed[name] = true
+	}
+	var out []Instance
+	for name := range touched {
+		if inst, ok := c.instance(name, now); ok && listed[name] {
+			out = append(out, inst)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// instances returns every complete, unexpired instance of the service.
+func (c *recordCache) instances(now time.Time) []Instance {
+	var out []Instance
+	for _, name := range c.instanceNames(now) {
+		if inst, ok := c.instance(name, now); ok {
+			out = append(out, inst)
+		}
+	}
+	return out
+}
+
+// instanceNames returns the instance names the service's PTR records list.
+func (c *recordCache) instanceNames(now time.Time) []string {
+	var names []string
+	for _, rr := range c.get(c.service, dns.TypePTR, now) {
+		names = append(names, strings.ToLower(rr.(*dns.PTR).Ptr))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// instance assembles an instance from its SRV, TXT and AAAA records. It is
+// incomplete until its SRV record has been seen.
+func (c *recordCache) instance(name string, now time.Time) (Instance, bool) {
+	srvs := c.get(name, dns.TypeSRV, now)
+	if len(srvs) == 0 {
+		return Instance{}, false
+	}
+	inst := Instance{Name: srvs[0].Header().Name, Host: srvs[0].(*dns.SRV).Target}
+	for _, rr := range c.get(name, dns.TypeTXT, now) {
+		inst.Text = append(inst.Text, rr.(*dns.TXT).Txt...)
+	}
+	for _, rr := range srvs {
+		for _, a := range c.get(rr.(*dns.SRV).Target, dns.TypeAAAA, now) {
+			if ip, ok := netip.AddrFromSlice(a.(*dns.AAAA).AAAA); ok && ip.Is6() && !ip.Is4In6() {
+				inst.Addrs = appendUnique(inst.Addrs, ip)
+			}
+		}
+		for _, a := range c.get(rr.(*dns.SRV).Target, dns.TypeA, now) {
+			if ip, ok := netip.AddrFromSlice(a.(*dns.A).A.To4()); ok {
+				inst.IPv4Addrs = appendUnique(inst.IPv4Addrs, ip)
+			}
+		}
+	}
+	return inst, true
+}
+
+// add caches rr. A zero TTL is a goodbye and removes the record; a record with
+// the cache-flush bit replaces the older records of its name and type.
+func (c *recordCache) add(rr dns.RR, now time.Time) {
+	hdr := rr.Header()
+	name := strings.ToLower(hdr.Name)
+	flush := hdr.Class&0x8000 != 0
+	id := recordID(rr)
+	if hdr.Ttl == 0 {
+		delete(c.records[name], id)
+		return
+	}
+	if c.records[name] == nil {
+		c.records[name] = make(map[string]cachedRecord)
+	}
+	if flush {
+		for other, r := range c.records[name] {
+			if other != id && r.rr.Header().Rrtype == hdr.Rrtype && now.Sub(r.seen) > cacheFlushWindow {
+				delete(c.records[name], other)
+			}
+		}
+	}
+	c.records[name][id] = cachedRecord{rr: rr, seen: now, expires: now.Add(time.Duration(hdr.Ttl) * time.Second)}
+}
+
+// get returns the unexpired records of type rrtype for name, dropping expired ones.
+func (c *recordCache) get(name string, rrtype uint16, now time.Time) []dns.RR {
+	name = strings.ToLower(dns.Fqdn(name))
+	var out []dns.RR
+	for id, r := range c.records[name] {
+		if !now.Before(r.expires) {
+			delete(c.records[name], id)
+			continue
+		}
+		if r.rr.Header().Rrtype == rrtype {
+			out = append(out, r.rr)
+		}
+	}
+	if len(c.records[name]) == 0 {
+		delete(c.records, name)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].String() < out[j].String() })
+	return out
+}
+
+// recordID identifies a record by its name, type and data, ignoring TTL and
+// the cache-flush bit.
+func recordID(rr dns.RR) string {
+	cp := dns.Copy(rr)
+	cp.Header().Ttl = 0
+	cp.Header().Class &^= 0x8000
+	cp.Header().Name = strings.ToLower(cp.Header().Name)
+	return fmt.Sprintf("%d %s", cp.Header().Rrtype, cp.String())
+}
+
+// MulticastInterfaces returns the names of the up, multicast-capable, non-loopback
+// interfaces that carry an IPv6 address.
+func MulticastInterfaces() []string {
+	all, err := net.Interfaces()
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, iface := range all {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagMulticast == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.To4() == nil {
+				names = append(names, iface.Name)
+				break
+			}
+		}
+	}
+	return names
+}
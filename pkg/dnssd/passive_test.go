@@ -0,0 +1,131 @@
+package dnssd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+func mdnsResponse(rrs ...string) *dns.Msg {
+	msg := &dns.Msg{}
+	msg.Response = true
+	for _, s := range rrs {
+		rr, err := dns.NewRR(s)
+		if err != nil {
+			panic(err)
+		}
+		msg.Answer = append(msg.Answer, rr)
+	}
+	return msg
+}
+
+func TestRecordCache(t *testing.T) {
+	cache := newRecordCache("_matter._tcp.local.")
+	now := time.Now()
+
+	// An announcement split over two packets: the instance is complete once
+	// both its PTR and SRV records have been seen.
+	if got := cache.observe(mdnsResponse(
+		"_matter._tcp.local. 4500 IN PTR Light._matter._tcp.local.",
+		`Light._matter._tcp.local. 4500 IN TXT "SII=5000"`,
+	), now); len(got) != 0 {
+		t.Errorf("Expected no instance without its SRV record, got %+v", got)
+	}
+	got := cache.observe(mdnsResponse(
+		"Light._matter._tcp.local. 120 IN SRV 0 0 5540 light.local.",
+		"light.local. 120 IN AAAA fd00:1111:2222:3333::10",
+		"light.local. 120 IN A 192.168.1.10",
+		"other.local. 120 IN AAAA fd00:9::1",
+	), now)
+	if len(got) != 1 || got[0].Host != "light.local." || len(got[0].Addrs) != 1 ||
+		got[0].Addrs[0].String() != "fd00:1111:2222:3333::10" || len(got[0].Text) != 1 {
+		t.Fatalf("Expected the complete Light instance, got %+v", got)
+	}
+	if len(got[0].IPv4Addrs) != 1 || got[0].IPv4Addrs[0].String() != "192.168.1.10" {
+		t.Errorf("Expected the IPv4 address tracked separately, got %v", got[0].IPv4Addrs)
+	}
+
+	// A new address with the cache-flush bit replaces the old one.
+	flush := mdnsResponse("light.local. 120 IN AAAA fd00:1111:2222:3333::11")
+	flush.Answer[0].Header().Class |= 0x8000
+	got = cache.observe(flush, now.Add(2*time.Second))
+	if len(got) != 1 || len(got[0].Addrs) != 1 || got[0].Addrs[0].String() != "fd00:1111:2222:3333::11" {
+		t.Errorf("Expected the address replaced, got %+v", got)
+	}
+
+	// Unrelated services are not reported.
+	if got := cache.observe(mdnsResponse(
+		"_hap._tcp.local. 4500 IN PTR Lock._hap._tcp.local.",
+		"Lock._hap._tcp.local. 120 IN SRV 0 0 80 lock.local.",
+	), now); len(got) != 0 {
+		t.Errorf("Expected no _matter._tcp instance, got %+v", got)
+	}
+
+	if insts := cache.instances(now.Add(time.Minute)); len(insts) != 1 {
+		t.Errorf("Expected the cached instance, got %+v", insts)
+	}
+	if insts := cache.instances(now.Add(3 * time.Minute)); len(insts) != 0 {
+		t.Errorf("Expected the instance gone once its SRV record expired, got %+v", insts)
+	}
+
+	// A goodbye (TTL 0) withdraws the instance at once.
+	cache.observe(mdnsResponse("Light._matter._tcp.local. 120 IN SRV 0 0 5540 light.local."), now)
+	cache.observe(mdnsResponse("_matter._tcp.local. 0 IN PTR Light._matter._tcp.local."), now)
+	if insts := cache.instances(now); len(insts) != 0 {
+		t.Errorf("Expected the instance withdrawn, got %+v", insts)
+	}
+}
+
+// windowBrowser reports one instance per browse and blocks until done is closed.
+type windowBrowser struct{ browses chan struct{} }
+
+func (b windowBrowser) Browse(service string, done <-chan struct{}, handler func(Instance)) {
+	b.browses <- struct{}{}
+	handler(Instance{Name: "Light._matter._tcp.local.", Host: "light.local."})
+	<-done
+}
+
+func TestPollingBrowser(t *testing.T) {
+	inner := windowBrowser{browses: make(chan struct{}, 10)}
+	browser := pollingBrowser{Browser: inner, interval: 20 * time.Millisecond, window: 5 * time.Millisecond}
+
+	done := make(chan struct{})
+	finished := make(chan struct{})
+	reported := 0
+	go func() {
+		browser.Browse("_matter._tcp", done, func(Instance) { reported++ })
+		close(finished)
+	}()
+	time.Sleep(70 * time.Millisecond)
+	close(done)
+	select {
+	case <-finished:
+	case <-time.After(time.Second):
+		t.Fatal("Expected Browse to return once done is closed")
+	}
+	if n := len(inner.browses); n < 2 || reported != n {
+		t.Errorf("Expected several browse windows each reporting the instance, got %d windows, %d reports", n, reported)
+	}
+}
+
+func TestNewBrowserModes(t *testing.T) {
+	if _, err := newDomainBrowser(Options{Mode: "passive", PollInterval: time.Minute}, "avahi", "local."); err == nil {
+		t.Error("Expected passive mode to require the zeroconf backend")
+	}
+	if b, _ := newDomainBrowser(Options{Mode: "passive", PollInterval: time.Minute}, "zeroconf", "local."); b == nil {
+		t.Error("Expected a passive browser")
+	} else if _, ok := b.(passiveBrowser); !ok {
+		t.Errorf("Expected a passive browser, got %T", b)
+	}
+	if b, _ := newDomainBrowser(Options{Mode: "active", PollInterval: time.Minute}, "zeroconf", "local."); b == nil {
+		t.Error("Expected a polling browser")
+	} else if _, ok := b.(pollingBrowser); !ok {
+		t.Errorf("Expected a polling browser, got %T", b)
+	}
+	if b, _ := newDomainBrowser(Options{Mode: "hybrid", PollInterval: time.Minute}, "zeroconf", "local."); b == nil {
+		t.Error("Expected a zeroconf browser")
+	} else if _, ok := b.(zeroconfBrowser); !ok {
+		t.Errorf("Expected a zeroconf browser, got %T", b)
+	}
+}
@@ -0,0 +1,134 @@
+package dnssd
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// TXTRecord returns the key=value strings of a TXT record as a map with the
+// values unescaped, so binary values such as xa= hold their raw bytes. Keys
+// are lower-cased, as RFC 6763 makes them case-insensitive, and the first
+// occurrence of a key wins. Keys without "=" are boolean attributes and map
+// to "".
+func TXTRecord(txt []string) map[string]string {
+	record := make(map[string]string, len(txt))
+	for _, field := range txt {
+		key, value, _ := strings.Cut(field, "=")
+		key = strings.ToLower(key)
+		if key == "" {
+			continue
+		}
+		if _, ok := record[key]; !ok {
+			record[key] = string(UnescapeTXT(value))
+		}
+	}
+	return record
+}
+
+// UnescapeTXT decodes DNS master file escapes in a TXT string: \DDD decimal
+// bytes and \X for a literal X. Every backend reports TXT strings with
+// non-printable bytes escaped as \DDD and quotes and backslashes as \" and \\.
+func UnescapeTXT(s string) []byte {
+	buf := make([]byte, 0, len(s))
+	for i := 0; i < len(s); {
+		if b, ok := decimalEscape(s[i:]); ok {
+			buf = append(buf, b)
+			i += 4
+			continue
+		}
+		if s[i] == '\\' && i+1 < len(s) && !isDigit(s[i+1]) {
+			buf = append(buf, s[i+1])
+			i += 2
+			continue
+		}
+		buf = append(buf, s[i])
+		i++
+	}
+	return buf
+}
+
+// decimalEscape decodes a \DDD escape at the start of s.
+func decimalEscape(s string) (byte, bool) {
+	if len(s) < 4 || s[0] != '\\' || !isDigit(s[1]) || !isDigit(s[2]) || !isDigit(s[3]) {
+		return 0, false
+	}
+	val := int(s[1]-'0')*100 + int(s[2]-'0')*10 + int(s[3]-'0')
+	if val > 255 {
+		return 0, false
+	}
+	return byte(val), true
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+// InstanceLabel returns the instance label of a DNS-SD service instance
+// name in presentation format, unescaped per RFC 6763 section 4.3: \DDD is a
+// decimal byte, \X is a literal X (including an escaped dot, which does not end
+// the label), and the label ends at the first unescaped dot. Control characters
+// are dropped and invalid UTF-8 is replaced, so the result is safe to display
+// and to use in route names.
+func InstanceLabel(fqdn string) string {
+	buf := make([]byte, 0, len(fqdn))
+	for i := 0; i < len(fqdn) && fqdn[i] != '.'; i++ {
+		c := fqdn[i]
+		if c == '\\' {
+			if b, ok := decimalEscape(fqdn[i:]); ok {
+				c = b
+				i += 3
+			} else if i+1 < len(fqdn) {
+				i++
+				c = fqdn[i]
+			} else {
+				break
+			}
+		}
+		buf = append(buf, c)
+	}
+	return strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, strings.ToValidUTF8(string(buf), "\uFFFD"))
+}
+
+// escapeTxt renders a raw TXT string with non-printable bytes, backslashes and
+// quotes escaped as \DDD, the form UnescapeTXT decodes, so TXT values look
+// the same whichever backend produced them.
+func escapeTxt(b []byte) string {
+	var sb strings.Builder
+	for _, c := range b {
+		if c < ' ' || c > '~' || c == '\\' || c == '"' {
+			fmt.Fprintf(&sb, "\\%03d", c)
+			continue
+		}
+		sb.WriteByte(c)
+	}
+	return sb.String()
+}
+
+// instanceName builds a full DNS-SD instance name from an unescaped instance
+// label, escaping dots and backslashes in the label as in DNS presentation format.
+func instanceName(label, service, domain string) string {
+	label = strings.NewReplacer(`\`, `\\`, ".", `\.`).Replace(label)
+	return fmt.Sprintf("%s.%s.%s.", label, service, strings.TrimSuffix(domain, "."))
+}
+
+// TXTHex returns the binary value of TXT key as hex, or "" when the key is
+// missing or its value is not size bytes long. Thread announces its extended
+// address (xa) and extended PAN ID (xp) this way in _meshcop._udp and _trel._udp.
+func TXTHex(txt []string, key string, size int) string {
+	for _, field := range txt {
+		if !strings.HasPrefix(field, key+"=") {
+			continue
+		}
+		if val := UnescapeTXT(field[len(key)+1:]); len(val) == size {
+			return hex.EncodeToString(val)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,174 @@
+package dnssd
+
+import (
+	"testing"
+)
+
+func TestInstanceLabel(t *testing.T) {
+	tests := []struct {
+		name     string
+		fqdn     string
+		expected string
+	}{
+		{
+			name:     "Standard FQDN",
+			fqdn:     "ThreadRouter1._meshcop._udp.local.",
+			expected: "ThreadRouter1",
+		},
+		{
+			name:     "Simple name",
+			fqdn:     "Router1",
+			expected: "Router1",
+		},
+		{
+			name:     "Name with underscores",
+			fqdn:     "Thread_Border_Router._meshcop._udp.local.",
+			expected: "Thread_Border_Router",
+		},
+		{
+			name:     "Name with escaped spaces and parentheses",
+			fqdn:     "Living\\ Room\\ Apple\\ TV\\ \\(4\\)._meshcop._udp.local.",
+			expected: "Living Room Apple TV (4)",
+		},
+		{
+			name:     "Empty string",
+			fqdn:     "",
+			expected: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := InstanceLabel(tt.fqdn)
+			if result != tt.expected {
+				t.Errorf("InstanceLabel(%s) = %s, want %s", tt.fqdn, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestInstanceLabelEdgeCases(t *testing.T) {
+	tests := []struct {
+		name     string
+		fqdn     string
+		expected string
+	}{
+		{
+			name:     "FQDN with multiple dots",
+			fqdn:     "router.subdomain.domain.local.",
+			expected: "router",
+		},
+		{
+			name:     "FQDN with special characters",
+			fqdn:     "router-123._meshcop._udp.local.",
+			expected: "router-123",
+		},
+		{
+			name:     "FQDN with numbers",
+			fqdn:     "router123._meshcop._udp.local.",
+			expected: "router123",
+		},
+		{
+			name:     "Single dot",
+			fqdn:     "router.",
+			expected: "router",
+		},
+		{
+			name:     "No dots",
+			fqdn:     "router",
+			expected: "router",
+		},
+		{
+			name:     "Only dots",
+			fqdn:     "...",
+			expected: "",
+		},
+		{
+			name:     "Escaped dot",
+			fqdn:     "Hallway\\.Light._matter._tcp.local.",
+			expected: "Hallway.Light",
+		},
+		{
+			name:     "Decimal escapes",
+			fqdn:     "Caf\\195\\169\\032Light._matter._tcp.local.",
+			expected: "Café Light",
+		},
+		{
+			name:     "Escaped backslash",
+			fqdn:     "back\\\\slash._meshcop._udp.local.",
+			expected: "back\\slash",
+		},
+		{
+			name:     "Control characters and invalid UTF-8",
+			fqdn:     "bad\\000\\255name._meshcop._udp.local.",
+			expected: "bad\uFFFDname",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := InstanceLabel(tt.fqdn)
+			if result != tt.expected {
+				t.Errorf("InstanceLabel(%s) = %s, want %s", tt.fqdn, result, tt.expected)
+			}
+		})
+	}
+}
+
+func TestUnescapeTXT(t *testing.T) {
+	tests := []struct {
+		input    string
+		expected []byte
+	}{
+		{`omr=@\253\000`, []byte{'o', 'm', 'r', '=', '@', 0xfd, 0x00}},
+		{`a\"b\\c`, []byte(`a"b\c`)},
+		{`\34\0`, []byte(`\34\0`)},
+		{`trailing\`, []byte(`trailing\`)},
+	}
+
+	for _, tt := range tests {
+		if got := UnescapeTXT(tt.input); string(got) != string(tt.expected) {
+			t.Errorf("UnescapeTXT(%q): expected %q, got %q", tt.input, tt.expected, got)
+		}
+	}
+}
+
+func TestTXTRecord(t *testing.T) {
+	got := TXTRecord([]string{"rv=1", "XA=\\222\\173\\000\\001\\002\\003\\004\\005", "rv=2", "dn=Living\\ Room", "tv=1.3.0", "bool", "=orphan"})
+	want := map[string]string{
+		"rv":   "1",
+		"xa":   "\xde\xad\x00\x01\x02\x03\x04\x05",
+		"dn":   "Living Room",
+		"tv":   "1.3.0",
+		"bool": "",
+	}
+	if len(got) != len(want) {
+		t.Errorf("Expected %d keys, got %q", len(want), got)
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("Expected %s=%q, got %q", k, v, got[k])
+		}
+	}
+}
+
+func TestTXTHex(t *testing.T) {
+	txt := []string{
+		"rv=1",
+		"xa=\\018\\052V\\120\\154\\188\\222\\240", // 12 34 56 78 9a bc de f0
+		"xp=\\001\\002",
+	}
+	tests := []struct {
+		key      string
+		expected string
+	}{
+		{"xa", "123456789abcdef0"},
+		{"xp", ""}, // wrong length
+		{"nn", ""}, // missing
+	}
+	for _, tt := range tests {
+		if got := TXTHex(txt, tt.key, 8); got != tt.expected {
+			t.Errorf("TXTHex(%s): expected %q, got %q", tt.key, tt.expected, got)
+		}
+	}
+}
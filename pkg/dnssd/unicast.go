@@ -0,0 +1,178 @@
+package dnssd
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// unicastBrowser discovers services with plain unicast DNS-SD queries (RFC 6763)
+// against a DNS server: an Avahi/mDNS reflector or proxy answering for "local.",
+// or a wide-area DNS-SD domain. It works where multicast cannot reach the daemon,
+// such as Docker bridge networks and VLAN-isolated hosts.
+type unicastBrowser struct {
+	server   string
+	domain   string
+	interval time.Duration
+	client   *dns.Client
+	log      Logger
+	observer Observer
+}
+
+func newUnicastBrowser(opts Options, domain string) (*unicastBrowser, error) {
+	opts = opts.withDefaults()
+	if opts.Server == "" {
+		return nil, errors.New("unicast discovery requires a DNS server")
+	}
+	server := opts.Server
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(strings.Trim(server, "[]"), "53")
+	}
+	return &unicastBrowser{
+		server:   server,
+		domain:   dns.Fqdn(domain),
+		interval: orDefault(opts.PollInterval, 30*time.Second),
+		client:   &dns.Client{Timeout: orDefault(opts.Timeout, 5*time.Second)},
+		log:      opts.Logger,
+		observer: opts.Observer,
+	}, nil
+}
+
+// Browse implements Browser by polling the server every interval.
+func (b *unicastBrowser) Browse(service string, done <-chan struct{}, handler func(Instance)) {
+	poll(done, b.interval, "unicast DNS-SD "+service, b.log, func() error {
+		round := newBrowseRound(b.observer, "unicast", service)
+		instances, err := b.lookup(service)
+		if err != nil {
+			return err
+		}
+		for _, inst := range instances {
+			round.entry()
+			handler(inst)
+		}
+		round.finish()
+		return nil
+	})
+}
+
+// lookup enumerates the instances of service and resolves each one. Records a
+// server volunteers in the additional section are used before asking for them.
+// Instances that fail to resolve are logged and skipped, so one broken record
+// set doesn't hide the others.
+func (b *unicastBrowser) lookup(service string) ([]Instance, error) {
+	cache := make(map[string][]dns.RR)
+	ptrs, err := b.query(cache, service+"."+b.domain, dns.TypePTR)
+	if err != nil {
+		return nil, err
+	}
+
+	var instances []Instance
+	for _, rr := range ptrs {
+		ptr, ok := rr.(*dns.PTR)
+		if !ok {
+			continue
+		}
+		inst, err := b.resolve(cache, ptr.Ptr)
+		if err != nil {
+			b.log.Warnf("Unicast DNS-SD: skipping %s, resolving it failed: %v", ptr.Ptr, err)
+			continue
+		}
+		instances = append(instances, inst)
+	}
+	return instances, nil
+}
+
+// resolve looks up the SRV, TXT and AAAA records of a service instance.
+func (b *unicastBrowser) resolve(cache map[string][]dns.RR, name string) (Instance, error) {
+	inst := Instance{Name: name}
+
+	txts, err := b.query(cache, name, dns.TypeTXT)
+	if err != nil {
+		return inst, err
+	}
+	for _, rr := range txts {
+		if txt, ok := rr.(*dns.TXT); ok {
+			inst.Text = append(inst.Text, txt.Txt...)
+		}
+	}
+
+	srvs, err := b.query(cache, name, dns.TypeSRV)
+	if err != nil {
+		return inst, err
+	}
+	for _, rr := range srvs {
+		srv, ok := rr.(*dns.SRV)
+		if !ok {
+			continue
+		}
+		if inst.Host == "" {
+			inst.Host = srv.Target
+		}
+		aaaas, err := b.query(cache, srv.Target, dns.TypeAAAA)
+		if err != nil {
+			return inst, err
+		}
+		for _, rr := range aaaas {
+			aaaa, ok := rr.(*dns.AAAA)
+			if !ok {
+				continue
+			}
+			if ip, ok := netip.AddrFromSlice(aaaa.AAAA); ok && ip.Is6() && !ip.Is4In6() {
+				inst.Addrs = appendUnique(inst.Addrs, ip)
+			}
+		}
+		// A records are only taken from the answers already received, IPv4
+		// addresses are informational and not worth another query.
+		for _, rr := range cached(cache, srv.Target, dns.TypeA) {
+			if ip, ok := netip.AddrFromSlice(rr.(*dns.A).A.To4()); ok {
+				inst.IPv4Addrs = appendUnique(inst.IPv4Addrs, ip)
+			}
+		}
+	}
+	return inst, nil
+}
+
+// query returns the records of type qtype for name, from cache when present.
+// Every record of a response, including the additional section, is cached.
+func (b *unicastBrowser) query(cache map[string][]dns.RR, name string, qtype uint16) ([]dns.RR, error) {
+	if rrs := cached(cache, name, qtype); len(rrs) > 0 {
+		return rrs, nil
+	}
+
+	msg := new(dns.Msg)
+	msg.SetQuestion(dns.Fqdn(name), qtype)
+	resp, _, err := b.client.Exchange(msg, b.server)
+	if err == nil && resp.Truncated {
+		tcp := *b.client
+		tcp.Net = "tcp"
+		resp, _, err = tcp.Exchange(msg, b.server)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if resp.Rcode != dns.RcodeSuccess && resp.Rcode != dns.RcodeNameError {
+		return nil, fmt.Errorf("%s %s: %s", dns.TypeToString[qtype], name, dns.RcodeToString[resp.Rcode])
+	}
+
+	for _, rr := range append(resp.Answer, resp.Extra...) {
+		key := strings.ToLower(rr.Header().Name)
+		cache[key] = append(cache[key], rr)
+	}
+	return cached(cache, name, qtype), nil
+}
+
+// cached returns the cached records of type qtype for name.
+func cached(cache map[string][]dns.RR, name string, qtype uint16) []dns.RR {
+	var out []dns.RR
+	for _, rr := range cache[strings.ToLower(dns.Fqdn(name))] {
+		if rr.Header().Rrtype == qtype {
+			out = append(out, rr)
+		}
+	}
+	return out
+}
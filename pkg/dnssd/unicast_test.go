@@ -0,0 +1,132 @@
+package dnssd
+
+import (
+	"net"
+	"net/netip"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/miekg/dns"
+)
+
+// startDNSServer serves zone records over UDP on a loopback port and returns
+// its address. Names starting with "broken" answer SERVFAIL.
+func startDNSServer(t *testing.T, records []string, extra bool) string {
+	t.Helper()
+	var rrs []dns.RR
+	for _, r := range records {
+		rr, err := dns.NewRR(r)
+		if err != nil {
+			t.Fatalf("Bad record %q: %v", r, err)
+		}
+		rrs = append(rrs, rr)
+	}
+
+	pc, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := &dns.Server{PacketConn: pc, Handler: dns.HandlerFunc(func(w dns.ResponseWriter, req *dns.Msg) {
+		resp := new(dns.Msg)
+		resp.SetReply(req)
+		q := req.Question[0]
+		if strings.HasPrefix(q.Name, "broken") {
+			resp.SetRcode(req, dns.RcodeServerFailure)
+			_ = w.WriteMsg(resp)
+			return
+		}
+		for _, rr := range rrs {
+			switch {
+			case rr.Header().Name == q.Name && rr.Header().Rrtype == q.Qtype:
+				resp.Answer = append(resp.Answer, rr)
+			case extra && q.Qtype == dns.TypePTR:
+				resp.Extra = append(resp.Extra, rr)
+			}
+		}
+		_ = w.WriteMsg(resp)
+	})}
+	go func() { _ = srv.ActivateAndServe() }()
+	t.Cleanup(func() { _ = srv.Shutdown() })
+	return pc.LocalAddr().String()
+}
+
+func TestUnicastBrowserLookup(t *testing.T) {
+	records := []string{
+		`_meshcop._udp.local. 120 IN PTR Router1._meshcop._udp.local.`,
+		`Router1._meshcop._udp.local. 120 IN SRV 0 0 49154 router1.local.`,
+		`Router1._meshcop._udp.local. 120 IN TXT "nn=home" "rv=1"`,
+		`router1.local. 120 IN AAAA 2001:4860:4860:1234::ff`,
+		`router1.local. 120 IN AAAA fd00:1111:2222:3333::1`,
+	}
+
+	for _, extra := range []bool{false, true} {
+		addr := startDNSServer(t, records, extra)
+		b, err := newUnicastBrowser(Options{Server: addr, PollInterval: time.Minute}, "local")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		instances, err := b.lookup("_meshcop._udp")
+		if err != nil {
+			t.Fatalf("lookup failed (additional records %v): %v", extra, err)
+		}
+		if len(instances) != 1 {
+			t.Fatalf("Expected 1 instance, got %d", len(instances))
+		}
+		inst := instances[0]
+		if InstanceLabel(inst.Name) != "Router1" {
+			t.Errorf("Expected Router1, got %s", inst.Name)
+		}
+		if len(inst.Addrs) != 2 || inst.Addrs[0] != netip.MustParseAddr("2001:4860:4860:1234::ff") {
+			t.Errorf("Unexpected addresses %v", inst.Addrs)
+		}
+		if len(inst.Text) != 2 || inst.Text[0] != "nn=home" {
+			t.Errorf("Unexpected TXT %v", inst.Text)
+		}
+	}
+}
+
+// TestUnicastBrowserLookupSkipsBroken verifies an instance that fails to
+// resolve doesn't drop the others from the poll.
+func TestUnicastBrowserLookupSkipsBroken(t *testing.T) {
+	addr := startDNSServer(t, []string{
+		`_meshcop._udp.local. 120 IN PTR broken._meshcop._udp.local.`,
+		`_meshcop._udp.local. 120 IN PTR Router1._meshcop._udp.local.`,
+		`Router1._meshcop._udp.local. 120 IN SRV 0 0 49154 router1.local.`,
+		`router1.local. 120 IN AAAA 2001:4860:4860:1234::ff`,
+	}, false)
+	b, err := newUnicastBrowser(Options{Server: addr, PollInterval: time.Minute}, "local")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	instances, err := b.lookup("_meshcop._udp")
+	if err != nil {
+		t.Fatalf("Expected the broken instance skipped, got %v", err)
+	}
+	if len(instances) != 1 || InstanceLabel(instances[0].Name) != "Router1" {
+		t.Errorf("Expected only Router1, got %+v", instances)
+	}
+}
+
+func TestNewBrowser(t *testing.T) {
+	if _, err := NewBrowser(Options{Backend: "zeroconf"}); err != nil {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	if _, err := NewBrowser(Options{Backend: "unicast"}); err == nil {
+		t.Error("Expected error for unicast backend without a server")
+	}
+	if _, err := NewBrowser(Options{Backend: "carrier-pigeon"}); err == nil {
+		t.Error("Expected error for unknown backend")
+	}
+
+	b, _ := NewBrowser(Options{Backend: "dnssd"})
+	if d := b.(dnssdBrowser); d.timeout != 3*time.Second || d.refresh != time.Minute {
+		t.Errorf("Expected default timeout and renew interval, got %v %v", d.timeout, d.refresh)
+	}
+	b, _ = NewBrowser(Options{Backend: "avahi", Timeout: 10 * time.Second, RenewInterval: 15 * time.Minute})
+	if a := b.(avahiBrowser); a.timeout != 10*time.Second || a.refresh != 15*time.Minute {
+		t.Errorf("Expected configured timeout and renew interval, got %v %v", a.timeout, a.refresh)
+	}
+}
@@ -0,0 +1,145 @@
+package dnssd
+
+import (
+	"context"
+	"net/netip"
+	"time"
+
+	"github.com/grandcat/zeroconf"
+)
+
+// zeroconfBrowser browses with the embedded grandcat/zeroconf mDNS stack. It is
+// the portable default backend.
+type zeroconfBrowser struct {
+	domain   string
+	refresh  time.Duration
+	log      Logger
+	observer Observer
+}
+
+// Browse implements Browser.
+func (b zeroconfBrowser) Browse(service string, done <-chan struct{}, handler func(Instance)) {
+	b.browseService(service, done, func(entry *zeroconf.ServiceEntry) {
+		handler(Instance{
+			Name:      entry.ServiceInstanceName(),
+			Host:      entry.HostName,
+			Addrs:     extractIPv6s(entry),
+			Text:      entry.Text,
+			IPv4Addrs: extractIPv4s(entry),
+		})
+	})
+}
+
+// browseService runs a zeroconf Browse loop for the given service type in the
+// browser's domain until done is closed. On error it waits 5 seconds before
+// restarting. The handler is called for each entry. If the refresh interval is
+// positive, the browse is renewed on that interval to send fresh mDNS queries,
+// which forces devices to re-announce and prevents stale state. Renewals restart
+// the browse immediately, so listening never lapses. The key rule: never close
+// the entries channel — only cancel the context; zeroconf owns it.
+func (b zeroconfBrowser) browseService(service string, done <-chan struct{}, handler func(*zeroconf.ServiceEntry)) {
+	domain, refreshInterval := b.domain, b.refresh
+	for {
+		ctx, cancel := context.WithCancel(context.Background())
+		renewed := make(chan struct{})
+
+		// Stop browsing when done is closed, or renew after refreshInterval.
+		go func() {
+			if refreshInterval > 0 {
+				select {
+				case <-done:
+					cancel()
+				case <-time.After(refreshInterval):
+					b.log.Debugf("mDNS browse %s: periodic refresh", service)
+					close(renewed)
+					cancel()
+				case <-ctx.Done():
+				}
+			} else {
+				select {
+				case <-done:
+					cancel()
+				case <-ctx.Done():
+				}
+			}
+		}()
+
+		resolver, err := zeroconf.NewResolver()
+		if err != nil {
+			cancel()
+			b.log.Warnf("mDNS browse %s: failed to create resolver: %v, retrying in 5s", service, err)
+			select {
+			case <-done:
+				return
+			case <-time.After(5 * time.Second):
+				continue
+			}
+		}
+
+		// zeroconf owns entries and closes it when ctx is cancelled. Never close it here.
+		entries := make(chan *zeroconf.ServiceEntry)
+		round := newBrowseRound(b.observer, "zeroconf", service)
+		go func() {
+			for entry := range entries {
+				round.entry()
+				// A panic must not stop draining entries, which zeroconf blocks on.
+				_ = recovered("mDNS browse "+service, b.log, func() { handler(entry) })
+			}
+		}()
+
+		if err := resolver.Browse(ctx, service, domain, entries); err != nil {
+			cancel()
+			b.log.Warnf("mDNS browse %s: %v, retrying in 5s", service, err)
+			select {
+			case <-done:
+				return
+			case <-time.After(5 * time.Second):
+				continue
+			}
+		}
+
+		// Browse returned — either context was cancelled (done) or an error.
+		<-ctx.Done()
+		cancel()
+		round.finish()
+
+		select {
+		case <-done:
+			return
+		case <-renewed:
+			continue
+		default:
+			// Context was cancelled for another reason; restart.
+			b.log.Debugf("mDNS browse %s: restarting", service)
+			time.Sleep(5 * time.Second)
+		}
+	}
+}
+
+// extractIPv4s returns all IPv4 addresses from a zeroconf ServiceEntry.
+func extractIPv4s(entry *zeroconf.ServiceEntry) []netip.Addr {
+	var ips []netip.Addr
+	for _, raw := range entry.AddrIPv4 {
+		ip, ok := netip.AddrFromSlice(raw)
+		if !ok {
+			continue
+		}
+		if ip = ip.Unmap(); ip.Is4() {
+			ips = appendUnique(ips, ip)
+		}
+	}
+	return ips
+}
+
+// extractIPv6s returns all IPv6 addresses from a zeroconf ServiceEntry.
+func extractIPv6s(entry *zeroconf.ServiceEntry) []netip.Addr {
+	var ips []netip.Addr
+	for _, raw := range entry.AddrIPv6 {
+		ip, ok := netip.AddrFromSlice(raw)
+		if !ok || !ip.Is6() || ip.Is4In6() {
+			continue
+		}
+		ips = appendUnique(ips, ip)
+	}
+	return ips
+}
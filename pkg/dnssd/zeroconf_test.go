@@ -0,0 +1,69 @@
+package dnssd
+
+import (
+	"net"
+	"testing"
+
+	"github.com/grandcat/zeroconf"
+)
+
+func TestExtractIPv6s(t *testing.T) {
+	tests := []struct {
+		name      string
+		addrs     []net.IP
+		wantCount int
+	}{
+		{
+			name:      "Single global IPv6",
+			addrs:     []net.IP{net.ParseIP("fd00:1234:5678:9abc::1")},
+			wantCount: 1,
+		},
+		{
+			name:      "IPv4 address filtered out",
+			addrs:     []net.IP{net.ParseIP("192.168.1.1")},
+			wantCount: 0,
+		},
+		{
+			name:      "No addresses",
+			addrs:     nil,
+			wantCount: 0,
+		},
+		{
+			name:      "Multiple IPv6 addresses",
+			addrs:     []net.IP{net.ParseIP("2001:4860:4860::8888"), net.ParseIP("fd00::1")},
+			wantCount: 2,
+		},
+		{
+			name:      "Mixed IPv4 and IPv6",
+			addrs:     []net.IP{net.ParseIP("192.168.1.1"), net.ParseIP("fd00::1")},
+			wantCount: 1,
+		},
+		{
+			name:      "Duplicate IPv6 addresses deduplicated",
+			addrs:     []net.IP{net.ParseIP("fd00::1"), net.ParseIP("fd00::1")},
+			wantCount: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := &zeroconf.ServiceEntry{
+				AddrIPv6: tt.addrs,
+			}
+			result := extractIPv6s(entry)
+			if len(result) != tt.wantCount {
+				t.Errorf("extractIPv6s() returned %d IPs, want %d", len(result), tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestExtractIPv4s(t *testing.T) {
+	entry := &zeroconf.ServiceEntry{
+		AddrIPv4: []net.IP{net.ParseIP("192.168.1.10"), net.ParseIP("192.168.1.10"), net.ParseIP("10.0.0.5")},
+	}
+	result := extractIPv4s(entry)
+	if len(result) != 2 || result[0].String() != "192.168.1.10" || result[1].String() != "10.0.0.5" {
+		t.Errorf("Expected 192.168.1.10 and 10.0.0.5, got %v", result)
+	}
+}
@@ -0,0 +1,34 @@
+package threaddiscovery
+
+import "testing"
+
+// TXT records come from whatever happens to be on the network, so this fuzz
+// target checks that a malformed omr= never panics or yields a prefix the
+// daemon would route.
+// Run it with go test -fuzz=FuzzOMRPrefix ./pkg/threaddiscovery
+
+func FuzzOMRPrefix(f *testing.F) {
+	for _, seed := range []string{
+		"omr=@\\253\\000\\017\\017\\034\\034\\068\\068",
+		"omr=\\064\\253\\000\\017\\017\\034\\034\\068\\068",
+		"omr=\\255\\253",
+		"omr=\\",
+		"omr=\\999",
+		"omr=",
+		"rv=1",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, field string) {
+		prefix := omrPrefix([]string{field})
+		if !prefix.IsValid() {
+			return
+		}
+		if prefix != prefix.Masked() {
+			t.Errorf("Expected masked prefix, got %s", prefix)
+		}
+		if !prefix.Addr().Is6() || !prefix.Addr().IsPrivate() {
+			t.Errorf("Expected an IPv6 ULA prefix, got %s", prefix)
+		}
+	})
+}
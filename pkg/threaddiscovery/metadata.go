@@ -0,0 +1,112 @@
+package threaddiscovery
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/netip"
+	"os"
+	"strings"
+)
+
+// OUIDatabase maps IEEE organizationally unique identifiers to vendor names.
+// Lookups fall back to builtinOUIs, so a nil database still names common vendors.
+type OUIDatabase map[[3]byte]string
+
+// builtinOUIs covers vendors and radio chip makers common in Matter and Thread gear.
+var builtinOUIs = OUIDatabase{
+	{0x00, 0x0b, 0x57}: "Silicon Labs",
+	{0x00, 0x0d, 0x6f}: "Silicon Labs (Ember)",
+	{0x00, 0x12, 0x4b}: "Texas Instruments",
+	{0x00, 0x15, 0x8d}: "NXP (Jennic)",
+	{0x00, 0x17, 0x88}: "Signify (Philips Hue)",
+	{0x18, 0xb4, 0x30}: "Google Nest",
+	{0x24, 0x0a, 0xc4}: "Espressif",
+	{0x30, 0xae, 0xa4}: "Espressif",
+	{0x84, 0xf3, 0xeb}: "Espressif",
+	{0x90, 0xfd, 0x9f}: "Silicon Labs",
+	{0xa4, 0xcf, 0x12}: "Espressif",
+	{0xf4, 0xce, 0x36}: "Nordic Semiconductor",
+}
+
+// LoadOUIDatabase reads an IEEE MA-L registry export, either oui.csv
+// ("MA-L,001788,Signify,...") or oui.txt ("00-17-88   (hex)   Signify"). An
+// empty path returns an empty database, which uses the built-in vendors.
+func LoadOUIDatabase(path string) (OUIDatabase, error) {
+	db := OUIDatabase{}
+	if path == "" {
+		return db, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var assignment, vendor string
+		line := scanner.Text()
+		if before, after, ok := strings.Cut(line, "(hex)"); ok {
+			assignment, vendor = strings.ReplaceAll(strings.TrimSpace(before), "-", ""), after
+		} else if fields := strings.SplitN(line, ",", 4); len(fields) >= 3 && fields[0] == "MA-L" {
+			assignment, vendor = fields[1], fields[2]
+		}
+		var oui [3]byte
+		if len(assignment) != 6 {
+			continue
+		}
+		if _, err := hex.Decode(oui[:], []byte(assignment)); err != nil {
+			continue
+		}
+		if vendor = strings.Trim(strings.TrimSpace(vendor), `"`); vendor != "" {
+			db[oui] = vendor
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read %s: %v", path, err)
+	}
+	return db, nil
+}
+
+// Vendor returns the vendor of a globally administered hardware address, or "".
+func (db OUIDatabase) Vendor(mac net.HardwareAddr) string {
+	if len(mac) < 3 || mac[0]&0x02 != 0 {
+		return ""
+	}
+	oui := [3]byte{mac[0], mac[1], mac[2]}
+	if vendor, ok := db[oui]; ok {
+		return vendor
+	}
+	return builtinOUIs[oui]
+}
+
+// deviceMAC derives a device's hardware address. Matter devices name their host
+// after their 48-bit MAC or, on Thread, their 64-bit extended address; failing
+// that, an address with an EUI-64 interface identifier embeds the MAC.
+func deviceMAC(host string, addrs []netip.Addr) net.HardwareAddr {
+	label, _, _ := strings.Cut(host, ".")
+	if len(label) == 12 || len(label) == 16 {
+		if mac, err := hex.DecodeString(label); err == nil {
+			return mac
+		}
+	}
+	for _, ip := range addrs {
+		if mac := MACFromEUI64(ip); mac != nil {
+			return mac
+		}
+	}
+	return nil
+}
+
+// MACFromEUI64 returns the hardware address embedded in an IPv6 address with
+// an EUI-64 interface identifier, or nil.
+func MACFromEUI64(ip netip.Addr) net.HardwareAddr {
+	if !ip.Is6() || ip.Is4In6() {
+		return nil
+	}
+	if b := ip.As16(); b[11] == 0xff && b[12] == 0xfe {
+		return net.HardwareAddr{b[8] ^ 0x02, b[9], b[10], b[13], b[14], b[15]}
+	}
+	return nil
+}
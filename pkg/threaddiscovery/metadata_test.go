@@ -0,0 +1,97 @@
+package threaddiscovery
+
+import (
+	"net"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestDeviceMAC(t *testing.T) {
+	tests := []struct {
+		name     string
+		host     string
+		addrs    []string
+		expected string
+	}{
+		{
+			name:     "MAC hostname",
+			host:     "001788010203.local.",
+			expected: "00:17:88:01:02:03",
+		},
+		{
+			name:     "Thread extended address hostname",
+			host:     "F4CE36A1B2C3D4E5.local.",
+			expected: "f4:ce:36:a1:b2:c3:d4:e5",
+		},
+		{
+			name:     "EUI-64 interface identifier",
+			host:     "lamp.local.",
+			addrs:    []string{"fd00:1111:2222:3333:1234:5678:9abc:def0", "fe80::217:88ff:fe01:203"},
+			expected: "00:17:88:01:02:03",
+		},
+		{
+			name:  "Random interface identifier",
+			host:  "lamp.local.",
+			addrs: []string{"fd00:1111:2222:3333:1234:5678:9abc:def0"},
+		},
+		{
+			name: "Non-hex hostname",
+			host: "kitchen-light.local.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var addrs []netip.Addr
+			for _, a := range tt.addrs {
+				addrs = append(addrs, netip.MustParseAddr(a))
+			}
+			if got := deviceMAC(tt.host, addrs).String(); got != tt.expected {
+				t.Errorf("Expected MAC %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestOUIDatabase(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "oui.csv")
+	csv := "Registry,Assignment,Organization Name,Organization Address\n" +
+		"MA-L,0055DA,Example Lighting Inc.,Somewhere\n" +
+		"MA-L,\"bad\",Broken,\n"
+	if err := os.WriteFile(path, []byte(csv), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	db, err := LoadOUIDatabase(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		mac      string
+		expected string
+	}{
+		{"00:55:da:01:02:03", "Example Lighting Inc."},
+		{"00:17:88:01:02:03", "Signify (Philips Hue)"}, // built in
+		{"02:17:88:01:02:03", ""},                      // locally administered
+		{"12:34:56:78:9a:bc", ""},
+	}
+	for _, tt := range tests {
+		mac, _ := net.ParseMAC(tt.mac)
+		if got := db.Vendor(mac); got != tt.expected {
+			t.Errorf("Expected vendor %q for %s, got %q", tt.expected, tt.mac, got)
+		}
+	}
+
+	txt := filepath.Join(t.TempDir(), "oui.txt")
+	if err := os.WriteFile(txt, []byte("00-55-DA   (hex)\t\tExample Lighting Inc.\n0055DA     (base 16)\t\tExample Lighting Inc.\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if db, err := LoadOUIDatabase(txt); err != nil || len(db) != 1 {
+		t.Errorf("Expected 1 vendor from oui.txt, got %v (%v)", db, err)
+	}
+	if _, err := LoadOUIDatabase(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("Expected error for missing file")
+	}
+}
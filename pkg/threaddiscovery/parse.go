@@ -0,0 +1,79 @@
+package threaddiscovery
+
+import (
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/pkg/dnssd"
+)
+
+// ParseBorderRouter returns the border router a _meshcop._udp instance
+// announces, seen now. It returns false when the instance has no IPv6 address.
+func ParseBorderRouter(inst dnssd.Instance) (*BorderRouter, bool) {
+	if len(inst.Addrs) == 0 {
+		return nil, false
+	}
+	name := dnssd.InstanceLabel(inst.Name)
+	return &BorderRouter{
+		Name:       name,
+		Host:       strings.TrimSuffix(inst.Host, "."),
+		ExtAddress: dnssd.TXTHex(inst.Text, "xa", 8),
+		ExtPANID:   dnssd.TXTHex(inst.Text, "xp", 8),
+		OMRPrefix:  omrPrefix(inst.Text),
+		Type:       RouterType(name, inst.Host, inst.Text),
+		IPv6Addrs:  inst.Addrs,
+		TXT:        dnssd.TXTRecord(inst.Text),
+		Seen:       time.Now(),
+	}, true
+}
+
+// ParseDevice returns the Matter device a _matter._tcp instance announces,
+// seen now, with its vendor looked up in ouis. It returns false when the
+// instance has no address.
+func ParseDevice(inst dnssd.Instance, ouis OUIDatabase) (*Device, bool) {
+	if len(inst.Addrs) == 0 && len(inst.IPv4Addrs) == 0 {
+		return nil, false
+	}
+	device := &Device{
+		Name:      dnssd.InstanceLabel(inst.Name),
+		Host:      strings.TrimSuffix(inst.Host, "."),
+		IPv6Addrs: inst.Addrs,
+		IPv4Addrs: inst.IPv4Addrs,
+		TXT:       dnssd.TXTRecord(inst.Text),
+		Seen:      time.Now(),
+	}
+	if mac := deviceMAC(inst.Host, inst.Addrs); mac != nil {
+		device.MAC = mac.String()
+		device.Vendor = ouis.Vendor(mac)
+	}
+	return device, true
+}
+
+// omrPrefix parses the Thread Off-Mesh Route prefix from _meshcop._udp TXT records.
+// The omr= field is: 1 byte prefix-length, followed by ceil(prefixLen/8) prefix bytes.
+// The prefix bytes are not zero-padded to 16 bytes — only significant bytes are included.
+func omrPrefix(txt []string) netip.Prefix {
+	for _, field := range txt {
+		if !strings.HasPrefix(field, "omr=") {
+			continue
+		}
+		val := dnssd.UnescapeTXT(field[4:])
+		if len(val) < 2 {
+			continue
+		}
+		prefixLen := int(val[0])
+		if prefixLen == 0 || prefixLen > 128 {
+			continue
+		}
+		var raw [16]byte
+		copy(raw[:], val[1:])
+		prefix := netip.PrefixFrom(netip.AddrFrom16(raw), prefixLen).Masked()
+		// Check the masked prefix so a short length can't widen a ULA past fc00::/7.
+		if !prefix.Addr().IsPrivate() {
+			continue
+		}
+		return prefix
+	}
+	return netip.Prefix{}
+}
@@ -0,0 +1,61 @@
+package threaddiscovery
+
+import (
+	"strings"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/pkg/dnssd"
+)
+
+// Border router types derived by RouterType.
+const (
+	RouterTypeAppleTV     = "apple-tv"
+	RouterTypeHomePod     = "homepod"
+	RouterTypeNestHub     = "nest-hub"
+	RouterTypeNestWifi    = "nest-wifi"
+	RouterTypeEero        = "eero"
+	RouterTypeSmartThings = "smartthings"
+	RouterTypeOTBR        = "otbr"
+)
+
+// routerTypePatterns map lowercase substrings of the vendor and model names
+// (vn= and mn= in _meshcop._udp) or of the instance and host names to router
+// types, most specific first.
+var routerTypePatterns = []struct {
+	pattern, routerType string
+}{
+	{"appletv", RouterTypeAppleTV},
+	{"apple tv", RouterTypeAppleTV},
+	{"apple-tv", RouterTypeAppleTV},
+	{"homepod", RouterTypeHomePod},
+	{"audioaccessory", RouterTypeHomePod},
+	{"nest hub", RouterTypeNestHub},
+	{"nesthub", RouterTypeNestHub},
+	{"nest-hub", RouterTypeNestHub},
+	{"nest wifi", RouterTypeNestWifi},
+	{"nestwifi", RouterTypeNestWifi},
+	{"nest-wifi", RouterTypeNestWifi},
+	{"eero", RouterTypeEero},
+	{"smartthings", RouterTypeSmartThings},
+	{"openthread", RouterTypeOTBR},
+	{"otbr", RouterTypeOTBR},
+}
+
+// RouterType returns the model family of a border router, e.g. "apple-tv" or
+// "otbr", from its TXT vendor and model names, falling back to its instance and
+// host names. It returns "" when nothing matches.
+func RouterType(name, host string, txt []string) string {
+	record := dnssd.TXTRecord(txt)
+	candidates := []string{record["mn"], record["vn"], name, host}
+	for _, c := range candidates {
+		c = strings.ToLower(c)
+		if c == "" {
+			continue
+		}
+		for _, p := range routerTypePatterns {
+			if strings.Contains(c, p.pattern) {
+				return p.routerType
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,26 @@
+package threaddiscovery
+
+import "testing"
+
+// TestRouterType verifies router types are derived from TXT model names first,
+// then from instance and host names.
+func TestRouterType(t *testing.T) {
+	tests := []struct {
+		name, host string
+		txt        []string
+		want       string
+	}{
+		{"Living Room", "Living-Room.local.", []string{"vn=Apple Inc.", "mn=AppleTV14,1"}, RouterTypeAppleTV},
+		{"Kitchen", "Kitchen.local.", []string{"vn=Apple Inc.", "mn=AudioAccessory5,1"}, RouterTypeHomePod},
+		{"Nest Hub", "", []string{"vn=Google Inc.", "mn=Google Nest Hub"}, RouterTypeNestHub},
+		{"OpenThread BorderRouter #1F2E", "otbr.local.", []string{"vn=OpenThread"}, RouterTypeOTBR},
+		{"eero Thread Border Router", "", nil, RouterTypeEero},
+		{"Bedroom", "bedroom-homepod.local.", nil, RouterTypeHomePod},
+		{"Mystery", "mystery.local.", []string{"vn=Acme"}, ""},
+	}
+	for _, tt := range tests {
+		if got := RouterType(tt.name, tt.host, tt.txt); got != tt.want {
+			t.Errorf("Expected %q to be type %q, got %q", tt.name, tt.want, got)
+		}
+	}
+}
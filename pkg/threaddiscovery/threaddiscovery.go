@@ -0,0 +1,159 @@
+// Package threaddiscovery finds Matter devices and Thread border routers on
+// the local network over DNS-SD, as thread-route-updater does, for other Go
+// programs to reuse without running the daemon:
+//
+//	browser, err := threaddiscovery.New(threaddiscovery.Options{})
+//	if err != nil {
+//		return err
+//	}
+//	for event := range browser.Subscribe(ctx) {
+//		switch event.Kind {
+//		case threaddiscovery.BorderRouterSeen:
+//			fmt.Println(event.BorderRouter.Name, event.BorderRouter.OMRPrefix)
+//		case threaddiscovery.DeviceSeen:
+//			fmt.Println(event.Device.Name, event.Device.IPv6Addrs)
+//		}
+//	}
+//
+// Devices and routers are reported every time they are seen, not only when
+// they appear; consumers that want a list keep the latest sighting by name.
+package threaddiscovery
+
+import (
+	"context"
+	"net/netip"
+	"sync"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/pkg/dnssd"
+)
+
+// Options selects how a Browser discovers. The zero value browses the local.
+// domain with the embedded mDNS implementation.
+type Options struct {
+	Backend      string        // "zeroconf" (default), "unicast", "avahi" or "dnssd"
+	Mode         string        // "hybrid" (default), "passive" (announcements only) or "active" (polling only)
+	Server       string        // unicast: DNS server or mDNS proxy as host:port
+	Domains      []string      // browse domains; default "local."
+	PollInterval time.Duration // unicast and active mode: query interval; default 30s
+	Timeout      time.Duration // bound on each resolve or query; 0 uses the backend default
+	OUIFile      string        // IEEE OUI registry used to name device vendors; empty uses the built-in list
+	Logger       Logger        // receives browse failures and details; nil discards them
+}
+
+// Logger receives the messages of a Browser, see dnssd.Logger.
+type Logger = dnssd.Logger
+
+// EventKind tells what an Event reports.
+type EventKind string
+
+// Event kinds.
+const (
+	BorderRouterSeen EventKind = "border-router"
+	DeviceSeen       EventKind = "device"
+)
+
+// Event is a sighting of a border router or a Matter device.
+type Event struct {
+	Kind         EventKind
+	BorderRouter *BorderRouter // set for BorderRouterSeen
+	Device       *Device       // set for DeviceSeen
+}
+
+// BorderRouter is a Thread border router announced as _meshcop._udp.
+type BorderRouter struct {
+	Name       string            // DNS-SD instance name, unescaped
+	Host       string            // host name from the SRV record, without the trailing dot
+	ExtAddress string            // Thread extended address (xa), hex
+	ExtPANID   string            // Thread extended PAN ID (xp), hex
+	OMRPrefix  netip.Prefix      // Thread mesh prefix announced in omr=, if any
+	Type       string            // model family, e.g. "apple-tv", "nest-hub" or "otbr"
+	IPv6Addrs  []netip.Addr      // addresses of the router on the LAN
+	TXT        map[string]string // TXT metadata, see TXTRecord
+	Seen       time.Time
+}
+
+// Device is a Matter device announced as _matter._tcp.
+type Device struct {
+	Name      string            // DNS-SD instance name: compressed fabric and node ID
+	Host      string            // host name from the SRV record, without the trailing dot
+	MAC       string            // hardware address, when the host name or an EUI-64 address reveals it
+	Vendor    string            // vendor of MAC, when known
+	IPv6Addrs []netip.Addr      // addresses of the device; Thread devices only have IPv6
+	IPv4Addrs []netip.Addr      // IPv4 addresses, announced by Wi-Fi and Ethernet devices
+	TXT       map[string]string // TXT metadata, see TXTRecord
+	Seen      time.Time
+}
+
+// TXTRecord returns the key=value strings of a TXT record as a map with the
+// values unescaped, so binary values such as xa= hold their raw bytes. Keys
+// are lower-cased and the first occurrence of a key wins.
+func TXTRecord(txt []string) map[string]string {
+	return dnssd.TXTRecord(txt)
+}
+
+// Browser discovers border routers and Matter devices. It is safe for
+// concurrent use; each Subscribe browses on its own.
+type Browser struct {
+	browser dnssd.Browser
+	ouis    OUIDatabase
+}
+
+// New returns a Browser for opts, or an error when the backend or OUI file is
+// unusable.
+func New(opts Options) (*Browser, error) {
+	browser, err := dnssd.NewBrowser(dnssd.Options{
+		Backend:      opts.Backend,
+		Mode:         opts.Mode,
+		Server:       opts.Server,
+		Domains:      opts.Domains,
+		PollInterval: opts.PollInterval,
+		Timeout:      opts.Timeout,
+		Logger:       opts.Logger,
+	})
+	if err != nil {
+		return nil, err
+	}
+	ouis, err := LoadOUIDatabase(opts.OUIFile)
+	if err != nil {
+		return nil, err
+	}
+	return &Browser{browser: browser, ouis: ouis}, nil
+}
+
+// Subscribe browses until ctx is done, sending an Event for every sighting.
+// The channel is closed once browsing has stopped. Sightings are not dropped,
+// so a consumer that stops reading stalls browsing until ctx is done.
+func (b *Browser) Subscribe(ctx context.Context) <-chan Event {
+	ch := make(chan Event, 16)
+	send := func(e Event) {
+		select {
+		case ch <- e:
+		case <-ctx.Done():
+		}
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		b.browser.Browse("_meshcop._udp", ctx.Done(), func(inst dnssd.Instance) {
+			if router, ok := ParseBorderRouter(inst); ok {
+				send(Event{Kind: BorderRouterSeen, BorderRouter: router})
+			}
+		})
+	}()
+	go func() {
+		defer wg.Done()
+		b.browser.Browse("_matter._tcp", ctx.Done(), func(inst dnssd.Instance) {
+			if device, ok := ParseDevice(inst, b.ouis); ok {
+				send(Event{Kind: DeviceSeen, Device: device})
+			}
+		})
+	}()
+	go func() {
+		wg.Wait()
+		close(ch)
+	}()
+	return ch
+}
@@ -0,0 +1,99 @@
+package threaddiscovery
+
+import (
+	"context"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/rafaelgaspar/unifi-thread-route-updater/pkg/dnssd"
+)
+
+// fakeBrowser reports fixed instances per service once, then waits for done.
+type fakeBrowser map[string][]dnssd.Instance
+
+func (b fakeBrowser) Browse(service string, done <-chan struct{}, handler func(dnssd.Instance)) {
+	for _, inst := range b[service] {
+		handler(inst)
+	}
+	<-done
+}
+
+func TestSubscribe(t *testing.T) {
+	b := &Browser{browser: fakeBrowser{
+		"_meshcop._udp": {
+			{
+				Name:  "Kitchen._meshcop._udp.local.",
+				Host:  "kitchen.local.",
+				Addrs: []netip.Addr{netip.MustParseAddr("2001:db8::1")},
+				Text:  []string{"vn=Apple Inc.", "mn=AppleTV", "xp=\\222\\173\\000\\001\\002\\003\\004\\005", "omr=@\\253\\205\\001\\002\\003\\004\\005\\006"},
+			},
+			{Name: "NoAddress._meshcop._udp.local."},
+		},
+		"_matter._tcp": {
+			{
+				Name:  "ABCD1234-0000000000000001._matter._tcp.local.",
+				Host:  "0217880102030000.local.",
+				Addrs: []netip.Addr{netip.MustParseAddr("fd00:1::5")},
+				Text:  []string{"SII=5000", "SAI=300"},
+			},
+		},
+	}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := b.Subscribe(ctx)
+	var router *BorderRouter
+	var device *Device
+	for router == nil || device == nil {
+		select {
+		case e := <-ch:
+			switch e.Kind {
+			case BorderRouterSeen:
+				router = e.BorderRouter
+			case DeviceSeen:
+				device = e.Device
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Expected a border router and a device event")
+		}
+	}
+
+	if router.Name != "Kitchen" || router.Host != "kitchen.local" || router.Type != "apple-tv" ||
+		router.ExtPANID != "dead000102030405" || router.OMRPrefix != netip.MustParsePrefix("fdcd:102:304:506::/64") {
+		t.Errorf("Expected the Kitchen Apple TV with its PAN ID and mesh prefix, got %+v", router)
+	}
+	if router.TXT["vn"] != "Apple Inc." || router.TXT["mn"] != "AppleTV" {
+		t.Errorf("Expected the TXT metadata of the router, got %q", router.TXT)
+	}
+	if device.Name != "ABCD1234-0000000000000001" || device.Host != "0217880102030000.local" ||
+		len(device.IPv6Addrs) != 1 || device.TXT["sii"] != "5000" {
+		t.Errorf("Expected the Matter device with its TXT metadata, got %+v", device)
+	}
+
+	cancel()
+	for range ch {
+	}
+}
+
+func TestSubscribeStopsWithoutReader(t *testing.T) {
+	instances := make([]dnssd.Instance, 100)
+	for i := range instances {
+		instances[i] = dnssd.Instance{Name: "Router._meshcop._udp.local.", Addrs: []netip.Addr{netip.MustParseAddr("2001:db8::1")}}
+	}
+	b := &Browser{browser: fakeBrowser{"_meshcop._udp": instances}}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := b.Subscribe(ctx)
+	cancel()
+	done := make(chan struct{})
+	go func() {
+		for range ch {
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Expected the channel closed once the context is done")
+	}
+}
@@ -0,0 +1,294 @@
+package unifiroutes
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// newTestClient returns a client pointed at a test server running handler.
+func newTestClient(t *testing.T, handler http.Handler) *Client {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return New(Config{BaseURL: srv.URL, Username: "tester", Password: "secret"})
+}
+
+func TestUsesV2(t *testing.T) {
+	tests := []struct {
+		version  string
+		expected bool
+	}{
+		{"8.6.9", false},
+		{"9.0.108", true},
+		{"10.1.2", true},
+		{"", false},
+		{"garbage", false},
+	}
+
+	for _, tt := range tests {
+		if got := usesV2(tt.version); got != tt.expected {
+			t.Errorf("usesV2(%q): expected %v, got %v", tt.version, tt.expected, got)
+		}
+	}
+}
+
+// TestLoginSession verifies a login keeps the session and sends it on requests.
+func TestLoginSession(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req loginRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Username != "tester" || req.Password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("X-CSRF-Token", "csrf123")
+		http.SetCookie(w, &http.Cookie{Name: "TOKEN", Value: "tok456"})
+		_, _ = w.Write([]byte(`{"meta":{"rc":"ok"}}`))
+	}))
+
+	if client.HasSession() {
+		t.Fatal("Expected no session before login")
+	}
+	if err := client.Login(context.Background()); err != nil {
+		t.Fatalf("Login failed: %v", err)
+	}
+	req, _ := http.NewRequest(http.MethodGet, "http://unifi.example/", nil)
+	client.Authorize(req)
+	if req.Header.Get("X-CSRF-Token") != "csrf123" || req.Header.Get("Authorization") != "Bearer tok456" {
+		t.Errorf("Expected the session headers set, got %v", req.Header)
+	}
+	client.ClearSession()
+	if client.HasSession() {
+		t.Error("Expected the session cleared")
+	}
+}
+
+// secretLogger is a Logger that records the secrets registered with it.
+type secretLogger struct {
+	discard
+	secrets map[string]bool
+}
+
+func (l *secretLogger) RegisterSecret(secret string) { l.secrets[secret] = true }
+func (l *secretLogger) ForgetSecret(secret string)   { delete(l.secrets, secret) }
+
+// TestLoginRegistersSecrets verifies the session cookie and CSRF token are
+// handed to a Logger that redacts secrets.
+func TestLoginRegistersSecrets(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-CSRF-Token", "csrf123")
+		http.SetCookie(w, &http.Cookie{Name: "TOKEN", Value: "tok456"})
+		_, _ = w.Write([]byte(`{"meta":{"rc":"ok"}}`))
+	}))
+	defer srv.Close()
+	log := &secretLogger{secrets: make(map[string]bool)}
+	client := New(Config{BaseURL: srv.URL, Username: "tester", Password: "secret", Logger: log})
+
+	if err := client.Login(context.Background()); err != nil {
+		t.Fatalf("Login failed: %v", err)
+	}
+	if !log.secrets["csrf123"] || !log.secrets["tok456"] || len(log.secrets) != 2 {
+		t.Errorf("Expected the cookie and CSRF token registered, got %v", log.secrets)
+	}
+}
+
+// TestV2Routes verifies a v9 controller is driven through the v2 endpoints.
+func TestV2Routes(t *testing.T) {
+	var added, updated v2Route
+	var deletedPath, updatedPath string
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/proxy/network/api/s/default/stat/sysinfo":
+			_, _ = w.Write([]byte(`{"meta":{"rc":"ok"},"data":[{"version":"9.0.108"}]}`))
+		case r.URL.Path == "/proxy/network/v2/api/site/default/static-routes" && r.Method == http.MethodGet:
+			_, _ = w.Write([]byte(`[{"_id":"r1","name":"Office","enabled":true,"destination":"fd00::/64","next_hop":"2001:4860::1","distance":2,"route_type":"nexthop-route"}]`))
+		case r.URL.Path == "/proxy/network/v2/api/site/default/static-routes" && r.Method == http.MethodPost:
+			_ = json.NewDecoder(r.Body).Decode(&added)
+			w.WriteHeader(http.StatusCreated)
+		case r.Method == http.MethodPut:
+			updatedPath = r.URL.Path
+			_ = json.NewDecoder(r.Body).Decode(&updated)
+		case r.Method == http.MethodDelete:
+			deletedPath = r.URL.Path
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	ctx := context.Background()
+
+	current, err := client.List(ctx)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(current) != 1 || current[0].ID != "r1" || current[0].StaticRouteNetwork != "fd00::/64" ||
+		current[0].StaticRouteNexthop != "2001:4860::1" || current[0].StaticRouteDistance != 2 || !current[0].IsStatic() {
+		t.Errorf("Unexpected routes: %+v", current)
+	}
+
+	route := Route{Name: "Lab", StaticRouteNetwork: "fd01::/64", StaticRouteNexthop: "2001:4860::2", StaticRouteDistance: 1}
+	if err := client.Create(ctx, route); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if added.Destination != "fd01::/64" || added.NextHop != "2001:4860::2" || added.IPVersion != "v6" {
+		t.Errorf("Unexpected payload: %+v", added)
+	}
+	route.ID = "r1"
+	if err := client.Update(ctx, route); err != nil {
+		t.Fatalf("Update failed: %v", err)
+	}
+	if updatedPath != "/proxy/network/v2/api/site/default/static-routes/r1" || updated.NextHop != "2001:4860::2" {
+		t.Errorf("Unexpected update %s: %+v", updatedPath, updated)
+	}
+	if err := client.Delete(ctx, "r1"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if deletedPath != "/proxy/network/v2/api/site/default/static-routes/r1" {
+		t.Errorf("Unexpected delete path %s", deletedPath)
+	}
+}
+
+// TestSite verifies the configured site is used in the endpoint paths.
+func TestSite(t *testing.T) {
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		_, _ = w.Write([]byte(`{"meta":{"rc":"ok"},"data":[]}`))
+	}))
+	t.Cleanup(srv.Close)
+	client := New(Config{BaseURL: srv.URL, Site: "lab", APIVersion: "v1"})
+
+	if _, err := client.List(context.Background()); err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(paths) != 1 || paths[0] != "/proxy/network/api/s/lab/rest/routing" {
+		t.Errorf("Expected the lab site listed, got %v", paths)
+	}
+}
+
+// TestTypedErrors verifies controller answers match the package errors.
+func TestTypedErrors(t *testing.T) {
+	tests := []struct {
+		status int
+		target error
+	}{
+		{http.StatusUnauthorized, ErrUnauthorized},
+		{http.StatusForbidden, ErrUnauthorized},
+		{http.StatusNotFound, ErrNotFound},
+		{http.StatusTooManyRequests, ErrRateLimited},
+		{http.StatusBadGateway, ErrServer},
+	}
+	for _, tt := range tests {
+		client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tt.status)
+		}))
+		client.cfg.APIVersion = "v1"
+
+		err := client.Delete(context.Background(), "r1")
+		if !errors.Is(err, tt.target) {
+			t.Errorf("Status %d: expected %v, got %v", tt.status, tt.target, err)
+		}
+		var apiErr *APIError
+		if !errors.As(err, &apiErr) || apiErr.StatusCode != tt.status {
+			t.Errorf("Status %d: expected an *APIError, got %v", tt.status, err)
+		}
+		if tt.target != ErrNotFound && errors.Is(err, ErrNotFound) {
+			t.Errorf("Status %d: expected no match for ErrNotFound", tt.status)
+		}
+	}
+
+	login := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	if err := login.Login(context.Background()); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("Expected a refused login to match ErrUnauthorized, got %v", err)
+	}
+}
+
+// TestContextCancel verifies requests stop with the context.
+func TestContextCancel(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Expected no request with a canceled context")
+	}))
+	client.cfg.APIVersion = "v1"
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := client.List(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("Expected context.Canceled, got %v", err)
+	}
+}
+
+// TestTrafficRoutes verifies IPv6 routes are written as traffic routes.
+func TestTrafficRoutes(t *testing.T) {
+	var added trafficRoute
+	var deletedPath string
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/proxy/network/v2/api/site/default/trafficroutes" && r.Method == http.MethodGet:
+			_, _ = w.Write([]byte(`[` +
+				`{"_id":"t1","description":"Thread route via Kitchen","enabled":true,"matching_target":"IP","ip_addresses":[{"ip_or_subnet":"fd00::/64","ip_version":"v6"}],"next_hop":"2001:4860::1"},` +
+				`{"_id":"t2","description":"Streaming","enabled":true,"matching_target":"DOMAIN","network_id":"wan2","domains":[{"domain":"example.com"}]}]`))
+		case r.URL.Path == "/proxy/network/v2/api/site/default/trafficroutes" && r.Method == http.MethodPost:
+			_ = json.NewDecoder(r.Body).Decode(&added)
+		case r.Method == http.MethodDelete:
+			deletedPath = r.URL.Path
+		default:
+			t.Errorf("Unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	client.cfg.APIVersion = "traffic"
+	ctx := context.Background()
+
+	current, err := client.List(ctx)
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(current) != 2 || current[0].Name != "Thread route via Kitchen" || current[0].StaticRouteNetwork != "fd00::/64" ||
+		current[0].StaticRouteNexthop != "2001:4860::1" || !current[0].IsStatic() || !current[0].IsIPv6() {
+		t.Errorf("Unexpected routes: %+v", current)
+	}
+	if current[1].StaticRouteNetwork != "" || current[1].StaticRouteType != "interface-route" {
+		t.Errorf("Expected the domain route listed without a network, got %+v", current[1])
+	}
+
+	route := Route{Name: "Thread route via Office", Enabled: true, StaticRouteNetwork: "fd01::/64", StaticRouteNexthop: "2001:4860::2"}
+	if err := client.Create(ctx, route); err != nil {
+		t.Fatalf("Create failed: %v", err)
+	}
+	if added.Description != route.Name || added.MatchingTarget != "IP" || added.NextHop != "2001:4860::2" ||
+		len(added.IPAddresses) != 1 || added.IPAddresses[0].IPOrSubnet != "fd01::/64" || added.IPAddresses[0].IPVersion != "v6" ||
+		len(added.TargetDevices) != 1 || added.TargetDevices[0].Type != "ALL_CLIENTS" {
+		t.Errorf("Unexpected payload: %+v", added)
+	}
+	if err := client.Delete(ctx, "t1"); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if deletedPath != "/proxy/network/v2/api/site/default/trafficroutes/t1" {
+		t.Errorf("Unexpected delete path %s", deletedPath)
+	}
+}
+
+// TestTrafficRoutesDetected verifies a v9 controller without a static-routes
+// endpoint is driven through traffic routes.
+func TestTrafficRoutesDetected(t *testing.T) {
+	client := newTestClient(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/proxy/network/api/s/default/stat/sysinfo":
+			_, _ = w.Write([]byte(`{"meta":{"rc":"ok"},"data":[{"version":"9.1.120"}]}`))
+		case "/proxy/network/v2/api/site/default/trafficroutes":
+			_, _ = w.Write([]byte(`[]`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+
+	if !client.UsesTrafficRoutes(context.Background()) {
+		t.Error("Expected traffic routes used")
+	}
+}
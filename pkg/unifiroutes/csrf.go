@@ -0,0 +1,40 @@
+package unifiroutes
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"strings"
+)
+
+// loginCSRFToken returns the CSRF token of a login response. UniFi OS sends it
+// in X-CSRF-Token, or X-Updated-CSRF-Token, and also as the csrfToken claim of
+// the session JWT, which is used when a proxy in between drops the headers.
+func loginCSRFToken(resp *http.Response, session string) string {
+	for _, header := range []string{"X-CSRF-Token", "X-Updated-CSRF-Token"} {
+		if token := resp.Header.Get(header); token != "" {
+			return token
+		}
+	}
+	return jwtCSRFToken(session)
+}
+
+// jwtCSRFToken returns the csrfToken claim of a JWT, without verifying it, or
+// "" when token is not a JWT carrying one.
+func jwtCSRFToken(token string) string {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return ""
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return ""
+	}
+	var claims struct {
+		CSRFToken string `json:"csrfToken"`
+	}
+	if json.Unmarshal(payload, &claims) != nil {
+		return ""
+	}
+	return claims.CSRFToken
+}
@@ -0,0 +1,30 @@
+package unifiroutes
+
+import (
+	"encoding/base64"
+	"testing"
+)
+
+// testJWT returns an unsigned JWT with payload as its claims.
+func testJWT(payload string) string {
+	enc := base64.RawURLEncoding
+	return enc.EncodeToString([]byte(`{"alg":"HS256"}`)) + "." + enc.EncodeToString([]byte(payload)) + ".sig"
+}
+
+func TestJWTCSRFToken(t *testing.T) {
+	tests := []struct {
+		name     string
+		token    string
+		expected string
+	}{
+		{"claim", testJWT(`{"csrfToken":"abc","userId":"1"}`), "abc"},
+		{"no claim", testJWT(`{"userId":"1"}`), ""},
+		{"not a JWT", "opaque-session", ""},
+		{"bad payload", "a.!!!.c", ""},
+	}
+	for _, tt := range tests {
+		if got := jwtCSRFToken(tt.token); got != tt.expected {
+			t.Errorf("%s: expected %q, got %q", tt.name, tt.expected, got)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package unifiroutes
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Errors an *APIError matches with errors.Is, by status code.
+var (
+	ErrUnauthorized = errors.New("unauthorized") // 401 and 403: log in again, or the account lacks permission
+	ErrNotFound     = errors.New("not found")    // 404: no such route, or no such endpoint on this controller
+	ErrRateLimited  = errors.New("rate limited") // 429: too many requests or failed logins
+	ErrServer       = errors.New("server error") // 5xx
+)
+
+// ErrTooLarge is returned for a response body over MaxResponseBytes.
+var ErrTooLarge = errors.New("response too large")
+
+// APIError is returned when the controller answers with an unexpected status.
+type APIError struct {
+	StatusCode int
+	Body       string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API request failed with status %d: %s", e.StatusCode, e.Body)
+}
+
+// Is reports whether the status code of e is the one target stands for.
+func (e *APIError) Is(target error) bool {
+	switch target {
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrServer:
+		return e.StatusCode >= 500
+	}
+	return false
+}
+
+// ControllerError is the structured form of a controller error payload: the
+// legacy {"meta":{"rc":"error","msg":"api.err.X","validationError":{"field":..}}}
+// or the v2 {"code":"api.err.X","message":..,"details":{"field":..}}.
+type ControllerError struct {
+	Code    string `json:"code"`
+	Message string `json:"message,omitempty"`
+	Field   string `json:"field,omitempty"` // the offending field of a validation error
+}
+
+// String returns the code, followed by the field and message when present.
+func (e ControllerError) String() string {
+	s := e.Code
+	if e.Field != "" {
+		s += " (field " + e.Field + ")"
+	}
+	if e.Message != "" && e.Message != e.Code {
+		s += ": " + e.Message
+	}
+	return s
+}
+
+// Controller returns the structured controller error carried in the response
+// body, if the body is a controller error payload.
+func (e *APIError) Controller() (ControllerError, bool) {
+	var payload struct {
+		Meta struct {
+			RC              string `json:"rc"`
+			Msg             string `json:"msg"`
+			ValidationError struct {
+				Field string `json:"field"`
+			} `json:"validationError"`
+		} `json:"meta"`
+		Code    string `json:"code"`
+		Message string `json:"message"`
+		Details struct {
+			Field string `json:"field"`
+		} `json:"details"`
+	}
+	if err := json.Unmarshal([]byte(e.Body), &payload); err != nil {
+		return ControllerError{}, false
+	}
+	switch {
+	case payload.Meta.Msg != "":
+		return ControllerError{Code: payload.Meta.Msg, Field: payload.Meta.ValidationError.Field}, true
+	case payload.Code != "":
+		return ControllerError{Code: payload.Code, Message: payload.Message, Field: payload.Details.Field}, true
+	}
+	return ControllerError{}, false
+}
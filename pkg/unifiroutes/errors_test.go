@@ -0,0 +1,35 @@
+package unifiroutes
+
+import "testing"
+
+func TestControllerError(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		expected ControllerError
+		ok       bool
+	}{
+		{"legacy", `{"meta":{"rc":"error","msg":"api.err.NameExisted"},"data":[]}`,
+			ControllerError{Code: "api.err.NameExisted"}, true},
+		{"legacy validation", `{"meta":{"rc":"error","msg":"api.err.InvalidPayload","validationError":{"field":"static-route_nexthop","pattern":"^.*$"}}}`,
+			ControllerError{Code: "api.err.InvalidPayload", Field: "static-route_nexthop"}, true},
+		{"v2", `{"code":"api.err.RouteExists","message":"Route already exists","errorCode":400,"details":{"field":"name"}}`,
+			ControllerError{Code: "api.err.RouteExists", Message: "Route already exists", Field: "name"}, true},
+		{"not json", "Bad Request", ControllerError{}, false},
+		{"other json", `{"error":"nope"}`, ControllerError{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := (&APIError{StatusCode: 400, Body: tt.body}).Controller()
+			if got != tt.expected || ok != tt.ok {
+				t.Errorf("Expected %+v, %v, got %+v, %v", tt.expected, tt.ok, got, ok)
+			}
+		})
+	}
+
+	ce := ControllerError{Code: "api.err.RouteExists", Message: "Route already exists", Field: "name"}
+	if got := ce.String(); got != "api.err.RouteExists (field name): Route already exists" {
+		t.Errorf("Unexpected description %q", got)
+	}
+}
@@ -0,0 +1,142 @@
+package unifiroutes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// legacyAPI manages static routes through the classic /api/s/<site>/rest/routing
+// endpoints.
+type legacyAPI struct {
+	c *Client
+}
+
+// list retrieves all static routes from /rest/routing.
+func (a legacyAPI) list(ctx context.Context) ([]Route, error) {
+	return collectPages(ctx, a.page)
+}
+
+// page retrieves one page of static routes using the _start/_limit parameters.
+func (a legacyAPI) page(ctx context.Context, offset, limit int) ([]Route, error) {
+	url := fmt.Sprintf("%s/proxy/network/api/s/%s/rest/routing?_start=%d&_limit=%d", a.c.cfg.BaseURL, a.c.cfg.Site, offset, limit)
+
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	a.c.Authorize(req)
+
+	resp, err := a.c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer a.c.closeBody(resp)
+
+	body, err := ReadBody(resp)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &APIError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	routes, schema, err := decodeRoutes(body)
+	if err != nil {
+		return nil, err
+	}
+	a.c.noteSchema(schema)
+	return routes, nil
+}
+
+// add posts a new static route to /rest/routing.
+func (a legacyAPI) add(ctx context.Context, route Route) error {
+	url := fmt.Sprintf("%s/proxy/network/api/s/%s/rest/routing", a.c.cfg.BaseURL, a.c.cfg.Site)
+
+	jsonData, err := json.Marshal(route)
+	if err != nil {
+		return err
+	}
+	a.c.log.Debugf("UniFi: add route payload: %s", string(jsonData))
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return err
+	}
+	a.c.Authorize(req)
+
+	resp, err := a.c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer a.c.closeBody(resp)
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		a.c.log.Debugf("UniFi: add route response: status=%d body=%s", resp.StatusCode, string(body))
+		return &APIError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	return nil
+}
+
+// update replaces the static route with route.ID by route.
+func (a legacyAPI) update(ctx context.Context, route Route) error {
+	url := fmt.Sprintf("%s/proxy/network/api/s/%s/rest/routing/%s", a.c.cfg.BaseURL, a.c.cfg.Site, route.ID)
+
+	jsonData, err := json.Marshal(route)
+	if err != nil {
+		return err
+	}
+	a.c.log.Debugf("UniFi: update route payload: %s", string(jsonData))
+
+	req, err := http.NewRequestWithContext(ctx, "PUT", url, bytes.NewBuffer(jsonData))
+	if err != nil {
+		return err
+	}
+	a.c.Authorize(req)
+
+	resp, err := a.c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer a.c.closeBody(resp)
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		a.c.log.Debugf("UniFi: update route response: status=%d body=%s", resp.StatusCode, string(body))
+		return &APIError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	return nil
+}
+
+// remove deletes the static route with the given id.
+func (a legacyAPI) remove(ctx context.Context, routeID string) error {
+	url := fmt.Sprintf("%s/proxy/network/api/s/%s/rest/routing/%s", a.c.cfg.BaseURL, a.c.cfg.Site, routeID)
+
+	req, err := http.NewRequestWithContext(ctx, "DELETE", url, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "thread-route-updater/1.0")
+	a.c.Authorize(req)
+
+	resp, err := a.c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer a.c.closeBody(resp)
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return &APIError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	return nil
+}
@@ -0,0 +1,65 @@
+package unifiroutes
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+const (
+	// pageSize is the number of routes requested per listing page.
+	pageSize = 200
+	// maxPages bounds a listing so a controller that ignores paging parameters
+	// cannot keep a listing going forever.
+	maxPages = 50
+	// MaxResponseBytes caps a single API response body.
+	MaxResponseBytes = 16 << 20
+)
+
+// fetchPage returns up to limit routes starting at offset.
+type fetchPage func(ctx context.Context, offset, limit int) ([]Route, error)
+
+// collectPages lists the complete route table page by page. Listing stops at
+// the first short page, or when a page brings no new route IDs, which is how a
+// controller that ignores the paging parameters shows up.
+func collectPages(ctx context.Context, fetch fetchPage) ([]Route, error) {
+	var all []Route
+	seen := make(map[string]bool)
+	offset := 0
+	for page := 0; page < maxPages; page++ {
+		routes, err := fetch(ctx, offset, pageSize)
+		if err != nil {
+			return nil, err
+		}
+		added := 0
+		for _, r := range routes {
+			if r.ID != "" {
+				if seen[r.ID] {
+					continue
+				}
+				seen[r.ID] = true
+			}
+			all = append(all, r)
+			added++
+		}
+		if len(routes) < pageSize || added == 0 {
+			return all, nil
+		}
+		offset += len(routes)
+	}
+	return nil, fmt.Errorf("route listing exceeded %d pages of %d routes", maxPages, pageSize)
+}
+
+// ReadBody reads a response body, failing with ErrTooLarge on bodies larger
+// than MaxResponseBytes rather than returning a truncated route table.
+func ReadBody(resp *http.Response) ([]byte, error) {
+	body, err := io.ReadAll(io.LimitReader(resp.Body, MaxResponseBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(body) > MaxResponseBytes {
+		return nil, fmt.Errorf("%w: over %d bytes", ErrTooLarge, MaxResponseBytes)
+	}
+	return body, nil
+}
@@ -0,0 +1,73 @@
+package unifiroutes
+
+import (
+	"context"
+	"fmt"
+	"testing"
+)
+
+// pagedRoutes returns n routes with distinct IDs.
+func pagedRoutes(n int) []Route {
+	out := make([]Route, n)
+	for i := range out {
+		out[i] = Route{ID: fmt.Sprintf("r%d", i), StaticRouteNetwork: fmt.Sprintf("fd00:%x::/64", i)}
+	}
+	return out
+}
+
+func TestCollectPages(t *testing.T) {
+	t.Run("paginating controller", func(t *testing.T) {
+		table := pagedRoutes(2*pageSize + 17)
+		calls := 0
+		got, err := collectPages(context.Background(), func(_ context.Context, offset, limit int) ([]Route, error) {
+			calls++
+			end := offset + limit
+			if end > len(table) {
+				end = len(table)
+			}
+			return table[offset:end], nil
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(got) != len(table) {
+			t.Errorf("Expected %d routes, got %d", len(table), len(got))
+		}
+		if calls != 3 {
+			t.Errorf("Expected 3 page requests, got %d", calls)
+		}
+	})
+
+	t.Run("controller ignoring paging parameters", func(t *testing.T) {
+		table := pagedRoutes(pageSize + 5)
+		calls := 0
+		got, err := collectPages(context.Background(), func(_ context.Context, offset, limit int) ([]Route, error) {
+			calls++
+			return table, nil
+		})
+		if err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+		if len(got) != len(table) {
+			t.Errorf("Expected %d routes, got %d", len(table), len(got))
+		}
+		if calls != 2 {
+			t.Errorf("Expected 2 page requests, got %d", calls)
+		}
+	})
+
+	t.Run("endless listing", func(t *testing.T) {
+		next := 0
+		_, err := collectPages(context.Background(), func(_ context.Context, offset, limit int) ([]Route, error) {
+			page := make([]Route, limit)
+			for i := range page {
+				page[i] = Route{ID: fmt.Sprintf("r%d", next)}
+				next++
+			}
+			return page, nil
+		})
+		if err == nil {
+			t.Error("Expected error for a listing exceeding the page cap")
+		}
+	})
+}
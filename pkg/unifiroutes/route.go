@@ -0,0 +1,98 @@
+package unifiroutes
+
+import "net/netip"
+
+// RouteTypeStatic is the type of user-defined static routes in /rest/routing.
+const RouteTypeStatic = "static-route"
+
+// Route is a controller routing table entry in the legacy /rest/routing
+// schema, which routes of the v2 API are converted to and from.
+type Route struct {
+	ID                  string `json:"_id,omitempty"`
+	Enabled             bool   `json:"enabled"`
+	Name                string `json:"name"`
+	Type                string `json:"type"`
+	StaticRouteNexthop  string `json:"static-route_nexthop"`
+	StaticRouteNetwork  string `json:"static-route_network"`
+	StaticRouteType     string `json:"static-route_type"`
+	StaticRouteDistance int    `json:"static-route_distance"`
+	GatewayType         string `json:"gateway_type"`
+	GatewayDevice       string `json:"gateway_device"`
+	SiteID              string `json:"site_id,omitempty"`
+	Description         string `json:"description,omitempty"`
+
+	// StaticRouteInterface is the network ID an interface route goes out of.
+	StaticRouteInterface string `json:"static-route_interface,omitempty"`
+}
+
+// IsStatic reports whether the entry is a user-defined static route. The
+// routing table also lists entries of other kinds.
+func (r Route) IsStatic() bool {
+	return r.Type == RouteTypeStatic
+}
+
+// IsIPv6 reports whether the destination network is an IPv6 prefix.
+func (r Route) IsIPv6() bool {
+	prefix, err := netip.ParsePrefix(r.StaticRouteNetwork)
+	return err == nil && prefix.Addr().Is6() && !prefix.Addr().Is4In6()
+}
+
+// v2Route is the static-route schema of the v2 API.
+type v2Route struct {
+	ID            string `json:"_id,omitempty"`
+	Name          string `json:"name"`
+	Enabled       bool   `json:"enabled"`
+	Destination   string `json:"destination"`
+	NextHop       string `json:"next_hop"`
+	Distance      int    `json:"distance"`
+	RouteType     string `json:"route_type"`
+	Interface     string `json:"interface,omitempty"` // network ID of interface routes
+	IPVersion     string `json:"ip_version"`
+	GatewayDevice string `json:"gateway_device,omitempty"`
+	Description   string `json:"description,omitempty"`
+}
+
+// toV2 converts a Route to the v2 schema.
+func toV2(r Route) v2Route {
+	return v2Route{
+		ID:            r.ID,
+		Name:          r.Name,
+		Enabled:       r.Enabled,
+		Destination:   r.StaticRouteNetwork,
+		NextHop:       r.StaticRouteNexthop,
+		Distance:      r.StaticRouteDistance,
+		RouteType:     r.StaticRouteType,
+		Interface:     r.StaticRouteInterface,
+		IPVersion:     "v6",
+		GatewayDevice: r.GatewayDevice,
+		Description:   r.Description,
+	}
+}
+
+// fromV2 converts a v2 static route to a Route.
+func fromV2(r v2Route) Route {
+	route := Route{
+		ID:                  r.ID,
+		Enabled:             r.Enabled,
+		Name:                r.Name,
+		Type:                RouteTypeStatic,
+		StaticRouteNexthop:  r.NextHop,
+		StaticRouteNetwork:  r.Destination,
+		StaticRouteType:     r.RouteType,
+		StaticRouteDistance: r.Distance,
+		GatewayType:         "default",
+		GatewayDevice:       r.GatewayDevice,
+		Description:         r.Description,
+	}
+	route.StaticRouteInterface = r.Interface
+	return route
+}
+
+// fromV2List converts v2 static routes to Routes.
+func fromV2List(data []v2Route) []Route {
+	out := make([]Route, 0, len(data))
+	for _, r := range data {
+		out = append(out, fromV2(r))
+	}
+	return out
+}
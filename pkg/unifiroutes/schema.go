@@ -0,0 +1,161 @@
+package unifiroutes
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// routeSchema identifies the shape of a static route listing. Controller
+// firmware families answer the same request in different shapes, so listings
+// are decoded by the shape of the body rather than by the endpoint asked.
+type routeSchema string
+
+const (
+	// schemaLegacy is {"meta":{"rc":"ok"},"data":[...]} with legacy route fields.
+	schemaLegacy routeSchema = "legacy"
+	// schemaV2Array is a bare [...] of v2 routes, as Network 8 answers.
+	schemaV2Array routeSchema = "v2-array"
+	// schemaV2Envelope is {"data":[...]} of v2 routes, as some Network 9 releases answer.
+	schemaV2Envelope routeSchema = "v2-envelope"
+)
+
+// decodeRoutes decodes a static route listing of any known schema. It
+// tolerates the differences seen across firmwares: unknown fields, numbers and
+// booleans sent as strings, and "data": null or no data at all for an empty list.
+func decodeRoutes(body []byte) ([]Route, routeSchema, error) {
+	body = bytes.TrimSpace(body)
+	if len(body) > 0 && body[0] == '[' {
+		var data []v2Route
+		if err := json.Unmarshal(body, &data); err != nil {
+			return nil, schemaV2Array, fmt.Errorf("decoding %s route listing: %w", schemaV2Array, err)
+		}
+		return fromV2List(data), schemaV2Array, nil
+	}
+
+	var envelope struct {
+		Meta *struct {
+			RC  string `json:"rc"`
+			Msg string `json:"msg"`
+		} `json:"meta"`
+		Data []json.RawMessage `json:"data"`
+	}
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return nil, "", fmt.Errorf("decoding route listing: %w", err)
+	}
+	schema := schemaV2Envelope
+	if envelope.Meta != nil || (len(envelope.Data) > 0 && isLegacyRoute(envelope.Data[0])) {
+		schema = schemaLegacy
+	}
+	if envelope.Meta != nil && envelope.Meta.RC != "ok" {
+		return nil, schema, fmt.Errorf("API returned error: %s", envelope.Meta.RC)
+	}
+
+	routes := make([]Route, 0, len(envelope.Data))
+	for _, raw := range envelope.Data {
+		var route Route
+		if schema == schemaLegacy {
+			if err := json.Unmarshal(raw, &route); err != nil {
+				return nil, schema, fmt.Errorf("decoding %s route: %w", schema, err)
+			}
+		} else {
+			var v2 v2Route
+			if err := json.Unmarshal(raw, &v2); err != nil {
+				return nil, schema, fmt.Errorf("decoding %s route: %w", schema, err)
+			}
+			route = fromV2(v2)
+		}
+		routes = append(routes, route)
+	}
+	return routes, schema, nil
+}
+
+// isLegacyRoute reports whether a route object uses the legacy field names.
+func isLegacyRoute(raw json.RawMessage) bool {
+	var fields map[string]json.RawMessage
+	if json.Unmarshal(raw, &fields) != nil {
+		return false
+	}
+	_, ok := fields["static-route_network"]
+	return ok
+}
+
+// noteSchema records the schema of a route listing, logging when it changes,
+// which usually means the controller was upgraded.
+func (c *Client) noteSchema(schema routeSchema) {
+	c.mu.Lock()
+	changed := c.schema != schema
+	c.schema = schema
+	c.mu.Unlock()
+	if changed {
+		c.log.Infof("UniFi: controller lists routes in the %s schema", schema)
+	}
+}
+
+// FlexInt is an integer the controller may send as a number, a numeric
+// string, an empty string or null.
+type FlexInt int
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (n *FlexInt) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	if s == "" || s == "null" {
+		*n = 0
+		return nil
+	}
+	v, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return fmt.Errorf("invalid number %s", data)
+	}
+	*n = FlexInt(v)
+	return nil
+}
+
+// FlexBool is a boolean the controller may send as true/false, as a string
+// of either, or as 0/1.
+type FlexBool bool
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (b *FlexBool) UnmarshalJSON(data []byte) error {
+	switch strings.ToLower(strings.Trim(string(data), `"`)) {
+	case "true", "1":
+		*b = true
+	case "false", "0", "", "null":
+		*b = false
+	default:
+		return fmt.Errorf("invalid boolean %s", data)
+	}
+	return nil
+}
+
+// UnmarshalJSON decodes a legacy static route, tolerating loosely typed fields.
+func (r *Route) UnmarshalJSON(data []byte) error {
+	type plain Route
+	aux := struct {
+		*plain
+		Enabled  FlexBool `json:"enabled"`
+		Distance FlexInt  `json:"static-route_distance"`
+	}{plain: (*plain)(r)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	r.Enabled, r.StaticRouteDistance = bool(aux.Enabled), int(aux.Distance)
+	return nil
+}
+
+// UnmarshalJSON decodes a v2 static route, tolerating loosely typed fields.
+func (r *v2Route) UnmarshalJSON(data []byte) error {
+	type plain v2Route
+	aux := struct {
+		*plain
+		Enabled  FlexBool `json:"enabled"`
+		Distance FlexInt  `json:"distance"`
+	}{plain: (*plain)(r)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	r.Enabled, r.Distance = bool(aux.Enabled), int(aux.Distance)
+	return nil
+}
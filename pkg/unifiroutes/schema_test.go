@@ -0,0 +1,122 @@
+package unifiroutes
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite the golden files in testdata/schema")
+
+// TestDecodeRoutesGolden decodes the route listings captured per firmware family
+// in testdata/schema and compares them with the reviewed .golden decodings.
+// Run with -update after adding a listing to write its golden file.
+func TestDecodeRoutesGolden(t *testing.T) {
+	inputs, err := filepath.Glob(filepath.Join("testdata", "schema", "*.json"))
+	if err != nil || len(inputs) == 0 {
+		t.Fatalf("Expected route listings in testdata/schema, got %v (%v)", inputs, err)
+	}
+	for _, input := range inputs {
+		name := strings.TrimSuffix(filepath.Base(input), ".json")
+		t.Run(name, func(t *testing.T) {
+			body, err := os.ReadFile(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			routes, schema, err := decodeRoutes(body)
+			if err != nil {
+				t.Fatalf("Expected %s to decode, got %v", input, err)
+			}
+			got, err := json.MarshalIndent(struct {
+				Schema routeSchema `json:"schema"`
+				Routes []Route     `json:"routes"`
+			}{schema, routes}, "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			got = append(got, '\n')
+
+			golden := strings.TrimSuffix(input, ".json") + ".golden"
+			if *updateGolden {
+				if err := os.WriteFile(golden, got, 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("Missing golden file, run the test with -update: %v", err)
+			}
+			if string(got) != string(want) {
+				t.Errorf("Expected %s to decode to\n%s\ngot\n%s", input, want, got)
+			}
+		})
+	}
+}
+
+// TestDecodeRoutesSchemas verifies the schema detection and error handling of
+// route listings.
+func TestDecodeRoutesSchemas(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantSchema routeSchema
+		wantRoutes int
+		wantErr    bool
+	}{
+		{"bare array", `[{"destination":"fd00::/64","next_hop":"2001:db8::1"}]`, schemaV2Array, 1, false},
+		{"empty array", ` [] `, schemaV2Array, 0, false},
+		{"v2 envelope", `{"data":[{"destination":"fd00::/64"}]}`, schemaV2Envelope, 1, false},
+		{"legacy fields without meta", `{"data":[{"static-route_network":"fd00::/64"}]}`, schemaLegacy, 1, false},
+		{"legacy", `{"meta":{"rc":"ok"},"data":[{"static-route_network":"fd00::/64"}]}`, schemaLegacy, 1, false},
+		{"no data", `{"meta":{"rc":"ok"}}`, schemaLegacy, 0, false},
+		{"null data", `{"data":null}`, schemaV2Envelope, 0, false},
+		{"legacy error", `{"meta":{"rc":"error","msg":"api.err.NoPermission"},"data":[]}`, schemaLegacy, 0, true},
+		{"bad distance", `[{"distance":"near"}]`, schemaV2Array, 0, true},
+		{"not json", `<html>`, "", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			routes, schema, err := decodeRoutes([]byte(tt.body))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Expected error %v, got %v", tt.wantErr, err)
+			}
+			if schema != tt.wantSchema {
+				t.Errorf("Expected schema %q, got %q", tt.wantSchema, schema)
+			}
+			if len(routes) != tt.wantRoutes {
+				t.Errorf("Expected %d routes, got %d", tt.wantRoutes, len(routes))
+			}
+		})
+	}
+}
+
+// TestFlexFields verifies loosely typed numbers and booleans decode.
+func TestFlexFields(t *testing.T) {
+	tests := []struct {
+		json     string
+		enabled  bool
+		distance int
+		wantErr  bool
+	}{
+		{`{"enabled":true,"static-route_distance":1}`, true, 1, false},
+		{`{"enabled":"true","static-route_distance":"2"}`, true, 2, false},
+		{`{"enabled":1,"static-route_distance":""}`, true, 0, false},
+		{`{"enabled":"0","static-route_distance":null}`, false, 0, false},
+		{`{"enabled":false,"static-route_distance":3.0}`, false, 3, false},
+		{`{"enabled":"maybe"}`, false, 0, true},
+	}
+	for _, tt := range tests {
+		var route Route
+		err := json.Unmarshal([]byte(tt.json), &route)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: expected error %v, got %v", tt.json, tt.wantErr, err)
+			continue
+		}
+		if !tt.wantErr && (route.Enabled != tt.enabled || route.StaticRouteDistance != tt.distance) {
+			t.Errorf("%s: expected enabled=%v distance=%d, got %+v", tt.json, tt.enabled, tt.distance, route)
+		}
+	}
+}
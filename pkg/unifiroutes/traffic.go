@@ -0,0 +1,189 @@
+package unifiroutes
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// trafficAPI manages routes as IPv6 traffic routes through the
+// /v2/api/site/<site>/trafficroutes endpoints, which some gateways offer in
+// place of static routes. Traffic routes have no name, distance or gateway
+// device: the route name is kept in their description, and the others are
+// dropped.
+type trafficAPI struct {
+	c *Client
+}
+
+// trafficRoute is the traffic route schema.
+type trafficRoute struct {
+	ID             string          `json:"_id,omitempty"`
+	Description    string          `json:"description"`
+	Enabled        bool            `json:"enabled"`
+	MatchingTarget string          `json:"matching_target"` // "IP" for routes by destination address
+	IPAddresses    []trafficTarget `json:"ip_addresses"`
+	NetworkID      string          `json:"network_id,omitempty"` // interface the route goes out of
+	NextHop        string          `json:"next_hop"`
+	TargetDevices  []trafficDevice `json:"target_devices"`
+	KillSwitch     bool            `json:"kill_switch_enabled"`
+	Domains        []any           `json:"domains"`
+	IPRanges       []any           `json:"ip_ranges"`
+	Regions        []any           `json:"regions"`
+}
+
+// trafficTarget is a destination address or subnet of a traffic route.
+type trafficTarget struct {
+	IPOrSubnet string `json:"ip_or_subnet"`
+	IPVersion  string `json:"ip_version"`
+	PortRanges []any  `json:"port_ranges"`
+	Ports      []any  `json:"ports"`
+}
+
+// trafficDevice is a source a traffic route applies to.
+type trafficDevice struct {
+	Type string `json:"type"`
+}
+
+// toTraffic converts a Route to a traffic route applying to all clients.
+func toTraffic(r Route) trafficRoute {
+	return trafficRoute{
+		ID:             r.ID,
+		Description:    r.Name,
+		Enabled:        r.Enabled,
+		MatchingTarget: "IP",
+		IPAddresses:    []trafficTarget{{IPOrSubnet: r.StaticRouteNetwork, IPVersion: "v6", PortRanges: []any{}, Ports: []any{}}},
+		NetworkID:      r.StaticRouteInterface,
+		NextHop:        r.StaticRouteNexthop,
+		TargetDevices:  []trafficDevice{{Type: "ALL_CLIENTS"}},
+		Domains:        []any{},
+		IPRanges:       []any{},
+		Regions:        []any{},
+	}
+}
+
+// fromTraffic converts a traffic route to a Route. Its network is the first
+// IPv6 destination, or empty for routes matching domains or regions.
+func fromTraffic(t trafficRoute) Route {
+	route := Route{
+		ID:                   t.ID,
+		Enabled:              t.Enabled,
+		Name:                 t.Description,
+		Type:                 RouteTypeStatic,
+		StaticRouteNexthop:   t.NextHop,
+		StaticRouteType:      "nexthop-route",
+		StaticRouteInterface: t.NetworkID,
+		GatewayType:          "default",
+	}
+	if t.NetworkID != "" {
+		route.StaticRouteType = "interface-route"
+	}
+	if t.MatchingTarget == "IP" {
+		for _, target := range t.IPAddresses {
+			if target.IPVersion == "v6" {
+				route.StaticRouteNetwork = target.IPOrSubnet
+				break
+			}
+		}
+	}
+	return route
+}
+
+// url returns the trafficroutes endpoint followed by suffix.
+func (a trafficAPI) url(suffix string) string {
+	return fmt.Sprintf("%s/proxy/network/v2/api/site/%s/trafficroutes%s", a.c.cfg.BaseURL, a.c.cfg.Site, suffix)
+}
+
+// list retrieves all traffic routes. The endpoint is not paged.
+func (a trafficAPI) list(ctx context.Context) ([]Route, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", a.url(""), nil)
+	if err != nil {
+		return nil, err
+	}
+	a.c.Authorize(req)
+
+	resp, err := a.c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer a.c.closeBody(resp)
+
+	body, err := ReadBody(resp)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, &APIError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+
+	var data []trafficRoute
+	if err := json.Unmarshal(body, &data); err != nil {
+		return nil, fmt.Errorf("decoding traffic route listing: %w", err)
+	}
+	routes := make([]Route, 0, len(data))
+	for _, t := range data {
+		routes = append(routes, fromTraffic(t))
+	}
+	return routes, nil
+}
+
+// add creates a traffic route.
+func (a trafficAPI) add(ctx context.Context, route Route) error {
+	return a.send(ctx, "POST", "", route)
+}
+
+// update replaces the traffic route with route.ID by route.
+func (a trafficAPI) update(ctx context.Context, route Route) error {
+	return a.send(ctx, "PUT", "/"+route.ID, route)
+}
+
+// send writes route to the endpoint with the given method and suffix.
+func (a trafficAPI) send(ctx context.Context, method, suffix string, route Route) error {
+	jsonData, err := json.Marshal(toTraffic(route))
+	if err != nil {
+		return err
+	}
+	a.c.log.Debugf("UniFi: %s traffic route payload: %s", method, string(jsonData))
+
+	req, err := http.NewRequestWithContext(ctx, method, a.url(suffix), bytes.NewBuffer(jsonData))
+	if err != nil {
+		return err
+	}
+	a.c.Authorize(req)
+
+	resp, err := a.c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer a.c.closeBody(resp)
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		body, _ := io.ReadAll(resp.Body)
+		a.c.log.Debugf("UniFi: %s traffic route response: status=%d body=%s", method, resp.StatusCode, string(body))
+		return &APIError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+	return nil
+}
+
+// remove deletes the traffic route with the given id.
+func (a trafficAPI) remove(ctx context.Context, routeID string) error {
+	req, err := http.NewRequestWithContext(ctx, "DELETE", a.url("/"+routeID), nil)
+	if err != nil {
+		return err
+	}
+	a.c.Authorize(req)
+
+	resp, err := a.c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer a.c.closeBody(resp)
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
+		body, _ := io.ReadAll(resp.Body)
+		return &APIError{StatusCode: resp.StatusCode, Body: string(body)}
+	}
+	return nil
+}